@@ -0,0 +1,580 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hmziqrs/maziq/internal/audit"
+	"github.com/hmziqrs/maziq/internal/cast"
+	"github.com/hmziqrs/maziq/internal/engine"
+	"github.com/hmziqrs/maziq/internal/fetch"
+	"github.com/hmziqrs/maziq/internal/health"
+	"github.com/hmziqrs/maziq/internal/metrics"
+	"github.com/hmziqrs/maziq/internal/policy"
+	"github.com/hmziqrs/maziq/internal/resource"
+	"github.com/hmziqrs/maziq/internal/selfupdate"
+	"github.com/hmziqrs/maziq/internal/shell"
+	"github.com/hmziqrs/maziq/internal/sysprefs"
+	"github.com/hmziqrs/maziq/internal/templates"
+	"github.com/hmziqrs/maziq/internal/textdiff"
+)
+
+func init() {
+	commands = append(commands,
+		command{
+			name:    "plan",
+			summary: "Show what apply would change",
+			run:     runPlan,
+		},
+		command{
+			name:    "apply",
+			summary: "Converge the machine to the template",
+			run:     runApply,
+		},
+	)
+}
+
+func runPlan(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("plan", flag.ContinueOnError)
+	ref := fs.String("template", "", "template name or path (default from config)")
+	asJSON := fs.Bool("json", false, "print the plan as JSON")
+	snapshot := fs.String("snapshot", "", "write the plan, without the machine's state, to this golden `file`")
+	check := fs.String("check", "", "compare the plan with the golden `file` written by --snapshot and fail if it changed")
+	scope := scopeFlags(fs)
+	groups := groupFlags(fs)
+	subsystems := subsystemFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	sc, err := scope()
+	if err != nil {
+		return err
+	}
+	t, _, err := loadTemplate(ctx, *ref)
+	if err != nil {
+		return err
+	}
+	if err := groups(t); err != nil {
+		return err
+	}
+	switch {
+	case *snapshot != "":
+		data, err := engine.Snapshot(ctx, newEnv(t))
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(*snapshot, data, 0o644); err != nil {
+			return err
+		}
+		fmt.Printf("✓ Wrote %s\n", *snapshot)
+		return nil
+	case *check != "":
+		return checkSnapshot(ctx, newEnv(t), *check)
+	}
+	env := newEnv(t)
+	subsystems(env)
+	plan, err := engine.Build(ctx, env)
+	if err != nil {
+		return err
+	}
+	if sc != "" {
+		plan.Restrict(sc)
+	}
+	if *asJSON {
+		return printPlanJSON(plan)
+	}
+	printPlan(plan)
+	return nil
+}
+
+func runApply(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("apply", flag.ContinueOnError)
+	ref := fs.String("template", "", "template name or path (default from config)")
+	dryRun := fs.Bool("dry-run", false, "show what would be applied without changing anything")
+	yes := fs.Bool("yes", false, "do not ask for confirmation")
+	step := fs.Bool("interactive", false, "ask before each change whether to apply it")
+	asJSON := fs.Bool("json", false, "print the report as JSON")
+	full := fs.Bool("full", false, "check every resource, even those verified by a recent apply")
+	override := fs.Bool("override-policy", false, "apply changes the policy blocks")
+	record := fs.String("record", "", "record the session, with every command and its output, to this asciicast `file`")
+	scope := scopeFlags(fs)
+	groups := groupFlags(fs)
+	subsystems := subsystemFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	sc, err := scope()
+	if err != nil {
+		return err
+	}
+	if *step && (*yes || *asJSON || !interactive()) {
+		return errors.New("--interactive asks at the terminal; it cannot be combined with --yes or --json")
+	}
+	var rec *cast.Recorder
+	if *record != "" {
+		var err error
+		if rec, err = cast.Create(*record, "maziq apply "+strings.Join(args, " ")); err != nil {
+			return err
+		}
+		restore := rec.Tee()
+		defer func() {
+			restore()
+			if err := rec.Close(); err != nil {
+				fmt.Fprintf(os.Stderr, "warning: recording the session: %v\n", err)
+				return
+			}
+			fmt.Fprintf(os.Stderr, "Session recorded to %s; replay it with asciinema play %s\n", *record, *record)
+		}()
+	}
+	t, cfg, err := loadTemplate(ctx, *ref)
+	if err != nil {
+		return err
+	}
+	if err := groups(t); err != nil {
+		return err
+	}
+	env := newEnv(t)
+	subsystems(env)
+	// Behind a proxy, a wrong address would otherwise show up as every
+	// download failing in turn.
+	if !t.Proxy.IsZero() {
+		cctx, cancel := context.WithTimeout(fetch.WithProxy(ctx, t.Proxy.Environ()), 10*time.Second)
+		if level, detail := health.Network(cctx); level == health.Fail {
+			fmt.Fprintf(os.Stderr, "warning: network: %s\n", detail)
+		}
+		cancel()
+	}
+	if l, ok := env.Runner.(shell.Local); ok && rec != nil {
+		env.Runner = rec.Runner(l)
+	}
+	if !*asJSON && interactive() {
+		env.Wait = waitEnter
+	}
+	build := engine.Build
+	if !*full && cfg.Apply.VerifyEvery > 0 {
+		build = func(ctx context.Context, env *resource.Env) (*engine.Plan, error) {
+			return engine.BuildIncremental(ctx, env, cfg.Apply.VerifyEvery)
+		}
+	}
+	plan, err := build(ctx, env)
+	if err != nil {
+		return err
+	}
+	if sc != "" {
+		plan.Restrict(sc)
+	}
+	if *override && len(plan.Violations) > 0 {
+		plan.Override()
+		fmt.Fprintf(os.Stderr, "warning: overriding %d policy violation(s)\n", len(plan.Violations))
+	}
+	pending := plan.Pending()
+	if len(pending) == 0 {
+		if !*dryRun {
+			if err := engine.SaveVerified(plan, engine.Report{}); err != nil {
+				fmt.Fprintf(os.Stderr, "warning: recording the run: %v\n", err)
+			}
+		}
+		fmt.Println("✓ Nothing to do; everything is converged.")
+		if n := plan.Cached(); n > 0 {
+			fmt.Printf("  %d resource(s) were verified by an earlier apply; --full checks them again.\n", n)
+		}
+		return nil
+	}
+	if !*asJSON {
+		printPlan(plan)
+	}
+	if !*dryRun && !*yes && !*step && !confirm(ctx, fmt.Sprintf("Apply %d change(s)?", len(pending))) {
+		return exitCode(1)
+	}
+
+	opts := engine.Options{DryRun: *dryRun, OnTimeout: cfg.Apply.OnTimeout, QuarantineAfter: cfg.Apply.QuarantineAfter}
+	if *step {
+		opts.Confirm = confirmStep(env)
+	}
+	report := renderEvents(engine.Start(ctx, plan, env, opts))
+	// Interrupted runs are audited too: what they changed stays changed.
+	if !*dryRun {
+		if err := audit.Record("cli", plan, report); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: writing the audit log: %v\n", err)
+		}
+	}
+	if ctx.Err() != nil {
+		printReport(report)
+		fmt.Fprintf(os.Stderr, "\nStopped after %d of %d change(s). Changes already made are kept; run maziq apply again to continue.\n",
+			report.Count(engine.OutcomeApplied)+report.Count(engine.OutcomeFailed), len(pending))
+		return ctx.Err()
+	}
+	if !*dryRun {
+		if err := metrics.Record(metrics.FromReport(plan, report, selfupdate.Version, env.Facts["macos"])); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: recording metrics: %v\n", err)
+		}
+		if err := engine.SaveLast(*ref, report); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: recording the run: %v\n", err)
+		}
+		if err := engine.SaveVerified(plan, report); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: recording the run: %v\n", err)
+		}
+	}
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			return err
+		}
+	} else {
+		printReport(report)
+	}
+	if err := followUp(ctx, env, report.Restart(), !*asJSON && !*yes && interactive(), *asJSON); err != nil {
+		return err
+	}
+	if report.Count(engine.OutcomeFailed) > 0 {
+		return exitCode(1)
+	}
+	return nil
+}
+
+// checkSnapshot compares the plan with a golden file, under the facts the
+// file was written with, and prints what changed.
+func checkSnapshot(ctx context.Context, env *resource.Env, path string) error {
+	want, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	for k, v := range engine.SnapshotFacts(want) {
+		env.Facts[k] = v
+	}
+	got, err := engine.Snapshot(ctx, env)
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(got, want) {
+		fmt.Printf("✓ The plan matches %s.\n", path)
+		return nil
+	}
+	fmt.Printf("The plan differs from %s:\n\n", path)
+	for _, l := range lineDiff(strings.Split(string(want), "\n"), strings.Split(string(got), "\n")) {
+		fmt.Println(l)
+	}
+	fmt.Printf("\nRun maziq plan --snapshot %s to accept the change.\n", path)
+	return exitCode(1)
+}
+
+// lineDiff returns the lines removed from a (prefixed "-") and added in b
+// (prefixed "+"), in order, leaving out the lines they share.
+func lineDiff(a, b []string) []string {
+	var out []string
+	for _, l := range textdiff.Lines(a, b) {
+		switch l.Op {
+		case textdiff.Delete:
+			out = append(out, "- "+l.Text)
+		case textdiff.Insert:
+			out = append(out, "+ "+l.Text)
+		}
+	}
+	return out
+}
+
+// renderEvents shows the progress of a run on stderr as it happens and
+// returns its report.
+func renderEvents(events <-chan engine.Event) engine.Report {
+	var report engine.Report
+	for e := range events {
+		switch e := e.(type) {
+		case engine.TaskStarted:
+			fmt.Fprintf(os.Stderr, "→ [%d/%d] applying %s: %s\n", e.Index, e.Total, e.ID, e.Description)
+		case engine.TaskLog:
+			fmt.Fprintf(os.Stderr, "→ %s\n", e.Line)
+		case engine.RunFinished:
+			report = e.Report
+		}
+	}
+	return report
+}
+
+// scopeFlags adds --system and --user to fs. The function it returns gives
+// the scope they select once fs is parsed, or "" for both.
+func scopeFlags(fs *flag.FlagSet) func() (string, error) {
+	system := fs.Bool("system", false, "only resources shared by every user: apps, Homebrew, system settings")
+	user := fs.Bool("user", false, "only the current user's resources: dotfiles, preferences, fonts")
+	return func() (string, error) {
+		switch {
+		case *system && *user:
+			return "", errors.New("--system and --user cannot be combined; leave both off for everything")
+		case *system:
+			return resource.ScopeSystem, nil
+		case *user:
+			return resource.ScopeUser, nil
+		}
+		return "", nil
+	}
+}
+
+// groupFlags adds --profile, --with and --without to fs. The function it
+// returns sets the profile and group toggles they select on a template once
+// fs is parsed.
+func groupFlags(fs *flag.FlagSet) func(t *templates.Template) error {
+	profile := fs.String("profile", "", "turn on the template's groups for this `profile` (default from config)")
+	with := fs.String("with", "", "turn on these comma-separated `groups`")
+	without := fs.String("without", "", "turn off these comma-separated `groups`")
+	return func(t *templates.Template) error {
+		if *profile != "" {
+			t.Profile = *profile
+		}
+		for _, set := range []struct {
+			names string
+			on    bool
+		}{{*with, true}, {*without, false}} {
+			for _, name := range splitList(set.names) {
+				if !slices.ContainsFunc(t.Groups, func(g templates.Group) bool { return g.Name == name }) {
+					return fmt.Errorf("%s has no group %q", t.Name, name)
+				}
+				if t.Toggles == nil {
+					t.Toggles = map[string]bool{}
+				}
+				t.Toggles[name] = set.on
+			}
+		}
+		return nil
+	}
+}
+
+// subsystemFlags adds --only and --skip to fs. The function it returns
+// limits an environment's plans to the subsystems they select once fs is
+// parsed.
+func subsystemFlags(fs *flag.FlagSet) func(env *resource.Env) {
+	only := fs.String("only", "", "only these comma-separated `subsystems`, such as brew,dotfiles, and what they require")
+	skip := fs.String("skip", "", "leave out these comma-separated `subsystems`, such as defaults")
+	return func(env *resource.Env) {
+		env.Only, env.Skip = splitList(*only), splitList(*skip)
+	}
+}
+
+// splitList splits a comma-separated flag value, dropping empty items.
+func splitList(s string) []string {
+	var out []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+func printPlan(plan *engine.Plan) {
+	what := "resources"
+	if plan.Scope != "" {
+		what = plan.Scope + "-scoped resources"
+	}
+	fmt.Printf("Plan for %s: %d %s, %d pending\n\n", plan.Template, len(plan.Items), what, len(plan.Pending()))
+	if len(plan.GroupsOff) > 0 {
+		fmt.Printf("  Groups off: %s (--with turns them on)\n\n", strings.Join(plan.GroupsOff, ", "))
+	}
+	for _, it := range plan.Items {
+		switch {
+		case it.Err != nil:
+			fmt.Printf("  ! %-32s check failed: %v\n", it.ID(), it.Err)
+		case it.State.Blocked != "":
+			fmt.Printf("  ⚠ %-32s %s\n", it.ID(), it.State.Blocked)
+			printSettingsHint(it.Resource)
+		case it.Pending():
+			fmt.Printf("  + %-32s %s (%s → %s)%s\n", it.ID(), it.Resource.Describe(), it.State.Current, it.State.Desired, planTags(plan, it))
+		default:
+			fmt.Printf("  ✓ %-32s %s%s\n", it.ID(), it.State.Current, planTags(plan, it))
+		}
+		if it.State.Warning != "" {
+			fmt.Printf("    %-32s ⚠ %s\n", "", it.State.Warning)
+		}
+	}
+	fmt.Println()
+	if n := len(plan.Violations); n > 0 {
+		fmt.Printf("%d policy violation(s) in %s:\n", n, policy.File())
+		for _, v := range plan.Violations {
+			fmt.Printf("  ✗ %s\n", v)
+		}
+		fmt.Println("\nApply skips the changes the policy blocks; apply --override-policy makes them anyway.")
+		fmt.Println()
+	}
+}
+
+// planTags marks the items that need root, unless the whole plan is
+// system-scoped, and those the machine's maziq.local.toml added or changed.
+func planTags(plan *engine.Plan, it engine.Item) string {
+	var tags string
+	if it.Pending() && plan.Scope == "" && resource.ScopeOf(it.Resource) == resource.ScopeSystem {
+		tags += " [system]"
+	}
+	if it.Local {
+		tags += " [local]"
+	}
+	return tags
+}
+
+// printSettingsHint points at the System Settings pane for resources that
+// can only be fixed there.
+func printSettingsHint(r any) {
+	l, ok := r.(sysprefs.Linker)
+	if !ok {
+		return
+	}
+	if p, ok := sysprefs.Lookup(l.SettingsPane()); ok {
+		fmt.Printf("    %-32s fix in System Settings → %s (maziq settings %s)\n", "", p.Title, p.Name)
+	}
+}
+
+func printPlanJSON(plan *engine.Plan) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(plan.Summary())
+}
+
+func printReport(r engine.Report) {
+	fmt.Println()
+	for _, o := range r.Outcomes {
+		switch o.Status {
+		case engine.OutcomeApplied:
+			fmt.Printf("  ✓ %-32s applied in %s\n", o.ID, o.Duration.Round(time.Millisecond))
+		case engine.OutcomeFailed:
+			fmt.Printf("  ✗ %-32s %s\n", o.ID, o.Error)
+			if o.Quarantined {
+				fmt.Printf("    %-32s quarantined after repeated failures; diagnostics in %s\n", "", o.Bundle)
+			}
+			if o.Fix != nil {
+				fmt.Printf("    %-32s known problem: %s (maziq fix %s)\n", "", o.Fix.Title(), o.ID)
+			}
+		case engine.OutcomeSkipped:
+			if o.Error != "" {
+				fmt.Printf("  - %-32s skipped: %s\n", o.ID, o.Error)
+			}
+		}
+	}
+	fmt.Printf("\n%d applied, %d failed, %d skipped, %d unchanged in %s\n",
+		r.Count(engine.OutcomeApplied), r.Count(engine.OutcomeFailed), r.Count(engine.OutcomeSkipped),
+		r.Count(engine.OutcomeOK), r.Finished.Sub(r.Started).Round(time.Millisecond))
+	if slow := r.Slowest(3); len(slow) > 1 {
+		parts := make([]string, len(slow))
+		for i, o := range slow {
+			parts[i] = fmt.Sprintf("%s (%s)", o.ID, o.Duration.Round(100*time.Millisecond))
+		}
+		fmt.Printf("Slowest: %s\n", strings.Join(parts, ", "))
+	}
+}
+
+// confirmStep asks before each change of an interactive apply, showing what
+// it does and, on request, the diff of the file it writes. An interrupted
+// question aborts.
+func confirmStep(env *resource.Env) func(ctx context.Context, it engine.Item, index, total int) engine.Step {
+	return func(ctx context.Context, it engine.Item, index, total int) engine.Step {
+		fmt.Printf("\n[%d/%d] %s\n  %s (%s → %s)\n", index, total, it.ID(), it.Resource.Describe(), it.State.Current, it.State.Desired)
+		_, canDiff := it.Resource.(resource.Differ)
+		options := "[y]es, [n]o, [a]ll, [q]uit"
+		if canDiff {
+			options = "[y]es, [n]o, [d]iff, [a]ll, [q]uit"
+		}
+		for {
+			fmt.Printf("  Apply? %s ", options)
+			var line string
+			select {
+			case l, ok := <-stdinLines():
+				if !ok {
+					fmt.Println()
+					return engine.StepAbort
+				}
+				line = l
+			case <-ctx.Done():
+				fmt.Println()
+				return engine.StepAbort
+			}
+			switch strings.ToLower(strings.TrimSpace(line)) {
+			case "y", "yes":
+				return engine.StepApply
+			case "n", "no", "s", "skip":
+				return engine.StepSkip
+			case "a", "all":
+				return engine.StepApplyAll
+			case "q", "quit", "abort":
+				return engine.StepAbort
+			case "d", "diff":
+				if canDiff {
+					printChange(ctx, env, it.Resource.(resource.Differ))
+				}
+			}
+		}
+	}
+}
+
+// printChange prints the diff of the file d writes.
+func printChange(ctx context.Context, env *resource.Env, d resource.Differ) {
+	c, err := d.Diff(ctx, env)
+	if err != nil {
+		fmt.Printf("  cannot diff: %v\n", err)
+		return
+	}
+	fmt.Printf("  %s\n", c.Path)
+	for _, l := range lineDiff(strings.Split(string(c.Current), "\n"), strings.Split(string(c.Desired), "\n")) {
+		fmt.Println("    " + l)
+	}
+}
+
+// confirm asks a yes/no question on the terminal, defaulting to no. An
+// interrupted question is answered no.
+func confirm(ctx context.Context, question string) bool {
+	fmt.Printf("%s [y/N] ", question)
+	select {
+	case line := <-stdinLines():
+		answer := strings.ToLower(strings.TrimSpace(line))
+		return answer == "y" || answer == "yes"
+	case <-ctx.Done():
+		fmt.Println()
+		return false
+	}
+}
+
+// interactive reports whether stdin is a terminal.
+func interactive() bool {
+	info, err := os.Stdin.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// waitEnter implements resource.Env.Wait on the terminal.
+func waitEnter(ctx context.Context, prompt string) error {
+	fmt.Printf("  %s ", prompt)
+	select {
+	case _, ok := <-stdinLines():
+		if !ok {
+			return io.EOF
+		}
+		return nil
+	case <-ctx.Done():
+		fmt.Println()
+		return ctx.Err()
+	}
+}
+
+// stdinLines reads stdin from a single goroutine so a wait that ends early
+// does not leave a reader behind to swallow the next answer.
+var stdinLines = sync.OnceValue(func() <-chan string {
+	ch := make(chan string)
+	go func() {
+		r := bufio.NewReader(os.Stdin)
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				close(ch)
+				return
+			}
+			ch <- line
+		}
+	}()
+	return ch
+})
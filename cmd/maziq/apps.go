@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/hmziqrs/maziq/internal/modules/direct"
+)
+
+func init() {
+	commands = append(commands, command{
+		name:        "apps",
+		summary:     "List or uninstall apps installed from dmg, pkg or zip downloads",
+		subcommands: []string{"uninstall"},
+		run:         runApps,
+	})
+}
+
+func runApps(ctx context.Context, args []string) error {
+	if len(args) > 0 && args[0] == "uninstall" {
+		return runAppsUninstall(ctx, args[1:])
+	}
+	fs := flag.NewFlagSet("apps", flag.ContinueOnError)
+	ref := fs.String("template", "", "template name or path (default from config)")
+	asJSON := fs.Bool("json", false, "print the apps as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	t, _, err := loadTemplate(ctx, *ref)
+	if err != nil {
+		return err
+	}
+	env := newEnv(t)
+	apps, err := direct.Apps(env)
+	if err != nil {
+		return err
+	}
+	type row struct {
+		Name      string `json:"name"`
+		Installed string `json:"installed,omitempty"`
+		Latest    string `json:"latest,omitempty"`
+		Managed   bool   `json:"managed"`
+		Error     string `json:"error,omitempty"`
+	}
+	managed, err := direct.Load()
+	if err != nil {
+		return err
+	}
+	rows := []row{}
+	for _, a := range apps {
+		r := row{Name: a.Name()}
+		_, r.Managed = managed[a.Name()]
+		version, ok, err := a.Installed(ctx, env)
+		switch {
+		case err != nil:
+			r.Error = err.Error()
+		case ok:
+			r.Installed = version
+			if version == "" {
+				r.Installed = "installed"
+			}
+		}
+		if latest, err := a.Latest(ctx, env); err != nil {
+			r.Error = err.Error()
+		} else {
+			r.Latest = latest
+		}
+		rows = append(rows, r)
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(rows)
+	}
+	if len(rows) == 0 {
+		fmt.Printf("Template %q installs no apps directly.\n", t.Name)
+		return nil
+	}
+	for _, r := range rows {
+		installed, note := r.Installed, ""
+		if installed == "" {
+			installed = "-"
+		}
+		switch {
+		case r.Error != "":
+			note = "! " + r.Error
+		case r.Installed == "":
+			note = "not installed"
+		case r.Latest != "" && r.Latest != r.Installed:
+			note = "update to " + r.Latest
+		case !r.Managed:
+			note = "installed outside MazIQ"
+		}
+		fmt.Printf("%-28s %-14s %s\n", r.Name, installed, note)
+	}
+	return nil
+}
+
+func runAppsUninstall(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("apps uninstall", flag.ContinueOnError)
+	yes := fs.Bool("yes", false, "do not ask for confirmation")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("usage: maziq apps uninstall [--yes] <name>")
+	}
+	name := fs.Arg(0)
+	rec, ok, err := direct.Lookup(name)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		names, _ := direct.Names()
+		return fmt.Errorf("%q was not installed by MazIQ; installed: %v", name, names)
+	}
+	what := rec.App
+	if what == "" {
+		what = fmt.Sprintf("receipts %v", rec.Pkgs)
+	}
+	if !*yes && !confirm(ctx, fmt.Sprintf("Remove %s?", what)) {
+		return exitCode(1)
+	}
+	return direct.Uninstall(ctx, newEnv(nil), name)
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/hmziqrs/maziq/internal/audit"
+	"github.com/hmziqrs/maziq/internal/engine"
+	"github.com/hmziqrs/maziq/internal/modules/software"
+	"github.com/hmziqrs/maziq/internal/osv"
+	"github.com/hmziqrs/maziq/internal/resource"
+)
+
+func init() {
+	commands = append(commands, command{
+		name:        "audit",
+		summary:     "Show or verify the log of applies, or check installed software for vulnerabilities",
+		subcommands: []string{"show", "verify", "cve"},
+		run:         runAudit,
+	})
+}
+
+func runAudit(ctx context.Context, args []string) error {
+	sub := "show"
+	if len(args) > 0 {
+		sub, args = args[0], args[1:]
+	}
+	switch sub {
+	case "show":
+		return showAudit(args)
+	case "verify":
+		return verifyAudit()
+	case "cve":
+		return auditCVE(ctx, args)
+	}
+	return errors.New("usage: maziq audit [show [--json] | verify | cve [--template name] [--fail-on severity] [--json]]")
+}
+
+func showAudit(args []string) error {
+	fs := flag.NewFlagSet("audit show", flag.ContinueOnError)
+	asJSON := fs.Bool("json", false, "print the entries as JSON lines")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	entries, err := audit.Read()
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		for _, e := range entries {
+			if err := enc.Encode(e); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if len(entries) == 0 {
+		fmt.Println("No applies recorded.")
+		return nil
+	}
+	for _, e := range entries {
+		fmt.Printf("#%d %s %s@%s via %s: %s", e.Seq, e.Time.Local().Format("2006-01-02 15:04"), e.User, e.Host, e.Via, e.Template)
+		if e.Commit != "" {
+			fmt.Printf(" at %s", shortCommit(e.Commit))
+		}
+		fmt.Println()
+		for _, c := range e.Changes {
+			line := fmt.Sprintf("    %-8s %s", c.Status, c.ID)
+			if c.Error != "" {
+				line += ": " + c.Error
+			}
+			fmt.Println(line)
+		}
+		for _, v := range e.Overrides {
+			fmt.Printf("    override %s\n", v)
+		}
+	}
+	return nil
+}
+
+// shortCommit abbreviates a commit hash, keeping any +dirty suffix.
+func shortCommit(c string) string {
+	hash, dirty, _ := strings.Cut(c, "+")
+	if len(hash) > 12 {
+		hash = hash[:12]
+	}
+	if dirty != "" {
+		hash += "+" + dirty
+	}
+	return hash
+}
+
+func verifyAudit() error {
+	n, problems, err := audit.Verify()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s: %d entries\n", audit.Path(), n)
+	for _, p := range problems {
+		fmt.Printf("  ✗ %s\n", p)
+	}
+	if len(problems) > 0 {
+		return exitCode(1)
+	}
+	fmt.Println("✓ The hash chain is intact.")
+	return nil
+}
+
+// auditCVE looks up the installed software in OSV. It fails when a finding
+// is at least as severe as --fail-on, so CI can gate on it.
+func auditCVE(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("audit cve", flag.ContinueOnError)
+	ref := fs.String("template", "", "template name or path (default from config)")
+	failOn := fs.String("fail-on", "", "exit 1 on a vulnerability of this `severity` or worse: low, medium, high or critical")
+	asJSON := fs.Bool("json", false, "print the findings as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	threshold := osv.SeverityUnknown
+	if *failOn != "" {
+		var err error
+		if threshold, err = osv.ParseSeverity(*failOn); err != nil {
+			return err
+		}
+	}
+	t, _, err := loadTemplate(ctx, *ref)
+	if err != nil {
+		return err
+	}
+	components, err := engine.Components(ctx, newEnv(t), warnComponent)
+	if err != nil {
+		return err
+	}
+	findings, err := osv.Check(ctx, components)
+	if err != nil {
+		return err
+	}
+	failed := false
+	for _, f := range findings {
+		if threshold != osv.SeverityUnknown && f.Worst() >= threshold {
+			failed = true
+		}
+	}
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if findings == nil {
+			findings = []osv.Finding{}
+		}
+		if err := enc.Encode(findings); err != nil {
+			return err
+		}
+	} else {
+		printFindings(components, findings)
+	}
+	if failed {
+		return exitCode(1)
+	}
+	return nil
+}
+
+func printFindings(components []resource.Component, findings []osv.Finding) {
+	var covered int
+	for _, c := range components {
+		if osv.Covered(c) {
+			covered++
+		}
+	}
+	fmt.Printf("Checked %d of %d installed packages against OSV; Homebrew packages and apps have no advisory feed.\n", covered, len(components))
+	if len(findings) == 0 {
+		fmt.Println("✓ No known vulnerabilities.")
+		return
+	}
+	for _, f := range findings {
+		fmt.Printf("\n%s %s (%s)\n", f.Component.Name, f.Component.Version, f.Component.ID)
+		for _, v := range f.Vulns {
+			line := fmt.Sprintf("  %-8s %s", v.Severity, v.ID)
+			if len(v.Aliases) > 0 {
+				line += " (" + strings.Join(v.Aliases, ", ") + ")"
+			}
+			if v.Summary != "" {
+				line += ": " + v.Summary
+			}
+			fmt.Println(line)
+			if v.Fixed != "" {
+				fmt.Printf("           fixed in %s\n", v.Fixed)
+			}
+		}
+		if cmd, err := software.UpgradeCommand(f.Component.ID); err == nil {
+			fmt.Printf("  upgrade: %s\n", cmd)
+		}
+	}
+}
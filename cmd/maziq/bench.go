@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"runtime/pprof"
+	"sort"
+	"time"
+
+	"github.com/hmziqrs/maziq/internal/engine"
+)
+
+func init() {
+	commands = append(commands, command{
+		name:    "bench",
+		summary: "Time planning and the last apply, per resource",
+		run:     runBench,
+	})
+}
+
+// benchRow is one resource's timings.
+type benchRow struct {
+	ID      string        `json:"id"`
+	Check   time.Duration `json:"check"`
+	Apply   time.Duration `json:"last_apply,omitempty"`
+	Pending bool          `json:"pending"`
+}
+
+func runBench(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("bench", flag.ContinueOnError)
+	ref := fs.String("template", "", "template name or path (default: the one last applied)")
+	top := fs.Int("top", 15, "how many resources to list")
+	cpuProfile := fs.String("cpuprofile", "", "write a CPU profile of the run to this file")
+	asJSON := fs.Bool("json", false, "print every resource's timings as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	last, haveLast, err := engine.Last()
+	if err != nil {
+		return err
+	}
+	if *ref == "" && haveLast {
+		*ref = last.Ref
+	}
+	if *cpuProfile != "" {
+		f, err := os.Create(*cpuProfile)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		if err := pprof.StartCPUProfile(f); err != nil {
+			return err
+		}
+		defer pprof.StopCPUProfile()
+	}
+
+	start := time.Now()
+	t, _, err := loadTemplate(ctx, *ref)
+	if err != nil {
+		return err
+	}
+	loaded := time.Since(start)
+	env := newEnv(t)
+	env.Logf = func(string, ...any) {}
+	start = time.Now()
+	plan, err := engine.Build(ctx, env)
+	if err != nil {
+		return err
+	}
+	planned := time.Since(start)
+	start = time.Now()
+	engine.Apply(ctx, plan, env, engine.Options{DryRun: true})
+	replayed := time.Since(start)
+
+	applied := map[string]time.Duration{}
+	if haveLast && last.Report.Template == t.Name {
+		for _, o := range last.Report.Outcomes {
+			applied[o.ID] = o.Duration
+		}
+	}
+	var rows []benchRow
+	var checks time.Duration
+	for _, it := range plan.Items {
+		rows = append(rows, benchRow{ID: it.ID(), Check: it.Checked, Apply: applied[it.ID()], Pending: it.Pending()})
+		checks += it.Checked
+	}
+	sort.SliceStable(rows, func(i, j int) bool { return rows[i].Check+rows[i].Apply > rows[j].Check+rows[j].Apply })
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(rows)
+	}
+	fmt.Printf("%s: %d resources\n\n", t.Name, len(plan.Items))
+	fmt.Printf("  load template   %10s\n", loaded.Round(time.Millisecond))
+	fmt.Printf("  build resources %10s\n", (planned - checks).Round(time.Millisecond))
+	fmt.Printf("  check           %10s\n", checks.Round(time.Millisecond))
+	fmt.Printf("  dry-run apply   %10s\n\n", replayed.Round(time.Millisecond))
+	fmt.Printf("%-40s %10s %12s\n", "RESOURCE", "CHECK", "LAST APPLY")
+	for i, r := range rows {
+		if i == *top {
+			fmt.Printf("… %d more\n", len(rows)-i)
+			break
+		}
+		apply := "-"
+		if r.Apply > 0 {
+			apply = r.Apply.Round(time.Millisecond).String()
+		}
+		fmt.Printf("%-40s %10s %12s\n", r.ID, r.Check.Round(time.Millisecond), apply)
+	}
+	if haveLast && len(applied) > 0 {
+		fmt.Printf("\nLast apply: %s on %s\n", last.Report.Finished.Sub(last.Report.Started).Round(time.Millisecond), last.Report.Started.Format(time.RFC3339))
+	}
+	return nil
+}
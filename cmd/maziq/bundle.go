@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/hmziqrs/maziq/internal/bundle"
+	"github.com/hmziqrs/maziq/internal/config"
+	"github.com/hmziqrs/maziq/internal/selfupdate"
+)
+
+func init() {
+	commands = append(commands, command{
+		name:        "bundle",
+		summary:     "Download a template's installs into a bundle and apply it offline",
+		subcommands: []string{"create", "apply"},
+		run:         runBundle,
+	})
+}
+
+func runBundle(ctx context.Context, args []string) error {
+	sub := ""
+	if len(args) > 0 {
+		sub, args = args[0], args[1:]
+	}
+	switch sub {
+	case "create":
+		return createBundle(ctx, args)
+	case "apply":
+		return applyBundle(ctx, args)
+	}
+	return errors.New("usage: maziq bundle create [--template ref] <dir> | maziq bundle apply <dir> [apply flags]")
+}
+
+func createBundle(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("bundle create", flag.ContinueOnError)
+	ref := fs.String("template", "", "template name or path (default from config)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("usage: maziq bundle create [--template ref] <dir>")
+	}
+	dir := fs.Arg(0)
+	if err := bundle.Record(dir); err != nil {
+		return err
+	}
+	t, cfg, err := loadTemplate(ctx, *ref)
+	if err != nil {
+		return err
+	}
+	m, err := bundle.Create(ctx, newEnv(t), dir, selfupdate.Version, cfg.Baseline.URL, warnComponent)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("✓ Bundled %d resource(s) of %s into %s (%s)\n", m.Fetched, m.Template, dir, dirSize(dir))
+	fmt.Printf("  Homebrew's downloads are for macOS %s on %s; apply on a machine like this one.\n", m.MacOS, m.Arch)
+	if len(m.Missing) > 0 {
+		fmt.Printf("\n%d resource(s) will still need the network:\n", len(m.Missing))
+		for _, x := range m.Missing {
+			fmt.Printf("  %-32s %s\n", x.ID, x.Reason)
+		}
+	}
+	return nil
+}
+
+// applyBundle applies the bundle's template with apply's flags, answering
+// every download from the bundle.
+func applyBundle(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: maziq bundle apply <dir> [apply flags]")
+	}
+	// The flags are apply's; help shows them.
+	if args[0] == "-h" || args[0] == "--help" {
+		return runApply(ctx, args)
+	}
+	dir := args[0]
+	m, err := bundle.Open(dir)
+	if err != nil {
+		return err
+	}
+	env := newEnv(nil)
+	if m.Arch != env.Facts["arch"] || m.MacOS != env.Facts["macos"] {
+		fmt.Fprintf(os.Stderr, "warning: the bundle was made on macOS %s on %s; Homebrew may not find bottles for this machine\n", m.MacOS, m.Arch)
+	}
+	for _, x := range m.Missing {
+		fmt.Fprintf(os.Stderr, "warning: %s is not in the bundle: %s\n", x.ID, x.Reason)
+	}
+	if err := bundle.InstallHomebrew(ctx, env, dir); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+	}
+	return runApply(ctx, append([]string{"--template", bundle.TemplatePath(dir)}, args[1:]...))
+}
+
+// dirSize totals the sizes of the files under dir.
+func dirSize(dir string) config.Size {
+	var n int64
+	_ = filepath.WalkDir(dir, func(_ string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			if info, err := d.Info(); err == nil {
+				n += info.Size()
+			}
+		}
+		return nil
+	})
+	return config.Size(n)
+}
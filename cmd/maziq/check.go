@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/hmziqrs/maziq/internal/catalog"
+	"github.com/hmziqrs/maziq/internal/config"
+	"github.com/hmziqrs/maziq/internal/facts"
+	"github.com/hmziqrs/maziq/internal/manager"
+	"github.com/hmziqrs/maziq/internal/shell"
+)
+
+func init() {
+	commands = append(commands, command{
+		name:    "check",
+		summary: "Report installation status of template software",
+		run:     runCheck,
+	})
+}
+
+func runCheck(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("check", flag.ContinueOnError)
+	ref := fs.String("template", "", "template name or path (default from config)")
+	onlyBaseline := fs.Bool("baseline", false, "report compliance with the organization baseline only")
+	asJSON := fs.Bool("json", false, "print results as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	t, cfg, err := loadTemplate(ctx, *ref)
+	if err != nil {
+		return err
+	}
+	if *onlyBaseline && cfg.Baseline.URL == "" {
+		return fmt.Errorf("no baseline configured; set [baseline] url in %s or MAZIQ_BASELINE", config.Path())
+	}
+
+	runner := shell.FromEnv(shell.Local{Env: t.Proxy.Environ()})
+	entries, err := t.Active(facts.Detect())
+	if err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		// Only the software named on the command line.
+		only := map[string]bool{}
+		for _, id := range fs.Args() {
+			only[id] = true
+		}
+		kept := entries[:0]
+		for _, e := range entries {
+			if only[e.ID] {
+				kept = append(kept, e)
+			}
+		}
+		entries = kept
+	}
+
+	type row struct {
+		manager.Result
+		Baseline bool `json:"baseline"`
+	}
+	var rows []row
+	missing := 0
+	for _, e := range entries {
+		if *onlyBaseline && !e.Locked() {
+			continue
+		}
+		sw, ok := catalog.Lookup(e.ID)
+		if !ok {
+			rows = append(rows, row{Result: manager.Result{ID: e.ID, Status: manager.StatusUnknown}, Baseline: e.Locked()})
+			missing++
+			continue
+		}
+		r := row{Result: manager.Detect(ctx, runner, sw), Baseline: e.Locked()}
+		if r.Status != manager.StatusInstalled {
+			missing++
+		}
+		rows = append(rows, r)
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(rows); err != nil {
+			return err
+		}
+	} else {
+		for _, r := range rows {
+			mark := "✓"
+			if r.Status != manager.StatusInstalled {
+				mark = "✗"
+			}
+			lock := " "
+			if r.Baseline {
+				lock = "🔒"
+			}
+			fmt.Printf("%s %s %-22s %-14s %s\n", mark, lock, r.ID, r.Status, r.Version)
+		}
+		if *onlyBaseline {
+			fmt.Printf("\nBaseline compliance: %d/%d\n", len(rows)-missing, len(rows))
+		} else {
+			fmt.Printf("\n%d/%d installed\n", len(rows)-missing, len(rows))
+		}
+	}
+	if *onlyBaseline && missing > 0 {
+		return exitCode(1)
+	}
+	return nil
+}
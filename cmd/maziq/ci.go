@@ -0,0 +1,245 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/hmziqrs/maziq/internal/e2e"
+	"github.com/hmziqrs/maziq/internal/engine"
+	"github.com/hmziqrs/maziq/internal/junit"
+	"github.com/hmziqrs/maziq/internal/templates"
+)
+
+func init() {
+	commands = append(commands, command{
+		name:    "ci",
+		summary: "Lint, apply and test a template non-interactively on a CI runner",
+		run:     runCI,
+	})
+}
+
+// runCI is lint, apply --yes and test in one run for a throwaway macOS
+// runner. HOME and MAZIQ_HOME point into the prefix, so dotfiles, state and
+// config start empty and stay out of the runner's account; installers that
+// write system-wide (brew, pkg) still do, which is why this is for
+// throwaway machines.
+func runCI(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("ci", flag.ContinueOnError)
+	ref := fs.String("template", "", "template name or path (default from config)")
+	prefix := fs.String("prefix", "", "directory to use as HOME and MAZIQ_HOME (default a new temporary directory)")
+	junitPath := fs.String("junit", "", "write lint, apply and test results as JUnit XML to this `file`")
+	filter := fs.String("run", "", "only run test cases whose name contains this string")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if _, err := os.Stat(*ref); err == nil && *ref != "" {
+		// Resolve a path before HOME moves.
+		if abs, err := filepath.Abs(*ref); err == nil {
+			*ref = abs
+		}
+	}
+	if *prefix == "" {
+		dir, err := os.MkdirTemp("", "maziq-ci-")
+		if err != nil {
+			return err
+		}
+		*prefix = dir
+	}
+	home, state := filepath.Join(*prefix, "home"), filepath.Join(*prefix, "maziq")
+	for _, dir := range []string{home, state} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+	}
+	os.Setenv("HOME", home)
+	os.Setenv("MAZIQ_HOME", state)
+	fmt.Printf("Prefix %s\n", *prefix)
+
+	var suites []junit.Suite
+	defer func() {
+		if *junitPath == "" {
+			return
+		}
+		if err := junit.Write(*junitPath, suites); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: writing %s: %v\n", *junitPath, err)
+		}
+	}()
+
+	t, cfg, err := loadTemplate(ctx, *ref)
+	if err != nil {
+		annotate("error", "", "Loading the template", err.Error())
+		return err
+	}
+	// Annotations name the template relative to the checkout.
+	var file string
+	if wd, err := os.Getwd(); err == nil && !t.IsBuiltin() {
+		if rel, err := filepath.Rel(wd, t.Path); err == nil && !strings.HasPrefix(rel, "..") {
+			file = rel
+		}
+	}
+
+	group("Lint " + t.Name)
+	lint := junit.Suite{Name: "lint"}
+	lintFailed := false
+	for _, f := range templates.Lint(t) {
+		if f.Severity < templates.SeverityWarning {
+			continue
+		}
+		fmt.Printf("  %s\n", f)
+		annotate(f.Severity.String(), file, f.Where, f.Message)
+		if f.Severity == templates.SeverityError {
+			lint.Cases = append(lint.Cases, junit.Case{Name: f.Where, Failure: f.Message})
+			lintFailed = true
+		}
+	}
+	if !lintFailed {
+		lint.Cases = append(lint.Cases, junit.Case{Name: t.Name})
+	}
+	suites = append(suites, lint)
+	endGroup()
+	if lintFailed {
+		return exitCode(1)
+	}
+
+	group("Apply " + t.Name)
+	env := newEnv(t)
+	plan, err := engine.Build(ctx, env)
+	if err != nil {
+		endGroup()
+		annotate("error", file, "Planning", err.Error())
+		return err
+	}
+	printPlan(plan)
+	apply := junit.Suite{Name: "apply"}
+	var report engine.Report
+	for e := range engine.Start(ctx, plan, env, engine.Options{OnTimeout: cfg.Apply.OnTimeout}) {
+		switch e := e.(type) {
+		case engine.TaskStarted:
+			fmt.Printf("→ [%d/%d] applying %s: %s\n", e.Index, e.Total, e.ID, e.Description)
+		case engine.TaskLog:
+			fmt.Printf("→ %s\n", e.Line)
+		case engine.TaskDone:
+			o := e.Outcome
+			c := junit.Case{Name: o.ID, Duration: o.Duration}
+			switch o.Status {
+			case engine.OutcomeFailed:
+				c.Failure = o.Error
+				annotate("error", file, o.ID, o.Error)
+			case engine.OutcomeSkipped:
+				c.Skipped = o.Error
+			}
+			apply.Cases = append(apply.Cases, c)
+		case engine.RunFinished:
+			report = e.Report
+		}
+	}
+	suites = append(suites, apply)
+	printReport(report)
+	endGroup()
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	group("Test " + t.Name)
+	rs, err := engine.Resources(ctx, env)
+	if err != nil {
+		endGroup()
+		return err
+	}
+	cases, err := e2e.Cases(env, rs)
+	if err != nil {
+		endGroup()
+		return err
+	}
+	tests := junit.Suite{Name: "e2e"}
+	testsFailed := 0
+	for _, r := range e2e.Run(ctx, env, filterCases(cases, *filter)) {
+		c := junit.Case{Name: r.Case.Name, ClassName: "e2e." + r.Case.Resource, Duration: r.Duration, Output: r.Output}
+		if r.Case.Resource == "" {
+			c.ClassName = "e2e"
+		}
+		if r.Passed {
+			fmt.Printf("  ✓ %s (%s)\n", r.Case.Name, r.Duration.Round(time.Millisecond))
+		} else {
+			testsFailed++
+			c.Failure = r.Error
+			fmt.Printf("  ✗ %s: %s\n", r.Case.Name, r.Error)
+			annotate("error", file, "Test "+r.Case.Name, r.Error)
+		}
+		tests.Cases = append(tests.Cases, c)
+	}
+	suites = append(suites, tests)
+	endGroup()
+
+	applyFailed := report.Count(engine.OutcomeFailed)
+	fmt.Printf("\n%d change(s) failed, %d of %d test(s) failed\n", applyFailed, testsFailed, len(tests.Cases))
+	if applyFailed+testsFailed > 0 {
+		return exitCode(1)
+	}
+	return nil
+}
+
+// filterCases keeps the cases whose name contains filter.
+func filterCases(cases []e2e.Case, filter string) []e2e.Case {
+	if filter == "" {
+		return cases
+	}
+	kept := cases[:0]
+	for _, c := range cases {
+		if strings.Contains(c.Name, filter) {
+			kept = append(kept, c)
+		}
+	}
+	return kept
+}
+
+// githubActions reports whether MazIQ runs in GitHub Actions, where
+// workflow commands fold the log into groups and turn errors into
+// annotations on the pull request.
+func githubActions() bool {
+	return os.Getenv("GITHUB_ACTIONS") == "true"
+}
+
+func group(title string) {
+	if githubActions() {
+		fmt.Printf("::group::%s\n", escapeData(title))
+	} else {
+		fmt.Printf("\n== %s\n", title)
+	}
+}
+
+func endGroup() {
+	if githubActions() {
+		fmt.Println("::endgroup::")
+	}
+}
+
+// annotate reports a problem as a workflow command, attached to the
+// template file when there is one. Outside GitHub Actions the problem has
+// already been printed.
+func annotate(level, file, title, msg string) {
+	if !githubActions() {
+		return
+	}
+	var props []string
+	if file != "" {
+		props = append(props, "file="+escapeProperty(file))
+	}
+	if title != "" {
+		props = append(props, "title="+escapeProperty(title))
+	}
+	fmt.Printf("::%s %s::%s\n", level, strings.Join(props, ","), escapeData(msg))
+}
+
+var (
+	dataEscaper     = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A")
+	propertyEscaper = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C")
+)
+
+func escapeData(s string) string     { return dataEscaper.Replace(s) }
+func escapeProperty(s string) string { return propertyEscaper.Replace(s) }
@@ -0,0 +1,363 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/hmziqrs/maziq/internal/catalog"
+	"github.com/hmziqrs/maziq/internal/e2e"
+	"github.com/hmziqrs/maziq/internal/engine"
+	"github.com/hmziqrs/maziq/internal/modules/direct"
+	"github.com/hmziqrs/maziq/internal/quarantine"
+	"github.com/hmziqrs/maziq/internal/resource"
+	"github.com/hmziqrs/maziq/internal/sysprefs"
+	"github.com/hmziqrs/maziq/internal/templates"
+)
+
+func init() {
+	commands = append(commands,
+		command{
+			name:    "completion",
+			summary: "Print a zsh, bash or fish completion script",
+			run:     runCompletion,
+		},
+		command{
+			name:    "__complete",
+			summary: "List completions for the words after maziq, one per line",
+			run:     runComplete,
+		},
+	)
+}
+
+// completionScripts ask maziq __complete for candidates on every Tab, so
+// template, test and package names stay current without regenerating them.
+var completionScripts = map[string]string{
+	"bash": `# bash completion for maziq; add to ~/.bashrc:
+#   source <(maziq completion bash)
+_maziq() {
+	local IFS=$'\n'
+	COMPREPLY=($(compgen -W "$(maziq __complete "${COMP_WORDS[@]:1:COMP_CWORD-1}" 2>/dev/null | cut -f1)" -- "${COMP_WORDS[COMP_CWORD]}"))
+}
+complete -o default -F _maziq maziq
+`,
+	"zsh": `#compdef maziq
+# zsh completion for maziq; add to ~/.zshrc:
+#   source <(maziq completion zsh)
+_maziq() {
+	local -a values
+	maziq __complete "${(@)words[2,CURRENT-1]}" 2>/dev/null | while IFS=$'\t' read -r value desc; do
+		values+=("${value//:/\\:}${desc:+:$desc}")
+	done
+	if (( ${#values} )); then
+		_describe 'maziq' values
+	else
+		_files
+	fi
+}
+compdef _maziq maziq
+`,
+	"fish": `# fish completion for maziq; save as ~/.config/fish/completions/maziq.fish:
+#   maziq completion fish > ~/.config/fish/completions/maziq.fish
+complete -c maziq -f -a '(maziq __complete (commandline -opc)[2..-1] 2>/dev/null)'
+`,
+}
+
+func runCompletion(ctx context.Context, args []string) error {
+	if len(args) != 1 || completionScripts[args[0]] == "" {
+		return fmt.Errorf("usage: maziq completion <zsh|bash|fish>")
+	}
+	fmt.Print(completionScripts[args[0]])
+	return nil
+}
+
+// candidate is one completion with an optional description.
+type candidate struct {
+	value, desc string
+}
+
+// flagValues complete the value of a flag, keyed by flag name. ref is the
+// --template already typed, if any.
+var flagValues = map[string]func(cmd, ref string) []candidate{
+	"template": func(string, string) []candidate { return templateCandidates() },
+	"run": func(cmd, ref string) []candidate {
+		if cmd != "test" && cmd != "ci" {
+			return nil
+		}
+		return testCandidates(ref)
+	},
+}
+
+// argValues complete positional arguments, keyed by command and, for
+// commands with subcommands, "command subcommand".
+var argValues = map[string]func() []candidate{
+	"check":            packageCandidates,
+	"explain":          sectionCandidates,
+	"fix":              fixCandidates,
+	"settings":         paneCandidates,
+	"apps uninstall":   appCandidates,
+	"template lint":    templateCandidates,
+	"quarantine clear": quarantineCandidates,
+}
+
+// flagless lists the commands that take no flags. Flags are discovered by
+// running a command with -h, which these would not treat as a request for
+// help, so they must never be run that way.
+var flagless = map[string]bool{
+	"version":         true,
+	"completion":      true,
+	"audit verify":    true,
+	"demo status":     true,
+	"metrics show":    true,
+	"metrics clear":   true,
+	"quarantine list": true,
+	"state check":     true,
+	"state repair":    true,
+	"state compact":   true,
+	"template list":   true,
+}
+
+// runComplete prints the candidates for the word after args, which are the
+// words already typed after "maziq", as "value<TAB>description" lines. The
+// shell filters them by the word being typed.
+func runComplete(ctx context.Context, args []string) error {
+	out := bufio.NewWriter(os.Stdout)
+	defer out.Flush()
+	for _, c := range complete(ctx, args) {
+		if c.desc != "" {
+			fmt.Fprintf(out, "%s\t%s\n", c.value, c.desc)
+		} else {
+			fmt.Fprintln(out, c.value)
+		}
+	}
+	return nil
+}
+
+func complete(ctx context.Context, args []string) []candidate {
+	if len(args) == 0 {
+		var out []candidate
+		for _, c := range commands {
+			if !c.hidden() {
+				out = append(out, candidate{c.name, c.summary})
+			}
+		}
+		return append(out, candidate{"help", "Show usage"})
+	}
+	var cmd *command
+	for i := range commands {
+		if commands[i].name == args[0] {
+			cmd = &commands[i]
+		}
+	}
+	if cmd == nil {
+		return nil
+	}
+	if cmd.name == "completion" {
+		if len(args) == 1 {
+			return []candidate{{"zsh", ""}, {"bash", ""}, {"fish", ""}}
+		}
+		return nil
+	}
+
+	key, rest := cmd.name, args[1:]
+	if len(cmd.subcommands) > 0 {
+		if len(rest) == 0 {
+			var out []candidate
+			for _, s := range cmd.subcommands {
+				out = append(out, candidate{s, ""})
+			}
+			return out
+		}
+		if !slices.Contains(cmd.subcommands, rest[0]) {
+			return nil
+		}
+		key, rest = cmd.name+" "+rest[0], rest[1:]
+	}
+	flags := commandFlags(ctx, cmd, key)
+	var ref string
+	for i, a := range rest[:max(len(rest)-1, 0)] {
+		if a == "-template" || a == "--template" {
+			ref = rest[i+1]
+		}
+	}
+	if len(rest) > 0 {
+		last := rest[len(rest)-1]
+		name := strings.TrimLeft(last, "-")
+		if i := slices.IndexFunc(flags, func(f flagInfo) bool { return f.name == name }); i >= 0 && strings.HasPrefix(last, "-") && flags[i].arg != "" {
+			if values := flagValues[name]; values != nil {
+				return values(cmd.name, ref)
+			}
+			return nil
+		}
+	}
+	var out []candidate
+	if values := argValues[key]; values != nil && !flagless[key] {
+		out = values()
+	}
+	for _, f := range flags {
+		out = append(out, candidate{"--" + f.name, f.usage})
+	}
+	return out
+}
+
+// flagInfo is a flag as its FlagSet's usage shows it. arg names the value
+// it takes and is empty for boolean flags.
+type flagInfo struct {
+	name, arg, usage string
+}
+
+// flagLine matches the flag lines of a FlagSet's default usage, such as
+// "  -template string" or "  -yes".
+var flagLine = regexp.MustCompile(`^  -([\w-]+)(?: (\w+))?`)
+
+// commandFlags discovers key's flags by running the command with -h and
+// reading the usage its FlagSet prints, so completions cannot drift from
+// the flags a command actually defines.
+func commandFlags(ctx context.Context, cmd *command, key string) []flagInfo {
+	if flagless[key] {
+		return nil
+	}
+	args := []string{"-h"}
+	if sub, ok := strings.CutPrefix(key, cmd.name+" "); ok {
+		args = []string{sub, "-h"}
+	}
+	tmp, err := os.CreateTemp("", "maziq-complete")
+	if err != nil {
+		return nil
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+	devnull, err := os.Open(os.DevNull)
+	if err != nil {
+		return nil
+	}
+	defer devnull.Close()
+
+	stdout, stderr := os.Stdout, os.Stderr
+	os.Stdout, os.Stderr = devnull, tmp
+	_ = cmd.run(ctx, args)
+	os.Stdout, os.Stderr = stdout, stderr
+
+	if _, err := tmp.Seek(0, 0); err != nil {
+		return nil
+	}
+	var flags []flagInfo
+	sc := bufio.NewScanner(tmp)
+	for sc.Scan() {
+		line := sc.Text()
+		if m := flagLine.FindStringSubmatch(line); m != nil {
+			flags = append(flags, flagInfo{name: m[1], arg: m[2]})
+			continue
+		}
+		// The usage string follows on the next, tab-indented line.
+		if n := len(flags); n > 0 && flags[n-1].usage == "" {
+			flags[n-1].usage = strings.TrimSpace(line)
+		}
+	}
+	return flags
+}
+
+func templateCandidates() []candidate {
+	var out []candidate
+	for _, name := range templates.List() {
+		out = append(out, candidate{name, ""})
+	}
+	return out
+}
+
+// testCandidates lists the case names of ref, or of the configured
+// template. Secrets are left encrypted since only names are needed.
+func testCandidates(ref string) []candidate {
+	t, _, err := loadTemplateOffline(context.Background(), ref)
+	if err != nil {
+		return nil
+	}
+	env := newEnv(t)
+	rs, err := resource.Build(env)
+	if err != nil {
+		rs = nil
+	}
+	cases, err := e2e.Cases(env, rs)
+	if err != nil {
+		return nil
+	}
+	var out []candidate
+	for _, c := range cases {
+		out = append(out, candidate{c.Name, c.Resource})
+	}
+	return out
+}
+
+func paneCandidates() []candidate {
+	var out []candidate
+	for _, name := range sysprefs.Names() {
+		p, _ := sysprefs.Lookup(name)
+		out = append(out, candidate{name, p.Title})
+	}
+	return out
+}
+
+func appCandidates() []candidate {
+	names, err := direct.Names()
+	if err != nil {
+		return nil
+	}
+	var out []candidate
+	for _, name := range names {
+		out = append(out, candidate{name, ""})
+	}
+	return out
+}
+
+// packageCandidates lists catalog software, for commands that take
+// software IDs.
+func packageCandidates() []candidate {
+	var out []candidate
+	for _, sw := range catalog.All() {
+		out = append(out, candidate{sw.ID, sw.Name})
+	}
+	return out
+}
+
+func quarantineCandidates() []candidate {
+	records, err := quarantine.Load()
+	if err != nil {
+		return nil
+	}
+	var out []candidate
+	for id, r := range records {
+		if r.Quarantined() {
+			out = append(out, candidate{id, ""})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].value < out[j].value })
+	return out
+}
+
+// fixCandidates lists the failures of the last apply that have a fix.
+func fixCandidates() []candidate {
+	run, ok, err := engine.Last()
+	if err != nil || !ok {
+		return nil
+	}
+	var out []candidate
+	for _, o := range run.Report.Outcomes {
+		if o.Fix != nil {
+			out = append(out, candidate{o.ID, o.Fix.Title()})
+		}
+	}
+	return out
+}
+
+func sectionCandidates() []candidate {
+	var out []candidate
+	for _, s := range templates.Sections() {
+		out = append(out, candidate{s.Key, firstSentence(s.Doc)})
+	}
+	return out
+}
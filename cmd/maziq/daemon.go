@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/hmziqrs/maziq/internal/daemon"
+	"github.com/hmziqrs/maziq/internal/resource"
+	"github.com/hmziqrs/maziq/internal/templates"
+)
+
+func init() {
+	commands = append(commands, command{
+		name:    "daemon",
+		summary: "Serve plan, apply and status on a local Unix socket",
+		run:     runDaemon,
+	})
+}
+
+func runDaemon(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("daemon", flag.ContinueOnError)
+	socket := fs.String("socket", daemon.SocketPath(), "Unix socket to listen on")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	srv := &daemon.Server{
+		Load: func(ctx context.Context, ref string) (*templates.Template, error) {
+			t, _, err := loadTemplate(ctx, ref)
+			return t, err
+		},
+		Env: func(t *templates.Template) *resource.Env { return newEnv(t) },
+		Logf: func(format string, args ...any) {
+			fmt.Fprintf(os.Stderr, "→ "+format+"\n", args...)
+		},
+	}
+	return srv.ListenAndServe(ctx, *socket)
+}
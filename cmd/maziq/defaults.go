@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+
+	"github.com/hmziqrs/maziq/internal/facts"
+	"github.com/hmziqrs/maziq/internal/modules/defaults"
+	"github.com/hmziqrs/maziq/internal/resource"
+	"github.com/hmziqrs/maziq/internal/secrets"
+	"github.com/hmziqrs/maziq/internal/shell"
+)
+
+func init() {
+	commands = append(commands, command{
+		name:        "defaults",
+		summary:     "Capture preference tweaks as template entries",
+		subcommands: []string{"capture"},
+		run:         runDefaults,
+	})
+}
+
+func runDefaults(ctx context.Context, args []string) error {
+	if len(args) == 0 || args[0] != "capture" {
+		return errors.New("usage: maziq defaults capture [flags] [domain...]")
+	}
+	fs := flag.NewFlagSet("defaults capture", flag.ContinueOnError)
+	unknown := fs.Bool("unknown", false, "also emit keys without a known factory value, commented out")
+	macos := fs.String("macos", "", "macOS major version to compare against (default: this machine)")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	domains := fs.Args()
+	if len(domains) == 0 {
+		domains = defaults.FactoryDomains()
+	}
+	f := facts.Detect()
+	if *macos != "" {
+		f["macos"] = *macos
+	}
+	env := &resource.Env{Runner: shell.FromEnv(shell.Local{}), Secrets: secrets.Default(), Facts: f}
+	captured, err := defaults.Capture(ctx, env, domains, f["macos"], *unknown)
+	if err != nil {
+		return err
+	}
+
+	version := f["macos"]
+	if version == "" {
+		version = "unknown"
+	}
+	fmt.Printf("# Preferences that differ from macOS %s factory values in %s.\n", version, strings.Join(domains, ", "))
+	for _, c := range captured {
+		entry, err := encodeCaptured(c)
+		if err != nil {
+			return err
+		}
+		if !c.Known() {
+			entry = "# no known factory value; keep it if you set it on purpose\n# " + strings.ReplaceAll(strings.TrimSuffix(entry, "\n"), "\n", "\n# ") + "\n"
+		}
+		fmt.Print("\n" + entry)
+	}
+	if len(captured) == 0 {
+		fmt.Fprintln(os.Stderr, "No tweaks found.")
+	}
+	return nil
+}
+
+// encodeCaptured renders c as a [[defaults]] entry, with the factory value
+// as a trailing comment.
+func encodeCaptured(c defaults.Captured) (string, error) {
+	var b strings.Builder
+	b.WriteString("[[defaults]]\n")
+	for _, f := range []struct {
+		key   string
+		value any
+	}{{"domain", c.Domain}, {"key", c.Key}, {"value", c.Value}, {"restart", c.Restart}} {
+		if f.value == "" {
+			continue
+		}
+		v, err := tomlValue(f.value)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&b, "%s = %s", f.key, v)
+		if f.key == "value" && c.Known() {
+			factory, err := tomlValue(c.Factory)
+			if err != nil {
+				return "", err
+			}
+			fmt.Fprintf(&b, " # factory: %s", factory)
+		}
+		b.WriteString("\n")
+	}
+	return b.String(), nil
+}
+
+// tomlValue encodes v as a TOML value.
+func tomlValue(v any) (string, error) {
+	var b strings.Builder
+	if err := toml.NewEncoder(&b).Encode(map[string]any{"v": v}); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(strings.TrimPrefix(b.String(), "v = ")), nil
+}
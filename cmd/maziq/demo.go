@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/hmziqrs/maziq/internal/demo"
+)
+
+func init() {
+	commands = append(commands, command{
+		name:        "demo",
+		summary:     "Show or reset what demo templates changed",
+		subcommands: []string{"status", "reset"},
+		run:         runDemo,
+	})
+}
+
+func runDemo(ctx context.Context, args []string) error {
+	sub := "status"
+	if len(args) > 0 {
+		sub, args = args[0], args[1:]
+	}
+	switch sub {
+	case "status":
+		return demoStatus()
+	case "reset":
+		return demoReset(ctx, args)
+	}
+	return errors.New("usage: maziq demo [status | reset [--due]]")
+}
+
+func demoStatus() error {
+	s, err := demo.Load()
+	if err != nil {
+		return err
+	}
+	if s == nil {
+		fmt.Println("No demo changes to reset.")
+		return nil
+	}
+	fmt.Printf("%d change(s) by %s since %s:\n", len(s.Undo), s.Template, s.Started.Local().Format("2006-01-02 15:04"))
+	for _, u := range s.Undo {
+		fmt.Printf("  %s\n", u.ID)
+	}
+	if !s.ResetAt.IsZero() {
+		fmt.Printf("\nThe daemon resets them at %s.\n", s.ResetAt.Local().Format("2006-01-02 15:04"))
+	}
+	return nil
+}
+
+func demoReset(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("demo reset", flag.ContinueOnError)
+	due := fs.Bool("due", false, "only reset once the template's reset_after has passed, for running from a schedule")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	s, err := demo.Load()
+	if err != nil {
+		return err
+	}
+	if s == nil || *due && !s.Due(time.Now()) {
+		fmt.Println("✓ Nothing to reset.")
+		return nil
+	}
+	n, err := demo.Reset(ctx, newEnv(nil))
+	if err != nil {
+		fmt.Printf("Took back %d of %d change(s).\n", n, len(s.Undo))
+		return err
+	}
+	fmt.Printf("✓ Took back %d change(s) made by %s.\n", n, s.Template)
+	return nil
+}
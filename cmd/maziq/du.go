@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/hmziqrs/maziq/internal/config"
+	"github.com/hmziqrs/maziq/internal/du"
+)
+
+func init() {
+	commands = append(commands, command{
+		name:    "du",
+		summary: "Show how much disk the managed software and its caches take up",
+		run:     runDU,
+	})
+}
+
+func runDU(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("du", flag.ContinueOnError)
+	ref := fs.String("template", "", "template name or path (default from config)")
+	sortBy := fs.String("sort", "size", "sort by size, name or category")
+	only := fs.String("category", "", "only list this `category`: "+strings.Join(du.Categories, ", "))
+	asJSON := fs.Bool("json", false, "print the report as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if !slices.Contains([]string{"size", "name", "category"}, *sortBy) {
+		return fmt.Errorf("unknown sort %q; use size, name or category", *sortBy)
+	}
+	if *only != "" && !slices.Contains(du.Categories, *only) {
+		return fmt.Errorf("unknown category %q; use one of %s", *only, strings.Join(du.Categories, ", "))
+	}
+	t, _, err := loadTemplate(ctx, *ref)
+	if err != nil {
+		return err
+	}
+	report, err := du.Collect(ctx, newEnv(t), warnComponent)
+	if err != nil {
+		return err
+	}
+	if *only != "" {
+		report.Entries = slices.DeleteFunc(report.Entries, func(e du.Entry) bool { return e.Category != *only })
+	}
+	du.Sort(report.Entries, *sortBy)
+	if *asJSON {
+		if report.Entries == nil {
+			report.Entries = []du.Entry{}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	}
+	if len(report.Entries) == 0 {
+		fmt.Println("Nothing installed to measure.")
+		return nil
+	}
+	fmt.Printf("%-28s %-10s %10s\n", "NAME", "CATEGORY", "SIZE")
+	for _, e := range report.Entries {
+		fmt.Printf("%-28s %-10s %10s\n", e.Name, e.Category, config.Size(e.Size))
+	}
+	fmt.Println()
+	for _, c := range du.Categories {
+		if n, ok := report.Totals[c]; ok && (*only == "" || c == *only) {
+			fmt.Printf("%-39s %10s\n", c, config.Size(n))
+		}
+	}
+	if *only == "" {
+		fmt.Printf("%-39s %10s\n", "total", config.Size(report.Total))
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/hmziqrs/maziq/internal/facts"
+	"github.com/hmziqrs/maziq/internal/templates"
+)
+
+func init() {
+	commands = append(commands, command{
+		name:    "explain",
+		summary: "Describe a template section: its keys, conditions and an example",
+		run:     runExplain,
+	})
+}
+
+// kindSections maps resource kinds whose name differs from their template
+// section, so a resource ID from plan output can be explained as is.
+var kindSections = map[string]string{
+	"bin":         "binaries",
+	"osupdate":    "updates",
+	"kubecontext": "kubernetes",
+	"aws":         "cloud",
+	"gcloud":      "cloud",
+	"azure":       "cloud",
+	"screenlock":  "screensaver",
+}
+
+func runExplain(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("explain", flag.ContinueOnError)
+	asJSON := fs.Bool("json", false, "print the section as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		for _, s := range templates.Sections() {
+			fmt.Printf("  %-14s %s\n", s.Key, firstSentence(s.Doc))
+		}
+		fmt.Println("\nRun maziq explain <section> for its keys and an example.")
+		return nil
+	}
+	if fs.NArg() > 1 {
+		return fmt.Errorf("usage: maziq explain [section | resource ID]")
+	}
+	s, ok := lookupSection(fs.Arg(0))
+	if !ok {
+		return fmt.Errorf("no template section %q; run maziq explain to list them", fs.Arg(0))
+	}
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(s)
+	}
+	printSection(s)
+	return nil
+}
+
+// lookupSection accepts a section key, its singular, or a resource ID such
+// as "font:JetBrains Mono".
+func lookupSection(name string) (templates.Section, bool) {
+	kind, _, _ := strings.Cut(name, ":")
+	for _, key := range []string{name, kind, kindSections[kind], kind + "s"} {
+		if s, ok := templates.LookupSection(key); ok && key != "" {
+			return s, true
+		}
+	}
+	return templates.Section{}, false
+}
+
+func printSection(s templates.Section) {
+	switch s.Type {
+	case "array of tables":
+		fmt.Printf("[[%s]]\n", s.Key)
+	case "table":
+		fmt.Printf("[%s]\n", s.Key)
+	default:
+		fmt.Printf("%s = <%s>\n", s.Key, s.Type)
+	}
+	if s.Doc != "" {
+		fmt.Printf("\n%s\n", s.Doc)
+	}
+	if len(s.Fields) > 0 {
+		fmt.Println("\nKeys:")
+		for _, f := range s.Fields {
+			fmt.Println(strings.TrimRight(fmt.Sprintf("  %-22s %-22s %s", f.Key, f.Type, f.Doc), " "))
+		}
+	}
+	if s.Conditional {
+		fmt.Printf("\nConditions:\n%s\n", indent(conditionHelp(), "  "))
+	}
+	if s.Example != "" {
+		fmt.Printf("\nExample:\n%s\n", indent(s.Example, "  "))
+	}
+}
+
+// conditionHelp describes what a `when` condition can test.
+func conditionHelp() string {
+	var b strings.Builder
+	b.WriteString("`when` is an expression such as arch == \"arm64\" && !work, combining\n")
+	b.WriteString("==, !=, !, &&, || and parentheses. Names are facts or template vars:\n")
+	for _, name := range facts.Names() {
+		d, _ := facts.DomainOf(name)
+		if d.Open {
+			fmt.Fprintf(&b, "  %-10s any value\n", name)
+		} else {
+			fmt.Fprintf(&b, "  %-10s %s\n", name, strings.Join(d.Values, ", "))
+		}
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+func firstSentence(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if i := strings.Index(s, ". "); i >= 0 {
+		return s[:i+1]
+	}
+	return s
+}
+
+func indent(s, prefix string) string {
+	lines := strings.Split(s, "\n")
+	for i, l := range lines {
+		if l != "" {
+			lines[i] = prefix + l
+		}
+	}
+	return strings.Join(lines, "\n")
+}
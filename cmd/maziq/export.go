@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/hmziqrs/maziq/internal/engine"
+	"github.com/hmziqrs/maziq/internal/export"
+	"github.com/hmziqrs/maziq/internal/resource"
+	"github.com/hmziqrs/maziq/internal/selfupdate"
+)
+
+func init() {
+	commands = append(commands, command{
+		name:        "export",
+		summary:     "Write a template out as a standalone shell script or Ansible playbook",
+		subcommands: []string{"script", "ansible"},
+		run:         runExport,
+	})
+}
+
+func runExport(ctx context.Context, args []string) error {
+	const usage = "usage: maziq export <script|ansible> [--template ref] [--pending] [-o file]"
+	if len(args) == 0 || (args[0] != "script" && args[0] != "ansible") {
+		return errors.New(usage)
+	}
+	format := args[0]
+	fs := flag.NewFlagSet("export "+format, flag.ContinueOnError)
+	ref := fs.String("template", "", "template name or path (default from config)")
+	pending := fs.Bool("pending", false, "export only what this machine is missing")
+	output := fs.String("o", "", "write to this file instead of stdout")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return errors.New(usage)
+	}
+	t, _, err := loadTemplate(ctx, *ref)
+	if err != nil {
+		return err
+	}
+	env := newEnv(t)
+	var rs []resource.Resource
+	if *pending {
+		plan, err := engine.Build(ctx, env)
+		if err != nil {
+			return err
+		}
+		for _, it := range plan.Pending() {
+			rs = append(rs, it.Resource)
+		}
+	} else if rs, err = engine.Resources(ctx, env); err != nil {
+		return err
+	}
+
+	entries, skipped := export.Collect(ctx, env, rs)
+	h := export.Header{Template: t.Name, Source: t.Path, Version: selfupdate.Version, Home: env.Path("~")}
+	if h.Source == "" {
+		h.Source = "the template " + t.Name
+	}
+	var data []byte
+	mode := os.FileMode(0o644)
+	if format == "script" {
+		data, mode = export.Script(h, entries, skipped), 0o755
+	} else {
+		data = export.Ansible(h, entries, skipped)
+	}
+	for _, s := range skipped {
+		fmt.Fprintf(os.Stderr, "warning: %s not exported: %s\n", s.ID, s.Reason)
+	}
+	if *output == "" {
+		os.Stdout.Write(data)
+		return nil
+	}
+	if err := os.WriteFile(*output, data, mode); err != nil {
+		return err
+	}
+	fmt.Printf("✓ Wrote %s with %d step(s)\n", *output, len(entries))
+	return nil
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"slices"
+
+	"github.com/hmziqrs/maziq/internal/engine"
+)
+
+func init() {
+	commands = append(commands, command{
+		name:    "fix",
+		summary: "Walk through the fixes for known failures of the last apply",
+		run:     runFix,
+	})
+}
+
+func runFix(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("fix", flag.ContinueOnError)
+	yes := fs.Bool("yes", false, "run every fix without asking")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	run, ok, err := engine.Last()
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("no apply has been recorded yet")
+	}
+	var fixable []engine.Outcome
+	for _, o := range run.Report.Outcomes {
+		if o.Fix != nil && (fs.NArg() == 0 || slices.Contains(fs.Args(), o.ID)) {
+			fixable = append(fixable, o)
+		}
+	}
+	if len(fixable) == 0 {
+		fmt.Println("The last apply had no failures MazIQ knows how to fix.")
+		return nil
+	}
+	env := newEnv(nil)
+	if interactive() {
+		env.Wait = waitEnter
+	}
+	var fixed int
+	for _, o := range fixable {
+		fmt.Printf("\n%s failed: %s\n", o.ID, o.Error)
+		fmt.Printf("%s. %s\n", o.Fix.Title(), o.Fix.Explain())
+		for i, s := range o.Fix.Steps() {
+			line := fmt.Sprintf("  %d. %s", i+1, s.Say)
+			if s.Command != nil {
+				line += ": " + s.Command.String()
+			}
+			fmt.Println(line)
+		}
+		if !*yes && !confirm(ctx, "Run these steps?") {
+			continue
+		}
+		if err := o.Fix.Run(ctx, env); err != nil {
+			fmt.Printf("  ✗ %v\n", err)
+			continue
+		}
+		fmt.Printf("  ✓ %s\n", o.Fix.Title())
+		fixed++
+	}
+	if fixed == 0 {
+		return exitCode(1)
+	}
+	fmt.Println("\nRun maziq apply to try again.")
+	return nil
+}
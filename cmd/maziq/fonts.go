@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/hmziqrs/maziq/internal/modules/fonts"
+)
+
+func init() {
+	commands = append(commands, command{
+		name:    "fonts",
+		summary: "List installed fonts, marking those MazIQ manages",
+		run:     runFonts,
+	})
+}
+
+func runFonts(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("fonts", flag.ContinueOnError)
+	ref := fs.String("template", "", "template name or path (default from config)")
+	unmanaged := fs.Bool("unmanaged", false, "only list fonts no template entry accounts for")
+	asJSON := fs.Bool("json", false, "print the inventory as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	t, _, err := loadTemplate(ctx, *ref)
+	if err != nil {
+		return err
+	}
+	inventory := fonts.Inventory(t)
+	var rows []fonts.Entry
+	managed := 0
+	for _, e := range inventory {
+		if e.Managed() {
+			managed++
+			if *unmanaged {
+				continue
+			}
+		}
+		rows = append(rows, e)
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(rows)
+	}
+	for _, e := range rows {
+		owner := "unmanaged"
+		if e.Managed() {
+			owner = e.Owner
+		}
+		fmt.Printf("%-40s %-20s %s\n", e.PostScript, owner, e.File)
+	}
+	fmt.Printf("\n%d managed, %d unmanaged\n", managed, len(inventory)-managed)
+	return nil
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/hmziqrs/maziq/internal/graph"
+)
+
+func init() {
+	commands = append(commands, command{
+		name:    "graph",
+		summary: "Show which template entries depend on which, or print the graph as DOT",
+		run:     runGraph,
+	})
+}
+
+func runGraph(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("graph", flag.ContinueOnError)
+	ref := fs.String("template", "", "template name or path (default from config)")
+	dot := fs.Bool("dot", false, "print the graph in Graphviz's DOT language")
+	asJSON := fs.Bool("json", false, "print the nodes and edges as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 1 {
+		return fmt.Errorf("usage: maziq graph [--dot | --json] [ID]")
+	}
+	t, _, err := loadTemplate(ctx, *ref)
+	if err != nil {
+		return err
+	}
+	g, err := graph.Build(ctx, newEnv(t))
+	if err != nil {
+		return err
+	}
+	id := fs.Arg(0)
+	if id != "" {
+		if !g.Has(id) {
+			return fmt.Errorf("%s is not in the plan; maziq plan lists the IDs", id)
+		}
+		g = g.Focus(id)
+	}
+	switch {
+	case *dot:
+		return g.Dot(os.Stdout)
+	case *asJSON:
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(g)
+	case id != "":
+		printNeighbours(g, id)
+		return nil
+	}
+	if len(g.Edges) == 0 {
+		fmt.Println("No entry depends on another.")
+		return nil
+	}
+	for _, n := range g.Nodes {
+		if edges := g.Requires(n.ID); len(edges) > 0 {
+			fmt.Println(n.ID)
+			for _, e := range edges {
+				fmt.Println("  → " + edgeLabel(e.To, e))
+			}
+		}
+	}
+	return nil
+}
+
+// printNeighbours prints what id needs and what needs it.
+func printNeighbours(g *graph.Graph, id string) {
+	fmt.Println(id)
+	for _, e := range g.Requires(id) {
+		fmt.Println("  requires     " + edgeLabel(e.To, e))
+	}
+	for _, e := range g.RequiredBy(id) {
+		fmt.Println("  required by  " + edgeLabel(e.From, e))
+	}
+	if deps := g.Dependents(id); len(deps) > 0 {
+		fmt.Printf("\nRemoving %s would break %s.\n", id, strings.Join(deps, ", "))
+	} else {
+		fmt.Printf("\nNothing else in the template needs %s.\n", id)
+	}
+}
+
+func edgeLabel(id string, e graph.Edge) string {
+	switch e.Kind {
+	case graph.Order:
+		return id + " ([[order]])"
+	case graph.Brew:
+		return id + " (Homebrew dependency)"
+	}
+	return id
+}
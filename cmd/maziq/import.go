@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/hmziqrs/maziq/internal/importer"
+	"github.com/hmziqrs/maziq/internal/resource"
+)
+
+func init() {
+	commands = append(commands, command{
+		name:        "import",
+		summary:     "Convert a chezmoi source directory, setup scripts or Ansible playbooks into a template",
+		subcommands: []string{"chezmoi", "script", "ansible"},
+		run:         runImport,
+	})
+}
+
+func runImport(ctx context.Context, args []string) error {
+	const usage = "usage: maziq import <chezmoi|script|ansible> [--name name] [-o file] [path...]"
+	if len(args) == 0 {
+		return errors.New(usage)
+	}
+	from := args[0]
+	fs := flag.NewFlagSet("import "+from, flag.ContinueOnError)
+	name := fs.String("name", "imported", "name of the template")
+	output := fs.String("o", "", "write the template to this file instead of stdout")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	paths := fs.Args()
+	var r *importer.Result
+	var err error
+	switch from {
+	case "chezmoi":
+		dir := (&resource.Env{}).Path(importer.DefaultChezmoi)
+		if len(paths) > 1 {
+			return errors.New("usage: maziq import chezmoi [--name name] [-o file] [source-dir]")
+		} else if len(paths) == 1 {
+			dir = paths[0]
+		}
+		paths = []string{dir}
+		r, err = importer.Chezmoi(dir)
+	case "script":
+		if len(paths) == 0 {
+			return errors.New("usage: maziq import script [--name name] [-o file] <script|Brewfile|dir>...")
+		}
+		r, err = importer.Script(paths...)
+	case "ansible":
+		if len(paths) == 0 {
+			return errors.New("usage: maziq import ansible [--name name] [-o file] <playbook|vars file>...")
+		}
+		r, err = importer.Ansible(paths...)
+	default:
+		return errors.New(usage)
+	}
+	if err != nil {
+		return err
+	}
+
+	data, err := r.Template(*name, fmt.Sprintf("Imported from %s %s", from, strings.Join(paths, ", ")))
+	if err != nil {
+		return err
+	}
+	if *output == "" {
+		os.Stdout.Write(data)
+	} else {
+		if _, err := os.Stat(*output); err == nil {
+			return fmt.Errorf("%s already exists", *output)
+		}
+		if err := os.WriteFile(*output, data, 0o644); err != nil {
+			return err
+		}
+	}
+
+	w := os.Stderr
+	if r.Empty() {
+		fmt.Fprintln(w, "warning: nothing could be converted")
+	} else if *output != "" {
+		fmt.Printf("✓ Wrote %s: %d software, %d font(s), %d App Store app(s), %d preference(s), %d repo(s), %d config(s)\n",
+			*output, len(r.Software), len(r.Fonts), len(r.AppStore), len(r.Defaults), len(r.Repos), len(r.Configs))
+	}
+	if len(r.Skipped) > 0 {
+		fmt.Fprintf(w, "\n%d item(s) were not converted:\n", len(r.Skipped))
+		for _, s := range r.Skipped {
+			fmt.Fprintf(w, "  %s\n      %s: %s\n", s.Source, s.What, s.Reason)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/hmziqrs/maziq/internal/baseline"
+	"github.com/hmziqrs/maziq/internal/config"
+	"github.com/hmziqrs/maziq/internal/facts"
+	"github.com/hmziqrs/maziq/internal/fetch"
+	"github.com/hmziqrs/maziq/internal/resource"
+	"github.com/hmziqrs/maziq/internal/secrets"
+	"github.com/hmziqrs/maziq/internal/shell"
+	"github.com/hmziqrs/maziq/internal/templates"
+)
+
+// loadTemplate resolves the template named by ref (or the configured default)
+// with the machine's maziq.local.toml over it, and merges the organization
+// baseline underneath when one is configured.
+// Overruled personal settings are reported on stderr.
+func loadTemplate(ctx context.Context, ref string) (*templates.Template, config.Config, error) {
+	return resolveTemplate(ctx, ref, false)
+}
+
+// loadTemplateOffline is loadTemplate with the cached baseline, for checks
+// that must not wait on the network. Without a cached copy the baseline is
+// left out.
+func loadTemplateOffline(ctx context.Context, ref string) (*templates.Template, config.Config, error) {
+	return resolveTemplate(ctx, ref, true)
+}
+
+func resolveTemplate(ctx context.Context, ref string, offline bool) (*templates.Template, config.Config, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, cfg, fmt.Errorf("config: %w", err)
+	}
+	if ref == "" {
+		ref = cfg.Template
+	}
+	t, err := templates.Resolve(ref)
+	if err != nil {
+		return nil, cfg, err
+	}
+	if t, err = t.WithLocal(); err != nil {
+		return nil, cfg, err
+	}
+	// A personal proxy is needed to reach the baseline.
+	ctx = fetch.WithProxy(ctx, t.Proxy.Environ())
+	t.Profile = cfg.Profile
+	if cfg.Baseline.URL == "" {
+		return t, cfg, nil
+	}
+
+	var res baseline.Result
+	if offline {
+		if res, err = baseline.Cached(); err != nil {
+			return t, cfg, nil
+		}
+	} else if res, err = baseline.Fetch(ctx, cfg.Baseline.URL); err != nil {
+		return nil, cfg, err
+	}
+	if res.FetchErr != nil {
+		fmt.Fprintf(os.Stderr, "warning: using cached baseline: %v\n", res.FetchErr)
+	}
+	merged, notes := templates.Merge(res.Template, t)
+	for _, n := range notes {
+		fmt.Fprintf(os.Stderr, "baseline: %s\n", n)
+	}
+	if t.Shared != nil {
+		merged.Shared, _ = templates.Merge(res.Template, t.Shared)
+	}
+	merged.Profile = cfg.Profile
+	return merged, cfg, nil
+}
+
+// newEnv builds the resource environment for t on this machine, logging
+// progress to stderr. Commands are supervised with the configured timeouts.
+func newEnv(t *templates.Template) *resource.Env {
+	cfg, _ := config.Load()
+	// Commands get the template's proxy and mirrors; engine passes them to
+	// MazIQ's own downloads.
+	var proxy []string
+	if t != nil {
+		proxy = t.Proxy.Environ()
+	}
+	return &resource.Env{
+		Runner:   shell.FromEnv(shell.Local{Timeout: cfg.Apply.Timeout, IdleTimeout: cfg.Apply.IdleTimeout, Env: proxy}),
+		Secrets:  secrets.Default(),
+		Facts:    facts.Detect(),
+		Template: t,
+		Logf: func(format string, args ...any) {
+			fmt.Fprintf(os.Stderr, "→ "+format+"\n", args...)
+		},
+	}
+}
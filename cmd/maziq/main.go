@@ -1,205 +1,126 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
 
-	tea "github.com/charmbracelet/bubbletea"
-	"github.com/charmbracelet/lipgloss"
+	"github.com/hmziqrs/maziq/internal/glyphs"
+	"github.com/hmziqrs/maziq/internal/tui"
 )
 
-// Styles
-var (
-	// Colors
-	primaryColor   = lipgloss.Color("#00D9FF")
-	secondaryColor = lipgloss.Color("#7C3AED")
-	accentColor    = lipgloss.Color("#10B981")
-	mutedColor     = lipgloss.Color("#6B7280")
-	errorColor     = lipgloss.Color("#EF4444")
-
-	// Title style
-	titleStyle = lipgloss.NewStyle().
-			Foreground(primaryColor).
-			Bold(true).
-			Padding(0, 1).
-			MarginTop(1).
-			MarginBottom(1)
-
-	// Logo ASCII art style
-	logoStyle = lipgloss.NewStyle().
-			Foreground(secondaryColor).
-			Bold(true)
-
-	// Subtitle style
-	subtitleStyle = lipgloss.NewStyle().
-			Foreground(mutedColor).
-			Italic(true).
-			MarginBottom(1)
-
-	// Box style for content sections
-	boxStyle = lipgloss.NewStyle().
-			Border(lipgloss.RoundedBorder()).
-			BorderForeground(primaryColor).
-			Padding(1, 2).
-			MarginTop(1).
-			MarginBottom(1)
-
-	// Menu item styles
-	menuItemStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#E5E7EB")).
-			PaddingLeft(2)
-
-	selectedMenuItemStyle = lipgloss.NewStyle().
-				Foreground(primaryColor).
-				Bold(true).
-				PaddingLeft(0)
-
-	// Help style
-	helpStyle = lipgloss.NewStyle().
-			Foreground(mutedColor).
-			Padding(1, 0)
-
-	// Status indicator styles
-	readyStyle = lipgloss.NewStyle().
-			Foreground(accentColor).
-			Bold(true)
-
-	errorStyle = lipgloss.NewStyle().
-			Foreground(errorColor).
-			Bold(true)
-)
-
-type model struct {
-	width         int
-	height        int
-	selectedMenu  int
-	menuItems     []string
-	ready         bool
-}
-
-func initialModel() model {
-	return model{
-		menuItems: []string{
-			"Software Catalog",
-			"Templates",
-			"E2E Testing",
-			"Configuration",
-		},
-		ready: true,
-	}
-}
-
-func (m model) Init() tea.Cmd {
-	return nil
+// command is a CLI subcommand. With no subcommand MazIQ starts the TUI.
+// The context run gets is cancelled on Ctrl+C or SIGTERM.
+type command struct {
+	name    string
+	summary string
+	// subcommands are the words the command accepts as its first argument,
+	// for shell completion.
+	subcommands []string
+	run         func(ctx context.Context, args []string) error
 }
 
-func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
-	switch msg := msg.(type) {
-	case tea.WindowSizeMsg:
-		m.width = msg.Width
-		m.height = msg.Height
-		return m, nil
+var commands []command
 
-	case tea.KeyMsg:
-		switch msg.String() {
-		case "ctrl+c", "q":
-			return m, tea.Quit
+// hidden commands are plumbing for scripts and are not listed in usage.
+func (c command) hidden() bool { return strings.HasPrefix(c.name, "__") }
 
-		case "up", "k":
-			if m.selectedMenu > 0 {
-				m.selectedMenu--
-			}
+// exitCode is returned by commands that have already reported their result
+// and only need the process to exit with a specific status.
+type exitCode int
 
-		case "down", "j":
-			if m.selectedMenu < len(m.menuItems)-1 {
-				m.selectedMenu++
-			}
+func (e exitCode) Error() string { return fmt.Sprintf("exit status %d", int(e)) }
 
-		case "enter", " ":
-			// TODO: Handle menu selection
+func main() {
+	if len(os.Args) < 2 && os.Getenv("ACCESSIBLE") == "" {
+		if err := tui.Run(); err != nil {
+			fmt.Printf("Error: %v", err)
+			os.Exit(1)
 		}
+		return
+	}
+	// In plain mode everything printed from here on is ASCII only.
+	flush := func() {}
+	if glyphs.Plain() {
+		flush = glyphs.Filter()
 	}
-	return m, nil
+	code := dispatch()
+	flush()
+	os.Exit(code)
 }
 
-func (m model) View() string {
-	if m.width == 0 {
-		return "Loading..."
+// dispatch runs the accessible interface or the command named by the
+// arguments and returns the exit status.
+func dispatch() int {
+	if len(os.Args) < 2 || os.Args[1] == "--accessible" {
+		// A screen-reader friendly, line-by-line version of the TUI.
+		ctx, stop := notifyContext()
+		defer stop()
+		if err := tui.RunAccessible(ctx, os.Stdin, os.Stdout); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return 1
+		}
+		return 0
 	}
 
-	var sections []string
-
-	// Logo and title
-	logo := logoStyle.Render(`
- ███╗   ███╗ █████╗ ███████╗██╗ ██████╗
- ████╗ ████║██╔══██╗╚══███╔╝██║██╔═══██╗
- ██╔████╔██║███████║  ███╔╝ ██║██║   ██║
- ██║╚██╔╝██║██╔══██║ ███╔╝  ██║██║▄▄ ██║
- ██║ ╚═╝ ██║██║  ██║███████╗██║╚██████╔╝
- ╚═╝     ╚═╝╚═╝  ╚═╝╚══════╝╚═╝ ╚══▀▀═╝ `)
-
-	subtitle := subtitleStyle.Render("macOS Provisioning & Automation Tool")
-
-	header := lipgloss.JoinVertical(lipgloss.Center, logo, subtitle)
-	sections = append(sections, header)
-
-	// Status indicator
-	var status string
-	if m.ready {
-		status = readyStyle.Render("● Ready")
-	} else {
-		status = errorStyle.Render("● Not Ready")
+	name, args := os.Args[1], os.Args[2:]
+	if name == "help" || name == "-h" || name == "--help" {
+		usage()
+		return 0
 	}
-	statusBox := boxStyle.Width(m.width - 4).Render(status)
-	sections = append(sections, statusBox)
-
-	// Menu
-	var menuItems []string
-	for i, item := range m.menuItems {
-		var renderedItem string
-		if i == m.selectedMenu {
-			renderedItem = selectedMenuItemStyle.Render("❯ " + item)
-		} else {
-			renderedItem = menuItemStyle.Render("  " + item)
+	for _, c := range commands {
+		if c.name == name {
+			ctx, stop := notifyContext()
+			err := c.run(ctx, args)
+			interrupted := ctx.Err() != nil
+			stop()
+			var code exitCode
+			switch {
+			case interrupted:
+				if err != nil && !errors.Is(err, context.Canceled) {
+					fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				}
+				fmt.Fprintln(os.Stderr, "Interrupted.")
+				return 130
+			case errors.As(err, &code):
+				return int(code)
+			case err != nil:
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				return 1
+			}
+			return 0
 		}
-		menuItems = append(menuItems, renderedItem)
 	}
-
-	menu := strings.Join(menuItems, "\n")
-	menuBox := boxStyle.
-		Width(m.width - 4).
-		Render(menu)
-	sections = append(sections, menuBox)
-
-	// Help text
-	help := helpStyle.Render(
-		"↑/↓ or j/k: Navigate • Enter: Select • q: Quit",
-	)
-	sections = append(sections, help)
-
-	// Join all sections
-	content := lipgloss.JoinVertical(lipgloss.Left, sections...)
-
-	// Center the content
-	return lipgloss.Place(
-		m.width,
-		m.height,
-		lipgloss.Center,
-		lipgloss.Center,
-		content,
-	)
+	fmt.Fprintf(os.Stderr, "unknown command %q\n\n", name)
+	usage()
+	return 2
 }
 
-func main() {
-	p := tea.NewProgram(
-		initialModel(),
-		tea.WithAltScreen(),
-		tea.WithMouseCellMotion(),
-	)
+// notifyContext returns a context cancelled on Ctrl+C or SIGTERM. After the
+// first signal, a second one kills MazIQ outright.
+func notifyContext() (context.Context, context.CancelFunc) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+	return ctx, stop
+}
 
-	if _, err := p.Run(); err != nil {
-		fmt.Printf("Error: %v", err)
-		os.Exit(1)
+func usage() {
+	fmt.Println("Usage: maziq [command] [flags]")
+	fmt.Println()
+	fmt.Println("Run without a command to start the interactive TUI, or with --accessible")
+	fmt.Println("(or ACCESSIBLE=1) for a line-by-line version that works with screen readers.")
+	fmt.Println()
+	fmt.Println("Commands:")
+	for _, c := range commands {
+		if !c.hidden() {
+			fmt.Printf("  %-12s %s\n", c.name, c.summary)
+		}
 	}
 }
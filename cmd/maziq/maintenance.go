@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	"github.com/hmziqrs/maziq/internal/config"
+	"github.com/hmziqrs/maziq/internal/maintenance"
+	"github.com/hmziqrs/maziq/internal/shell"
+)
+
+func init() {
+	commands = append(commands, command{
+		name:    "maintenance",
+		summary: "Run brew cleanup and prune old MazIQ bundles, caches and logs",
+		run:     runMaintenance,
+	})
+}
+
+func runMaintenance(ctx context.Context, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	fs := flag.NewFlagSet("maintenance", flag.ContinueOnError)
+	dryRun := fs.Bool("dry-run", false, "report what would be reclaimed without removing anything")
+	keep := fs.Int("keep-days", cfg.Maintenance.KeepDays, "keep files from the last `n` days (maintenance.keep_days)")
+	noBrew := fs.Bool("no-brew", false, "leave Homebrew's cache alone")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *keep < 1 {
+		return fmt.Errorf("--keep-days: %d (want at least 1)", *keep)
+	}
+	runner := shell.FromEnv(shell.Local{Timeout: cfg.Apply.Timeout, IdleTimeout: cfg.Apply.IdleTimeout})
+	results := maintenance.Run(ctx, runner, maintenance.Options{KeepDays: *keep, Brew: !*noBrew, DryRun: *dryRun})
+
+	failed := false
+	for _, r := range results {
+		switch {
+		case r.Err != nil:
+			failed = true
+			fmt.Printf("✗ %-20s %v\n", r.Task, r.Err)
+		case r.Skipped != "":
+			fmt.Printf("- %-20s %s\n", r.Task, r.Skipped)
+		case r.Removed == 0 && r.Freed == 0:
+			fmt.Printf("✓ %-20s nothing to reclaim\n", r.Task)
+		default:
+			fmt.Printf("✓ %-20s %d removed, %s\n", r.Task, r.Removed, config.Size(r.Freed))
+		}
+	}
+	verb := "Reclaimed"
+	if *dryRun {
+		verb = "Would reclaim"
+	}
+	fmt.Printf("\n%s %s, keeping the last %d days.\n", verb, config.Size(maintenance.Freed(results)), *keep)
+	if failed {
+		return exitCode(1)
+	}
+	return nil
+}
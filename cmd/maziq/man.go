@@ -0,0 +1,140 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hmziqrs/maziq/internal/selfupdate"
+	"github.com/hmziqrs/maziq/internal/templates"
+)
+
+func init() {
+	commands = append(commands, command{
+		name:    "man",
+		summary: "Write the maziq(1) and maziq-template(5) manual pages",
+		run:     runMan,
+	})
+}
+
+func runMan(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("man", flag.ContinueOnError)
+	dir := fs.String("dir", "man", "directory to write the pages to")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	pages := map[string][]byte{
+		filepath.Join("man1", "maziq.1"):          commandsPage(ctx),
+		filepath.Join("man5", "maziq-template.5"): templatePage(),
+	}
+	for name, page := range pages {
+		path := filepath.Join(*dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, page, 0o644); err != nil {
+			return err
+		}
+		fmt.Printf("✓ Wrote %s\n", path)
+	}
+	return nil
+}
+
+// commandsPage documents every command and the flags its FlagSet defines.
+func commandsPage(ctx context.Context) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, ".TH MAZIQ 1 \"\" \"maziq %s\" \"MazIQ Manual\"\n", roff(selfupdate.Version))
+	b.WriteString(".SH NAME\nmaziq \\- provision a Mac from a template and keep it converged\n")
+	b.WriteString(".SH SYNOPSIS\n.B maziq\n[\\fIcommand\\fR] [\\fIflags\\fR]\n")
+	b.WriteString(".SH DESCRIPTION\nRun without a command to start the interactive TUI.\n")
+	b.WriteString("Templates are described in\n.BR maziq-template (5),\nand section by section by\n.BR \"maziq explain\" .\n")
+	b.WriteString(".SH COMMANDS\n")
+	for i := range commands {
+		c := &commands[i]
+		if c.hidden() {
+			continue
+		}
+		keys := []string{c.name}
+		for _, s := range c.subcommands {
+			keys = append(keys, c.name+" "+s)
+		}
+		for _, key := range keys {
+			flags := commandFlags(ctx, c, key)
+			if key != c.name && len(flags) == 0 {
+				continue
+			}
+			fmt.Fprintf(&b, ".SS %s\n", roff(key))
+			if key == c.name {
+				fmt.Fprintf(&b, "%s.\n", roff(c.summary))
+			}
+			for _, f := range flags {
+				if f.arg != "" {
+					fmt.Fprintf(&b, ".TP\n.BI \"\\-\\-%s \" %s\n", roff(f.name), roff(f.arg))
+				} else {
+					fmt.Fprintf(&b, ".TP\n.B \\-\\-%s\n", roff(f.name))
+				}
+				fmt.Fprintf(&b, "%s\n", roff(f.usage))
+			}
+		}
+	}
+	b.WriteString(".SH FILES\n.TP\n.I ~/.maziq/config.toml\nConfiguration.\n")
+	b.WriteString(".TP\n.I ~/.maziq/templates\nUser templates, besides ./templates and the built-in ones.\n")
+	b.WriteString(".TP\n.I ~/.maziq/state\nWhat MazIQ has done to the machine.\n")
+	b.WriteString(".SH ENVIRONMENT\n.TP\n.B MAZIQ_HOME\nUsed instead of ~/.maziq.\n")
+	b.WriteString(".SH SEE ALSO\n.BR maziq-template (5)\n")
+	return b.Bytes()
+}
+
+// templatePage documents the template format from the same schema explain
+// prints.
+func templatePage() []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, ".TH MAZIQ-TEMPLATE 5 \"\" \"maziq %s\" \"MazIQ Manual\"\n", roff(selfupdate.Version))
+	b.WriteString(".SH NAME\nmaziq\\-template \\- MazIQ template file format\n")
+	b.WriteString(".SH DESCRIPTION\nA template is a TOML file describing software and settings for a Mac.\n")
+	b.WriteString("Unknown keys are rejected when the template is loaded;\n.B maziq template lint\nchecks the rest.\n")
+	b.WriteString(".SH CONDITIONS\n")
+	for _, line := range strings.Split(conditionHelp(), "\n") {
+		if name, rest, ok := strings.Cut(strings.TrimSpace(line), " "); ok && strings.HasPrefix(line, "  ") {
+			fmt.Fprintf(&b, ".TP\n.B %s\n%s\n", roff(name), roff(strings.TrimSpace(rest)))
+		} else {
+			fmt.Fprintf(&b, "%s\n", roff(line))
+		}
+	}
+	b.WriteString(".SH SECTIONS\n")
+	for _, s := range templates.Sections() {
+		fmt.Fprintf(&b, ".SS %s\n", roff(s.Key))
+		fmt.Fprintf(&b, "Type: %s.\n", roff(s.Type))
+		if s.Conditional {
+			b.WriteString("Entries take a\n.B when\ncondition.\n")
+		}
+		for _, para := range strings.Split(s.Doc, "\n\n") {
+			fmt.Fprintf(&b, ".PP\n%s\n", roff(para))
+		}
+		for _, f := range s.Fields {
+			fmt.Fprintf(&b, ".TP\n.B %s\n(%s) %s\n", roff(f.Key), roff(f.Type), roff(f.Doc))
+		}
+		if s.Example != "" {
+			fmt.Fprintf(&b, ".PP\nExample:\n.PP\n.RS\n.nf\n%s\n.fi\n.RE\n", roff(s.Example))
+		}
+	}
+	b.WriteString(".SH SEE ALSO\n.BR maziq (1)\n")
+	return b.Bytes()
+}
+
+// roff escapes text for a man page body line.
+func roff(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\e`)
+	s = strings.ReplaceAll(s, "-", `\-`)
+	lines := strings.Split(s, "\n")
+	for i, l := range lines {
+		if strings.HasPrefix(l, ".") || strings.HasPrefix(l, "'") {
+			lines[i] = `\&` + l
+		}
+	}
+	return strings.Join(lines, "\n")
+}
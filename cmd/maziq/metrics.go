@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/hmziqrs/maziq/internal/config"
+	"github.com/hmziqrs/maziq/internal/metrics"
+)
+
+func init() {
+	commands = append(commands, command{
+		name:        "metrics",
+		summary:     "Show, export or clear opt-in local apply statistics",
+		subcommands: []string{"show", "export", "clear"},
+		run:         runMetrics,
+	})
+}
+
+func runMetrics(ctx context.Context, args []string) error {
+	sub := "show"
+	if len(args) > 0 {
+		sub, args = args[0], args[1:]
+	}
+	switch sub {
+	case "show":
+		return showMetrics()
+	case "export":
+		return exportMetrics(args)
+	case "clear":
+		if err := metrics.Clear(); err != nil {
+			return err
+		}
+		fmt.Println("✓ Cleared recorded runs.")
+		return nil
+	}
+	return errors.New("usage: maziq metrics [show | export [--format json|csv] | clear]")
+}
+
+func showMetrics() error {
+	if !metrics.Enabled() {
+		fmt.Printf("Metrics are off. Add this to %s to record apply statistics locally:\n\n[metrics]\nenabled = true\n\n", config.Path())
+	}
+	runs, err := metrics.Load()
+	if err != nil {
+		return err
+	}
+	if len(runs) == 0 {
+		fmt.Println("No runs recorded.")
+		return nil
+	}
+	s := metrics.Summarize(runs)
+	fmt.Printf("%d apply runs since %s, %d with failures, %s on average\n\n",
+		s.Runs, s.Since.Format("2006-01-02"), s.FailedRuns, s.Mean.Round(time.Second))
+	fmt.Printf("%-14s %8s %8s %9s %10s\n", "BACKEND", "APPLIED", "FAILED", "FAIL RATE", "AVG TIME")
+	for _, name := range s.BackendNames() {
+		c := s.Backends[name]
+		avg := time.Duration(0)
+		if n := c.Applied + c.Failed; n > 0 {
+			avg = time.Duration(c.DurationMS/int64(n)) * time.Millisecond
+		}
+		fmt.Printf("%-14s %8d %8d %8.1f%% %10s\n", name, c.Applied, c.Failed, 100*c.FailureRate(), avg.Round(100*time.Millisecond))
+	}
+	return nil
+}
+
+// exportMetrics writes the raw runs, one object per run as JSON or one row
+// per run and backend as CSV, for pooling across a team.
+func exportMetrics(args []string) error {
+	fs := flag.NewFlagSet("metrics export", flag.ContinueOnError)
+	format := fs.String("format", "json", "json or csv")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	runs, err := metrics.Load()
+	if err != nil {
+		return err
+	}
+	switch *format {
+	case "json":
+		if runs == nil {
+			runs = []metrics.Run{}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(runs)
+	case "csv":
+		w := csv.NewWriter(os.Stdout)
+		_ = w.Write([]string{"timestamp", "version", "macos", "arch", "run_duration_ms", "backend", "applied", "failed", "duration_ms"})
+		for _, r := range runs {
+			names := make([]string, 0, len(r.Backends))
+			for n := range r.Backends {
+				names = append(names, n)
+			}
+			sort.Strings(names)
+			for _, n := range names {
+				c := r.Backends[n]
+				_ = w.Write([]string{
+					time.Unix(r.Timestamp, 0).UTC().Format(time.RFC3339), r.Version, r.MacOS, r.Arch,
+					strconv.FormatInt(r.DurationMS, 10), n,
+					strconv.Itoa(c.Applied), strconv.Itoa(c.Failed), strconv.FormatInt(c.DurationMS, 10),
+				})
+			}
+		}
+		w.Flush()
+		return w.Error()
+	}
+	return fmt.Errorf("unknown format %q; use json or csv", *format)
+}
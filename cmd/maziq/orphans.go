@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/hmziqrs/maziq/internal/orphans"
+)
+
+func init() {
+	commands = append(commands, command{
+		name:    "orphans",
+		summary: "Find and remove formulae left behind by packages the template dropped",
+		run:     runOrphans,
+	})
+}
+
+func runOrphans(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("orphans", flag.ContinueOnError)
+	ref := fs.String("template", "", "template name or path (default from config)")
+	remove := fs.Bool("remove", false, "uninstall the orphans that nothing installed needs")
+	dryRun := fs.Bool("dry-run", false, "with --remove, show the brew command without running it")
+	yes := fs.Bool("yes", false, "do not ask for confirmation")
+	asJSON := fs.Bool("json", false, "print the report as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	t, _, err := loadTemplate(ctx, *ref)
+	if err != nil {
+		return err
+	}
+	env := newEnv(t)
+	rep, err := orphans.Find(ctx, env)
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		if rep.Orphans == nil {
+			rep.Orphans = []orphans.Orphan{}
+		}
+		if rep.Dropped == nil {
+			rep.Dropped = []string{}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(rep)
+	}
+	printOrphans(rep, t.Name)
+	removable := rep.Removable()
+	if len(removable) == 0 {
+		return nil
+	}
+	if !*remove {
+		fmt.Printf("\nRun `maziq orphans --remove` to uninstall the %d that nothing installed needs.\n", len(removable))
+		return nil
+	}
+	if *dryRun {
+		fmt.Println("\nWould run: brew uninstall --formula " + strings.Join(removable, " "))
+		return nil
+	}
+	if !*yes && !confirm(ctx, fmt.Sprintf("Uninstall %d formula(e)?", len(removable))) {
+		return exitCode(1)
+	}
+	if err := orphans.Remove(ctx, env.Runner, removable); err != nil {
+		return err
+	}
+	fmt.Printf("✓ Uninstalled %s\n", strings.Join(removable, ", "))
+	return nil
+}
+
+func printOrphans(rep orphans.Report, template string) {
+	if rep.Skipped != "" {
+		fmt.Println(rep.Skipped + "; nothing to check.")
+		return
+	}
+	if len(rep.Orphans) == 0 {
+		fmt.Printf("Every formula Homebrew installed is in %s or needed by something that is.\n", template)
+	} else {
+		fmt.Printf("%d formula(e) installed only as dependencies of packages %s does not have:\n\n", len(rep.Orphans), template)
+		for _, o := range rep.Orphans {
+			if o.Removable() {
+				fmt.Printf("  %s\n", o.Formula)
+			} else {
+				fmt.Printf("  %-24s needed by %s\n", o.Formula, strings.Join(o.NeededBy, ", "))
+			}
+		}
+	}
+	if len(rep.Dropped) > 0 {
+		fmt.Printf("\nInstalled by MazIQ and since dropped from %s: %s\n", template, strings.Join(rep.Dropped, ", "))
+		fmt.Println("Uninstall them with brew, or add them back, to settle the formulae they need.")
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"sort"
+
+	"github.com/hmziqrs/maziq/internal/quarantine"
+)
+
+func init() {
+	commands = append(commands, command{
+		name:        "quarantine",
+		summary:     "List or clear resources that applies skip after failing repeatedly",
+		subcommands: []string{"list", "clear"},
+		run:         runQuarantine,
+	})
+}
+
+func runQuarantine(ctx context.Context, args []string) error {
+	sub := "list"
+	if len(args) > 0 {
+		sub, args = args[0], args[1:]
+	}
+	switch sub {
+	case "list":
+		return listQuarantine()
+	case "clear":
+		return clearQuarantine(args)
+	}
+	return errors.New("usage: maziq quarantine [list | clear [--all] [id...]]")
+}
+
+func listQuarantine() error {
+	records, err := quarantine.Load()
+	if err != nil {
+		return err
+	}
+	ids := make([]string, 0, len(records))
+	for id := range records {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	var failing int
+	for _, id := range ids {
+		r := records[id]
+		if !r.Quarantined() {
+			failing++
+			continue
+		}
+		fmt.Printf("%s: quarantined %s after %d failed applies\n", id, r.Since.Local().Format("2006-01-02 15:04"), r.Failures)
+		if n := len(r.Errors); n > 0 {
+			fmt.Printf("    last error: %s\n", r.Errors[n-1].Error)
+		}
+		if r.Bundle != "" {
+			fmt.Printf("    diagnostics: %s\n", r.Bundle)
+		}
+	}
+	if len(ids) == failing {
+		fmt.Println("Nothing is quarantined.")
+	}
+	if failing > 0 {
+		fmt.Printf("%d other resource(s) failed their last apply.\n", failing)
+	}
+	return nil
+}
+
+func clearQuarantine(args []string) error {
+	fs := flag.NewFlagSet("quarantine clear", flag.ContinueOnError)
+	all := fs.Bool("all", false, "clear every quarantined resource")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	ids := fs.Args()
+	records, err := quarantine.Load()
+	if err != nil {
+		return err
+	}
+	if *all {
+		ids = ids[:0]
+		for id := range records {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+	}
+	if len(ids) == 0 {
+		return errors.New("usage: maziq quarantine clear [--all] [id...]")
+	}
+	for _, id := range ids {
+		if _, ok := records[id]; !ok {
+			return fmt.Errorf("%s is not quarantined", id)
+		}
+		if err := quarantine.Clear(id); err != nil {
+			return err
+		}
+		fmt.Printf("✓ %s will be applied again\n", id)
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hmziqrs/maziq/internal/modules/defaults"
+	"github.com/hmziqrs/maziq/internal/resource"
+	"github.com/hmziqrs/maziq/internal/shell"
+)
+
+// followUp performs the restarts applied changes need. With ask set it lists
+// them and asks first; otherwise processes are relaunched right away and a
+// logout or reboot is only suggested.
+func followUp(ctx context.Context, env *resource.Env, r resource.Restart, ask, quiet bool) error {
+	if r.Empty() {
+		return nil
+	}
+	if !quiet {
+		fmt.Println("\nSome changes take effect after a restart:")
+		if len(r.Processes) > 0 {
+			fmt.Printf("  ↻ relaunch %s\n", strings.Join(r.Processes, ", "))
+		}
+		switch {
+		case r.Reboot:
+			fmt.Println("  ↻ reboot this Mac")
+		case r.Logout:
+			fmt.Println("  ↻ log out and back in")
+		}
+		fmt.Println()
+	}
+
+	if len(r.Processes) > 0 && (!ask || confirm(ctx, fmt.Sprintf("Relaunch %s now?", strings.Join(r.Processes, ", ")))) {
+		defaults.Relaunch(ctx, env, r.Processes)
+	}
+	switch {
+	case r.Reboot && ask:
+		return askReboot(ctx, env)
+	case r.Reboot:
+		if !quiet {
+			fmt.Println("Reboot when convenient: sudo shutdown -r now")
+		}
+	case r.Logout && ask:
+		if confirm(ctx, "Log out now? Unsaved work in other apps may be lost.") {
+			_, err := env.Run(ctx, shell.Cmd("osascript", "-e", `tell application "System Events" to log out`))
+			return err
+		}
+	case r.Logout:
+		if !quiet {
+			fmt.Println("Log out and back in when convenient.")
+		}
+	}
+	return nil
+}
+
+// askReboot offers to reboot now or schedule it in a number of minutes.
+func askReboot(ctx context.Context, env *resource.Env) error {
+	fmt.Print("Reboot now, in how many minutes, or later? [now/<minutes>/Later] ")
+	var answer string
+	select {
+	case line := <-stdinLines():
+		answer = strings.ToLower(strings.TrimSpace(line))
+	case <-ctx.Done():
+		fmt.Println()
+	}
+	when := ""
+	switch minutes, err := strconv.Atoi(answer); {
+	case answer == "now":
+		when = "now"
+	case err == nil && minutes > 0:
+		when = "+" + answer
+	default:
+		fmt.Println("Reboot when convenient: sudo shutdown -r now")
+		return nil
+	}
+	if _, err := env.Run(ctx, shell.Command{Name: "shutdown", Args: []string{"-r", when}, Sudo: true}); err != nil {
+		return err
+	}
+	if when != "now" {
+		fmt.Printf("Reboot scheduled in %s minute(s); cancel it with: sudo killall shutdown\n", answer)
+	}
+	return nil
+}
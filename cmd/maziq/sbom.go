@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/hmziqrs/maziq/internal/engine"
+	"github.com/hmziqrs/maziq/internal/sbom"
+	"github.com/hmziqrs/maziq/internal/selfupdate"
+)
+
+func init() {
+	commands = append(commands, command{
+		name:    "sbom",
+		summary: "Write a CycloneDX or SPDX bill of materials for the installed software",
+		run:     runSBOM,
+	})
+}
+
+func runSBOM(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("sbom", flag.ContinueOnError)
+	ref := fs.String("template", "", "template name or path (default from config)")
+	format := fs.String("format", sbom.FormatCycloneDX, "cyclonedx or spdx")
+	out := fs.String("output", "", "write to this `file` instead of stdout")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	t, _, err := loadTemplate(ctx, *ref)
+	if err != nil {
+		return err
+	}
+	env := newEnv(t)
+	components, err := engine.Components(ctx, env, warnComponent)
+	if err != nil {
+		return err
+	}
+	doc := sbom.Document{Template: t.Name, Host: env.Facts["hostname"], Created: time.Now(), Tool: selfupdate.Version, Components: components}
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	if err := doc.Write(w, *format); err != nil {
+		return err
+	}
+	if *out != "" {
+		fmt.Printf("✓ Wrote %s with %d component(s)\n", *out, len(doc.Components))
+	}
+	return nil
+}
+
+// warnComponent reports a resource that could not describe what it installed.
+func warnComponent(id string, err error) {
+	fmt.Fprintf(os.Stderr, "warning: %s: %v\n", id, err)
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/hmziqrs/maziq/internal/secrets"
+	"github.com/hmziqrs/maziq/internal/templates"
+)
+
+func init() {
+	commands = append(commands, command{
+		name:        "secrets",
+		summary:     "Manage the template key and encrypt sensitive sections",
+		subcommands: []string{"keygen", "encrypt", "decrypt"},
+		run:         runSecrets,
+	})
+}
+
+// sectionFlag collects repeated --section flags.
+type sectionFlag []string
+
+func (s *sectionFlag) String() string     { return strings.Join(*s, ",") }
+func (s *sectionFlag) Set(v string) error { *s = append(*s, v); return nil }
+
+func runSecrets(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: maziq secrets <keygen|encrypt|decrypt> [flags]")
+	}
+	switch args[0] {
+	case "keygen":
+		return runSecretsKeygen(ctx, args[1:])
+	case "encrypt", "decrypt":
+		return runSecretsCrypt(ctx, args[0], args[1:])
+	}
+	return fmt.Errorf("unknown secrets subcommand %q", args[0])
+}
+
+func runSecretsKeygen(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("secrets keygen", flag.ContinueOnError)
+	force := fs.Bool("force", false, "replace an existing key (existing ciphertext becomes unreadable)")
+	show := fs.Bool("print", false, "print the key so it can be shared with teammates")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	kc := secrets.Keychain{}
+	if _, err := kc.Get(ctx, secrets.KeyName); err == nil && !*force {
+		return errors.New("a template key already exists in the Keychain; use --force to replace it")
+	}
+	key, err := secrets.GenerateKey()
+	if err != nil {
+		return err
+	}
+	if err := kc.Set(ctx, secrets.KeyName, key); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Stored template key in Keychain (service %q, account %q)\n", secrets.Service, secrets.KeyName)
+	if *show {
+		fmt.Println(key)
+	}
+	return nil
+}
+
+// runSecretsCrypt encrypts a template in place, or prints it decrypted.
+// Decryption never writes plaintext back to disk.
+func runSecretsCrypt(ctx context.Context, op string, args []string) error {
+	fs := flag.NewFlagSet("secrets "+op, flag.ContinueOnError)
+	var sections sectionFlag
+	fs.Var(&sections, "section", "table to process (repeatable, default: vars)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: maziq secrets %s [--section name]... <template.toml>", op)
+	}
+	if len(sections) == 0 {
+		sections = templates.EncryptedSections
+	}
+	for _, s := range sections {
+		if _, ok := templates.LookupSection(s); !ok {
+			return fmt.Errorf("--section %s: not a template section", s)
+		}
+	}
+	path := fs.Arg(0)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	key, err := secrets.LoadKey(ctx, secrets.Default())
+	if err != nil {
+		return err
+	}
+
+	if op == "decrypt" {
+		out, _, err := secrets.DecryptFile(data, sections, key)
+		if err != nil {
+			return err
+		}
+		_, err = os.Stdout.Write(out)
+		return err
+	}
+
+	out, n, err := secrets.EncryptFile(data, sections, key)
+	if err != nil {
+		return err
+	}
+	if _, err := templates.Parse(out, path); err != nil {
+		return fmt.Errorf("refusing to write: encrypted template no longer parses: %w", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, out, info.Mode().Perm()); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Encrypted %d value(s) in %s\n", n, path)
+	return nil
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"runtime"
+
+	"github.com/hmziqrs/maziq/internal/selfupdate"
+	"github.com/hmziqrs/maziq/internal/shell"
+)
+
+func init() {
+	commands = append(commands,
+		command{
+			name:    "version",
+			summary: "Print the maziq version",
+			run:     runVersion,
+		},
+		command{
+			name:    "self-update",
+			summary: "Replace maziq with the latest release",
+			run:     runSelfUpdate,
+		},
+	)
+}
+
+func runVersion(ctx context.Context, args []string) error {
+	fmt.Println("maziq", selfupdate.Version)
+	return nil
+}
+
+func runSelfUpdate(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("self-update", flag.ContinueOnError)
+	check := fs.Bool("check", false, "only report whether a newer release exists")
+	yes := fs.Bool("yes", false, "do not ask for confirmation")
+	force := fs.Bool("force", false, "install the latest release even if it is not newer")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	a, err := selfupdate.Check(ctx)
+	if err != nil {
+		return err
+	}
+	switch {
+	case a.Newer():
+		fmt.Printf("maziq %s is available (running %s).\n", a.Latest, a.Current)
+	case a.Current == "dev" && !*force:
+		fmt.Printf("Latest release is %s.\n", a.Latest)
+		return fmt.Errorf("%w; use --force to install %s", selfupdate.ErrDevBuild, a.Latest)
+	case !*force:
+		fmt.Printf("✓ maziq %s is the latest release.\n", a.Current)
+		return nil
+	}
+	if *check {
+		return nil
+	}
+	if !*yes && !confirm(ctx, fmt.Sprintf("Install maziq %s?", a.Latest)) {
+		return exitCode(1)
+	}
+	exe, err := selfupdate.Apply(ctx, shell.Local{}, a.Release, runtime.GOARCH)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("✓ Replaced %s with maziq %s.\n", exe, a.Latest)
+	return nil
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/hmziqrs/maziq/internal/modules/jobs"
+	"github.com/hmziqrs/maziq/internal/modules/services"
+	"github.com/hmziqrs/maziq/internal/templates"
+)
+
+func init() {
+	commands = append(commands, command{
+		name:    "services",
+		summary: "List Homebrew services and scheduled jobs and the state the template wants",
+		run:     runServices,
+	})
+}
+
+func runServices(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("services", flag.ContinueOnError)
+	ref := fs.String("template", "", "template name or path (default from config)")
+	asJSON := fs.Bool("json", false, "print services as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	t, _, err := loadTemplate(ctx, *ref)
+	if err != nil {
+		return err
+	}
+	env := newEnv(t)
+	list, err := services.List(ctx, env)
+	if err != nil {
+		return err
+	}
+	wanted := map[string]string{}
+	for _, s := range t.Services {
+		if ok, err := env.Holds(s.When); err == nil && ok {
+			wanted[s.Name] = s.State
+			if wanted[s.Name] == "" {
+				wanted[s.Name] = templates.ServiceStarted
+			}
+		}
+	}
+
+	type row struct {
+		services.Status
+		Desired string `json:"desired,omitempty"`
+		// Scheduled jobs only.
+		Schedule string     `json:"schedule,omitempty"`
+		NextRun  *time.Time `json:"next_run,omitempty"`
+		LastExit *int       `json:"last_exit,omitempty"`
+	}
+	var rows []row
+	for _, s := range list {
+		rows = append(rows, row{Status: s, Desired: wanted[s.Name]})
+		delete(wanted, s.Name)
+	}
+	missing := make([]string, 0, len(wanted))
+	for name := range wanted {
+		missing = append(missing, name)
+	}
+	sort.Strings(missing)
+	for _, name := range missing {
+		rows = append(rows, row{Status: services.Status{Name: name, Status: "not installed"}, Desired: wanted[name]})
+	}
+	scheduled, err := jobs.List(ctx, env)
+	if err != nil {
+		return err
+	}
+	for _, j := range scheduled {
+		r := row{Status: services.Status{Name: j.Name, Status: "not loaded", File: j.Log}, Desired: "loaded", Schedule: j.Schedule, LastExit: j.LastExit}
+		if j.Loaded {
+			r.Status.Status = "loaded"
+		}
+		if !j.NextRun.IsZero() {
+			r.NextRun = &j.NextRun
+		}
+		rows = append(rows, r)
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(rows)
+	}
+	for _, r := range rows {
+		mark := " "
+		switch {
+		case r.Desired == "":
+		case r.Desired == templates.ServiceStarted && r.Status.Status == "started",
+			r.Desired == templates.ServiceStopped && r.Status.Status != "started":
+			mark = "✓"
+		default:
+			mark = "✗"
+		}
+		if r.Schedule == "" {
+			fmt.Printf("%s %-24s %-14s %s\n", mark, r.Name, r.Status.Status, r.Desired)
+			continue
+		}
+		if r.Status.Status == "loaded" {
+			mark = "✓"
+		}
+		detail := r.Schedule
+		if r.NextRun != nil {
+			detail += ", next " + r.NextRun.Format("Mon Jan 2 15:04")
+		}
+		switch {
+		case r.LastExit == nil:
+			detail += ", not run yet"
+		case *r.LastExit == 0:
+			detail += ", last run ok"
+		default:
+			detail += fmt.Sprintf(", last run exited %d", *r.LastExit)
+		}
+		fmt.Printf("%s %-24s %-14s %s\n", mark, "job "+r.Name, r.Status.Status, detail)
+	}
+	return nil
+}
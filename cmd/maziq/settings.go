@@ -0,0 +1,37 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	"github.com/hmziqrs/maziq/internal/shell"
+	"github.com/hmziqrs/maziq/internal/sysprefs"
+)
+
+func init() {
+	commands = append(commands, command{
+		name:    "settings",
+		summary: "Open a System Settings pane, or list the known panes",
+		run:     runSettings,
+	})
+}
+
+func runSettings(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("settings", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	switch fs.NArg() {
+	case 0:
+		for _, name := range sysprefs.Names() {
+			p, _ := sysprefs.Lookup(name)
+			fmt.Printf("%-20s %s\n", p.Name, p.Title)
+		}
+		return nil
+	case 1:
+		return sysprefs.Open(ctx, shell.Local{}, fs.Arg(0))
+	default:
+		return fmt.Errorf("usage: maziq settings [pane]")
+	}
+}
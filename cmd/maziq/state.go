@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/hmziqrs/maziq/internal/state"
+)
+
+func init() {
+	commands = append(commands, command{
+		name:        "state",
+		summary:     "Check, repair or compact MazIQ's state store",
+		subcommands: []string{"check", "repair", "compact"},
+		run:         runState,
+	})
+}
+
+func runState(ctx context.Context, args []string) error {
+	sub := "check"
+	if len(args) > 0 {
+		sub = args[0]
+	}
+	switch sub {
+	case "check":
+		s, err := state.Check()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s: %d keys, %d journal entries since the last compaction\n", state.Dir(), s.Keys, s.Entries)
+		for _, p := range s.Problems {
+			fmt.Printf("  ✗ %v\n", p)
+		}
+		if s.Torn {
+			fmt.Println("  ! the journal ends in a partial entry from an interrupted write; the next change drops it")
+		}
+		if len(s.Problems) > 0 {
+			fmt.Println("\nRun maziq state repair to keep what is intact.")
+			return exitCode(1)
+		}
+		fmt.Println("✓ No problems found.")
+		return nil
+	case "repair":
+		n, err := state.Repair()
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			fmt.Println("✓ Nothing to repair.")
+			return nil
+		}
+		fmt.Printf("✓ Dropped %d damaged part(s); the originals are kept in %s with a .corrupt suffix.\n", n, state.Dir())
+		return nil
+	case "compact":
+		if err := state.Compact(); err != nil {
+			return err
+		}
+		fmt.Println("✓ Compacted.")
+		return nil
+	}
+	return errors.New("usage: maziq state [check | repair | compact]")
+}
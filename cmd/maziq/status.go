@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/hmziqrs/maziq/internal/daemon"
+	"github.com/hmziqrs/maziq/internal/health"
+	"github.com/hmziqrs/maziq/internal/status"
+	"github.com/hmziqrs/maziq/internal/tui"
+)
+
+func init() {
+	commands = append(commands, command{
+		name:    "status",
+		summary: "Show drift, outdated software and health (read-only)",
+		run:     runStatus,
+	})
+}
+
+func runStatus(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("status", flag.ContinueOnError)
+	ref := fs.String("template", "", "template name or path (default from config)")
+	watch := fs.Bool("watch", false, "show a live, read-only dashboard")
+	interval := fs.Duration("interval", 30*time.Second, "refresh interval for --watch")
+	asJSON := fs.Bool("json", false, "print the snapshot as JSON (same as --format json)")
+	format := fs.String("format", "text", "text, json or swiftbar")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *asJSON {
+		*format = "json"
+	}
+	if *format != "text" && *format != "json" && *format != "swiftbar" {
+		return fmt.Errorf("unknown format %q; use text, json or swiftbar", *format)
+	}
+
+	// Menu bar plugins refresh often; a running daemon saves them loading
+	// the template and baseline on every refresh.
+	if c, err := daemon.Dial(daemon.SocketPath()); err == nil && !*watch {
+		snap, err := c.Status(ctx, *ref)
+		if err != nil {
+			return err
+		}
+		return printStatus(snap, *format)
+	}
+
+	t, _, err := loadTemplate(ctx, *ref)
+	if err != nil {
+		return err
+	}
+	collect := func(ctx context.Context) status.Snapshot { return status.Collect(ctx, t) }
+
+	if *watch {
+		return tui.RunDashboard(collect, *interval)
+	}
+	return printStatus(collect(ctx), *format)
+}
+
+func printStatus(snap status.Snapshot, format string) error {
+	switch format {
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(snap)
+	case "swiftbar":
+		printSwiftBar(snap)
+	default:
+		printSnapshot(snap)
+	}
+	return nil
+}
+
+// printSwiftBar prints the snapshot as a SwiftBar (or xbar) plugin: a title
+// with the drift and outdated counts, then a menu listing them with actions
+// that open a terminal.
+func printSwiftBar(s status.Snapshot) {
+	switch {
+	case !s.Healthy() || len(s.Errors) > 0:
+		fmt.Println("MazIQ ✗ | color=red")
+	case len(s.Drift) > 0 || len(s.Outdated) > 0:
+		fmt.Printf("MazIQ %d↯ %d↑ | color=orange\n", len(s.Drift), len(s.Outdated))
+	default:
+		fmt.Println("MazIQ ✓")
+	}
+	fmt.Println("---")
+	fmt.Printf("%s: %d/%d installed\n", s.Template, s.Installed(), len(s.Software))
+	if len(s.Drift) > 0 {
+		fmt.Printf("Drift: %d\n", len(s.Drift))
+		for _, id := range s.Drift {
+			fmt.Printf("--%s | color=red\n", id)
+		}
+	}
+	if len(s.Outdated) > 0 {
+		fmt.Printf("Outdated: %d\n", len(s.Outdated))
+		ids := make([]string, 0, len(s.Outdated))
+		for id := range s.Outdated {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		for _, id := range ids {
+			fmt.Printf("--%s → %s\n", id, s.Outdated[id])
+		}
+	}
+	for _, h := range s.Health {
+		if h.Level == health.Fail {
+			fmt.Printf("✗ %s | color=red\n", h.Name)
+		}
+	}
+	for _, e := range s.Errors {
+		fmt.Printf("error: %s | color=red\n", e)
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		exe = "maziq"
+	}
+	fmt.Println("---")
+	fmt.Printf("Apply… | bash=%q param1=apply terminal=true refresh=true\n", exe)
+	fmt.Printf("Open MazIQ | bash=%q terminal=true\n", exe)
+	fmt.Println("Refresh | refresh=true")
+}
+
+func printSnapshot(s status.Snapshot) {
+	fmt.Printf("Template:  %s\n", s.Template)
+	fmt.Printf("Installed: %d/%d\n", s.Installed(), len(s.Software))
+	fmt.Printf("Drift:     %d\n", len(s.Drift))
+	for _, id := range s.Drift {
+		fmt.Printf("  ✗ %s\n", id)
+	}
+	fmt.Printf("Outdated:  %d\n", len(s.Outdated))
+	ids := make([]string, 0, len(s.Outdated))
+	for id := range s.Outdated {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		fmt.Printf("  ↑ %s → %s\n", id, s.Outdated[id])
+	}
+	fmt.Println("Health:")
+	for _, h := range s.Health {
+		mark := map[health.Level]string{health.OK: "✓", health.Warn: "!", health.Fail: "✗"}[h.Level]
+		fmt.Printf("  %s %s", mark, h.Name)
+		if h.Detail != "" {
+			fmt.Printf(" (%s)", h.Detail)
+		}
+		fmt.Println()
+	}
+	if s.LastApply != nil {
+		fmt.Printf("Last run:  %s %s at %s\n", s.LastApply.Action, s.LastApply.Software, s.LastApply.Time().Format(time.RFC3339))
+	}
+	for _, e := range s.Errors {
+		fmt.Printf("error: %s\n", e)
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/hmziqrs/maziq/internal/templates"
+)
+
+func init() {
+	commands = append(commands, command{
+		name:        "template",
+		summary:     "List and lint templates",
+		subcommands: []string{"list", "lint"},
+		run:         runTemplate,
+	})
+}
+
+func runTemplate(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: maziq template <list|lint> [flags]")
+	}
+	switch args[0] {
+	case "list":
+		for _, name := range templates.List() {
+			fmt.Println(name)
+		}
+		return nil
+	case "lint":
+		return runTemplateLint(ctx, args[1:])
+	}
+	return fmt.Errorf("unknown template subcommand %q", args[0])
+}
+
+func runTemplateLint(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("template lint", flag.ContinueOnError)
+	strict := fs.Bool("strict", false, "treat warnings as failures")
+	asJSON := fs.Bool("json", false, "print findings as JSON")
+	verbose := fs.Bool("v", false, "include informational findings")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	refs := fs.Args()
+	if len(refs) == 0 {
+		refs = templates.List()
+	}
+
+	failMin := templates.SeverityError
+	if *strict {
+		failMin = templates.SeverityWarning
+	}
+	showMin := templates.SeverityWarning
+	if *verbose {
+		showMin = templates.SeverityInfo
+	}
+
+	type report struct {
+		Template string              `json:"template"`
+		Path     string              `json:"path,omitempty"`
+		Error    string              `json:"error,omitempty"`
+		Findings []templates.Finding `json:"findings"`
+	}
+	var reports []report
+	failed := false
+	for _, ref := range refs {
+		r := report{Template: ref, Findings: []templates.Finding{}}
+		t, err := templates.Resolve(ref)
+		if err != nil {
+			r.Error = err.Error()
+			failed = true
+		} else {
+			r.Path = t.Path
+			for _, f := range templates.Lint(t) {
+				if f.Severity >= showMin || *asJSON {
+					r.Findings = append(r.Findings, f)
+				}
+				if f.Severity >= failMin {
+					failed = true
+				}
+			}
+		}
+		reports = append(reports, r)
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(reports); err != nil {
+			return err
+		}
+	} else {
+		for _, r := range reports {
+			switch {
+			case r.Error != "":
+				fmt.Printf("✗ %s: %s\n", r.Template, r.Error)
+			case len(r.Findings) == 0:
+				fmt.Printf("✓ %s\n", r.Template)
+			default:
+				fmt.Printf("%s (%s)\n", r.Template, r.Path)
+				for _, f := range r.Findings {
+					fmt.Printf("  %s\n", f)
+				}
+			}
+		}
+	}
+	if failed {
+		return exitCode(1)
+	}
+	return nil
+}
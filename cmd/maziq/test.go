@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/hmziqrs/maziq/internal/e2e"
+	"github.com/hmziqrs/maziq/internal/engine"
+	"github.com/hmziqrs/maziq/internal/resource"
+)
+
+func init() {
+	commands = append(commands, command{
+		name:    "test",
+		summary: "Run the template's E2E assertions",
+		run:     runTest,
+	})
+}
+
+func runTest(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	ref := fs.String("template", "", "template name or path (default from config)")
+	filter := fs.String("run", "", "only run cases whose name contains this string")
+	asJSON := fs.Bool("json", false, "print results as JSON")
+	matrix := fs.Bool("matrix", false, "run the cases of the [[matrix]] platforms this machine is and report the whole matrix")
+	var merge []string
+	fs.Func("merge", "with --matrix, take results from the `file` of another platform's test --matrix --json (repeatable)", func(s string) error {
+		merge = append(merge, s)
+		return nil
+	})
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	t, _, err := loadTemplate(ctx, *ref)
+	if err != nil {
+		return err
+	}
+	env := newEnv(t)
+	if *matrix {
+		return runTestMatrix(ctx, env, *filter, merge, *asJSON)
+	}
+	rs, err := engine.Resources(ctx, env)
+	if err != nil {
+		return err
+	}
+	cases, err := e2e.Cases(env, rs)
+	if err != nil {
+		return err
+	}
+	results := e2e.Run(ctx, env, filterCases(cases, *filter))
+	failed := 0
+	for _, r := range results {
+		if !r.Passed {
+			failed++
+		}
+	}
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(results); err != nil {
+			return err
+		}
+	} else {
+		for _, r := range results {
+			if r.Passed {
+				fmt.Printf("  ✓ %s (%s)\n", r.Case.Name, r.Duration.Round(time.Millisecond))
+			} else {
+				fmt.Printf("  ✗ %s: %s\n", r.Case.Name, r.Error)
+			}
+		}
+		fmt.Printf("\n%d passed, %d failed\n", len(results)-failed, failed)
+	}
+	if failed > 0 {
+		return exitCode(1)
+	}
+	return nil
+}
+
+// runTestMatrix runs the cases of the matrix platforms this machine is, and
+// reports every case on every platform: passed, failed, untested, or blank
+// where it does not apply. Results of runs on other platforms are merged in
+// from their JSON.
+func runTestMatrix(ctx context.Context, env *resource.Env, filter string, merge []string, asJSON bool) error {
+	if len(env.Template.Matrix) == 0 {
+		return fmt.Errorf("template %q declares no [[matrix]] platforms", env.Template.Name)
+	}
+	m, cases, err := e2e.BuildMatrix(env, func(e *resource.Env) ([]resource.Resource, error) {
+		return engine.Resources(ctx, e)
+	})
+	if err != nil {
+		return err
+	}
+	var here []string
+	for _, p := range env.Template.Matrix {
+		if e2e.Matches(env, p) {
+			here = append(here, p.Name)
+		}
+	}
+	if len(here) == 0 && !asJSON {
+		fmt.Fprintln(os.Stderr, "warning: this machine is none of the matrix platforms; nothing is run here")
+	}
+	m.Record(here, e2e.Run(ctx, env, filterCases(cases, filter)))
+	for _, path := range merge {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		var o e2e.Matrix
+		if err := json.Unmarshal(data, &o); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		m.Merge(&o)
+	}
+
+	failed := 0
+	for _, p := range m.Platforms {
+		failed += m.Count(p, e2e.StatusFailed)
+	}
+	if asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(m); err != nil {
+			return err
+		}
+	} else {
+		printMatrix(m)
+	}
+	if failed > 0 {
+		return exitCode(1)
+	}
+	return nil
+}
+
+func printMatrix(m *e2e.Matrix) {
+	width := len("case")
+	for _, r := range m.Rows {
+		width = max(width, len(r.Name))
+	}
+	fmt.Printf("  %-*s", width, "case")
+	for _, p := range m.Platforms {
+		fmt.Printf("  %s", p)
+	}
+	fmt.Println()
+	marks := map[string]string{e2e.StatusPassed: "✓", e2e.StatusFailed: "✗", e2e.StatusUntested: "○"}
+	for _, r := range m.Rows {
+		line := fmt.Sprintf("  %-*s", width, r.Name)
+		for _, p := range m.Platforms {
+			line += fmt.Sprintf("  %-*s", len(p), marks[r.Status[p]])
+		}
+		fmt.Println(strings.TrimRight(line, " "))
+	}
+	fmt.Println("\n✓ passed  ✗ failed  ○ untested  blank: does not apply")
+	fmt.Println()
+	for _, p := range m.Platforms {
+		fmt.Printf("  %s: %d passed, %d failed, %d untested\n", p,
+			m.Count(p, e2e.StatusPassed), m.Count(p, e2e.StatusFailed), m.Count(p, e2e.StatusUntested))
+	}
+}
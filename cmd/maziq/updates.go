@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/hmziqrs/maziq/internal/modules/updates"
+)
+
+func init() {
+	commands = append(commands, command{
+		name:    "updates",
+		summary: "List pending macOS updates and their deferral",
+		run:     runUpdates,
+	})
+}
+
+func runUpdates(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("updates", flag.ContinueOnError)
+	ref := fs.String("template", "", "template name or path (default from config)")
+	asJSON := fs.Bool("json", false, "print updates as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	t, _, err := loadTemplate(ctx, *ref)
+	if err != nil {
+		return err
+	}
+	list, err := updates.List(ctx, newEnv(t))
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(list)
+	}
+	if len(list) == 0 {
+		fmt.Println("✓ macOS is up to date.")
+		return nil
+	}
+	now := time.Now()
+	for _, u := range list {
+		note := ""
+		switch {
+		case u.Deferred(t.Updates.DeferDays, now):
+			note = "deferred until " + u.Seen.AddDate(0, 0, int(t.Updates.DeferDays)).Format("2006-01-02")
+		case u.Restart:
+			note = "needs a restart"
+		}
+		fmt.Printf("%-48s %-12s %s\n", u.Title, u.Version, note)
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/hmziqrs/maziq/internal/engine"
+)
+
+func init() {
+	commands = append(commands, command{
+		name:    "verify",
+		summary: "Quickly check convergence with local checks only",
+		run:     runVerify,
+	})
+}
+
+func runVerify(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("verify", flag.ContinueOnError)
+	ref := fs.String("template", "", "template name or path (default from config)")
+	quiet := fs.Bool("quiet", false, "print nothing; only set the exit status")
+	asJSON := fs.Bool("json", false, "print the verified resources as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	t, _, err := loadTemplateOffline(ctx, *ref)
+	if err != nil {
+		return err
+	}
+	plan, unverified, err := engine.Verify(ctx, newEnv(t))
+	if err != nil {
+		return err
+	}
+	pending := plan.Pending()
+
+	switch {
+	case *asJSON:
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(plan.Summary()); err != nil {
+			return err
+		}
+	case *quiet:
+	case len(pending) == 0:
+		fmt.Printf("✓ %d resources verified", len(plan.Items))
+		if unverified > 0 {
+			fmt.Printf("; %d need a full check (maziq plan)", unverified)
+		}
+		fmt.Println()
+	default:
+		for _, it := range pending {
+			if it.Err != nil {
+				fmt.Printf("  ! %-32s %v\n", it.ID(), it.Err)
+				continue
+			}
+			fmt.Printf("  ✗ %-32s %s (want %s)\n", it.ID(), it.State.Current, it.State.Desired)
+		}
+		fmt.Printf("\n%d of %d verified resources drifted; run maziq apply.\n", len(pending), len(plan.Items))
+	}
+	if len(pending) > 0 {
+		return exitCode(1)
+	}
+	return nil
+}
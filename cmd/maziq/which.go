@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/hmziqrs/maziq/internal/which"
+)
+
+func init() {
+	commands = append(commands, command{
+		name:    "which",
+		summary: "Show which template entry and package manager provide a command or app",
+		run:     runWhich,
+	})
+}
+
+func runWhich(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("which", flag.ContinueOnError)
+	ref := fs.String("template", "", "template name or path (default from config)")
+	unmanaged := fs.Bool("unmanaged", false, "list the commands on PATH that no template entry installed")
+	asJSON := fs.Bool("json", false, "print the answers as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 && !*unmanaged {
+		return fmt.Errorf("usage: maziq which [--json] NAME... | maziq which --unmanaged")
+	}
+	t, _, err := loadTemplate(ctx, *ref)
+	if err != nil {
+		return err
+	}
+	ix, err := which.NewIndex(ctx, newEnv(t), warnComponent)
+	if err != nil {
+		return err
+	}
+	var answers []which.Answer
+	if *unmanaged {
+		answers = ix.Unmanaged()
+	}
+	for _, name := range fs.Args() {
+		answers = append(answers, ix.Lookup(name))
+	}
+	if *asJSON {
+		if answers == nil {
+			answers = []which.Answer{}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(answers)
+	}
+	if *unmanaged {
+		printUnmanaged(answers[:len(answers)-fs.NArg()], t.Name)
+		answers = answers[len(answers)-fs.NArg():]
+	}
+	missing := false
+	for _, a := range answers {
+		printAnswer(a)
+		missing = missing || !a.Found()
+	}
+	if missing {
+		return exitCode(1)
+	}
+	return nil
+}
+
+func printAnswer(a which.Answer) {
+	if !a.Found() {
+		fmt.Printf("%s: %s\n", a.Name, a.Describe())
+		return
+	}
+	fmt.Println(a.Name + " → " + a.Path)
+	if a.Target != "" {
+		fmt.Println("  → " + a.Target)
+	}
+	fmt.Println("  " + a.Describe())
+	if a.Expected != "" {
+		fmt.Printf("  %s in the template provides a %s too, which this one is not\n", a.Expected, a.Name)
+	}
+}
+
+// printUnmanaged lists commands by what installed them, one line per
+// package.
+func printUnmanaged(answers []which.Answer, template string) {
+	if len(answers) == 0 {
+		fmt.Printf("Every command on PATH outside macOS comes from %s.\n", template)
+		return
+	}
+	var keys []string
+	byPackage := map[string][]string{}
+	for _, a := range answers {
+		key := a.From()
+		if key == "" {
+			key = "unknown, in " + strings.TrimSuffix(a.Path, "/"+a.Name)
+		}
+		if _, ok := byPackage[key]; !ok {
+			keys = append(keys, key)
+		}
+		byPackage[key] = append(byPackage[key], a.Name)
+	}
+	fmt.Printf("%d command(s) on PATH that %s does not account for:\n\n", len(answers), template)
+	for _, k := range keys {
+		fmt.Printf("  %-32s %s\n", k, strings.Join(byPackage[k], " "))
+	}
+	fmt.Println()
+}
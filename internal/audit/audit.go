@@ -0,0 +1,260 @@
+// Package audit keeps a log of what applies changed on the machine: who ran
+// them, when, from which template and commit, and which policy violations
+// were overridden.
+//
+// Entries are appended to ~/.maziq/audit.jsonl. Each carries the SHA-256 of
+// the one before it, so editing or removing an entry breaks every hash after
+// it, and the sequence number and hash of the newest entry are kept in the
+// state store as well, so cutting entries off the end shows too. Verify
+// checks both. The hashes are not keyed and the head lives in the same
+// writable directory, so this catches accidental edits and careless ones,
+// not someone who recomputes the chain and the head after editing.
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/hmziqrs/maziq/internal/config"
+	"github.com/hmziqrs/maziq/internal/engine"
+	"github.com/hmziqrs/maziq/internal/policy"
+	"github.com/hmziqrs/maziq/internal/state"
+)
+
+// headKey is the state store key of the newest entry's sequence and hash.
+const headKey = "audit/head"
+
+// Path returns the location of the log.
+func Path() string {
+	return filepath.Join(config.Dir(), "audit.jsonl")
+}
+
+// Entry is one apply.
+type Entry struct {
+	Seq  int64     `json:"seq"`
+	Time time.Time `json:"time"`
+	User string    `json:"user"`
+	Host string    `json:"host"`
+	// Via is what ran the apply: cli, tui or daemon.
+	Via      string `json:"via"`
+	Template string `json:"template"`
+	Source   string `json:"source,omitempty"`
+	// Commit is the git commit of the template file, with a "+dirty"
+	// suffix when it had uncommitted changes.
+	Commit    string             `json:"commit,omitempty"`
+	Changes   []Change           `json:"changes"`
+	Overrides []policy.Violation `json:"overrides,omitempty"`
+	// Prev is the hash of the entry before, empty for the first.
+	Prev string `json:"prev"`
+	Hash string `json:"hash"`
+}
+
+// Change is what happened to one resource.
+type Change struct {
+	ID     string `json:"id"`
+	Status string `json:"status"`
+	Scope  string `json:"scope"`
+	Error  string `json:"error,omitempty"`
+}
+
+type head struct {
+	Seq  int64  `json:"seq"`
+	Hash string `json:"hash"`
+}
+
+// hash is the SHA-256 of the entry with its Hash left out.
+func (e Entry) hash() string {
+	e.Hash = ""
+	data, _ := json.Marshal(e)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Record appends the apply of plan that produced report, unless it changed
+// nothing. via says what ran it.
+func Record(via string, plan *engine.Plan, report engine.Report) error {
+	e := Entry{
+		Time:     report.Finished.UTC(),
+		Via:      via,
+		Template: plan.Template,
+		Source:   plan.Source,
+		Commit:   commit(plan.Source),
+		Changes:  []Change{},
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now().UTC()
+	}
+	for _, o := range report.Outcomes {
+		if o.Status != engine.OutcomeOK {
+			e.Changes = append(e.Changes, Change{ID: o.ID, Status: o.Status, Scope: o.Scope, Error: o.Error})
+		}
+	}
+	if len(e.Changes) == 0 {
+		return nil
+	}
+	if plan.Overridden {
+		e.Overrides = plan.Violations
+	}
+	if u, err := user.Current(); err == nil {
+		e.User = u.Username
+	}
+	e.Host, _ = os.Hostname()
+
+	if err := os.MkdirAll(filepath.Dir(Path()), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(Path(), os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err != nil {
+		return err
+	}
+	defer syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+	last, err := lastEntry(f)
+	if err != nil {
+		return err
+	}
+	e.Seq, e.Prev = last.Seq+1, last.Hash
+	e.Hash = e.hash()
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		return err
+	}
+	return state.Put(headKey, head{Seq: e.Seq, Hash: e.Hash})
+}
+
+// lastEntry returns the newest entry in f, or a zero Entry when it is empty.
+func lastEntry(f *os.File) (Entry, error) {
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return Entry{}, err
+	}
+	var last Entry
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for sc.Scan() {
+		if len(bytes.TrimSpace(sc.Bytes())) == 0 {
+			continue
+		}
+		if err := json.Unmarshal(sc.Bytes(), &last); err != nil {
+			return Entry{}, fmt.Errorf("%s: %w", Path(), err)
+		}
+	}
+	return last, sc.Err()
+}
+
+// commit returns the git commit of the template file at path, if it is in
+// a repository.
+func commit(path string) string {
+	if path == "" || strings.HasPrefix(path, "builtin:") {
+		return ""
+	}
+	dir := filepath.Dir(path)
+	out, err := exec.Command("git", "-C", dir, "rev-parse", "HEAD").Output()
+	if err != nil {
+		return ""
+	}
+	c := strings.TrimSpace(string(out))
+	if out, err := exec.Command("git", "-C", dir, "status", "--porcelain", "--", filepath.Base(path)).Output(); err == nil && len(bytes.TrimSpace(out)) > 0 {
+		c += "+dirty"
+	}
+	return c
+}
+
+// Read returns every entry in order. It does not verify them.
+func Read() ([]Entry, error) {
+	data, err := os.ReadFile(Path())
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var out []Entry
+	for i, line := range bytes.Split(data, []byte("\n")) {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		var e Entry
+		if err := json.Unmarshal(line, &e); err != nil {
+			return out, fmt.Errorf("line %d: %w", i+1, err)
+		}
+		out = append(out, e)
+	}
+	return out, nil
+}
+
+// Problem is a place where the log was edited or damaged.
+type Problem struct {
+	// Seq is the entry at fault, or 0 for the log as a whole.
+	Seq     int64  `json:"seq"`
+	Message string `json:"message"`
+}
+
+func (p Problem) String() string {
+	if p.Seq == 0 {
+		return p.Message
+	}
+	return fmt.Sprintf("entry %d: %s", p.Seq, p.Message)
+}
+
+// Verify checks the hash chain and that the log ends where the state store
+// says it should. It returns the entries checked and every problem found.
+func Verify() (int, []Problem, error) {
+	entries, err := Read()
+	var problems []Problem
+	if err != nil {
+		problems = append(problems, Problem{Message: "unreadable: " + err.Error()})
+	}
+	prev, seq := "", int64(0)
+	for _, e := range entries {
+		seq++
+		switch {
+		case e.Seq != seq:
+			problems = append(problems, Problem{Seq: e.Seq, Message: fmt.Sprintf("expected entry %d; entries were removed or reordered", seq)})
+			seq = e.Seq
+		case e.Prev != prev:
+			problems = append(problems, Problem{Seq: e.Seq, Message: "does not follow the entry before it; an earlier entry was edited or removed"})
+		}
+		if e.hash() != e.Hash {
+			problems = append(problems, Problem{Seq: e.Seq, Message: "hash mismatch; the entry was edited"})
+		}
+		prev = e.Hash
+	}
+	var h head
+	ok, err := state.Get(headKey, &h)
+	switch {
+	case err != nil:
+		return len(entries), problems, err
+	case !ok && len(entries) > 0:
+		problems = append(problems, Problem{Message: "the state store has no record of the log; it may have been reset"})
+	case ok && h.Seq > seq:
+		problems = append(problems, Problem{Message: fmt.Sprintf("the log ends at entry %d but %d were written; it was truncated", seq, h.Seq)})
+	case ok && h.Seq == seq && h.Hash != prev:
+		problems = append(problems, Problem{Seq: seq, Message: "differs from the newest entry recorded in the state store"})
+	case ok && h.Seq < seq:
+		problems = append(problems, Problem{Message: fmt.Sprintf("entries after %d were not written by MazIQ", h.Seq)})
+	}
+	return len(entries), problems, nil
+}
@@ -0,0 +1,190 @@
+package audit
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/hmziqrs/maziq/internal/engine"
+	"github.com/hmziqrs/maziq/internal/state"
+)
+
+// record appends n applies to a log in a temporary MAZIQ_HOME.
+func record(t *testing.T, n int) {
+	t.Helper()
+	t.Setenv("MAZIQ_HOME", t.TempDir())
+	for i := 0; i < n; i++ {
+		plan := &engine.Plan{Template: "test"}
+		report := engine.Report{Outcomes: []engine.Outcome{
+			{ID: "defaults:com.example/a", Status: engine.OutcomeApplied},
+			{ID: "defaults:com.example/b", Status: engine.OutcomeOK},
+		}}
+		if err := Record("cli", plan, report); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func entries(t *testing.T) []Entry {
+	t.Helper()
+	es, err := Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return es
+}
+
+// rewrite replaces the log with es.
+func rewrite(t *testing.T, es []Entry) {
+	t.Helper()
+	var b strings.Builder
+	for _, e := range es {
+		line, err := json.Marshal(e)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b.Write(line)
+		b.WriteByte('\n')
+	}
+	if err := os.WriteFile(Path(), []byte(b.String()), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRecordChains(t *testing.T) {
+	record(t, 3)
+	es := entries(t)
+	if len(es) != 3 {
+		t.Fatalf("recorded %d entries, want 3", len(es))
+	}
+	prev := ""
+	for i, e := range es {
+		if e.Seq != int64(i+1) || e.Prev != prev || e.Hash != e.hash() {
+			t.Errorf("entry %d = seq %d, prev %q, hash %q", i+1, e.Seq, e.Prev, e.Hash)
+		}
+		if len(e.Changes) != 1 || e.Changes[0].ID != "defaults:com.example/a" {
+			t.Errorf("entry %d changes = %+v, want only the applied one", i+1, e.Changes)
+		}
+		prev = e.Hash
+	}
+	n, problems, err := Verify()
+	if err != nil || n != 3 || len(problems) != 0 {
+		t.Errorf("Verify = %d, %v, %v; want 3 clean entries", n, problems, err)
+	}
+}
+
+func TestRecordSkipsNoChange(t *testing.T) {
+	t.Setenv("MAZIQ_HOME", t.TempDir())
+	report := engine.Report{Outcomes: []engine.Outcome{{ID: "x", Status: engine.OutcomeOK}}}
+	if err := Record("cli", &engine.Plan{Template: "test"}, report); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(Path()); !os.IsNotExist(err) {
+		t.Errorf("an apply that changed nothing created the log: %v", err)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	tests := []struct {
+		name string
+		// damage edits a log of three entries.
+		damage func(t *testing.T)
+		seq    int64
+		want   string
+	}{
+		{
+			name: "edited entry",
+			damage: func(t *testing.T) {
+				es := entries(t)
+				es[1].Template = "other"
+				rewrite(t, es)
+			},
+			seq: 2, want: "hash mismatch",
+		},
+		{
+			name: "edited and rehashed entry",
+			damage: func(t *testing.T) {
+				es := entries(t)
+				es[1].Template = "other"
+				es[1].Hash = es[1].hash()
+				rewrite(t, es)
+			},
+			seq: 3, want: "does not follow the entry before it",
+		},
+		{
+			name: "removed entry",
+			damage: func(t *testing.T) {
+				es := entries(t)
+				rewrite(t, []Entry{es[0], es[2]})
+			},
+			seq: 3, want: "expected entry 2",
+		},
+		{
+			name: "truncated log",
+			damage: func(t *testing.T) {
+				rewrite(t, entries(t)[:2])
+			},
+			want: "ends at entry 2 but 3 were written",
+		},
+		{
+			name: "torn line",
+			damage: func(t *testing.T) {
+				raw, err := os.ReadFile(Path())
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(Path(), raw[:len(raw)-20], 0o600); err != nil {
+					t.Fatal(err)
+				}
+			},
+			want: "unreadable: line 3",
+		},
+		{
+			name: "replaced newest entry",
+			damage: func(t *testing.T) {
+				es := entries(t)
+				es[2].User = "someone"
+				es[2].Hash = es[2].hash()
+				rewrite(t, es)
+			},
+			seq: 3, want: "differs from the newest entry",
+		},
+		{
+			name: "appended entry",
+			damage: func(t *testing.T) {
+				es := entries(t)
+				e := es[2]
+				e.Seq, e.Prev = 4, e.Hash
+				e.Hash = e.hash()
+				rewrite(t, append(es, e))
+			},
+			want: "entries after 3 were not written by MazIQ",
+		},
+		{
+			name: "lost head",
+			damage: func(t *testing.T) {
+				if err := state.Delete(headKey); err != nil {
+					t.Fatal(err)
+				}
+			},
+			want: "no record of the log",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			record(t, 3)
+			tt.damage(t)
+			_, problems, err := Verify()
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, p := range problems {
+				if p.Seq == tt.seq && strings.Contains(p.Message, tt.want) {
+					return
+				}
+			}
+			t.Errorf("problems = %v, want entry %d: %s", problems, tt.seq, tt.want)
+		})
+	}
+}
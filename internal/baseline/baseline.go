@@ -0,0 +1,108 @@
+// Package baseline fetches the organization baseline template that IT teams
+// enforce underneath every personal template.
+package baseline
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/hmziqrs/maziq/internal/config"
+	"github.com/hmziqrs/maziq/internal/fetch"
+	"github.com/hmziqrs/maziq/internal/templates"
+)
+
+const fetchTimeout = 15 * time.Second
+
+// CachePath is where the last successfully fetched baseline is kept so
+// compliance can still be checked offline.
+func CachePath() string {
+	return filepath.Join(config.Dir(), "baseline.toml")
+}
+
+// Result is a fetched baseline.
+type Result struct {
+	Template *templates.Template
+	// Stale is set when the remote was unreachable and the cache was used.
+	Stale bool
+	// FetchErr is the remote error that forced a stale result.
+	FetchErr error
+}
+
+// Fetch loads the baseline from url, refreshing the local cache. If the
+// remote cannot be reached the cached copy is returned and marked stale.
+func Fetch(ctx context.Context, url string) (Result, error) {
+	data, err := read(ctx, url)
+	if err == nil {
+		t, perr := templates.Parse(data, url)
+		if perr != nil {
+			return Result{}, fmt.Errorf("baseline: %w", perr)
+		}
+		if werr := writeCache(data); werr != nil {
+			return Result{}, fmt.Errorf("baseline: cache: %w", werr)
+		}
+		return Result{Template: t}, nil
+	}
+
+	res, cerr := Cached()
+	if cerr != nil {
+		return Result{}, fmt.Errorf("baseline: %w (no cached copy)", err)
+	}
+	res.FetchErr = err
+	return res, nil
+}
+
+// Cached returns the last fetched baseline without contacting the remote.
+// It is always marked stale.
+func Cached() (Result, error) {
+	cached, err := os.ReadFile(CachePath())
+	if err != nil {
+		return Result{}, err
+	}
+	t, err := templates.Parse(cached, CachePath())
+	if err != nil {
+		return Result{}, fmt.Errorf("baseline: cached copy: %w", err)
+	}
+	return Result{Template: t, Stale: true}, nil
+}
+
+func read(ctx context.Context, url string) ([]byte, error) {
+	switch {
+	case strings.HasPrefix(url, "http://"), strings.HasPrefix(url, "https://"):
+		ctx, cancel := context.WithTimeout(ctx, fetchTimeout)
+		defer cancel()
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := fetch.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("GET %s: %s", url, resp.Status)
+		}
+		return io.ReadAll(resp.Body)
+	case strings.HasPrefix(url, "file://"):
+		return os.ReadFile(strings.TrimPrefix(url, "file://"))
+	default:
+		return os.ReadFile(url)
+	}
+}
+
+func writeCache(data []byte) error {
+	if err := os.MkdirAll(config.Dir(), 0o755); err != nil {
+		return err
+	}
+	tmp := CachePath() + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, CachePath())
+}
@@ -0,0 +1,193 @@
+// Package bundle makes and opens offline bundles: the downloads applying a
+// template needs, made on a connected machine, so that a machine without
+// network access can apply the template from them.
+//
+// A bundle is a directory:
+//
+//	bundle.json     the Manifest
+//	template.toml   the template
+//	Homebrew.pkg    Homebrew's installer
+//	homebrew/       Homebrew's cache: bottles, casks and its API data
+//	http/           every response MazIQ's own downloads received
+package bundle
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/hmziqrs/maziq/internal/engine"
+	"github.com/hmziqrs/maziq/internal/fetch"
+	"github.com/hmziqrs/maziq/internal/github"
+	"github.com/hmziqrs/maziq/internal/resource"
+	"github.com/hmziqrs/maziq/internal/shell"
+)
+
+const (
+	manifestFile = "bundle.json"
+	templateFile = "template.toml"
+	homebrewPkg  = "Homebrew.pkg"
+)
+
+// Manifest describes a bundle.
+type Manifest struct {
+	Template string    `json:"template"`
+	Created  time.Time `json:"created"`
+	// Version is the MazIQ that made the bundle.
+	Version string `json:"version"`
+	// Arch and MacOS are those of the machine that made the bundle, which
+	// Homebrew fetched bottles for.
+	Arch  string `json:"arch"`
+	MacOS string `json:"macos"`
+	// Baseline is the organization baseline merged under the template.
+	Baseline string `json:"baseline,omitempty"`
+	Fetched  int    `json:"fetched"`
+	// Missing lists the resources an offline apply cannot make.
+	Missing []Missing `json:"missing,omitempty"`
+}
+
+// Missing is a resource the bundle has no downloads for.
+type Missing struct {
+	ID     string `json:"id"`
+	Reason string `json:"reason"`
+}
+
+// TemplatePath returns where the bundle in dir keeps its template.
+func TemplatePath(dir string) string { return filepath.Join(dir, templateFile) }
+
+// brewEnv has Homebrew use the bundle's cache, and nothing else: no
+// updates, no API refreshes and no cleanup of what is in it.
+func brewEnv(dir string) map[string]string {
+	return map[string]string{
+		"HOMEBREW_CACHE":                filepath.Join(dir, "homebrew"),
+		"HOMEBREW_NO_AUTO_UPDATE":       "1",
+		"HOMEBREW_NO_INSTALL_CLEANUP":   "1",
+		"HOMEBREW_API_AUTO_UPDATE_SECS": "315360000",
+		"HOMEBREW_NO_ANALYTICS":         "1",
+	}
+}
+
+func setenv(vars map[string]string) {
+	for k, v := range vars {
+		os.Setenv(k, v)
+	}
+}
+
+// Record starts recording into dir, which must be new, empty or an earlier
+// bundle to refresh. Everything downloaded from then on, by MazIQ or by
+// Homebrew, ends up in the bundle; loading the template after Record puts
+// the baseline in too.
+func Record(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	if len(entries) > 0 {
+		if _, err := os.Stat(filepath.Join(dir, manifestFile)); err != nil {
+			return fmt.Errorf("%s is not empty and not a bundle", dir)
+		}
+	}
+	if err := fetch.Record(filepath.Join(dir, "http")); err != nil {
+		return err
+	}
+	setenv(brewEnv(dir))
+	// Homebrew has to be able to update its API data while recording.
+	os.Unsetenv("HOMEBREW_API_AUTO_UPDATE_SECS")
+	return nil
+}
+
+// Create fetches what applying env.Template needs into dir, after Record.
+// Failures of single resources are reported to warn and listed in the
+// manifest; the bundle is still written.
+func Create(ctx context.Context, env *resource.Env, dir, version, baseline string, warn func(id string, err error)) (Manifest, error) {
+	m := Manifest{Template: env.Template.Name, Created: time.Now().UTC(), Version: version,
+		Arch: env.Facts["arch"], MacOS: env.Facts["macos"], Baseline: baseline}
+	if err := os.WriteFile(TemplatePath(dir), env.Template.Raw, 0o644); err != nil {
+		return m, err
+	}
+	env.Log("fetching Homebrew's installer")
+	if err := fetchHomebrew(ctx, filepath.Join(dir, homebrewPkg)); err != nil {
+		warn("homebrew", err)
+		m.Missing = append(m.Missing, Missing{ID: "homebrew", Reason: err.Error()})
+	}
+	n, err := engine.Fetch(ctx, env, func(id string, err error) {
+		warn(id, err)
+		m.Missing = append(m.Missing, Missing{ID: id, Reason: err.Error()})
+	})
+	m.Fetched = n
+	if err != nil {
+		return m, err
+	}
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return m, err
+	}
+	return m, os.WriteFile(filepath.Join(dir, manifestFile), data, 0o644)
+}
+
+// fetchHomebrew downloads the installer package of Homebrew's latest
+// release.
+func fetchHomebrew(ctx context.Context, dest string) error {
+	rel, err := github.Latest(ctx, "Homebrew/brew")
+	if err != nil {
+		return err
+	}
+	for _, a := range rel.Assets {
+		if strings.HasSuffix(a.Name, ".pkg") {
+			return fetch.Download(ctx, a.URL, dest)
+		}
+	}
+	return fmt.Errorf("Homebrew %s has no installer package", rel.Tag)
+}
+
+// Open reads the bundle in dir and sets MazIQ up to apply from it: requests
+// are answered from the bundle without touching the network, Homebrew
+// installs from the bundle's cache, and the bundle's baseline is merged
+// unless $MAZIQ_BASELINE names another.
+func Open(dir string) (Manifest, error) {
+	var m Manifest
+	data, err := os.ReadFile(filepath.Join(dir, manifestFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return m, fmt.Errorf("%s is not a bundle; make one with maziq bundle create", dir)
+	}
+	if err != nil {
+		return m, err
+	}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return m, fmt.Errorf("%s: %w", manifestFile, err)
+	}
+	fetch.Replay(filepath.Join(dir, "http"))
+	setenv(brewEnv(dir))
+	if m.Baseline != "" && os.Getenv("MAZIQ_BASELINE") == "" {
+		os.Setenv("MAZIQ_BASELINE", m.Baseline)
+	}
+	return m, nil
+}
+
+// InstallHomebrew installs Homebrew from the bundle's package when it is not
+// installed, and puts it on PATH for the rest of the run.
+func InstallHomebrew(ctx context.Context, env *resource.Env, dir string) error {
+	prefix := "/usr/local"
+	if env.Facts["arch"] == "arm64" {
+		prefix = "/opt/homebrew"
+	}
+	brew := filepath.Join(prefix, "bin", "brew")
+	if _, err := os.Stat(brew); err == nil {
+		return nil
+	}
+	pkg := filepath.Join(dir, homebrewPkg)
+	if _, err := os.Stat(pkg); err != nil {
+		return errors.New("Homebrew is not installed and the bundle has no installer for it")
+	}
+	env.Log("installing Homebrew from %s", pkg)
+	if _, err := env.Run(ctx, shell.Command{Name: "installer", Args: []string{"-pkg", pkg, "-target", "/"}, Sudo: true}); err != nil {
+		return err
+	}
+	return os.Setenv("PATH", filepath.Dir(brew)+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
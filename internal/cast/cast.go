@@ -0,0 +1,198 @@
+// Package cast records sessions in asciicast v2, the format asciinema plays,
+// so a provisioning run can be replayed with its commands, their output and
+// its timing when one machine ends up different from another.
+package cast
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/hmziqrs/maziq/internal/shell"
+)
+
+// Recorder writes a recording. It is safe for concurrent use; everything
+// written to it becomes output in the recording, timed from Create.
+type Recorder struct {
+	mu      sync.Mutex
+	f       *os.File
+	w       *bufio.Writer
+	start   time.Time
+	pending []byte
+	lastCR  bool
+}
+
+// header is the first line of an asciicast v2 file.
+type header struct {
+	Version   int               `json:"version"`
+	Width     int               `json:"width"`
+	Height    int               `json:"height"`
+	Timestamp int64             `json:"timestamp"`
+	Title     string            `json:"title,omitempty"`
+	Env       map[string]string `json:"env,omitempty"`
+}
+
+// Create starts a recording at path. The terminal size is taken from
+// $COLUMNS and $LINES, falling back to 80x24.
+func Create(path, title string) (*Recorder, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	r := &Recorder{f: f, w: bufio.NewWriter(f), start: time.Now()}
+	h := header{
+		Version:   2,
+		Width:     envInt("COLUMNS", 80),
+		Height:    envInt("LINES", 24),
+		Timestamp: r.start.Unix(),
+		Title:     title,
+		Env:       map[string]string{"SHELL": os.Getenv("SHELL"), "TERM": os.Getenv("TERM")},
+	}
+	line, _ := json.Marshal(h)
+	r.w.Write(append(line, '\n'))
+	return r, nil
+}
+
+func envInt(name string, def int) int {
+	if n, err := strconv.Atoi(os.Getenv(name)); err == nil && n > 0 {
+		return n
+	}
+	return def
+}
+
+// Write records p as output. Line feeds become CRLF, as a terminal would
+// have received them, and a character split across two writes is held back
+// until it is whole.
+func (r *Recorder) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.pending = append(r.pending, p...)
+	cut := complete(r.pending)
+	if cut == 0 {
+		return len(p), nil
+	}
+	var b bytes.Buffer
+	for _, c := range r.pending[:cut] {
+		if c == '\n' && !r.lastCR {
+			b.WriteByte('\r')
+		}
+		b.WriteByte(c)
+		r.lastCR = c == '\r'
+	}
+	r.pending = append(r.pending[:0], r.pending[cut:]...)
+	return len(p), r.event("o", b.String())
+}
+
+// Mark adds a marker, which asciinema play can pause at and jump between.
+func (r *Recorder) Mark(label string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_ = r.event("m", label)
+}
+
+func (r *Recorder) event(kind, data string) error {
+	t := time.Since(r.start).Seconds()
+	line, err := json.Marshal([]any{json.Number(strconv.FormatFloat(t, 'f', 6, 64)), kind, data})
+	if err != nil {
+		return err
+	}
+	_, err = r.w.Write(append(line, '\n'))
+	return err
+}
+
+// Close writes what is left and closes the file.
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.pending) > 0 {
+		_ = r.event("o", string(r.pending))
+		r.pending = nil
+	}
+	if err := r.w.Flush(); err != nil {
+		r.f.Close()
+		return err
+	}
+	return r.f.Close()
+}
+
+// complete returns how much of p ends on a whole UTF-8 sequence.
+func complete(p []byte) int {
+	for i := len(p) - 1; i >= 0 && i >= len(p)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(p[i]) {
+			if !utf8.FullRune(p[i:]) {
+				return i
+			}
+			break
+		}
+	}
+	return len(p)
+}
+
+// Tee points os.Stdout and os.Stderr at pipes that copy everything written
+// to them both to the original files and into the recording, so it shows
+// what the user saw. The returned func restores them and waits for the
+// copies to drain.
+func (r *Recorder) Tee() (restore func()) {
+	restoreOut := r.tee(&os.Stdout)
+	restoreErr := r.tee(&os.Stderr)
+	return func() {
+		restoreOut()
+		restoreErr()
+	}
+}
+
+func (r *Recorder) tee(f **os.File) func() {
+	pr, pw, err := os.Pipe()
+	if err != nil {
+		return func() {}
+	}
+	orig := *f
+	*f = pw
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, _ = io.Copy(io.MultiWriter(orig, r), pr)
+	}()
+	return func() {
+		*f = orig
+		pw.Close()
+		<-done
+		pr.Close()
+	}
+}
+
+// Runner returns a Runner that records every command l runs: a marker, the
+// command line, its output as it arrives, and how it exited and how long it
+// took. Commands run quietly are recorded all the same, dimmed so they stand
+// apart from what was on screen.
+func (r *Recorder) Runner(l shell.Local) shell.Runner {
+	return runner{l: l, rec: r}
+}
+
+type runner struct {
+	l   shell.Local
+	rec *Recorder
+}
+
+func (x runner) Run(ctx context.Context, c shell.Command) (shell.Result, error) {
+	line := c.String()
+	x.rec.Mark(line)
+	fmt.Fprintf(x.rec, "\x1b[2m$ %s\x1b[0m\n", line)
+	l := x.l
+	if l.Output != nil {
+		l.Output = io.MultiWriter(l.Output, x.rec)
+	} else {
+		l.Output = x.rec
+	}
+	res, err := l.Run(ctx, c)
+	fmt.Fprintf(x.rec, "\x1b[2m# exit %d after %s\x1b[0m\n", res.ExitCode, res.Duration.Round(time.Millisecond))
+	return res, err
+}
@@ -0,0 +1,172 @@
+// Package catalog holds the software definitions MazIQ knows how to manage.
+package catalog
+
+import (
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Kind distinguishes GUI application bundles from command line tools.
+type Kind string
+
+const (
+	KindGUI Kind = "gui"
+	KindCLI Kind = "cli"
+)
+
+// Backend names the mechanism used to install a piece of software.
+type Backend string
+
+const (
+	BackendBrew    Backend = "brew"
+	BackendCask    Backend = "cask"
+	BackendCargo   Backend = "cargo"
+	BackendNPM     Backend = "npm"
+	BackendScript  Backend = "script"
+	BackendManual  Backend = "manual"
+	BackendRustup  Backend = "rustup"
+	BackendUV      Backend = "uv"
+	BackendXcode   Backend = "xcode-select"
+	BackendUnknown Backend = ""
+)
+
+// Source is one way of installing a piece of software. Entries list sources
+// in order of preference; the manager falls back to the next one on failure.
+type Source struct {
+	Backend Backend
+	// Package is the formula, cask, crate or npm package name.
+	Package string
+	// Script is the shell snippet used by script and manual backends.
+	Script string
+}
+
+// Software describes a single catalog entry.
+type Software struct {
+	ID      string
+	Name    string
+	Kind    Kind
+	Sources []Source
+	// Deps lists catalog IDs that must be installed first.
+	Deps []string
+	// Version is the command used to detect the installed version of CLI tools.
+	Version []string
+	// App is the bundle name under /Applications for GUI software.
+	App string
+	// Arch replaces Sources on one architecture, "arm64" or "amd64", for
+	// software whose builds for each are separate packages.
+	Arch map[string][]Source
+	// IntelOnly marks software that only ships Intel builds, which run
+	// under Rosetta on Apple silicon.
+	IntelOnly bool
+	// Completions maps the commands the software installs to the command
+	// that prints their shell completion script, with {shell} standing for
+	// zsh, bash or fish. Homebrew installs the scripts of what it installs
+	// itself, so only software from other sources needs them.
+	Completions map[string][]string
+}
+
+// ForArch returns s as it is installed on arch: from the sources for arch,
+// and needing Rosetta there when it only ships Intel builds.
+func (s Software) ForArch(arch string) Software {
+	if srcs, ok := s.Arch[arch]; ok {
+		s.Sources = srcs
+	}
+	if s.IntelOnly && arch == "arm64" {
+		s.Deps = append(slices.Clone(s.Deps), "rosetta")
+	}
+	return s
+}
+
+// Primary returns the preferred install source.
+func (s Software) Primary() Source {
+	if len(s.Sources) == 0 {
+		return Source{}
+	}
+	return s.Sources[0]
+}
+
+// HasProbe reports whether the entry carries a built-in installation probe.
+func (s Software) HasProbe() bool {
+	return len(s.Version) > 0 || s.App != ""
+}
+
+var registry = map[string]Software{}
+
+func register(entries ...Software) {
+	for _, s := range entries {
+		registry[s.ID] = s
+	}
+}
+
+// Lookup returns the catalog entry for id.
+func Lookup(id string) (Software, bool) {
+	s, ok := registry[id]
+	return s, ok
+}
+
+// ByPackage returns the catalog entry installed from pkg with backend. A
+// formula given without its tap matches a tapped one.
+func ByPackage(backend Backend, pkg string) (Software, bool) {
+	for _, s := range All() {
+		for _, src := range s.Sources {
+			if src.Backend == backend && (src.Package == pkg || strings.HasSuffix(src.Package, "/"+pkg)) {
+				return s, true
+			}
+		}
+	}
+	return Software{}, false
+}
+
+// All returns every catalog entry sorted by ID.
+func All() []Software {
+	out := make([]Software, 0, len(registry))
+	for _, s := range registry {
+		out = append(out, s)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+// IDs returns every catalog ID sorted alphabetically.
+func IDs() []string {
+	out := make([]string, 0, len(registry))
+	for id := range registry {
+		out = append(out, id)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Suggest returns the catalog ID closest to id, or "" when nothing is close
+// enough to be a plausible typo.
+func Suggest(id string) string {
+	best, bestDist := "", len(id)/2+1
+	for known := range registry {
+		if d := distance(strings.ToLower(id), known); d < bestDist || (d == bestDist && known < best) {
+			best, bestDist = known, d
+		}
+	}
+	return best
+}
+
+// distance is the Levenshtein edit distance between a and b.
+func distance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
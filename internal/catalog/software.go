@@ -0,0 +1,291 @@
+package catalog
+
+func init() {
+	register(
+		// Foundations
+		Software{
+			ID:      "homebrew",
+			Name:    "Homebrew",
+			Kind:    KindCLI,
+			Sources: []Source{{Backend: BackendScript, Script: `/bin/bash -c "$(curl -fsSL https://raw.githubusercontent.com/Homebrew/install/HEAD/install.sh)"`}},
+			Version: []string{"brew", "--version"},
+		},
+		Software{
+			ID:      "rosetta",
+			Name:    "Rosetta 2",
+			Kind:    KindCLI,
+			Sources: []Source{{Backend: BackendScript, Script: "softwareupdate --install-rosetta --agree-to-license"}},
+			// Prints x86_64 only when Intel code can run.
+			Version: []string{"/usr/bin/arch", "-x86_64", "/usr/bin/uname", "-m"},
+		},
+		Software{
+			ID:      "xcode_clt",
+			Name:    "Xcode Command Line Tools",
+			Kind:    KindCLI,
+			Sources: []Source{{Backend: BackendXcode, Script: "xcode-select --install"}},
+			Version: []string{"xcode-select", "--version"},
+		},
+
+		Software{
+			ID:      "mas",
+			Name:    "mas (Mac App Store CLI)",
+			Kind:    KindCLI,
+			Sources: []Source{{Backend: BackendBrew, Package: "mas"}},
+			Deps:    []string{"homebrew"},
+			Version: []string{"mas", "version"},
+		},
+		Software{
+			ID:      "duti",
+			Name:    "duti",
+			Kind:    KindCLI,
+			Sources: []Source{{Backend: BackendBrew, Package: "duti"}},
+			Deps:    []string{"homebrew"},
+			Version: []string{"duti", "-V"},
+		},
+		Software{
+			ID:      "minisign",
+			Name:    "minisign",
+			Kind:    KindCLI,
+			Sources: []Source{{Backend: BackendBrew, Package: "minisign"}},
+			Deps:    []string{"homebrew"},
+			Version: []string{"minisign", "-v"},
+		},
+		Software{
+			ID:      "gnupg",
+			Name:    "GnuPG",
+			Kind:    KindCLI,
+			Sources: []Source{{Backend: BackendBrew, Package: "gnupg"}},
+			Deps:    []string{"homebrew"},
+			Version: []string{"gpg", "--version"},
+		},
+		Software{
+			ID:      "tmux",
+			Name:    "tmux",
+			Kind:    KindCLI,
+			Sources: []Source{{Backend: BackendBrew, Package: "tmux"}},
+			Deps:    []string{"homebrew"},
+			Version: []string{"tmux", "-V"},
+		},
+		Software{
+			ID:      "direnv",
+			Name:    "direnv",
+			Kind:    KindCLI,
+			Sources: []Source{{Backend: BackendBrew, Package: "direnv"}},
+			Deps:    []string{"homebrew"},
+			Version: []string{"direnv", "version"},
+		},
+
+		// Kubernetes
+		Software{
+			ID:      "kubectl",
+			Name:    "kubectl",
+			Kind:    KindCLI,
+			Sources: []Source{{Backend: BackendBrew, Package: "kubernetes-cli"}},
+			Deps:    []string{"homebrew"},
+			Version: []string{"kubectl", "version", "--client"},
+		},
+		Software{
+			ID:      "helm",
+			Name:    "Helm",
+			Kind:    KindCLI,
+			Sources: []Source{{Backend: BackendBrew, Package: "helm"}},
+			Deps:    []string{"homebrew"},
+			Version: []string{"helm", "version", "--short"},
+		},
+		Software{
+			ID:      "k9s",
+			Name:    "k9s",
+			Kind:    KindCLI,
+			Sources: []Source{{Backend: BackendBrew, Package: "k9s"}},
+			Deps:    []string{"homebrew"},
+			Version: []string{"k9s", "version", "--short"},
+		},
+
+		// Cloud CLIs
+		Software{
+			ID:      "aws_cli",
+			Name:    "AWS CLI",
+			Kind:    KindCLI,
+			Sources: []Source{{Backend: BackendBrew, Package: "awscli"}},
+			Deps:    []string{"homebrew"},
+			Version: []string{"aws", "--version"},
+		},
+		Software{
+			ID:      "gcloud",
+			Name:    "Google Cloud CLI",
+			Kind:    KindCLI,
+			Sources: []Source{{Backend: BackendCask, Package: "gcloud-cli"}},
+			Deps:    []string{"homebrew"},
+			Version: []string{"gcloud", "--version"},
+		},
+		Software{
+			ID:      "azure_cli",
+			Name:    "Azure CLI",
+			Kind:    KindCLI,
+			Sources: []Source{{Backend: BackendBrew, Package: "azure-cli"}},
+			Deps:    []string{"homebrew"},
+			Version: []string{"az", "version"},
+		},
+
+		// Browsers
+		cask("brave", "Brave Browser", "brave-browser", "Brave Browser.app"),
+		cask("firefox", "Firefox", "firefox", "Firefox.app"),
+		cask("chrome", "Google Chrome", "google-chrome", "Google Chrome.app"),
+
+		// Terminals
+		cask("ghostty", "Ghostty", "ghostty", "Ghostty.app"),
+		cask("kitty", "kitty", "kitty", "kitty.app"),
+		cask("alacritty", "Alacritty", "alacritty", "Alacritty.app"),
+		cask("iterm2", "iTerm2", "iterm2", "iTerm.app"),
+
+		// Keyboard & window management
+		cask("karabiner_elements", "Karabiner-Elements", "karabiner-elements", "Karabiner-Elements.app"),
+		Software{
+			ID:      "skhd",
+			Name:    "skhd",
+			Kind:    KindCLI,
+			Sources: []Source{{Backend: BackendBrew, Package: "koekeishiya/formulae/skhd"}},
+			Deps:    []string{"homebrew"},
+			Version: []string{"skhd", "--version"},
+		},
+		Software{
+			ID:      "yabai",
+			Name:    "yabai",
+			Kind:    KindCLI,
+			Sources: []Source{{Backend: BackendBrew, Package: "koekeishiya/formulae/yabai"}},
+			Deps:    []string{"homebrew"},
+			Version: []string{"yabai", "--version"},
+		},
+
+		// Editors
+		cask("cursor", "Cursor", "cursor", "Cursor.app"),
+		cask("windsurf", "Windsurf", "windsurf", "Windsurf.app"),
+		cask("visual_studio_code", "Visual Studio Code", "visual-studio-code", "Visual Studio Code.app"),
+		cask("zed_stable", "Zed", "zed", "Zed.app"),
+
+		// Productivity & API tooling
+		cask("raycast", "Raycast", "raycast", "Raycast.app"),
+		cask("docker_desktop", "Docker Desktop", "docker", "Docker.app"),
+		cask("postman", "Postman", "postman", "Postman.app"),
+		cask("yaak", "Yaak", "yaak", "Yaak.app"),
+
+		// Rust
+		Software{
+			ID:      "rustup",
+			Name:    "rustup",
+			Kind:    KindCLI,
+			Sources: []Source{{Backend: BackendScript, Script: "curl --proto '=https' --tlsv1.2 -sSf https://sh.rustup.rs | sh -s -- -y"}},
+			Version: []string{"rustup", "--version"},
+			Completions: map[string][]string{
+				"rustup": {"rustup", "completions", "{shell}"},
+			},
+		},
+		Software{
+			ID:      "rust_stable",
+			Name:    "Rust (stable)",
+			Kind:    KindCLI,
+			Sources: []Source{{Backend: BackendRustup, Package: "stable"}},
+			Deps:    []string{"rustup"},
+			Version: []string{"rustc", "--version"},
+			Completions: map[string][]string{
+				"cargo": {"rustup", "completions", "{shell}", "cargo"},
+			},
+		},
+		crate("cargo_just", "just", "just", "just", "--version"),
+		crate("cargo_binstall", "cargo-binstall", "cargo-binstall", "cargo", "binstall", "-V"),
+		crate("cargo_watch", "cargo-watch", "cargo-watch", "cargo", "watch", "--version"),
+		crate("simple_http_server", "simple-http-server", "simple-http-server", "simple-http-server", "--version"),
+
+		// JavaScript runtimes
+		Software{
+			ID:      "nvm",
+			Name:    "nvm",
+			Kind:    KindCLI,
+			Sources: []Source{{Backend: BackendScript, Script: "curl -o- https://raw.githubusercontent.com/nvm-sh/nvm/master/install.sh | bash"}},
+			Version: []string{"bash", "-c", "source \"$HOME/.nvm/nvm.sh\" && nvm --version"},
+		},
+		Software{
+			ID:      "bun",
+			Name:    "Bun",
+			Kind:    KindCLI,
+			Sources: []Source{{Backend: BackendScript, Script: "curl -fsSL https://bun.sh/install | bash"}, {Backend: BackendBrew, Package: "oven-sh/bun/bun"}},
+			Version: []string{"bun", "--version"},
+		},
+
+		// Languages & mobile
+		Software{
+			ID:      "go",
+			Name:    "Go",
+			Kind:    KindCLI,
+			Sources: []Source{{Backend: BackendBrew, Package: "go"}},
+			Deps:    []string{"homebrew"},
+			Version: []string{"go", "version"},
+		},
+		Software{
+			ID:      "flutter",
+			Name:    "Flutter",
+			Kind:    KindCLI,
+			Sources: []Source{{Backend: BackendCask, Package: "flutter"}},
+			Deps:    []string{"homebrew"},
+			Version: []string{"flutter", "--version"},
+		},
+		cask("android_studio", "Android Studio", "android-studio", "Android Studio.app"),
+		npm("react_native_cli", "React Native CLI", "@react-native-community/cli", "react-native"),
+		npm("electron_forge", "Electron Forge", "@electron-forge/cli", "electron-forge"),
+
+		// AI assistants
+		npm("codex_cli", "Codex CLI", "@openai/codex", "codex"),
+		npm("claude_cli", "Claude CLI", "@anthropic-ai/claude-code", "claude"),
+		Software{
+			ID:      "claude_multi_cli",
+			Name:    "Claude Multi CLI",
+			Kind:    KindCLI,
+			Sources: []Source{{Backend: BackendManual, Script: "Install claude-multi from its project README."}},
+			Deps:    []string{"claude_cli"},
+			Version: []string{"claude-multi", "--version"},
+		},
+		Software{
+			ID:      "kimi_cli",
+			Name:    "Kimi CLI",
+			Kind:    KindCLI,
+			Sources: []Source{{Backend: BackendUV, Package: "kimi-cli"}},
+			Version: []string{"kimi", "--version"},
+		},
+		npm("gemini_cli", "Gemini CLI", "@google/gemini-cli", "gemini"),
+		npm("qwen_cli", "Qwen Code", "@qwen-code/qwen-code", "qwen"),
+		npm("opencode_cli", "opencode", "opencode-ai", "opencode"),
+	)
+}
+
+func cask(id, name, pkg, app string) Software {
+	return Software{
+		ID:      id,
+		Name:    name,
+		Kind:    KindGUI,
+		Sources: []Source{{Backend: BackendCask, Package: pkg}},
+		Deps:    []string{"homebrew"},
+		App:     app,
+	}
+}
+
+func crate(id, name, pkg string, version ...string) Software {
+	return Software{
+		ID:      id,
+		Name:    name,
+		Kind:    KindCLI,
+		Sources: []Source{{Backend: BackendCargo, Package: pkg}},
+		Deps:    []string{"rust_stable"},
+		Version: version,
+	}
+}
+
+func npm(id, name, pkg, bin string) Software {
+	return Software{
+		ID:      id,
+		Name:    name,
+		Kind:    KindCLI,
+		Sources: []Source{{Backend: BackendNPM, Package: pkg}},
+		Deps:    []string{"bun"},
+		Version: []string{bin, "--version"},
+	}
+}
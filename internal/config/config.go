@@ -0,0 +1,279 @@
+// Package config loads the user's MazIQ settings from ~/.maziq/config.toml.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+// Config is the user-level configuration. Every field is optional.
+type Config struct {
+	// Template is the personal template applied by default.
+	Template string `toml:"template"`
+	// Profile turns on the template's groups that name it, such as "work"
+	// or "home".
+	Profile string `toml:"profile"`
+	// Baseline configures an organization baseline merged under Template.
+	Baseline Baseline `toml:"baseline"`
+	// GitHub configures release lookups for binaries and self-update.
+	GitHub GitHub `toml:"github"`
+	// Metrics opts in to recording apply statistics locally.
+	Metrics Metrics `toml:"metrics"`
+	// Apply supervises the commands apply runs.
+	Apply Apply `toml:"apply"`
+	// UI adjusts how the TUI and command output are drawn.
+	UI UI `toml:"ui"`
+	// Policy restricts what templates may install.
+	Policy Policy `toml:"policy"`
+	// Network retries downloads and API calls that fail on a flaky network.
+	Network Network `toml:"network"`
+	// Maintenance sets how long `maziq maintenance` keeps old files.
+	Maintenance Maintenance `toml:"maintenance"`
+}
+
+// Maintenance is the retention policy for what MazIQ leaves behind:
+// diagnostics bundles, caches, job logs, metrics and set-aside state files.
+type Maintenance struct {
+	// KeepDays is how many days of them are kept. Homebrew's cleanup
+	// prunes its download cache to the same age.
+	KeepDays int `toml:"keep_days"`
+}
+
+// Network is the retry policy for downloads and API calls. Only failures
+// that may pass are retried: dropped connections, timeouts, DNS errors and
+// overloaded servers, not missing files or refused credentials.
+type Network struct {
+	// Attempts is how many times a request is tried; 1 never retries.
+	Attempts int `toml:"attempts"`
+	// Backoff is the wait after the first failure, doubled after each
+	// later one up to MaxBackoff.
+	Backoff    time.Duration `toml:"backoff"`
+	MaxBackoff time.Duration `toml:"max_backoff"`
+	// Jitter randomises each wait by up to this fraction of it, so machines
+	// that lost the network together do not retry in lockstep.
+	Jitter float64 `toml:"jitter"`
+	// Limit caps download bandwidth, per second, for MazIQ's own downloads
+	// and Homebrew's. Zero is no cap.
+	Limit Size `toml:"limit"`
+	// DeferOver holds back casks whose download is larger than this until
+	// Window, for metered or shared connections. Zero defers nothing.
+	DeferOver Size   `toml:"defer_over"`
+	Window    Window `toml:"window"`
+}
+
+// Policy points at the policy file, which defaults to ~/.maziq/policy.toml.
+type Policy struct {
+	// Path is the policy file; $MAZIQ_POLICY takes precedence.
+	Path string `toml:"path"`
+}
+
+// UI holds display settings.
+type UI struct {
+	// Plain draws with ASCII only, for CI logs and terminals or fonts that
+	// show box drawing, status dots and emoji poorly, and drops the moving
+	// parts of the TUI such as the live progress bar.
+	Plain bool `toml:"plain"`
+}
+
+// Apply limits how long a command may run before MazIQ kills it. A zero
+// duration disables that limit.
+type Apply struct {
+	// Timeout is the longest any single command may run.
+	Timeout time.Duration `toml:"timeout"`
+	// IdleTimeout is the longest a command may go without printing anything.
+	IdleTimeout time.Duration `toml:"idle_timeout"`
+	// OnTimeout is what happens to a change whose command was killed: fail
+	// it (the default), retry it once, or skip it without failing the run.
+	OnTimeout string `toml:"on_timeout"`
+	// VerifyEvery is how long apply trusts a resource that an earlier apply
+	// left converged, as long as its desired state has not changed.
+	VerifyEvery time.Duration `toml:"verify_every"`
+	// QuarantineAfter is how many applies of a resource may fail in a row
+	// before later applies skip it. Zero turns quarantine off.
+	QuarantineAfter int `toml:"quarantine_after"`
+}
+
+// Timeout policies.
+const (
+	TimeoutFail  = "fail"
+	TimeoutRetry = "retry"
+	TimeoutSkip  = "skip"
+)
+
+// Metrics controls the local usage statistics. Nothing is ever sent
+// anywhere; `maziq metrics export` is the only way they leave the machine.
+type Metrics struct {
+	Enabled bool `toml:"enabled"`
+}
+
+// GitHub holds GitHub API settings.
+type GitHub struct {
+	// Token raises the API rate limit from 60 to 5,000 requests an hour. It
+	// needs no scopes. $GITHUB_TOKEN and $GH_TOKEN take precedence.
+	Token string `toml:"token"`
+}
+
+// Baseline points at an organization-managed template.
+type Baseline struct {
+	// URL is an http(s) URL, file:// URL or local path.
+	URL string `toml:"url"`
+}
+
+// DefaultTemplate is used when neither the config nor a flag names one.
+const DefaultTemplate = "hmziq"
+
+// Dir returns MazIQ's state directory, ~/.maziq, honouring $MAZIQ_HOME.
+func Dir() string {
+	if dir := os.Getenv("MAZIQ_HOME"); dir != "" {
+		return dir
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ".maziq"
+	}
+	return filepath.Join(home, ".maziq")
+}
+
+// Path returns the location of the config file.
+func Path() string {
+	return filepath.Join(Dir(), "config.toml")
+}
+
+// Load reads the config file. A missing file yields the defaults.
+func Load() (Config, error) {
+	cfg := Config{
+		Template:    DefaultTemplate,
+		Apply:       Apply{Timeout: 2 * time.Hour, IdleTimeout: 30 * time.Minute, OnTimeout: TimeoutFail, VerifyEvery: 24 * time.Hour, QuarantineAfter: 3},
+		Network:     Network{Attempts: 4, Backoff: 2 * time.Second, MaxBackoff: time.Minute, Jitter: 0.3},
+		Maintenance: Maintenance{KeepDays: 30},
+	}
+	if _, err := toml.DecodeFile(Path(), &cfg); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return cfg, err
+	}
+	switch cfg.Apply.OnTimeout {
+	case TimeoutFail, TimeoutRetry, TimeoutSkip:
+	default:
+		return cfg, fmt.Errorf("apply.on_timeout: unknown policy %q (want fail, retry or skip)", cfg.Apply.OnTimeout)
+	}
+	if cfg.Network.Attempts < 1 {
+		return cfg, fmt.Errorf("network.attempts: %d (want at least 1)", cfg.Network.Attempts)
+	}
+	if cfg.Network.Jitter < 0 || cfg.Network.Jitter > 1 {
+		return cfg, fmt.Errorf("network.jitter: %g (want between 0 and 1)", cfg.Network.Jitter)
+	}
+	if cfg.Maintenance.KeepDays < 1 {
+		return cfg, fmt.Errorf("maintenance.keep_days: %d (want at least 1)", cfg.Maintenance.KeepDays)
+	}
+	if cfg.Network.DeferOver > 0 && cfg.Network.Window.IsZero() {
+		return cfg, errors.New("network.defer_over: needs a window to defer to, e.g. window = \"01:00-06:00\"")
+	}
+	if url := os.Getenv("MAZIQ_BASELINE"); url != "" {
+		cfg.Baseline.URL = url
+	}
+	return cfg, nil
+}
+
+// Size is a number of bytes, written in TOML as "500MB", "1.5GB" or a plain
+// byte count. Units are powers of 1000, as download sizes are.
+type Size int64
+
+var sizeUnits = []struct {
+	suffix string
+	n      float64
+}{{"TB", 1e12}, {"GB", 1e9}, {"MB", 1e6}, {"KB", 1e3}, {"T", 1e12}, {"G", 1e9}, {"M", 1e6}, {"K", 1e3}, {"B", 1}}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (s *Size) UnmarshalText(text []byte) error {
+	str := strings.ToUpper(strings.TrimSpace(string(text)))
+	mult := 1.0
+	for _, u := range sizeUnits {
+		if rest, ok := strings.CutSuffix(str, u.suffix); ok {
+			str, mult = strings.TrimSpace(rest), u.n
+			break
+		}
+	}
+	n, err := strconv.ParseFloat(str, 64)
+	if err != nil || n < 0 {
+		return fmt.Errorf("invalid size %q (want e.g. 500MB)", text)
+	}
+	*s = Size(n * mult)
+	return nil
+}
+
+// String prints the size in its largest unit, to one decimal place.
+func (s Size) String() string {
+	for _, u := range sizeUnits[:4] {
+		if float64(s) >= u.n {
+			return strconv.FormatFloat(math.Round(float64(s)/u.n*10)/10, 'f', -1, 64) + " " + u.suffix
+		}
+	}
+	return strconv.FormatInt(int64(s), 10) + " B"
+}
+
+// Window is a daily span of local time, such as 01:00-06:00. It may wrap
+// past midnight; the zero Window is empty.
+type Window struct {
+	// Start and End are offsets from midnight.
+	Start, End time.Duration
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (w *Window) UnmarshalText(text []byte) error {
+	start, end, ok := strings.Cut(string(text), "-")
+	var err error
+	if ok {
+		if w.Start, err = clock(start); err == nil {
+			w.End, err = clock(end)
+		}
+	}
+	if !ok || err != nil || w.Start == w.End {
+		return fmt.Errorf("invalid window %q (want e.g. 01:00-06:00)", text)
+	}
+	return nil
+}
+
+func clock(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// IsZero reports whether the window is unset.
+func (w Window) IsZero() bool { return w.Start == w.End }
+
+// Contains reports whether t falls inside the window.
+func (w Window) Contains(t time.Time) bool {
+	if w.IsZero() {
+		return false
+	}
+	d := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute + time.Duration(t.Second())*time.Second
+	if w.Start < w.End {
+		return d >= w.Start && d < w.End
+	}
+	return d >= w.Start || d < w.End
+}
+
+// Next returns when the window next opens after t.
+func (w Window) Next(t time.Time) time.Time {
+	open := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location()).Add(w.Start)
+	if !open.After(t) {
+		open = open.AddDate(0, 0, 1)
+	}
+	return open
+}
+
+func (w Window) String() string {
+	f := func(d time.Duration) string { return fmt.Sprintf("%02d:%02d", int(d.Hours()), int(d.Minutes())%60) }
+	return f(w.Start) + "-" + f(w.End)
+}
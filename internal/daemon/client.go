@@ -0,0 +1,113 @@
+package daemon
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+
+	"github.com/hmziqrs/maziq/internal/engine"
+	"github.com/hmziqrs/maziq/internal/status"
+)
+
+// Client talks to a running daemon.
+type Client struct {
+	http *http.Client
+}
+
+// Dial connects to the daemon listening on path. It fails when none is.
+func Dial(path string) (*Client, error) {
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	conn.Close()
+	return &Client{http: &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", path)
+		},
+	}}}, nil
+}
+
+// Status collects a snapshot for the template ref, or the configured one.
+func (c *Client) Status(ctx context.Context, ref string) (status.Snapshot, error) {
+	var s status.Snapshot
+	err := c.get(ctx, "/v1/status", url.Values{"template": {ref}}, &s)
+	return s, err
+}
+
+// Plan plans the template ref, or the configured one.
+func (c *Client) Plan(ctx context.Context, ref string) (engine.Summary, error) {
+	var s engine.Summary
+	err := c.get(ctx, "/v1/plan", url.Values{"template": {ref}}, &s)
+	return s, err
+}
+
+// Apply starts applying the template ref, leaving out the pending changes
+// exclude, and returns the run's events like engine.Start. Cancelling ctx
+// stops the run once the change in progress finishes. If the stream breaks
+// off the channel is closed without a RunFinished.
+func (c *Client) Apply(ctx context.Context, ref string, dryRun bool, exclude []string) (<-chan engine.Event, error) {
+	q := url.Values{"template": {ref}, "exclude": exclude}
+	if dryRun {
+		q.Set("dry_run", "1")
+	}
+	resp, err := c.do(ctx, http.MethodPost, "/v1/apply", q)
+	if err != nil {
+		return nil, err
+	}
+	events := make(chan engine.Event, 16)
+	go func() {
+		defer close(events)
+		defer resp.Body.Close()
+		sc := bufio.NewScanner(resp.Body)
+		sc.Buffer(nil, 1<<20)
+		for sc.Scan() {
+			var m message
+			if json.Unmarshal(sc.Bytes(), &m) != nil {
+				continue
+			}
+			if e, err := m.event(); err == nil {
+				events <- e
+			}
+		}
+	}()
+	return events, nil
+}
+
+func (c *Client) get(ctx context.Context, path string, q url.Values, v any) error {
+	resp, err := c.do(ctx, http.MethodGet, path, q)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// do sends a request and turns error responses into errors.
+func (c *Client) do(ctx context.Context, method, path string, q url.Values) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, "http://maziq"+path+"?"+q.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		var e struct {
+			Error string `json:"error"`
+		}
+		if json.NewDecoder(resp.Body).Decode(&e) == nil && e.Error != "" {
+			return nil, errors.New(e.Error)
+		}
+		return nil, fmt.Errorf("daemon: %s", resp.Status)
+	}
+	return resp, nil
+}
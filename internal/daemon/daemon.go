@@ -0,0 +1,348 @@
+// Package daemon serves plan, apply and status over a Unix socket so GUIs,
+// menu bar apps and MDM agents can drive provisioning without a terminal.
+//
+// The API is HTTP with JSON bodies:
+//
+//	GET  /v1/status[?template=ref]   status.Snapshot
+//	GET  /v1/plan[?template=ref]     engine.Summary
+//	POST /v1/apply[?template=ref][&dry_run=1]
+//
+// Apply streams one JSON object per line, {"type": "task_started", "event":
+// {...}}, ending with a "run_finished" event. Only one apply runs at a time;
+// another request gets 409 Conflict. Closing the connection stops the run
+// once the change in progress finishes; the rest are skipped.
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/hmziqrs/maziq/internal/audit"
+	"github.com/hmziqrs/maziq/internal/config"
+	"github.com/hmziqrs/maziq/internal/demo"
+	"github.com/hmziqrs/maziq/internal/engine"
+	"github.com/hmziqrs/maziq/internal/metrics"
+	"github.com/hmziqrs/maziq/internal/resource"
+	"github.com/hmziqrs/maziq/internal/selfupdate"
+	"github.com/hmziqrs/maziq/internal/status"
+	"github.com/hmziqrs/maziq/internal/templates"
+)
+
+// SocketPath is where the daemon listens by default.
+func SocketPath() string {
+	return filepath.Join(config.Dir(), "maziq.sock")
+}
+
+// Server answers API requests.
+type Server struct {
+	// Load resolves a template reference; an empty ref means the configured
+	// default.
+	Load func(ctx context.Context, ref string) (*templates.Template, error)
+	// Env builds the environment resources run in.
+	Env func(t *templates.Template) *resource.Env
+	// Logf, when set, receives a line per request.
+	Logf func(format string, args ...any)
+
+	applying sync.Mutex
+}
+
+// ListenAndServe serves on the Unix socket at path until ctx ends. A stale
+// socket left by a daemon that did not shut down cleanly is replaced; a live
+// one is an error.
+func (s *Server) ListenAndServe(ctx context.Context, path string) error {
+	if conn, err := net.Dial("unix", path); err == nil {
+		conn.Close()
+		return fmt.Errorf("a daemon is already listening on %s", path)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	_ = os.Remove(path)
+	// Anyone who can reach the socket can change the machine, so it is
+	// created readable by the user alone rather than chmodded after.
+	umask := syscall.Umask(0o177)
+	l, err := net.Listen("unix", path)
+	syscall.Umask(umask)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(path)
+
+	s.logf("listening on %s", path)
+	srv := &http.Server{Handler: s.Handler()}
+	go func() {
+		<-ctx.Done()
+		srv.Close()
+	}()
+	go s.resetDemos(ctx)
+	go s.applyDeferred(ctx)
+	if err := srv.Serve(l); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// Handler returns the API's routes.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/status", s.status)
+	mux.HandleFunc("GET /v1/plan", s.plan)
+	mux.HandleFunc("POST /v1/apply", s.apply)
+	return mux
+}
+
+// resetDemos takes back a demo template's changes once its reset_after has
+// passed, checking every minute. An apply in progress delays the reset.
+func (s *Server) resetDemos(ctx context.Context) {
+	tick := time.NewTicker(time.Minute)
+	defer tick.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-tick.C:
+		}
+		d, err := demo.Load()
+		if err != nil || d == nil || !d.Due(time.Now()) || !s.applying.TryLock() {
+			continue
+		}
+		env := s.Env(nil)
+		env.Logf = s.Logf
+		n, err := demo.Reset(ctx, env)
+		s.applying.Unlock()
+		if err != nil {
+			s.logf("demo reset: took back %d of %d change(s): %v", n, len(d.Undo), err)
+			continue
+		}
+		s.logf("demo reset: took back %d change(s) made by %s", n, d.Template)
+	}
+}
+
+func (s *Server) logf(format string, args ...any) {
+	if s.Logf != nil {
+		s.Logf(format, args...)
+	}
+}
+
+func (s *Server) status(w http.ResponseWriter, r *http.Request) {
+	t, err := s.Load(r.Context(), r.URL.Query().Get("template"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	s.logf("status %s", t.Name)
+	writeJSON(w, status.Collect(r.Context(), t))
+}
+
+func (s *Server) plan(w http.ResponseWriter, r *http.Request) {
+	_, plan, err := s.build(r, 0)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	s.logf("plan %s: %d pending", plan.Template, len(plan.Pending()))
+	writeJSON(w, plan.Summary())
+}
+
+func (s *Server) apply(w http.ResponseWriter, r *http.Request) {
+	if !s.applying.TryLock() {
+		writeError(w, http.StatusConflict, errors.New("an apply is already running"))
+		return
+	}
+	defer s.applying.Unlock()
+
+	cfg, _ := config.Load()
+	env, plan, err := s.build(r, cfg.Apply.VerifyEvery)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	plan.Exclude(r.URL.Query()["exclude"])
+	opts := engine.Options{DryRun: r.URL.Query().Get("dry_run") == "1", OnTimeout: cfg.Apply.OnTimeout, QuarantineAfter: cfg.Apply.QuarantineAfter, Stop: r.Context().Done()}
+	s.logf("apply %s: %d pending", plan.Template, len(plan.Pending()))
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	flusher, _ := w.(http.Flusher)
+	enc := json.NewEncoder(w)
+	// A client that went away stops the run through opts.Stop, between
+	// changes; cancelling the request context would kill the command in
+	// the middle of one. Keep draining so the run finishes cleanly.
+	for e := range engine.Start(context.WithoutCancel(r.Context()), plan, env, opts) {
+		if done, ok := e.(engine.RunFinished); ok && !opts.DryRun {
+			s.record(r.URL.Query().Get("template"), env, plan, done.Report)
+		}
+		if enc.Encode(message{Type: typeOf(e), Event: e}) == nil && flusher != nil {
+			flusher.Flush()
+		}
+	}
+}
+
+// record saves a finished run's metrics and results.
+func (s *Server) record(ref string, env *resource.Env, plan *engine.Plan, report engine.Report) {
+	if err := metrics.Record(metrics.FromReport(plan, report, selfupdate.Version, env.Facts["macos"])); err != nil {
+		s.logf("recording metrics: %v", err)
+	}
+	if err := audit.Record("daemon", plan, report); err != nil {
+		s.logf("writing the audit log: %v", err)
+	}
+	if err := engine.SaveLast(ref, report); err != nil {
+		s.logf("recording the run: %v", err)
+	}
+	if err := engine.SaveVerified(plan, report); err != nil {
+		s.logf("recording the run: %v", err)
+	}
+}
+
+// applyDeferred applies the configured template when the network window
+// opens, so the large downloads network.defer_over held back happen then.
+// An apply in progress when it opens skips that window.
+func (s *Server) applyDeferred(ctx context.Context) {
+	tick := time.NewTicker(time.Minute)
+	defer tick.Stop()
+	was := true
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-tick.C:
+		}
+		cfg, err := config.Load()
+		if err != nil || cfg.Network.DeferOver <= 0 {
+			continue
+		}
+		open := cfg.Network.Window.Contains(time.Now())
+		opening := open && !was
+		was = open
+		if !opening || !s.applying.TryLock() {
+			continue
+		}
+		s.applyWindow(ctx, cfg)
+		s.applying.Unlock()
+	}
+}
+
+func (s *Server) applyWindow(ctx context.Context, cfg config.Config) {
+	t, err := s.Load(ctx, "")
+	if err != nil {
+		s.logf("network window: %v", err)
+		return
+	}
+	env := s.Env(t)
+	env.Logf = s.Logf
+	plan, err := engine.Build(ctx, env)
+	if err != nil {
+		s.logf("network window: %v", err)
+		return
+	}
+	if len(plan.Pending()) == 0 {
+		return
+	}
+	s.logf("network window %s: applying %d pending change(s)", cfg.Network.Window, len(plan.Pending()))
+	report := engine.Apply(ctx, plan, env, engine.Options{OnTimeout: cfg.Apply.OnTimeout, QuarantineAfter: cfg.Apply.QuarantineAfter})
+	s.record("", env, plan, report)
+	s.logf("network window: %d applied, %d failed", report.Count(engine.OutcomeApplied), report.Count(engine.OutcomeFailed))
+}
+
+// build plans the requested template. When verified is not zero, resources
+// an earlier apply verified within that long are not checked again.
+func (s *Server) build(r *http.Request, verified time.Duration) (*resource.Env, *engine.Plan, error) {
+	t, err := s.Load(r.Context(), r.URL.Query().Get("template"))
+	if err != nil {
+		return nil, nil, err
+	}
+	env := s.Env(t)
+	if verified > 0 {
+		plan, err := engine.BuildIncremental(r.Context(), env, verified)
+		return env, plan, err
+	}
+	plan, err := engine.Build(r.Context(), env)
+	return env, plan, err
+}
+
+// message is one line of an apply stream.
+type message struct {
+	Type  string `json:"type"`
+	Event any    `json:"event"`
+	// raw is the undecoded event of a received message.
+	raw json.RawMessage
+}
+
+func (m *message) UnmarshalJSON(data []byte) error {
+	var v struct {
+		Type  string          `json:"type"`
+		Event json.RawMessage `json:"event"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	m.Type, m.raw = v.Type, v.Event
+	return nil
+}
+
+// Event types on the wire.
+const (
+	typeStarted  = "task_started"
+	typeLog      = "task_log"
+	typeDone     = "task_done"
+	typeProgress = "task_progress"
+	typeFinished = "run_finished"
+)
+
+func typeOf(e engine.Event) string {
+	switch e.(type) {
+	case engine.TaskStarted:
+		return typeStarted
+	case engine.TaskLog:
+		return typeLog
+	case engine.TaskDone:
+		return typeDone
+	case engine.TaskProgress:
+		return typeProgress
+	case engine.RunFinished:
+		return typeFinished
+	}
+	return ""
+}
+
+// event decodes m back into the engine event it carries.
+func (m message) event() (engine.Event, error) {
+	switch m.Type {
+	case typeStarted:
+		return decode[engine.TaskStarted](m.raw)
+	case typeLog:
+		return decode[engine.TaskLog](m.raw)
+	case typeDone:
+		return decode[engine.TaskDone](m.raw)
+	case typeProgress:
+		return decode[engine.TaskProgress](m.raw)
+	case typeFinished:
+		return decode[engine.RunFinished](m.raw)
+	}
+	return nil, fmt.Errorf("unknown event %q", m.Type)
+}
+
+func decode[E engine.Event](data []byte) (engine.Event, error) {
+	var e E
+	err := json.Unmarshal(data, &e)
+	return e, err
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, code int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,103 @@
+// Package demo keeps the session of a demo template: how to take back each
+// change its applies made, in order, until `maziq demo reset` takes them
+// all back and the machine is as it was before the first one.
+package demo
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hmziqrs/maziq/internal/resource"
+	"github.com/hmziqrs/maziq/internal/state"
+	"github.com/hmziqrs/maziq/internal/templates"
+)
+
+const key = "demo/session"
+
+// Session is what demo applies have changed since the last reset.
+type Session struct {
+	Template string    `json:"template"`
+	Started  time.Time `json:"started"`
+	// ResetAt is when the daemon resets the machine, or zero for never.
+	ResetAt time.Time `json:"reset_at,omitzero"`
+	// Undo takes back the changes, in the order they were applied.
+	Undo []resource.Undo `json:"undo"`
+}
+
+// Due reports whether the session should have been reset by now.
+func (s *Session) Due(now time.Time) bool {
+	return !s.ResetAt.IsZero() && !now.Before(s.ResetAt)
+}
+
+// Load returns the current session, or nil when nothing needs resetting.
+func Load() (*Session, error) {
+	var s Session
+	ok, err := state.Get(key, &s)
+	if err != nil || !ok {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// Join returns an error when t's changes cannot be added to the session:
+// another template's is open, and a reset would take back both as one.
+func Join(t *templates.Template) error {
+	s, err := Load()
+	if err != nil {
+		return err
+	}
+	return s.join(t)
+}
+
+func (s *Session) join(t *templates.Template) error {
+	if s != nil && s.Template != t.Name {
+		return fmt.Errorf("the demo session of %s is still open; run maziq demo reset first", s.Template)
+	}
+	return nil
+}
+
+// Track adds an applied change of t to the session, starting one if there
+// is none. It refuses a change of another template than the session's; see
+// Join.
+func Track(t *templates.Template, u resource.Undo) error {
+	s, err := Load()
+	if err != nil {
+		return err
+	}
+	if err := s.join(t); err != nil {
+		return err
+	}
+	if s == nil {
+		s = &Session{Template: t.Name, Started: time.Now().UTC()}
+		if t.Demo != nil && t.Demo.ResetAfter > 0 {
+			s.ResetAt = s.Started.Add(t.Demo.ResetAfter)
+		}
+	}
+	s.Undo = append(s.Undo, u)
+	return state.Put(key, s)
+}
+
+// Reset takes back the session's changes, newest first, and reports through
+// env as it goes. A change that cannot be taken back stops the reset; it
+// and the older ones stay in the session for another try.
+func Reset(ctx context.Context, env *resource.Env) (int, error) {
+	s, err := Load()
+	if err != nil || s == nil {
+		return 0, err
+	}
+	done := 0
+	for i := len(s.Undo) - 1; i >= 0; i-- {
+		u := s.Undo[i]
+		if err := u.Run(ctx, env); err != nil {
+			s.Undo = s.Undo[:i+1]
+			if perr := state.Put(key, s); perr != nil {
+				return done, perr
+			}
+			return done, fmt.Errorf("%s: %w", u.ID, err)
+		}
+		env.Log("took back %s", u.ID)
+		done++
+	}
+	return done, state.Delete(key)
+}
@@ -0,0 +1,182 @@
+// Package du measures the disk space taken by the software a template
+// manages, and by the caches its package managers keep, to help decide
+// what to drop from a template on a small disk.
+package du
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/hmziqrs/maziq/internal/catalog"
+	"github.com/hmziqrs/maziq/internal/config"
+	"github.com/hmziqrs/maziq/internal/engine"
+	"github.com/hmziqrs/maziq/internal/modules/binaries"
+	"github.com/hmziqrs/maziq/internal/modules/software"
+	"github.com/hmziqrs/maziq/internal/resource"
+	"github.com/hmziqrs/maziq/internal/shell"
+)
+
+// Categories, in the order reports list them.
+const (
+	Formulae = "formulae"
+	Casks    = "casks"
+	Apps     = "apps"
+	Packages = "packages"
+	Binaries = "binaries"
+	Tools    = "tools"
+	Caches   = "caches"
+)
+
+// Categories lists every category in report order.
+var Categories = []string{Formulae, Casks, Apps, Packages, Binaries, Tools, Caches}
+
+// Entry is one installed package or cache.
+type Entry struct {
+	// ID is the resource that manages the package, "" for caches.
+	ID       string   `json:"id,omitempty"`
+	Name     string   `json:"name"`
+	Category string   `json:"category"`
+	Size     int64    `json:"size"`
+	Paths    []string `json:"paths"`
+}
+
+// Report is the disk usage of a template's software.
+type Report struct {
+	Entries []Entry `json:"entries"`
+	// Totals are by category.
+	Totals map[string]int64 `json:"totals"`
+	Total  int64            `json:"total"`
+}
+
+// Collect measures the installed software env.Template manages and the
+// package manager caches on the machine, largest first. Software that
+// cannot be described is reported to warn and left out.
+func Collect(ctx context.Context, env *resource.Env, warn func(id string, err error)) (Report, error) {
+	components, err := engine.Components(ctx, env, warn)
+	if err != nil {
+		return Report{}, err
+	}
+	var entries []Entry
+	// Toolchains such as rustup install several entries' executables as
+	// one file; it is counted for the first.
+	seen := map[string]bool{}
+	for _, c := range components {
+		e := Entry{ID: c.ID, Name: c.Name, Category: category(c.ID)}
+		for _, p := range c.Paths {
+			if !seen[p] {
+				seen[p] = true
+				e.Paths = append(e.Paths, p)
+				e.Size += Size(p)
+			}
+		}
+		if len(e.Paths) > 0 {
+			entries = append(entries, e)
+		}
+	}
+	for _, c := range caches(ctx, env) {
+		if _, err := os.Stat(c.Paths[0]); err == nil {
+			c.Category, c.Size = Caches, Size(c.Paths[0])
+			entries = append(entries, c)
+		}
+	}
+	r := Report{Totals: map[string]int64{}}
+	for _, e := range entries {
+		r.Totals[e.Category] += e.Size
+		r.Total += e.Size
+	}
+	r.Entries = Sort(entries, "size")
+	return r, nil
+}
+
+// category files a component under the backend that installed it.
+func category(id string) string {
+	if strings.HasPrefix(id, binaries.Kind+":") {
+		return Binaries
+	}
+	name, ok := strings.CutPrefix(id, software.Kind+":")
+	if !ok {
+		// App Store and directly downloaded apps.
+		return Apps
+	}
+	sw, _ := catalog.Lookup(name)
+	switch sw.Primary().Backend {
+	case catalog.BackendBrew:
+		return Formulae
+	case catalog.BackendCask:
+		return Casks
+	case catalog.BackendNPM, catalog.BackendCargo, catalog.BackendUV:
+		return Packages
+	}
+	if sw.App != "" {
+		return Apps
+	}
+	return Tools
+}
+
+// caches returns the package manager caches, whether or not they exist.
+func caches(ctx context.Context, env *resource.Env) []Entry {
+	home, _ := os.UserHomeDir()
+	brew := filepath.Join(home, "Library", "Caches", "Homebrew")
+	if res, err := env.Run(ctx, shell.Cmd("brew", "--cache")); err == nil && strings.TrimSpace(res.Stdout) != "" {
+		brew = strings.TrimSpace(res.Stdout)
+	}
+	return []Entry{
+		{Name: "Homebrew downloads", Paths: []string{brew}},
+		{Name: "npm", Paths: []string{filepath.Join(home, ".npm")}},
+		{Name: "Cargo registry", Paths: []string{filepath.Join(home, ".cargo", "registry")}},
+		{Name: "uv", Paths: []string{filepath.Join(home, ".cache", "uv")}},
+		{Name: "pip", Paths: []string{filepath.Join(home, "Library", "Caches", "pip")}},
+		{Name: "MazIQ", Paths: []string{filepath.Join(config.Dir(), "cache")}},
+	}
+}
+
+// Sort orders entries by "size" (largest first), "name", or "category"
+// (report order, then largest first). It sorts in place and returns
+// entries.
+func Sort(entries []Entry, by string) []Entry {
+	bySize := func(a, b Entry) int {
+		if a.Size != b.Size {
+			if a.Size > b.Size {
+				return -1
+			}
+			return 1
+		}
+		return strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+	}
+	switch by {
+	case "name":
+		slices.SortStableFunc(entries, func(a, b Entry) int {
+			return strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+		})
+	case "category":
+		slices.SortStableFunc(entries, func(a, b Entry) int {
+			if c := slices.Index(Categories, a.Category) - slices.Index(Categories, b.Category); c != 0 {
+				return c
+			}
+			return bySize(a, b)
+		})
+	default:
+		slices.SortStableFunc(entries, bySize)
+	}
+	return entries
+}
+
+// Size returns the bytes of the regular files under path, without
+// following symlinks.
+func Size(path string) int64 {
+	var n int64
+	_ = filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if info, err := d.Info(); err == nil && info.Mode().IsRegular() {
+			n += info.Size()
+		}
+		return nil
+	})
+	return n
+}
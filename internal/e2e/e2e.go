@@ -0,0 +1,91 @@
+// Package e2e runs a template's end-to-end assertions: the [[tests]] it
+// declares plus the assertions contributed by its resources.
+package e2e
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/hmziqrs/maziq/internal/resource"
+	"github.com/hmziqrs/maziq/internal/shell"
+)
+
+// Case is one assertion to run.
+type Case struct {
+	Name     string `json:"name"`
+	Software string `json:"software,omitempty"`
+	// Resource is the ID of the resource that contributed the case.
+	Resource string `json:"resource,omitempty"`
+	Run      string `json:"run"`
+	Expect   string `json:"expect,omitempty"`
+	// Secrets maps environment variables of Run to secret names.
+	Secrets map[string]string `json:"secrets,omitempty"`
+}
+
+// Result is the outcome of one case.
+type Result struct {
+	Case     Case          `json:"case"`
+	Passed   bool          `json:"passed"`
+	Output   string        `json:"output,omitempty"`
+	Error    string        `json:"error,omitempty"`
+	Duration time.Duration `json:"duration"`
+}
+
+// Cases collects the template tests whose conditions hold plus every
+// resource assertion.
+func Cases(env *resource.Env, rs []resource.Resource) ([]Case, error) {
+	var out []Case
+	for _, t := range env.Template.Tests {
+		ok, err := env.Holds(t.When)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			out = append(out, Case{Name: t.Name, Software: t.Software, Run: t.Run, Expect: t.Expect})
+		}
+	}
+	for _, r := range rs {
+		a, ok := r.(resource.Asserter)
+		if !ok {
+			continue
+		}
+		for _, as := range a.Assertions() {
+			out = append(out, Case{Name: as.Name, Resource: r.ID(), Run: as.Run, Expect: as.Expect, Secrets: as.Secrets})
+		}
+	}
+	return out, nil
+}
+
+// Run executes every case in order. A case passes when its command exits
+// zero and, if Expect is set, its output contains the expanded Expect.
+func Run(ctx context.Context, env *resource.Env, cases []Case) []Result {
+	out := make([]Result, 0, len(cases))
+	for _, c := range cases {
+		start := time.Now()
+		cmd := shell.Script(env.Expand(c.Run))
+		var err error
+		for name, secret := range c.Secrets {
+			var v string
+			if v, err = env.Secrets.Get(ctx, secret); err != nil {
+				break
+			}
+			cmd.Env = append(cmd.Env, name+"="+v)
+		}
+		var res shell.Result
+		if err == nil {
+			res, err = env.Run(ctx, cmd)
+		}
+		r := Result{Case: c, Output: strings.TrimSpace(res.Stdout + res.Stderr), Duration: time.Since(start)}
+		switch expect := env.Expand(c.Expect); {
+		case err != nil:
+			r.Error = err.Error()
+		case expect != "" && !strings.Contains(r.Output, expect):
+			r.Error = "output does not contain " + `"` + expect + `"`
+		default:
+			r.Passed = true
+		}
+		out = append(out, r)
+	}
+	return out
+}
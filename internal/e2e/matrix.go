@@ -0,0 +1,149 @@
+package e2e
+
+import (
+	"github.com/hmziqrs/maziq/internal/facts"
+	"github.com/hmziqrs/maziq/internal/resource"
+	"github.com/hmziqrs/maziq/internal/templates"
+)
+
+// Statuses of a case on a platform. A case missing from a platform's
+// column does not apply there.
+const (
+	StatusPassed   = "passed"
+	StatusFailed   = "failed"
+	StatusUntested = "untested"
+)
+
+// Matrix is the consolidated report of a template's test matrix: every case
+// that applies on at least one platform, and how it fared on each.
+type Matrix struct {
+	Template  string   `json:"template"`
+	Platforms []string `json:"platforms"`
+	Rows      []Row    `json:"rows"`
+}
+
+// Row is a case across the platforms of the matrix.
+type Row struct {
+	Name     string `json:"name"`
+	Resource string `json:"resource,omitempty"`
+	// Status maps platform names to a Status constant.
+	Status map[string]string `json:"status"`
+}
+
+// PlatformEnv returns env as it would be on p: the current machine's facts
+// with p's laid over them.
+func PlatformEnv(env *resource.Env, p templates.Platform) *resource.Env {
+	f := make(facts.Facts, len(env.Facts)+len(p.Facts))
+	for k, v := range env.Facts {
+		f[k] = v
+	}
+	for k, v := range p.Facts {
+		f[k] = v
+	}
+	pe := *env
+	pe.Facts = f
+	return &pe
+}
+
+// Matches reports whether env is on p: every fact p sets has that value.
+// Template vars p sets are assumed rather than compared.
+func Matches(env *resource.Env, p templates.Platform) bool {
+	for k, v := range p.Facts {
+		if facts.Known(k) && env.Facts[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// BuildMatrix lists the cases of every platform of env.Template's matrix,
+// all untested, and returns the cases to run on this machine: those of the
+// platforms it matches. Resources are built per platform with build, as
+// their assertions can depend on facts too.
+func BuildMatrix(env *resource.Env, build func(*resource.Env) ([]resource.Resource, error)) (*Matrix, []Case, error) {
+	m := &Matrix{Template: env.Template.Name, Platforms: []string{}, Rows: []Row{}}
+	index := map[string]int{}
+	var here []Case
+	queued := map[string]bool{}
+	for _, p := range env.Template.Matrix {
+		m.Platforms = append(m.Platforms, p.Name)
+		pe := PlatformEnv(env, p)
+		rs, err := build(pe)
+		if err != nil {
+			return nil, nil, err
+		}
+		cases, err := Cases(pe, rs)
+		if err != nil {
+			return nil, nil, err
+		}
+		match := Matches(env, p)
+		for _, c := range cases {
+			k := c.key()
+			i, ok := index[k]
+			if !ok {
+				i = len(m.Rows)
+				index[k] = i
+				m.Rows = append(m.Rows, Row{Name: c.Name, Resource: c.Resource, Status: map[string]string{}})
+			}
+			m.Rows[i].Status[p.Name] = StatusUntested
+			if match && !queued[k] {
+				queued[k] = true
+				here = append(here, c)
+			}
+		}
+	}
+	return m, here, nil
+}
+
+func (c Case) key() string { return c.Resource + "\x00" + c.Name }
+
+// Record fills in results for every platform in platforms.
+func (m *Matrix) Record(platforms []string, results []Result) {
+	status := map[string]string{}
+	for _, r := range results {
+		status[r.Case.key()] = StatusFailed
+		if r.Passed {
+			status[r.Case.key()] = StatusPassed
+		}
+	}
+	for i := range m.Rows {
+		s, ok := status[Case{Name: m.Rows[i].Name, Resource: m.Rows[i].Resource}.key()]
+		if !ok {
+			continue
+		}
+		for _, p := range platforms {
+			if _, applies := m.Rows[i].Status[p]; applies {
+				m.Rows[i].Status[p] = s
+			}
+		}
+	}
+}
+
+// Merge takes the results o has for cases m leaves untested, so matrices
+// from runs on different platforms add up to one report.
+func (m *Matrix) Merge(o *Matrix) {
+	for _, or := range o.Rows {
+		for i := range m.Rows {
+			r := &m.Rows[i]
+			if r.Name != or.Name || r.Resource != or.Resource {
+				continue
+			}
+			for p, s := range or.Status {
+				if cur, applies := r.Status[p]; applies && cur == StatusUntested {
+					r.Status[p] = s
+				}
+			}
+		}
+	}
+}
+
+// Count returns how many cases have status on platform.
+func (m *Matrix) Count(platform, status string) int {
+	n := 0
+	for _, r := range m.Rows {
+		if r.Status[platform] == status {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,575 @@
+// Package engine plans and applies a template: it builds resources from every
+// registered module, orders them by their requirements, checks which ones
+// have drifted and converges those.
+package engine
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hmziqrs/maziq/internal/demo"
+	"github.com/hmziqrs/maziq/internal/fetch"
+	"github.com/hmziqrs/maziq/internal/policy"
+	"github.com/hmziqrs/maziq/internal/quarantine"
+	"github.com/hmziqrs/maziq/internal/remedy"
+	"github.com/hmziqrs/maziq/internal/resource"
+)
+
+// Item is one planned resource together with its checked state.
+type Item struct {
+	Resource resource.Resource
+	State    resource.State
+	// Err is set when Check itself failed.
+	Err error
+	// Checked is how long Check took.
+	Checked time.Duration
+	// Cached is when an earlier apply verified the item, if the plan
+	// trusted that instead of checking it.
+	Cached time.Time
+	// Local is set when the machine's local file added the item or changed
+	// what it wants.
+	Local bool
+}
+
+// ID returns the resource ID.
+func (i Item) ID() string { return i.Resource.ID() }
+
+// Pending reports whether Apply would act on the item.
+func (i Item) Pending() bool { return i.Err != nil || !i.State.Converged }
+
+// Plan is the ordered set of resources for a template.
+type Plan struct {
+	Template string
+	// Source is the path the template was loaded from.
+	Source string
+	Items  []Item
+	// Violations are the policy rules the plan breaks. Pending items that
+	// break one are blocked until the plan is overridden.
+	Violations []policy.Violation
+	// Overridden is set once Override has lifted those blocks.
+	Overridden bool
+	// Scope, when set, is the only scope the plan holds; see Restrict.
+	Scope string
+	// Ordering is what the template's [[order]] rules add to the
+	// requirements of the items; see Ordering.
+	Ordering map[string][]string
+	// GroupsOff names the template's groups that are off, whose resources
+	// the plan leaves out.
+	GroupsOff []string
+}
+
+// Pending returns the items that are not converged.
+func (p *Plan) Pending() []Item {
+	var out []Item
+	for _, it := range p.Items {
+		if it.Pending() {
+			out = append(out, it)
+		}
+	}
+	return out
+}
+
+// Cached returns how many items were trusted from an earlier apply instead
+// of checked.
+func (p *Plan) Cached() int {
+	n := 0
+	for _, it := range p.Items {
+		if !it.Cached.IsZero() {
+			n++
+		}
+	}
+	return n
+}
+
+// Summary is a plan without its resources: what the CLI prints with --json
+// and the daemon sends to its clients.
+type Summary struct {
+	Template   string             `json:"template"`
+	Items      []ItemSummary      `json:"items"`
+	Violations []policy.Violation `json:"violations,omitempty"`
+	GroupsOff  []string           `json:"groups_off,omitempty"`
+}
+
+// ItemSummary describes one planned item.
+type ItemSummary struct {
+	ID          string `json:"id"`
+	Description string `json:"description"`
+	Pending     bool   `json:"pending"`
+	Current     string `json:"current"`
+	Desired     string `json:"desired"`
+	Blocked     string `json:"blocked,omitempty"`
+	Warning     string `json:"warning,omitempty"`
+	Error       string `json:"error,omitempty"`
+	Scope       string `json:"scope"`
+	Local       bool   `json:"local,omitempty"`
+}
+
+// Summary returns the plan's items as plain values.
+func (p *Plan) Summary() Summary {
+	out := Summary{Template: p.Template, Items: []ItemSummary{}, Violations: p.Violations, GroupsOff: p.GroupsOff}
+	for _, it := range p.Items {
+		i := ItemSummary{ID: it.ID(), Description: it.Resource.Describe(), Pending: it.Pending(), Current: it.State.Current, Desired: it.State.Desired, Blocked: it.State.Blocked, Warning: it.State.Warning, Scope: resource.ScopeOf(it.Resource), Local: it.Local}
+		if it.Err != nil {
+			i.Error = it.Err.Error()
+		}
+		out.Items = append(out.Items, i)
+	}
+	return out
+}
+
+// Pending returns the summaries of the items that are not converged.
+func (s Summary) Pending() []ItemSummary {
+	var out []ItemSummary
+	for _, it := range s.Items {
+		if it.Pending {
+			out = append(out, it)
+		}
+	}
+	return out
+}
+
+// Resources builds the ordered resources for env.Template without checking
+// them. Encrypted template values are decrypted first so resources see
+// plaintext.
+func Resources(ctx context.Context, env *resource.Env) ([]resource.Resource, error) {
+	rs, _, err := resources(ctx, env)
+	return rs, err
+}
+
+// proxied returns ctx with env.Template's proxy and mirrors for the
+// downloads made with it.
+func proxied(ctx context.Context, env *resource.Env) context.Context {
+	if env.Template == nil {
+		return ctx
+	}
+	return fetch.WithProxy(ctx, env.Template.Proxy.Environ())
+}
+
+// resources is Resources, also returning the template's Ordering.
+func resources(ctx context.Context, env *resource.Env) ([]resource.Resource, map[string][]string, error) {
+	if err := env.Template.Decrypt(ctx, env.Secrets); err != nil {
+		return nil, nil, err
+	}
+	rs, err := resource.Build(env)
+	if err != nil {
+		return nil, nil, err
+	}
+	rs = grouped(env, rs)
+	ordering, err := Ordering(env, rs)
+	if err != nil {
+		return nil, nil, err
+	}
+	if rs, err = filtered(env, rs, ordering); err != nil {
+		return nil, nil, err
+	}
+	rs, err = order(rs, ordering)
+	return rs, ordering, err
+}
+
+// Components describes the installed software env.Template manages, in
+// plan order. Resources that fail to describe themselves are reported to
+// warn and skipped.
+func Components(ctx context.Context, env *resource.Env, warn func(id string, err error)) ([]resource.Component, error) {
+	ctx = proxied(ctx, env)
+	rs, err := Resources(ctx, env)
+	if err != nil {
+		return nil, err
+	}
+	var out []resource.Component
+	for _, r := range rs {
+		c, ok := r.(resource.Componenter)
+		if !ok {
+			continue
+		}
+		comp, installed, err := c.Component(ctx, env)
+		if err != nil {
+			warn(r.ID(), err)
+			continue
+		}
+		if installed {
+			out = append(out, comp)
+		}
+	}
+	return out, nil
+}
+
+// Fetch makes the downloads applying env.Template would make, for every
+// resource that can, installed or not, in plan order. It returns how many
+// resources fetched. Resources that fail, or that need the network at
+// apply time, are reported to warn.
+func Fetch(ctx context.Context, env *resource.Env, warn func(id string, err error)) (int, error) {
+	ctx = proxied(ctx, env)
+	rs, err := Resources(ctx, env)
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, r := range rs {
+		f, ok := r.(resource.Fetcher)
+		if !ok {
+			continue
+		}
+		env.Log("fetching %s", r.ID())
+		if err := f.Fetch(ctx, env); err != nil {
+			if ctx.Err() != nil {
+				return n, ctx.Err()
+			}
+			warn(r.ID(), err)
+			continue
+		}
+		n++
+	}
+	return n, nil
+}
+
+// Build creates the plan for env.Template, checking every resource.
+func Build(ctx context.Context, env *resource.Env) (*Plan, error) {
+	ctx = proxied(ctx, env)
+	rs, ordering, err := resources(ctx, env)
+	if err != nil {
+		return nil, err
+	}
+	plan := &Plan{Template: env.Template.Name, Source: env.Template.Path, Ordering: ordering, GroupsOff: groupsOff(env)}
+	for _, r := range rs {
+		start := time.Now()
+		state, err := r.Check(ctx, env)
+		plan.Items = append(plan.Items, Item{Resource: r, State: state, Err: err, Checked: time.Since(start)})
+	}
+	if err := enforce(ctx, env, plan); err != nil {
+		return nil, err
+	}
+	untracked(ctx, env, plan)
+	if err := quarantined(plan); err != nil {
+		return nil, err
+	}
+	if err := markLocal(ctx, env, plan); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// markLocal flags the items of plan that the machine's local file added
+// or changed, by comparing them with the shared template's.
+func markLocal(ctx context.Context, env *resource.Env, plan *Plan) error {
+	if env.Template.Shared == nil {
+		return nil
+	}
+	shared := *env
+	shared.Template = env.Template.Shared
+	if err := shared.Template.Decrypt(ctx, env.Secrets); err != nil {
+		return err
+	}
+	rs, err := resource.Build(&shared)
+	if err != nil {
+		return err
+	}
+	hashes := map[string]string{}
+	for _, r := range rs {
+		hashes[r.ID()] = Hash(r)
+	}
+	for i, it := range plan.Items {
+		plan.Items[i].Local = hashes[it.ID()] != Hash(it.Resource)
+	}
+	return nil
+}
+
+// policyPrefix starts the Blocked reason of items the policy blocks.
+const policyPrefix = "policy: "
+
+// enforce evaluates the policy, if there is one, against plan. Pending
+// items that break it are blocked, so apply skips them; converged ones are
+// flagged, as there is nothing to hold back.
+func enforce(ctx context.Context, env *resource.Env, plan *Plan) error {
+	p, err := policy.Load()
+	if err != nil || p == nil {
+		return err
+	}
+	items := make([]policy.Item, len(plan.Items))
+	for i, it := range plan.Items {
+		items[i] = policy.Item{Resource: it.Resource, State: it.State}
+	}
+	plan.Violations = p.Evaluate(ctx, env.Runner, items)
+	for _, v := range plan.Violations {
+		for i := range plan.Items {
+			it := &plan.Items[i]
+			switch {
+			case it.ID() != v.ID:
+			case it.Pending() && it.State.Blocked == "":
+				it.State.Blocked = policyPrefix + v.Message
+			case !it.Pending() && it.State.Warning == "":
+				it.State.Warning = policyPrefix + v.Message
+			}
+		}
+	}
+	return nil
+}
+
+// untracked blocks the pending items of a demo template that could not be
+// taken back by a reset, and all of them while another template's demo
+// session is open.
+func untracked(ctx context.Context, env *resource.Env, plan *Plan) {
+	if env.Template == nil || env.Template.Demo == nil {
+		return
+	}
+	joinErr := demo.Join(env.Template)
+	for i := range plan.Items {
+		it := &plan.Items[i]
+		if !it.Pending() || it.State.Blocked != "" {
+			continue
+		}
+		if joinErr != nil {
+			it.State.Blocked = joinErr.Error()
+			continue
+		}
+		rv, ok := it.Resource.(resource.Reverter)
+		if !ok {
+			it.State.Blocked = "cannot be taken back by maziq demo reset, so demo templates do not apply it"
+			continue
+		}
+		if _, err := rv.Undo(ctx, env); err != nil {
+			it.State.Blocked = fmt.Sprintf("cannot be taken back by maziq demo reset (%v), so demo templates do not apply it", err)
+		}
+	}
+}
+
+// quarantined blocks the pending items that kept failing until the user
+// clears them.
+func quarantined(plan *Plan) error {
+	records, err := quarantine.Load()
+	if err != nil {
+		return err
+	}
+	for i := range plan.Items {
+		it := &plan.Items[i]
+		rec, ok := records[it.ID()]
+		if !ok || !rec.Quarantined() || !it.Pending() || it.State.Blocked != "" {
+			continue
+		}
+		it.State.Blocked = fmt.Sprintf("quarantined after %d failed applies; run maziq quarantine clear %s to try again", rec.Failures, it.ID())
+	}
+	return nil
+}
+
+// Override lifts the blocks the policy put on plan, for an apply the user
+// chose to run against it. The violations stay on the plan for the record.
+func (p *Plan) Override() {
+	p.Overridden = len(p.Violations) > 0
+	for i := range p.Items {
+		if strings.HasPrefix(p.Items[i].State.Blocked, policyPrefix) {
+			p.Items[i].State.Blocked = ""
+		}
+	}
+}
+
+// Restrict drops the items outside scope, for applying a shared machine's
+// system baseline separately from each user's own resources. Pending items
+// that require a dropped item that is not converged are blocked until the
+// other scope has been applied.
+func (p *Plan) Restrict(scope string) {
+	p.Scope = scope
+	dropped := map[string]bool{}
+	var items []Item
+	for _, it := range p.Items {
+		if resource.ScopeOf(it.Resource) == scope {
+			items = append(items, it)
+		} else if it.Pending() {
+			dropped[it.ID()] = true
+		}
+	}
+	other := resource.ScopeSystem
+	if scope == resource.ScopeSystem {
+		other = resource.ScopeUser
+	}
+	for i := range items {
+		it := &items[i]
+		if id := blockedBy(it.Resource, p.Ordering, dropped); id != "" && it.Pending() && it.State.Blocked == "" {
+			it.State.Blocked = fmt.Sprintf("requires %s from the %s scope; run maziq apply --%s first", id, other, other)
+		}
+	}
+	p.Items = items
+}
+
+// Exclude leaves the pending items ids out of the run, for a user picking
+// changes from the plan. Pending items that require one are blocked.
+func (p *Plan) Exclude(ids []string) {
+	dropped := map[string]bool{}
+	var items []Item
+	for _, it := range p.Items {
+		if it.Pending() && slices.Contains(ids, it.ID()) {
+			dropped[it.ID()] = true
+		} else {
+			items = append(items, it)
+		}
+	}
+	for i := range items {
+		it := &items[i]
+		if id := blockedBy(it.Resource, p.Ordering, dropped); id != "" && it.Pending() && it.State.Blocked == "" {
+			it.State.Blocked = fmt.Sprintf("requires %s, which was left out of this run", id)
+		}
+	}
+	p.Items = items
+}
+
+// order sorts resources so requirements, their own and the template's
+// ordering, come first while otherwise keeping builder order. Requirements
+// on resources absent from the plan are ignored.
+func order(rs []resource.Resource, ordering map[string][]string) ([]resource.Resource, error) {
+	index := map[string]int{}
+	for i, r := range rs {
+		index[r.ID()] = i
+	}
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	mark := make([]int, len(rs))
+	out := make([]resource.Resource, 0, len(rs))
+	var visit func(i int, path []string) error
+	visit = func(i int, path []string) error {
+		switch mark[i] {
+		case done:
+			return nil
+		case visiting:
+			return cycleError(append(path, rs[i].ID()), ordering)
+		}
+		mark[i] = visiting
+		for _, id := range requirements(rs[i], ordering) {
+			if j, ok := index[id]; ok {
+				if err := visit(j, append(path, rs[i].ID())); err != nil {
+					return err
+				}
+			}
+		}
+		mark[i] = done
+		out = append(out, rs[i])
+		return nil
+	}
+	for i := range rs {
+		if err := visit(i, nil); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// Outcome statuses.
+const (
+	OutcomeOK      = "ok"
+	OutcomeApplied = "applied"
+	OutcomeFailed  = "failed"
+	OutcomeSkipped = "skipped"
+)
+
+// Outcome is what happened to one item during Apply.
+type Outcome struct {
+	ID       string        `json:"id"`
+	Status   string        `json:"status"`
+	Error    string        `json:"error,omitempty"`
+	Duration time.Duration `json:"duration"`
+	// TimedOut is set when a command the change ran was killed by the
+	// timeout or idle watchdog.
+	TimedOut bool `json:"timed_out,omitempty"`
+	// Restart is what the applied change needs restarted to take effect.
+	Restart *resource.Restart `json:"restart,omitempty"`
+	// Scope is the resource's, user or system.
+	Scope string `json:"scope"`
+	// Quarantined is set when this failure quarantined the resource, and
+	// Bundle is then where its diagnostics were written.
+	Quarantined bool   `json:"quarantined,omitempty"`
+	Bundle      string `json:"bundle,omitempty"`
+	// Fix is set when the failure is a known one MazIQ can fix.
+	Fix *remedy.Fix `json:"fix,omitempty"`
+}
+
+// Report summarises an Apply run.
+type Report struct {
+	Template string    `json:"template"`
+	Started  time.Time `json:"started"`
+	Finished time.Time `json:"finished"`
+	Outcomes []Outcome `json:"outcomes"`
+}
+
+// Restart merges what the applied changes need restarted.
+func (r Report) Restart() resource.Restart {
+	var out resource.Restart
+	for _, o := range r.Outcomes {
+		if o.Restart != nil {
+			out = out.Merge(*o.Restart)
+		}
+	}
+	return out
+}
+
+// Slowest returns the outcomes that took time, longest first, at most n.
+func (r Report) Slowest(n int) []Outcome {
+	var out []Outcome
+	for _, o := range r.Outcomes {
+		if o.Duration > 0 {
+			out = append(out, o)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Duration > out[j].Duration })
+	if len(out) > n {
+		out = out[:n]
+	}
+	return out
+}
+
+// Count returns how many outcomes have status.
+func (r Report) Count(status string) int {
+	n := 0
+	for _, o := range r.Outcomes {
+		if o.Status == status {
+			n++
+		}
+	}
+	return n
+}
+
+// Options tunes Apply.
+type Options struct {
+	// DryRun reports what would be applied without doing it.
+	DryRun bool
+	// OnTimeout is the config.Apply policy for changes whose command was
+	// killed by the watchdog. Empty means fail.
+	OnTimeout string
+	// QuarantineAfter is how many applies of a resource may fail in a row
+	// before it is quarantined. Zero never quarantines.
+	QuarantineAfter int
+	// Confirm, when set, is asked before each pending change is applied,
+	// with its position among them; see Step.
+	Confirm func(ctx context.Context, it Item, index, total int) Step
+	// Stop, when closed, skips the changes not yet started. Unlike
+	// cancelling the context, it lets the change in progress finish.
+	Stop <-chan struct{}
+}
+
+// Step is the answer to Options.Confirm.
+type Step int
+
+const (
+	// StepApply applies the change.
+	StepApply Step = iota
+	// StepSkip skips it, and the changes that require it.
+	StepSkip
+	// StepAbort skips it and every change after it.
+	StepAbort
+	// StepApplyAll applies it and the rest without asking again.
+	StepApplyAll
+)
+
+func blockedBy(r resource.Resource, ordering map[string][]string, failed map[string]bool) string {
+	var ids []string
+	for _, id := range requirements(r, ordering) {
+		if failed[id] {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return strings.Join(ids, ", ")
+}
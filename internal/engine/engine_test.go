@@ -0,0 +1,216 @@
+package engine
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/hmziqrs/maziq/internal/facts"
+	"github.com/hmziqrs/maziq/internal/resource"
+	"github.com/hmziqrs/maziq/internal/shell"
+	"github.com/hmziqrs/maziq/internal/templates"
+)
+
+// chain has c before a through an [[order]] rule, and b on its own.
+const chain = `
+name = "chain"
+
+[[defaults]]
+domain = "com.example"
+key = "a"
+value = "1"
+
+[[defaults]]
+domain = "com.example"
+key = "b"
+value = "2"
+
+[[defaults]]
+domain = "com.example"
+key = "c"
+value = "3"
+
+[[order]]
+resource = "defaults:com.example/a"
+requires = ["defaults:com.example/c"]
+`
+
+// testEnv returns an Env for the template src that runs m instead of
+// commands, with MazIQ's state kept in a temporary directory.
+func testEnv(t *testing.T, src string, m *shell.Mock) *resource.Env {
+	t.Helper()
+	t.Setenv("MAZIQ_HOME", t.TempDir())
+	t.Setenv("MAZIQ_POLICY", "")
+	tmpl, err := templates.Parse([]byte(src), "test.toml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &resource.Env{
+		Runner:   m,
+		Facts:    facts.Facts{"arch": "arm64", "macos": "15.0", "os": "darwin", "shell": "zsh", "home": t.TempDir()},
+		Template: tmpl,
+	}
+}
+
+func testPlan(t *testing.T, env *resource.Env) *Plan {
+	t.Helper()
+	plan, err := Build(context.Background(), env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return plan
+}
+
+func ids(items []Item) []string {
+	var out []string
+	for _, it := range items {
+		out = append(out, it.ID())
+	}
+	return out
+}
+
+func TestBuildOrdersByRequirements(t *testing.T) {
+	plan := testPlan(t, testEnv(t, chain, &shell.Mock{}))
+	want := []string{"defaults:com.example/c", "defaults:com.example/a", "defaults:com.example/b"}
+	if got := ids(plan.Items); !slices.Equal(got, want) {
+		t.Errorf("order = %v, want %v", got, want)
+	}
+}
+
+func TestBuildChecksWithRunner(t *testing.T) {
+	m := &shell.Mock{Responses: []shell.Response{{Match: "defaults read com.example b", Stdout: "2\n"}}}
+	plan := testPlan(t, testEnv(t, chain, m))
+	var pending []string
+	for _, it := range plan.Pending() {
+		pending = append(pending, it.ID())
+	}
+	if want := []string{"defaults:com.example/c", "defaults:com.example/a"}; !slices.Equal(pending, want) {
+		t.Errorf("pending = %v, want %v", pending, want)
+	}
+}
+
+func TestApplySkipsDependentsOfFailure(t *testing.T) {
+	m := &shell.Mock{Responses: []shell.Response{{Match: "defaults write com.example c", Exit: 1, Stderr: "denied"}}}
+	env := testEnv(t, chain, m)
+	report := Apply(context.Background(), testPlan(t, env), env, Options{})
+	status := map[string]Outcome{}
+	for _, o := range report.Outcomes {
+		status[o.ID] = o
+	}
+	for id, want := range map[string]string{
+		"defaults:com.example/c": OutcomeFailed,
+		"defaults:com.example/a": OutcomeSkipped,
+		"defaults:com.example/b": OutcomeApplied,
+	} {
+		if got := status[id].Status; got != want {
+			t.Errorf("%s: status = %q, want %q", id, got, want)
+		}
+	}
+	if got, want := status["defaults:com.example/a"].Error, "requires defaults:com.example/c"; got != want {
+		t.Errorf("skip reason = %q, want %q", got, want)
+	}
+	for _, c := range m.Calls() {
+		if line := c.String(); strings.Contains(line, "write com.example a") {
+			t.Errorf("ran %q after its requirement failed", line)
+		}
+	}
+}
+
+func TestApplyDryRunWritesNothing(t *testing.T) {
+	m := &shell.Mock{}
+	env := testEnv(t, chain, m)
+	plan := testPlan(t, env)
+	checks := len(m.Calls())
+	report := Apply(context.Background(), plan, env, Options{DryRun: true})
+	if n := report.Count(OutcomeSkipped); n != 3 {
+		t.Errorf("skipped %d items, want 3", n)
+	}
+	if calls := m.Calls()[checks:]; len(calls) > 0 {
+		t.Errorf("dry run ran %v", calls)
+	}
+}
+
+func TestApplyStopFinishesCurrentChange(t *testing.T) {
+	m := &shell.Mock{}
+	env := testEnv(t, chain, m)
+	plan := testPlan(t, env)
+	stop := make(chan struct{})
+	opts := Options{Stop: stop}
+	// The first change closes stop as it is confirmed, as a client going
+	// away mid-change would.
+	opts.Confirm = func(ctx context.Context, it Item, index, total int) Step {
+		close(stop)
+		return StepApplyAll
+	}
+	report := Apply(context.Background(), plan, env, opts)
+	want := []string{OutcomeApplied, OutcomeSkipped, OutcomeSkipped}
+	for i, o := range report.Outcomes {
+		if o.Status != want[i] {
+			t.Errorf("%s: status = %q, want %q", o.ID, o.Status, want[i])
+		}
+	}
+}
+
+func TestExcludeBlocksDependents(t *testing.T) {
+	plan := testPlan(t, testEnv(t, chain, &shell.Mock{}))
+	plan.Exclude([]string{"defaults:com.example/c"})
+	if want := []string{"defaults:com.example/a", "defaults:com.example/b"}; !slices.Equal(ids(plan.Items), want) {
+		t.Fatalf("items = %v, want %v", ids(plan.Items), want)
+	}
+	if got, want := plan.Items[0].State.Blocked, "requires defaults:com.example/c, which was left out of this run"; got != want {
+		t.Errorf("a: blocked = %q, want %q", got, want)
+	}
+	if got := plan.Items[1].State.Blocked; got != "" {
+		t.Errorf("b: blocked = %q, want nothing", got)
+	}
+}
+
+// scoped has a user preference that waits for the system time zone.
+const scoped = `
+name = "scoped"
+
+[region]
+timezone = "Europe/Berlin"
+
+[[defaults]]
+domain = "com.example"
+key = "a"
+value = "1"
+
+[[order]]
+resource = "defaults:com.example/a"
+requires = ["timezone:system"]
+`
+
+func TestRestrict(t *testing.T) {
+	tests := []struct {
+		scope   string
+		want    []string
+		blocked string
+	}{
+		{resource.ScopeUser, []string{"defaults:com.example/a"}, "requires timezone:system from the system scope; run maziq apply --system first"},
+		{resource.ScopeSystem, []string{"defaults:/Library/Preferences/com.apple.timezone.auto/Active", "timezone:system"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.scope, func(t *testing.T) {
+			plan := testPlan(t, testEnv(t, scoped, &shell.Mock{}))
+			plan.Restrict(tt.scope)
+			if got := ids(plan.Items); !slices.Equal(got, tt.want) {
+				t.Fatalf("items = %v, want %v", got, tt.want)
+			}
+			if got := plan.Items[0].State.Blocked; got != tt.blocked {
+				t.Errorf("blocked = %q, want %q", got, tt.blocked)
+			}
+		})
+	}
+}
+
+func TestOnlyKeepsRequirements(t *testing.T) {
+	env := testEnv(t, scoped, &shell.Mock{})
+	env.Only = []string{"timezone"}
+	plan := testPlan(t, env)
+	if want := []string{"defaults:/Library/Preferences/com.apple.timezone.auto/Active", "timezone:system"}; !slices.Equal(ids(plan.Items), want) {
+		t.Errorf("items = %v, want %v", ids(plan.Items), want)
+	}
+}
@@ -0,0 +1,262 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hmziqrs/maziq/internal/config"
+	"github.com/hmziqrs/maziq/internal/demo"
+	"github.com/hmziqrs/maziq/internal/fetch"
+	"github.com/hmziqrs/maziq/internal/quarantine"
+	"github.com/hmziqrs/maziq/internal/remedy"
+	"github.com/hmziqrs/maziq/internal/resource"
+	"github.com/hmziqrs/maziq/internal/shell"
+)
+
+// Event is something that happened while applying a plan. Renderers — the
+// CLI, the TUI — receive events from Start and switch on the concrete type.
+type Event interface {
+	event()
+}
+
+// TaskStarted is sent before a pending item is applied.
+type TaskStarted struct {
+	ID          string `json:"id"`
+	Description string `json:"description"`
+	// Index counts from 1 among the pending items; Total is their number.
+	Index int `json:"index"`
+	Total int `json:"total"`
+}
+
+// TaskLog is a progress line a resource reported through resource.Env.Log.
+type TaskLog struct {
+	ID   string `json:"id"`
+	Line string `json:"line"`
+}
+
+// TaskDone is sent once per item, pending or not, with what happened to it.
+type TaskDone struct {
+	Outcome Outcome `json:"outcome"`
+}
+
+// TaskProgress reports how many of the pending items have been dealt with,
+// for progress bars. It follows the TaskDone of every pending item.
+type TaskProgress struct {
+	Done  int `json:"done"`
+	Total int `json:"total"`
+}
+
+// RunFinished is the last event of a run.
+type RunFinished struct {
+	Report Report `json:"report"`
+}
+
+func (TaskStarted) event()  {}
+func (TaskLog) event()      {}
+func (TaskDone) event()     {}
+func (TaskProgress) event() {}
+func (RunFinished) event()  {}
+
+// Start applies plan on its own goroutine and returns the events of the run.
+// The channel is closed after RunFinished. Resources log through TaskLog
+// events instead of env.Logf; env.Wait is still called from the run's
+// goroutine, so it must be safe to call from there.
+func Start(ctx context.Context, plan *Plan, env *resource.Env, opts Options) <-chan Event {
+	events := make(chan Event, 16)
+	go func() {
+		defer close(events)
+		report := run(ctx, plan, env, opts, func(e Event) { events <- e })
+		events <- RunFinished{Report: report}
+	}()
+	return events
+}
+
+// Apply converges every pending item in order and returns the report. Log
+// lines go to env.Logf as they arrive. A failed item causes the items that
+// require it to be skipped; unrelated items still run.
+func Apply(ctx context.Context, plan *Plan, env *resource.Env, opts Options) Report {
+	var report Report
+	for e := range Start(ctx, plan, env, opts) {
+		switch e := e.(type) {
+		case TaskStarted:
+			env.Log("applying %s: %s", e.ID, e.Description)
+		case TaskLog:
+			env.Log("%s", e.Line)
+		case RunFinished:
+			report = e.Report
+		}
+	}
+	return report
+}
+
+func timedOut(err error) bool {
+	var t *shell.TimeoutError
+	return errors.As(err, &t)
+}
+
+func run(ctx context.Context, plan *Plan, env *resource.Env, opts Options, emit func(Event)) Report {
+	ctx = proxied(ctx, env)
+	report := Report{Template: plan.Template, Started: time.Now()}
+	failed := map[string]bool{}
+	streaks := failureStreaks(env, opts)
+	total := len(plan.Pending())
+	done := 0
+	confirm, aborted := opts.Confirm, false
+	// ask returns why the user turned it down, or "" to apply it.
+	ask := func(it Item) string {
+		if confirm == nil {
+			return ""
+		}
+		switch confirm(ctx, it, done+1, total) {
+		case StepSkip:
+			return "skipped at the prompt"
+		case StepAbort:
+			aborted = true
+			return "apply aborted at the prompt"
+		case StepApplyAll:
+			confirm = nil
+		}
+		return ""
+	}
+	for _, it := range plan.Items {
+		o := Outcome{ID: it.ID(), Status: OutcomeOK, Scope: resource.ScopeOf(it.Resource)}
+		// Resources see an Env whose log lines become events for this item.
+		taskEnv := *env
+		taskEnv.Logf = func(format string, args ...any) {
+			emit(TaskLog{ID: o.ID, Line: fmt.Sprintf(format, args...)})
+		}
+		switch {
+		case !it.Pending():
+		case it.State.Blocked != "":
+			o.Status, o.Error = OutcomeSkipped, it.State.Blocked
+			failed[o.ID] = true
+		case blockedBy(it.Resource, plan.Ordering, failed) != "":
+			o.Status, o.Error = OutcomeSkipped, "requires "+blockedBy(it.Resource, plan.Ordering, failed)
+			failed[o.ID] = true
+		case ctx.Err() != nil:
+			o.Status, o.Error = OutcomeSkipped, ctx.Err().Error()
+		case aborted:
+			o.Status, o.Error = OutcomeSkipped, "apply aborted at the prompt"
+		case stopped(opts.Stop):
+			o.Status, o.Error = OutcomeSkipped, "apply stopped before this change"
+		case opts.DryRun:
+			o.Status = OutcomeSkipped
+			taskEnv.Log("would apply %s: %s", o.ID, it.Resource.Describe())
+		default:
+			if reason := ask(it); reason != "" {
+				o.Status, o.Error = OutcomeSkipped, reason
+				failed[o.ID] = true
+				break
+			}
+			start := time.Now()
+			emit(TaskStarted{ID: o.ID, Description: it.Resource.Describe(), Index: done + 1, Total: total})
+			var transcript *quarantine.Transcript
+			if streaks != nil {
+				transcript = &quarantine.Transcript{Runner: taskEnv.Runner}
+				taskEnv.Runner = transcript
+				logf := taskEnv.Logf
+				taskEnv.Logf = func(format string, args ...any) {
+					transcript.Log(fmt.Sprintf(format, args...))
+					logf(format, args...)
+				}
+			}
+			// Downloads retried on a flaky network say so in the item's log.
+			applyCtx := fetch.WithLog(ctx, taskEnv.Log)
+			undo, err := prepareUndo(ctx, &taskEnv, it.Resource)
+			if err == nil {
+				err = it.Resource.Apply(applyCtx, &taskEnv)
+			}
+			if timedOut(err) && opts.OnTimeout == config.TimeoutRetry && ctx.Err() == nil {
+				taskEnv.Log("%v; retrying", err)
+				err = it.Resource.Apply(applyCtx, &taskEnv)
+			}
+			o.TimedOut = timedOut(err)
+			switch {
+			case o.TimedOut && opts.OnTimeout == config.TimeoutSkip:
+				o.Status, o.Error = OutcomeSkipped, err.Error()
+				failed[o.ID] = true
+			case err != nil:
+				o.Status, o.Error = OutcomeFailed, err.Error()
+				o.Fix = remedy.Match(&taskEnv, it.Resource, err)
+				failed[o.ID] = true
+				// An interrupted apply says nothing about the resource.
+				if transcript != nil && ctx.Err() == nil {
+					rec, qerr := quarantine.Fail(ctx, &taskEnv, it.Resource, err, transcript.String(), opts.QuarantineAfter)
+					if qerr != nil {
+						taskEnv.Log("warning: recording the failure of %s: %v", o.ID, qerr)
+					}
+					o.Quarantined, o.Bundle = rec.Quarantined(), rec.Bundle
+				}
+			default:
+				o.Status = OutcomeApplied
+				if _, ok := streaks[o.ID]; ok {
+					if err := quarantine.Clear(o.ID); err != nil {
+						taskEnv.Log("warning: clearing the failures of %s: %v", o.ID, err)
+					}
+				}
+				if undo != nil {
+					if err := demo.Track(env.Template, *undo); err != nil {
+						taskEnv.Log("warning: recording how to reset %s: %v", o.ID, err)
+					}
+				}
+				if r, ok := it.Resource.(resource.Restarter); ok && !r.Restarts().Empty() {
+					restart := r.Restarts()
+					o.Restart = &restart
+				}
+			}
+			o.Duration = time.Since(start)
+		}
+		report.Outcomes = append(report.Outcomes, o)
+		emit(TaskDone{Outcome: o})
+		if it.Pending() {
+			done++
+			emit(TaskProgress{Done: done, Total: total})
+		}
+	}
+	report.Finished = time.Now()
+	return report
+}
+
+// stopped reports whether stop has been closed.
+func stopped(stop <-chan struct{}) bool {
+	select {
+	case <-stop:
+		return true
+	default:
+		return false
+	}
+}
+
+// failureStreaks returns the resources that failed their last apply, or nil
+// when the run does not count failures towards quarantine.
+func failureStreaks(env *resource.Env, opts Options) map[string]quarantine.Record {
+	if opts.QuarantineAfter <= 0 || opts.DryRun {
+		return nil
+	}
+	records, err := quarantine.Load()
+	if err != nil {
+		env.Log("warning: reading failed applies: %v", err)
+		return nil
+	}
+	return records
+}
+
+// prepareUndo records how to take back r's change when env.Template is a
+// demo template, and returns nil otherwise. A change that cannot be
+// recorded is not made.
+func prepareUndo(ctx context.Context, env *resource.Env, r resource.Resource) (*resource.Undo, error) {
+	rv, ok := r.(resource.Reverter)
+	if !ok || env.Template == nil || env.Template.Demo == nil {
+		return nil, nil
+	}
+	if err := demo.Join(env.Template); err != nil {
+		return nil, err
+	}
+	u, err := rv.Undo(ctx, env)
+	if err != nil {
+		return nil, fmt.Errorf("recording how to reset it: %w", err)
+	}
+	return &u, nil
+}
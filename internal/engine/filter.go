@@ -0,0 +1,93 @@
+package engine
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/hmziqrs/maziq/internal/catalog"
+	"github.com/hmziqrs/maziq/internal/modules/software"
+	"github.com/hmziqrs/maziq/internal/resource"
+)
+
+// Subsystems returns the subsystems env.Only and env.Skip select r by: its
+// kind and, for software, the backend that installs it. Casks count as
+// brew too.
+func Subsystems(r resource.Resource) []string {
+	kind, _, _ := strings.Cut(r.ID(), ":")
+	out := []string{kind}
+	if s, ok := r.(*software.Software); ok {
+		switch b := s.Catalog().Primary().Backend; b {
+		case catalog.BackendUnknown:
+		case catalog.BackendCask:
+			out = append(out, string(b), string(catalog.BackendBrew))
+		default:
+			out = append(out, string(b))
+		}
+	}
+	return out
+}
+
+// inSubsystem reports whether r is in one of names, which may be plural:
+// dotfiles is dotfile.
+func inSubsystem(r resource.Resource, names []string) bool {
+	for _, s := range Subsystems(r) {
+		for _, n := range names {
+			if strings.TrimSuffix(n, "s") == strings.TrimSuffix(s, "s") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// filtered applies env.Only and env.Skip to rs. What the resources Only
+// keeps require is kept with them, unless Skip names it. A subsystem that
+// no resource is in is an error, as it is most likely misspelt.
+func filtered(env *resource.Env, rs []resource.Resource, ordering map[string][]string) ([]resource.Resource, error) {
+	if len(env.Only) == 0 && len(env.Skip) == 0 {
+		return rs, nil
+	}
+	var known []string
+	for _, r := range rs {
+		for _, s := range Subsystems(r) {
+			if !slices.Contains(known, s) {
+				known = append(known, s)
+			}
+		}
+	}
+	for _, n := range slices.Concat(env.Only, env.Skip) {
+		if !slices.ContainsFunc(rs, func(r resource.Resource) bool { return inSubsystem(r, []string{n}) }) {
+			return nil, fmt.Errorf("nothing in %s is in the %q subsystem; it has %s", env.Template.Name, n, strings.Join(known, ", "))
+		}
+	}
+	byID := map[string]resource.Resource{}
+	for _, r := range rs {
+		byID[r.ID()] = r
+	}
+	keep := map[string]bool{}
+	var add func(r resource.Resource)
+	add = func(r resource.Resource) {
+		if keep[r.ID()] || inSubsystem(r, env.Skip) {
+			return
+		}
+		keep[r.ID()] = true
+		for _, id := range requirements(r, ordering) {
+			if req, ok := byID[id]; ok {
+				add(req)
+			}
+		}
+	}
+	for _, r := range rs {
+		if len(env.Only) == 0 || inSubsystem(r, env.Only) {
+			add(r)
+		}
+	}
+	var out []resource.Resource
+	for _, r := range rs {
+		if keep[r.ID()] {
+			out = append(out, r)
+		}
+	}
+	return out, nil
+}
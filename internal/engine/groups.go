@@ -0,0 +1,66 @@
+package engine
+
+import (
+	"context"
+
+	"github.com/hmziqrs/maziq/internal/resource"
+)
+
+// GroupMembers returns the IDs each of the template's groups names, by
+// group name, whether the group is on or off.
+func GroupMembers(ctx context.Context, env *resource.Env) (map[string][]string, error) {
+	if err := env.Template.Decrypt(ctx, env.Secrets); err != nil {
+		return nil, err
+	}
+	rs, err := resource.Build(env)
+	if err != nil {
+		return nil, err
+	}
+	return members(env, rs), nil
+}
+
+func members(env *resource.Env, rs []resource.Resource) map[string][]string {
+	out := map[string][]string{}
+	for _, g := range env.Template.Groups {
+		for _, p := range g.Resources {
+			out[g.Name] = append(out[g.Name], matchIDs(env, p, rs)...)
+		}
+	}
+	return out
+}
+
+// groupsOff returns the names of the template's groups that are off.
+func groupsOff(env *resource.Env) []string {
+	var out []string
+	for _, g := range env.Template.Groups {
+		if !env.Template.GroupOn(g) {
+			out = append(out, g.Name)
+		}
+	}
+	return out
+}
+
+// grouped drops the resources that only groups which are off name.
+func grouped(env *resource.Env, rs []resource.Resource) []resource.Resource {
+	if len(groupsOff(env)) == 0 {
+		return rs
+	}
+	byGroup := members(env, rs)
+	on, off := map[string]bool{}, map[string]bool{}
+	for _, g := range env.Template.Groups {
+		for _, id := range byGroup[g.Name] {
+			if env.Template.GroupOn(g) {
+				on[id] = true
+			} else {
+				off[id] = true
+			}
+		}
+	}
+	var out []resource.Resource
+	for _, r := range rs {
+		if !off[r.ID()] || on[r.ID()] {
+			out = append(out, r)
+		}
+	}
+	return out
+}
@@ -0,0 +1,108 @@
+package engine
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/hmziqrs/maziq/internal/resource"
+	"github.com/hmziqrs/maziq/internal/state"
+)
+
+// verifiedKey is the state store key of the verification records.
+const verifiedKey = "apply/verified"
+
+// Verified records that a resource was converged at a point in time.
+type Verified struct {
+	Hash string `json:"hash"`
+	At   int64  `json:"at"`
+}
+
+// Hash fingerprints r's desired state.
+func Hash(r resource.Resource) string {
+	desc := r.Describe()
+	if f, ok := r.(resource.Fingerprinter); ok {
+		desc = f.Fingerprint()
+	}
+	sum := sha256.Sum256(fmt.Appendf(nil, "%s\x00%s", r.ID(), desc))
+	return hex.EncodeToString(sum[:])
+}
+
+// BuildIncremental is Build for repeat applies: resources that a previous
+// apply verified within maxAge and whose desired state has not changed
+// since are trusted to be converged and not checked again.
+func BuildIncremental(ctx context.Context, env *resource.Env, maxAge time.Duration) (*Plan, error) {
+	rs, ordering, err := resources(ctx, env)
+	if err != nil {
+		return nil, err
+	}
+	var verified map[string]Verified
+	if _, err := state.Get(verifiedKey, &verified); err != nil {
+		return nil, err
+	}
+	plan := &Plan{Template: env.Template.Name, Source: env.Template.Path, Ordering: ordering, GroupsOff: groupsOff(env)}
+	for _, r := range rs {
+		if v, ok := verified[r.ID()]; ok && v.Hash == Hash(r) && time.Since(time.Unix(v.At, 0)) < maxAge {
+			at := time.Unix(v.At, 0)
+			plan.Items = append(plan.Items, Item{Resource: r, Cached: at, State: resource.State{
+				Converged: true,
+				Current:   "unchanged since " + at.Format("Jan 2 15:04"),
+			}})
+			continue
+		}
+		start := time.Now()
+		st, err := r.Check(ctx, env)
+		plan.Items = append(plan.Items, Item{Resource: r, State: st, Err: err, Checked: time.Since(start)})
+	}
+	if err := enforce(ctx, env, plan); err != nil {
+		return nil, err
+	}
+	untracked(ctx, env, plan)
+	if err := quarantined(plan); err != nil {
+		return nil, err
+	}
+	if err := markLocal(ctx, env, plan); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// SaveVerified records which items of plan the apply that produced report
+// left converged, for BuildIncremental. Items missing from the report count
+// as converged when the plan found them so. Items that were trusted from an
+// earlier verification keep its time, so they are checked again once it is
+// too old. Failed and skipped items are forgotten.
+func SaveVerified(plan *Plan, report Report) error {
+	var verified map[string]Verified
+	if _, err := state.Get(verifiedKey, &verified); err != nil {
+		return err
+	}
+	if verified == nil {
+		verified = map[string]Verified{}
+	}
+	status := map[string]string{}
+	for _, o := range report.Outcomes {
+		status[o.ID] = o.Status
+	}
+	now := report.Finished.Unix()
+	if report.Finished.IsZero() {
+		now = time.Now().Unix()
+	}
+	for _, it := range plan.Items {
+		id := it.ID()
+		st, ran := status[id]
+		if !ran && !it.Pending() {
+			st = OutcomeOK
+		}
+		switch {
+		case st != OutcomeOK && st != OutcomeApplied:
+			delete(verified, id)
+		case !it.Cached.IsZero():
+		default:
+			verified[id] = Verified{Hash: Hash(it.Resource), At: now}
+		}
+	}
+	return state.Put(verifiedKey, verified)
+}
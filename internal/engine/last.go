@@ -0,0 +1,26 @@
+package engine
+
+import "github.com/hmziqrs/maziq/internal/state"
+
+// lastKey is the state store key of the most recent apply.
+const lastKey = "apply/last"
+
+// LastRun is the most recent apply that was not a dry run.
+type LastRun struct {
+	// Ref is the template reference it was run with, empty for the
+	// configured default.
+	Ref    string `json:"ref,omitempty"`
+	Report Report `json:"report"`
+}
+
+// SaveLast records report as the most recent apply.
+func SaveLast(ref string, report Report) error {
+	return state.Put(lastKey, LastRun{Ref: ref, Report: report})
+}
+
+// Last returns the most recent apply, if one was recorded.
+func Last() (LastRun, bool, error) {
+	var run LastRun
+	ok, err := state.Get(lastKey, &run)
+	return run, ok, err
+}
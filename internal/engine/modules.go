@@ -0,0 +1,38 @@
+package engine
+
+// Modules register their resource builders from init. Do not rely on import
+// order for sequencing; resources declare what they need via Requires.
+import (
+	_ "github.com/hmziqrs/maziq/internal/modules/binaries"
+	_ "github.com/hmziqrs/maziq/internal/modules/browser"
+	_ "github.com/hmziqrs/maziq/internal/modules/cloud"
+	_ "github.com/hmziqrs/maziq/internal/modules/databases"
+	_ "github.com/hmziqrs/maziq/internal/modules/defaults"
+	_ "github.com/hmziqrs/maziq/internal/modules/direct"
+	_ "github.com/hmziqrs/maziq/internal/modules/directories"
+	_ "github.com/hmziqrs/maziq/internal/modules/direnv"
+	_ "github.com/hmziqrs/maziq/internal/modules/energy"
+	_ "github.com/hmziqrs/maziq/internal/modules/files"
+	_ "github.com/hmziqrs/maziq/internal/modules/fonts"
+	_ "github.com/hmziqrs/maziq/internal/modules/handlers"
+	_ "github.com/hmziqrs/maziq/internal/modules/jobs"
+	_ "github.com/hmziqrs/maziq/internal/modules/kubernetes"
+	_ "github.com/hmziqrs/maziq/internal/modules/license"
+	_ "github.com/hmziqrs/maziq/internal/modules/manual"
+	_ "github.com/hmziqrs/maziq/internal/modules/mas"
+	_ "github.com/hmziqrs/maziq/internal/modules/network"
+	_ "github.com/hmziqrs/maziq/internal/modules/printers"
+	_ "github.com/hmziqrs/maziq/internal/modules/region"
+	_ "github.com/hmziqrs/maziq/internal/modules/repos"
+	_ "github.com/hmziqrs/maziq/internal/modules/screensaver"
+	_ "github.com/hmziqrs/maziq/internal/modules/screenshots"
+	_ "github.com/hmziqrs/maziq/internal/modules/services"
+	_ "github.com/hmziqrs/maziq/internal/modules/shellrc"
+	_ "github.com/hmziqrs/maziq/internal/modules/software"
+	_ "github.com/hmziqrs/maziq/internal/modules/sound"
+	_ "github.com/hmziqrs/maziq/internal/modules/spotlight"
+	_ "github.com/hmziqrs/maziq/internal/modules/terminal"
+	_ "github.com/hmziqrs/maziq/internal/modules/tiling"
+	_ "github.com/hmziqrs/maziq/internal/modules/tmux"
+	_ "github.com/hmziqrs/maziq/internal/modules/updates"
+)
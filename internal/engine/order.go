@@ -0,0 +1,99 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/hmziqrs/maziq/internal/resource"
+)
+
+// Ordering returns the requirements the template's [[order]] rules add to
+// rs, keyed by the ID of the resource that must wait. Patterns that match
+// nothing on this machine add nothing.
+func Ordering(env *resource.Env, rs []resource.Resource) (map[string][]string, error) {
+	out := map[string][]string{}
+	add := func(from, to string) {
+		if from != to && !slices.Contains(out[from], to) {
+			out[from] = append(out[from], to)
+		}
+	}
+	for i, rule := range env.Template.Order {
+		ok, err := env.Holds(rule.When)
+		if err != nil {
+			return nil, fmt.Errorf("order[%d]: when: %w", i, err)
+		}
+		if !ok {
+			continue
+		}
+		for _, id := range matchIDs(env, rule.Resource, rs) {
+			for _, p := range rule.Requires {
+				for _, req := range matchIDs(env, p, rs) {
+					add(id, req)
+				}
+			}
+			for _, p := range rule.Before {
+				for _, later := range matchIDs(env, p, rs) {
+					add(later, id)
+				}
+			}
+		}
+	}
+	return out, nil
+}
+
+// matchIDs returns the IDs of rs that pattern names. The name part of the
+// pattern has its variables and a leading ~ expanded, and * in it matches
+// any run of characters.
+func matchIDs(env *resource.Env, pattern string, rs []resource.Resource) []string {
+	kind, name, ok := strings.Cut(pattern, ":")
+	if !ok {
+		return nil
+	}
+	name = env.Expand(name)
+	if name == "~" || strings.HasPrefix(name, "~/") {
+		name = env.Path(name)
+	}
+	parts := strings.Split(kind+":"+name, "*")
+	for i, p := range parts {
+		parts[i] = regexp.QuoteMeta(p)
+	}
+	re := regexp.MustCompile("^" + strings.Join(parts, ".*") + "$")
+	var out []string
+	for _, r := range rs {
+		if re.MatchString(r.ID()) {
+			out = append(out, r.ID())
+		}
+	}
+	return out
+}
+
+// requirements returns what r waits for: its own requirements and those
+// the template's ordering adds.
+func requirements(r resource.Resource, ordering map[string][]string) []string {
+	var ids []string
+	if req, ok := r.(resource.Requirer); ok {
+		ids = append(ids, req.Requires()...)
+	}
+	return append(ids, ordering[r.ID()]...)
+}
+
+// cycleError reports the cycle that closes at the end of path, in which
+// each resource requires the next, naming the [[order]] rules that take
+// part so they can be fixed.
+func cycleError(path []string, ordering map[string][]string) error {
+	path = path[slices.Index(path, path[len(path)-1]):]
+	msg := "dependency cycle: " + strings.Join(path, " → ")
+	var rules []string
+	for i := 0; i+1 < len(path); i++ {
+		if slices.Contains(ordering[path[i]], path[i+1]) {
+			rules = append(rules, path[i+1]+" before "+path[i])
+		}
+	}
+	if len(rules) > 0 {
+		msg += " ([[order]] puts " + strings.Join(rules, ", ") + ")"
+	}
+	return errors.New(msg)
+}
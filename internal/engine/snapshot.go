@@ -0,0 +1,76 @@
+package engine
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/hmziqrs/maziq/internal/facts"
+	"github.com/hmziqrs/maziq/internal/resource"
+)
+
+// snapshotFacts are the facts a snapshot records and is checked under, so
+// the same template gives the same snapshot on every machine. They are the
+// facts with a closed domain; hostname and user are left out, and the home
+// directory is written as ~.
+var snapshotFacts = []string{"arch", "macos", "os", "shell"}
+
+const (
+	titlePrefix = "# maziq plan snapshot: "
+	factsPrefix = "# facts: "
+)
+
+// Snapshot renders the plan for env.Template as a golden file: every
+// resource in apply order with what it does and what it requires, and none
+// of the machine's current state. It only changes when the template, or
+// what MazIQ makes of it, does.
+func Snapshot(ctx context.Context, env *resource.Env) ([]byte, error) {
+	rs, ordering, err := resources(ctx, env)
+	if err != nil {
+		return nil, err
+	}
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "%s%s\n", titlePrefix, env.Template.Name)
+	var fs []string
+	for _, name := range snapshotFacts {
+		if v := env.Facts[name]; v != "" {
+			fs = append(fs, name+"="+v)
+		}
+	}
+	fmt.Fprintf(&b, "%s%s\n", factsPrefix, strings.Join(fs, " "))
+	home := env.Facts["home"]
+	for _, r := range rs {
+		line := fmt.Sprintf("%-40s %s", r.ID(), r.Describe())
+		if ids := slices.Sorted(slices.Values(requirements(r, ordering))); len(ids) > 0 {
+			line += " (requires " + strings.Join(ids, ", ") + ")"
+		}
+		if home != "" {
+			line = strings.ReplaceAll(line, home, "~")
+		}
+		fmt.Fprintln(&b, line)
+	}
+	return b.Bytes(), nil
+}
+
+// SnapshotFacts returns the facts a snapshot was taken under, to check it
+// under the same ones.
+func SnapshotFacts(snapshot []byte) facts.Facts {
+	f := facts.Facts{}
+	sc := bufio.NewScanner(bytes.NewReader(snapshot))
+	for sc.Scan() {
+		rest, ok := strings.CutPrefix(sc.Text(), factsPrefix)
+		if !ok {
+			continue
+		}
+		for _, kv := range strings.Fields(rest) {
+			if k, v, ok := strings.Cut(kv, "="); ok && slices.Contains(snapshotFacts, k) {
+				f[k] = v
+			}
+		}
+		break
+	}
+	return f
+}
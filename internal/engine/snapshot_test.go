@@ -0,0 +1,113 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hmziqrs/maziq/internal/facts"
+	"github.com/hmziqrs/maziq/internal/shell"
+	builtin "github.com/hmziqrs/maziq/templates"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// TestSnapshotGolden compares the plan snapshot of every built-in template
+// with testdata/<name>.golden, as maziq plan --check does. Run
+// go test ./internal/engine -update to accept a change.
+func TestSnapshotGolden(t *testing.T) {
+	names, err := fs.Glob(builtin.FS, "*.toml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range names {
+		name := strings.TrimSuffix(file, ".toml")
+		t.Run(name, func(t *testing.T) {
+			data, err := builtin.FS.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			env := testEnv(t, string(data), &shell.Mock{})
+			env.Template.Path = "builtin:" + file
+			env.Facts["home"] = "/Users/demo"
+			got, err := Snapshot(context.Background(), env)
+			if err != nil {
+				t.Fatal(err)
+			}
+			path := filepath.Join("testdata", name+".golden")
+			if *update {
+				if err := os.WriteFile(path, got, 0o644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			want, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("snapshot differs from %s:\n%s", path, strings.Join(lineDiff(want, got), "\n"))
+			}
+		})
+	}
+}
+
+// lineDiff returns the lines of a and b that differ, marked - and +.
+func lineDiff(a, b []byte) []string {
+	as, bs := strings.Split(string(a), "\n"), strings.Split(string(b), "\n")
+	var out []string
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		switch {
+		case i >= len(bs):
+			out = append(out, "-"+as[i])
+		case i >= len(as):
+			out = append(out, "+"+bs[i])
+		case as[i] != bs[i]:
+			out = append(out, "-"+as[i], "+"+bs[i])
+		}
+	}
+	return out
+}
+
+func TestSnapshotFacts(t *testing.T) {
+	env := testEnv(t, chain, &shell.Mock{})
+	env.Facts["hostname"] = "booth-1"
+	snap, err := Snapshot(context.Background(), env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(snap, []byte("booth-1")) {
+		t.Errorf("snapshot records the hostname:\n%s", snap)
+	}
+	got := SnapshotFacts(snap)
+	want := facts.Facts{"arch": "arm64", "macos": "15.0", "os": "darwin", "shell": "zsh"}
+	if len(got) != len(want) {
+		t.Fatalf("facts = %v, want %v", got, want)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("facts[%s] = %q, want %q", k, got[k], v)
+		}
+	}
+}
+
+// TestSnapshotIgnoresState checks that a snapshot does not change with
+// what the machine reports, only with the template.
+func TestSnapshotIgnoresState(t *testing.T) {
+	snap := func(m *shell.Mock) []byte {
+		s, err := Snapshot(context.Background(), testEnv(t, chain, m))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+	converged := &shell.Mock{Responses: []shell.Response{{Match: "defaults read", Stdout: "1\n"}}}
+	if a, b := snap(&shell.Mock{}), snap(converged); !bytes.Equal(a, b) {
+		t.Errorf("snapshot changed with the machine's state:\n%s", strings.Join(lineDiff(a, b), "\n"))
+	}
+}
@@ -0,0 +1,36 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/hmziqrs/maziq/internal/resource"
+)
+
+// Verify checks env.Template with resource.Verifier only. The plan holds the
+// resources that could be verified; the count is of those that could not,
+// because they have no local check or it could not decide.
+func Verify(ctx context.Context, env *resource.Env) (*Plan, int, error) {
+	rs, err := Resources(ctx, env)
+	if err != nil {
+		return nil, 0, err
+	}
+	plan := &Plan{Template: env.Template.Name}
+	unverified := 0
+	for _, r := range rs {
+		v, ok := r.(resource.Verifier)
+		if !ok {
+			unverified++
+			continue
+		}
+		start := time.Now()
+		st, err := v.Verify(ctx, env)
+		if errors.Is(err, resource.ErrUnverifiable) {
+			unverified++
+			continue
+		}
+		plan.Items = append(plan.Items, Item{Resource: r, State: st, Err: err, Checked: time.Since(start)})
+	}
+	return plan, unverified, nil
+}
@@ -0,0 +1,280 @@
+// Package export writes a template's resources out as a standalone shell
+// script or Ansible playbook, for machines where MazIQ itself may not run.
+// Only resources implementing resource.Exporter can be written out; the
+// rest are listed so they can be applied some other way.
+package export
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hmziqrs/maziq/internal/resource"
+	"github.com/hmziqrs/maziq/internal/shell"
+)
+
+// Entry is one exported resource.
+type Entry struct {
+	ID          string
+	Description string
+	Step        resource.Step
+	Restart     resource.Restart
+}
+
+// Skipped is a resource that could not be exported.
+type Skipped struct {
+	ID     string
+	Reason string
+}
+
+// Collect exports rs in order.
+func Collect(ctx context.Context, env *resource.Env, rs []resource.Resource) ([]Entry, []Skipped) {
+	var entries []Entry
+	var skipped []Skipped
+	for _, r := range rs {
+		x, ok := r.(resource.Exporter)
+		if !ok {
+			skipped = append(skipped, Skipped{ID: r.ID(), Reason: "needs MazIQ to apply"})
+			continue
+		}
+		step, err := x.Export(ctx, env)
+		if err != nil {
+			skipped = append(skipped, Skipped{ID: r.ID(), Reason: err.Error()})
+			continue
+		}
+		e := Entry{ID: r.ID(), Description: r.Describe(), Step: step}
+		if rr, ok := r.(resource.Restarter); ok {
+			e.Restart = rr.Restarts()
+		}
+		entries = append(entries, e)
+	}
+	return entries, skipped
+}
+
+// Header describes an export at the top of the file.
+type Header struct {
+	Template string
+	Source   string
+	Version  string
+	Home     string
+}
+
+func (h Header) lines() []string {
+	return []string{
+		fmt.Sprintf("%s, exported from %s by MazIQ %s on %s.", h.Template, h.Source, h.Version, time.Now().Format("2006-01-02")),
+		"Each step checks first, so running it again only makes the changes",
+		"that are missing. Paths are those of " + h.Home + ".",
+	}
+}
+
+// brewPath puts Homebrew on PATH for steps after the one installing it.
+const brewPath = "/opt/homebrew/bin:/usr/local/bin"
+
+// line renders c as a shell line. bash -c scripts are written inline.
+func line(c shell.Command) string {
+	s := c.String()
+	if c.Name == "/bin/bash" && len(c.Args) == 2 && c.Args[0] == "-c" && !c.Sudo && len(c.Env) == 0 {
+		s = c.Args[1]
+	}
+	if len(c.Env) > 0 {
+		env := make([]string, len(c.Env))
+		for i, kv := range c.Env {
+			env[i] = shell.Quote(kv)
+		}
+		s = "env " + strings.Join(env, " ") + " " + s
+	}
+	if c.Dir != "" {
+		s = "(cd " + shell.Quote(c.Dir) + " && " + s + ")"
+	}
+	return s
+}
+
+// heredoc is the delimiter files are written with.
+const heredoc = "MAZIQ_EOF"
+
+// writeFile renders the shell lines that write f.
+func writeFile(f resource.FileContent) []string {
+	path := shell.Quote(f.Path)
+	out := []string{"mkdir -p " + shell.Quote(dir(f.Path))}
+	content := string(f.Content)
+	if strings.HasSuffix(content, "\n") && !strings.Contains("\n"+content, "\n"+heredoc+"\n") {
+		out = append(out, "cat > "+path+" <<'"+heredoc+"'\n"+content+heredoc)
+	} else {
+		out = append(out, "printf '%s' "+shell.Quote(content)+" > "+path)
+	}
+	return append(out, fmt.Sprintf("chmod %o %s", f.Mode.Perm(), path))
+}
+
+func dir(path string) string {
+	if i := strings.LastIndexByte(path, '/'); i > 0 {
+		return path[:i]
+	}
+	return "/"
+}
+
+// Script renders entries as a bash script.
+func Script(h Header, entries []Entry, skipped []Skipped) []byte {
+	var b bytes.Buffer
+	b.WriteString("#!/bin/bash\n")
+	for _, l := range h.lines() {
+		b.WriteString("# " + l + "\n")
+	}
+	b.WriteString("set -euo pipefail\n")
+	fmt.Fprintf(&b, "export PATH=\"%s:$PATH\"\n", brewPath)
+	b.WriteString("restart=\"\"\n")
+	for _, e := range entries {
+		fmt.Fprintf(&b, "\n# %s: %s\n", e.ID, e.Description)
+		fmt.Fprintf(&b, "if ! %s; then\n", e.Step.Check)
+		fmt.Fprintf(&b, "  echo %s\n", shell.Quote("→ "+e.ID))
+		var lines []string
+		for _, f := range e.Step.Files {
+			lines = append(lines, writeFile(f)...)
+		}
+		for _, c := range e.Step.Commands {
+			lines = append(lines, line(c))
+		}
+		for _, p := range e.Restart.Processes {
+			lines = append(lines, "restart=\"$restart "+p+"\"")
+		}
+		for _, l := range lines {
+			// Here-document bodies must not be indented.
+			first, rest, _ := strings.Cut(l, "\n")
+			b.WriteString("  " + first + "\n")
+			if rest != "" {
+				b.WriteString(rest + "\n")
+			}
+		}
+		b.WriteString("fi\n")
+	}
+	b.WriteString("\nfor p in $(printf '%s\\n' $restart | sort -u); do\n  killall \"$p\" 2>/dev/null || true\ndone\n")
+	if note := logout(entries); note != "" {
+		fmt.Fprintf(&b, "echo %s\n", shell.Quote(note))
+	}
+	writeSkipped(&b, skipped)
+	return b.Bytes()
+}
+
+// logout returns what to tell the user when some change only takes effect
+// after a logout or reboot.
+func logout(entries []Entry) string {
+	var r resource.Restart
+	for _, e := range entries {
+		r = r.Merge(e.Restart)
+	}
+	switch {
+	case r.Reboot:
+		return "Restart the Mac for every change to take effect."
+	case r.Logout:
+		return "Log out and back in for every change to take effect."
+	}
+	return ""
+}
+
+func writeSkipped(b *bytes.Buffer, skipped []Skipped) {
+	if len(skipped) == 0 {
+		return
+	}
+	b.WriteString("\n# Not exported; apply these with MazIQ or by hand:\n")
+	for _, s := range skipped {
+		fmt.Fprintf(b, "#   %s: %s\n", s.ID, s.Reason)
+	}
+}
+
+// changed is printed by Ansible shell tasks that made their change.
+const changed = "maziq: changed"
+
+// Ansible renders entries as a playbook for localhost. Restarts are
+// handlers, so they run once and only after a change. Commands and file
+// contents are marked !unsafe so Ansible does not template them. Shell
+// tasks run under /bin/sh, so they stop at the first failing command with
+// set -eu rather than Script's pipefail.
+func Ansible(h Header, entries []Entry, skipped []Skipped) []byte {
+	var b bytes.Buffer
+	for _, l := range h.lines() {
+		b.WriteString("# " + l + "\n")
+	}
+	b.WriteString("- name: " + q(h.Template) + "\n")
+	b.WriteString("  hosts: localhost\n  connection: local\n  gather_facts: false\n")
+	b.WriteString("  environment:\n    PATH: " + q(brewPath+":{{ lookup('env', 'PATH') }}") + "\n")
+	b.WriteString("  tasks:\n")
+	processes := map[string]bool{}
+	for i, e := range entries {
+		name := q(e.ID + ": " + e.Description)
+		var notify string
+		if len(e.Restart.Processes) > 0 {
+			var hs []string
+			for _, p := range e.Restart.Processes {
+				processes[p] = true
+				hs = append(hs, q("restart "+p))
+			}
+			notify = "      notify: [" + strings.Join(hs, ", ") + "]\n"
+		}
+		if len(e.Step.Files) > 0 {
+			var conds []string
+			if len(e.Step.Commands) > 0 {
+				// The commands also run when the files are already in place
+				// but the check fails, so checked before the files are written.
+				reg := fmt.Sprintf("check_%d", i)
+				conds = append(conds, reg+".rc != 0")
+				fmt.Fprintf(&b, "    - name: %s\n      ansible.builtin.shell: !unsafe |\n", q("check "+e.ID))
+				b.WriteString(indent(e.Step.Check, "        "))
+				fmt.Fprintf(&b, "      register: %s\n      changed_when: false\n      failed_when: false\n", reg)
+			}
+			for j, f := range e.Step.Files {
+				reg := fmt.Sprintf("file_%d_%d", i, j)
+				conds = append(conds, reg+" is changed")
+				fmt.Fprintf(&b, "    - name: %s\n      ansible.builtin.file:\n        path: %s\n        state: directory\n", q("directory for "+f.Path), q(dir(f.Path)))
+				fmt.Fprintf(&b, "    - name: %s\n      ansible.builtin.copy:\n        dest: %s\n        content: !unsafe %s\n        mode: %s\n      register: %s\n%s",
+					name, q(f.Path), q(string(f.Content)), q(fmt.Sprintf("%04o", f.Mode.Perm())), reg, notify)
+			}
+			if len(e.Step.Commands) > 0 {
+				fmt.Fprintf(&b, "    - name: %s\n      ansible.builtin.shell: !unsafe |\n", q("after "+e.ID))
+				b.WriteString(indent("set -eu", "        "))
+				for _, c := range e.Step.Commands {
+					b.WriteString(indent(line(c), "        "))
+				}
+				fmt.Fprintf(&b, "      when: %s\n", strings.Join(conds, " or "))
+			}
+			continue
+		}
+		fmt.Fprintf(&b, "    - name: %s\n      ansible.builtin.shell: !unsafe |\n", name)
+		b.WriteString(indent("set -eu", "        "))
+		b.WriteString(indent("if "+e.Step.Check+"; then exit 0; fi", "        "))
+		for _, c := range e.Step.Commands {
+			b.WriteString(indent(line(c), "        "))
+		}
+		b.WriteString(indent("echo "+shell.Quote(changed), "        "))
+		fmt.Fprintf(&b, "      register: result\n      changed_when: %s\n%s", q("'"+changed+"' in result.stdout"), notify)
+	}
+	if len(processes) > 0 {
+		b.WriteString("  handlers:\n")
+		names := make([]string, 0, len(processes))
+		for p := range processes {
+			names = append(names, p)
+		}
+		sort.Strings(names)
+		for _, p := range names {
+			fmt.Fprintf(&b, "    - name: %s\n      ansible.builtin.command: killall %s\n      failed_when: false\n", q("restart "+p), shell.Quote(p))
+		}
+	}
+	if note := logout(entries); note != "" {
+		b.WriteString("# " + note + "\n")
+	}
+	writeSkipped(&b, skipped)
+	return b.Bytes()
+}
+
+// q quotes s as a YAML double-quoted scalar, which JSON strings are.
+func q(s string) string {
+	data, _ := json.Marshal(s)
+	return string(data)
+}
+
+// indent indents every line of s for a YAML block scalar.
+func indent(s, prefix string) string {
+	return prefix + strings.ReplaceAll(s, "\n", "\n"+prefix) + "\n"
+}
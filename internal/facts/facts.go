@@ -0,0 +1,95 @@
+// Package facts detects properties of the running machine that templates can
+// branch on in `when` conditions.
+package facts
+
+import (
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Facts maps fact names (os, arch, macos, ...) to their detected values.
+type Facts map[string]string
+
+// Domain lists the values a fact can take. Open facts (hostname, user) have
+// no closed domain and accept any value.
+type Domain struct {
+	Values []string
+	Open   bool
+}
+
+var domains = map[string]Domain{
+	"os":       {Values: []string{"darwin", "linux"}},
+	"arch":     {Values: []string{"arm64", "amd64"}},
+	"macos":    {Values: []string{"11", "12", "13", "14", "15", "26"}},
+	"shell":    {Values: []string{"zsh", "bash", "fish"}},
+	"hostname": {Open: true},
+	"user":     {Open: true},
+	"home":     {Open: true},
+}
+
+// Known reports whether name is a built-in fact.
+func Known(name string) bool {
+	_, ok := domains[name]
+	return ok
+}
+
+// DomainOf returns the value domain for a built-in fact.
+func DomainOf(name string) (Domain, bool) {
+	d, ok := domains[name]
+	return d, ok
+}
+
+// Names returns every built-in fact name sorted alphabetically.
+func Names() []string {
+	out := make([]string, 0, len(domains))
+	for name := range domains {
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Detect gathers facts from the current machine. Arch is the machine's,
+// not that of the maziq build running on it.
+func Detect() Facts {
+	f := Facts{
+		"os":   runtime.GOOS,
+		"arch": runtime.GOARCH,
+	}
+	if Translated() {
+		f["arch"] = "arm64"
+	}
+	if host, err := os.Hostname(); err == nil {
+		f["hostname"] = host
+	}
+	if u, err := user.Current(); err == nil {
+		f["user"] = u.Username
+		f["home"] = u.HomeDir
+	}
+	if sh := os.Getenv("SHELL"); sh != "" {
+		f["shell"] = filepath.Base(sh)
+	}
+	if runtime.GOOS == "darwin" {
+		if out, err := exec.Command("sw_vers", "-productVersion").Output(); err == nil {
+			version := strings.TrimSpace(string(out))
+			f["macos"], _, _ = strings.Cut(version, ".")
+		}
+	}
+	return f
+}
+
+// Translated reports whether maziq is an Intel build running under Rosetta
+// on Apple silicon.
+var Translated = sync.OnceValue(func() bool {
+	if runtime.GOOS != "darwin" || runtime.GOARCH != "amd64" {
+		return false
+	}
+	out, err := exec.Command("sysctl", "-n", "sysctl.proc_translated").Output()
+	return err == nil && strings.TrimSpace(string(out)) == "1"
+})
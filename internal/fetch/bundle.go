@@ -0,0 +1,196 @@
+package fetch
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// recording is the directory responses are being recorded into, if any.
+var recording string
+
+// Record keeps every complete GET and HEAD response Client receives in dir,
+// for Replay to answer from on a machine without network access.
+func Record(dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	recording = dir
+	Client.Transport = recorder{dir: dir, next: Client.Transport}
+	return nil
+}
+
+// Recording reports whether responses are being recorded, so that callers
+// with caches of their own make the requests anyway.
+func Recording() bool { return recording != "" }
+
+// Replay answers Client's requests from the responses Record kept in dir and
+// fails those it has none for, without touching the network.
+func Replay(dir string) {
+	Client.Transport = replayer{dir: dir}
+}
+
+// recorded is a response without its body, which is kept next to it.
+type recorded struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Status int         `json:"status"`
+	Header http.Header `json:"header"`
+}
+
+func key(dir, method, url string) string {
+	sum := sha256.Sum256([]byte(method + " " + url))
+	return filepath.Join(dir, hex.EncodeToString(sum[:16]))
+}
+
+type recorder struct {
+	dir  string
+	next http.RoundTripper
+}
+
+func (r recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		return r.next.RoundTrip(req)
+	}
+	// A resumed download would leave only its tail in the bundle.
+	if req.Header.Get("Range") != "" {
+		req = req.Clone(req.Context())
+		req.Header.Del("Range")
+	}
+	resp, err := r.next.RoundTrip(req)
+	if err != nil || resp.StatusCode >= 400 {
+		return resp, err
+	}
+	body, err := os.CreateTemp(r.dir, ".body-*")
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	base := key(r.dir, req.Method, req.URL.String())
+	resp.Body = &recordingBody{ReadCloser: resp.Body, file: body, base: base,
+		meta: recorded{Method: req.Method, URL: req.URL.String(), Status: resp.StatusCode, Header: resp.Header}}
+	return resp, nil
+}
+
+// recordingBody copies a response body into the bundle as it is read. One
+// that is closed early is read to the end first, so that what a caller
+// did not need is there for a caller that does.
+type recordingBody struct {
+	io.ReadCloser
+	file *os.File
+	base string
+	meta recorded
+	done bool
+	err  error
+}
+
+func (b *recordingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 && b.err == nil {
+		_, b.err = b.file.Write(p[:n])
+	}
+	if err == io.EOF {
+		b.finish()
+	} else if err != nil {
+		b.err = err
+	}
+	return n, err
+}
+
+func (b *recordingBody) Close() error {
+	if !b.done && b.err == nil {
+		if _, b.err = io.Copy(b.file, b.ReadCloser); b.err == nil {
+			b.finish()
+		}
+	}
+	if !b.done {
+		b.file.Close()
+		os.Remove(b.file.Name())
+	}
+	return b.ReadCloser.Close()
+}
+
+func (b *recordingBody) finish() {
+	b.done = true
+	meta, err := json.Marshal(b.meta)
+	if err == nil && b.err == nil {
+		err = b.file.Close()
+	}
+	if err == nil && b.err == nil {
+		if err = os.Rename(b.file.Name(), b.base+".body"); err == nil {
+			err = os.WriteFile(b.base+".json", meta, 0o644)
+		}
+	}
+	if err != nil || b.err != nil {
+		b.file.Close()
+		os.Remove(b.file.Name())
+	}
+}
+
+// ErrNotBundled is returned for a request the bundle has no response for.
+var ErrNotBundled = errors.New("not in the bundle")
+
+type replayer struct {
+	dir string
+}
+
+func (r replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := key(r.dir, req.Method, req.URL.String())
+	data, err := os.ReadFile(base + ".json")
+	if errors.Is(err, fs.ErrNotExist) && req.Method == http.MethodHead {
+		// A HEAD is answered by the GET of the same URL.
+		base = key(r.dir, http.MethodGet, req.URL.String())
+		data, err = os.ReadFile(base + ".json")
+	}
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%s %s: %w", req.Method, req.URL, ErrNotBundled)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var meta recorded
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return nil, err
+	}
+	resp := &http.Response{
+		Status:     strconv.Itoa(meta.Status) + " " + http.StatusText(meta.Status),
+		StatusCode: meta.Status,
+		Proto:      "HTTP/1.1", ProtoMajor: 1, ProtoMinor: 1,
+		Header:        meta.Header,
+		Body:          http.NoBody,
+		ContentLength: -1,
+		Request:       req,
+	}
+	if n, err := strconv.ParseInt(meta.Header.Get("Content-Length"), 10, 64); err == nil {
+		resp.ContentLength = n
+	}
+	f, err := os.Open(base + ".body")
+	if err != nil {
+		return nil, err
+	}
+	st, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	// The body is stored as it was received, already decoded.
+	resp.Header.Del("Content-Encoding")
+	if meta.Method == http.MethodGet {
+		resp.ContentLength = st.Size()
+		resp.Header.Set("Content-Length", strconv.FormatInt(st.Size(), 10))
+	}
+	if req.Method == http.MethodHead {
+		f.Close()
+		return resp, nil
+	}
+	resp.Body = f
+	return resp, nil
+}
@@ -0,0 +1,166 @@
+// Package fetch downloads remote resources (fonts, installers, archives) for
+// the provisioning modules.
+package fetch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Client is the HTTP client used for downloads. It honours the proxy
+// environment variables as they are when each request is made.
+var Client = &http.Client{Timeout: 30 * time.Minute, Transport: transport()}
+
+// request sends a request to url and hands a successful response to read,
+// retrying transient failures of either under the configured policy.
+func request(ctx context.Context, method, url string, body []byte, header http.Header, read func(*http.Response) error) error {
+	return Retry(ctx, func() error {
+		var r io.Reader
+		if body != nil {
+			r = bytes.NewReader(body)
+		}
+		req, err := http.NewRequestWithContext(ctx, method, url, r)
+		if err != nil {
+			return err
+		}
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		resp, err := Client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return statusError(resp)
+		}
+		if err := read(resp); err != nil {
+			return fmt.Errorf("%s %s: %w", method, url, err)
+		}
+		return nil
+	})
+}
+
+// Download writes the body of url to dest atomically: the file only appears
+// once the transfer has completed. A transfer that breaks off is resumed
+// where it stopped when the server supports ranges.
+func Download(ctx context.Context, url, dest string) error {
+	if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(dest), ".download-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+	var got int64
+	err = Retry(ctx, func() error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return err
+		}
+		if got > 0 {
+			req.Header.Set("Range", fmt.Sprintf("bytes=%d-", got))
+		}
+		resp, err := Client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		switch resp.StatusCode {
+		case http.StatusPartialContent:
+		case http.StatusOK:
+			// The server sent everything again.
+			if err := tmp.Truncate(0); err != nil {
+				return err
+			}
+			if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+				return err
+			}
+			got = 0
+		default:
+			return statusError(resp)
+		}
+		n, err := io.Copy(tmp, throttle(ctx, resp.Body))
+		got += n
+		if err != nil {
+			return fmt.Errorf("GET %s: %w", url, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), dest)
+}
+
+// JSON decodes the JSON body of url into v.
+func JSON(ctx context.Context, url string, v any) error {
+	return request(ctx, http.MethodGet, url, nil, nil, func(resp *http.Response) error {
+		return json.NewDecoder(resp.Body).Decode(v)
+	})
+}
+
+// PostJSON posts body to url as JSON and decodes the JSON response into v.
+func PostJSON(ctx context.Context, url string, body, v any) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	header := http.Header{"Content-Type": {"application/json"}}
+	return request(ctx, http.MethodPost, url, data, header, func(resp *http.Response) error {
+		return json.NewDecoder(resp.Body).Decode(v)
+	})
+}
+
+// Text returns the body of url, which must be small.
+func Text(ctx context.Context, url string) (string, error) {
+	var text string
+	err := request(ctx, http.MethodGet, url, nil, nil, func(resp *http.Response) error {
+		body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+		text = string(body)
+		return err
+	})
+	return text, err
+}
+
+// TempDownload downloads url into a fresh temporary directory and returns the
+// file path together with a cleanup function.
+func TempDownload(ctx context.Context, url string) (string, func(), error) {
+	dir, err := os.MkdirTemp("", "maziq-fetch-*")
+	if err != nil {
+		return "", func() {}, err
+	}
+	cleanup := func() { os.RemoveAll(dir) }
+	name := filepath.Base(stripQuery(url))
+	if name == "." || name == "/" {
+		name = "download"
+	}
+	dest := filepath.Join(dir, name)
+	if err := Download(ctx, url, dest); err != nil {
+		cleanup()
+		return "", func() {}, err
+	}
+	return dest, cleanup, nil
+}
+
+// stripQuery removes the query string and fragment from url.
+func stripQuery(url string) string {
+	for i, c := range url {
+		if c == '?' || c == '#' {
+			return url[:i]
+		}
+	}
+	return url
+}
@@ -0,0 +1,127 @@
+package fetch
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/hmziqrs/maziq/internal/config"
+)
+
+// limiter spreads reads over time so that all downloads together stay under
+// a byte rate. Each read reserves the next slot of the shared budget and
+// sleeps until it comes round.
+type limiter struct {
+	mu   sync.Mutex
+	next time.Time
+}
+
+var bandwidth limiter
+
+// chunk bounds a single read so that one reservation never covers seconds
+// of transfer.
+const chunk = 32 << 10
+
+func (l *limiter) wait(ctx context.Context, n int, rate config.Size) error {
+	l.mu.Lock()
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	at := l.next
+	l.next = l.next.Add(time.Duration(float64(n) / float64(rate) * float64(time.Second)))
+	l.mu.Unlock()
+	select {
+	case <-time.After(time.Until(at)):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+type throttled struct {
+	ctx  context.Context
+	r    io.Reader
+	rate config.Size
+}
+
+func (t throttled) Read(p []byte) (int, error) {
+	if len(p) > chunk {
+		p = p[:chunk]
+	}
+	if err := bandwidth.wait(t.ctx, len(p), t.rate); err != nil {
+		return 0, err
+	}
+	return t.r.Read(p)
+}
+
+// throttle caps reads from r at the configured bandwidth limit, shared with
+// every other download in flight.
+func throttle(ctx context.Context, r io.Reader) io.Reader {
+	cfg, _ := config.Load()
+	if cfg.Network.Limit <= 0 {
+		return r
+	}
+	return throttled{ctx: ctx, r: r, rate: cfg.Network.Limit}
+}
+
+// Size returns the length of the download at url, asking the server without
+// fetching the body. It fails when the server does not say.
+func Size(ctx context.Context, url string) (int64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := Do(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, statusError(resp)
+	}
+	if resp.ContentLength < 0 {
+		return 0, fmt.Errorf("HEAD %s: no Content-Length", url)
+	}
+	return resp.ContentLength, nil
+}
+
+// Deferred reports whether a download is held back until the configured
+// window, with its size and when the window opens. size is only asked when
+// the download could be deferred; one of unknown size goes ahead.
+func Deferred(now time.Time, size func() (int64, error)) (config.Size, time.Time, bool) {
+	cfg, _ := config.Load()
+	n := cfg.Network
+	if n.DeferOver <= 0 || n.Window.Contains(now) {
+		return 0, time.Time{}, false
+	}
+	b, err := size()
+	if err != nil || b <= int64(n.DeferOver) {
+		return 0, time.Time{}, false
+	}
+	return config.Size(b), n.Window.Next(now), true
+}
+
+// BrewEnv returns the environment that carries the bandwidth limit to the
+// curl Homebrew downloads with: a curl config file holding limit-rate,
+// written under ~/.maziq, and HOMEBREW_CURLRC pointing at it.
+func BrewEnv() []string {
+	cfg, _ := config.Load()
+	if cfg.Network.Limit <= 0 {
+		return nil
+	}
+	path := filepath.Join(config.Dir(), "curlrc")
+	data := fmt.Sprintf("# Written by maziq from network.limit in %s.\nlimit-rate = %d\n", config.Path(), int64(cfg.Network.Limit))
+	if err := os.MkdirAll(config.Dir(), 0o755); err != nil {
+		return nil
+	}
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		return nil
+	}
+	return []string{"HOMEBREW_CURLRC=" + path}
+}
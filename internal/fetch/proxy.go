@@ -0,0 +1,115 @@
+package fetch
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// transport is http.DefaultTransport with proxy, below.
+func transport() http.RoundTripper {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.Proxy = proxy
+	return t
+}
+
+type proxyKey struct{}
+
+// WithProxy returns a context whose downloads, and the proxy checks of
+// Getenv, see environ — a template's Proxy.Environ — ahead of MazIQ's own
+// environment. The template's proxy stays with the requests made for it,
+// so a daemon serving several templates does not carry one's over to the
+// next.
+func WithProxy(ctx context.Context, environ []string) context.Context {
+	if len(environ) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, proxyKey{}, environ)
+}
+
+// Getenv returns the variable key, or its lower-case spelling, from the
+// environment ctx carries or else from MazIQ's own.
+func Getenv(ctx context.Context, key string) string {
+	environ, _ := ctx.Value(proxyKey{}).([]string)
+	for _, k := range []string{key, strings.ToLower(key)} {
+		for i := len(environ) - 1; i >= 0; i-- {
+			if v, ok := strings.CutPrefix(environ[i], k+"="); ok && v != "" {
+				return v
+			}
+		}
+	}
+	for _, k := range []string{key, strings.ToLower(key)} {
+		if v := os.Getenv(k); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// proxy is http.ProxyFromEnvironment reading the request's context, as
+// Getenv does, and without its cache, which would keep whatever the
+// environment said at the first request.
+func proxy(req *http.Request) (*url.URL, error) {
+	ctx := req.Context()
+	host := req.URL.Hostname()
+	if bypass(ctx, host) {
+		return nil, nil
+	}
+	p := Getenv(ctx, "HTTP_PROXY")
+	if req.URL.Scheme == "https" {
+		p = Getenv(ctx, "HTTPS_PROXY")
+	}
+	if p == "" {
+		return nil, nil
+	}
+	if !strings.Contains(p, "://") {
+		p = "http://" + p
+	}
+	return url.Parse(p)
+}
+
+// Proxy returns the proxy requests to url made with ctx go through, or ""
+// for none.
+func Proxy(ctx context.Context, rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return ""
+	}
+	p, err := proxy(req)
+	if err != nil || p == nil {
+		return ""
+	}
+	return p.Redacted()
+}
+
+// bypass reports whether host is reached directly: it is the machine
+// itself, or NO_PROXY lists it or a domain it is in.
+func bypass(ctx context.Context, host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+		return true
+	}
+	for _, e := range strings.Split(Getenv(ctx, "NO_PROXY"), ",") {
+		e = strings.TrimSpace(e)
+		if h, _, err := net.SplitHostPort(e); err == nil {
+			e = h
+		}
+		switch {
+		case e == "":
+		case e == "*":
+			return true
+		case host == strings.TrimPrefix(e, "."), strings.HasSuffix(host, "."+strings.TrimPrefix(e, ".")):
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,159 @@
+package fetch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/hmziqrs/maziq/internal/config"
+)
+
+// Policy is how network operations are retried: up to Attempts tries,
+// waiting Backoff after the first failure and twice as long after each
+// later one, up to MaxBackoff. Jitter randomises every wait by up to that
+// fraction of it.
+type Policy struct {
+	Attempts   int
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+	Jitter     float64
+}
+
+// policy is the configured Policy.
+func policy() Policy {
+	cfg, _ := config.Load()
+	n := cfg.Network
+	return Policy{Attempts: n.Attempts, Backoff: n.Backoff, MaxBackoff: n.MaxBackoff, Jitter: n.Jitter}
+}
+
+// Retry runs op under the configured policy.
+func Retry(ctx context.Context, op func() error) error {
+	return policy().Do(ctx, op)
+}
+
+// Do runs op until it succeeds, fails with an error that is not Transient,
+// runs out of attempts or ctx ends. It returns op's last error.
+func (p Policy) Do(ctx context.Context, op func() error) error {
+	wait := p.Backoff
+	for attempt := 1; ; attempt++ {
+		err := op()
+		if err == nil || attempt >= p.Attempts || ctx.Err() != nil || !Transient(err) {
+			return err
+		}
+		d := wait
+		if p.Jitter > 0 {
+			d = time.Duration(float64(d) * (1 + p.Jitter*(2*rand.Float64()-1)))
+		}
+		// A server that says when to come back is believed, within reason.
+		var se *StatusError
+		if errors.As(err, &se) && se.RetryAfter > d && se.RetryAfter <= p.MaxBackoff {
+			d = se.RetryAfter
+		}
+		logf(ctx, "%v; retrying in %s (attempt %d of %d)", err, d.Round(100*time.Millisecond), attempt+1, p.Attempts)
+		select {
+		case <-time.After(d):
+		case <-ctx.Done():
+			return err
+		}
+		wait = min(wait*2, p.MaxBackoff)
+	}
+}
+
+// StatusError is a response with a status other than the one expected.
+type StatusError struct {
+	Method, URL string
+	Code        int
+	Status      string
+	// RetryAfter is how long the server asked to wait, if it did.
+	RetryAfter time.Duration
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s %s: %s", e.Method, e.URL, e.Status)
+}
+
+func statusError(resp *http.Response) *StatusError {
+	e := &StatusError{Method: resp.Request.Method, URL: resp.Request.URL.String(), Code: resp.StatusCode, Status: resp.Status}
+	if sec, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		e.RetryAfter = time.Duration(sec) * time.Second
+	}
+	return e
+}
+
+// Transient reports whether err is worth retrying: the network dropped,
+// timed out or could not resolve a name, or the server was overloaded or
+// failing. Missing files, refused credentials and bad certificates are
+// permanent.
+func Transient(err error) bool {
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+	var se *StatusError
+	if errors.As(err, &se) {
+		switch se.Code {
+		case http.StatusRequestTimeout, http.StatusTooEarly, http.StatusTooManyRequests,
+			http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+		return false
+	}
+	var ne net.Error
+	if errors.As(err, &ne) && ne.Timeout() {
+		return true
+	}
+	var dns *net.DNSError
+	var op *net.OpError
+	return errors.As(err, &dns) || errors.As(err, &op) ||
+		errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.EPIPE)
+}
+
+type logKey struct{}
+
+// WithLog returns a context whose network retries are reported to logf, so
+// a long wait shows up in the output of the change that is waiting.
+func WithLog(ctx context.Context, logf func(format string, args ...any)) context.Context {
+	return context.WithValue(ctx, logKey{}, logf)
+}
+
+func logf(ctx context.Context, format string, args ...any) {
+	if f, ok := ctx.Value(logKey{}).(func(string, ...any)); ok && f != nil {
+		f(format, args...)
+	}
+}
+
+// Do sends req, retrying transient errors and server failures. Responses
+// with any other status are returned for the caller to handle. req must be
+// replayable: without a body, or with GetBody set as http.NewRequest does
+// for in-memory bodies.
+func Do(req *http.Request) (*http.Response, error) {
+	var resp *http.Response
+	err := Retry(req.Context(), func() error {
+		r := req
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return err
+			}
+			r = req.Clone(req.Context())
+			r.Body = body
+		}
+		var err error
+		if resp, err = Client.Do(r); err != nil {
+			return err
+		}
+		// Rate limits are the caller's to handle; they rarely lift in time.
+		if se := statusError(resp); se.Code != http.StatusTooManyRequests && Transient(se) {
+			resp.Body.Close()
+			return se
+		}
+		return nil
+	})
+	return resp, err
+}
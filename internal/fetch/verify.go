@@ -0,0 +1,115 @@
+package fetch
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/hmziqrs/maziq/internal/shell"
+)
+
+// Integrity is what a download must match. It mirrors templates.Integrity,
+// so a template value converts directly: fetch.Integrity(spec.Integrity).
+type Integrity struct {
+	SHA256      string
+	Signature   string
+	MinisignKey string
+	GPGKey      string
+}
+
+// Pinned reports whether anything is checked.
+func (in Integrity) Pinned() bool {
+	return in.SHA256 != "" || in.Signature != ""
+}
+
+// ErrMismatch is returned when a download does not match its checksum or
+// signature.
+var ErrMismatch = errors.New("integrity check failed")
+
+// Verify checks the file at path against in: the SHA-256 digest first, then
+// the detached signature, which is downloaded next to the file.
+func Verify(ctx context.Context, r shell.Runner, path string, in Integrity) error {
+	if in.SHA256 != "" {
+		got, err := SHA256(path)
+		if err != nil {
+			return err
+		}
+		if !strings.EqualFold(got, in.SHA256) {
+			return fmt.Errorf("%w: sha256 of %s is %s, want %s", ErrMismatch, path, got, in.SHA256)
+		}
+	}
+	if in.Signature == "" {
+		return nil
+	}
+	sig := path + ".sig"
+	if err := Download(ctx, in.Signature, sig); err != nil {
+		return fmt.Errorf("signature: %w", err)
+	}
+	defer os.Remove(sig)
+	switch {
+	case in.MinisignKey != "":
+		_, err := r.Run(ctx, shell.Cmd("minisign", "-V", "-q", "-P", in.MinisignKey, "-m", path, "-x", sig))
+		if errors.As(err, new(*shell.ExitError)) {
+			return fmt.Errorf("%w: minisign signature does not verify: %v", ErrMismatch, err)
+		}
+		return err
+	case in.GPGKey != "":
+		return verifyGPG(ctx, r, path, sig, in.GPGKey)
+	}
+	return errors.New("signature needs minisign_key or gpg_key")
+}
+
+var gpgFingerprint = regexp.MustCompile(`^[0-9A-F]{40}$`)
+
+// GPGFingerprint normalises a gpg_key to the 40 upper-case hex digits of a
+// full fingerprint. Short and long key IDs are rejected, as other keys can
+// be made to share them.
+func GPGFingerprint(key string) (string, error) {
+	fpr := strings.ToUpper(strings.Join(strings.Fields(key), ""))
+	if !gpgFingerprint.MatchString(fpr) {
+		return "", fmt.Errorf("gpg_key %q is not a full 40-digit fingerprint", key)
+	}
+	return fpr, nil
+}
+
+// verifyGPG accepts the signature only when it is valid and made by the key
+// with the expected fingerprint, not merely any key in the keyring. The
+// primary key's fingerprint, the last field of VALIDSIG, is compared, so a
+// signature from one of its subkeys counts as the key's.
+func verifyGPG(ctx context.Context, r shell.Runner, path, sig, key string) error {
+	want, err := GPGFingerprint(key)
+	if err != nil {
+		return err
+	}
+	res, err := r.Run(ctx, shell.Cmd("gpg", "--batch", "--status-fd", "1", "--verify", sig, path))
+	if err != nil && !errors.As(err, new(*shell.ExitError)) {
+		return err
+	}
+	for _, line := range strings.Split(res.Stdout, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 3 && fields[0] == "[GNUPG:]" && fields[1] == "VALIDSIG" && fields[len(fields)-1] == want {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: no valid GPG signature from %s", ErrMismatch, key)
+}
+
+// SHA256 returns the hex digest of the file at path.
+func SHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,105 @@
+// Package gatekeeper checks code signatures and notarization of downloaded
+// apps and manages the com.apple.quarantine attribute that makes Gatekeeper
+// inspect an app on first launch.
+package gatekeeper
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/hmziqrs/maziq/internal/shell"
+)
+
+// QuarantineAttr is the extended attribute browsers and installers set on
+// downloaded files.
+const QuarantineAttr = "com.apple.quarantine"
+
+// Assessment is what codesign and Gatekeeper think of an app bundle.
+type Assessment struct {
+	Signed    bool   `json:"signed"`
+	Notarized bool   `json:"notarized"`
+	Team      string `json:"team,omitempty"`
+	// Source is Gatekeeper's verdict, e.g. "Notarized Developer ID".
+	Source string `json:"source,omitempty"`
+}
+
+// Problem describes why the app would not pass Gatekeeper, or returns ""
+// when it is signed and notarized.
+func (a Assessment) Problem() string {
+	switch {
+	case !a.Signed:
+		return "unsigned or signature invalid"
+	case !a.Notarized:
+		return "signed but not notarized"
+	}
+	return ""
+}
+
+// Assess verifies the signature of the bundle at path and asks Gatekeeper
+// whether it would allow the app to run.
+func Assess(ctx context.Context, r shell.Runner, path string) (Assessment, error) {
+	var a Assessment
+	_, err := r.Run(ctx, shell.Cmd("codesign", "--verify", "--deep", "--strict", path))
+	if err != nil && !isExit(err) {
+		return a, err
+	}
+	a.Signed = err == nil
+	if !a.Signed {
+		return a, nil
+	}
+	// codesign and spctl report on stderr.
+	if res, err := r.Run(ctx, shell.Cmd("codesign", "-dv", path)); err == nil {
+		a.Team = field(res.Stderr, "TeamIdentifier=")
+	}
+	res, err := r.Run(ctx, shell.Cmd("spctl", "--assess", "--type", "execute", "-vv", path))
+	if err != nil && !isExit(err) {
+		return a, err
+	}
+	a.Source = field(res.Stderr, "source=")
+	a.Notarized = err == nil && (a.Source == "Notarized Developer ID" || a.Source == "Apple System" || a.Source == "Mac App Store")
+	return a, nil
+}
+
+// Quarantined reports whether path still carries the quarantine attribute,
+// meaning it has not been opened since it was downloaded.
+func Quarantined(ctx context.Context, r shell.Runner, path string) (bool, error) {
+	_, err := r.Run(ctx, shell.Cmd("xattr", "-p", QuarantineAttr, path))
+	if err != nil {
+		if isExit(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// Quarantine marks path as downloaded so Gatekeeper checks it on first
+// launch. Installers that fetch with Go's HTTP client do not get this for
+// free the way browsers and curl-via-brew do.
+func Quarantine(ctx context.Context, r shell.Runner, path, agent string) error {
+	// flags;timestamp;agent;uuid — 0081 is "downloaded, not yet approved".
+	_, err := r.Run(ctx, shell.Cmd("xattr", "-w", QuarantineAttr, "0081;00000000;"+agent+";", path))
+	return err
+}
+
+// Release removes the quarantine attribute from path and everything inside
+// it. Only do this for apps whose Assessment has no Problem.
+func Release(ctx context.Context, r shell.Runner, path string) error {
+	_, err := r.Run(ctx, shell.Cmd("xattr", "-dr", QuarantineAttr, path))
+	return err
+}
+
+func isExit(err error) bool {
+	return errors.As(err, new(*shell.ExitError))
+}
+
+// field returns the value after prefix on the first line that has it.
+func field(out, prefix string) string {
+	for _, line := range strings.Split(out, "\n") {
+		if v, ok := strings.CutPrefix(strings.TrimSpace(line), prefix); ok {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,165 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/hmziqrs/maziq/internal/config"
+	"github.com/hmziqrs/maziq/internal/fetch"
+)
+
+// TTL is how long a cached response is used without asking GitHub again.
+// Past it, the cached ETag makes the request conditional, and GitHub does
+// not count a 304 against the rate limit.
+var TTL = time.Hour
+
+// Token returns the API token: $GITHUB_TOKEN, $GH_TOKEN or the github.token
+// config key, or "" to go unauthenticated. Unauthenticated clients get 60
+// requests an hour, which a template with a dozen binaries exhausts quickly.
+func Token() string {
+	for _, name := range []string{"GITHUB_TOKEN", "GH_TOKEN"} {
+		if t := os.Getenv(name); t != "" {
+			return t
+		}
+	}
+	if cfg, err := config.Load(); err == nil {
+		return cfg.GitHub.Token
+	}
+	return ""
+}
+
+// RateLimitError is returned when GitHub refuses a request for exceeding the
+// rate limit and nothing is cached to fall back on.
+type RateLimitError struct {
+	Reset time.Time
+}
+
+func (e *RateLimitError) Error() string {
+	msg := "GitHub API rate limit exceeded"
+	if !e.Reset.IsZero() {
+		msg += "; resets at " + e.Reset.Local().Format("15:04")
+	}
+	if Token() == "" {
+		msg += "; set GITHUB_TOKEN or github.token in config.toml for a higher limit"
+	}
+	return msg
+}
+
+type cached struct {
+	ETag    string          `json:"etag,omitempty"`
+	Fetched time.Time       `json:"fetched"`
+	Body    json.RawMessage `json:"body"`
+}
+
+var cacheMu sync.Mutex
+
+// CachePath is where API responses are cached.
+func CachePath() string {
+	return filepath.Join(config.Dir(), "cache", "github.json")
+}
+
+func loadCache() map[string]cached {
+	out := map[string]cached{}
+	if data, err := os.ReadFile(CachePath()); err == nil {
+		_ = json.Unmarshal(data, &out)
+	}
+	return out
+}
+
+func storeCache(url string, c cached) {
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+	m := loadCache()
+	m[url] = c
+	data, err := json.Marshal(m)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(CachePath()), 0o755); err != nil {
+		return
+	}
+	_ = os.WriteFile(CachePath(), data, 0o644)
+}
+
+// get decodes the API response at url into v, answering from the cache while
+// it is fresh and falling back to a stale entry when rate limited.
+func get(ctx context.Context, url string, v any) error {
+	cacheMu.Lock()
+	entry, ok := loadCache()[url]
+	cacheMu.Unlock()
+	// A bundle being recorded needs the full response, not a cache hit or
+	// a 304.
+	if fetch.Recording() {
+		ok = false
+	}
+	if ok && time.Since(entry.Fetched) < TTL {
+		return json.Unmarshal(entry.Body, v)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if t := Token(); t != "" {
+		req.Header.Set("Authorization", "Bearer "+t)
+	}
+	if ok && entry.ETag != "" {
+		req.Header.Set("If-None-Match", entry.ETag)
+	}
+	resp, err := fetch.Do(req)
+	if err != nil {
+		if ok {
+			return json.Unmarshal(entry.Body, v)
+		}
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && ok:
+		entry.Fetched = time.Now()
+		storeCache(url, entry)
+		return json.Unmarshal(entry.Body, v)
+	case rateLimited(resp):
+		if ok {
+			return json.Unmarshal(entry.Body, v)
+		}
+		return &RateLimitError{Reset: resetTime(resp)}
+	case resp.StatusCode != http.StatusOK:
+		return fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return err
+	}
+	storeCache(url, cached{ETag: resp.Header.Get("ETag"), Fetched: time.Now(), Body: body})
+	return nil
+}
+
+func rateLimited(resp *http.Response) bool {
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return true
+	}
+	return resp.StatusCode == http.StatusForbidden && resp.Header.Get("X-RateLimit-Remaining") == "0"
+}
+
+func resetTime(resp *http.Response) time.Time {
+	sec, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	return time.Unix(sec, 0)
+}
@@ -0,0 +1,186 @@
+// Package github looks up releases and their assets through the GitHub REST
+// API, for tools installed from release archives.
+package github
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/hmziqrs/maziq/internal/fetch"
+)
+
+// API is the GitHub REST endpoint.
+var API = "https://api.github.com"
+
+// Release is a published GitHub release.
+type Release struct {
+	Tag        string  `json:"tag_name"`
+	Name       string  `json:"name"`
+	Prerelease bool    `json:"prerelease"`
+	Assets     []Asset `json:"assets"`
+}
+
+// Asset is a file attached to a release.
+type Asset struct {
+	Name string `json:"name"`
+	URL  string `json:"browser_download_url"`
+	Size int64  `json:"size"`
+}
+
+// Latest returns the newest non-prerelease release of repo ("owner/name").
+func Latest(ctx context.Context, repo string) (Release, error) {
+	var r Release
+	err := get(ctx, fmt.Sprintf("%s/repos/%s/releases/latest", API, repo), &r)
+	return r, err
+}
+
+// Tagged returns the release of repo with the given tag.
+func Tagged(ctx context.Context, repo, tag string) (Release, error) {
+	var r Release
+	err := get(ctx, fmt.Sprintf("%s/repos/%s/releases/tags/%s", API, repo, tag), &r)
+	return r, err
+}
+
+// Archive extensions Select considers.
+var archiveExts = []string{".tar.gz", ".tgz", ".zip"}
+
+// IsArchive reports whether name is an archive MazIQ can unpack.
+func IsArchive(name string) bool {
+	lower := strings.ToLower(name)
+	for _, ext := range archiveExts {
+		if strings.HasSuffix(lower, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	macNames  = []string{"darwin", "macos", "mac", "apple", "osx"}
+	archNames = map[string][]string{
+		"arm64": {"arm64", "aarch64"},
+		"amd64": {"amd64", "x64", "intel"},
+	}
+	universalNames = []string{"universal", "all"}
+)
+
+// Select picks the macOS archive for arch (a GOARCH value) among the
+// release assets. A pattern, when set, is a regular expression the asset
+// name must match and replaces the guesswork. Otherwise assets naming arch
+// are preferred over universal ones; assets naming another architecture are
+// never picked.
+func (r Release) Select(arch, pattern string) (Asset, error) {
+	if pattern != "" {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return Asset{}, fmt.Errorf("asset: %w", err)
+		}
+		for _, a := range r.Assets {
+			if re.MatchString(a.Name) {
+				return a, nil
+			}
+		}
+		return Asset{}, fmt.Errorf("%s has no asset matching %q", r.Tag, pattern)
+	}
+	best, bestScore := Asset{}, 0
+	for _, a := range r.Assets {
+		if !IsArchive(a.Name) {
+			continue
+		}
+		words := tokens(a.Name)
+		if !hasAny(words, macNames) {
+			continue
+		}
+		score := 1
+		switch {
+		case hasAny(words, archNames[arch]):
+			score = 3
+		case hasAny(words, universalNames):
+			score = 2
+		case otherArch(words, arch):
+			continue
+		}
+		if score > bestScore {
+			best, bestScore = a, score
+		}
+	}
+	if bestScore == 0 {
+		return Asset{}, fmt.Errorf("%s has no macOS %s archive; set asset to pick one", r.Tag, arch)
+	}
+	return best, nil
+}
+
+// Checksum looks for the sha256 of asset in the release: a sibling
+// "<asset>.sha256" file or a checksums list such as checksums.txt or
+// SHA256SUMS. It returns "" when the release publishes none.
+func (r Release) Checksum(ctx context.Context, asset Asset) (string, error) {
+	for _, a := range r.Assets {
+		lower := strings.ToLower(a.Name)
+		sibling := lower == strings.ToLower(asset.Name)+".sha256"
+		list := strings.Contains(lower, "checksum") || strings.Contains(lower, "sha256sum")
+		if !sibling && !list {
+			continue
+		}
+		if strings.HasSuffix(lower, ".sig") || strings.HasSuffix(lower, ".asc") || strings.HasSuffix(lower, ".minisig") {
+			continue
+		}
+		body, err := fetch.Text(ctx, a.URL)
+		if err != nil {
+			return "", err
+		}
+		if sum := findSum(body, asset.Name, sibling); sum != "" {
+			return sum, nil
+		}
+	}
+	return "", nil
+}
+
+var sumLine = regexp.MustCompile(`^([0-9a-fA-F]{64})(?:\s+\*?(\S*))?`)
+
+// findSum returns the digest for name in a sha256sum-style listing. A
+// sibling file may hold just the digest.
+func findSum(body, name string, sibling bool) string {
+	sc := bufio.NewScanner(strings.NewReader(body))
+	for sc.Scan() {
+		m := sumLine.FindStringSubmatch(strings.TrimSpace(sc.Text()))
+		if m == nil {
+			continue
+		}
+		if m[2] == name || strings.HasSuffix(m[2], "/"+name) || (sibling && m[2] == "") {
+			return strings.ToLower(m[1])
+		}
+	}
+	return ""
+}
+
+// tokens splits an asset name into lower-case words. x86_64 is read as
+// amd64 so the underscore does not split it.
+func tokens(name string) []string {
+	name = strings.ReplaceAll(strings.ToLower(name), "x86_64", "amd64")
+	return strings.FieldsFunc(name, func(c rune) bool {
+		return c == '-' || c == '_' || c == '.' || c == ' '
+	})
+}
+
+func hasAny(words, names []string) bool {
+	for _, w := range words {
+		for _, n := range names {
+			if w == n {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func otherArch(words []string, arch string) bool {
+	for a, names := range archNames {
+		if a != arch && hasAny(words, names) {
+			return true
+		}
+	}
+	return false
+}
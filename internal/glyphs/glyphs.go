@@ -0,0 +1,114 @@
+// Package glyphs swaps the symbols MazIQ draws with — status dots, check
+// marks, arrows, box drawing and the logo's block art — for plain ASCII in
+// plain mode, for CI logs and terminals or fonts that render them poorly.
+package glyphs
+
+import (
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/hmziqrs/maziq/internal/config"
+)
+
+// Plain reports whether plain mode is on: $MAZIQ_PLAIN when set, [ui] plain
+// in the config otherwise.
+var Plain = sync.OnceValue(func() bool {
+	if v := os.Getenv("MAZIQ_PLAIN"); v != "" {
+		return v != "0" && v != "false"
+	}
+	cfg, err := config.Load()
+	return err == nil && cfg.UI.Plain
+})
+
+// stdout is standard output as the process started with it.
+var stdout = os.Stdout
+
+// Stdout returns standard output before Filter replaced it, for full-screen
+// programs that need the terminal and draw through ASCII themselves.
+func Stdout() *os.File {
+	return stdout
+}
+
+// replacer maps every symbol to ASCII of the same width, so text laid out
+// in columns or boxes stays aligned after the swap.
+var replacer = strings.NewReplacer(
+	"✓", "+", "✗", "x", "⚠", "!", "●", "*", "◐", "~", "○", "o",
+	"→", ">", "↑", "^", "↓", "v", "↻", "~", "↯", "!", "❯", ">", "▶", ">",
+	"…", ".", "•", "-", "·", "-", "—", "-", "“", `"`, "”", `"`, "🔒", "L ",
+	"█", "#", "░", ".", "▏", "|", "▄", "_", "▀", "^",
+	"╭", "+", "╮", "+", "╰", "+", "╯", "+", "┌", "+", "┐", "+", "└", "+", "┘", "+",
+	"╔", "+", "╗", "+", "╚", "+", "╝", "+", "═", "=", "║", "|", "─", "-", "│", "|",
+)
+
+// ASCII returns s with every symbol replaced.
+func ASCII(s string) string {
+	return replacer.Replace(s)
+}
+
+// Filter points os.Stdout and os.Stderr at pipes that copy everything
+// written to them through ASCII to the original files. The returned func
+// restores them and waits for the copies to drain; call it before exiting.
+func Filter() (flush func()) {
+	restoreOut := filter(&os.Stdout)
+	restoreErr := filter(&os.Stderr)
+	return func() {
+		restoreOut()
+		restoreErr()
+	}
+}
+
+func filter(f **os.File) func() {
+	r, w, err := os.Pipe()
+	if err != nil {
+		return func() {}
+	}
+	orig := *f
+	*f = w
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		copyASCII(orig, r)
+	}()
+	return func() {
+		*f = orig
+		w.Close()
+		<-done
+		r.Close()
+	}
+}
+
+// copyASCII copies as data arrives, so prompts without a newline still
+// show, holding back a symbol split across two reads.
+func copyASCII(dst io.Writer, src io.Reader) {
+	buf := make([]byte, 32*1024)
+	var pending []byte
+	for {
+		n, err := src.Read(buf)
+		pending = append(pending, buf[:n]...)
+		cut := complete(pending)
+		if cut > 0 {
+			_, _ = io.WriteString(dst, ASCII(string(pending[:cut])))
+			pending = append(pending[:0], pending[cut:]...)
+		}
+		if err != nil {
+			_, _ = dst.Write(pending)
+			return
+		}
+	}
+}
+
+// complete returns how much of p ends on a whole UTF-8 sequence.
+func complete(p []byte) int {
+	for i := len(p) - 1; i >= 0 && i >= len(p)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(p[i]) {
+			if !utf8.FullRune(p[i:]) {
+				return i
+			}
+			break
+		}
+	}
+	return len(p)
+}
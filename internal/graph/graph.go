@@ -0,0 +1,260 @@
+// Package graph is the dependency graph of a template's resources: the
+// requirements resources declare and those its [[order]] rules add, which
+// order the plan, and the formulae Homebrew installed as dependencies of
+// others. It answers what removing an entry from a template would break.
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/hmziqrs/maziq/internal/catalog"
+	"github.com/hmziqrs/maziq/internal/engine"
+	"github.com/hmziqrs/maziq/internal/modules/software"
+	"github.com/hmziqrs/maziq/internal/resource"
+	"github.com/hmziqrs/maziq/internal/shell"
+)
+
+// Edge kinds.
+const (
+	// Requires is a requirement a resource declares.
+	Requires = "requires"
+	// Order is a requirement a template's [[order]] rule adds.
+	Order = "order"
+	// Brew is a formula Homebrew installed another one to depend on.
+	Brew = "brew"
+)
+
+// Node is one resource.
+type Node struct {
+	ID          string `json:"id"`
+	Description string `json:"description"`
+}
+
+// Edge says From needs To.
+type Edge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Kind string `json:"kind"`
+}
+
+// Graph holds the resources in plan order and the edges between them.
+type Graph struct {
+	Nodes []Node `json:"nodes"`
+	Edges []Edge `json:"edges"`
+}
+
+// Build returns the graph of env.Template. Homebrew's edges are only known
+// for installed formulae, and are left out when Homebrew is not installed.
+func Build(ctx context.Context, env *resource.Env) (*Graph, error) {
+	rs, err := engine.Resources(ctx, env)
+	if err != nil {
+		return nil, err
+	}
+	g := &Graph{}
+	present := map[string]bool{}
+	for _, r := range rs {
+		g.Nodes = append(g.Nodes, Node{ID: r.ID(), Description: r.Describe()})
+		present[r.ID()] = true
+	}
+	seen := map[[2]string]bool{}
+	add := func(from, to, kind string) {
+		key := [2]string{from, to}
+		if from != to && present[to] && !seen[key] {
+			seen[key] = true
+			g.Edges = append(g.Edges, Edge{From: from, To: to, Kind: kind})
+		}
+	}
+	for _, r := range rs {
+		if req, ok := r.(resource.Requirer); ok {
+			for _, id := range req.Requires() {
+				add(r.ID(), id, Requires)
+			}
+		}
+	}
+	ordering, err := engine.Ordering(env, rs)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range rs {
+		for _, id := range ordering[r.ID()] {
+			add(r.ID(), id, Order)
+		}
+	}
+	deps, err := brewDeps(ctx, env, rs)
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range deps {
+		add(d.From, d.To, Brew)
+	}
+	return g, nil
+}
+
+// brewDeps asks Homebrew which installed formulae depend on which, and
+// returns the dependencies between the template's formulae.
+func brewDeps(ctx context.Context, env *resource.Env, rs []resource.Resource) ([]Edge, error) {
+	formulae := map[string]string{}
+	for _, r := range rs {
+		if s, ok := r.(*software.Software); ok {
+			if src := s.Catalog().Primary(); src.Backend == catalog.BackendBrew {
+				formulae[src.Package[strings.LastIndexByte(src.Package, '/')+1:]] = r.ID()
+			}
+		}
+	}
+	if len(formulae) == 0 {
+		return nil, nil
+	}
+	res, err := env.Run(ctx, shell.Script("command -v brew >/dev/null || exit 0; brew deps --installed --for-each"))
+	if err != nil {
+		if errors.As(err, new(*shell.ExitError)) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var out []Edge
+	// One line per formula: "git: gettext pcre2".
+	for _, line := range strings.Split(res.Stdout, "\n") {
+		name, deps, ok := strings.Cut(line, ":")
+		from, managed := formulae[strings.TrimSpace(name)]
+		if !ok || !managed {
+			continue
+		}
+		for _, dep := range strings.Fields(deps) {
+			if to, ok := formulae[dep[strings.LastIndexByte(dep, '/')+1:]]; ok {
+				out = append(out, Edge{From: from, To: to, Kind: Brew})
+			}
+		}
+	}
+	return out, nil
+}
+
+// Has reports whether id is a node.
+func (g *Graph) Has(id string) bool {
+	return slices.ContainsFunc(g.Nodes, func(n Node) bool { return n.ID == id })
+}
+
+// Requires returns what id needs directly, with the kind of each edge.
+func (g *Graph) Requires(id string) []Edge {
+	var out []Edge
+	for _, e := range g.Edges {
+		if e.From == id {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// RequiredBy returns what needs id directly.
+func (g *Graph) RequiredBy(id string) []Edge {
+	var out []Edge
+	for _, e := range g.Edges {
+		if e.To == id {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// Dependents returns everything that needs id, directly or through other
+// resources, in plan order: what removing id from the template would break.
+func (g *Graph) Dependents(id string) []string {
+	hit := map[string]bool{}
+	queue := []string{id}
+	for len(queue) > 0 {
+		next := queue[0]
+		queue = queue[1:]
+		for _, e := range g.RequiredBy(next) {
+			if !hit[e.From] && e.From != id {
+				hit[e.From] = true
+				queue = append(queue, e.From)
+			}
+		}
+	}
+	var out []string
+	for _, n := range g.Nodes {
+		if hit[n.ID] {
+			out = append(out, n.ID)
+		}
+	}
+	return out
+}
+
+// Focus returns the part of the graph that id takes part in: id, what it
+// needs and what needs it, each transitively.
+func (g *Graph) Focus(id string) *Graph {
+	keep := map[string]bool{id: true}
+	for _, d := range g.Dependents(id) {
+		keep[d] = true
+	}
+	queue := []string{id}
+	for len(queue) > 0 {
+		next := queue[0]
+		queue = queue[1:]
+		for _, e := range g.Requires(next) {
+			if !keep[e.To] {
+				keep[e.To] = true
+				queue = append(queue, e.To)
+			}
+		}
+	}
+	out := &Graph{}
+	for _, n := range g.Nodes {
+		if keep[n.ID] {
+			out.Nodes = append(out.Nodes, n)
+		}
+	}
+	for _, e := range g.Edges {
+		if keep[e.From] && keep[e.To] {
+			out.Edges = append(out.Edges, e)
+		}
+	}
+	return out
+}
+
+// Dot writes the graph in Graphviz's DOT language, clustering nodes by
+// resource kind. Edges from [[order]] rules are bold and Homebrew's are
+// dashed.
+func (g *Graph) Dot(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("digraph maziq {\n\trankdir=LR;\n\tnode [shape=box, fontname=\"Helvetica\"];\n")
+	var kinds []string
+	byKind := map[string][]string{}
+	for _, n := range g.Nodes {
+		kind, _, _ := strings.Cut(n.ID, ":")
+		if _, ok := byKind[kind]; !ok {
+			kinds = append(kinds, kind)
+		}
+		byKind[kind] = append(byKind[kind], n.ID)
+	}
+	for i, kind := range kinds {
+		fmt.Fprintf(&b, "\tsubgraph cluster_%d {\n\t\tlabel=%s;\n", i, quote(kind))
+		for _, id := range byKind[kind] {
+			_, name, _ := strings.Cut(id, ":")
+			fmt.Fprintf(&b, "\t\t%s [label=%s];\n", quote(id), quote(name))
+		}
+		b.WriteString("\t}\n")
+	}
+	for _, e := range g.Edges {
+		attrs := ""
+		switch e.Kind {
+		case Order:
+			attrs = " [style=bold]"
+		case Brew:
+			attrs = " [style=dashed]"
+		}
+		fmt.Fprintf(&b, "\t%s -> %s%s;\n", quote(e.From), quote(e.To), attrs)
+	}
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// quote returns s as a DOT string.
+func quote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
@@ -0,0 +1,179 @@
+// Package health runs quick, read-only checks of the provisioning
+// prerequisites on this machine.
+package health
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/hmziqrs/maziq/internal/config"
+	"github.com/hmziqrs/maziq/internal/fetch"
+)
+
+// Level is the outcome of a check.
+type Level string
+
+const (
+	OK   Level = "ok"
+	Warn Level = "warn"
+	Fail Level = "fail"
+)
+
+// Result is the outcome of one check.
+type Result struct {
+	Name   string `json:"name"`
+	Level  Level  `json:"level"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// Check is a single health probe.
+type Check struct {
+	Name string
+	Run  func(ctx context.Context) (Level, string)
+}
+
+const checkTimeout = 5 * time.Second
+
+// minFreeBytes is the free space below which provisioning is likely to fail.
+const minFreeBytes = 10 << 30
+
+// Checks is the default set of health checks.
+var Checks = []Check{
+	{Name: "macOS", Run: checkOS},
+	{Name: "Homebrew", Run: checkCommand("brew", "--version")},
+	{Name: "Xcode Command Line Tools", Run: checkCommand("xcode-select", "-p")},
+	{Name: "Disk space", Run: checkDisk},
+	{Name: "State directory", Run: checkStateDir},
+	{Name: "Man pages", Run: checkManPath},
+	{Name: "Network", Run: Network},
+}
+
+// Run executes every check in order.
+func Run(ctx context.Context) []Result {
+	out := make([]Result, 0, len(Checks))
+	for _, c := range Checks {
+		cctx, cancel := context.WithTimeout(ctx, checkTimeout)
+		level, detail := c.Run(cctx)
+		cancel()
+		out = append(out, Result{Name: c.Name, Level: level, Detail: detail})
+	}
+	return out
+}
+
+func checkOS(context.Context) (Level, string) {
+	if runtime.GOOS != "darwin" {
+		return Warn, runtime.GOOS + " is not supported yet"
+	}
+	return OK, runtime.GOARCH
+}
+
+func checkCommand(name string, args ...string) func(context.Context) (Level, string) {
+	return func(ctx context.Context) (Level, string) {
+		if _, err := exec.LookPath(name); err != nil {
+			return Fail, name + " not found on PATH"
+		}
+		if err := exec.CommandContext(ctx, name, args...).Run(); err != nil {
+			return Fail, err.Error()
+		}
+		return OK, ""
+	}
+}
+
+func checkDisk(context.Context) (Level, string) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return Warn, err.Error()
+	}
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(home, &st); err != nil {
+		return Warn, err.Error()
+	}
+	free := st.Bavail * uint64(st.Bsize)
+	detail := fmt.Sprintf("%.1f GB free", float64(free)/(1<<30))
+	if free < minFreeBytes {
+		return Warn, detail
+	}
+	return OK, detail
+}
+
+func checkStateDir(context.Context) (Level, string) {
+	dir := config.Dir()
+	if _, err := os.Stat(dir); err != nil {
+		return Warn, dir + " does not exist yet"
+	}
+	// Checks are read-only, so probe permissions instead of writing a file.
+	if err := syscall.Access(dir, 0x2); err != nil {
+		return Fail, dir + " is not writable"
+	}
+	return OK, dir
+}
+
+// checkManPath asks man for one of the pages Homebrew installed. MANPATH
+// set without them hides every tool's page; the managed rc block adds
+// them when the template installs software.
+func checkManPath(ctx context.Context) (Level, string) {
+	brew, err := exec.LookPath("brew")
+	if err != nil {
+		return OK, "no Homebrew"
+	}
+	pages, _ := filepath.Glob(filepath.Join(filepath.Dir(filepath.Dir(brew)), "share", "man", "man1", "*.1"))
+	if len(pages) == 0 {
+		return OK, "Homebrew has no man pages"
+	}
+	page := strings.TrimSuffix(filepath.Base(pages[0]), ".1")
+	if err := exec.CommandContext(ctx, "man", "-w", page).Run(); err != nil {
+		return Warn, fmt.Sprintf("man does not find %s; Homebrew's share/man is not on MANPATH (maziq apply adds it from the shell block)", page)
+	}
+	return OK, ""
+}
+
+// Network reaches Homebrew's API and bottles, or the mirrors a template's
+// [proxy] points at, the way installs will: through the proxy ctx carries
+// (see fetch.WithProxy) or the environment's. Any HTTP response counts;
+// the bottle registry answers 401 without a token. An unreachable mirror or proxy fails the check, as
+// every install would; without either, the machine may just be offline.
+func Network(ctx context.Context) (Level, string) {
+	configured := false
+	endpoint := func(key, def string) string {
+		if v := fetch.Getenv(ctx, key); v != "" {
+			configured = true
+			return v
+		}
+		return def
+	}
+	urls := []string{
+		endpoint("HOMEBREW_API_DOMAIN", "https://formulae.brew.sh/api"),
+		endpoint("HOMEBREW_BOTTLE_DOMAIN", "https://ghcr.io/v2/homebrew/core"),
+	}
+	via := fetch.Proxy(ctx, urls[0])
+	level := Warn
+	if configured || via != "" {
+		level = Fail
+	}
+	for _, u := range urls {
+		req, err := http.NewRequestWithContext(ctx, http.MethodHead, u, nil)
+		if err != nil {
+			return Fail, err.Error()
+		}
+		resp, err := fetch.Client.Do(req)
+		if err != nil {
+			if via != "" {
+				return level, fmt.Sprintf("%s unreachable through %s: %v", req.URL.Host, via, err)
+			}
+			return level, fmt.Sprintf("%s unreachable: %v", req.URL.Host, err)
+		}
+		resp.Body.Close()
+	}
+	if via != "" {
+		return OK, "through " + via
+	}
+	return OK, ""
+}
@@ -0,0 +1,81 @@
+// Package history persists what MazIQ installed, when, and from which
+// source, so it can answer "how did this get here" even when probes fail.
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/hmziqrs/maziq/internal/config"
+)
+
+// Record is one line of install_history.jsonl.
+type Record struct {
+	Software  string `json:"software"`
+	Action    string `json:"action"`
+	Version   string `json:"version,omitempty"`
+	Source    string `json:"source,omitempty"`
+	Timestamp int64  `json:"timestamp"`
+}
+
+// Time returns the record timestamp.
+func (r Record) Time() time.Time {
+	return time.Unix(r.Timestamp, 0)
+}
+
+// Path returns the history file location.
+func Path() string {
+	return filepath.Join(config.Dir(), "install_history.jsonl")
+}
+
+// Append writes a record, stamping it with the current time if unset.
+func Append(r Record) error {
+	if r.Timestamp == 0 {
+		r.Timestamp = time.Now().Unix()
+	}
+	if err := os.MkdirAll(filepath.Dir(Path()), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(Path(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return json.NewEncoder(f).Encode(r)
+}
+
+// Load returns every record in file order. Malformed lines are skipped.
+func Load() ([]Record, error) {
+	f, err := os.Open(Path())
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var out []Record
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var r Record
+		if json.Unmarshal(sc.Bytes(), &r) == nil && r.Software != "" {
+			out = append(out, r)
+		}
+	}
+	return out, sc.Err()
+}
+
+// Last returns the most recent record, if any.
+func Last() (Record, bool) {
+	records, err := Load()
+	if err != nil || len(records) == 0 {
+		return Record{}, false
+	}
+	return records[len(records)-1], true
+}
@@ -0,0 +1,473 @@
+package importer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/hmziqrs/maziq/internal/catalog"
+)
+
+// Ansible imports Ansible playbooks, task files and variable files. The
+// macOS modules with a template equivalent are converted: homebrew,
+// homebrew_cask, mas, osx_defaults and git, plus command and shell tasks the
+// script importer understands. Roles are followed when they sit next to the
+// playbook. The variables of geerlingguy's mac-dev-playbook
+// (homebrew_installed_packages, homebrew_cask_apps and mas_installed_apps)
+// are converted wherever they are set.
+func Ansible(paths ...string) (*Result, error) {
+	a := &ansible{r: &Result{}, vars: map[string]any{}}
+	for _, p := range paths {
+		if err := a.file(p); err != nil {
+			return nil, err
+		}
+	}
+	a.wellKnownVars()
+	return a.r, nil
+}
+
+type ansible struct {
+	r    *Result
+	vars map[string]any
+}
+
+func (a *ansible) load(path string) (any, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	v, err := parseYAML(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return v, nil
+}
+
+// file imports a playbook, a list of tasks or a file of variables.
+func (a *ansible) file(path string) error {
+	v, err := a.load(path)
+	if err != nil {
+		return err
+	}
+	switch v := v.(type) {
+	case map[string]any:
+		a.setVars(v)
+	case []any:
+		for _, item := range v {
+			m, ok := item.(map[string]any)
+			if !ok {
+				continue
+			}
+			if _, play := m["hosts"]; play {
+				if err := a.play(path, m); err != nil {
+					return err
+				}
+			} else if _, imp := m["import_playbook"]; imp {
+				if err := a.file(filepath.Join(filepath.Dir(path), str(m["import_playbook"]))); err != nil {
+					return err
+				}
+			} else {
+				a.task(path, m)
+			}
+		}
+	}
+	return nil
+}
+
+func (a *ansible) setVars(m map[string]any) {
+	for k, v := range m {
+		a.vars[k] = v
+	}
+}
+
+func (a *ansible) play(path string, play map[string]any) error {
+	dir := filepath.Dir(path)
+	if vars, ok := play["vars"].(map[string]any); ok {
+		a.setVars(vars)
+	}
+	for _, f := range list(play["vars_files"]) {
+		v, err := a.load(filepath.Join(dir, str(f)))
+		if err != nil {
+			// mac-dev-playbook lists an optional config.yml.
+			if os.IsNotExist(err) {
+				continue
+			}
+			return err
+		}
+		if m, ok := v.(map[string]any); ok {
+			a.setVars(m)
+		}
+	}
+	for _, key := range []string{"pre_tasks", "roles", "tasks", "post_tasks"} {
+		for _, item := range list(play[key]) {
+			if key == "roles" {
+				a.role(path, item)
+				continue
+			}
+			if m, ok := item.(map[string]any); ok {
+				a.task(path, m)
+			}
+		}
+	}
+	return nil
+}
+
+// wellKnownRoles are the roles whose work the variables carry.
+var wellKnownRoles = map[string]bool{
+	"geerlingguy.mac.homebrew": true, "geerlingguy.homebrew": true,
+	"geerlingguy.mac.mas": true, "geerlingguy.mas": true,
+	"elliotweiser.osx-command-line-tools": true,
+}
+
+// role imports a role found in roles/ next to the playbook.
+func (a *ansible) role(path string, item any) {
+	name := str(item)
+	if m, ok := item.(map[string]any); ok {
+		name = str(m["role"])
+		if name == "" {
+			name = str(m["name"])
+		}
+	}
+	if name == "elliotweiser.osx-command-line-tools" {
+		a.r.addSoftware("xcode_clt")
+	}
+	if wellKnownRoles[name] {
+		return
+	}
+	dir := filepath.Join(filepath.Dir(path), "roles", name)
+	if v, err := a.load(filepath.Join(dir, "defaults", "main.yml")); err == nil {
+		if m, ok := v.(map[string]any); ok {
+			for k, x := range m {
+				if _, set := a.vars[k]; !set {
+					a.vars[k] = x
+				}
+			}
+		}
+	}
+	v, err := a.load(filepath.Join(dir, "tasks", "main.yml"))
+	if err != nil {
+		a.r.skip(path, "role "+name, "not found next to the playbook")
+		return
+	}
+	for _, t := range list(v) {
+		if m, ok := t.(map[string]any); ok {
+			a.task(filepath.Join(dir, "tasks", "main.yml"), m)
+		}
+	}
+}
+
+// quietModules do nothing a template needs to repeat.
+var quietModules = map[string]bool{
+	"debug": true, "assert": true, "pause": true, "meta": true, "fail": true, "set_fact": true,
+	"homebrew_tap": true, "stat": true, "include_vars": true,
+}
+
+// task converts one task.
+func (a *ansible) task(path string, t map[string]any) {
+	source := path
+	if name := str(t["name"]); name != "" {
+		source += ": " + name
+	}
+	for _, key := range []string{"block", "rescue", "always"} {
+		for _, item := range list(t[key]) {
+			if m, ok := item.(map[string]any); ok {
+				a.task(path, m)
+			}
+		}
+	}
+	for _, key := range []string{"include_tasks", "import_tasks", "ansible.builtin.include_tasks", "ansible.builtin.import_tasks"} {
+		if f, ok := t[key]; ok {
+			file := filepath.Join(filepath.Dir(path), str(f))
+			v, err := a.load(file)
+			if err != nil {
+				a.r.skip(source, key+" "+str(f), err.Error())
+				return
+			}
+			for _, item := range list(v) {
+				if m, ok := item.(map[string]any); ok {
+					a.task(file, m)
+				}
+			}
+			return
+		}
+	}
+	module, args := moduleOf(t)
+	if module == "" || quietModules[module] {
+		return
+	}
+	items := []any{nil}
+	for _, key := range []string{"loop", "with_items", "with_list"} {
+		if l, ok := t[key]; ok {
+			v, ok := a.resolve(l, nil)
+			if !ok {
+				a.r.skip(source, module, "loops over "+str(l)+", which is not set in the playbook")
+				return
+			}
+			items = list(v)
+		}
+	}
+	for _, item := range items {
+		a.module(source, module, args, item)
+	}
+}
+
+// moduleOf finds the module a task calls and its arguments, dropping the
+// ansible.builtin. and community.general. collection prefixes.
+func moduleOf(t map[string]any) (string, map[string]any) {
+	for key, v := range t {
+		name := key
+		for _, prefix := range []string{"ansible.builtin.", "community.general.", "ansible.legacy."} {
+			name = strings.TrimPrefix(name, prefix)
+		}
+		if taskKeywords[name] {
+			continue
+		}
+		args, ok := v.(map[string]any)
+		if !ok {
+			args = map[string]any{}
+			if s := str(v); s != "" {
+				if name == "command" || name == "shell" {
+					args["cmd"] = s
+				} else {
+					for k, v := range freeForm(s) {
+						args[k] = v
+					}
+				}
+			}
+		}
+		if cmd, ok := t["args"].(map[string]any); ok {
+			for k, v := range cmd {
+				args[k] = v
+			}
+		}
+		return name, args
+	}
+	return "", nil
+}
+
+// freeForm parses a module's key=value arguments. Values may hold spaces
+// inside quotes or Jinja expressions.
+func freeForm(s string) map[string]any {
+	out := map[string]any{}
+	key := ""
+	for _, f := range strings.Fields(s) {
+		k, v, ok := strings.Cut(f, "=")
+		if ok && key != "" && strings.Count(str(out[key]), "{{") > strings.Count(str(out[key]), "}}") {
+			ok = false
+		}
+		if ok {
+			key, out[k] = k, strings.Trim(v, `"'`)
+			continue
+		}
+		if key != "" {
+			out[key] = str(out[key]) + " " + strings.Trim(f, `"'`)
+		}
+	}
+	return out
+}
+
+// taskKeywords are the keys of a task that are not its module.
+var taskKeywords = map[string]bool{
+	"name": true, "when": true, "loop": true, "with_items": true, "with_list": true, "loop_control": true,
+	"become": true, "become_user": true, "tags": true, "register": true, "changed_when": true,
+	"failed_when": true, "ignore_errors": true, "notify": true, "vars": true, "args": true,
+	"environment": true, "no_log": true, "check_mode": true, "delegate_to": true, "until": true,
+	"retries": true, "delay": true, "block": true, "rescue": true, "always": true,
+}
+
+// module converts one call of a module, with item for loops.
+func (a *ansible) module(source, module string, args map[string]any, item any) {
+	get := func(key string) (any, bool) {
+		v, ok := args[key]
+		if !ok {
+			return nil, true
+		}
+		return a.resolve(v, item)
+	}
+	state, _ := get("state")
+	if s := str(state); s == "absent" || s == "removed" || s == "uninstalled" {
+		a.r.skip(source, module, "removes rather than installs")
+		return
+	}
+	switch module {
+	case "homebrew", "homebrew_cask":
+		backend := catalog.BackendBrew
+		if module == "homebrew_cask" {
+			backend = catalog.BackendCask
+		}
+		names, ok := get("name")
+		if !ok {
+			a.r.skip(source, module, "names packages with a variable that is not set")
+			return
+		}
+		for _, n := range list(names) {
+			for _, pkg := range strings.Split(packageName(n), ",") {
+				if pkg = strings.TrimSpace(pkg); pkg != "" {
+					a.r.addPackage(source, backend, pkg)
+				}
+			}
+		}
+	case "mas":
+		ids, ok := get("id")
+		if !ok {
+			a.r.skip(source, module, "names apps with a variable that is not set")
+			return
+		}
+		for _, id := range list(ids) {
+			n, err := strconv.ParseInt(str(id), 10, 64)
+			if err != nil {
+				a.r.skip(source, "mas "+str(id), "the App Store ID is not a number")
+				continue
+			}
+			a.r.addApp("", n)
+		}
+	case "osx_defaults":
+		a.defaults(source, get)
+	case "git":
+		repo, _ := get("repo")
+		dest, _ := get("dest")
+		version, _ := get("version")
+		if str(repo) == "" || strings.Contains(str(repo)+str(dest), "{{") {
+			a.r.skip(source, module, "the repository or destination uses a variable that is not set")
+			return
+		}
+		r := Repo{URL: str(repo), Path: str(dest)}
+		if v := str(version); v != "" && v != "HEAD" {
+			r.Branch = v
+		}
+		a.r.addRepo(r)
+	case "command", "shell":
+		cmd, ok := get("cmd")
+		if !ok || strings.Contains(str(cmd), "{{") {
+			a.r.skip(source, module, "the command uses a variable that is not set")
+			return
+		}
+		for _, line := range strings.Split(str(cmd), "\n") {
+			for _, c := range splitCommands(line) {
+				a.r.command(source, "", c)
+			}
+		}
+	default:
+		a.r.skip(source, module, "no template equivalent")
+	}
+}
+
+// defaults converts an osx_defaults task.
+func (a *ansible) defaults(source string, get func(string) (any, bool)) {
+	domain, _ := get("domain")
+	key, _ := get("key")
+	typ, _ := get("type")
+	value, ok := get("value")
+	host, _ := get("host")
+	if !ok || str(key) == "" {
+		a.r.skip(source, "osx_defaults", "the key or value uses a variable that is not set")
+		return
+	}
+	dom, t := str(domain), str(typ)
+	if dom == "" {
+		dom = "NSGlobalDomain"
+	}
+	if t == "" {
+		t = "string"
+	}
+	args := []string{"write", dom, str(key), "-" + t}
+	if items, ok := value.([]any); ok && t == "array" {
+		for _, item := range items {
+			args = append(args, str(item))
+		}
+	} else {
+		args = append(args, str(value))
+	}
+	d, reason := parseDefaults(args)
+	if reason != "" {
+		a.r.skip(source, "osx_defaults "+dom+" "+str(key), reason)
+		return
+	}
+	d.CurrentHost = str(host) == "currentHost"
+	a.r.addDefault(d)
+}
+
+// wellKnownVars converts the variables of mac-dev-playbook's roles.
+func (a *ansible) wellKnownVars() {
+	for _, v := range list(a.vars["homebrew_installed_packages"]) {
+		a.r.addPackage("homebrew_installed_packages", catalog.BackendBrew, packageName(v))
+	}
+	for _, v := range list(a.vars["homebrew_cask_apps"]) {
+		a.r.addPackage("homebrew_cask_apps", catalog.BackendCask, packageName(v))
+	}
+	for _, v := range list(a.vars["mas_installed_apps"]) {
+		m, _ := v.(map[string]any)
+		if n, err := strconv.ParseInt(str(m["id"]), 10, 64); err == nil {
+			a.r.addApp(str(m["name"]), n)
+		}
+	}
+	for _, v := range list(a.vars["mas_installed_app_ids"]) {
+		if n, err := strconv.ParseInt(str(v), 10, 64); err == nil {
+			a.r.addApp("", n)
+		}
+	}
+}
+
+// packageName is a package given as a name or as a table with one.
+func packageName(v any) string {
+	if m, ok := v.(map[string]any); ok {
+		return str(m["name"])
+	}
+	return str(v)
+}
+
+// resolve substitutes a value that is a single Jinja expression: a variable
+// or a field of the loop item. ok is false when that cannot be done.
+func (a *ansible) resolve(v any, item any) (any, bool) {
+	s, isStr := v.(string)
+	if !isStr || !strings.Contains(s, "{{") {
+		return v, true
+	}
+	expr := strings.TrimSpace(s)
+	if !strings.HasPrefix(expr, "{{") || !strings.HasSuffix(expr, "}}") || strings.Count(expr, "{{") > 1 {
+		return nil, false
+	}
+	expr = strings.TrimSpace(expr[2 : len(expr)-2])
+	name, field, _ := strings.Cut(expr, ".")
+	var out any
+	switch {
+	case name == "item":
+		out = item
+	default:
+		x, ok := a.vars[name]
+		if !ok {
+			return nil, false
+		}
+		out = x
+	}
+	if field != "" {
+		m, ok := out.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		out = m[field]
+	}
+	if out == nil {
+		return nil, false
+	}
+	return a.resolve(out, item)
+}
+
+func list(v any) []any {
+	switch v := v.(type) {
+	case nil:
+		return nil
+	case []any:
+		return v
+	}
+	return []any{v}
+}
+
+func str(v any) string {
+	if v == nil {
+		return ""
+	}
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,186 @@
+package importer
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultChezmoi is where chezmoi keeps its source directory by default.
+const DefaultChezmoi = "~/.local/share/chezmoi"
+
+// chezmoiConfigs maps the targets that a template section links to that
+// section. Karabiner's config is a directory.
+var chezmoiConfigs = map[string]string{
+	".tmux.conf":                "tmux",
+	".config/tmux/tmux.conf":    "tmux",
+	".config/karabiner":         "karabiner",
+	".skhdrc":                   "skhd",
+	".config/skhd/skhdrc":       "skhd",
+	".yabairc":                  "yabai",
+	".config/yabai/yabairc":     "yabai",
+	".Brewfile":                 "",
+	".config/homebrew/Brewfile": "",
+}
+
+// Chezmoi imports a chezmoi source directory. Scripts are converted like
+// shell scripts and Brewfiles like Brewfiles. Configs that a template
+// section links, such as ~/.tmux.conf, are linked from the source
+// directory. Other dotfiles, and files chezmoi renders from templates,
+// decrypts or edits in place, are reported.
+func Chezmoi(dir string) (*Result, error) {
+	if root, err := os.ReadFile(filepath.Join(dir, ".chezmoiroot")); err == nil {
+		dir = filepath.Join(dir, strings.TrimSpace(string(root)))
+	}
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	ignore := chezmoiIgnore(dir)
+	r := &Result{}
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(dir, path)
+		if rel == "." {
+			return nil
+		}
+		name := d.Name()
+		if name == ".chezmoiscripts" {
+			return nil
+		}
+		// chezmoi ignores other dot names in its source directory.
+		if strings.HasPrefix(name, ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		target, attrs := chezmoiTarget(rel)
+		if ignore(target) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			if section, ok := chezmoiConfigs[target]; ok && section != "" {
+				r.setConfig(section, tildify(path))
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if attrs["run"] || strings.HasPrefix(rel, ".chezmoiscripts"+string(filepath.Separator)) {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			r.script(path, data)
+			return nil
+		}
+		section, known := chezmoiConfigs[target]
+		switch {
+		case attrs["template"] || attrs["encrypted"] || attrs["modify"]:
+			reason := "chezmoi renders it from a template; link the rendered file yourself"
+			if !attrs["template"] {
+				reason = "chezmoi decrypts or edits it in place; manage it yourself"
+			}
+			r.skip(path, "~/"+target, reason)
+		case known && section == "":
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			r.brewfile(path, data)
+		case known:
+			r.setConfig(section, tildify(path))
+		case attrs["remove"]:
+			r.skip(path, "~/"+target, "removes a file; no template equivalent")
+		default:
+			r.skip(path, "~/"+target, "a dotfile no template section manages")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// chezmoiIgnore reads the patterns of .chezmoiignore and returns whether a
+// target matches one. Lines that are template directives are passed over,
+// so targets ignored only on some machines are imported.
+func chezmoiIgnore(dir string) func(target string) bool {
+	data, _ := os.ReadFile(filepath.Join(dir, ".chezmoiignore"))
+	var patterns []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "#") && !strings.Contains(line, "{{") {
+			patterns = append(patterns, line)
+		}
+	}
+	return func(target string) bool {
+		for _, p := range patterns {
+			exclude := strings.HasPrefix(p, "!")
+			if ok, _ := path.Match(strings.TrimPrefix(p, "!"), target); ok || strings.HasPrefix(target, strings.TrimSuffix(strings.TrimPrefix(p, "!"), "/**")+"/") {
+				return !exclude
+			}
+		}
+		return false
+	}
+}
+
+// chezmoiPrefixes are the attribute prefixes of source names, in the order
+// chezmoi allows them.
+var chezmoiPrefixes = []struct{ prefix, attr string }{
+	{"remove_", "remove"}, {"external_", "external"}, {"exact_", "exact"}, {"create_", "create"},
+	{"modify_", "modify"}, {"run_", "run"}, {"once_", "once"}, {"onchange_", "onchange"},
+	{"before_", "before"}, {"after_", "after"}, {"symlink_", "symlink"}, {"encrypted_", "encrypted"},
+	{"private_", "private"}, {"readonly_", "readonly"}, {"empty_", "empty"}, {"executable_", "executable"},
+}
+
+// chezmoiTarget decodes a source path into its target relative to the home
+// directory, with the attributes of its last element.
+func chezmoiTarget(rel string) (string, map[string]bool) {
+	parts := strings.Split(filepath.ToSlash(rel), "/")
+	var attrs map[string]bool
+	for i, p := range parts {
+		attrs = map[string]bool{}
+		for _, a := range chezmoiPrefixes {
+			if strings.HasPrefix(p, a.prefix) {
+				p, attrs[a.attr] = p[len(a.prefix):], true
+			}
+		}
+		switch {
+		case strings.HasPrefix(p, "literal_"):
+			p = p[len("literal_"):]
+		case strings.HasPrefix(p, "dot_"):
+			p = "." + p[len("dot_"):]
+		}
+		for _, s := range []struct{ suffix, attr string }{{".tmpl", "template"}, {".literal", ""}, {".age", "encrypted"}, {".asc", "encrypted"}} {
+			if strings.HasSuffix(p, s.suffix) && (s.attr != "encrypted" || attrs["encrypted"]) {
+				p = strings.TrimSuffix(p, s.suffix)
+				if s.attr != "" {
+					attrs[s.attr] = true
+				}
+			}
+		}
+		parts[i] = p
+	}
+	return strings.Join(parts, "/"), attrs
+}
+
+// tildify writes paths under the home directory with ~.
+func tildify(path string) string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	if rel, err := filepath.Rel(home, path); err == nil && !strings.HasPrefix(rel, "..") {
+		return filepath.Join("~", rel)
+	}
+	return path
+}
@@ -0,0 +1,209 @@
+// Package importer converts setups written for other tools into MazIQ
+// templates: chezmoi source directories, strap-style shell scripts and
+// Brewfiles, and a subset of Ansible macOS playbooks. What has a template
+// equivalent is converted; everything else is reported as Skipped so it can
+// be moved over by hand.
+package importer
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+
+	"github.com/hmziqrs/maziq/internal/catalog"
+	"github.com/hmziqrs/maziq/internal/modules/defaults"
+)
+
+// Result is what an import converted and what it could not.
+type Result struct {
+	// Software lists catalog IDs in the order they were found.
+	Software []string
+	Fonts    []Font
+	AppStore []App
+	Defaults []Default
+	Repos    []Repo
+	// Configs maps [tmux], [karabiner], [skhd] and [yabai] to the config
+	// file or directory to link.
+	Configs map[string]string
+	Skipped []Skipped
+
+	// read records the files already imported.
+	read map[string]bool
+}
+
+// Font is a [[fonts]] entry from a font cask.
+type Font struct {
+	Name string `toml:"name"`
+	Cask string `toml:"cask"`
+}
+
+// App is a [[mas]] entry.
+type App struct {
+	Name string `toml:"name"`
+	ID   int64  `toml:"id"`
+}
+
+// Default is a [[defaults]] entry.
+type Default struct {
+	Domain      string `toml:"domain"`
+	Key         string `toml:"key"`
+	Value       any    `toml:"value"`
+	CurrentHost bool   `toml:"current_host,omitempty"`
+	Restart     string `toml:"restart,omitempty"`
+}
+
+// Repo is a [[repos.repo]] entry.
+type Repo struct {
+	URL    string `toml:"url"`
+	Path   string `toml:"path,omitempty"`
+	Branch string `toml:"branch,omitempty"`
+}
+
+// Skipped is something found in the source with no template equivalent.
+type Skipped struct {
+	// Source is where it was found, as file:line when there is a line.
+	Source string
+	What   string
+	Reason string
+}
+
+// first reports whether path is being imported for the first time.
+func (r *Result) first(path string) bool {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	if r.read[path] {
+		return false
+	}
+	if r.read == nil {
+		r.read = map[string]bool{}
+	}
+	r.read[path] = true
+	return true
+}
+
+func (r *Result) skip(source, what, reason string) {
+	r.Skipped = append(r.Skipped, Skipped{Source: source, What: what, Reason: reason})
+}
+
+func (r *Result) addSoftware(id string) {
+	if !slices.Contains(r.Software, id) {
+		r.Software = append(r.Software, id)
+	}
+}
+
+// addPackage adds the catalog entry for a package, a font cask as a font.
+func (r *Result) addPackage(source string, backend catalog.Backend, pkg string) {
+	if s, ok := catalog.ByPackage(backend, pkg); ok {
+		r.addSoftware(s.ID)
+		return
+	}
+	if backend == catalog.BackendCask && strings.HasPrefix(pkg, "font-") {
+		if !slices.ContainsFunc(r.Fonts, func(f Font) bool { return f.Cask == pkg }) {
+			r.Fonts = append(r.Fonts, Font{Name: fontName(pkg), Cask: pkg})
+		}
+		return
+	}
+	r.skip(source, fmt.Sprintf("%s %s", backend, pkg), "not in the catalog")
+}
+
+// fontName guesses a family name from a font cask: font-fira-code is
+// "Fira Code".
+func fontName(cask string) string {
+	words := strings.Split(strings.TrimPrefix(cask, "font-"), "-")
+	for i, w := range words {
+		if w != "" {
+			words[i] = strings.ToUpper(w[:1]) + w[1:]
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+func (r *Result) addApp(name string, id int64) {
+	if slices.ContainsFunc(r.AppStore, func(a App) bool { return a.ID == id }) {
+		return
+	}
+	if name == "" {
+		name = strconv.FormatInt(id, 10)
+	}
+	r.AppStore = append(r.AppStore, App{Name: name, ID: id})
+	r.addSoftware("mas")
+}
+
+// addDefault adds a preference, replacing an earlier write of the same key.
+func (r *Result) addDefault(d Default) {
+	if d.Domain == "-g" || d.Domain == "NSGlobalDomain" {
+		d.Domain = "NSGlobalDomain"
+	}
+	d.Restart = defaults.Restart(d.Domain)
+	for i, x := range r.Defaults {
+		if x.Domain == d.Domain && x.Key == d.Key && x.CurrentHost == d.CurrentHost {
+			r.Defaults[i] = d
+			return
+		}
+	}
+	r.Defaults = append(r.Defaults, d)
+}
+
+func (r *Result) addRepo(repo Repo) {
+	if !slices.ContainsFunc(r.Repos, func(x Repo) bool { return x.URL == repo.URL }) {
+		r.Repos = append(r.Repos, repo)
+	}
+}
+
+// configSoftware is the software each config section needs.
+var configSoftware = map[string]string{"tmux": "tmux", "karabiner": "karabiner_elements", "skhd": "skhd", "yabai": "yabai"}
+
+func (r *Result) setConfig(section, path string) {
+	if r.Configs == nil {
+		r.Configs = map[string]string{}
+	}
+	r.Configs[section] = path
+	r.addSoftware(configSoftware[section])
+}
+
+// Empty reports whether nothing was converted.
+func (r *Result) Empty() bool {
+	return len(r.Software) == 0 && len(r.Fonts) == 0 && len(r.AppStore) == 0 &&
+		len(r.Defaults) == 0 && len(r.Repos) == 0 && len(r.Configs) == 0
+}
+
+// Template renders the converted setup as a template named name.
+func (r *Result) Template(name, description string) ([]byte, error) {
+	type config struct {
+		Config string `toml:"config"`
+	}
+	type repos struct {
+		Repos []Repo `toml:"repo"`
+	}
+	out := struct {
+		Name        string    `toml:"name"`
+		Description string    `toml:"description,omitempty"`
+		Software    []string  `toml:"software,omitempty"`
+		Fonts       []Font    `toml:"fonts,omitempty"`
+		AppStore    []App     `toml:"mas,omitempty"`
+		Defaults    []Default `toml:"defaults,omitempty"`
+		Repos       *repos    `toml:"repos,omitempty"`
+		Tmux        *config   `toml:"tmux,omitempty"`
+		Karabiner   *config   `toml:"karabiner,omitempty"`
+		Skhd        *config   `toml:"skhd,omitempty"`
+		Yabai       *config   `toml:"yabai,omitempty"`
+	}{Name: name, Description: description, Software: r.Software, Fonts: r.Fonts, AppStore: r.AppStore, Defaults: r.Defaults}
+	if len(r.Repos) > 0 {
+		out.Repos = &repos{r.Repos}
+	}
+	for section, dst := range map[string]**config{"tmux": &out.Tmux, "karabiner": &out.Karabiner, "skhd": &out.Skhd, "yabai": &out.Yabai} {
+		if p, ok := r.Configs[section]; ok {
+			*dst = &config{p}
+		}
+	}
+	var b strings.Builder
+	if err := toml.NewEncoder(&b).Encode(out); err != nil {
+		return nil, err
+	}
+	return []byte(b.String()), nil
+}
@@ -0,0 +1,559 @@
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/hmziqrs/maziq/internal/catalog"
+)
+
+// Script imports strap-style setup scripts. Each path is a shell script, a
+// Brewfile, or a directory holding them: its *.sh files, Brewfile and
+// .Brewfile, and the scripts under script/.
+func Script(paths ...string) (*Result, error) {
+	r := &Result{}
+	for _, p := range paths {
+		info, err := os.Stat(p)
+		if err != nil {
+			return nil, err
+		}
+		files := []string{p}
+		if info.IsDir() {
+			files = scriptFiles(p)
+			if len(files) == 0 {
+				return nil, fmt.Errorf("%s holds no shell scripts or Brewfile", p)
+			}
+		}
+		for _, f := range files {
+			if err := r.file(f); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return r, nil
+}
+
+func scriptFiles(dir string) []string {
+	var out []string
+	for _, pattern := range []string{"Brewfile", ".Brewfile", "*.sh", "script/*"} {
+		matches, _ := filepath.Glob(filepath.Join(dir, pattern))
+		for _, m := range matches {
+			if info, err := os.Stat(m); err == nil && info.Mode().IsRegular() {
+				out = append(out, m)
+			}
+		}
+	}
+	return out
+}
+
+// file imports a Brewfile or a shell script.
+func (r *Result) file(path string) error {
+	if !r.first(path) {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if base := filepath.Base(path); strings.EqualFold(strings.TrimPrefix(base, "."), "Brewfile") {
+		r.brewfile(path, data)
+		return nil
+	}
+	r.script(path, data)
+	return nil
+}
+
+// heredoc matches the start of a here-document and captures its delimiter.
+var heredoc = regexp.MustCompile(`<<-?\s*['"]?(\w+)['"]?`)
+
+// script converts the commands of a shell script, one line at a time. A
+// Brewfile fed to brew bundle as a here-document is converted too; lines
+// that are only chezmoi template directives are passed over.
+func (r *Result) script(path string, data []byte) {
+	lines := strings.Split(string(data), "\n")
+	for i := 0; i < len(lines); i++ {
+		source := fmt.Sprintf("%s:%d", path, i+1)
+		line := strings.TrimSpace(lines[i])
+		for strings.HasSuffix(line, "\\") && i+1 < len(lines) {
+			i++
+			line = strings.TrimSuffix(line, "\\") + " " + strings.TrimSpace(lines[i])
+		}
+		if strings.HasPrefix(line, "{{") && strings.HasSuffix(line, "}}") {
+			continue
+		}
+		if m := heredoc.FindStringSubmatchIndex(line); m != nil {
+			delim := line[m[2]:m[3]]
+			var body []string
+			for i+1 < len(lines) && strings.TrimSpace(lines[i+1]) != delim {
+				i++
+				body = append(body, lines[i])
+			}
+			i++
+			line = line[:m[0]]
+			if w := words(line); len(w) > 1 && w[0] == "brew" && w[1] == "bundle" {
+				r.brewfile(source, []byte(strings.Join(body, "\n")))
+				continue
+			}
+		}
+		for _, cmd := range splitCommands(line) {
+			r.command(source, filepath.Dir(path), cmd)
+		}
+	}
+}
+
+// splitCommands splits a line into its commands at ;, && and ||, outside
+// quotes, dropping comments.
+func splitCommands(line string) []string {
+	var out []string
+	var cur strings.Builder
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			i = len(line)
+			continue
+		case c == ';' || (c == '&' || c == '|') && i+1 < len(line) && line[i+1] == c:
+			out = append(out, cur.String())
+			cur.Reset()
+			if c != ';' {
+				i++
+			}
+			continue
+		}
+		cur.WriteByte(c)
+	}
+	out = append(out, cur.String())
+	return out
+}
+
+// words splits a command into words, removing quotes. $HOME becomes ~.
+func words(cmd string) []string {
+	var out []string
+	var cur strings.Builder
+	var quote byte
+	inWord := false
+	for i := 0; i < len(cmd); i++ {
+		c := cmd[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+				continue
+			}
+		case c == '\'' || c == '"':
+			quote, inWord = c, true
+			continue
+		case c == ' ' || c == '\t':
+			if inWord {
+				out = append(out, cur.String())
+				cur.Reset()
+				inWord = false
+			}
+			continue
+		}
+		cur.WriteByte(c)
+		inWord = true
+	}
+	if inWord {
+		out = append(out, cur.String())
+	}
+	for i, w := range out {
+		for _, home := range []string{"${HOME}", "$HOME"} {
+			if strings.HasPrefix(w, home) {
+				out[i] = "~" + w[len(home):]
+			}
+		}
+	}
+	return out
+}
+
+// templated is why lines filled in from chezmoi's data are not converted.
+const templated = "filled in from chezmoi data; run chezmoi execute-template and import the result"
+
+// quiet are the commands that only steer a script: they are neither
+// converted nor worth reporting.
+var quiet = map[string]bool{
+	"echo": true, "printf": true, "set": true, "shift": true, "local": true, "readonly": true,
+	"declare": true, "export": true, "return": true, "exit": true, "if": true, "fi": true, "for": true,
+	"while": true, "until": true, "done": true, "case": true, "esac": true, ";;": true, "{": true,
+	"}": true, "true": true, "false": true, ":": true, "sleep": true, "cd": true, "pushd": true,
+	"popd": true, "trap": true, "source": true, ".": true, "[": true, "[[": true, "test": true,
+	"function": true, "read": true, "unset": true, "log": true, "logn": true, "logk": true,
+	"abort": true, "command": true, "type": true, "which": true, "wait": true,
+}
+
+// command converts one shell command found at source. dir resolves the
+// relative paths the command names.
+func (r *Result) command(source, dir, cmd string) {
+	w := words(cmd)
+	for len(w) > 0 && (w[0] == "then" || w[0] == "else" || w[0] == "do" || w[0] == "sudo" || w[0] == "!" ||
+		strings.Contains(w[0], "=") && !strings.HasPrefix(w[0], "-")) {
+		w = w[1:]
+	}
+	if len(w) == 0 || quiet[w[0]] || strings.HasSuffix(w[0], "()") || strings.HasPrefix(w[0], "#") {
+		return
+	}
+	cmd = strings.Join(w, " ")
+	if strings.Contains(cmd, "{{") {
+		r.skip(source, cmd, templated)
+		return
+	}
+	if strings.Contains(cmd, "Homebrew/install") {
+		r.addSoftware("homebrew")
+		return
+	}
+	switch w[0] {
+	case "brew":
+		r.brew(source, dir, w[1:], cmd)
+		return
+	case "defaults":
+		if d, reason := parseDefaults(w[1:]); reason == "" {
+			r.addDefault(d)
+		} else if reason != "-" {
+			r.skip(source, cmd, reason)
+		}
+		return
+	case "mas":
+		if len(w) > 1 && w[1] == "install" {
+			for _, id := range w[2:] {
+				if n, err := strconv.ParseInt(id, 10, 64); err == nil {
+					r.addApp("", n)
+				} else {
+					r.skip(source, cmd, "the App Store ID is not a number")
+				}
+			}
+			return
+		}
+		if len(w) > 1 && (w[1] == "signin" || w[1] == "upgrade" || w[1] == "outdated") {
+			return
+		}
+	case "git":
+		if repo, ok := parseClone(w[1:]); ok {
+			r.addRepo(repo)
+			return
+		}
+	case "killall":
+		if len(w) > 1 && (w[len(w)-1] == "Dock" || w[len(w)-1] == "Finder" || w[len(w)-1] == "SystemUIServer") {
+			// MazIQ restarts these after writing their preferences.
+			return
+		}
+	case "xcode-select":
+		if len(w) > 1 && w[1] == "--install" {
+			r.addSoftware("xcode_clt")
+			return
+		}
+	case "cargo", "npm", "uv":
+		if pkgs, backend, ok := parseToolInstall(w); ok {
+			for _, p := range pkgs {
+				r.addPackage(source, backend, p)
+			}
+			return
+		}
+	}
+	r.skip(source, cmd, "no template equivalent")
+}
+
+// brew converts a brew command with args.
+func (r *Result) brew(source, dir string, args []string, cmd string) {
+	if len(args) == 0 {
+		return
+	}
+	switch args[0] {
+	case "install", "reinstall":
+		backend := catalog.BackendBrew
+		var pkgs []string
+		for _, a := range args[1:] {
+			switch {
+			case a == "--cask" || a == "--casks":
+				backend = catalog.BackendCask
+			case strings.HasPrefix(a, "-"):
+			default:
+				pkgs = append(pkgs, a)
+			}
+		}
+		for _, p := range pkgs {
+			r.addPackage(source, backend, p)
+		}
+	case "cask":
+		if len(args) > 1 && args[1] == "install" {
+			for _, p := range args[2:] {
+				if !strings.HasPrefix(p, "-") {
+					r.addPackage(source, catalog.BackendCask, p)
+				}
+			}
+			return
+		}
+		r.skip(source, cmd, "no template equivalent")
+	case "bundle":
+		file := "Brewfile"
+		for i, a := range args[1:] {
+			switch {
+			case a == "--global":
+				file = "~/.Brewfile"
+			case strings.HasPrefix(a, "--file="):
+				file = a[len("--file="):]
+			case a == "--file" && i+2 < len(args):
+				file = args[i+2]
+			}
+		}
+		if strings.HasPrefix(file, "~/") {
+			home, _ := os.UserHomeDir()
+			file = filepath.Join(home, file[2:])
+		} else if !filepath.IsAbs(file) {
+			// Scripts under script/ usually run from the directory above.
+			rel := file
+			file = filepath.Join(dir, rel)
+			if _, err := os.Stat(file); err != nil {
+				file = filepath.Join(filepath.Dir(dir), rel)
+			}
+		}
+		if err := r.file(file); err != nil {
+			r.skip(source, cmd, fmt.Sprintf("could not read %s: %v", file, err))
+		}
+	case "tap", "update", "upgrade", "cleanup", "doctor", "analytics", "autoremove", "shellenv", "--prefix", "list":
+		// Taps come with the software that needs them; the rest is upkeep
+		// MazIQ does itself.
+	default:
+		r.skip(source, cmd, "no template equivalent")
+	}
+}
+
+// parseDefaults converts the arguments of a defaults command. The reason is
+// "" on success and "-" for commands that only read.
+func parseDefaults(args []string) (Default, string) {
+	var d Default
+	if len(args) > 0 && args[0] == "-currentHost" {
+		d.CurrentHost, args = true, args[1:]
+	}
+	if len(args) == 0 || args[0] != "write" {
+		if len(args) > 0 && (args[0] == "read" || args[0] == "read-type" || args[0] == "domains" || args[0] == "find") {
+			return d, "-"
+		}
+		return d, "only defaults write has a template equivalent"
+	}
+	if len(args) < 4 {
+		return d, "a defaults write without a key and value"
+	}
+	d.Domain, d.Key = args[1], args[2]
+	if strings.Contains(d.Domain, "$") || slices.ContainsFunc(args[3:], func(a string) bool { return strings.Contains(a, "$") }) {
+		return d, "uses a shell variable"
+	}
+	typ, raw := "-string", args[3:]
+	if strings.HasPrefix(raw[0], "-") {
+		typ, raw = raw[0], raw[1:]
+	}
+	switch typ {
+	case "-array":
+		items := []any{}
+		for len(raw) > 0 {
+			v, rest, reason := parseItem(raw)
+			if reason != "" {
+				return d, reason
+			}
+			items, raw = append(items, v), rest
+		}
+		d.Value = items
+		return d, ""
+	case "-dict":
+		dict := map[string]any{}
+		for len(raw) > 0 {
+			if len(raw) < 2 {
+				return d, "a dictionary key without a value"
+			}
+			v, rest, reason := parseItem(raw[1:])
+			if reason != "" {
+				return d, reason
+			}
+			dict[raw[0]], raw = v, rest
+		}
+		d.Value = dict
+		return d, ""
+	case "-array-add", "-dict-add":
+		return d, "adds to a value instead of setting it"
+	}
+	if len(raw) != 1 {
+		return d, "more than one value"
+	}
+	v, reason := parseScalar(typ, raw[0])
+	d.Value = v
+	return d, reason
+}
+
+// parseItem reads one element of an -array or -dict value: a string, or a
+// type flag and a value. It returns the arguments after it.
+func parseItem(args []string) (any, []string, string) {
+	if !strings.HasPrefix(args[0], "-") {
+		return args[0], args[1:], ""
+	}
+	if len(args) < 2 {
+		return nil, nil, "a type without a value"
+	}
+	v, reason := parseScalar(args[0], args[1])
+	return v, args[2:], reason
+}
+
+// parseScalar converts a value written with the defaults type flag typ.
+func parseScalar(typ, raw string) (any, string) {
+	switch typ {
+	case "-string":
+		return raw, ""
+	case "-bool", "-boolean":
+		switch strings.ToLower(raw) {
+		case "true", "yes", "1":
+			return true, ""
+		case "false", "no", "0":
+			return false, ""
+		}
+		return nil, "not a boolean"
+	case "-int", "-integer":
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return nil, "not an integer"
+		}
+		return n, ""
+	case "-float":
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return nil, "not a number"
+		}
+		return f, ""
+	}
+	return nil, fmt.Sprintf("values of type %s need a [[defaults]] entry written by hand", typ)
+}
+
+// parseClone converts a git clone.
+func parseClone(args []string) (Repo, bool) {
+	if len(args) == 0 || args[0] != "clone" {
+		return Repo{}, false
+	}
+	var repo Repo
+	var pos []string
+	for i := 1; i < len(args); i++ {
+		switch a := args[i]; {
+		case (a == "-b" || a == "--branch") && i+1 < len(args):
+			repo.Branch = args[i+1]
+			i++
+		case strings.HasPrefix(a, "--branch="):
+			repo.Branch = a[len("--branch="):]
+		case strings.HasPrefix(a, "-"):
+		default:
+			pos = append(pos, a)
+		}
+	}
+	if len(pos) == 0 || strings.Contains(strings.Join(pos, " "), "$") {
+		return Repo{}, false
+	}
+	repo.URL = pos[0]
+	if len(pos) > 1 {
+		repo.Path = pos[1]
+	}
+	return repo, true
+}
+
+// parseToolInstall converts cargo install, npm install -g and uv tool
+// install.
+func parseToolInstall(w []string) ([]string, catalog.Backend, bool) {
+	var backend catalog.Backend
+	var rest []string
+	switch {
+	case w[0] == "cargo" && len(w) > 1 && w[1] == "install":
+		backend, rest = catalog.BackendCargo, w[2:]
+	case w[0] == "npm" && len(w) > 1 && (w[1] == "install" || w[1] == "i") && (slices.Contains(w, "-g") || slices.Contains(w, "--global")):
+		backend, rest = catalog.BackendNPM, w[2:]
+	case w[0] == "uv" && len(w) > 2 && w[1] == "tool" && w[2] == "install":
+		backend, rest = catalog.BackendUV, w[3:]
+	default:
+		return nil, "", false
+	}
+	var pkgs []string
+	for _, a := range rest {
+		if !strings.HasPrefix(a, "-") {
+			pkgs = append(pkgs, a)
+		}
+	}
+	return pkgs, backend, true
+}
+
+// brewfile converts a Brewfile: its brew, cask and mas lines. Taps need no
+// entry; anything else is reported.
+func (r *Result) brewfile(path string, data []byte) {
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if i := strings.Index(line, " #"); i >= 0 {
+			line = strings.TrimSpace(line[:i])
+		}
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "{{") && strings.HasSuffix(line, "}}") {
+			continue
+		}
+		source := fmt.Sprintf("%s:%d", path, n)
+		if strings.Contains(line, "{{") {
+			r.skip(source, line, templated)
+			continue
+		}
+		verb, rest, _ := strings.Cut(line, " ")
+		args := brewfileArgs(rest)
+		switch {
+		case verb == "tap" || verb == "cask_args":
+		case (verb == "brew" || verb == "cask") && len(args) > 0:
+			backend := catalog.BackendBrew
+			if verb == "cask" {
+				backend = catalog.BackendCask
+			}
+			r.addPackage(source, backend, args[0])
+		case verb == "mas" && len(args) > 0:
+			id, err := strconv.ParseInt(brewfileOption(rest, "id"), 10, 64)
+			if err != nil {
+				r.skip(source, line, "no numeric id")
+				continue
+			}
+			r.addApp(args[0], id)
+		default:
+			r.skip(source, line, "no template equivalent")
+		}
+	}
+}
+
+// brewfileArgs returns the quoted strings of a Brewfile line's arguments.
+func brewfileArgs(s string) []string {
+	var out []string
+	for {
+		i := strings.IndexAny(s, `"'`)
+		if i < 0 {
+			return out
+		}
+		j := strings.IndexByte(s[i+1:], s[i])
+		if j < 0 {
+			return out
+		}
+		out = append(out, s[i+1:i+1+j])
+		s = s[i+2+j:]
+	}
+}
+
+// brewfileOption returns the value of a key: value option.
+func brewfileOption(s, key string) string {
+	i := strings.Index(s, key+":")
+	if i < 0 {
+		return ""
+	}
+	v := strings.TrimSpace(s[i+len(key)+1:])
+	if j := strings.IndexAny(v, ", "); j >= 0 {
+		v = v[:j]
+	}
+	return strings.Trim(v, `"'`)
+}
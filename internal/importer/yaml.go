@@ -0,0 +1,347 @@
+package importer
+
+import (
+	"fmt"
+	"strings"
+)
+
+// parseYAML reads the YAML that Ansible playbooks are written in, well
+// enough to import them: block mappings and sequences, flow collections on
+// one line, quoted and plain scalars and block scalars. Scalars stay
+// strings; anchors, tags and multi-document files are not supported.
+// Mappings are map[string]any and sequences []any.
+func parseYAML(data []byte) (any, error) {
+	var lines []yamlLine
+	raw := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	for i, l := range raw {
+		text := strings.TrimRight(l, " \t")
+		trimmed := strings.TrimLeft(text, " ")
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") || trimmed == "---" || trimmed == "..." {
+			continue
+		}
+		if strings.HasPrefix(trimmed, "\t") {
+			return nil, fmt.Errorf("line %d: tabs are not allowed for indentation", i+1)
+		}
+		lines = append(lines, yamlLine{n: i + 1, indent: len(text) - len(trimmed), text: trimmed, raw: l})
+	}
+	if len(lines) == 0 {
+		return nil, nil
+	}
+	p := &yamlParser{lines: lines}
+	v, err := p.node(lines[0].indent)
+	if err != nil {
+		return nil, err
+	}
+	if p.i < len(lines) {
+		return nil, fmt.Errorf("line %d: unexpected indentation", lines[p.i].n)
+	}
+	return v, nil
+}
+
+type yamlLine struct {
+	n      int
+	indent int
+	text   string
+	raw    string
+}
+
+type yamlParser struct {
+	lines []yamlLine
+	i     int
+}
+
+// node parses the block that starts at the current line, indented indent.
+func (p *yamlParser) node(indent int) (any, error) {
+	l := p.lines[p.i]
+	if l.text == "-" || strings.HasPrefix(l.text, "- ") {
+		return p.sequence(indent)
+	}
+	if _, _, ok := splitKey(l.text); ok {
+		return p.mapping(indent)
+	}
+	p.i++
+	return scalar(l.text)
+}
+
+func (p *yamlParser) sequence(indent int) ([]any, error) {
+	var out []any
+	for p.i < len(p.lines) {
+		l := &p.lines[p.i]
+		if l.indent != indent || !(l.text == "-" || strings.HasPrefix(l.text, "- ")) {
+			break
+		}
+		item := strings.TrimSpace(strings.TrimPrefix(l.text, "-"))
+		if item == "" {
+			p.i++
+			if p.i < len(p.lines) && p.lines[p.i].indent > indent {
+				v, err := p.node(p.lines[p.i].indent)
+				if err != nil {
+					return nil, err
+				}
+				out = append(out, v)
+			} else {
+				out = append(out, nil)
+			}
+			continue
+		}
+		// "- key: value" opens a mapping indented as far as its key.
+		if _, _, ok := splitKey(item); ok || strings.HasPrefix(item, "- ") {
+			l.indent += len(l.text) - len(item)
+			l.text = item
+			v, err := p.node(l.indent)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, v)
+			continue
+		}
+		p.i++
+		v, err := scalar(item)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", l.n, err)
+		}
+		out = append(out, v)
+	}
+	return out, nil
+}
+
+func (p *yamlParser) mapping(indent int) (map[string]any, error) {
+	out := map[string]any{}
+	for p.i < len(p.lines) {
+		l := p.lines[p.i]
+		if l.indent != indent {
+			if l.indent > indent {
+				return nil, fmt.Errorf("line %d: unexpected indentation", l.n)
+			}
+			break
+		}
+		key, value, ok := splitKey(l.text)
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected a key", l.n)
+		}
+		p.i++
+		switch {
+		case value == "|" || value == ">" || strings.HasPrefix(value, "|") || strings.HasPrefix(value, ">"):
+			out[key] = p.block(indent, value[0] == '>')
+		case value != "":
+			v, err := scalar(value)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", l.n, err)
+			}
+			out[key] = v
+		case p.i < len(p.lines) && p.lines[p.i].indent > indent:
+			v, err := p.node(p.lines[p.i].indent)
+			if err != nil {
+				return nil, err
+			}
+			out[key] = v
+		case p.i < len(p.lines) && p.lines[p.i].indent == indent && strings.HasPrefix(p.lines[p.i].text, "- "):
+			// A sequence may sit at its key's indentation.
+			v, err := p.sequence(indent)
+			if err != nil {
+				return nil, err
+			}
+			out[key] = v
+		default:
+			out[key] = nil
+		}
+	}
+	return out, nil
+}
+
+// block reads the lines of a block scalar indented past indent; folded
+// scalars join them with spaces.
+func (p *yamlParser) block(indent int, folded bool) string {
+	var lines []string
+	first := -1
+	for p.i < len(p.lines) && p.lines[p.i].indent > indent {
+		l := p.lines[p.i]
+		if first < 0 {
+			first = l.indent
+		}
+		lines = append(lines, strings.TrimRight(l.raw[min(first, l.indent):], " \t"))
+		p.i++
+	}
+	if folded {
+		return strings.Join(lines, " ")
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// splitKey splits "key: value" outside quotes and flow collections.
+func splitKey(s string) (key, value string, ok bool) {
+	if s == "" || strings.ContainsRune(`"'[{`, rune(s[0])) && !strings.Contains(s, `": `) && !strings.Contains(s, `':`) {
+		return "", "", false
+	}
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			if i == 0 {
+				quote = c
+			}
+		case c == ':' && (i+1 == len(s) || s[i+1] == ' '):
+			key = strings.Trim(strings.TrimSpace(s[:i]), `"'`)
+			return key, stripComment(strings.TrimSpace(s[i+1:])), true
+		}
+	}
+	return "", "", false
+}
+
+// stripComment removes a trailing comment outside quotes.
+func stripComment(s string) string {
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case (c == '"' || c == '\'') && (i == 0 || s[i-1] == ' ' || s[i-1] == '[' || s[i-1] == '{' || s[i-1] == ','):
+			quote = c
+		case c == '#' && i > 0 && s[i-1] == ' ':
+			return strings.TrimSpace(s[:i])
+		}
+	}
+	return s
+}
+
+// scalar parses a value on one line: a quoted or plain string or a flow
+// collection.
+func scalar(s string) (any, error) {
+	s = stripComment(s)
+	f := &flow{s: s}
+	v, err := f.value()
+	if err != nil {
+		return nil, err
+	}
+	if f.skip(); f.i < len(f.s) {
+		// Plain scalars may contain anything after the first token.
+		if s[0] != '[' && s[0] != '{' && s[0] != '"' && s[0] != '\'' {
+			return s, nil
+		}
+		return nil, fmt.Errorf("unexpected %q", f.s[f.i:])
+	}
+	return v, nil
+}
+
+// flow parses flow collections and scalars.
+type flow struct {
+	s string
+	i int
+}
+
+func (f *flow) skip() {
+	for f.i < len(f.s) && f.s[f.i] == ' ' {
+		f.i++
+	}
+}
+
+func (f *flow) value() (any, error) {
+	f.skip()
+	if f.i >= len(f.s) {
+		return "", nil
+	}
+	switch f.s[f.i] {
+	case '[':
+		f.i++
+		var out []any
+		for {
+			f.skip()
+			if f.i < len(f.s) && f.s[f.i] == ']' {
+				f.i++
+				return out, nil
+			}
+			v, err := f.value()
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, v)
+			if err := f.next(']'); err != nil {
+				return nil, err
+			}
+			if f.s[f.i-1] == ']' {
+				return out, nil
+			}
+		}
+	case '{':
+		f.i++
+		out := map[string]any{}
+		for {
+			f.skip()
+			if f.i < len(f.s) && f.s[f.i] == '}' {
+				f.i++
+				return out, nil
+			}
+			k, err := f.value()
+			if err != nil {
+				return nil, err
+			}
+			f.skip()
+			if f.i >= len(f.s) || f.s[f.i] != ':' {
+				return nil, fmt.Errorf("expected : after %v", k)
+			}
+			f.i++
+			v, err := f.value()
+			if err != nil {
+				return nil, err
+			}
+			out[fmt.Sprint(k)] = v
+			if err := f.next('}'); err != nil {
+				return nil, err
+			}
+			if f.s[f.i-1] == '}' {
+				return out, nil
+			}
+		}
+	case '"', '\'':
+		q := f.s[f.i]
+		var b strings.Builder
+		for f.i++; f.i < len(f.s); f.i++ {
+			c := f.s[f.i]
+			switch {
+			case c == q && q == '\'' && f.i+1 < len(f.s) && f.s[f.i+1] == '\'':
+				b.WriteByte('\'')
+				f.i++
+			case c == q:
+				f.i++
+				return b.String(), nil
+			case c == '\\' && q == '"' && f.i+1 < len(f.s):
+				f.i++
+				switch f.s[f.i] {
+				case 'n':
+					b.WriteByte('\n')
+				case 't':
+					b.WriteByte('\t')
+				default:
+					b.WriteByte(f.s[f.i])
+				}
+			default:
+				b.WriteByte(c)
+			}
+		}
+		return nil, fmt.Errorf("unterminated string")
+	}
+	start := f.i
+	for f.i < len(f.s) && !strings.ContainsRune(",]}", rune(f.s[f.i])) &&
+		!(f.s[f.i] == ':' && (f.i+1 == len(f.s) || f.s[f.i+1] == ' ')) {
+		f.i++
+	}
+	return strings.TrimSpace(f.s[start:f.i]), nil
+}
+
+// next consumes the comma between items or the closing bracket.
+func (f *flow) next(end byte) error {
+	f.skip()
+	if f.i < len(f.s) && (f.s[f.i] == ',' || f.s[f.i] == end) {
+		f.i++
+		return nil
+	}
+	return fmt.Errorf("expected , or %c", end)
+}
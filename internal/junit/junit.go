@@ -0,0 +1,103 @@
+// Package junit writes test results in the JUnit XML format CI systems
+// read to show per-test results on a pull request.
+package junit
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Suite is a group of cases, such as the changes of an apply or the E2E
+// assertions.
+type Suite struct {
+	Name  string
+	Cases []Case
+}
+
+// Case is one result. A case with Failure set failed; one with Skipped set
+// did not run.
+type Case struct {
+	Name      string
+	ClassName string
+	Duration  time.Duration
+	Failure   string
+	Skipped   string
+	// Output is attached as the case's system-out.
+	Output string
+}
+
+type xmlSuites struct {
+	XMLName  xml.Name   `xml:"testsuites"`
+	Tests    int        `xml:"tests,attr"`
+	Failures int        `xml:"failures,attr"`
+	Skipped  int        `xml:"skipped,attr"`
+	Time     string     `xml:"time,attr"`
+	Suites   []xmlSuite `xml:"testsuite"`
+}
+
+type xmlSuite struct {
+	Name     string    `xml:"name,attr"`
+	Tests    int       `xml:"tests,attr"`
+	Failures int       `xml:"failures,attr"`
+	Skipped  int       `xml:"skipped,attr"`
+	Time     string    `xml:"time,attr"`
+	Cases    []xmlCase `xml:"testcase"`
+}
+
+type xmlCase struct {
+	Name      string      `xml:"name,attr"`
+	ClassName string      `xml:"classname,attr"`
+	Time      string      `xml:"time,attr"`
+	Failure   *xmlMessage `xml:"failure,omitempty"`
+	Skipped   *xmlMessage `xml:"skipped,omitempty"`
+	SystemOut string      `xml:"system-out,omitempty"`
+}
+
+type xmlMessage struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// Write writes suites to path.
+func Write(path string, suites []Suite) error {
+	var out xmlSuites
+	var total time.Duration
+	for _, s := range suites {
+		xs := xmlSuite{Name: s.Name, Tests: len(s.Cases), Cases: []xmlCase{}}
+		var d time.Duration
+		for _, c := range s.Cases {
+			xc := xmlCase{Name: c.Name, ClassName: c.ClassName, Time: seconds(c.Duration), SystemOut: c.Output}
+			if xc.ClassName == "" {
+				xc.ClassName = s.Name
+			}
+			switch {
+			case c.Failure != "":
+				xs.Failures++
+				xc.Failure = &xmlMessage{Message: c.Failure, Text: c.Failure}
+			case c.Skipped != "":
+				xs.Skipped++
+				xc.Skipped = &xmlMessage{Message: c.Skipped}
+			}
+			d += c.Duration
+			xs.Cases = append(xs.Cases, xc)
+		}
+		xs.Time = seconds(d)
+		total += d
+		out.Tests += xs.Tests
+		out.Failures += xs.Failures
+		out.Skipped += xs.Skipped
+		out.Suites = append(out.Suites, xs)
+	}
+	out.Time = seconds(total)
+	data, err := xml.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append([]byte(xml.Header), append(data, '\n')...), 0o644)
+}
+
+func seconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
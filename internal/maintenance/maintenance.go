@@ -0,0 +1,215 @@
+// Package maintenance reclaims the disk space that provisioning leaves
+// behind: it runs `brew cleanup` and prunes MazIQ's own diagnostics
+// bundles, caches, job logs, metrics and set-aside state files once they
+// are older than the retention window. The audit log and install history
+// are records, not leftovers, and are never pruned.
+package maintenance
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hmziqrs/maziq/internal/config"
+	"github.com/hmziqrs/maziq/internal/du"
+	"github.com/hmziqrs/maziq/internal/metrics"
+	"github.com/hmziqrs/maziq/internal/modules/jobs"
+	"github.com/hmziqrs/maziq/internal/quarantine"
+	"github.com/hmziqrs/maziq/internal/shell"
+	"github.com/hmziqrs/maziq/internal/state"
+)
+
+// Options configures a run.
+type Options struct {
+	// KeepDays is the retention window; anything older goes.
+	KeepDays int
+	// Brew runs `brew cleanup` as well.
+	Brew bool
+	// DryRun reports what would be reclaimed and removes nothing.
+	DryRun bool
+}
+
+// Result is what one task reclaimed.
+type Result struct {
+	Task string
+	// Removed counts files, or runs for the metrics log.
+	Removed int
+	Freed   int64
+	// Skipped says why the task did not run.
+	Skipped string
+	Err     error
+}
+
+// Freed totals the bytes results reclaimed.
+func Freed(results []Result) int64 {
+	var n int64
+	for _, r := range results {
+		n += r.Freed
+	}
+	return n
+}
+
+// Run performs every task and returns their results in order. A failed
+// task does not stop the others.
+func Run(ctx context.Context, runner shell.Runner, opts Options) []Result {
+	cutoff := time.Now().AddDate(0, 0, -opts.KeepDays)
+	var out []Result
+	if opts.Brew {
+		out = append(out, brewCleanup(ctx, runner, opts))
+	}
+	home, _ := os.UserHomeDir()
+	logs := filepath.Join(home, strings.TrimPrefix(jobs.LogDir, "~/"))
+	return append(out,
+		bundles(cutoff, opts.DryRun),
+		prune("caches", filepath.Join(config.Dir(), "cache"), "*", cutoff, opts.DryRun),
+		prune("job logs", logs, "*.log", cutoff, opts.DryRun),
+		prune("temporary files", os.TempDir(), "maziq-*", cutoff, opts.DryRun),
+		pruneMetrics(cutoff, opts.DryRun),
+		stateFiles(cutoff, opts.DryRun),
+	)
+}
+
+var (
+	brewFreed   = regexp.MustCompile(`(?:freed|free) approximately ([\d.]+)([KMGT]?B)`)
+	brewRemoved = regexp.MustCompile(`(?m)^(?:Removing|Would remove): `)
+)
+
+// brewCleanup removes outdated downloads, old versions and stale lock
+// files, and reads the space freed from Homebrew's summary.
+func brewCleanup(ctx context.Context, runner shell.Runner, opts Options) Result {
+	res := Result{Task: "Homebrew cleanup"}
+	if _, err := runner.Run(ctx, shell.Script("command -v brew")); err != nil {
+		if errors.As(err, new(*shell.ExitError)) {
+			res.Skipped = "Homebrew is not installed"
+		} else {
+			res.Err = err
+		}
+		return res
+	}
+	args := []string{"cleanup", "--prune=" + strconv.Itoa(opts.KeepDays)}
+	if opts.DryRun {
+		args = append(args, "--dry-run")
+	}
+	out, err := runner.Run(ctx, shell.Cmd("brew", args...))
+	if err != nil {
+		res.Err = err
+		return res
+	}
+	text := out.Stdout + out.Stderr
+	res.Removed = len(brewRemoved.FindAllString(text, -1))
+	if m := brewFreed.FindStringSubmatch(text); m != nil {
+		n, _ := strconv.ParseFloat(m[1], 64)
+		res.Freed = int64(n * brewUnits[m[2]])
+	}
+	return res
+}
+
+// brewUnits are the units Homebrew prints sizes in, powers of 1024.
+var brewUnits = map[string]float64{"B": 1, "KB": 1 << 10, "MB": 1 << 20, "GB": 1 << 30, "TB": 1 << 40}
+
+// bundles prunes quarantine diagnostics bundles, keeping those of
+// resources still in quarantine whatever their age.
+func bundles(cutoff time.Time, dry bool) Result {
+	records, err := quarantine.Load()
+	if err != nil {
+		return Result{Task: "diagnostics bundles", Err: err}
+	}
+	keep := map[string]bool{}
+	for _, r := range records {
+		if r.Quarantined() {
+			keep[r.Bundle] = true
+		}
+	}
+	return pruneFunc("diagnostics bundles", quarantine.Dir(), "*.zip", cutoff, dry, func(path string) bool { return keep[path] })
+}
+
+// stateFiles compacts the state store and prunes the damaged files
+// `maziq state repair` set aside and the files imported into the store.
+func stateFiles(cutoff time.Time, dry bool) Result {
+	res := Result{Task: "state"}
+	for _, p := range []struct{ dir, pattern string }{
+		{state.Dir(), "*.corrupt-*"},
+		{config.Dir(), "*.migrated"},
+	} {
+		r := prune(res.Task, p.dir, p.pattern, cutoff, dry)
+		if r.Err != nil {
+			return r
+		}
+		res.Removed += r.Removed
+		res.Freed += r.Freed
+	}
+	if dry {
+		return res
+	}
+	s, err := state.Check()
+	if err != nil || s.Entries == 0 {
+		res.Err = err
+		return res
+	}
+	before := du.Size(state.Dir())
+	if err := state.Compact(); err != nil {
+		res.Err = err
+		return res
+	}
+	res.Freed += max(before-du.Size(state.Dir()), 0)
+	return res
+}
+
+// pruneMetrics drops recorded runs older than the cutoff.
+func pruneMetrics(cutoff time.Time, dry bool) Result {
+	res := Result{Task: "metrics"}
+	runs, err := metrics.Load()
+	if err != nil {
+		res.Err = err
+		return res
+	}
+	for _, r := range runs {
+		if time.Unix(r.Timestamp, 0).Before(cutoff) {
+			line, _ := json.Marshal(r)
+			res.Removed++
+			res.Freed += int64(len(line)) + 1
+		}
+	}
+	if res.Removed > 0 && !dry {
+		_, res.Err = metrics.Prune(cutoff)
+	}
+	return res
+}
+
+// prune removes the entries of dir matching pattern that were last
+// modified before the cutoff. A missing dir has nothing to prune.
+func prune(task, dir, pattern string, cutoff time.Time, dry bool) Result {
+	return pruneFunc(task, dir, pattern, cutoff, dry, func(string) bool { return false })
+}
+
+func pruneFunc(task, dir, pattern string, cutoff time.Time, dry bool, keep func(path string) bool) Result {
+	res := Result{Task: task}
+	matches, err := filepath.Glob(filepath.Join(dir, pattern))
+	if err != nil {
+		res.Err = err
+		return res
+	}
+	for _, path := range matches {
+		info, err := os.Lstat(path)
+		if err != nil || !info.ModTime().Before(cutoff) || keep(path) {
+			continue
+		}
+		n := du.Size(path)
+		if !dry {
+			if err := os.RemoveAll(path); err != nil {
+				res.Err = fmt.Errorf("%s: %w", path, err)
+				return res
+			}
+		}
+		res.Removed++
+		res.Freed += n
+	}
+	return res
+}
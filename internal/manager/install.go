@@ -0,0 +1,135 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/hmziqrs/maziq/internal/catalog"
+	"github.com/hmziqrs/maziq/internal/facts"
+	"github.com/hmziqrs/maziq/internal/fetch"
+	"github.com/hmziqrs/maziq/internal/history"
+	"github.com/hmziqrs/maziq/internal/shell"
+)
+
+// ErrManual is returned for sources that need a human to install them.
+var ErrManual = errors.New("manual installation required")
+
+// InstallCommand returns the command that installs sw from src.
+func InstallCommand(src catalog.Source) (shell.Command, error) {
+	switch src.Backend {
+	case catalog.BackendBrew:
+		return shell.Cmd("brew", "install", src.Package), nil
+	case catalog.BackendCask:
+		return shell.Cmd("brew", "install", "--cask", src.Package), nil
+	case catalog.BackendCargo:
+		return shell.Cmd("cargo", "install", "--locked", src.Package), nil
+	case catalog.BackendNPM:
+		return shell.Cmd("bun", "add", "--global", src.Package), nil
+	case catalog.BackendRustup:
+		return shell.Cmd("rustup", "toolchain", "install", src.Package), nil
+	case catalog.BackendUV:
+		return shell.Cmd("uv", "tool", "install", src.Package), nil
+	case catalog.BackendScript, catalog.BackendXcode:
+		return shell.Script(src.Script), nil
+	case catalog.BackendManual:
+		return shell.Command{}, fmt.Errorf("%w: %s", ErrManual, src.Script)
+	}
+	return shell.Command{}, fmt.Errorf("unsupported backend %q", src.Backend)
+}
+
+// UninstallCommand returns the command that removes what InstallCommand
+// installed from src. Scripts and manual steps cannot be taken back.
+func UninstallCommand(src catalog.Source) (shell.Command, error) {
+	switch src.Backend {
+	case catalog.BackendBrew:
+		return shell.Cmd("brew", "uninstall", src.Package), nil
+	case catalog.BackendCask:
+		return shell.Cmd("brew", "uninstall", "--cask", src.Package), nil
+	case catalog.BackendCargo:
+		return shell.Cmd("cargo", "uninstall", src.Package), nil
+	case catalog.BackendNPM:
+		return shell.Cmd("bun", "remove", "--global", src.Package), nil
+	case catalog.BackendRustup:
+		return shell.Cmd("rustup", "toolchain", "uninstall", src.Package), nil
+	case catalog.BackendUV:
+		return shell.Cmd("uv", "tool", "uninstall", src.Package), nil
+	}
+	return shell.Command{}, fmt.Errorf("%s installs cannot be undone", src.Backend)
+}
+
+// UpgradeCommand returns the command that brings what InstallCommand
+// installed from src up to the latest release.
+func UpgradeCommand(src catalog.Source) (shell.Command, error) {
+	switch src.Backend {
+	case catalog.BackendBrew:
+		return native(src, limited(src, shell.Cmd("brew", "upgrade", src.Package))), nil
+	case catalog.BackendCask:
+		return native(src, limited(src, shell.Cmd("brew", "upgrade", "--cask", src.Package))), nil
+	case catalog.BackendCargo:
+		return shell.Cmd("cargo", "install", "--locked", src.Package), nil
+	case catalog.BackendNPM:
+		return shell.Cmd("bun", "add", "--global", src.Package+"@latest"), nil
+	case catalog.BackendRustup:
+		return shell.Cmd("rustup", "update", src.Package), nil
+	case catalog.BackendUV:
+		return shell.Cmd("uv", "tool", "upgrade", src.Package), nil
+	}
+	return shell.Command{}, fmt.Errorf("%s installs cannot be upgraded by maziq", src.Backend)
+}
+
+// limited passes the configured bandwidth limit to Homebrew's downloads.
+// The other backends have no way to take one.
+func limited(src catalog.Source, c shell.Command) shell.Command {
+	if src.Backend == catalog.BackendBrew || src.Backend == catalog.BackendCask {
+		c.Env = append(c.Env, fetch.BrewEnv()...)
+	}
+	return c
+}
+
+// native starts Homebrew as arm64 when maziq runs under Rosetta, so it
+// picks arm64 bottles and cask variants, and the arm64 Homebrew in
+// /opt/homebrew does not refuse to run.
+func native(src catalog.Source, c shell.Command) shell.Command {
+	if (src.Backend == catalog.BackendBrew || src.Backend == catalog.BackendCask) && facts.Translated() {
+		c.Args = append([]string{"-arm64", c.Name}, c.Args...)
+		c.Name = "arch"
+	}
+	return c
+}
+
+// Install tries each source of sw in order until one succeeds, recording the
+// source used in the install history. It returns the successful source.
+func Install(ctx context.Context, r shell.Runner, sw catalog.Software) (catalog.Source, error) {
+	var errs []error
+	for _, src := range sw.Sources {
+		cmd, err := InstallCommand(src)
+		if err == nil {
+			cmd = native(src, limited(src, cmd))
+			_, err = r.Run(ctx, cmd)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", src.Backend, err))
+			if ctx.Err() != nil {
+				break
+			}
+			continue
+		}
+		// Installers such as `bash -c "$(curl …)"` can exit zero without
+		// installing anything, so trust the probe over the exit status.
+		res := Detect(ctx, r, sw)
+		if res.Status == StatusNotInstalled {
+			errs = append(errs, fmt.Errorf("%s: installer succeeded but %s was not detected", src.Backend, sw.Name))
+			continue
+		}
+		rec := history.Record{Software: sw.ID, Action: "install", Source: string(src.Backend), Version: res.Version}
+		if err := history.Append(rec); err != nil {
+			return src, fmt.Errorf("installed, but history: %w", err)
+		}
+		return src, nil
+	}
+	if len(errs) == 0 {
+		return catalog.Source{}, fmt.Errorf("%s has no install sources", sw.ID)
+	}
+	return catalog.Source{}, errors.Join(errs...)
+}
@@ -0,0 +1,90 @@
+package manager
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/hmziqrs/maziq/internal/catalog"
+	"github.com/hmziqrs/maziq/internal/fetch"
+	"github.com/hmziqrs/maziq/internal/shell"
+)
+
+// Outdated lists catalog IDs whose Homebrew formula or cask has a newer
+// version available, keyed by ID with the available version as value. Entries
+// installed through other backends are not reported, nor is anything
+// without Homebrew.
+func Outdated(ctx context.Context, r shell.Runner, ids []string) (map[string]string, error) {
+	if brewPrefix(ctx, r) == "" {
+		return nil, nil
+	}
+	res, err := r.Run(ctx, shell.Cmd("brew", "outdated", "--json=v2"))
+	if err != nil {
+		return nil, err
+	}
+	out := []byte(res.Stdout)
+	var report struct {
+		Formulae []struct {
+			Name              string   `json:"name"`
+			CurrentVersion    string   `json:"current_version"`
+			InstalledVersions []string `json:"installed_versions"`
+		} `json:"formulae"`
+		Casks []struct {
+			Name           string `json:"name"`
+			CurrentVersion string `json:"current_version"`
+		} `json:"casks"`
+	}
+	if err := json.Unmarshal(out, &report); err != nil {
+		return nil, err
+	}
+
+	available := map[string]string{}
+	for _, f := range report.Formulae {
+		available[string(catalog.BackendBrew)+":"+f.Name] = f.CurrentVersion
+	}
+	for _, c := range report.Casks {
+		available[string(catalog.BackendCask)+":"+c.Name] = c.CurrentVersion
+	}
+
+	result := map[string]string{}
+	for _, id := range ids {
+		sw, ok := catalog.Lookup(id)
+		if !ok {
+			continue
+		}
+		for _, src := range sw.Sources {
+			if v, ok := available[string(src.Backend)+":"+shortName(src.Package)]; ok {
+				result[id] = v
+				break
+			}
+		}
+	}
+	return result, nil
+}
+
+// shortName strips a tap prefix such as "oven-sh/bun/" from a formula name.
+func shortName(pkg string) string {
+	return pkg[strings.LastIndexByte(pkg, '/')+1:]
+}
+
+// CaskSize returns the size of a cask's download, asking brew for its URL
+// and the server for the length.
+func CaskSize(ctx context.Context, r shell.Runner, cask string) (int64, error) {
+	res, err := r.Run(ctx, shell.Cmd("brew", "info", "--cask", "--json=v2", cask))
+	if err != nil {
+		return 0, err
+	}
+	var info struct {
+		Casks []struct {
+			URL string `json:"url"`
+		} `json:"casks"`
+	}
+	if err := json.Unmarshal([]byte(res.Stdout), &info); err != nil {
+		return 0, fmt.Errorf("brew info %s: %w", cask, err)
+	}
+	if len(info.Casks) == 0 || info.Casks[0].URL == "" {
+		return 0, fmt.Errorf("brew info %s: no download URL", cask)
+	}
+	return fetch.Size(ctx, info.Casks[0].URL)
+}
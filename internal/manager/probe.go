@@ -0,0 +1,148 @@
+// Package manager detects, installs and updates catalog software.
+package manager
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/hmziqrs/maziq/internal/catalog"
+	"github.com/hmziqrs/maziq/internal/shell"
+)
+
+// Status is the installation state of a catalog entry.
+type Status string
+
+const (
+	StatusInstalled    Status = "installed"
+	StatusNotInstalled Status = "not installed"
+	StatusUnknown      Status = "unknown"
+)
+
+// Result is the outcome of probing a single entry.
+type Result struct {
+	ID      string `json:"id"`
+	Status  Status `json:"status"`
+	Version string `json:"version,omitempty"`
+	// Path is the app bundle for GUI software.
+	Path string `json:"path,omitempty"`
+}
+
+const probeTimeout = 10 * time.Second
+
+// appDirs are searched for GUI bundles before falling back to Spotlight.
+var appDirs = []string{"/Applications", filepath.Join(os.Getenv("HOME"), "Applications")}
+
+// Detect probes whether sw is installed, following the standard rules:
+// mdls for .app bundles, `<tool> --version` for CLIs. The probes run
+// through r, so a shell.Mock answers them; app bundles in the usual
+// folders are still looked for on disk first.
+func Detect(ctx context.Context, r shell.Runner, sw catalog.Software) Result {
+	res := Result{ID: sw.ID, Status: StatusUnknown}
+	switch {
+	case sw.App != "":
+		path := findApp(ctx, r, sw.App)
+		if path == "" {
+			res.Status = StatusNotInstalled
+			return res
+		}
+		res.Status, res.Path = StatusInstalled, path
+		res.Version = output(ctx, r, "mdls", "-name", "kMDItemVersion", "-raw", path)
+		if res.Version == "(null)" {
+			res.Version = ""
+		}
+	case len(sw.Version) > 0:
+		// A tool that is not on PATH fails to run, which output reports
+		// as no output.
+		out := output(ctx, r, sw.Version[0], sw.Version[1:]...)
+		if out == "" {
+			res.Status = StatusNotInstalled
+			return res
+		}
+		res.Status = StatusInstalled
+		res.Version, _, _ = strings.Cut(out, "\n")
+	}
+	return res
+}
+
+// Present is a cheap Detect for prompt hooks: it looks for the app bundle in
+// the usual folders or the CLI on PATH, without Spotlight or running the
+// tool. Entries with neither are StatusUnknown.
+func Present(sw catalog.Software) Status {
+	switch {
+	case sw.App != "":
+		for _, dir := range appDirs {
+			if _, err := os.Stat(filepath.Join(dir, sw.App)); err == nil {
+				return StatusInstalled
+			}
+		}
+		return StatusNotInstalled
+	case len(sw.Version) > 0:
+		if _, err := exec.LookPath(sw.Version[0]); err != nil {
+			return StatusNotInstalled
+		}
+		return StatusInstalled
+	}
+	return StatusUnknown
+}
+
+// Locate returns where the software res found is installed: the app
+// bundle, with a cask's staging directory in the Caskroom; a formula's kegs
+// in the Cellar; or else the executable, with symlinks resolved.
+func Locate(ctx context.Context, r shell.Runner, sw catalog.Software, res Result) []string {
+	var out []string
+	if res.Path != "" {
+		out = append(out, res.Path)
+	}
+	switch src := sw.Primary(); src.Backend {
+	case catalog.BackendBrew:
+		if prefix := brewPrefix(ctx, r); prefix != "" {
+			return append(out, filepath.Join(prefix, "Cellar", shortName(src.Package)))
+		}
+	case catalog.BackendCask:
+		if prefix := brewPrefix(ctx, r); prefix != "" {
+			out = append(out, filepath.Join(prefix, "Caskroom", shortName(src.Package)))
+		}
+	}
+	if len(out) == 0 && len(sw.Version) > 0 {
+		if path, err := exec.LookPath(sw.Version[0]); err == nil {
+			if real, err := filepath.EvalSymlinks(path); err == nil {
+				path = real
+			}
+			out = append(out, path)
+		}
+	}
+	return out
+}
+
+// brewPrefix returns Homebrew's prefix, or "" without Homebrew.
+func brewPrefix(ctx context.Context, r shell.Runner) string {
+	return output(ctx, r, "brew", "--prefix")
+}
+
+func findApp(ctx context.Context, r shell.Runner, app string) string {
+	for _, dir := range appDirs {
+		path := filepath.Join(dir, app)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	out := output(ctx, r, "mdfind", "kMDItemFSName == '"+app+"'c && kMDItemContentType == 'com.apple.application-bundle'")
+	path, _, _ := strings.Cut(out, "\n")
+	return path
+}
+
+// output runs a probe command and returns its trimmed stdout, or "" on any
+// failure. Probes never prompt, so stdin is left closed.
+func output(ctx context.Context, r shell.Runner, name string, args ...string) string {
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+	res, err := r.Run(ctx, shell.Cmd(name, args...))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(res.Stdout)
+}
@@ -0,0 +1,234 @@
+// Package metrics keeps opt-in, local-only statistics about apply runs:
+// how long they took and how often each backend failed. Records hold no
+// template names, software IDs, hostnames or errors, so exports can be pooled
+// by a team without identifying anyone's machine.
+package metrics
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"time"
+
+	"github.com/hmziqrs/maziq/internal/catalog"
+	"github.com/hmziqrs/maziq/internal/config"
+	"github.com/hmziqrs/maziq/internal/engine"
+	"github.com/hmziqrs/maziq/internal/resource"
+)
+
+// Run is one apply run.
+type Run struct {
+	Timestamp int64  `json:"timestamp"`
+	Version   string `json:"version"`
+	MacOS     string `json:"macos,omitempty"`
+	Arch      string `json:"arch"`
+	// DurationMS is the wall time of the whole run.
+	DurationMS int64 `json:"duration_ms"`
+	Applied    int   `json:"applied"`
+	Failed     int   `json:"failed"`
+	Skipped    int   `json:"skipped"`
+	// Backends breaks the applied and failed changes down by backend.
+	Backends map[string]Counts `json:"backends"`
+}
+
+// Counts tallies changes for one backend.
+type Counts struct {
+	Applied    int   `json:"applied"`
+	Failed     int   `json:"failed"`
+	DurationMS int64 `json:"duration_ms"`
+}
+
+// FailureRate is the share of attempted changes that failed.
+func (c Counts) FailureRate() float64 {
+	if n := c.Applied + c.Failed; n > 0 {
+		return float64(c.Failed) / float64(n)
+	}
+	return 0
+}
+
+// Enabled reports whether the user opted in.
+func Enabled() bool {
+	cfg, err := config.Load()
+	return err == nil && cfg.Metrics.Enabled
+}
+
+// Path is where runs are recorded.
+func Path() string {
+	return filepath.Join(config.Dir(), "metrics.jsonl")
+}
+
+// Backend names what installed or configured r: the catalog backend for
+// software, the resource kind for everything else.
+func Backend(r resource.Resource) string {
+	if s, ok := r.(interface{ Catalog() catalog.Software }); ok {
+		if src := s.Catalog().Sources; len(src) > 0 {
+			return string(src[0].Backend)
+		}
+	}
+	return resource.Kind(r.ID())
+}
+
+// FromReport summarises an apply run. Only attempted changes are counted
+// per backend; converged and skipped items did not exercise it.
+func FromReport(plan *engine.Plan, report engine.Report, version, macos string) Run {
+	backends := map[string]string{}
+	for _, it := range plan.Items {
+		backends[it.ID()] = Backend(it.Resource)
+	}
+	run := Run{
+		Timestamp:  report.Started.Unix(),
+		Version:    version,
+		MacOS:      macos,
+		Arch:       runtime.GOARCH,
+		DurationMS: report.Finished.Sub(report.Started).Milliseconds(),
+		Applied:    report.Count(engine.OutcomeApplied),
+		Failed:     report.Count(engine.OutcomeFailed),
+		Skipped:    report.Count(engine.OutcomeSkipped),
+		Backends:   map[string]Counts{},
+	}
+	for _, o := range report.Outcomes {
+		if o.Status != engine.OutcomeApplied && o.Status != engine.OutcomeFailed {
+			continue
+		}
+		c := run.Backends[backends[o.ID]]
+		if o.Status == engine.OutcomeApplied {
+			c.Applied++
+		} else {
+			c.Failed++
+		}
+		c.DurationMS += o.Duration.Milliseconds()
+		run.Backends[backends[o.ID]] = c
+	}
+	return run
+}
+
+// Record appends run when metrics are enabled and does nothing otherwise.
+func Record(run Run) error {
+	if !Enabled() {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(Path()), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(Path(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return json.NewEncoder(f).Encode(run)
+}
+
+// Load returns every recorded run. Malformed lines are skipped.
+func Load() ([]Run, error) {
+	f, err := os.Open(Path())
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var out []Run
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var r Run
+		if json.Unmarshal(sc.Bytes(), &r) == nil {
+			out = append(out, r)
+		}
+	}
+	return out, sc.Err()
+}
+
+// Clear deletes the recorded runs.
+func Clear() error {
+	err := os.Remove(Path())
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// Prune drops the runs recorded before t and returns how many it dropped.
+func Prune(t time.Time) (int, error) {
+	runs, err := Load()
+	if err != nil || len(runs) == 0 {
+		return 0, err
+	}
+	var keep []Run
+	for _, r := range runs {
+		if !time.Unix(r.Timestamp, 0).Before(t) {
+			keep = append(keep, r)
+		}
+	}
+	dropped := len(runs) - len(keep)
+	if dropped == 0 {
+		return 0, nil
+	}
+	tmp := Path() + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return 0, err
+	}
+	enc := json.NewEncoder(f)
+	for _, r := range keep {
+		if err := enc.Encode(r); err != nil {
+			f.Close()
+			return 0, err
+		}
+	}
+	if err := f.Close(); err != nil {
+		return 0, err
+	}
+	return dropped, os.Rename(tmp, Path())
+}
+
+// Summary aggregates runs.
+type Summary struct {
+	Runs int `json:"runs"`
+	// FailedRuns had at least one failed change.
+	FailedRuns int               `json:"failed_runs"`
+	Mean       time.Duration     `json:"mean"`
+	Since      time.Time         `json:"since"`
+	Backends   map[string]Counts `json:"backends"`
+}
+
+// Summarize totals runs.
+func Summarize(runs []Run) Summary {
+	s := Summary{Runs: len(runs), Backends: map[string]Counts{}}
+	var total int64
+	for i, r := range runs {
+		if i == 0 || r.Timestamp < s.Since.Unix() {
+			s.Since = time.Unix(r.Timestamp, 0)
+		}
+		total += r.DurationMS
+		if r.Failed > 0 {
+			s.FailedRuns++
+		}
+		for name, c := range r.Backends {
+			sum := s.Backends[name]
+			sum.Applied += c.Applied
+			sum.Failed += c.Failed
+			sum.DurationMS += c.DurationMS
+			s.Backends[name] = sum
+		}
+	}
+	if len(runs) > 0 {
+		s.Mean = time.Duration(total/int64(len(runs))) * time.Millisecond
+	}
+	return s
+}
+
+// BackendNames returns the summary's backends sorted by name.
+func (s Summary) BackendNames() []string {
+	names := make([]string, 0, len(s.Backends))
+	for n := range s.Backends {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
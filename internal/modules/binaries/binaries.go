@@ -0,0 +1,276 @@
+// Package binaries installs single-binary tools from GitHub release archives
+// into ~/.local/bin, picking the asset for the machine's architecture and
+// remembering the installed release so newer ones show up as upgrades.
+package binaries
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/hmziqrs/maziq/internal/fetch"
+	"github.com/hmziqrs/maziq/internal/github"
+	"github.com/hmziqrs/maziq/internal/resource"
+	"github.com/hmziqrs/maziq/internal/sbom"
+	"github.com/hmziqrs/maziq/internal/templates"
+)
+
+// Kind is the resource kind for release binaries.
+const Kind = "bin"
+
+func init() {
+	resource.Register(build)
+}
+
+func build(env *resource.Env) ([]resource.Resource, error) {
+	bins, err := Binaries(env)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]resource.Resource, len(bins))
+	for i, b := range bins {
+		out[i] = b
+	}
+	return out, nil
+}
+
+// Binaries returns the template's binaries that apply to this machine.
+func Binaries(env *resource.Env) ([]*Binary, error) {
+	var out []*Binary
+	for _, b := range env.Template.Binaries {
+		ok, err := env.Holds(b.When)
+		if err != nil {
+			return nil, fmt.Errorf("binaries %q: when: %w", b.Name, err)
+		}
+		if !ok {
+			continue
+		}
+		if owner, name, _ := strings.Cut(b.Repo, "/"); owner == "" || name == "" || strings.Contains(name, "/") {
+			return nil, fmt.Errorf("binaries %q: repo must be owner/name", b.Name)
+		}
+		out = append(out, &Binary{spec: b})
+	}
+	return out, nil
+}
+
+// Dir is where binaries are installed.
+func Dir() string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".local", "bin")
+}
+
+// Binary is one tool installed from a release archive.
+type Binary struct {
+	spec templates.Binary
+}
+
+// ID implements resource.Resource.
+func (b *Binary) ID() string { return resource.ID(Kind, b.spec.Name) }
+
+// Name returns the template name of the binary.
+func (b *Binary) Name() string { return b.spec.Name }
+
+// Describe implements resource.Resource.
+func (b *Binary) Describe() string {
+	return fmt.Sprintf("install %s from github.com/%s releases", b.spec.Executable(), b.spec.Repo)
+}
+
+// Path returns where the binary is installed.
+func (b *Binary) Path() string {
+	return filepath.Join(Dir(), b.spec.Executable())
+}
+
+// Release returns the release the template asks for: the pinned version or
+// the latest one.
+func (b *Binary) Release(ctx context.Context) (github.Release, error) {
+	if b.spec.Version != "" {
+		return github.Tagged(ctx, b.spec.Repo, b.spec.Version)
+	}
+	return github.Latest(ctx, b.spec.Repo)
+}
+
+// Verify implements resource.Verifier: the binary only has to exist.
+func (b *Binary) Verify(ctx context.Context, env *resource.Env) (resource.State, error) {
+	state := resource.State{Desired: "installed", Current: "installed"}
+	if _, err := os.Stat(b.Path()); err != nil {
+		state.Current = "not installed"
+		return state, nil
+	}
+	state.Converged = true
+	return state, nil
+}
+
+// Check implements resource.Resource. A binary MazIQ did not install is left
+// alone; one it did is upgraded when its release is not the wanted one.
+func (b *Binary) Check(ctx context.Context, env *resource.Env) (resource.State, error) {
+	state := resource.State{Desired: "installed"}
+	if _, err := os.Stat(b.Path()); err != nil {
+		state.Current = "not installed"
+		return state, nil
+	}
+	rec, managed, err := Lookup(b.spec.Name)
+	if err != nil {
+		return state, err
+	}
+	if !managed {
+		state.Converged = true
+		state.Current = "installed outside MazIQ"
+		state.Desired = state.Current
+		return state, nil
+	}
+	state.Current = "installed (" + rec.Tag + ")"
+	state.Version = rec.Tag
+	state.Converged = true
+	want := b.spec.Version
+	if want == "" {
+		r, err := b.Release(ctx)
+		if err != nil {
+			state.Warning = "could not check for updates: " + err.Error()
+		}
+		want = r.Tag
+	}
+	if want != "" && want != rec.Tag {
+		state.Converged = false
+		state.Desired = want
+	}
+	if state.Converged {
+		state.Desired = state.Current
+	}
+	return state, nil
+}
+
+// Component implements resource.Componenter. Binaries MazIQ did not
+// install are listed without a release.
+func (b *Binary) Component(ctx context.Context, env *resource.Env) (resource.Component, bool, error) {
+	if _, err := os.Stat(b.Path()); err != nil {
+		return resource.Component{}, false, nil
+	}
+	rec, managed, err := Lookup(b.spec.Name)
+	if err != nil {
+		return resource.Component{}, false, err
+	}
+	owner, _, _ := strings.Cut(b.spec.Repo, "/")
+	c := resource.Component{ID: b.ID(), Name: b.spec.Executable(), Supplier: "github.com/" + owner, Paths: []string{b.Path()}}
+	if managed {
+		c.Version = rec.Tag
+		c.URL = fmt.Sprintf("https://github.com/%s/releases/download/%s/%s", b.spec.Repo, rec.Tag, rec.Asset)
+		c.DownloadSHA256 = b.spec.SHA256
+	}
+	c.PURL = sbom.PURL("github", b.spec.Repo, c.Version, nil)
+	c.SHA256, err = fetch.SHA256(b.Path())
+	return c, true, err
+}
+
+// download fetches and verifies the archive of the wanted release. The
+// caller removes it with cleanup.
+func (b *Binary) download(ctx context.Context, env *resource.Env) (rel github.Release, asset github.Asset, archive string, cleanup func(), err error) {
+	if rel, err = b.Release(ctx); err != nil {
+		return
+	}
+	if asset, err = rel.Select(env.Facts["arch"], b.spec.Asset); err != nil {
+		return
+	}
+	if archive, cleanup, err = fetch.TempDownload(ctx, asset.URL); err != nil {
+		return
+	}
+	in := fetch.Integrity(b.spec.Integrity)
+	in.Signature = strings.ReplaceAll(env.Expand(in.Signature), templates.VersionPlaceholder, rel.Tag)
+	if in.SHA256 == "" {
+		if in.SHA256, err = rel.Checksum(ctx, asset); err != nil {
+			cleanup()
+			err = fmt.Errorf("checksums: %w", err)
+			return
+		}
+		if in.SHA256 == "" && !in.Pinned() {
+			env.Log("%s %s publishes no checksums; installing unverified", b.spec.Repo, rel.Tag)
+		}
+	}
+	if err = fetch.Verify(ctx, env.Runner, archive, in); err != nil {
+		cleanup()
+	}
+	return
+}
+
+// Fetch implements resource.Fetcher.
+func (b *Binary) Fetch(ctx context.Context, env *resource.Env) error {
+	_, _, _, cleanup, err := b.download(ctx, env)
+	if err == nil {
+		cleanup()
+	}
+	return err
+}
+
+// Apply implements resource.Resource.
+func (b *Binary) Apply(ctx context.Context, env *resource.Env) error {
+	rel, asset, archive, cleanup, err := b.download(ctx, env)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	if err := Extract(archive, b.spec.Executable(), b.Path()); err != nil {
+		return err
+	}
+
+	prev, ok, err := Lookup(b.spec.Name)
+	if err != nil {
+		return err
+	}
+	action := "install"
+	if ok {
+		action = "upgrade"
+		env.Log("upgraded %s from %s to %s", b.spec.Executable(), prev.Tag, rel.Tag)
+	} else {
+		env.Log("installed %s %s into %s", b.spec.Executable(), rel.Tag, Dir())
+	}
+	if !onPath(Dir()) {
+		env.Log("%s is not on your PATH", Dir())
+	}
+	return record(b.spec.Name, Record{Repo: b.spec.Repo, Tag: rel.Tag, Asset: asset.Name, Path: b.Path()}, action)
+}
+
+func onPath(dir string) bool {
+	return slices.Contains(filepath.SplitList(os.Getenv("PATH")), dir)
+}
+
+// Update is a newer release of a binary MazIQ installed.
+type Update struct {
+	Binary    *Binary `json:"-"`
+	Name      string  `json:"name"`
+	Installed string  `json:"installed"`
+	Latest    string  `json:"latest"`
+}
+
+// Outdated checks the binaries MazIQ installed that follow the latest
+// release. Pinned versions are plan's business, not an update. Lookups that
+// fail are reported together after the rest have been checked.
+func Outdated(ctx context.Context, env *resource.Env) ([]Update, error) {
+	bins, err := Binaries(env)
+	if err != nil {
+		return nil, err
+	}
+	managed, err := Load()
+	if err != nil {
+		return nil, err
+	}
+	var out []Update
+	var errs []error
+	for _, b := range bins {
+		rec, ok := managed[b.spec.Name]
+		if !ok || b.spec.Version != "" {
+			continue
+		}
+		r, err := b.Release(ctx)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", b.spec.Name, err))
+			continue
+		}
+		if r.Tag != rec.Tag {
+			out = append(out, Update{Binary: b, Name: b.spec.Name, Installed: rec.Tag, Latest: r.Tag})
+		}
+	}
+	return out, errors.Join(errs...)
+}
@@ -0,0 +1,107 @@
+package binaries
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Extract copies the file named bin out of a tar.gz or zip archive to dest,
+// replacing dest atomically. Archives usually nest the binary in a versioned
+// directory, so only the base name has to match.
+func Extract(archive, bin, dest string) error {
+	var err error
+	found := false
+	write := func(r io.Reader) error {
+		found = true
+		return WriteExecutable(r, dest)
+	}
+	if strings.HasSuffix(strings.ToLower(archive), ".zip") {
+		err = fromZip(archive, bin, write)
+	} else {
+		err = fromTarGz(archive, bin, write)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", filepath.Base(archive), err)
+	}
+	if !found {
+		return fmt.Errorf("%s has no file named %q; set bin to the executable's name", filepath.Base(archive), bin)
+	}
+	return nil
+}
+
+func fromTarGz(archive, bin string, write func(io.Reader) error) error {
+	f, err := os.Open(archive)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return err
+	}
+	tr := tar.NewReader(gz)
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if h.Typeflag == tar.TypeReg && path.Base(h.Name) == bin {
+			return write(tr)
+		}
+	}
+}
+
+func fromZip(archive, bin string, write func(io.Reader) error) error {
+	zr, err := zip.OpenReader(archive)
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+	for _, f := range zr.File {
+		if f.Mode().IsRegular() && path.Base(f.Name) == bin {
+			rc, err := f.Open()
+			if err != nil {
+				return err
+			}
+			defer rc.Close()
+			return write(rc)
+		}
+	}
+	return nil
+}
+
+// WriteExecutable writes r to dest with mode 0755 through a temporary file
+// in the same directory, so a running copy of the old binary is not
+// truncated underneath itself.
+func WriteExecutable(r io.Reader, dest string) error {
+	if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(dest), "."+filepath.Base(dest)+"-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(0o755); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), dest)
+}
@@ -0,0 +1,55 @@
+package binaries
+
+import (
+	"path/filepath"
+	"time"
+
+	"github.com/hmziqrs/maziq/internal/config"
+	"github.com/hmziqrs/maziq/internal/history"
+	"github.com/hmziqrs/maziq/internal/resource"
+	"github.com/hmziqrs/maziq/internal/state"
+)
+
+// Record is the release a binary was installed from.
+type Record struct {
+	Repo      string `json:"repo"`
+	Tag       string `json:"tag"`
+	Asset     string `json:"asset"`
+	Path      string `json:"path"`
+	Installed int64  `json:"installed"`
+}
+
+// stateKey is the state store prefix for the records.
+const stateKey = "binaries/"
+
+// legacyPath is where records were kept before the state store.
+func legacyPath() string {
+	return filepath.Join(config.Dir(), "binaries.json")
+}
+
+// Load returns the records by template binary name.
+func Load() (map[string]Record, error) {
+	if err := state.Import(legacyPath(), stateKey); err != nil {
+		return nil, err
+	}
+	return state.List[Record](stateKey)
+}
+
+// Lookup returns the record for the binary named name.
+func Lookup(name string) (Record, bool, error) {
+	if err := state.Import(legacyPath(), stateKey); err != nil {
+		return Record{}, false, err
+	}
+	var rec Record
+	ok, err := state.Get(stateKey+name, &rec)
+	return rec, ok, err
+}
+
+// record stores rec under name and appends it to the install history.
+func record(name string, rec Record, action string) error {
+	rec.Installed = time.Now().Unix()
+	if err := state.Put(stateKey+name, rec); err != nil {
+		return err
+	}
+	return history.Append(history.Record{Software: resource.ID(Kind, name), Action: action, Version: rec.Tag, Source: "github.com/" + rec.Repo})
+}
@@ -0,0 +1,254 @@
+// Package browser manages browser policy files: force-installed extensions,
+// the default search engine and pass-through policies for Chrome (a managed
+// preferences plist) and Firefox (policies.json in the app bundle).
+//
+// MazIQ owns each policy file outright. Check compares the file on disk with
+// the rendered policy, so manual edits are reported as drift and reverted by
+// apply.
+package browser
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/hmziqrs/maziq/internal/modules/software"
+	"github.com/hmziqrs/maziq/internal/plist"
+	"github.com/hmziqrs/maziq/internal/resource"
+	"github.com/hmziqrs/maziq/internal/shell"
+	"github.com/hmziqrs/maziq/internal/templates"
+)
+
+// Kind is the resource kind for browser policies.
+const Kind = "browser"
+
+// Policy file locations.
+var (
+	ChromePolicy  = "/Library/Managed Preferences/com.google.Chrome.plist"
+	FirefoxPolicy = "/Applications/Firefox.app/Contents/Resources/distribution/policies.json"
+)
+
+// chromeUpdateURL is the Chrome Web Store update service used for bare IDs.
+const chromeUpdateURL = "https://clients2.google.com/service/update2/crx"
+
+func init() {
+	resource.Register(build)
+}
+
+func build(env *resource.Env) ([]resource.Resource, error) {
+	var out []resource.Resource
+	for _, b := range []struct {
+		name    string
+		spec    *templates.Browser
+		catalog string
+		path    string
+		render  func(*templates.Browser) ([]byte, error)
+	}{
+		{"chrome", env.Template.Browsers.Chrome, "chrome", ChromePolicy, renderChrome},
+		{"firefox", env.Template.Browsers.Firefox, "firefox", FirefoxPolicy, renderFirefox},
+	} {
+		if b.spec == nil {
+			continue
+		}
+		ok, err := env.Holds(b.spec.When)
+		if err != nil {
+			return nil, fmt.Errorf("browsers.%s: when: %w", b.name, err)
+		}
+		if !ok {
+			continue
+		}
+		data, err := b.render(b.spec)
+		if err != nil {
+			return nil, fmt.Errorf("browsers.%s: %w", b.name, err)
+		}
+		out = append(out, &Policy{name: b.name, catalog: b.catalog, path: b.path, data: data})
+	}
+	return out, nil
+}
+
+// Policy is a rendered policy file for one browser.
+type Policy struct {
+	name    string
+	catalog string
+	path    string
+	data    []byte
+}
+
+// ID implements resource.Resource.
+func (p *Policy) ID() string { return resource.ID(Kind, p.name) }
+
+// Describe implements resource.Resource.
+func (p *Policy) Describe() string { return "write " + p.path }
+
+// Requires implements resource.Requirer.
+func (p *Policy) Requires() []string {
+	return []string{resource.ID(software.Kind, p.catalog)}
+}
+
+// Check implements resource.Resource. macOS may rewrite a managed
+// preferences plist in binary form, so Chrome's is compared by value.
+func (p *Policy) Check(ctx context.Context, env *resource.Env) (resource.State, error) {
+	state := resource.State{Desired: "managed"}
+	current, err := os.ReadFile(p.path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		state.Current = "missing"
+	case err != nil:
+		return state, err
+	case p.isPlist():
+		have, err := plist.Decode(current)
+		if err != nil {
+			state.Current = "unreadable"
+			break
+		}
+		want, _ := plist.Decode(p.data)
+		if changes := plist.Diff(have, want); len(changes) > 0 {
+			state.Current = fmt.Sprintf("%d key(s) differ", len(changes))
+			break
+		}
+		state.Converged, state.Current = true, "managed"
+	case bytes.Equal(current, p.data):
+		state.Converged, state.Current = true, "managed"
+	default:
+		state.Current = "drifted"
+	}
+	return state, nil
+}
+
+func (p *Policy) isPlist() bool { return filepath.Ext(p.path) == ".plist" }
+
+// Diff implements resource.Differ. A plist is shown by key path.
+func (p *Policy) Diff(ctx context.Context, env *resource.Env) (resource.FileChange, error) {
+	c := resource.FileChange{Path: p.path, Desired: p.data}
+	current, err := os.ReadFile(p.path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return c, err
+	}
+	c.Current = current
+	if !p.isPlist() {
+		return c, nil
+	}
+	want, err := plist.Decode(p.data)
+	if err != nil {
+		return c, err
+	}
+	c.Desired = []byte(plist.Flatten("", want))
+	if current != nil {
+		have, err := plist.Decode(current)
+		if err != nil {
+			return c, fmt.Errorf("%s: %w", p.path, err)
+		}
+		c.Current = []byte(plist.Flatten("", have))
+	}
+	return c, nil
+}
+
+// Merge implements resource.Merger.
+func (p *Policy) Merge(content []byte) error {
+	if !p.isPlist() {
+		p.data = content
+		return nil
+	}
+	v, err := plist.Unflatten(string(content))
+	if err != nil {
+		return err
+	}
+	data, err := plist.Encode(v)
+	if err != nil {
+		return err
+	}
+	p.data = data
+	return nil
+}
+
+// Apply implements resource.Resource. Both policy locations are root-owned,
+// so the file is staged in a temp dir and installed with sudo.
+func (p *Policy) Apply(ctx context.Context, env *resource.Env) error {
+	tmp, err := os.CreateTemp("", "maziq-policy-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(p.data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if _, err := env.Run(ctx, shell.Command{Name: "mkdir", Args: []string{"-p", filepath.Dir(p.path)}, Sudo: true}); err != nil {
+		return err
+	}
+	_, err = env.Run(ctx, shell.Command{Name: "install", Args: []string{"-m", "0644", tmp.Name(), p.path}, Sudo: true})
+	return err
+}
+
+func renderChrome(b *templates.Browser) ([]byte, error) {
+	policies := map[string]any{}
+	for k, v := range b.Policies {
+		policies[k] = v
+	}
+	if len(b.Extensions) > 0 {
+		var list []any
+		for _, e := range b.Extensions {
+			if e.ID == "" {
+				return nil, errors.New("extension has no id")
+			}
+			url := e.URL
+			if url == "" {
+				url = chromeUpdateURL
+			}
+			list = append(list, e.ID+";"+url)
+		}
+		policies["ExtensionInstallForcelist"] = list
+	}
+	if s := b.Search; s != nil {
+		policies["DefaultSearchProviderEnabled"] = true
+		policies["DefaultSearchProviderName"] = s.Name
+		policies["DefaultSearchProviderSearchURL"] = s.URL
+	}
+	if b.Homepage != "" {
+		policies["HomepageLocation"] = b.Homepage
+		policies["HomepageIsNewTabPage"] = false
+	}
+	return plist.Encode(policies)
+}
+
+func renderFirefox(b *templates.Browser) ([]byte, error) {
+	policies := map[string]any{}
+	for k, v := range b.Policies {
+		policies[k] = v
+	}
+	if len(b.Extensions) > 0 {
+		settings := map[string]any{}
+		for _, e := range b.Extensions {
+			if e.ID == "" || e.URL == "" {
+				return nil, fmt.Errorf("extension %q: Firefox extensions need both id and url", e.ID)
+			}
+			settings[e.ID] = map[string]any{
+				"installation_mode": "force_installed",
+				"install_url":       e.URL,
+			}
+		}
+		policies["ExtensionSettings"] = settings
+	}
+	if s := b.Search; s != nil {
+		policies["SearchEngines"] = map[string]any{
+			"Add":     []any{map[string]any{"Name": s.Name, "URLTemplate": s.URL}},
+			"Default": s.Name,
+		}
+	}
+	if b.Homepage != "" {
+		policies["Homepage"] = map[string]any{"URL": b.Homepage, "StartPage": "homepage"}
+	}
+	data, err := json.MarshalIndent(map[string]any{"policies": policies}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
@@ -0,0 +1,135 @@
+package cloud
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/hmziqrs/maziq/internal/modules/software"
+	"github.com/hmziqrs/maziq/internal/resource"
+	"github.com/hmziqrs/maziq/internal/shell"
+	"github.com/hmziqrs/maziq/internal/templates"
+)
+
+// AWSProfile is a named profile in the AWS CLI config file.
+type AWSProfile struct {
+	spec   templates.AWSProfile
+	config *awsConfig
+}
+
+// ID implements resource.Resource.
+func (p *AWSProfile) ID() string { return resource.ID(AWSKind, p.spec.Profile) }
+
+// Describe implements resource.Resource.
+func (p *AWSProfile) Describe() string { return "configure AWS profile " + p.spec.Profile }
+
+// Requires implements resource.Requirer.
+func (p *AWSProfile) Requires() []string {
+	return []string{resource.ID(software.Kind, "aws_cli")}
+}
+
+// Check implements resource.Resource.
+func (p *AWSProfile) Check(ctx context.Context, env *resource.Env) (resource.State, error) {
+	state := resource.State{Desired: "configured"}
+	sections, err := p.config.get()
+	if err != nil {
+		return state, err
+	}
+	have, ok := sections[awsSection(p.spec.Profile)]
+	if !ok {
+		state.Current = "missing"
+		return state, nil
+	}
+	for _, kv := range p.spec.Settings() {
+		if have[kv[0]] != env.Expand(kv[1]) {
+			state.Current = kv[0] + " differs"
+			return state, nil
+		}
+	}
+	state.Current, state.Converged = "configured", true
+	return state, nil
+}
+
+// Apply implements resource.Resource.
+func (p *AWSProfile) Apply(ctx context.Context, env *resource.Env) error {
+	for _, kv := range p.spec.Settings() {
+		if _, err := env.Run(ctx, shell.Cmd("aws", "configure", "set", kv[0], env.Expand(kv[1]), "--profile", p.spec.Profile)); err != nil {
+			return err
+		}
+	}
+	switch {
+	case p.spec.SSOStartURL != "":
+		env.Log("aws: sign in with `aws sso login --profile %s`", p.spec.Profile)
+	case p.spec.RoleARN == "":
+		env.Log("aws: add credentials with `aws configure --profile %s`", p.spec.Profile)
+	}
+	return nil
+}
+
+// Assertions implements resource.Asserter.
+func (p *AWSProfile) Assertions() []resource.Assertion {
+	return []resource.Assertion{{
+		Name:   "AWS profile " + p.spec.Profile + " has valid credentials",
+		Run:    "aws sts get-caller-identity --profile " + shell.Quote(p.spec.Profile) + " --query Account --output text",
+		Expect: p.spec.SSOAccountID,
+	}}
+}
+
+// awsConfigPath honours AWS_CONFIG_FILE like the CLI does.
+func awsConfigPath(env *resource.Env) string {
+	if p := os.Getenv("AWS_CONFIG_FILE"); p != "" {
+		return p
+	}
+	return env.Path("~/.aws/config")
+}
+
+// awsSection is the config file section name for profile.
+func awsSection(profile string) string {
+	if profile == "default" {
+		return "default"
+	}
+	return "profile " + profile
+}
+
+// awsConfig reads the AWS config file once per run.
+type awsConfig struct {
+	path     string
+	once     sync.Once
+	sections map[string]map[string]string
+	err      error
+}
+
+func (c *awsConfig) get() (map[string]map[string]string, error) {
+	c.once.Do(func() {
+		c.sections = map[string]map[string]string{}
+		f, err := os.Open(c.path)
+		if os.IsNotExist(err) {
+			return
+		}
+		if err != nil {
+			c.err = err
+			return
+		}
+		defer f.Close()
+		var cur map[string]string
+		sc := bufio.NewScanner(f)
+		for sc.Scan() {
+			line := strings.TrimSpace(sc.Text())
+			switch {
+			case line == "", line[0] == '#', line[0] == ';':
+			case line[0] == '[' && strings.HasSuffix(line, "]"):
+				name := strings.TrimSpace(line[1 : len(line)-1])
+				cur = map[string]string{}
+				c.sections[name] = cur
+			case cur != nil:
+				if k, v, ok := strings.Cut(line, "="); ok {
+					cur[strings.TrimSpace(k)] = strings.TrimSpace(v)
+				}
+			}
+		}
+		c.err = sc.Err()
+	})
+	return c.sections, c.err
+}
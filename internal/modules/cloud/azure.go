@@ -0,0 +1,132 @@
+package cloud
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/hmziqrs/maziq/internal/modules/software"
+	"github.com/hmziqrs/maziq/internal/resource"
+	"github.com/hmziqrs/maziq/internal/shell"
+	"github.com/hmziqrs/maziq/internal/templates"
+)
+
+// AzureDefaults sets the Azure CLI's default location and resource group.
+type AzureDefaults struct {
+	spec templates.Azure
+}
+
+// ID implements resource.Resource.
+func (a *AzureDefaults) ID() string { return resource.ID(AzureKind, "defaults") }
+
+// Describe implements resource.Resource.
+func (a *AzureDefaults) Describe() string { return "set Azure CLI defaults" }
+
+// Requires implements resource.Requirer.
+func (a *AzureDefaults) Requires() []string {
+	return []string{resource.ID(software.Kind, "azure_cli")}
+}
+
+func (a *AzureDefaults) settings(env *resource.Env) [][2]string {
+	var out [][2]string
+	if a.spec.Location != "" {
+		out = append(out, [2]string{"location", env.Expand(a.spec.Location)})
+	}
+	if a.spec.Group != "" {
+		out = append(out, [2]string{"group", env.Expand(a.spec.Group)})
+	}
+	return out
+}
+
+// Check implements resource.Resource.
+func (a *AzureDefaults) Check(ctx context.Context, env *resource.Env) (resource.State, error) {
+	state := resource.State{Desired: "configured"}
+	have := map[string]string{}
+	res, err := env.Run(ctx, shell.Cmd("az", "config", "get", "defaults", "--only-show-errors", "-o", "json"))
+	switch {
+	case errors.As(err, new(*shell.ExitError)):
+		// No [defaults] section yet.
+	case err != nil:
+		return state, err
+	default:
+		var rows []struct {
+			Name  string `json:"name"`
+			Value string `json:"value"`
+		}
+		if err := json.Unmarshal([]byte(res.Stdout), &rows); err != nil {
+			return state, fmt.Errorf("az config get: %w", err)
+		}
+		for _, r := range rows {
+			have[r.Name] = r.Value
+		}
+	}
+	for _, kv := range a.settings(env) {
+		if have[kv[0]] != kv[1] {
+			state.Current = kv[0] + " differs"
+			return state, nil
+		}
+	}
+	state.Current, state.Converged = "configured", true
+	return state, nil
+}
+
+// Apply implements resource.Resource.
+func (a *AzureDefaults) Apply(ctx context.Context, env *resource.Env) error {
+	args := []string{"config", "set"}
+	for _, kv := range a.settings(env) {
+		args = append(args, "defaults."+kv[0]+"="+kv[1])
+	}
+	_, err := env.Run(ctx, shell.Cmd("az", args...))
+	return err
+}
+
+// AzureSubscription selects the default subscription. It needs a signed-in
+// CLI and is blocked until then.
+type AzureSubscription struct {
+	id string
+}
+
+// ID implements resource.Resource.
+func (a *AzureSubscription) ID() string { return resource.ID(AzureKind, "subscription") }
+
+// Describe implements resource.Resource.
+func (a *AzureSubscription) Describe() string { return "select Azure subscription " + a.id }
+
+// Requires implements resource.Requirer.
+func (a *AzureSubscription) Requires() []string {
+	return []string{resource.ID(software.Kind, "azure_cli")}
+}
+
+// Check implements resource.Resource.
+func (a *AzureSubscription) Check(ctx context.Context, env *resource.Env) (resource.State, error) {
+	state := resource.State{Desired: a.id}
+	res, err := env.Run(ctx, shell.Cmd("az", "account", "show", "--query", "id", "-o", "tsv"))
+	switch {
+	case errors.As(err, new(*shell.ExitError)):
+		state.Current = "not logged in"
+		state.Blocked = "not logged in; run `az login`"
+	case err != nil:
+		return state, err
+	default:
+		state.Current = strings.TrimSpace(res.Stdout)
+		state.Converged = state.Current == a.id
+	}
+	return state, nil
+}
+
+// Apply implements resource.Resource.
+func (a *AzureSubscription) Apply(ctx context.Context, env *resource.Env) error {
+	_, err := env.Run(ctx, shell.Cmd("az", "account", "set", "--subscription", a.id))
+	return err
+}
+
+// Assertions implements resource.Asserter.
+func (a *AzureSubscription) Assertions() []resource.Assertion {
+	return []resource.Assertion{{
+		Name:   "Azure CLI is signed in to subscription " + a.id,
+		Run:    "az account show --query id -o tsv",
+		Expect: a.id,
+	}}
+}
@@ -0,0 +1,68 @@
+// Package cloud configures cloud CLI accounts: AWS profiles, gcloud
+// configurations and Azure defaults. Only the non-secret settings are
+// written; signing in is left to the user, who gets the command to run.
+package cloud
+
+import (
+	"fmt"
+
+	"github.com/hmziqrs/maziq/internal/resource"
+)
+
+// Resource kinds.
+const (
+	AWSKind    = "aws"
+	GCloudKind = "gcloud"
+	AzureKind  = "azure"
+)
+
+func init() {
+	resource.Register(build)
+}
+
+func build(env *resource.Env) ([]resource.Resource, error) {
+	spec := env.Template.Cloud
+	var out []resource.Resource
+	aws := &awsConfig{path: awsConfigPath(env)}
+	for i, p := range spec.AWS {
+		ok, err := env.Holds(p.When)
+		if err != nil {
+			return nil, fmt.Errorf("cloud.aws[%d]: when: %w", i, err)
+		}
+		if !ok {
+			continue
+		}
+		if p.Profile == "" {
+			return nil, fmt.Errorf("cloud.aws[%d]: profile is required", i)
+		}
+		out = append(out, &AWSProfile{spec: p, config: aws})
+	}
+	for i, c := range spec.GCloud {
+		ok, err := env.Holds(c.When)
+		if err != nil {
+			return nil, fmt.Errorf("cloud.gcloud[%d]: when: %w", i, err)
+		}
+		if !ok {
+			continue
+		}
+		if c.Configuration == "" {
+			return nil, fmt.Errorf("cloud.gcloud[%d]: configuration is required", i)
+		}
+		out = append(out, &GCloudConfig{spec: c})
+	}
+	if az := spec.Azure; az != nil {
+		ok, err := env.Holds(az.When)
+		if err != nil {
+			return nil, fmt.Errorf("cloud.azure: when: %w", err)
+		}
+		if ok {
+			if az.Location != "" || az.Group != "" {
+				out = append(out, &AzureDefaults{spec: *az})
+			}
+			if az.Subscription != "" {
+				out = append(out, &AzureSubscription{id: az.Subscription})
+			}
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,128 @@
+package cloud
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/hmziqrs/maziq/internal/modules/software"
+	"github.com/hmziqrs/maziq/internal/resource"
+	"github.com/hmziqrs/maziq/internal/shell"
+	"github.com/hmziqrs/maziq/internal/templates"
+)
+
+// GCloudConfig is a named gcloud configuration.
+type GCloudConfig struct {
+	spec templates.GCloudConfig
+}
+
+// ID implements resource.Resource.
+func (c *GCloudConfig) ID() string { return resource.ID(GCloudKind, c.spec.Configuration) }
+
+// Describe implements resource.Resource.
+func (c *GCloudConfig) Describe() string {
+	d := "configure gcloud configuration " + c.spec.Configuration
+	if c.spec.Activate {
+		d += " and activate it"
+	}
+	return d
+}
+
+// Requires implements resource.Requirer.
+func (c *GCloudConfig) Requires() []string {
+	return []string{resource.ID(software.Kind, "gcloud")}
+}
+
+// gcloudDescription is `gcloud config configurations describe --format=json`.
+type gcloudDescription struct {
+	IsActive   bool                         `json:"is_active"`
+	Properties map[string]map[string]string `json:"properties"`
+}
+
+func (c *GCloudConfig) describe(ctx context.Context, env *resource.Env) (*gcloudDescription, error) {
+	res, err := env.Run(ctx, shell.Cmd("gcloud", "config", "configurations", "describe", c.spec.Configuration, "--format=json"))
+	if errors.As(err, new(*shell.ExitError)) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var d gcloudDescription
+	if err := json.Unmarshal([]byte(res.Stdout), &d); err != nil {
+		return nil, fmt.Errorf("gcloud config configurations describe: %w", err)
+	}
+	return &d, nil
+}
+
+// Check implements resource.Resource.
+func (c *GCloudConfig) Check(ctx context.Context, env *resource.Env) (resource.State, error) {
+	state := resource.State{Desired: "configured"}
+	d, err := c.describe(ctx, env)
+	if err != nil {
+		return state, err
+	}
+	if d == nil {
+		state.Current = "missing"
+		return state, nil
+	}
+	for _, kv := range c.spec.Properties() {
+		section, key, _ := strings.Cut(kv[0], "/")
+		if d.Properties[section][key] != env.Expand(kv[1]) {
+			state.Current = kv[0] + " differs"
+			return state, nil
+		}
+	}
+	if c.spec.Activate && !d.IsActive {
+		state.Current = "not active"
+		return state, nil
+	}
+	state.Current, state.Converged = "configured", true
+	return state, nil
+}
+
+// Apply implements resource.Resource.
+func (c *GCloudConfig) Apply(ctx context.Context, env *resource.Env) error {
+	d, err := c.describe(ctx, env)
+	if err != nil {
+		return err
+	}
+	name := c.spec.Configuration
+	if d == nil {
+		if _, err := env.Run(ctx, shell.Cmd("gcloud", "config", "configurations", "create", name, "--no-activate")); err != nil {
+			return err
+		}
+	}
+	for _, kv := range c.spec.Properties() {
+		if _, err := env.Run(ctx, shell.Cmd("gcloud", "config", "set", kv[0], env.Expand(kv[1]), "--configuration", name)); err != nil {
+			return err
+		}
+	}
+	if c.spec.Activate {
+		if _, err := env.Run(ctx, shell.Cmd("gcloud", "config", "configurations", "activate", name)); err != nil {
+			return err
+		}
+	}
+	login := "gcloud auth login"
+	if c.spec.Account != "" {
+		login += " " + env.Expand(c.spec.Account)
+	}
+	env.Log("gcloud: sign in with `%s --configuration %s`", login, name)
+	return nil
+}
+
+// Assertions implements resource.Asserter.
+func (c *GCloudConfig) Assertions() []resource.Assertion {
+	if c.spec.Project == "" {
+		return []resource.Assertion{{
+			Name: "gcloud configuration " + c.spec.Configuration + " has an active account",
+			Run:  "gcloud auth print-access-token --configuration " + shell.Quote(c.spec.Configuration) + " >/dev/null",
+		}}
+	}
+	return []resource.Assertion{{
+		Name:   "gcloud configuration " + c.spec.Configuration + " can read its project",
+		Run:    "gcloud projects describe " + shell.Quote(c.spec.Project) + " --configuration " + shell.Quote(c.spec.Configuration) + " --format='value(projectId)'",
+		Expect: c.spec.Project,
+	}}
+}
@@ -0,0 +1,110 @@
+// Package databases bootstraps local development databases once their brew
+// service is running: roles and databases for Postgres and MySQL, a secured
+// MySQL root account, and Redis configuration.
+package databases
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hmziqrs/maziq/internal/modules/services"
+	"github.com/hmziqrs/maziq/internal/resource"
+	"github.com/hmziqrs/maziq/internal/templates"
+)
+
+// Kind is the resource kind for database bootstrap steps.
+const Kind = "database"
+
+func init() {
+	resource.Register(build)
+}
+
+func build(env *resource.Env) ([]resource.Resource, error) {
+	var out []resource.Resource
+	for i, d := range env.Template.Databases {
+		ok, err := env.Holds(d.When)
+		if err != nil {
+			return nil, fmt.Errorf("databases[%d]: when: %w", i, err)
+		}
+		if !ok {
+			continue
+		}
+		var steps []*Step
+		switch d.Engine {
+		case templates.EnginePostgres:
+			steps = postgres(d)
+		case templates.EngineMySQL:
+			steps = mysql(d)
+		case templates.EngineRedis:
+			steps = redis(d)
+		default:
+			return nil, fmt.Errorf("databases[%d]: unknown engine %q", i, d.Engine)
+		}
+		for j, s := range steps {
+			// Later steps build on earlier ones: the database is owned by
+			// the role, which needs the secured root account.
+			if j > 0 && d.Engine != templates.EngineRedis {
+				s.requires = append(s.requires, steps[j-1].ID())
+			}
+			if d.Service != "" {
+				s.requires = append(s.requires, resource.ID(services.Kind, d.Service))
+			}
+			out = append(out, s)
+		}
+	}
+	return out, nil
+}
+
+// Step is one idempotent bootstrap action.
+type Step struct {
+	name     string
+	desc     string
+	requires []string
+	check    func(ctx context.Context, env *resource.Env) (resource.State, error)
+	apply    func(ctx context.Context, env *resource.Env) error
+	asserts  []resource.Assertion
+}
+
+// ID implements resource.Resource.
+func (s *Step) ID() string { return resource.ID(Kind, s.name) }
+
+// Describe implements resource.Resource.
+func (s *Step) Describe() string { return s.desc }
+
+// Requires implements resource.Requirer.
+func (s *Step) Requires() []string { return s.requires }
+
+// Check implements resource.Resource.
+func (s *Step) Check(ctx context.Context, env *resource.Env) (resource.State, error) {
+	return s.check(ctx, env)
+}
+
+// Apply implements resource.Resource.
+func (s *Step) Apply(ctx context.Context, env *resource.Env) error {
+	return s.apply(ctx, env)
+}
+
+// Assertions implements resource.Asserter.
+func (s *Step) Assertions() []resource.Assertion { return s.asserts }
+
+// exists is a State for steps that are either done or not.
+func exists(done bool) resource.State {
+	if done {
+		return resource.State{Converged: true, Current: "present", Desired: "present"}
+	}
+	return resource.State{Current: "missing", Desired: "present"}
+}
+
+// literal quotes s as a SQL string literal.
+func literal(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// password fetches a secret, or "" when name is empty.
+func password(ctx context.Context, env *resource.Env, name string) (string, error) {
+	if name == "" {
+		return "", nil
+	}
+	return env.Secrets.Get(ctx, name)
+}
@@ -0,0 +1,129 @@
+package databases
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/hmziqrs/maziq/internal/resource"
+	"github.com/hmziqrs/maziq/internal/shell"
+	"github.com/hmziqrs/maziq/internal/templates"
+)
+
+// mysqlRun runs sql as root, passing the password through MYSQL_PWD and the
+// statements on stdin. An empty password means a fresh install where root
+// still authenticates without one.
+func mysqlRun(ctx context.Context, env *resource.Env, rootPW, sql string) (string, error) {
+	c := shell.Command{Name: "mysql", Args: []string{"-uroot", "-N", "-B"}, Stdin: sql}
+	if rootPW != "" {
+		c.Env = []string{"MYSQL_PWD=" + rootPW}
+	} else {
+		c.Args = append(c.Args, "--skip-password")
+	}
+	res, err := env.Run(ctx, c)
+	return strings.TrimSpace(res.Stdout), err
+}
+
+func mysql(d templates.Database) []*Step {
+	var steps []*Step
+	root := func(ctx context.Context, env *resource.Env) (string, error) {
+		return password(ctx, env, d.RootPasswordSecret)
+	}
+	if d.RootPasswordSecret != "" {
+		steps = append(steps, &Step{
+			name: "mysql/secure",
+			desc: "secure MySQL root account",
+			// Root still logging in without a password is the sign of a
+			// fresh, unsecured install.
+			check: func(ctx context.Context, env *resource.Env) (resource.State, error) {
+				_, err := mysqlRun(ctx, env, "", "SELECT 1")
+				if errors.As(err, new(*shell.ExitError)) {
+					return exists(true), nil
+				}
+				return exists(false), err
+			},
+			apply: func(ctx context.Context, env *resource.Env) error {
+				pw, err := root(ctx, env)
+				if err != nil {
+					return err
+				}
+				_, err = mysqlRun(ctx, env, "", strings.Join([]string{
+					"ALTER USER 'root'@'localhost' IDENTIFIED BY " + literal(pw) + ";",
+					"DELETE FROM mysql.user WHERE User = '';",
+					"DELETE FROM mysql.user WHERE User = 'root' AND Host NOT IN ('localhost', '127.0.0.1', '::1');",
+					"DROP DATABASE IF EXISTS test;",
+					"FLUSH PRIVILEGES;",
+				}, "\n"))
+				return err
+			},
+			asserts: []resource.Assertion{{
+				Name:   "MySQL root requires a password",
+				Run:    "mysql -uroot --skip-password -e 'SELECT 1' 2>&1 || true",
+				Expect: "Access denied",
+			}},
+		})
+	}
+	if d.Role != "" {
+		user := literal(d.Role) + "@'localhost'"
+		steps = append(steps, &Step{
+			name: "mysql/user:" + d.Role,
+			desc: "create MySQL user " + d.Role,
+			check: func(ctx context.Context, env *resource.Env) (resource.State, error) {
+				pw, err := root(ctx, env)
+				if err != nil {
+					return exists(false), err
+				}
+				out, err := mysqlRun(ctx, env, pw, "SELECT 1 FROM mysql.user WHERE User = "+literal(d.Role)+" AND Host = 'localhost'")
+				return exists(out == "1"), err
+			},
+			apply: func(ctx context.Context, env *resource.Env) error {
+				rootPW, err := root(ctx, env)
+				if err != nil {
+					return err
+				}
+				pw, err := password(ctx, env, d.PasswordSecret)
+				if err != nil {
+					return err
+				}
+				sql := "CREATE USER IF NOT EXISTS " + user
+				if pw != "" {
+					sql += " IDENTIFIED BY " + literal(pw)
+				}
+				sql += ";\n"
+				if d.Superuser {
+					sql += "GRANT ALL PRIVILEGES ON *.* TO " + user + " WITH GRANT OPTION;\n"
+				}
+				_, err = mysqlRun(ctx, env, rootPW, sql)
+				return err
+			},
+		})
+	}
+	if d.Database != "" {
+		steps = append(steps, &Step{
+			name: "mysql/db:" + d.Database,
+			desc: "create MySQL database " + d.Database,
+			check: func(ctx context.Context, env *resource.Env) (resource.State, error) {
+				pw, err := root(ctx, env)
+				if err != nil {
+					return exists(false), err
+				}
+				out, err := mysqlRun(ctx, env, pw, "SELECT 1 FROM information_schema.schemata WHERE schema_name = "+literal(d.Database))
+				return exists(out == "1"), err
+			},
+			apply: func(ctx context.Context, env *resource.Env) error {
+				pw, err := root(ctx, env)
+				if err != nil {
+					return err
+				}
+				db := "`" + strings.ReplaceAll(d.Database, "`", "``") + "`"
+				sql := "CREATE DATABASE IF NOT EXISTS " + db + ";\n"
+				if d.Role != "" {
+					sql += "GRANT ALL PRIVILEGES ON " + db + ".* TO " + literal(d.Role) + "@'localhost';\n"
+				}
+				_, err = mysqlRun(ctx, env, pw, sql)
+				return err
+			},
+		})
+	}
+	return steps
+}
@@ -0,0 +1,81 @@
+package databases
+
+import (
+	"context"
+	"strings"
+
+	"github.com/hmziqrs/maziq/internal/resource"
+	"github.com/hmziqrs/maziq/internal/shell"
+	"github.com/hmziqrs/maziq/internal/templates"
+)
+
+// ident quotes s as a Postgres identifier.
+func ident(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+}
+
+// psqlQuery runs a single-value query against the maintenance database as
+// the current user, which Homebrew's initdb makes a superuser.
+func psqlQuery(ctx context.Context, env *resource.Env, sql string) (string, error) {
+	res, err := env.Run(ctx, shell.Cmd("psql", "-d", "postgres", "-tAc", sql))
+	return strings.TrimSpace(res.Stdout), err
+}
+
+// psqlExec feeds sql on stdin so passwords never reach the command line.
+func psqlExec(ctx context.Context, env *resource.Env, sql string) error {
+	_, err := env.Run(ctx, shell.Command{Name: "psql", Args: []string{"-d", "postgres", "-v", "ON_ERROR_STOP=1", "-q"}, Stdin: sql})
+	return err
+}
+
+func postgres(d templates.Database) []*Step {
+	var steps []*Step
+	if d.Role != "" {
+		steps = append(steps, &Step{
+			name: "postgres/role:" + d.Role,
+			desc: "create Postgres role " + d.Role,
+			check: func(ctx context.Context, env *resource.Env) (resource.State, error) {
+				out, err := psqlQuery(ctx, env, "SELECT 1 FROM pg_roles WHERE rolname = "+literal(d.Role))
+				return exists(out == "1"), err
+			},
+			apply: func(ctx context.Context, env *resource.Env) error {
+				pw, err := password(ctx, env, d.PasswordSecret)
+				if err != nil {
+					return err
+				}
+				sql := "CREATE ROLE " + ident(d.Role) + " LOGIN CREATEDB"
+				if d.Superuser {
+					sql += " SUPERUSER"
+				}
+				if pw != "" {
+					sql += " PASSWORD " + literal(pw)
+				}
+				return psqlExec(ctx, env, sql+";\n")
+			},
+		})
+	}
+	if d.Database != "" {
+		owner := d.Role
+		steps = append(steps, &Step{
+			name: "postgres/db:" + d.Database,
+			desc: "create Postgres database " + d.Database,
+			check: func(ctx context.Context, env *resource.Env) (resource.State, error) {
+				out, err := psqlQuery(ctx, env, "SELECT 1 FROM pg_database WHERE datname = "+literal(d.Database))
+				return exists(out == "1"), err
+			},
+			apply: func(ctx context.Context, env *resource.Env) error {
+				args := []string{d.Database}
+				if owner != "" {
+					args = []string{"-O", owner, d.Database}
+				}
+				_, err := env.Run(ctx, shell.Cmd("createdb", args...))
+				return err
+			},
+			asserts: []resource.Assertion{{
+				Name:   "Postgres database " + d.Database + " accepts connections",
+				Run:    "psql -d " + shell.Quote(d.Database) + " -tAc 'SELECT 1'",
+				Expect: "1",
+			}},
+		})
+	}
+	return steps
+}
@@ -0,0 +1,57 @@
+package databases
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/hmziqrs/maziq/internal/resource"
+	"github.com/hmziqrs/maziq/internal/shell"
+	"github.com/hmziqrs/maziq/internal/templates"
+)
+
+// redis makes one step per CONFIG key, sorted so plans are stable.
+func redis(d templates.Database) []*Step {
+	keys := make([]string, 0, len(d.Config))
+	for k := range d.Config {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var steps []*Step
+	for _, key := range keys {
+		want := d.Config[key]
+		steps = append(steps, &Step{
+			name: "redis/" + key,
+			desc: "set Redis " + key + " to " + want,
+			check: func(ctx context.Context, env *resource.Env) (resource.State, error) {
+				state := resource.State{Desired: want}
+				res, err := env.Run(ctx, shell.Cmd("redis-cli", "CONFIG", "GET", key))
+				if err != nil {
+					return state, err
+				}
+				// Output is the key and value on separate lines.
+				lines := strings.Split(strings.TrimSpace(res.Stdout), "\n")
+				if len(lines) == 2 {
+					state.Current = strings.TrimSpace(lines[1])
+				}
+				state.Converged = state.Current == want
+				return state, nil
+			},
+			// CONFIG REWRITE writes the running config back to redis.conf so
+			// the value survives a service restart.
+			apply: func(ctx context.Context, env *resource.Env) error {
+				if _, err := env.Run(ctx, shell.Cmd("redis-cli", "CONFIG", "SET", key, want)); err != nil {
+					return err
+				}
+				_, err := env.Run(ctx, shell.Cmd("redis-cli", "CONFIG", "REWRITE"))
+				return err
+			},
+			asserts: []resource.Assertion{{
+				Name:   "Redis " + key + " is " + want,
+				Run:    "redis-cli CONFIG GET " + shell.Quote(key),
+				Expect: want,
+			}},
+		})
+	}
+	return steps
+}
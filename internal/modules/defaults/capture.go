@@ -0,0 +1,99 @@
+package defaults
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hmziqrs/maziq/internal/plist"
+	"github.com/hmziqrs/maziq/internal/resource"
+	"github.com/hmziqrs/maziq/internal/shell"
+)
+
+// Captured is a key whose value differs from the factory default.
+type Captured struct {
+	Domain string
+	Key    string
+	Value  any
+	// Factory is the factory value, or nil when it is not known.
+	Factory any
+	Restart string
+}
+
+// Known reports whether the factory value is known, which is what makes the
+// key a deliberate tweak rather than app state.
+func (c Captured) Known() bool { return c.Factory != nil }
+
+// Capture diffs each domain against the factory defaults for macos. Keys
+// without a known factory value are included only when unknown is set.
+// Values that are not a bool, number or string are skipped.
+func Capture(ctx context.Context, env *resource.Env, domains []string, macos string, unknown bool) ([]Captured, error) {
+	factory := Factory(macos)
+	var out []Captured
+	for _, domain := range domains {
+		values, err := Export(ctx, env, domain)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", domain, err)
+		}
+		keys := make([]string, 0, len(values))
+		for k := range values {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			want, known := factory[domain][k]
+			if (!known && !unknown) || known && plist.Equal(values[k], want) {
+				continue
+			}
+			out = append(out, Captured{Domain: domain, Key: k, Value: values[k], Factory: want, Restart: restarts[domain]})
+		}
+	}
+	return out, nil
+}
+
+// Export reads the scalar top-level values of a domain. A domain that does
+// not exist yet has no values.
+func Export(ctx context.Context, env *resource.Env, domain string) (map[string]any, error) {
+	values, err := readDomain(ctx, env, domain, false)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range values {
+		switch v.(type) {
+		case bool, int64, float64, string:
+		default:
+			delete(values, k)
+		}
+	}
+	return values, nil
+}
+
+// readDomain decodes every value of a domain. A domain that does not exist
+// yet has no values.
+func readDomain(ctx context.Context, env *resource.Env, domain string, currentHost bool) (map[string]any, error) {
+	args := []string{"export", domain, "-"}
+	if currentHost {
+		args = append([]string{"-currentHost"}, args...)
+	}
+	res, err := env.Run(ctx, shell.Cmd("defaults", args...))
+	if err != nil {
+		if errors.As(err, new(*shell.ExitError)) {
+			return map[string]any{}, nil
+		}
+		return nil, err
+	}
+	if strings.TrimSpace(res.Stdout) == "" {
+		return map[string]any{}, nil
+	}
+	v, err := plist.Decode([]byte(res.Stdout))
+	if err != nil {
+		return nil, err
+	}
+	values, ok := v.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("%s is a %s, not a dictionary", domain, plist.Type(v))
+	}
+	return values, nil
+}
@@ -0,0 +1,439 @@
+// Package defaults manages preference values with the `defaults` command.
+// Besides the raw `[[defaults]]` section, other modules build Settings for
+// the preferences they curate, and curated groups bundle settings that are
+// switched on and off together.
+package defaults
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hmziqrs/maziq/internal/plist"
+	"github.com/hmziqrs/maziq/internal/resource"
+	"github.com/hmziqrs/maziq/internal/shell"
+	"github.com/hmziqrs/maziq/internal/templates"
+)
+
+// Kind is the resource kind for preference values.
+const Kind = "defaults"
+
+func init() {
+	resource.Register(build)
+}
+
+func build(env *resource.Env) ([]resource.Resource, error) {
+	var out []resource.Resource
+	var grouped []*Setting
+	explicit := map[string]bool{}
+	for i, d := range env.Template.Defaults {
+		ok, err := env.Holds(d.When)
+		if err != nil {
+			return nil, fmt.Errorf("defaults[%d]: when: %w", i, err)
+		}
+		if !ok {
+			continue
+		}
+		if d.Group != "" {
+			g, ok := templates.SettingGroups[d.Group]
+			if !ok {
+				return nil, fmt.Errorf("defaults[%d]: unknown group %q", i, d.Group)
+			}
+			settings, err := Group(g)
+			if err != nil {
+				return nil, fmt.Errorf("defaults[%d] group %s: %w", i, d.Group, err)
+			}
+			grouped = append(grouped, settings...)
+			continue
+		}
+		if d.Domain == "" || d.Key == "" {
+			return nil, fmt.Errorf("defaults[%d]: domain and key are required", i)
+		}
+		s, err := New(d.Domain, d.Key, d.Value)
+		if err != nil {
+			return nil, fmt.Errorf("defaults[%d] %s %s: %w", i, d.Domain, d.Key, err)
+		}
+		s.CurrentHost, s.Restart, s.Logout = d.CurrentHost, d.Restart, d.Logout
+		explicit[s.ID()] = true
+		out = append(out, s)
+	}
+	// [trackpad], [menubar] and [sound] go before the groups, so they win
+	// over the ones that set the same keys.
+	var sections []*Setting
+	for _, g := range []templates.SettingGroup{
+		{Title: "Trackpad", Settings: env.Template.Trackpad.Settings()},
+		{Title: "Menu bar", Settings: env.Template.MenuBar.Settings()},
+		{Title: "Sound", Settings: env.Template.Sound.Settings()},
+	} {
+		settings, err := Group(g)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", strings.ToLower(g.Title), err)
+		}
+		sections = append(sections, settings...)
+	}
+	grouped = append(sections, grouped...)
+	// An explicit entry overrides the same key in a group.
+	for _, s := range grouped {
+		if !explicit[s.ID()] {
+			explicit[s.ID()] = true
+			out = append(out, s)
+		}
+	}
+	return out, nil
+}
+
+// Setting is one preference key.
+type Setting struct {
+	Domain string
+	Key    string
+	// CurrentHost targets the ByHost preferences.
+	CurrentHost bool
+	// Restart is relaunched after a write so it rereads its preferences.
+	Restart string
+	// Logout marks keys that are only read at login.
+	Logout bool
+	// Group is the title of the curated group the setting came from.
+	Group string
+	// Sudo writes as root, for domains under /Library/Preferences.
+	Sudo bool
+
+	typ   string // defaults write type flag without the dash
+	value string // canonical form, as printed by `defaults read`
+	data  any    // the value as a plist value
+}
+
+// New returns a setting for a TOML value: bool, integer, float, string or
+// datetime, or an array or table of them nested to any depth.
+func New(domain, key string, value any) (*Setting, error) {
+	s := &Setting{Domain: domain, Key: key}
+	if err := s.set(value); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// dateFormat is how `defaults read` prints dates.
+const dateFormat = "2006-01-02 15:04:05 -0700"
+
+func (s *Setting) set(value any) error {
+	v, err := plist.Normalize(value)
+	if err != nil {
+		return err
+	}
+	switch v := v.(type) {
+	case bool:
+		s.typ, s.value = "bool", "0"
+		if v {
+			s.value = "1"
+		}
+	case int64:
+		s.typ, s.value = "int", strconv.FormatInt(v, 10)
+	case float64:
+		s.typ, s.value = "float", strconv.FormatFloat(v, 'g', -1, 64)
+	case string:
+		s.typ, s.value = "string", v
+	case time.Time:
+		s.typ, s.value = "date", v.UTC().Format(dateFormat)
+	case []any:
+		s.typ, s.value = "array", plist.Format(v)
+	case map[string]any:
+		s.typ, s.value = "dict", plist.Format(v)
+	default:
+		return fmt.Errorf("unsupported value type %s", plist.Type(v))
+	}
+	s.data = v
+	return nil
+}
+
+// structured reports whether the value is an array or dict, which are read
+// and compared as a plist rather than as `defaults read` text.
+func (s *Setting) structured() bool { return s.typ == "array" || s.typ == "dict" }
+
+// ID implements resource.Resource.
+func (s *Setting) ID() string {
+	domain := s.Domain
+	if s.CurrentHost {
+		domain = "currentHost/" + domain
+	}
+	return resource.ID(Kind, domain+"/"+s.Key)
+}
+
+// Scope implements resource.Scoper. Domains written as root apply to every
+// user.
+func (s *Setting) Scope() string {
+	if s.Sudo {
+		return resource.ScopeSystem
+	}
+	return resource.ScopeUser
+}
+
+// Describe implements resource.Resource.
+func (s *Setting) Describe() string {
+	desc := fmt.Sprintf("set %s %s to %s", s.Domain, s.Key, s.Display())
+	if s.Group != "" {
+		desc += " (" + s.Group + ")"
+	}
+	return desc
+}
+
+// Display renders the desired value for humans.
+func (s *Setting) Display() string {
+	if s.typ == "bool" {
+		return strconv.FormatBool(s.value == "1")
+	}
+	return s.value
+}
+
+func (s *Setting) args(verb string, rest ...string) []string {
+	var args []string
+	if s.CurrentHost {
+		args = append(args, "-currentHost")
+	}
+	return append(append(args, verb, s.Domain, s.Key), rest...)
+}
+
+// Read returns the current value as printed by `defaults read`, and false
+// when the key is unset.
+func (s *Setting) Read(ctx context.Context, env *resource.Env) (string, bool, error) {
+	res, err := env.Run(ctx, shell.Cmd("defaults", s.args("read")...))
+	if err != nil {
+		if errors.As(err, new(*shell.ExitError)) {
+			return "", false, nil
+		}
+		return "", false, err
+	}
+	return strings.TrimSpace(res.Stdout), true, nil
+}
+
+// Verify implements resource.Verifier; reading a preference is already
+// local.
+func (s *Setting) Verify(ctx context.Context, env *resource.Env) (resource.State, error) {
+	return s.Check(ctx, env)
+}
+
+// ReadValue returns the current value as a plist value, and false when the
+// key is unset.
+func (s *Setting) ReadValue(ctx context.Context, env *resource.Env) (any, bool, error) {
+	values, err := readDomain(ctx, env, s.Domain, s.CurrentHost)
+	if err != nil {
+		return nil, false, err
+	}
+	v, ok := values[s.Key]
+	return v, ok, nil
+}
+
+// Check implements resource.Resource. An array or dict that differs is
+// reported by the first key path that does.
+func (s *Setting) Check(ctx context.Context, env *resource.Env) (resource.State, error) {
+	state := resource.State{Desired: s.Display()}
+	if reason := s.Unsupported(env); reason != "" {
+		state.Current, state.Blocked = "unsupported", reason
+		return state, nil
+	}
+	if s.structured() {
+		current, ok, err := s.ReadValue(ctx, env)
+		if err != nil {
+			return state, err
+		}
+		changes := plist.Diff(current, s.data)
+		switch {
+		case !ok:
+			state.Current = "unset"
+		case len(changes) == 0:
+			state.Current, state.Converged = s.Display(), true
+		case changes[0].Path == "":
+			state.Current = plist.Format(current)
+		default:
+			state.Current = changes[0].String()
+			if len(changes) > 1 {
+				state.Current += fmt.Sprintf(" and %d more", len(changes)-1)
+			}
+		}
+		return state, nil
+	}
+	current, ok, err := s.Read(ctx, env)
+	if err != nil {
+		return state, err
+	}
+	switch {
+	case !ok:
+		state.Current = "unset"
+	case s.typ == "bool":
+		state.Current = strconv.FormatBool(current == "1")
+	default:
+		state.Current = current
+	}
+	state.Converged = ok && s.equal(current)
+	return state, nil
+}
+
+// Unsupported explains why the key does nothing on this macOS version, or
+// returns "" when it works.
+func (s *Setting) Unsupported(env *resource.Env) string {
+	c, ok := templates.LookupCompat(s.Domain, s.Key)
+	macos := env.Facts["macos"]
+	if !ok || c.Supports(macos) {
+		return ""
+	}
+	reason := fmt.Sprintf("%s %s only works on %s, not macOS %s", s.Domain, s.Key, c.Range(), macos)
+	if c.Note != "" {
+		reason += "; " + c.Note
+	}
+	return reason
+}
+
+func (s *Setting) equal(current string) bool {
+	if s.typ == "float" {
+		a, errA := strconv.ParseFloat(current, 64)
+		b, errB := strconv.ParseFloat(s.value, 64)
+		return errA == nil && errB == nil && a == b
+	}
+	return current == s.value
+}
+
+// Apply implements resource.Resource.
+func (s *Setting) Apply(ctx context.Context, env *resource.Env) error {
+	write, err := s.write(s.data)
+	if err != nil {
+		return err
+	}
+	_, err = env.Run(ctx, write)
+	return err
+}
+
+// write returns the defaults command that writes v. Arrays and dicts are
+// passed as a plist fragment, which `defaults write` parses whole, so nested
+// values keep their types.
+func (s *Setting) write(v any) (shell.Command, error) {
+	var args []string
+	switch v := v.(type) {
+	case []any, map[string]any:
+		fragment, err := plist.Fragment(v)
+		if err != nil {
+			return shell.Command{}, err
+		}
+		args = s.args("write", fragment)
+	case bool:
+		args = s.args("write", "-bool", strconv.FormatBool(v))
+	case int64:
+		args = s.args("write", "-int", strconv.FormatInt(v, 10))
+	case float64:
+		args = s.args("write", "-float", strconv.FormatFloat(v, 'g', -1, 64))
+	case string:
+		args = s.args("write", "-string", v)
+	case time.Time:
+		args = s.args("write", "-date", v.UTC().Format(dateFormat))
+	default:
+		return shell.Command{}, fmt.Errorf("%s %s is a %s and cannot be written back", s.Domain, s.Key, plist.Type(v))
+	}
+	write := shell.Cmd("defaults", args...)
+	write.Sudo = s.Sudo
+	return write, nil
+}
+
+// Undo implements resource.Reverter: an unset key is deleted again and a
+// set one written back with its old type and, for arrays and dicts, its
+// nested values. Data cannot be written back.
+func (s *Setting) Undo(ctx context.Context, env *resource.Env) (resource.Undo, error) {
+	u := resource.Undo{ID: s.ID()}
+	current, ok, err := s.ReadValue(ctx, env)
+	if err != nil {
+		return u, err
+	}
+	if !ok {
+		del := shell.Cmd("defaults", s.args("delete")...)
+		del.Sudo = s.Sudo
+		u.Commands = []shell.Command{del}
+		return u, nil
+	}
+	write, err := s.write(current)
+	if err != nil {
+		return u, err
+	}
+	u.Commands = []shell.Command{write}
+	return u, nil
+}
+
+// Restarts implements resource.Restarter.
+func (s *Setting) Restarts() resource.Restart {
+	r := resource.Restart{Logout: s.Logout}
+	if s.Restart != "" {
+		r.Processes = []string{s.Restart}
+	}
+	return r
+}
+
+// Delete removes the key so the system default applies again. A key that is
+// already unset is not an error.
+func (s *Setting) Delete(ctx context.Context, env *resource.Env) error {
+	if _, ok, err := s.Read(ctx, env); err != nil || !ok {
+		return err
+	}
+	del := shell.Cmd("defaults", s.args("delete")...)
+	del.Sudo = s.Sudo
+	_, err := env.Run(ctx, del)
+	return err
+}
+
+// Export implements resource.Exporter. `defaults read` prints arrays and
+// dicts in a form the script cannot compare, so those are written on every
+// run; the write is idempotent.
+func (s *Setting) Export(ctx context.Context, env *resource.Env) (resource.Step, error) {
+	write, err := s.write(s.data)
+	if err != nil {
+		return resource.Step{}, err
+	}
+	check := "false"
+	if !s.structured() {
+		read := shell.Cmd("defaults", s.args("read")...)
+		check = "[ \"$(" + read.String() + " 2>/dev/null)\" = " + shell.Quote(s.value) + " ]"
+	}
+	return resource.Step{Check: check, Commands: []shell.Command{write}}, nil
+}
+
+// Diff implements resource.Differ: the key in its preferences file, one
+// key path per line.
+func (s *Setting) Diff(ctx context.Context, env *resource.Env) (resource.FileChange, error) {
+	c := resource.FileChange{Path: s.file(env), Desired: []byte(plist.Flatten(s.Key, s.data))}
+	current, ok, err := s.ReadValue(ctx, env)
+	if err != nil {
+		return c, err
+	}
+	if ok {
+		c.Current = []byte(plist.Flatten(s.Key, current))
+	}
+	return c, nil
+}
+
+// Merge implements resource.Merger.
+func (s *Setting) Merge(content []byte) error {
+	values, err := plist.Unflatten(string(content))
+	if err != nil {
+		return err
+	}
+	v, ok := values[s.Key]
+	if !ok {
+		return fmt.Errorf("the merge leaves %s unset", s.Key)
+	}
+	return s.set(v)
+}
+
+// file is the preferences file the domain is kept in.
+func (s *Setting) file(env *resource.Env) string {
+	name := s.Domain
+	if name == "NSGlobalDomain" {
+		name = ".GlobalPreferences"
+	}
+	switch {
+	case strings.HasPrefix(s.Domain, "/"):
+		return strings.TrimSuffix(s.Domain, ".plist") + ".plist"
+	case s.CurrentHost:
+		return env.Path("~/Library/Preferences/ByHost/" + name + ".<host>.plist")
+	case s.Sudo:
+		return "/Library/Preferences/" + name + ".plist"
+	}
+	return env.Path("~/Library/Preferences/" + name + ".plist")
+}
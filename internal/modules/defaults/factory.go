@@ -0,0 +1,131 @@
+package defaults
+
+import (
+	"sort"
+	"strconv"
+)
+
+// factoryValue is the value a fresh install of macOS reports for a key. Since
+// and Until bound the macOS major versions it applies to; zero is open.
+type factoryValue struct {
+	Domain, Key  string
+	Value        any
+	Since, Until int
+}
+
+// factory lists the out-of-the-box values of the keys people commonly
+// tweak. Keys not listed here have no known factory value and are only
+// captured on request.
+var factory = []factoryValue{
+	{Domain: "NSGlobalDomain", Key: "AppleShowAllExtensions", Value: false},
+	{Domain: "NSGlobalDomain", Key: "AppleShowScrollBars", Value: "Automatic"},
+	{Domain: "NSGlobalDomain", Key: "ApplePressAndHoldEnabled", Value: true},
+	{Domain: "NSGlobalDomain", Key: "AppleKeyboardUIMode", Value: int64(0)},
+	{Domain: "NSGlobalDomain", Key: "KeyRepeat", Value: int64(6)},
+	{Domain: "NSGlobalDomain", Key: "InitialKeyRepeat", Value: int64(25)},
+	{Domain: "NSGlobalDomain", Key: "NSAutomaticSpellingCorrectionEnabled", Value: true},
+	{Domain: "NSGlobalDomain", Key: "NSAutomaticQuoteSubstitutionEnabled", Value: true},
+	{Domain: "NSGlobalDomain", Key: "NSAutomaticDashSubstitutionEnabled", Value: true},
+	{Domain: "NSGlobalDomain", Key: "NSAutomaticCapitalizationEnabled", Value: true},
+	{Domain: "NSGlobalDomain", Key: "NSAutomaticPeriodSubstitutionEnabled", Value: true},
+	{Domain: "NSGlobalDomain", Key: "NSDocumentSaveNewDocumentsToCloud", Value: true},
+	{Domain: "NSGlobalDomain", Key: "com.apple.swipescrolldirection", Value: true},
+
+	{Domain: "com.apple.dock", Key: "autohide", Value: false},
+	{Domain: "com.apple.dock", Key: "autohide-delay", Value: 0.5},
+	{Domain: "com.apple.dock", Key: "autohide-time-modifier", Value: 0.5},
+	{Domain: "com.apple.dock", Key: "orientation", Value: "bottom"},
+	{Domain: "com.apple.dock", Key: "tilesize", Value: int64(48)},
+	{Domain: "com.apple.dock", Key: "magnification", Value: false},
+	{Domain: "com.apple.dock", Key: "mineffect", Value: "genie"},
+	{Domain: "com.apple.dock", Key: "minimize-to-application", Value: false},
+	{Domain: "com.apple.dock", Key: "show-recents", Value: true},
+	{Domain: "com.apple.dock", Key: "show-process-indicators", Value: true},
+	{Domain: "com.apple.dock", Key: "launchanim", Value: true},
+	{Domain: "com.apple.dock", Key: "mru-spaces", Value: true},
+	{Domain: "com.apple.dock", Key: "static-only", Value: false},
+	{Domain: "com.apple.dock", Key: "wvous-tl-corner", Value: int64(1)},
+	{Domain: "com.apple.dock", Key: "wvous-tr-corner", Value: int64(1)},
+	{Domain: "com.apple.dock", Key: "wvous-bl-corner", Value: int64(1)},
+	{Domain: "com.apple.dock", Key: "wvous-br-corner", Value: int64(1), Until: 11},
+	// Monterey put Quick Note on the bottom right corner.
+	{Domain: "com.apple.dock", Key: "wvous-br-corner", Value: int64(14), Since: 12},
+
+	{Domain: "com.apple.finder", Key: "AppleShowAllFiles", Value: false},
+	{Domain: "com.apple.finder", Key: "ShowPathbar", Value: false},
+	{Domain: "com.apple.finder", Key: "ShowStatusBar", Value: false},
+	{Domain: "com.apple.finder", Key: "_FXShowPosixPathInTitle", Value: false},
+	{Domain: "com.apple.finder", Key: "_FXSortFoldersFirst", Value: false},
+	{Domain: "com.apple.finder", Key: "FXDefaultSearchScope", Value: "SCev"},
+	{Domain: "com.apple.finder", Key: "FXEnableExtensionChangeWarning", Value: true},
+	{Domain: "com.apple.finder", Key: "FXPreferredViewStyle", Value: "icnv"},
+	{Domain: "com.apple.finder", Key: "ShowExternalHardDrivesOnDesktop", Value: true},
+	{Domain: "com.apple.finder", Key: "ShowHardDrivesOnDesktop", Value: false},
+	{Domain: "com.apple.finder", Key: "ShowRemovableMediaOnDesktop", Value: true},
+	{Domain: "com.apple.finder", Key: "QuitMenuItem", Value: false},
+
+	{Domain: "com.apple.screencapture", Key: "type", Value: "png"},
+	{Domain: "com.apple.screencapture", Key: "disable-shadow", Value: false},
+	{Domain: "com.apple.screencapture", Key: "show-thumbnail", Value: true},
+	{Domain: "com.apple.screencapture", Key: "include-date", Value: true},
+	{Domain: "com.apple.screencapture", Key: "showsCursor", Value: false},
+	{Domain: "com.apple.screencapture", Key: "name", Value: "Screen Shot", Until: 12},
+	// Ventura renamed "Screen Shot" to "Screenshot".
+	{Domain: "com.apple.screencapture", Key: "name", Value: "Screenshot", Since: 13},
+
+	{Domain: "com.apple.AppleMultitouchTrackpad", Key: "Clicking", Value: false},
+	{Domain: "com.apple.AppleMultitouchTrackpad", Key: "TrackpadRightClick", Value: true},
+	{Domain: "com.apple.AppleMultitouchTrackpad", Key: "TrackpadThreeFingerDrag", Value: false},
+
+	{Domain: "com.apple.LaunchServices", Key: "LSQuarantine", Value: true},
+}
+
+// restarts names the process that rereads each domain.
+var restarts = map[string]string{
+	"com.apple.controlcenter": "ControlCenter",
+	"com.apple.dock":          "Dock",
+	"com.apple.finder":        "Finder",
+	"com.apple.screencapture": "SystemUIServer",
+}
+
+// Factory returns the known factory values for a macOS major version, such
+// as the "macos" fact, by domain and key. An unknown version gets the values
+// of the newest release.
+func Factory(macos string) map[string]map[string]any {
+	major, err := strconv.Atoi(macos)
+	if err != nil {
+		major = 0
+	}
+	out := map[string]map[string]any{}
+	for _, f := range factory {
+		if major != 0 && (f.Since != 0 && major < f.Since || f.Until != 0 && major > f.Until) {
+			continue
+		}
+		if major == 0 && f.Until != 0 {
+			continue
+		}
+		if out[f.Domain] == nil {
+			out[f.Domain] = map[string]any{}
+		}
+		out[f.Domain][f.Key] = f.Value
+	}
+	return out
+}
+
+// FactoryDomains returns the domains with known factory values.
+func FactoryDomains() []string {
+	seen := map[string]bool{}
+	var out []string
+	for _, f := range factory {
+		if !seen[f.Domain] {
+			seen[f.Domain] = true
+			out = append(out, f.Domain)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Restart names the process that rereads domain, or "" when there is none
+// MazIQ knows of.
+func Restart(domain string) string { return restarts[domain] }
@@ -0,0 +1,93 @@
+package defaults
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hmziqrs/maziq/internal/resource"
+	"github.com/hmziqrs/maziq/internal/shell"
+	"github.com/hmziqrs/maziq/internal/templates"
+)
+
+// Group returns the settings of a curated group.
+func Group(g templates.SettingGroup) ([]*Setting, error) {
+	out := make([]*Setting, 0, len(g.Settings))
+	for _, gs := range g.Settings {
+		s, err := New(gs.Domain, gs.Key, gs.Value)
+		if err != nil {
+			return nil, fmt.Errorf("%s %s: %w", gs.Domain, gs.Key, err)
+		}
+		s.CurrentHost, s.Restart, s.Logout, s.Group = gs.CurrentHost, gs.Restart, gs.Logout, g.Title
+		out = append(out, s)
+	}
+	return out, nil
+}
+
+// GroupStatus is how much of a curated group is in effect.
+type GroupStatus struct {
+	Group templates.SettingGroup
+	// On counts the settings that already have the group's value.
+	On    int
+	Total int
+}
+
+// Enabled reports whether every setting in the group is in effect.
+func (s GroupStatus) Enabled() bool { return s.Total > 0 && s.On == s.Total }
+
+// CheckGroup reads every setting of g that this macOS version supports.
+func CheckGroup(ctx context.Context, env *resource.Env, g templates.SettingGroup) (GroupStatus, error) {
+	status := GroupStatus{Group: g}
+	settings, err := Group(g)
+	if err != nil {
+		return status, err
+	}
+	for _, s := range settings {
+		if s.Unsupported(env) != "" {
+			continue
+		}
+		status.Total++
+		state, err := s.Check(ctx, env)
+		if err != nil {
+			return status, err
+		}
+		if state.Converged {
+			status.On++
+		}
+	}
+	return status, nil
+}
+
+// SetGroup switches a curated group on, writing every setting, or off,
+// deleting its keys so the macOS defaults apply again. Keys this macOS
+// version does not support are left alone. It returns what needs restarting
+// for the change to show up.
+func SetGroup(ctx context.Context, env *resource.Env, g templates.SettingGroup, on bool) (resource.Restart, error) {
+	var restart resource.Restart
+	settings, err := Group(g)
+	if err != nil {
+		return restart, err
+	}
+	for _, s := range settings {
+		if s.Unsupported(env) != "" {
+			continue
+		}
+		if on {
+			err = s.Apply(ctx, env)
+		} else {
+			err = s.Delete(ctx, env)
+		}
+		if err != nil {
+			return restart, fmt.Errorf("%s %s: %w", s.Domain, s.Key, err)
+		}
+		restart = restart.Merge(s.Restarts())
+	}
+	return restart, nil
+}
+
+// Relaunch kills each process so launchd starts it again with fresh
+// preferences. A process that is not running is not a failure.
+func Relaunch(ctx context.Context, env *resource.Env, processes []string) {
+	for _, p := range processes {
+		_, _ = env.Run(ctx, shell.Cmd("killall", p))
+	}
+}
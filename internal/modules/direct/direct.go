@@ -0,0 +1,266 @@
+// Package direct installs apps that are in neither Homebrew nor the App
+// Store from the vendor's own dmg, pkg or zip, keeps track of what it
+// installed so drift, upgrades and uninstalls work, and upgrades them when
+// the vendor's version URL reports a newer release.
+package direct
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hmziqrs/maziq/internal/fetch"
+	"github.com/hmziqrs/maziq/internal/resource"
+	"github.com/hmziqrs/maziq/internal/sbom"
+	"github.com/hmziqrs/maziq/internal/templates"
+)
+
+// Kind is the resource kind for directly installed apps.
+const Kind = "app"
+
+// AppsDir is where app bundles are installed.
+const AppsDir = "/Applications"
+
+func init() {
+	resource.Register(build)
+}
+
+func build(env *resource.Env) ([]resource.Resource, error) {
+	apps, err := Apps(env)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]resource.Resource, len(apps))
+	for i, a := range apps {
+		out[i] = a
+	}
+	return out, nil
+}
+
+// Apps returns the template's apps that apply to this machine.
+func Apps(env *resource.Env) ([]*App, error) {
+	var out []*App
+	for _, a := range env.Template.Apps {
+		ok, err := env.Holds(a.When)
+		if err != nil {
+			return nil, fmt.Errorf("apps %q: when: %w", a.Name, err)
+		}
+		if !ok {
+			continue
+		}
+		if a.URL == "" {
+			return nil, fmt.Errorf("apps %q: url is required", a.Name)
+		}
+		if a.App == "" && a.PkgID == "" {
+			return nil, fmt.Errorf("apps %q: set app or pkg_id so MazIQ can tell it is installed", a.Name)
+		}
+		out = append(out, &App{spec: a})
+	}
+	return out, nil
+}
+
+// App is one directly installed app.
+type App struct {
+	spec templates.DirectApp
+}
+
+// ID implements resource.Resource.
+func (a *App) ID() string { return resource.ID(Kind, a.spec.Name) }
+
+// Scope implements resource.Scoper.
+func (a *App) Scope() string { return resource.ScopeSystem }
+
+// Describe implements resource.Resource.
+func (a *App) Describe() string {
+	return "install " + a.spec.Name + " from " + a.spec.URL
+}
+
+// Name returns the template name of the app.
+func (a *App) Name() string { return a.spec.Name }
+
+// Path returns where the app bundle is installed, or "" for pkgs without
+// one.
+func (a *App) Path() string {
+	if a.spec.App == "" {
+		return ""
+	}
+	return filepath.Join(AppsDir, a.spec.App)
+}
+
+// Installed returns the installed version, or ok false when the app is not
+// installed. The version is empty when the bundle does not declare one.
+func (a *App) Installed(ctx context.Context, env *resource.Env) (version string, ok bool, err error) {
+	if path := a.Path(); path != "" {
+		if _, err := os.Stat(path); err != nil {
+			return "", false, nil
+		}
+		return BundleVersion(ctx, env.Runner, path), true, nil
+	}
+	return pkgVersion(ctx, env, a.spec.PkgID)
+}
+
+// Latest asks the version URL for the newest release, or returns "" when the
+// app has none.
+func (a *App) Latest(ctx context.Context, env *resource.Env) (string, error) {
+	if a.spec.VersionURL == "" {
+		return "", nil
+	}
+	return LatestVersion(ctx, env.Expand(a.spec.VersionURL), a.spec.VersionKey)
+}
+
+// Verify implements resource.Verifier: the bundle or installer receipt has
+// to be there; the version URL is not asked.
+func (a *App) Verify(ctx context.Context, env *resource.Env) (resource.State, error) {
+	state := resource.State{Desired: "installed", Current: "not installed"}
+	version, ok, err := a.Installed(ctx, env)
+	if err != nil || !ok {
+		return state, err
+	}
+	state.Current, state.Converged = "installed"+suffix(version), true
+	return state, nil
+}
+
+// Check implements resource.Resource. A failed version lookup does not fail
+// the check; the app just cannot be upgraded this run.
+func (a *App) Check(ctx context.Context, env *resource.Env) (resource.State, error) {
+	state := resource.State{Desired: "installed"}
+	version, ok, err := a.Installed(ctx, env)
+	if err != nil {
+		return state, err
+	}
+	if !ok {
+		state.Current = "not installed"
+		rec, managed, err := Lookup(a.spec.Name)
+		if err != nil {
+			return state, err
+		}
+		if managed {
+			state.Current = "removed since MazIQ installed " + orUnknown(rec.Version)
+		}
+		return state, nil
+	}
+	state.Current = "installed" + suffix(version)
+	state.Converged = true
+	latest, err := a.Latest(ctx, env)
+	switch {
+	case err != nil:
+		state.Warning = "could not check for updates: " + err.Error()
+	case latest != "" && version != "" && normalize(latest) != normalize(version):
+		state.Converged = false
+		state.Desired = latest
+	}
+	if state.Converged {
+		state.Desired = state.Current
+	}
+	return state, nil
+}
+
+// Component implements resource.Componenter. The download URL is the one
+// MazIQ installed from, when it did.
+func (a *App) Component(ctx context.Context, env *resource.Env) (resource.Component, bool, error) {
+	version, ok, err := a.Installed(ctx, env)
+	if err != nil || !ok {
+		return resource.Component{}, false, err
+	}
+	rec, _, err := Lookup(a.spec.Name)
+	if err != nil {
+		return resource.Component{}, false, err
+	}
+	c := resource.Component{ID: a.ID(), Name: a.spec.Name, Version: version, URL: rec.URL, DownloadSHA256: a.spec.SHA256}
+	if path := a.Path(); path != "" {
+		c.Paths = []string{path}
+	}
+	var q url.Values
+	if c.URL != "" {
+		q = url.Values{"download_url": {c.URL}}
+		if u, err := url.Parse(c.URL); err == nil {
+			c.Supplier = u.Hostname()
+		}
+	}
+	c.PURL = sbom.PURL("generic", a.spec.Name, version, q)
+	return c, true, nil
+}
+
+// source returns the download of the latest version and what pins it.
+func (a *App) source(ctx context.Context, env *resource.Env) (url string, in fetch.Integrity, latest string, err error) {
+	if latest, err = a.Latest(ctx, env); err != nil {
+		return
+	}
+	if strings.Contains(a.spec.URL, templates.VersionPlaceholder) && latest == "" {
+		err = fmt.Errorf("url has %s but no version_url says what it is", templates.VersionPlaceholder)
+		return
+	}
+	url = expandVersion(env.Expand(a.spec.URL), latest)
+	in = fetch.Integrity(a.spec.Integrity)
+	in.Signature = expandVersion(env.Expand(in.Signature), latest)
+	return
+}
+
+// Fetch implements resource.Fetcher.
+func (a *App) Fetch(ctx context.Context, env *resource.Env) error {
+	url, in, _, err := a.source(ctx, env)
+	if err != nil {
+		return err
+	}
+	file, cleanup, err := fetch.TempDownload(ctx, url)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	return fetch.Verify(ctx, env.Runner, file, in)
+}
+
+// Apply implements resource.Resource. It installs the latest version, or
+// upgrades in place when an older one is installed.
+func (a *App) Apply(ctx context.Context, env *resource.Env) error {
+	url, in, latest, err := a.source(ctx, env)
+	if err != nil {
+		return err
+	}
+	prev, upgrade, err := a.Installed(ctx, env)
+	if err != nil {
+		return err
+	}
+
+	rec, err := install(ctx, env, a, url, in)
+	if err != nil {
+		return err
+	}
+	if rec.Version == "" {
+		rec.Version = latest
+	}
+	action := "install"
+	if upgrade {
+		action = "upgrade"
+		env.Log("upgraded %s from %s to %s", a.spec.Name, orUnknown(prev), orUnknown(rec.Version))
+	} else {
+		env.Log("installed %s %s", a.spec.Name, rec.Version)
+	}
+	return record(a.spec.Name, rec, action)
+}
+
+func expandVersion(s, version string) string {
+	return strings.ReplaceAll(s, templates.VersionPlaceholder, version)
+}
+
+// normalize drops a leading "v" so tags and bundle versions compare equal.
+func normalize(v string) string {
+	return strings.TrimPrefix(strings.TrimSpace(v), "v")
+}
+
+func suffix(v string) string {
+	if v == "" {
+		return ""
+	}
+	return " (" + v + ")"
+}
+
+func orUnknown(v string) string {
+	if v == "" {
+		return "unknown version"
+	}
+	return v
+}
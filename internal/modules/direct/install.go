@@ -0,0 +1,244 @@
+package direct
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/hmziqrs/maziq/internal/fetch"
+	"github.com/hmziqrs/maziq/internal/gatekeeper"
+	"github.com/hmziqrs/maziq/internal/modules/software"
+	"github.com/hmziqrs/maziq/internal/resource"
+	"github.com/hmziqrs/maziq/internal/shell"
+)
+
+// install downloads url, verifies it and installs what it contains: the
+// app bundle is copied into AppsDir and pkgs are run through installer.
+func install(ctx context.Context, env *resource.Env, a *App, url string, in fetch.Integrity) (Record, error) {
+	rec := Record{URL: url, App: a.Path()}
+	file, cleanup, err := fetch.TempDownload(ctx, url)
+	if err != nil {
+		return rec, err
+	}
+	defer cleanup()
+	if err := fetch.Verify(ctx, env.Runner, file, in); err != nil {
+		return rec, err
+	}
+	// Go's HTTP client does not set the quarantine attribute a browser
+	// would, so Gatekeeper would never look at the app.
+	if err := gatekeeper.Quarantine(ctx, env.Runner, file, "maziq"); err != nil {
+		env.Log("could not quarantine %s: %v", filepath.Base(file), err)
+	}
+
+	switch ext := strings.ToLower(filepath.Ext(file)); ext {
+	case ".dmg":
+		mount := filepath.Join(filepath.Dir(file), "mount")
+		// "Y" accepts the license agreement some images show.
+		if _, err := env.Run(ctx, shell.Command{Name: "hdiutil", Args: []string{"attach", "-nobrowse", "-noautoopen", "-readonly", "-mountpoint", mount, file}, Stdin: "Y\n"}); err != nil {
+			return rec, err
+		}
+		defer env.Run(context.WithoutCancel(ctx), shell.Cmd("hdiutil", "detach", "-force", mount))
+		err = installFrom(ctx, env, a, mount, &rec)
+	case ".zip":
+		dir := filepath.Join(filepath.Dir(file), "unzipped")
+		if _, err := env.Run(ctx, shell.Cmd("ditto", "-x", "-k", file, dir)); err != nil {
+			return rec, err
+		}
+		err = installFrom(ctx, env, a, dir, &rec)
+	case ".pkg", ".mpkg":
+		err = installPkg(ctx, env, a, file, &rec)
+	default:
+		err = fmt.Errorf("do not know how to install a %q download; expected dmg, pkg or zip", ext)
+	}
+	if err != nil {
+		return rec, err
+	}
+	if rec.App != "" {
+		rec.Version = BundleVersion(ctx, env.Runner, rec.App)
+		software.Release(ctx, env, rec.App)
+	}
+	return rec, nil
+}
+
+// installFrom installs from a mounted image or unpacked archive: the app
+// bundle when it has one, else the first pkg in it.
+func installFrom(ctx context.Context, env *resource.Env, a *App, dir string, rec *Record) error {
+	if a.spec.App != "" {
+		src, err := findBundle(dir, a.spec.App)
+		if err != nil {
+			return err
+		}
+		if src != "" {
+			return copyApp(ctx, env, src, a.Path())
+		}
+	}
+	pkgs, _ := filepath.Glob(filepath.Join(dir, "*.pkg"))
+	if len(pkgs) == 0 {
+		pkgs, _ = filepath.Glob(filepath.Join(dir, "*.mpkg"))
+	}
+	if len(pkgs) == 0 {
+		return fmt.Errorf("%s contains neither %s nor a pkg", filepath.Base(a.spec.URL), a.spec.App)
+	}
+	return installPkg(ctx, env, a, pkgs[0], rec)
+}
+
+// findBundle looks for name at the top of dir and one level down, where
+// archives often put it.
+func findBundle(dir, name string) (string, error) {
+	if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+		return filepath.Join(dir, name), nil
+	}
+	matches, err := filepath.Glob(filepath.Join(dir, "*", name))
+	if err != nil || len(matches) == 0 {
+		return "", err
+	}
+	return matches[0], nil
+}
+
+// copyApp replaces dest with the bundle at src. ditto keeps the signature,
+// extended attributes and symlinks inside the bundle intact.
+func copyApp(ctx context.Context, env *resource.Env, src, dest string) error {
+	sudo := !writable(filepath.Dir(dest))
+	if _, err := os.Stat(dest); err == nil {
+		if _, err := env.Run(ctx, shell.Command{Name: "rm", Args: []string{"-rf", dest}, Sudo: sudo}); err != nil {
+			return err
+		}
+	}
+	_, err := env.Run(ctx, shell.Command{Name: "ditto", Args: []string{src, dest}, Sudo: sudo})
+	return err
+}
+
+// installPkg runs the macOS installer on pkg and records the receipts it
+// added, so uninstall can forget them.
+func installPkg(ctx context.Context, env *resource.Env, a *App, pkg string, rec *Record) error {
+	before, err := receipts(ctx, env)
+	if err != nil {
+		return err
+	}
+	if _, err := env.Run(ctx, shell.Command{Name: "installer", Args: []string{"-pkg", pkg, "-target", "/"}, Sudo: true}); err != nil {
+		return err
+	}
+	after, err := receipts(ctx, env)
+	if err != nil {
+		return err
+	}
+	for _, id := range after {
+		if !slices.Contains(before, id) {
+			rec.Pkgs = append(rec.Pkgs, id)
+		}
+	}
+	// An upgrade reinstalls receipts that were already there.
+	prev, ok, err := Lookup(a.spec.Name)
+	if err != nil {
+		return err
+	}
+	if ok {
+		for _, id := range prev.Pkgs {
+			if !slices.Contains(rec.Pkgs, id) {
+				rec.Pkgs = append(rec.Pkgs, id)
+			}
+		}
+	}
+	if a.spec.PkgID != "" && !slices.Contains(rec.Pkgs, a.spec.PkgID) {
+		rec.Pkgs = append(rec.Pkgs, a.spec.PkgID)
+	}
+	if rec.App != "" {
+		if _, err := os.Stat(rec.App); err != nil {
+			return fmt.Errorf("installer finished but %s is missing", rec.App)
+		}
+	} else {
+		rec.Version, _, _ = pkgVersion(ctx, env, a.spec.PkgID)
+	}
+	return nil
+}
+
+// receipts lists the installer receipts on the machine.
+func receipts(ctx context.Context, env *resource.Env) ([]string, error) {
+	res, err := env.Run(ctx, shell.Cmd("pkgutil", "--pkgs"))
+	if err != nil {
+		return nil, err
+	}
+	return strings.Fields(res.Stdout), nil
+}
+
+// pkgVersion returns the version of the installer receipt id, with ok false
+// when there is no such receipt.
+func pkgVersion(ctx context.Context, env *resource.Env, id string) (version string, ok bool, err error) {
+	res, err := env.Run(ctx, shell.Cmd("pkgutil", "--pkg-info", id))
+	if err != nil {
+		if isExit(err) {
+			return "", false, nil
+		}
+		return "", false, err
+	}
+	for _, line := range strings.Split(res.Stdout, "\n") {
+		if v, ok := strings.CutPrefix(line, "version: "); ok {
+			return strings.TrimSpace(v), true, nil
+		}
+	}
+	return "", true, nil
+}
+
+// BundleVersion returns the short version string of the app bundle at path,
+// or "" when it has none.
+func BundleVersion(ctx context.Context, r shell.Runner, path string) string {
+	res, err := r.Run(ctx, shell.Cmd("defaults", "read", filepath.Join(path, "Contents", "Info"), "CFBundleShortVersionString"))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(res.Stdout)
+}
+
+// LatestVersion fetches url and returns the version it reports: the whole
+// body, or the value under key when the body is JSON. Dots in key descend
+// into nested objects.
+func LatestVersion(ctx context.Context, url, key string) (string, error) {
+	if key != "" {
+		var doc any
+		if err := fetch.JSON(ctx, url, &doc); err != nil {
+			return "", err
+		}
+		for _, part := range strings.Split(key, ".") {
+			m, ok := doc.(map[string]any)
+			if !ok {
+				return "", fmt.Errorf("%s: no %q in response", url, key)
+			}
+			doc = m[part]
+		}
+		switch v := doc.(type) {
+		case string:
+			return strings.TrimSpace(v), nil
+		case float64:
+			return fmt.Sprint(v), nil
+		}
+		return "", fmt.Errorf("%s: %q is not a version", url, key)
+	}
+	body, err := fetch.Text(ctx, url)
+	if err != nil {
+		return "", err
+	}
+	v := strings.TrimSpace(body)
+	if v == "" || strings.ContainsAny(v, " \n<{") {
+		return "", fmt.Errorf("%s does not return a bare version; set version_key for JSON", url)
+	}
+	return v, nil
+}
+
+// writable reports whether the current user can create files in dir.
+func writable(dir string) bool {
+	f, err := os.CreateTemp(dir, ".maziq-*")
+	if err != nil {
+		return false
+	}
+	f.Close()
+	os.Remove(f.Name())
+	return true
+}
+
+func isExit(err error) bool {
+	return errors.As(err, new(*shell.ExitError))
+}
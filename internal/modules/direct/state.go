@@ -0,0 +1,111 @@
+package direct
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/hmziqrs/maziq/internal/config"
+	"github.com/hmziqrs/maziq/internal/history"
+	"github.com/hmziqrs/maziq/internal/resource"
+	"github.com/hmziqrs/maziq/internal/shell"
+	"github.com/hmziqrs/maziq/internal/state"
+)
+
+// Record is what MazIQ remembers about an app it installed directly, since
+// neither brew nor the App Store will.
+type Record struct {
+	// App is the installed bundle, empty for pkgs without one.
+	App     string `json:"app,omitempty"`
+	Version string `json:"version,omitempty"`
+	URL     string `json:"url"`
+	// Pkgs are the installer receipts the install added.
+	Pkgs      []string `json:"pkgs,omitempty"`
+	Installed int64    `json:"installed"`
+}
+
+// stateKey is the state store prefix for the records.
+const stateKey = "apps/"
+
+// legacyPath is where records were kept before the state store.
+func legacyPath() string {
+	return filepath.Join(config.Dir(), "apps.json")
+}
+
+// Load returns the records by template app name.
+func Load() (map[string]Record, error) {
+	if err := state.Import(legacyPath(), stateKey); err != nil {
+		return nil, err
+	}
+	return state.List[Record](stateKey)
+}
+
+// Lookup returns the record for the app named name.
+func Lookup(name string) (Record, bool, error) {
+	if err := state.Import(legacyPath(), stateKey); err != nil {
+		return Record{}, false, err
+	}
+	var rec Record
+	ok, err := state.Get(stateKey+name, &rec)
+	return rec, ok, err
+}
+
+// Names returns the names of the apps MazIQ installed, sorted.
+func Names() ([]string, error) {
+	m, err := Load()
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// record stores rec under name and appends it to the install history.
+func record(name string, rec Record, action string) error {
+	rec.Installed = time.Now().Unix()
+	if err := state.Put(stateKey+name, rec); err != nil {
+		return err
+	}
+	return history.Append(history.Record{Software: resource.ID(Kind, name), Action: action, Version: rec.Version, Source: rec.URL})
+}
+
+// Uninstall removes an app MazIQ installed: the bundle is deleted and the
+// installer receipts are forgotten. Files a pkg put elsewhere stay; macOS
+// keeps no reliable list of what is safe to remove.
+func Uninstall(ctx context.Context, env *resource.Env, name string) error {
+	rec, ok, err := Lookup(name)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("%q was not installed by MazIQ", name)
+	}
+	if rec.App != "" {
+		if _, err := os.Stat(rec.App); err == nil {
+			sudo := !writable(filepath.Dir(rec.App))
+			if _, err := env.Run(ctx, shell.Command{Name: "rm", Args: []string{"-rf", rec.App}, Sudo: sudo}); err != nil {
+				return err
+			}
+		}
+	}
+	for _, id := range rec.Pkgs {
+		if _, ok, err := pkgVersion(ctx, env, id); err != nil || !ok {
+			continue
+		}
+		if _, err := env.Run(ctx, shell.Command{Name: "pkgutil", Args: []string{"--forget", id}, Sudo: true}); err != nil {
+			return err
+		}
+	}
+	if err := state.Delete(stateKey + name); err != nil {
+		return err
+	}
+	env.Log("uninstalled %s", name)
+	return history.Append(history.Record{Software: resource.ID(Kind, name), Action: "uninstall", Version: rec.Version, Source: rec.URL})
+}
@@ -0,0 +1,184 @@
+// Package directories creates the workspace skeleton declared under
+// `[[directories]]` and keeps the directories that ask for it out of
+// iCloud Drive. Spotlight exclusions for them are added by the spotlight
+// module.
+package directories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/hmziqrs/maziq/internal/resource"
+	"github.com/hmziqrs/maziq/internal/shell"
+	"github.com/hmziqrs/maziq/internal/templates"
+)
+
+// Kind is the resource kind for directories.
+const Kind = "directory"
+
+// ICloudIgnore is the extended attribute that keeps a file or directory in
+// Desktop or Documents from syncing to iCloud Drive.
+const ICloudIgnore = "com.apple.fileprovider.ignore#P"
+
+func init() {
+	resource.Register(build)
+}
+
+func build(env *resource.Env) ([]resource.Resource, error) {
+	var out []resource.Resource
+	seen := map[string]bool{}
+	for _, spec := range env.Template.Directories {
+		ok, err := env.Holds(spec.When)
+		if err != nil {
+			return nil, fmt.Errorf("directories %q: when: %w", spec.Path, err)
+		}
+		if !ok {
+			continue
+		}
+		var mode os.FileMode
+		if spec.Mode != "" {
+			m, err := strconv.ParseUint(spec.Mode, 8, 32)
+			switch {
+			case err != nil || m > 0o7777:
+				return nil, fmt.Errorf("directories %q: invalid mode %q", spec.Path, spec.Mode)
+			case m > 0o777:
+				// os.FileMode keeps these bits elsewhere; see the files module.
+				return nil, fmt.Errorf("directories %q: mode %q: setuid, setgid and sticky bits are not supported", spec.Path, spec.Mode)
+			}
+			mode = os.FileMode(m)
+		}
+		for _, p := range Paths(env, spec) {
+			if seen[p] {
+				continue
+			}
+			seen[p] = true
+			out = append(out, &Directory{path: p, mode: mode, icloud: spec.ExcludeICloud})
+		}
+	}
+	return out, nil
+}
+
+// Paths returns the directories an entry declares, with braces and
+// variables expanded.
+func Paths(env *resource.Env, spec templates.Directory) []string {
+	var out []string
+	for _, p := range templates.Braces(spec.Path) {
+		out = append(out, filepath.Clean(env.Path(p)))
+	}
+	return out
+}
+
+// ID returns the resource ID of the directory at path.
+func ID(path string) string { return resource.ID(Kind, path) }
+
+// Directory is one directory of the skeleton.
+type Directory struct {
+	path string
+	mode os.FileMode // 0 leaves the mode alone
+	// icloud keeps the directory out of iCloud Drive.
+	icloud bool
+}
+
+// ID implements resource.Resource.
+func (d *Directory) ID() string { return ID(d.path) }
+
+// Describe implements resource.Resource.
+func (d *Directory) Describe() string {
+	desc := "create " + d.path
+	if d.icloud {
+		desc += " excluded from iCloud"
+	}
+	return desc
+}
+
+// Verify implements resource.Verifier; the check only reads the directory.
+func (d *Directory) Verify(ctx context.Context, env *resource.Env) (resource.State, error) {
+	return d.Check(ctx, env)
+}
+
+// Check implements resource.Resource.
+func (d *Directory) Check(ctx context.Context, env *resource.Env) (resource.State, error) {
+	state := resource.State{Desired: "present"}
+	if d.icloud {
+		state.Desired = "present, not in iCloud"
+	}
+	info, err := os.Stat(d.path)
+	switch {
+	case os.IsNotExist(err):
+		state.Current = "missing"
+		return state, nil
+	case err != nil:
+		return state, err
+	case !info.IsDir():
+		state.Current = "file"
+		state.Blocked = d.path + " is a file"
+		return state, nil
+	case d.mode != 0 && info.Mode().Perm() != d.mode.Perm():
+		state.Current = fmt.Sprintf("mode %04o", info.Mode().Perm())
+		return state, nil
+	}
+	if d.icloud {
+		ignored, err := d.ignored(ctx, env)
+		if err != nil {
+			return state, err
+		}
+		if !ignored {
+			state.Current = "synced to iCloud"
+			return state, nil
+		}
+	}
+	state.Current, state.Converged = state.Desired, true
+	return state, nil
+}
+
+// ignored reports whether the directory carries ICloudIgnore.
+func (d *Directory) ignored(ctx context.Context, env *resource.Env) (bool, error) {
+	_, err := env.Run(ctx, shell.Cmd("xattr", "-p", ICloudIgnore, d.path))
+	if errors.As(err, new(*shell.ExitError)) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Apply implements resource.Resource.
+func (d *Directory) Apply(ctx context.Context, env *resource.Env) error {
+	mode := d.mode
+	if mode == 0 {
+		mode = 0o755
+	}
+	if err := os.MkdirAll(d.path, mode); err != nil {
+		return err
+	}
+	if d.mode != 0 {
+		if err := os.Chmod(d.path, d.mode); err != nil {
+			return err
+		}
+	}
+	if d.icloud {
+		_, err := env.Run(ctx, shell.Cmd("xattr", "-w", ICloudIgnore, "1", d.path))
+		return err
+	}
+	return nil
+}
+
+// Export implements resource.Exporter.
+func (d *Directory) Export(ctx context.Context, env *resource.Env) (resource.Step, error) {
+	path := shell.Quote(d.path)
+	step := resource.Step{
+		Check:    "[ -d " + path + " ]",
+		Commands: []shell.Command{shell.Cmd("mkdir", "-p", d.path)},
+	}
+	if d.mode != 0 {
+		step.Check += fmt.Sprintf(" && [ \"$(stat -f %%Lp %s)\" = %o ]", path, d.mode.Perm())
+		step.Commands = append(step.Commands, shell.Cmd("chmod", fmt.Sprintf("%04o", d.mode), d.path))
+	}
+	if d.icloud {
+		step.Check += " && xattr -p " + shell.Quote(ICloudIgnore) + " " + path + " >/dev/null 2>&1"
+		step.Commands = append(step.Commands, shell.Cmd("xattr", "-w", ICloudIgnore, "1", d.path))
+	}
+	return step, nil
+}
@@ -0,0 +1,254 @@
+// Package direnv hooks direnv into the login shell and writes and allows
+// per-project .envrc files, filling in values from the secrets provider.
+package direnv
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hmziqrs/maziq/internal/modules/repos"
+	"github.com/hmziqrs/maziq/internal/modules/software"
+	"github.com/hmziqrs/maziq/internal/resource"
+	"github.com/hmziqrs/maziq/internal/shell"
+	"github.com/hmziqrs/maziq/internal/templates"
+)
+
+// Kind is the resource kind for direnv settings.
+const Kind = "direnv"
+
+// header marks .envrc files maziq owns.
+const header = "# Managed by maziq; local edits are overwritten.\n"
+
+func init() {
+	resource.Register(build)
+}
+
+func build(env *resource.Env) ([]resource.Resource, error) {
+	spec := env.Template.Direnv
+	var out []resource.Resource
+	for i, p := range spec.Projects {
+		ok, err := env.Holds(p.When)
+		if err != nil {
+			return nil, fmt.Errorf("direnv.project[%d]: when: %w", i, err)
+		}
+		if !ok {
+			continue
+		}
+		if p.Path == "" {
+			return nil, fmt.Errorf("direnv.project[%d]: path is required", i)
+		}
+		out = append(out, &Project{spec: p, dir: env.Path(p.Path)})
+	}
+	if spec.Hook || len(out) > 0 {
+		out = append([]resource.Resource{newHook(env)}, out...)
+	}
+	return out, nil
+}
+
+// Hook adds `direnv hook` to the login shell's rc file.
+type Hook struct {
+	rc   string
+	line string
+}
+
+func newHook(env *resource.Env) *Hook {
+	switch env.Facts["shell"] {
+	case "bash":
+		return &Hook{rc: env.Path("~/.bash_profile"), line: `eval "$(direnv hook bash)"`}
+	case "fish":
+		return &Hook{rc: env.Path("~/.config/fish/config.fish"), line: "direnv hook fish | source"}
+	default:
+		return &Hook{rc: env.Path("~/.zshrc"), line: `eval "$(direnv hook zsh)"`}
+	}
+}
+
+// ID implements resource.Resource.
+func (h *Hook) ID() string { return resource.ID(Kind, "hook") }
+
+// Describe implements resource.Resource.
+func (h *Hook) Describe() string { return "hook direnv into " + h.rc }
+
+// Requires implements resource.Requirer.
+func (h *Hook) Requires() []string {
+	return []string{resource.ID(software.Kind, "direnv")}
+}
+
+// Check implements resource.Resource.
+func (h *Hook) Check(ctx context.Context, env *resource.Env) (resource.State, error) {
+	state := resource.State{Desired: "hooked"}
+	data, err := os.ReadFile(h.rc)
+	if err != nil && !os.IsNotExist(err) {
+		return state, err
+	}
+	if strings.Contains(string(data), "direnv hook") {
+		state.Current, state.Converged = "hooked", true
+	} else {
+		state.Current = "not hooked"
+	}
+	return state, nil
+}
+
+// Apply implements resource.Resource.
+func (h *Hook) Apply(ctx context.Context, env *resource.Env) error {
+	if err := os.MkdirAll(filepath.Dir(h.rc), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(h.rc, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(h.block()); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// block is what Apply appends to the rc file.
+func (h *Hook) block() string { return "\n# Added by maziq\n" + h.line + "\n" }
+
+// Diff implements resource.Differ.
+func (h *Hook) Diff(ctx context.Context, env *resource.Env) (resource.FileChange, error) {
+	c := resource.FileChange{Path: h.rc}
+	current, err := os.ReadFile(h.rc)
+	if err != nil && !os.IsNotExist(err) {
+		return c, err
+	}
+	c.Current, c.Desired = current, append(append([]byte{}, current...), h.block()...)
+	return c, nil
+}
+
+// Project is one directory with an allowed .envrc.
+type Project struct {
+	spec templates.DirenvProject
+	dir  string
+}
+
+// ID implements resource.Resource.
+func (p *Project) ID() string { return resource.ID(Kind, p.dir) }
+
+// Describe implements resource.Resource.
+func (p *Project) Describe() string {
+	if p.spec.Managed() {
+		return "write and allow " + p.envrc()
+	}
+	return "allow " + p.envrc()
+}
+
+// Requires implements resource.Requirer. A project cloned by the repos
+// module is provisioned after the clone.
+func (p *Project) Requires() []string {
+	return []string{
+		resource.ID(software.Kind, "direnv"),
+		resource.ID(Kind, "hook"),
+		resource.ID(repos.Kind, p.dir),
+	}
+}
+
+func (p *Project) envrc() string { return filepath.Join(p.dir, ".envrc") }
+
+// Check implements resource.Resource.
+func (p *Project) Check(ctx context.Context, env *resource.Env) (resource.State, error) {
+	state := resource.State{Desired: "allowed"}
+	have, err := os.ReadFile(p.envrc())
+	switch {
+	case os.IsNotExist(err):
+		// A repo cloned in the same run may bring its own .envrc.
+		state.Current = "missing"
+		return state, nil
+	case err != nil:
+		return state, err
+	}
+	if p.spec.Managed() {
+		want, err := p.render(ctx, env)
+		if err != nil {
+			return state, err
+		}
+		if !bytes.Equal(have, want) {
+			state.Current = "differs"
+			return state, nil
+		}
+	}
+	if !allowed(env, p.envrc(), have) {
+		state.Current = "not allowed"
+		return state, nil
+	}
+	state.Current, state.Converged = "allowed", true
+	return state, nil
+}
+
+// Apply implements resource.Resource. The file holds secrets, so it is only
+// readable by the user.
+func (p *Project) Apply(ctx context.Context, env *resource.Env) error {
+	if p.spec.Managed() {
+		data, err := p.render(ctx, env)
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(p.dir, 0o755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(p.envrc(), data, 0o600); err != nil {
+			return err
+		}
+		if err := os.Chmod(p.envrc(), 0o600); err != nil {
+			return err
+		}
+	} else if _, err := os.Stat(p.envrc()); err != nil {
+		return fmt.Errorf("nothing to allow: %w", err)
+	}
+	_, err := env.Run(ctx, shell.Cmd("direnv", "allow", p.dir))
+	return err
+}
+
+// render builds the .envrc: the content block, then env and secret exports
+// sorted by name.
+func (p *Project) render(ctx context.Context, env *resource.Env) ([]byte, error) {
+	var b strings.Builder
+	b.WriteString(header)
+	if p.spec.Content != "" {
+		b.WriteString(env.Expand(strings.TrimRight(p.spec.Content, "\n")))
+		b.WriteString("\n")
+	}
+	vars := map[string]string{}
+	for k, v := range p.spec.Env {
+		vars[k] = env.Expand(v)
+	}
+	for k, name := range p.spec.Secrets {
+		v, err := env.Secrets.Get(ctx, name)
+		if err != nil {
+			return nil, fmt.Errorf("direnv %s: %s: %w", p.dir, k, err)
+		}
+		vars[k] = v
+	}
+	names := make([]string, 0, len(vars))
+	for k := range vars {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	for _, k := range names {
+		fmt.Fprintf(&b, "export %s=%s\n", k, shell.Quote(vars[k]))
+	}
+	return []byte(b.String()), nil
+}
+
+// allowed mirrors direnv's allow list: a file named after the SHA-256 of the
+// .envrc path and its content under $XDG_DATA_HOME/direnv/allow.
+func allowed(env *resource.Env, envrc string, content []byte) bool {
+	h := sha256.New()
+	h.Write([]byte(envrc + "\n"))
+	h.Write(content)
+	data := os.Getenv("XDG_DATA_HOME")
+	if data == "" {
+		data = env.Path("~/.local/share")
+	}
+	_, err := os.Stat(filepath.Join(data, "direnv", "allow", hex.EncodeToString(h.Sum(nil))))
+	return err == nil
+}
@@ -0,0 +1,277 @@
+// Package dotfile keeps files whose whole content maziq owns. Modules that
+// render configuration (terminal emulators, tmux, hotkey daemons) build
+// Files; a local edit shows up as drift and apply puts the rendered
+// content back.
+package dotfile
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/hmziqrs/maziq/internal/resource"
+	"github.com/hmziqrs/maziq/internal/shell"
+)
+
+// Kind is the resource kind for managed files.
+const Kind = "dotfile"
+
+// Header opens rendered config files that take # comments.
+const Header = "# Managed by maziq; local edits are overwritten.\n"
+
+// BackupSuffix is appended to the path of a file maziq overwrites for the
+// first time.
+const BackupSuffix = ".maziq.bak"
+
+// File is one managed file.
+type File struct {
+	Path    string
+	Content []byte
+	Mode    os.FileMode
+	// Label names what the file configures in plan output, e.g. "Ghostty".
+	Label string
+	// Needs lists resource IDs that must be applied first.
+	Needs []string
+	// Reload is a bash script run after the file changes so the program
+	// using it picks up the new content. Its failure is only logged: the
+	// program may simply not be running yet.
+	Reload string
+}
+
+// New returns a managed file with mode 0644.
+func New(path string, content []byte) *File {
+	return &File{Path: path, Content: content, Mode: 0o644}
+}
+
+// ID implements resource.Resource.
+func (f *File) ID() string { return resource.ID(Kind, f.Path) }
+
+// Describe implements resource.Resource.
+func (f *File) Describe() string {
+	if f.Label != "" {
+		return "write " + f.Label + " config " + f.Path
+	}
+	return "write " + f.Path
+}
+
+// Requires implements resource.Requirer.
+func (f *File) Requires() []string { return f.Needs }
+
+// Verify implements resource.Verifier; the check only reads the file.
+func (f *File) Verify(ctx context.Context, env *resource.Env) (resource.State, error) {
+	return f.Check(ctx, env)
+}
+
+// Check implements resource.Resource. A symlink is left alone: it belongs to
+// another dotfiles manager and overwriting it would edit that repository.
+func (f *File) Check(ctx context.Context, env *resource.Env) (resource.State, error) {
+	state := resource.State{Desired: "managed"}
+	info, err := os.Lstat(f.Path)
+	switch {
+	case os.IsNotExist(err):
+		state.Current = "missing"
+		return state, nil
+	case err != nil:
+		return state, err
+	case info.Mode()&os.ModeSymlink != 0:
+		state.Current = "symlink"
+		state.Blocked = f.Path + " is a symlink; remove it to let maziq manage the file"
+		return state, nil
+	}
+	have, err := os.ReadFile(f.Path)
+	if err != nil {
+		return state, err
+	}
+	if !bytes.Equal(have, f.Content) {
+		state.Current = "drifted"
+		return state, nil
+	}
+	if f.Mode != 0 && info.Mode().Perm() != f.Mode {
+		state.Current = fmt.Sprintf("mode %o", info.Mode().Perm())
+		return state, nil
+	}
+	state.Current, state.Converged = "managed", true
+	return state, nil
+}
+
+// Apply implements resource.Resource. The first time an existing file is
+// replaced its content is kept next to it with BackupSuffix.
+func (f *File) Apply(ctx context.Context, env *resource.Env) error {
+	if err := os.MkdirAll(filepath.Dir(f.Path), 0o755); err != nil {
+		return err
+	}
+	if old, err := os.ReadFile(f.Path); err == nil {
+		backup := f.Path + BackupSuffix
+		if _, err := os.Stat(backup); os.IsNotExist(err) {
+			if err := os.WriteFile(backup, old, 0o600); err != nil {
+				return fmt.Errorf("backup: %w", err)
+			}
+			env.Log("saved the previous %s to %s", f.Path, backup)
+		}
+	}
+	mode := f.Mode
+	if mode == 0 {
+		mode = 0o644
+	}
+	tmp := f.Path + ".maziq.tmp"
+	if err := os.WriteFile(tmp, f.Content, mode); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp, mode); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, f.Path); err != nil {
+		return err
+	}
+	if f.Reload != "" {
+		if _, err := env.Run(ctx, shell.Script(f.Reload)); err != nil {
+			env.Log("reload after writing %s: %v", f.Path, err)
+		}
+	}
+	return nil
+}
+
+// Diff implements resource.Differ.
+func (f *File) Diff(ctx context.Context, env *resource.Env) (resource.FileChange, error) {
+	c := resource.FileChange{Path: f.Path, Desired: f.Content}
+	current, err := os.ReadFile(f.Path)
+	if err != nil && !os.IsNotExist(err) {
+		return c, err
+	}
+	c.Current = current
+	return c, nil
+}
+
+// Merge implements resource.Merger.
+func (f *File) Merge(content []byte) error {
+	f.Content = content
+	return nil
+}
+
+// Undo implements resource.Reverter: the file and its backup go back to
+// how they were.
+func (f *File) Undo(ctx context.Context, env *resource.Env) (resource.Undo, error) {
+	u := resource.Undo{ID: f.ID()}
+	for _, path := range []string{f.Path, f.Path + BackupSuffix} {
+		saved, err := resource.SaveFile(path)
+		if err != nil {
+			return u, err
+		}
+		u.Files = append(u.Files, saved)
+	}
+	return u, nil
+}
+
+// Link is a symlink to a file kept elsewhere, usually in a dotfiles
+// repository.
+type Link struct {
+	Path   string
+	Target string
+	Label  string
+	Needs  []string
+}
+
+// ID implements resource.Resource.
+func (l *Link) ID() string { return resource.ID(Kind, l.Path) }
+
+// Describe implements resource.Resource.
+func (l *Link) Describe() string {
+	if l.Label != "" {
+		return "link " + l.Label + " config " + l.Path + " → " + l.Target
+	}
+	return "link " + l.Path + " → " + l.Target
+}
+
+// Requires implements resource.Requirer.
+func (l *Link) Requires() []string { return l.Needs }
+
+// Verify implements resource.Verifier; the check only reads the link.
+func (l *Link) Verify(ctx context.Context, env *resource.Env) (resource.State, error) {
+	return l.Check(ctx, env)
+}
+
+// Check implements resource.Resource.
+func (l *Link) Check(ctx context.Context, env *resource.Env) (resource.State, error) {
+	state := resource.State{Desired: l.Target}
+	info, err := os.Lstat(l.Path)
+	switch {
+	case os.IsNotExist(err):
+		state.Current = "missing"
+	case err != nil:
+		return state, err
+	case info.Mode()&os.ModeSymlink == 0:
+		state.Current = "regular file"
+	default:
+		target, err := os.Readlink(l.Path)
+		if err != nil {
+			return state, err
+		}
+		state.Current = target
+		state.Converged = target == l.Target
+	}
+	return state, nil
+}
+
+// Apply implements resource.Resource. A regular file in the way is moved
+// aside with BackupSuffix; a stale link is replaced.
+func (l *Link) Apply(ctx context.Context, env *resource.Env) error {
+	if err := os.MkdirAll(filepath.Dir(l.Path), 0o755); err != nil {
+		return err
+	}
+	info, err := os.Lstat(l.Path)
+	switch {
+	case os.IsNotExist(err):
+	case err != nil:
+		return err
+	case info.Mode()&os.ModeSymlink != 0:
+		if err := os.Remove(l.Path); err != nil {
+			return err
+		}
+	default:
+		backup := l.Path + BackupSuffix
+		if _, err := os.Lstat(backup); err == nil {
+			return fmt.Errorf("%s is in the way and %s already exists", l.Path, backup)
+		}
+		if err := os.Rename(l.Path, backup); err != nil {
+			return err
+		}
+		env.Log("moved the previous %s to %s", l.Path, backup)
+	}
+	return os.Symlink(l.Target, l.Path)
+}
+
+// Export implements resource.Exporter. The file is compared by checksum;
+// the script writes it without the backup Apply keeps.
+func (f *File) Export(ctx context.Context, env *resource.Env) (resource.Step, error) {
+	mode := f.Mode
+	if mode == 0 {
+		mode = 0o644
+	}
+	sum := sha256.Sum256(f.Content)
+	step := resource.Step{
+		Check: "[ \"$(shasum -a 256 " + shell.Quote(f.Path) + " 2>/dev/null | cut -d' ' -f1)\" = " + hex.EncodeToString(sum[:]) + " ]",
+		Files: []resource.FileContent{{Path: f.Path, Content: f.Content, Mode: mode}},
+	}
+	if f.Reload != "" {
+		step.Commands = []shell.Command{shell.Script(f.Reload + " || true")}
+	}
+	return step, nil
+}
+
+// Export implements resource.Exporter. A regular file in the way is moved
+// aside as Apply does.
+func (l *Link) Export(ctx context.Context, env *resource.Env) (resource.Step, error) {
+	path, backup := shell.Quote(l.Path), shell.Quote(l.Path+BackupSuffix)
+	return resource.Step{
+		Check: "[ \"$(readlink " + path + ")\" = " + shell.Quote(l.Target) + " ]",
+		Commands: []shell.Command{
+			shell.Cmd("mkdir", "-p", filepath.Dir(l.Path)),
+			shell.Script("if [ -e " + path + " ] && [ ! -L " + path + " ]; then mv " + path + " " + backup + "; fi"),
+			shell.Cmd("ln", "-sfn", l.Target, l.Path),
+		},
+	}, nil
+}
@@ -0,0 +1,167 @@
+// Package energy manages power settings with pmset.
+package energy
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/hmziqrs/maziq/internal/resource"
+	"github.com/hmziqrs/maziq/internal/shell"
+	"github.com/hmziqrs/maziq/internal/templates"
+)
+
+// Kind is the resource kind for power settings.
+const Kind = "energy"
+
+// scopeFlags maps template scopes to pmset flags and the `pmset -g custom`
+// sections they affect.
+var scopeFlags = map[string]struct {
+	flag     string
+	sections []string
+}{
+	"all":     {"-a", []string{"Battery Power", "AC Power"}},
+	"battery": {"-b", []string{"Battery Power"}},
+	"charger": {"-c", []string{"AC Power"}},
+}
+
+func init() {
+	resource.Register(build)
+}
+
+func build(env *resource.Env) ([]resource.Resource, error) {
+	scopes := env.Template.Energy.Scopes()
+	names := make([]string, 0, len(scopes))
+	for name := range scopes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var out []resource.Resource
+	current := &custom{}
+	for _, scope := range names {
+		keys := make([]string, 0, len(scopes[scope]))
+		for k := range scopes[scope] {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			v, err := templates.PmsetValue(scopes[scope][key])
+			if err != nil {
+				return nil, fmt.Errorf("energy.%s.%s: %w", scope, key, err)
+			}
+			out = append(out, &Setting{scope: scope, key: key, value: v, current: current})
+		}
+	}
+	return out, nil
+}
+
+// Setting is one pmset key in one scope.
+type Setting struct {
+	scope   string
+	key     string
+	value   int64
+	current *custom
+}
+
+// ID implements resource.Resource.
+func (s *Setting) ID() string { return resource.ID(Kind, s.scope+"/"+s.key) }
+
+// Scope implements resource.Scoper.
+func (s *Setting) Scope() string { return resource.ScopeSystem }
+
+// Describe implements resource.Resource.
+func (s *Setting) Describe() string {
+	return fmt.Sprintf("pmset %s %s %d", scopeFlags[s.scope].flag, s.key, s.value)
+}
+
+// Check implements resource.Resource. A scope for a power source the Mac
+// does not have (battery on a desktop) is reported as converged.
+func (s *Setting) Check(ctx context.Context, env *resource.Env) (resource.State, error) {
+	want := strconv.FormatInt(s.value, 10)
+	state := resource.State{Desired: want}
+	sections, err := s.current.get(ctx, env)
+	if err != nil {
+		return state, err
+	}
+	var values []string
+	for _, name := range scopeFlags[s.scope].sections {
+		sec, ok := sections[name]
+		if !ok {
+			continue
+		}
+		v, ok := sec[s.key]
+		if !ok {
+			v = "unset"
+		}
+		values = append(values, v)
+	}
+	if len(values) == 0 {
+		state.Current, state.Converged = "n/a (no "+strings.ToLower(scopeFlags[s.scope].sections[0])+")", true
+		return state, nil
+	}
+	state.Converged = true
+	for _, v := range values {
+		if v != want {
+			state.Converged = false
+		}
+	}
+	state.Current = strings.Join(values, "/")
+	return state, nil
+}
+
+// Apply implements resource.Resource.
+func (s *Setting) Apply(ctx context.Context, env *resource.Env) error {
+	_, err := env.Run(ctx, shell.Command{
+		Name: "pmset",
+		Args: []string{scopeFlags[s.scope].flag, s.key, strconv.FormatInt(s.value, 10)},
+		Sudo: true,
+	})
+	return err
+}
+
+// custom caches `pmset -g custom` for a run.
+type custom struct {
+	once     sync.Once
+	sections map[string]map[string]string
+	err      error
+}
+
+func (c *custom) get(ctx context.Context, env *resource.Env) (map[string]map[string]string, error) {
+	c.once.Do(func() {
+		res, err := env.Run(ctx, shell.Cmd("pmset", "-g", "custom"))
+		if err != nil {
+			c.err = err
+			return
+		}
+		c.sections = parseCustom(res.Stdout)
+	})
+	return c.sections, c.err
+}
+
+// parseCustom reads output of the form
+//
+//	Battery Power:
+//	 lidwake              1
+//	 sleep                15
+//	AC Power:
+//	 sleep                0
+func parseCustom(out string) map[string]map[string]string {
+	sections := map[string]map[string]string{}
+	var cur map[string]string
+	for _, line := range strings.Split(out, "\n") {
+		if name, ok := strings.CutSuffix(strings.TrimSpace(line), ":"); ok && !strings.HasPrefix(line, " ") {
+			cur = map[string]string{}
+			sections[name] = cur
+			continue
+		}
+		f := strings.Fields(line)
+		if cur != nil && len(f) >= 2 {
+			cur[f[0]] = f[1]
+		}
+	}
+	return sections
+}
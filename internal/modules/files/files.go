@@ -0,0 +1,379 @@
+// Package files keeps the configuration files declared under `[[files]]`:
+// content inline or rendered from a source file next to the template, with
+// a mode and owner, or removed. Drift is detected by checksum, and the
+// first version maziq replaces or removes is kept next to the file.
+package files
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/hmziqrs/maziq/internal/modules/dotfile"
+	"github.com/hmziqrs/maziq/internal/resource"
+	"github.com/hmziqrs/maziq/internal/shell"
+	"github.com/hmziqrs/maziq/internal/templates"
+)
+
+// Kind is the resource kind for declared files.
+const Kind = "file"
+
+func init() {
+	resource.Register(build)
+}
+
+func build(env *resource.Env) ([]resource.Resource, error) {
+	var out []resource.Resource
+	for _, spec := range env.Template.Files {
+		ok, err := env.Holds(spec.When)
+		if err != nil {
+			return nil, fmt.Errorf("files %q: when: %w", spec.Path, err)
+		}
+		if !ok {
+			continue
+		}
+		f, err := newFile(env, spec)
+		if err != nil {
+			return nil, fmt.Errorf("files %q: %w", spec.Path, err)
+		}
+		out = append(out, f)
+	}
+	return out, nil
+}
+
+// File is one declared file.
+type File struct {
+	path    string
+	content []byte
+	mode    os.FileMode
+	owner   string // user, or "" to leave it
+	group   string // group, or "" to leave it
+	absent  bool
+	// sudo writes as root, for files outside the home directory or with an
+	// owner.
+	sudo bool
+}
+
+func newFile(env *resource.Env, spec templates.File) (*File, error) {
+	if spec.Path == "" {
+		return nil, fmt.Errorf("path is required")
+	}
+	f := &File{path: env.Path(spec.Path), absent: spec.State == templates.FileAbsent, mode: 0o644}
+	if spec.Mode != "" {
+		// os.FileMode keeps setuid, setgid and sticky elsewhere than the
+		// octal bits, so they would be dropped on write and never checked.
+		m, err := strconv.ParseUint(spec.Mode, 8, 32)
+		switch {
+		case err != nil || m > 0o7777:
+			return nil, fmt.Errorf("invalid mode %q", spec.Mode)
+		case m > 0o777:
+			return nil, fmt.Errorf("mode %q: setuid, setgid and sticky bits are not supported", spec.Mode)
+		}
+		f.mode = os.FileMode(m)
+	}
+	if spec.Owner != "" {
+		f.owner, f.group, _ = strings.Cut(env.Expand(spec.Owner), ":")
+	}
+	f.sudo = f.owner != "" || !inHome(env, f.path)
+	switch {
+	case f.absent:
+	case spec.Source != "":
+		data, err := os.ReadFile(env.Path(env.Template.Rel(spec.Source)))
+		if err != nil {
+			return nil, err
+		}
+		f.content = []byte(env.Expand(string(data)))
+	default:
+		f.content = []byte(env.Expand(spec.Content))
+	}
+	return f, nil
+}
+
+func inHome(env *resource.Env, path string) bool {
+	home := env.Path("~")
+	return path == home || strings.HasPrefix(path, home+string(filepath.Separator))
+}
+
+// ID implements resource.Resource.
+func (f *File) ID() string { return resource.ID(Kind, f.path) }
+
+// Describe implements resource.Resource.
+func (f *File) Describe() string {
+	if f.absent {
+		return "remove " + f.path
+	}
+	desc := fmt.Sprintf("write %s (%04o", f.path, f.mode)
+	if f.owner != "" {
+		desc += ", " + f.ownership()
+	}
+	return desc + ")"
+}
+
+// Fingerprint implements resource.Fingerprinter; the description leaves out
+// the content.
+func (f *File) Fingerprint() string {
+	return f.ID() + " " + f.Describe() + " " + checksum(f.content)
+}
+
+// Scope implements resource.Scoper.
+func (f *File) Scope() string {
+	if f.sudo {
+		return resource.ScopeSystem
+	}
+	return resource.ScopeUser
+}
+
+// Verify implements resource.Verifier; the check only reads the file.
+func (f *File) Verify(ctx context.Context, env *resource.Env) (resource.State, error) {
+	return f.Check(ctx, env)
+}
+
+// Check implements resource.Resource. Content is compared by checksum, and
+// both sides are shown by their first eight hex digits.
+func (f *File) Check(ctx context.Context, env *resource.Env) (resource.State, error) {
+	state := resource.State{Desired: "absent"}
+	if !f.absent {
+		state.Desired = "sha256 " + checksum(f.content)[:8]
+	}
+	info, err := os.Lstat(f.path)
+	switch {
+	case os.IsNotExist(err):
+		state.Current = "missing"
+		if f.absent {
+			state.Current, state.Converged = "absent", true
+		}
+		return state, nil
+	case err != nil:
+		return state, err
+	case f.absent:
+		state.Current = "present"
+		return state, nil
+	case info.Mode()&os.ModeSymlink != 0:
+		state.Current = "symlink"
+		state.Blocked = f.path + " is a symlink; remove it to let maziq manage the file"
+		return state, nil
+	case info.IsDir():
+		state.Current = "directory"
+		state.Blocked = f.path + " is a directory"
+		return state, nil
+	}
+	have, err := os.ReadFile(f.path)
+	if err != nil {
+		return state, err
+	}
+	if sum := checksum(have); sum != checksum(f.content) {
+		state.Current = "sha256 " + sum[:8]
+		return state, nil
+	}
+	if info.Mode().Perm() != f.mode.Perm() {
+		state.Current = fmt.Sprintf("mode %04o", info.Mode().Perm())
+		return state, nil
+	}
+	if owner, group := ownerOf(info); f.owner != "" && (owner != f.owner || f.group != "" && group != f.group) {
+		state.Current = "owner " + owner + ":" + group
+		return state, nil
+	}
+	state.Current, state.Converged = state.Desired, true
+	return state, nil
+}
+
+// ownerOf names the user and group that own a file. Unknown IDs are
+// returned as numbers.
+func ownerOf(info os.FileInfo) (string, string) {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return "", ""
+	}
+	uid, gid := strconv.FormatUint(uint64(st.Uid), 10), strconv.FormatUint(uint64(st.Gid), 10)
+	owner, group := uid, gid
+	if u, err := user.LookupId(uid); err == nil {
+		owner = u.Username
+	}
+	if g, err := user.LookupGroupId(gid); err == nil {
+		group = g.Name
+	}
+	return owner, group
+}
+
+func (f *File) ownership() string {
+	if f.group == "" {
+		return f.owner
+	}
+	return f.owner + ":" + f.group
+}
+
+func checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Apply implements resource.Resource. The first time an existing file is
+// replaced or removed its content is kept next to it with
+// dotfile.BackupSuffix.
+func (f *File) Apply(ctx context.Context, env *resource.Env) error {
+	if f.sudo {
+		return f.applySudo(ctx, env)
+	}
+	backup := f.path + dotfile.BackupSuffix
+	_, err := os.Stat(backup)
+	keep := os.IsNotExist(err)
+	if f.absent {
+		if keep {
+			env.Log("moved %s to %s", f.path, backup)
+			return os.Rename(f.path, backup)
+		}
+		return os.Remove(f.path)
+	}
+	if err := os.MkdirAll(filepath.Dir(f.path), 0o755); err != nil {
+		return err
+	}
+	if old, err := os.ReadFile(f.path); err == nil && keep {
+		if err := os.WriteFile(backup, old, 0o600); err != nil {
+			return fmt.Errorf("backup: %w", err)
+		}
+		env.Log("saved the previous %s to %s", f.path, backup)
+	}
+	tmp := f.path + ".maziq.tmp"
+	if err := os.WriteFile(tmp, f.content, f.mode); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp, f.mode); err != nil {
+		return err
+	}
+	return os.Rename(tmp, f.path)
+}
+
+// applySudo writes or removes the file as root. The content is staged in a
+// temporary file and installed from there with its mode and owner.
+func (f *File) applySudo(ctx context.Context, env *resource.Env) error {
+	src := ""
+	if !f.absent {
+		tmp, err := os.CreateTemp("", "maziq-file-*")
+		if err != nil {
+			return err
+		}
+		defer os.Remove(tmp.Name())
+		if _, err := tmp.Write(f.content); err != nil {
+			tmp.Close()
+			return err
+		}
+		if err := tmp.Close(); err != nil {
+			return err
+		}
+		src = tmp.Name()
+	}
+	for _, c := range f.commands(src) {
+		if _, err := env.Run(ctx, c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// commands returns the root commands that converge the file, installing
+// the content from src.
+func (f *File) commands(src string) []shell.Command {
+	path, backup := shell.Quote(f.path), shell.Quote(f.path+dotfile.BackupSuffix)
+	var out []shell.Command
+	if f.absent {
+		out = append(out, shell.Script("if [ -e "+backup+" ]; then rm -f "+path+"; else mv "+path+" "+backup+"; fi"))
+	} else {
+		install := []string{"-m", fmt.Sprintf("%04o", f.mode)}
+		if f.owner != "" {
+			install = append(install, "-o", f.owner)
+		}
+		if f.group != "" {
+			install = append(install, "-g", f.group)
+		}
+		out = append(out,
+			shell.Cmd("mkdir", "-p", filepath.Dir(f.path)),
+			shell.Script("if [ -f "+path+" ] && [ ! -e "+backup+" ]; then cp -p "+path+" "+backup+"; fi"),
+			shell.Cmd("install", append(install, src, f.path)...),
+		)
+	}
+	for i := range out {
+		out[i].Sudo = true
+	}
+	return out
+}
+
+// Diff implements resource.Differ.
+func (f *File) Diff(ctx context.Context, env *resource.Env) (resource.FileChange, error) {
+	c := resource.FileChange{Path: f.path}
+	if !f.absent {
+		c.Desired = f.content
+	}
+	current, err := os.ReadFile(f.path)
+	if err != nil && !os.IsNotExist(err) {
+		return c, err
+	}
+	c.Current = current
+	return c, nil
+}
+
+// Merge implements resource.Merger.
+func (f *File) Merge(content []byte) error {
+	if f.absent {
+		return fmt.Errorf("%s is set to be removed", f.path)
+	}
+	f.content = content
+	return nil
+}
+
+// Undo implements resource.Reverter: the file and its backup go back to
+// how they were.
+func (f *File) Undo(ctx context.Context, env *resource.Env) (resource.Undo, error) {
+	u := resource.Undo{ID: f.ID()}
+	for _, path := range []string{f.path, f.path + dotfile.BackupSuffix} {
+		saved, err := resource.SaveFile(path)
+		if err != nil {
+			return u, err
+		}
+		u.Files = append(u.Files, saved)
+	}
+	return u, nil
+}
+
+// Export implements resource.Exporter. Files written as root are staged in
+// a file from mktemp first, since the script runs as the user; a fixed path
+// in /tmp could be planted by another user and installed as root.
+func (f *File) Export(ctx context.Context, env *resource.Env) (resource.Step, error) {
+	path := shell.Quote(f.path)
+	if f.absent {
+		step := resource.Step{Check: "[ ! -e " + path + " ]"}
+		if f.sudo {
+			step.Commands = f.commands("")
+		} else {
+			backup := shell.Quote(f.path + dotfile.BackupSuffix)
+			step.Commands = []shell.Command{shell.Script("if [ -e " + backup + " ]; then rm -f " + path + "; else mv " + path + " " + backup + "; fi")}
+		}
+		return step, nil
+	}
+	step := resource.Step{
+		Check: "[ \"$(shasum -a 256 " + path + " 2>/dev/null | cut -d' ' -f1)\" = " + checksum(f.content) + " ]",
+	}
+	if !f.sudo {
+		step.Files = []resource.FileContent{{Path: f.path, Content: f.content, Mode: f.mode}}
+		return step, nil
+	}
+	step.Commands = []shell.Command{
+		shell.Script("stage=$(mktemp)"),
+		shell.Script(`trap 'rm -f "$stage"' EXIT`),
+		shell.Script("printf '%s' " + shell.Quote(string(f.content)) + ` > "$stage"`),
+	}
+	// The staged path is only known when the script runs, so the install
+	// reads it from $stage rather than a quoted argument.
+	const stage = "$stage"
+	for _, c := range f.commands(stage) {
+		step.Commands = append(step.Commands, shell.Script(strings.Replace(c.String(), shell.Quote(stage), `"$stage"`, 1)))
+	}
+	step.Commands = append(step.Commands, shell.Script(`rm -f "$stage"`))
+	return step, nil
+}
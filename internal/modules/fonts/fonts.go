@@ -0,0 +1,443 @@
+// Package fonts installs fonts from Homebrew casks, URLs or local files into
+// ~/Library/Fonts, deduplicating by PostScript name.
+package fonts
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/hmziqrs/maziq/internal/config"
+	"github.com/hmziqrs/maziq/internal/fetch"
+	"github.com/hmziqrs/maziq/internal/modules/software"
+	"github.com/hmziqrs/maziq/internal/resource"
+	"github.com/hmziqrs/maziq/internal/shell"
+	"github.com/hmziqrs/maziq/internal/templates"
+)
+
+// Kind is the resource kind for fonts.
+const Kind = "font"
+
+func init() {
+	resource.Register(build)
+}
+
+func build(env *resource.Env) ([]resource.Resource, error) {
+	var out []resource.Resource
+	for _, f := range env.Template.Fonts {
+		ok, err := env.Holds(f.When)
+		if err != nil {
+			return nil, fmt.Errorf("fonts %q: when: %w", f.Name, err)
+		}
+		if !ok {
+			continue
+		}
+		if _, n := f.Source(); n != 1 {
+			return nil, fmt.Errorf("fonts %q: set exactly one of cask, url or path", f.Name)
+		}
+		out = append(out, &Font{spec: f})
+	}
+	return out, nil
+}
+
+// UserDir is where MazIQ installs fonts.
+func UserDir() string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, "Library", "Fonts")
+}
+
+// searchDirs are scanned to detect installed fonts.
+func searchDirs() []string {
+	return []string{UserDir(), "/Library/Fonts"}
+}
+
+// Font is one declared font family.
+type Font struct {
+	spec templates.Font
+}
+
+// ID implements resource.Resource.
+func (f *Font) ID() string { return resource.ID(Kind, f.spec.Name) }
+
+// Describe implements resource.Resource.
+func (f *Font) Describe() string {
+	kind, _ := f.spec.Source()
+	switch kind {
+	case "cask":
+		return "install font cask " + f.spec.Cask
+	case "url":
+		return "install font from " + f.spec.URL
+	}
+	return "install font from " + f.spec.Path
+}
+
+// Requires implements resource.Requirer.
+func (f *Font) Requires() []string {
+	if f.spec.Cask != "" {
+		return []string{resource.ID(software.Kind, "homebrew")}
+	}
+	return nil
+}
+
+// Check implements resource.Resource.
+func (f *Font) Check(ctx context.Context, env *resource.Env) (resource.State, error) {
+	state := resource.State{Desired: "installed"}
+	installed := Scan()
+	names := f.spec.PostScript
+	if len(names) == 0 {
+		switch kind, _ := f.spec.Source(); kind {
+		case "cask":
+			_, err := env.Run(ctx, shell.Cmd("brew", "list", "--cask", f.spec.Cask))
+			state.Converged = err == nil
+		case "path":
+			files, err := fontFiles(env.Path(f.spec.Path))
+			if err != nil {
+				return state, err
+			}
+			names = psNames(files)
+		case "url":
+			names = loadManaged()[f.spec.Name]
+		}
+	}
+	if len(names) > 0 {
+		var missing []string
+		for _, n := range names {
+			if _, ok := installed[n]; !ok {
+				missing = append(missing, n)
+			}
+		}
+		state.Converged = len(missing) == 0
+		if !state.Converged {
+			state.Current = "missing " + strings.Join(missing, ", ")
+		}
+	}
+	if state.Converged {
+		state.Current = "installed"
+	} else if state.Current == "" {
+		state.Current = "not installed"
+	}
+	return state, nil
+}
+
+// Fetch implements resource.Fetcher.
+func (f *Font) Fetch(ctx context.Context, env *resource.Env) error {
+	switch kind, _ := f.spec.Source(); kind {
+	case "cask":
+		_, err := env.Run(ctx, shell.Cmd("brew", "fetch", "--cask", f.spec.Cask))
+		return err
+	case "url":
+		path, cleanup, err := fetch.TempDownload(ctx, env.Expand(f.spec.URL))
+		if err != nil {
+			return err
+		}
+		defer cleanup()
+		in := fetch.Integrity(f.spec.Integrity)
+		in.Signature = env.Expand(in.Signature)
+		return fetch.Verify(ctx, env.Runner, path, in)
+	}
+	return nil
+}
+
+// Export implements resource.Exporter for font casks. Fonts from a URL or
+// files need MazIQ to unpack and verify them.
+func (f *Font) Export(ctx context.Context, env *resource.Env) (resource.Step, error) {
+	if kind, _ := f.spec.Source(); kind != "cask" {
+		return resource.Step{}, fmt.Errorf("fonts from a %s cannot be exported", kind)
+	}
+	return resource.Step{
+		Check:    "brew list --cask " + shell.Quote(f.spec.Cask) + " >/dev/null 2>&1",
+		Commands: []shell.Command{shell.Cmd("brew", "install", "--cask", f.spec.Cask)},
+	}, nil
+}
+
+// Apply implements resource.Resource.
+func (f *Font) Apply(ctx context.Context, env *resource.Env) error {
+	var files []string
+	switch kind, _ := f.spec.Source(); kind {
+	case "cask":
+		before := Scan()
+		if _, err := env.Run(ctx, shell.Cmd("brew", "install", "--cask", f.spec.Cask)); err != nil {
+			return err
+		}
+		var added []string
+		for name := range Scan() {
+			if _, ok := before[name]; !ok {
+				added = append(added, name)
+			}
+		}
+		sort.Strings(added)
+		return recordManaged(f.spec.Name, append(added, f.spec.PostScript...))
+	case "url":
+		path, cleanup, err := fetch.TempDownload(ctx, env.Expand(f.spec.URL))
+		if err != nil {
+			return err
+		}
+		defer cleanup()
+		in := fetch.Integrity(f.spec.Integrity)
+		in.Signature = env.Expand(in.Signature)
+		if err := fetch.Verify(ctx, env.Runner, path, in); err != nil {
+			return err
+		}
+		if strings.EqualFold(filepath.Ext(path), ".zip") {
+			if files, err = unzipFonts(path, filepath.Dir(path)); err != nil {
+				return err
+			}
+		} else {
+			files = []string{path}
+		}
+	case "path":
+		var err error
+		if files, err = fontFiles(env.Path(f.spec.Path)); err != nil {
+			return err
+		}
+	}
+	if len(files) == 0 {
+		return errors.New("no font files found")
+	}
+	names, err := install(env, files)
+	if err != nil {
+		return err
+	}
+	return recordManaged(f.spec.Name, names)
+}
+
+// install copies font files into UserDir, skipping any whose PostScript
+// names are all installed already. It returns every PostScript name covered.
+func install(env *resource.Env, files []string) ([]string, error) {
+	installed := Scan()
+	if err := os.MkdirAll(UserDir(), 0o755); err != nil {
+		return nil, err
+	}
+	var covered []string
+	for _, file := range files {
+		names, err := PostScriptNames(file)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", filepath.Base(file), err)
+		}
+		dup := true
+		for _, n := range names {
+			if _, ok := installed[n]; !ok {
+				dup = false
+			}
+		}
+		if dup {
+			env.Log("skipping %s: %s already installed", filepath.Base(file), strings.Join(names, ", "))
+		} else {
+			dest := filepath.Join(UserDir(), filepath.Base(file))
+			if err := copyFile(file, dest); err != nil {
+				return nil, err
+			}
+			for _, n := range names {
+				installed[n] = dest
+			}
+		}
+		for _, n := range names {
+			if !slices.Contains(covered, n) {
+				covered = append(covered, n)
+			}
+		}
+	}
+	return covered, nil
+}
+
+// Installed maps a PostScript name to the file providing it.
+type Installed map[string]string
+
+// Scan indexes fonts in the user and system font directories. Files that
+// cannot be parsed are ignored.
+func Scan() Installed {
+	out := Installed{}
+	for _, dir := range searchDirs() {
+		files, _ := fontFiles(dir)
+		for _, file := range files {
+			names, err := PostScriptNames(file)
+			if err != nil {
+				continue
+			}
+			for _, n := range names {
+				if _, dup := out[n]; !dup {
+					out[n] = file
+				}
+			}
+		}
+	}
+	return out
+}
+
+// Entry is one installed font face in an inventory.
+type Entry struct {
+	PostScript string `json:"postscript"`
+	File       string `json:"file"`
+	// Owner is the template font entry that installed the face; empty for
+	// unmanaged fonts.
+	Owner string `json:"owner,omitempty"`
+}
+
+// Managed reports whether MazIQ installed the face.
+func (e Entry) Managed() bool { return e.Owner != "" }
+
+// Inventory lists every installed face, attributing each to the template
+// entry that declared or installed it.
+func Inventory(t *templates.Template) []Entry {
+	owners := map[string]string{}
+	for entry, names := range loadManaged() {
+		for _, n := range names {
+			owners[n] = entry
+		}
+	}
+	if t != nil {
+		for _, f := range t.Fonts {
+			for _, n := range f.PostScript {
+				owners[n] = f.Name
+			}
+		}
+	}
+	var out []Entry
+	for name, file := range Scan() {
+		out = append(out, Entry{PostScript: name, File: file, Owner: owners[name]})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].PostScript < out[j].PostScript })
+	return out
+}
+
+// fontFiles returns the font files at path, which may be a file, a directory
+// or a glob pattern.
+func fontFiles(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err == nil && !info.IsDir() {
+		return []string{path}, nil
+	}
+	var candidates []string
+	if err == nil {
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range entries {
+			candidates = append(candidates, filepath.Join(path, e.Name()))
+		}
+	} else if candidates, err = filepath.Glob(path); err != nil {
+		return nil, err
+	}
+	var out []string
+	for _, c := range candidates {
+		if isFontFile(c) {
+			out = append(out, c)
+		}
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+func isFontFile(name string) bool {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".ttf", ".otf", ".ttc":
+		return true
+	}
+	return false
+}
+
+func psNames(files []string) []string {
+	var out []string
+	for _, f := range files {
+		names, _ := PostScriptNames(f)
+		for _, n := range names {
+			if !slices.Contains(out, n) {
+				out = append(out, n)
+			}
+		}
+	}
+	return out
+}
+
+func unzipFonts(archive, dir string) ([]string, error) {
+	r, err := zip.OpenReader(archive)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	var out []string
+	for _, zf := range r.File {
+		base := filepath.Base(zf.Name)
+		if zf.FileInfo().IsDir() || !isFontFile(base) || strings.HasPrefix(base, "._") {
+			continue
+		}
+		dest := filepath.Join(dir, base)
+		if err := extract(zf, dest); err != nil {
+			return nil, err
+		}
+		out = append(out, dest)
+	}
+	return out, nil
+}
+
+func extract(zf *zip.File, dest string) error {
+	rc, err := zf.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	w, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(w, rc); err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+func copyFile(src, dest string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dest, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// managedPath records the PostScript names each URL or path entry installed,
+// since those cannot be recomputed without downloading again.
+func managedPath() string {
+	return filepath.Join(config.Dir(), "fonts.json")
+}
+
+func loadManaged() map[string][]string {
+	out := map[string][]string{}
+	data, err := os.ReadFile(managedPath())
+	if err == nil {
+		_ = json.Unmarshal(data, &out)
+	}
+	return out
+}
+
+func recordManaged(entry string, names []string) error {
+	m := loadManaged()
+	m[entry] = names
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(config.Dir(), 0o755); err != nil && !errors.Is(err, fs.ErrExist) {
+		return err
+	}
+	return os.WriteFile(managedPath(), data, 0o644)
+}
@@ -0,0 +1,111 @@
+package fonts
+
+import (
+	"encoding/binary"
+	"errors"
+	"os"
+	"unicode/utf16"
+)
+
+// nameIDPostScript is the `name` table record holding the PostScript name.
+const nameIDPostScript = 6
+
+var errNotFont = errors.New("not a TrueType/OpenType font")
+
+// PostScriptNames returns the PostScript names in a .ttf, .otf or .ttc file.
+// Collections yield one name per face.
+func PostScriptNames(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < 12 {
+		return nil, errNotFont
+	}
+	if string(data[:4]) != "ttcf" {
+		name, err := postScriptName(data, 0)
+		if err != nil {
+			return nil, err
+		}
+		return []string{name}, nil
+	}
+	count := int(binary.BigEndian.Uint32(data[8:12]))
+	if len(data) < 12+4*count {
+		return nil, errNotFont
+	}
+	var names []string
+	for i := 0; i < count; i++ {
+		off := int(binary.BigEndian.Uint32(data[12+4*i:]))
+		if name, err := postScriptName(data, off); err == nil {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return nil, errNotFont
+	}
+	return names, nil
+}
+
+// postScriptName reads nameID 6 from the font whose offset table starts at
+// off, preferring the Windows (UTF-16BE) record over the Macintosh one.
+func postScriptName(data []byte, off int) (string, error) {
+	if off+12 > len(data) {
+		return "", errNotFont
+	}
+	numTables := int(binary.BigEndian.Uint16(data[off+4:]))
+	dir := off + 12
+	if dir+16*numTables > len(data) {
+		return "", errNotFont
+	}
+	for i := 0; i < numTables; i++ {
+		rec := data[dir+16*i:]
+		if string(rec[:4]) != "name" {
+			continue
+		}
+		start := int(binary.BigEndian.Uint32(rec[8:]))
+		length := int(binary.BigEndian.Uint32(rec[12:]))
+		if start+length > len(data) || length < 6 {
+			return "", errNotFont
+		}
+		return readName(data[start : start+length])
+	}
+	return "", errNotFont
+}
+
+func readName(table []byte) (string, error) {
+	count := int(binary.BigEndian.Uint16(table[2:]))
+	storage := int(binary.BigEndian.Uint16(table[4:]))
+	var mac string
+	for i := 0; i < count; i++ {
+		r := 6 + 12*i
+		if r+12 > len(table) {
+			break
+		}
+		platform := binary.BigEndian.Uint16(table[r:])
+		nameID := binary.BigEndian.Uint16(table[r+6:])
+		length := int(binary.BigEndian.Uint16(table[r+8:]))
+		offset := int(binary.BigEndian.Uint16(table[r+10:]))
+		if nameID != nameIDPostScript {
+			continue
+		}
+		s, e := storage+offset, storage+offset+length
+		if e > len(table) {
+			continue
+		}
+		raw := table[s:e]
+		switch platform {
+		case 0, 3:
+			u := make([]uint16, len(raw)/2)
+			for j := range u {
+				u[j] = binary.BigEndian.Uint16(raw[2*j:])
+			}
+			return string(utf16.Decode(u)), nil
+		case 1:
+			mac = string(raw)
+		}
+	}
+	if mac != "" {
+		return mac, nil
+	}
+	return "", errNotFont
+}
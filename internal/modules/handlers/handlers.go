@@ -0,0 +1,125 @@
+// Package handlers sets default apps for file extensions, UTIs and URL
+// schemes through LaunchServices using duti.
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hmziqrs/maziq/internal/modules/software"
+	"github.com/hmziqrs/maziq/internal/resource"
+	"github.com/hmziqrs/maziq/internal/shell"
+	"github.com/hmziqrs/maziq/internal/templates"
+)
+
+// Kind is the resource kind for default handlers.
+const Kind = "handler"
+
+func init() {
+	resource.Register(build)
+}
+
+func build(env *resource.Env) ([]resource.Resource, error) {
+	keys := make([]string, 0, len(env.Template.Handlers))
+	for k := range env.Template.Handlers {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	out := make([]resource.Resource, 0, len(keys))
+	for _, k := range keys {
+		bundle := env.Template.Handlers[k]
+		if bundle == "" {
+			return nil, fmt.Errorf("handlers %q: empty bundle ID", k)
+		}
+		out = append(out, &Handler{key: k, bundle: bundle})
+	}
+	return out, nil
+}
+
+// Handler is the default app for one extension, UTI or URL scheme.
+type Handler struct {
+	key    string
+	bundle string
+}
+
+// ID implements resource.Resource.
+func (h *Handler) ID() string { return resource.ID(Kind, h.key) }
+
+// Describe implements resource.Resource.
+func (h *Handler) Describe() string {
+	return fmt.Sprintf("open %s with %s", h.key, h.bundle)
+}
+
+// Requires implements resource.Requirer.
+func (h *Handler) Requires() []string {
+	return []string{resource.ID(software.Kind, "duti")}
+}
+
+// Check implements resource.Resource.
+func (h *Handler) Check(ctx context.Context, env *resource.Env) (resource.State, error) {
+	state := resource.State{Desired: h.bundle}
+	current, err := Current(ctx, env, h.key)
+	if err != nil {
+		return state, err
+	}
+	state.Current = current
+	if current == "" {
+		state.Current = "none"
+	}
+	state.Converged = strings.EqualFold(current, h.bundle)
+	return state, nil
+}
+
+// Apply implements resource.Resource.
+func (h *Handler) Apply(ctx context.Context, env *resource.Env) error {
+	args := []string{"-s", h.bundle, h.key}
+	if templates.HandlerKindOf(h.key) != templates.HandlerScheme {
+		args = append(args, "all")
+	}
+	_, err := env.Run(ctx, shell.Cmd("duti", args...))
+	return err
+}
+
+// Export implements resource.Exporter.
+func (h *Handler) Export(ctx context.Context, env *resource.Env) (resource.Step, error) {
+	args := []string{"-s", h.bundle, h.key}
+	current := "duti -d " + shell.Quote(h.key) + " 2>/dev/null"
+	if templates.HandlerKindOf(h.key) != templates.HandlerScheme {
+		args = append(args, "all")
+	}
+	if templates.HandlerKindOf(h.key) == templates.HandlerExtension {
+		current = "duti -x " + shell.Quote(strings.TrimPrefix(h.key, ".")) + " 2>/dev/null | tail -n 1"
+	}
+	return resource.Step{
+		Check:    "[ \"$(" + current + " | tr '[:upper:]' '[:lower:]')\" = " + shell.Quote(strings.ToLower(h.bundle)) + " ]",
+		Commands: []shell.Command{shell.Cmd("duti", args...)},
+	}, nil
+}
+
+// Current returns the bundle ID LaunchServices uses for key, or "" when no
+// handler is registered.
+func Current(ctx context.Context, env *resource.Env, key string) (string, error) {
+	if templates.HandlerKindOf(key) == templates.HandlerExtension {
+		// duti -x prints the app name, path and bundle ID on three lines.
+		res, err := env.Run(ctx, shell.Cmd("duti", "-x", strings.TrimPrefix(key, ".")))
+		if err != nil {
+			if errors.As(err, new(*shell.ExitError)) {
+				return "", nil
+			}
+			return "", err
+		}
+		lines := strings.Split(strings.TrimSpace(res.Stdout), "\n")
+		return strings.TrimSpace(lines[len(lines)-1]), nil
+	}
+	res, err := env.Run(ctx, shell.Cmd("duti", "-d", key))
+	if err != nil {
+		if errors.As(err, new(*shell.ExitError)) {
+			return "", nil
+		}
+		return "", err
+	}
+	return strings.TrimSpace(res.Stdout), nil
+}
@@ -0,0 +1,212 @@
+// Package jobs runs the recurring commands declared under `[[jobs]]`. Each
+// job becomes a launchd agent: a property list in ~/Library/LaunchAgents,
+// kept like any other managed file, and the agent loaded into the user's
+// launchd domain.
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/hmziqrs/maziq/internal/modules/dotfile"
+	"github.com/hmziqrs/maziq/internal/plist"
+	"github.com/hmziqrs/maziq/internal/resource"
+	"github.com/hmziqrs/maziq/internal/schedule"
+	"github.com/hmziqrs/maziq/internal/shell"
+	"github.com/hmziqrs/maziq/internal/templates"
+)
+
+// Kind is the resource kind for loaded jobs.
+const Kind = "job"
+
+// LabelPrefix starts the launchd label of every job.
+const LabelPrefix = "com.hmziqrs.maziq.job."
+
+// LogDir is where jobs write their output, one NAME.log each.
+const LogDir = "~/Library/Logs/maziq/jobs"
+
+func init() {
+	resource.Register(build)
+}
+
+func build(env *resource.Env) ([]resource.Resource, error) {
+	var out []resource.Resource
+	for _, spec := range env.Template.Jobs {
+		ok, err := env.Holds(spec.When)
+		if err != nil {
+			return nil, fmt.Errorf("jobs %q: when: %w", spec.Name, err)
+		}
+		if !ok {
+			continue
+		}
+		j, err := newJob(env, spec)
+		if err != nil {
+			return nil, fmt.Errorf("jobs %q: %w", spec.Name, err)
+		}
+		content, err := j.render()
+		if err != nil {
+			return nil, fmt.Errorf("jobs %q: %w", spec.Name, err)
+		}
+		f := dotfile.New(j.path, content)
+		f.Label = "job " + spec.Name
+		// A loaded agent keeps the old definition until it is booted out.
+		f.Reload = "launchctl bootout " + j.target() + " 2>/dev/null; " + j.bootstrap()
+		j.needs = []string{f.ID()}
+		out = append(out, f, j)
+	}
+	return out, nil
+}
+
+// Job is a job's agent loaded into launchd.
+type Job struct {
+	name     string
+	run      string
+	label    string
+	path     string // the agent's property list
+	log      string
+	schedule *schedule.Schedule
+	needs    []string
+}
+
+func newJob(env *resource.Env, spec templates.Job) (*Job, error) {
+	if spec.Name == "" || spec.Run == "" {
+		return nil, errors.New("name and run are required")
+	}
+	s, err := schedule.Parse(spec.Schedule)
+	if err != nil {
+		return nil, err
+	}
+	label := LabelPrefix + spec.Name
+	return &Job{
+		name:     spec.Name,
+		run:      env.Expand(spec.Run),
+		label:    label,
+		path:     env.Path("~/Library/LaunchAgents/" + label + ".plist"),
+		log:      env.Path(LogDir + "/" + spec.Name + ".log"),
+		schedule: s,
+	}, nil
+}
+
+// render returns the agent's property list.
+func (j *Job) render() ([]byte, error) {
+	var calendar []any
+	for _, c := range j.schedule.Calendar() {
+		calendar = append(calendar, c)
+	}
+	return plist.Encode(map[string]any{
+		"Label":                 j.label,
+		"ProgramArguments":      []any{"/bin/bash", "-lc", j.run},
+		"StartCalendarInterval": calendar,
+		"StandardOutPath":       j.log,
+		"StandardErrorPath":     j.log,
+		"ProcessType":           "Background",
+	})
+}
+
+// target is the job's service target in the user's GUI domain.
+func (j *Job) target() string {
+	return "gui/$(id -u)/" + shell.Quote(j.label)
+}
+
+// bootstrap loads the agent unless it already is. launchd does not create
+// the log's directory itself.
+func (j *Job) bootstrap() string {
+	return "mkdir -p " + shell.Quote(filepath.Dir(j.log)) + "; launchctl print " + j.target() + " >/dev/null 2>&1 || launchctl bootstrap gui/$(id -u) " + shell.Quote(j.path)
+}
+
+// ID implements resource.Resource.
+func (j *Job) ID() string { return resource.ID(Kind, j.name) }
+
+// Describe implements resource.Resource.
+func (j *Job) Describe() string {
+	return "schedule " + j.name + " (" + j.schedule.String() + ")"
+}
+
+// Requires implements resource.Requirer.
+func (j *Job) Requires() []string { return j.needs }
+
+// Check implements resource.Resource.
+func (j *Job) Check(ctx context.Context, env *resource.Env) (resource.State, error) {
+	state := resource.State{Desired: "loaded"}
+	st, err := j.Status(ctx, env)
+	switch {
+	case err != nil:
+		return state, err
+	case st.Loaded:
+		state.Current, state.Converged = "loaded", true
+	default:
+		state.Current = "not loaded"
+	}
+	return state, nil
+}
+
+// Apply implements resource.Resource.
+func (j *Job) Apply(ctx context.Context, env *resource.Env) error {
+	_, err := env.Run(ctx, shell.Script(j.bootstrap()))
+	return err
+}
+
+// Export implements resource.Exporter.
+func (j *Job) Export(ctx context.Context, env *resource.Env) (resource.Step, error) {
+	return resource.Step{
+		Check:    "launchctl print " + j.target() + " >/dev/null 2>&1",
+		Commands: []shell.Command{shell.Script(j.bootstrap())},
+	}, nil
+}
+
+// Status is how a job stands in launchd.
+type Status struct {
+	Name     string    `json:"name"`
+	Schedule string    `json:"schedule"`
+	Loaded   bool      `json:"loaded"`
+	NextRun  time.Time `json:"next_run"`
+	// LastExit is the exit code of the last run, or nil before the first.
+	LastExit *int   `json:"last_exit,omitempty"`
+	Log      string `json:"log"`
+}
+
+var lastExit = regexp.MustCompile(`last exit code = (-?\d+)`)
+
+// Status asks launchd about the job. The next run is worked out from the
+// schedule, in local time.
+func (j *Job) Status(ctx context.Context, env *resource.Env) (Status, error) {
+	st := Status{Name: j.name, Schedule: j.schedule.String(), NextRun: j.schedule.Next(time.Now()), Log: j.log}
+	res, err := env.Run(ctx, shell.Script("launchctl print "+j.target()))
+	if err != nil {
+		if errors.As(err, new(*shell.ExitError)) {
+			return st, nil
+		}
+		return st, err
+	}
+	st.Loaded = true
+	if m := lastExit.FindStringSubmatch(res.Stdout); m != nil {
+		code, _ := strconv.Atoi(m[1])
+		st.LastExit = &code
+	}
+	return st, nil
+}
+
+// List returns the status of every job the template declares on this
+// machine.
+func List(ctx context.Context, env *resource.Env) ([]Status, error) {
+	rs, err := build(env)
+	if err != nil {
+		return nil, err
+	}
+	var out []Status
+	for _, r := range rs {
+		if j, ok := r.(*Job); ok {
+			st, err := j.Status(ctx, env)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, st)
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,236 @@
+// Package kubernetes merges declared cluster contexts into the user's
+// kubeconfig.
+package kubernetes
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/hmziqrs/maziq/internal/modules/software"
+	"github.com/hmziqrs/maziq/internal/resource"
+	"github.com/hmziqrs/maziq/internal/shell"
+	"github.com/hmziqrs/maziq/internal/templates"
+)
+
+// Kind is the resource kind for kubeconfig contexts.
+const Kind = "kubecontext"
+
+func init() {
+	resource.Register(build)
+}
+
+func build(env *resource.Env) ([]resource.Resource, error) {
+	config := &kubeconfig{path: env.Path("~/.kube/config")}
+	var out []resource.Resource
+	for i, c := range env.Template.Kubernetes.Contexts {
+		ok, err := env.Holds(c.When)
+		if err != nil {
+			return nil, fmt.Errorf("kubernetes.context[%d]: when: %w", i, err)
+		}
+		if !ok {
+			continue
+		}
+		if c.Name == "" {
+			return nil, fmt.Errorf("kubernetes.context[%d]: name is required", i)
+		}
+		if (c.File == "") == (c.Secret == "") {
+			return nil, fmt.Errorf("kubernetes context %q: set exactly one of file and secret", c.Name)
+		}
+		out = append(out, &Context{spec: c, config: config})
+	}
+	return out, nil
+}
+
+// Context is one merged kubeconfig context.
+type Context struct {
+	spec   templates.KubeContext
+	config *kubeconfig
+}
+
+// ID implements resource.Resource.
+func (c *Context) ID() string { return resource.ID(Kind, c.spec.Name) }
+
+// Describe implements resource.Resource.
+func (c *Context) Describe() string {
+	d := "merge kube context " + c.spec.Name + " into " + c.config.path
+	if c.spec.Current {
+		d += " and make it current"
+	}
+	return d
+}
+
+// Requires implements resource.Requirer.
+func (c *Context) Requires() []string {
+	return []string{resource.ID(software.Kind, "kubectl")}
+}
+
+// Check implements resource.Resource.
+func (c *Context) Check(ctx context.Context, env *resource.Env) (resource.State, error) {
+	state := resource.State{Desired: c.desired()}
+	view, err := c.config.get(ctx, env)
+	if err != nil {
+		return state, err
+	}
+	ns, ok := view.namespace(c.spec.Name)
+	switch {
+	case !ok:
+		state.Current = "missing"
+	case c.spec.Namespace != "" && ns != c.spec.Namespace:
+		state.Current = "namespace " + ns
+		if ns == "" {
+			state.Current = "no namespace"
+		}
+	case c.spec.Current && view.CurrentContext != c.spec.Name:
+		state.Current = "present, not current"
+	default:
+		state.Current, state.Converged = state.Desired, true
+	}
+	return state, nil
+}
+
+func (c *Context) desired() string {
+	d := "present"
+	if c.spec.Namespace != "" {
+		d += " in " + c.spec.Namespace
+	}
+	if c.spec.Current {
+		d += ", current"
+	}
+	return d
+}
+
+// Apply implements resource.Resource.
+func (c *Context) Apply(ctx context.Context, env *resource.Env) error {
+	view, err := c.config.get(ctx, env)
+	if err != nil {
+		return err
+	}
+	if _, ok := view.namespace(c.spec.Name); !ok {
+		if err := c.merge(ctx, env); err != nil {
+			return err
+		}
+	}
+	if c.spec.Namespace != "" {
+		if _, err := env.Run(ctx, shell.Cmd("kubectl", "config", "set-context", c.spec.Name, "--namespace="+c.spec.Namespace)); err != nil {
+			return err
+		}
+	}
+	if c.spec.Current {
+		if _, err := env.Run(ctx, shell.Cmd("kubectl", "config", "use-context", c.spec.Name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// merge flattens the user's kubeconfig and the source into one file. The
+// existing file comes first so its clusters and users win on name clashes.
+func (c *Context) merge(ctx context.Context, env *resource.Env) error {
+	src := env.Path(c.spec.File)
+	if c.spec.Secret != "" {
+		data, err := env.Secrets.Get(ctx, c.spec.Secret)
+		if err != nil {
+			return fmt.Errorf("kube context %s: %w", c.spec.Name, err)
+		}
+		f, err := os.CreateTemp("", "maziq-kubeconfig-*")
+		if err != nil {
+			return err
+		}
+		defer os.Remove(f.Name())
+		if _, err := f.WriteString(data); err != nil {
+			f.Close()
+			return err
+		}
+		if err := f.Close(); err != nil {
+			return err
+		}
+		src = f.Name()
+	}
+	cfg := c.config.path
+	res, err := env.Run(ctx, shell.Command{
+		Name: "kubectl",
+		Args: []string{"config", "view", "--flatten"},
+		Env:  []string{"KUBECONFIG=" + cfg + string(os.PathListSeparator) + src},
+	})
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(cfg), 0o700); err != nil {
+		return err
+	}
+	tmp := cfg + ".maziq.tmp"
+	if err := os.WriteFile(tmp, []byte(res.Stdout), 0o600); err != nil {
+		return err
+	}
+	check := shell.Command{Name: "kubectl", Args: []string{"config", "get-contexts", c.spec.Name}, Env: []string{"KUBECONFIG=" + tmp}}
+	if _, err := env.Run(ctx, check); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("kubeconfig for %s has no context named %q", c.spec.Name, c.spec.Name)
+	}
+	return os.Rename(tmp, cfg)
+}
+
+// Assertions implements resource.Asserter.
+func (c *Context) Assertions() []resource.Assertion {
+	if c.spec.Verify != nil && !*c.spec.Verify {
+		return nil
+	}
+	return []resource.Assertion{{
+		Name:   "cluster " + c.spec.Name + " is reachable",
+		Run:    "kubectl --context " + shell.Quote(c.spec.Name) + " cluster-info --request-timeout=10s",
+		Expect: "is running at",
+	}}
+}
+
+// view is the part of `kubectl config view -o json` maziq reads.
+type view struct {
+	CurrentContext string `json:"current-context"`
+	Contexts       []struct {
+		Name    string `json:"name"`
+		Context struct {
+			Namespace string `json:"namespace"`
+		} `json:"context"`
+	} `json:"contexts"`
+}
+
+// namespace returns the namespace of the named context and whether the
+// context exists.
+func (v *view) namespace(name string) (string, bool) {
+	for _, c := range v.Contexts {
+		if c.Name == name {
+			return c.Context.Namespace, true
+		}
+	}
+	return "", false
+}
+
+// kubeconfig reads the user's kubeconfig once per run.
+type kubeconfig struct {
+	path string
+	once sync.Once
+	view view
+	err  error
+}
+
+func (k *kubeconfig) get(ctx context.Context, env *resource.Env) (*view, error) {
+	k.once.Do(func() {
+		if _, err := os.Stat(k.path); os.IsNotExist(err) {
+			return
+		}
+		res, err := env.Run(ctx, shell.Command{
+			Name: "kubectl",
+			Args: []string{"config", "view", "-o", "json"},
+			Env:  []string{"KUBECONFIG=" + k.path},
+		})
+		if err != nil {
+			k.err = err
+			return
+		}
+		k.err = json.Unmarshal([]byte(res.Stdout), &k.view)
+	})
+	return &k.view, k.err
+}
@@ -0,0 +1,247 @@
+// Package license places license files and runs activation commands for
+// licensed apps, with the license values read from the secrets provider.
+package license
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/hmziqrs/maziq/internal/modules/software"
+	"github.com/hmziqrs/maziq/internal/resource"
+	"github.com/hmziqrs/maziq/internal/shell"
+	"github.com/hmziqrs/maziq/internal/templates"
+)
+
+// Kind is the resource kind for licenses.
+const Kind = "license"
+
+// SecretEnv carries the license value into activation and verify commands,
+// keeping it out of the process list.
+const SecretEnv = "MAZIQ_SECRET"
+
+func init() {
+	resource.Register(build)
+}
+
+func build(env *resource.Env) ([]resource.Resource, error) {
+	var out []resource.Resource
+	for _, l := range env.Template.Licenses {
+		ok, err := env.Holds(l.When)
+		if err != nil {
+			return nil, fmt.Errorf("licenses %q: when: %w", l.Name, err)
+		}
+		if !ok {
+			continue
+		}
+		if l.File == "" && l.Activate == "" {
+			return nil, fmt.Errorf("licenses %q: needs a file or an activate command", l.Name)
+		}
+		out = append(out, &License{spec: l})
+	}
+	return out, nil
+}
+
+// License is a license file and/or activation for one app.
+type License struct {
+	spec templates.License
+}
+
+// ID implements resource.Resource.
+func (l *License) ID() string { return resource.ID(Kind, l.spec.Name) }
+
+// Describe implements resource.Resource.
+func (l *License) Describe() string {
+	var parts []string
+	if l.spec.File != "" {
+		parts = append(parts, "place "+l.spec.File)
+	}
+	if l.spec.Activate != "" {
+		parts = append(parts, "run activation")
+	}
+	desc := strings.Join(parts, " and ")
+	if l.spec.Secret != "" {
+		desc += " using secret " + l.spec.Secret
+	}
+	return desc
+}
+
+// Requires implements resource.Requirer.
+func (l *License) Requires() []string {
+	if l.spec.Software == "" {
+		return nil
+	}
+	return []string{resource.ID(software.Kind, l.spec.Software)}
+}
+
+// Assertions implements resource.Asserter.
+func (l *License) Assertions() []resource.Assertion {
+	if l.spec.Verify == "" {
+		return nil
+	}
+	a := resource.Assertion{
+		Name:   l.spec.Name + " reports a licensed state",
+		Run:    l.script(l.spec.Verify),
+		Expect: l.spec.Expect,
+	}
+	if l.spec.Secret != "" {
+		a.Secrets = map[string]string{SecretEnv: l.spec.Secret}
+	}
+	return []resource.Assertion{a}
+}
+
+// Check implements resource.Resource.
+func (l *License) Check(ctx context.Context, env *resource.Env) (resource.State, error) {
+	state := resource.State{Desired: "licensed"}
+	if l.spec.File != "" {
+		want, err := l.content(ctx, env)
+		if err != nil {
+			return state, err
+		}
+		have, err := os.ReadFile(env.Path(l.spec.File))
+		switch {
+		case os.IsNotExist(err):
+			state.Current = "license file missing"
+			return state, nil
+		case err != nil:
+			return state, err
+		case !bytes.Equal(have, want):
+			state.Current = "license file differs"
+			return state, nil
+		}
+	}
+	if l.spec.Verify != "" {
+		if err := l.verify(ctx, env); err != nil {
+			state.Current = "not licensed"
+			return state, nil
+		}
+	} else if l.spec.Activate != "" {
+		// Without a verify command there is no way to tell whether the
+		// activation already happened.
+		state.Current = "unverified"
+		return state, nil
+	}
+	state.Current, state.Converged = "licensed", true
+	return state, nil
+}
+
+// Apply implements resource.Resource.
+func (l *License) Apply(ctx context.Context, env *resource.Env) error {
+	if l.spec.File != "" {
+		data, err := l.content(ctx, env)
+		if err != nil {
+			return err
+		}
+		mode, err := parseMode(l.spec.Mode)
+		if err != nil {
+			return err
+		}
+		path := env.Path(l.spec.File)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, data, mode); err != nil {
+			return err
+		}
+		if err := os.Chmod(path, mode); err != nil {
+			return err
+		}
+	}
+	if l.spec.Activate != "" {
+		cmd, err := l.command(ctx, env, l.spec.Activate)
+		if err != nil {
+			return err
+		}
+		if _, err := env.Run(ctx, cmd); err != nil {
+			return fmt.Errorf("activation: %w", err)
+		}
+	}
+	if l.spec.Verify != "" {
+		return l.verify(ctx, env)
+	}
+	return nil
+}
+
+func (l *License) verify(ctx context.Context, env *resource.Env) error {
+	cmd, err := l.command(ctx, env, l.spec.Verify)
+	if err != nil {
+		return err
+	}
+	res, err := env.Run(ctx, cmd)
+	if err != nil {
+		return fmt.Errorf("verify: %w", err)
+	}
+	if l.spec.Expect != "" && !strings.Contains(res.Stdout+res.Stderr, env.Expand(l.spec.Expect)) {
+		return fmt.Errorf("verify: output does not contain %q", l.spec.Expect)
+	}
+	return nil
+}
+
+// secret fetches the license value, or "" when no secret is configured.
+func (l *License) secret(ctx context.Context, env *resource.Env) (string, error) {
+	if l.spec.Secret == "" {
+		return "", nil
+	}
+	v, err := env.Secrets.Get(ctx, l.spec.Secret)
+	if err != nil {
+		return "", fmt.Errorf("license %s: %w", l.spec.Name, err)
+	}
+	return v, nil
+}
+
+// content renders the desired license file.
+func (l *License) content(ctx context.Context, env *resource.Env) ([]byte, error) {
+	secret, err := l.secret(ctx, env)
+	if err != nil {
+		return nil, err
+	}
+	if l.spec.Content == "" {
+		return []byte(secret), nil
+	}
+	return []byte(l.expand(env, secret, l.spec.Content)), nil
+}
+
+// command builds a bash command for script with the secret exported as
+// $MAZIQ_SECRET, which ${secret} refers to.
+func (l *License) command(ctx context.Context, env *resource.Env, script string) (shell.Command, error) {
+	secret, err := l.secret(ctx, env)
+	if err != nil {
+		return shell.Command{}, err
+	}
+	cmd := shell.Script(env.Expand(l.script(script)))
+	if secret != "" {
+		cmd.Env = []string{SecretEnv + "=" + secret}
+	}
+	return cmd, nil
+}
+
+// script turns ${secret} in a command into a reference to $MAZIQ_SECRET.
+// The value itself never goes into the script, which is shown in the
+// process list and kept in transcripts.
+func (l *License) script(s string) string {
+	return strings.ReplaceAll(s, "${"+templates.SecretVar+"}", `"$`+SecretEnv+`"`)
+}
+
+func (l *License) expand(env *resource.Env, secret, s string) string {
+	return templates.Expand(s, func(name string) string {
+		if name == templates.SecretVar {
+			return secret
+		}
+		return env.Lookup(name)
+	})
+}
+
+func parseMode(s string) (os.FileMode, error) {
+	if s == "" {
+		return 0o600, nil
+	}
+	m, err := strconv.ParseUint(s, 8, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid mode %q", s)
+	}
+	return os.FileMode(m), nil
+}
@@ -0,0 +1,204 @@
+// Package manual models steps maziq cannot perform itself, such as granting
+// a privacy permission or signing in to the App Store. Apply pauses on a
+// step, shows its instructions and continues once the user confirms or the
+// step's verification command passes. Without someone at the terminal the
+// step is reported as blocked.
+package manual
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/hmziqrs/maziq/internal/config"
+	"github.com/hmziqrs/maziq/internal/resource"
+	"github.com/hmziqrs/maziq/internal/shell"
+	"github.com/hmziqrs/maziq/internal/sysprefs"
+)
+
+// Kind is the resource kind for manual steps.
+const Kind = "manual"
+
+// pollInterval is how often a waiting step re-runs its verification.
+const pollInterval = 2 * time.Second
+
+func init() {
+	resource.Register(build)
+}
+
+func build(env *resource.Env) ([]resource.Resource, error) {
+	var out []resource.Resource
+	for i, m := range env.Template.Manual {
+		ok, err := env.Holds(m.When)
+		if err != nil {
+			return nil, fmt.Errorf("manual[%d]: when: %w", i, err)
+		}
+		if !ok {
+			continue
+		}
+		if m.Name == "" || m.Instructions == "" {
+			return nil, fmt.Errorf("manual[%d]: name and instructions are required", i)
+		}
+		out = append(out, &Step{
+			Name:         m.Name,
+			Title:        m.Name,
+			Instructions: env.Expand(m.Instructions),
+			Settings:     m.Settings,
+			Open:         env.Expand(m.Open),
+			Verify:       m.Verify,
+		})
+	}
+	return out, nil
+}
+
+// Step is one thing the user has to do by hand.
+type Step struct {
+	Name string
+	// Title is a short imperative summary, e.g. "grant yabai Accessibility".
+	Title string
+	// Instructions say where to go and what to change.
+	Instructions string
+	// Settings is the System Settings pane opened when the step starts.
+	Settings string
+	// Open is a URL or path handed to `open` when the step starts.
+	Open string
+	// Verify is a bash script that exits 0 once the step is done. Steps
+	// without one are done once confirmed.
+	Verify string
+	Needs  []string
+}
+
+// ID implements resource.Resource.
+func (s *Step) ID() string { return resource.ID(Kind, s.Name) }
+
+// Describe implements resource.Resource.
+func (s *Step) Describe() string { return s.Title }
+
+// Requires implements resource.Requirer.
+func (s *Step) Requires() []string { return s.Needs }
+
+// Check implements resource.Resource.
+func (s *Step) Check(ctx context.Context, env *resource.Env) (resource.State, error) {
+	state := resource.State{Desired: "done"}
+	done, err := s.done(ctx, env)
+	if err != nil {
+		return state, err
+	}
+	if done {
+		state.Current, state.Converged = "done", true
+		return state, nil
+	}
+	state.Current = "not done"
+	if env.Wait == nil {
+		state.Blocked = "manual step: " + s.Instructions
+	}
+	return state, nil
+}
+
+func (s *Step) done(ctx context.Context, env *resource.Env) (bool, error) {
+	if s.Verify == "" {
+		return confirmed()[s.Name], nil
+	}
+	_, err := env.Run(ctx, shell.Script(s.Verify))
+	switch {
+	case err == nil:
+		return true, nil
+	case errors.As(err, new(*shell.ExitError)):
+		return false, nil
+	default:
+		return false, err
+	}
+}
+
+// Apply implements resource.Resource. It shows the instructions and waits
+// for the user, finishing early when the verification starts passing.
+func (s *Step) Apply(ctx context.Context, env *resource.Env) error {
+	if env.Wait == nil {
+		return fmt.Errorf("manual step: %s", s.Instructions)
+	}
+	env.Log("manual step: %s", s.Instructions)
+	if s.Settings != "" {
+		if err := sysprefs.Open(ctx, env.Runner, s.Settings); err != nil {
+			env.Log("could not open System Settings: %v", err)
+		}
+	}
+	if s.Open != "" {
+		if _, err := env.Run(ctx, shell.Cmd("open", s.Open)); err != nil {
+			env.Log("could not open %s: %v", s.Open, err)
+		}
+	}
+	wait, cancel := context.WithCancel(ctx)
+	defer cancel()
+	if s.Verify != "" {
+		go func() {
+			t := time.NewTicker(pollInterval)
+			defer t.Stop()
+			for {
+				select {
+				case <-wait.Done():
+					return
+				case <-t.C:
+					if ok, _ := s.done(wait, env); ok {
+						cancel()
+						return
+					}
+				}
+			}
+		}()
+	}
+	if err := env.Wait(wait, "Press Enter when done"); err != nil && wait.Err() == nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if s.Verify == "" {
+		return confirm(s.Name)
+	}
+	if ok, err := s.done(ctx, env); err != nil || !ok {
+		return fmt.Errorf("still not done: %s", s.Instructions)
+	}
+	return nil
+}
+
+// SettingsPane implements sysprefs.Linker.
+func (s *Step) SettingsPane() string { return s.Settings }
+
+// Assertions implements resource.Asserter.
+func (s *Step) Assertions() []resource.Assertion {
+	if s.Verify == "" {
+		return nil
+	}
+	return []resource.Assertion{{Name: s.Title, Run: s.Verify}}
+}
+
+func confirmedPath() string {
+	return filepath.Join(config.Dir(), "manual.json")
+}
+
+// confirmed returns the names of unverifiable steps the user has confirmed.
+func confirmed() map[string]bool {
+	out := map[string]bool{}
+	data, err := os.ReadFile(confirmedPath())
+	if err == nil {
+		_ = json.Unmarshal(data, &out)
+	}
+	return out
+}
+
+func confirm(name string) error {
+	m := confirmed()
+	m[name] = true
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(config.Dir(), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(confirmedPath(), data, 0o644)
+}
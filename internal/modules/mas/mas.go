@@ -0,0 +1,260 @@
+// Package mas installs Mac App Store apps with the mas CLI. Before anything
+// is installed it checks the signed-in account and whether each app is sold
+// in that storefront, so unavailable apps show up as warnings in the plan
+// instead of failures halfway through apply.
+package mas
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/hmziqrs/maziq/internal/fetch"
+	"github.com/hmziqrs/maziq/internal/modules/software"
+	"github.com/hmziqrs/maziq/internal/resource"
+	"github.com/hmziqrs/maziq/internal/sbom"
+	"github.com/hmziqrs/maziq/internal/shell"
+	"github.com/hmziqrs/maziq/internal/templates"
+)
+
+// Kind is the resource kind for App Store apps.
+const Kind = "mas"
+
+// RegionEnv overrides the detected storefront region (ISO 3166 alpha-2).
+const RegionEnv = "MAZIQ_APP_STORE_REGION"
+
+// lookupURL is the public iTunes Search API endpoint.
+const lookupURL = "https://itunes.apple.com/lookup"
+
+func init() {
+	resource.Register(build)
+}
+
+func build(env *resource.Env) ([]resource.Resource, error) {
+	var out []resource.Resource
+	pre := &preflight{}
+	for _, a := range env.Template.AppStore {
+		ok, err := env.Holds(a.When)
+		if err != nil {
+			return nil, fmt.Errorf("mas %q: when: %w", a.Name, err)
+		}
+		if !ok {
+			continue
+		}
+		if a.ID <= 0 {
+			return nil, fmt.Errorf("mas %q: id must be the numeric App Store ID", a.Name)
+		}
+		out = append(out, &App{spec: a, pre: pre})
+	}
+	return out, nil
+}
+
+// App is one App Store app.
+type App struct {
+	spec templates.AppStoreApp
+	pre  *preflight
+}
+
+// ID implements resource.Resource.
+func (a *App) ID() string { return resource.ID(Kind, a.spec.Name) }
+
+// Scope implements resource.Scoper.
+func (a *App) Scope() string { return resource.ScopeSystem }
+
+// Describe implements resource.Resource.
+func (a *App) Describe() string {
+	return fmt.Sprintf("install %s from the App Store (%d)", a.spec.Name, a.spec.ID)
+}
+
+// Requires implements resource.Requirer.
+func (a *App) Requires() []string {
+	return []string{resource.ID(software.Kind, "mas")}
+}
+
+// Check implements resource.Resource.
+func (a *App) Check(ctx context.Context, env *resource.Env) (resource.State, error) {
+	state := resource.State{Desired: "installed"}
+	installed, err := a.pre.installed(ctx, env)
+	if err != nil {
+		return state, err
+	}
+	if _, ok := installed[a.spec.ID]; ok {
+		state.Converged, state.Current = true, "installed"
+		return state, nil
+	}
+	state.Current = "not installed"
+	state.Blocked = a.pre.blocked(ctx, env, a.spec.ID)
+	return state, nil
+}
+
+// Component implements resource.Componenter.
+func (a *App) Component(ctx context.Context, env *resource.Env) (resource.Component, bool, error) {
+	installed, err := a.pre.installed(ctx, env)
+	if err != nil {
+		return resource.Component{}, false, err
+	}
+	version, ok := installed[a.spec.ID]
+	if !ok {
+		return resource.Component{}, false, nil
+	}
+	id := strconv.FormatInt(a.spec.ID, 10)
+	var paths []string
+	if res, err := env.Run(ctx, shell.Cmd("mdfind", "kMDItemAppStoreAdamID == "+id)); err == nil {
+		if path, _, _ := strings.Cut(strings.TrimSpace(res.Stdout), "\n"); path != "" {
+			paths = []string{path}
+		}
+	}
+	return resource.Component{
+		ID:       a.ID(),
+		Name:     a.spec.Name,
+		Version:  version,
+		PURL:     sbom.PURL("generic", a.spec.Name, version, url.Values{"mas_id": {id}}),
+		Supplier: "Mac App Store",
+		URL:      "https://apps.apple.com/app/id" + id,
+		Paths:    paths,
+	}, true, nil
+}
+
+// Fetch implements resource.Fetcher: the App Store only installs online.
+func (a *App) Fetch(context.Context, *resource.Env) error { return resource.ErrNeedsNetwork }
+
+// Apply implements resource.Resource.
+func (a *App) Apply(ctx context.Context, env *resource.Env) error {
+	_, err := env.Run(ctx, shell.Cmd("mas", "install", strconv.FormatInt(a.spec.ID, 10)))
+	return err
+}
+
+// Export implements resource.Exporter.
+func (a *App) Export(ctx context.Context, env *resource.Env) (resource.Step, error) {
+	id := strconv.FormatInt(a.spec.ID, 10)
+	return resource.Step{
+		Check:    "mas list | grep -q '^ *" + id + " '",
+		Commands: []shell.Command{shell.Cmd("mas", "install", id)},
+	}, nil
+}
+
+// Account is the App Store session as far as it can be determined.
+type Account struct {
+	// Email is the signed-in Apple ID, empty when unknown.
+	Email string
+	// SignedOut is true only when mas positively reports no session.
+	SignedOut bool
+	// Region is the storefront country code, e.g. "US".
+	Region string
+}
+
+// DetectAccount queries mas and the system locale. `mas account` is not
+// available on every macOS release, so an unknown account is not an error.
+func DetectAccount(ctx context.Context, env *resource.Env) Account {
+	var acct Account
+	res, err := env.Run(ctx, shell.Cmd("mas", "account"))
+	out := strings.TrimSpace(res.Stdout + res.Stderr)
+	switch {
+	case err == nil && strings.Contains(out, "@"):
+		acct.Email = out
+	case strings.Contains(strings.ToLower(out), "not signed in"):
+		acct.SignedOut = true
+	}
+	acct.Region = strings.ToUpper(os.Getenv(RegionEnv))
+	if acct.Region == "" {
+		// The storefront is not exposed to the command line; the locale's
+		// region matches it for almost everyone.
+		res, err := env.Run(ctx, shell.Cmd("defaults", "read", "-g", "AppleLocale"))
+		if err == nil {
+			acct.Region = localeRegion(strings.TrimSpace(res.Stdout))
+		}
+	}
+	return acct
+}
+
+// localeRegion extracts the country from a locale such as "en_GB" or
+// "en_GB@currency=EUR".
+func localeRegion(locale string) string {
+	locale, _, _ = strings.Cut(locale, "@")
+	if i := strings.LastIndexAny(locale, "_-"); i >= 0 && len(locale)-i == 3 {
+		return strings.ToUpper(locale[i+1:])
+	}
+	return ""
+}
+
+// Available reports whether the app is sold in region.
+func Available(ctx context.Context, id int64, region string) (bool, error) {
+	q := url.Values{"id": {strconv.FormatInt(id, 10)}, "country": {region}}
+	var body struct {
+		ResultCount int `json:"resultCount"`
+	}
+	if err := fetch.JSON(ctx, lookupURL+"?"+q.Encode(), &body); err != nil {
+		return false, err
+	}
+	return body.ResultCount > 0, nil
+}
+
+// preflight caches the per-run account and inventory lookups shared by every
+// App resource.
+type preflight struct {
+	listOnce sync.Once
+	list     map[int64]string
+	listErr  error
+
+	acctOnce sync.Once
+	acct     Account
+}
+
+// installed maps the IDs of the installed apps to their versions.
+func (p *preflight) installed(ctx context.Context, env *resource.Env) (map[int64]string, error) {
+	p.listOnce.Do(func() {
+		res, err := env.Run(ctx, shell.Cmd("mas", "list"))
+		var exit *shell.ExitError
+		if err != nil && !errors.As(err, &exit) {
+			p.listErr = fmt.Errorf("mas is not installed; add %q to software", "mas")
+			return
+		}
+		p.list = parseList(res.Stdout)
+	})
+	return p.list, p.listErr
+}
+
+// blocked returns why id cannot be installed, or "" when it can or the
+// answer is unknown.
+func (p *preflight) blocked(ctx context.Context, env *resource.Env, id int64) string {
+	p.acctOnce.Do(func() { p.acct = DetectAccount(ctx, env) })
+	if p.acct.SignedOut {
+		return "not signed in to the App Store; sign in with the App Store app first"
+	}
+	if p.acct.Region == "" {
+		return ""
+	}
+	ok, err := Available(ctx, id, p.acct.Region)
+	if err != nil {
+		env.Log("could not check App Store availability of %d: %v", id, err)
+		return ""
+	}
+	if !ok {
+		return fmt.Sprintf("not available in the %s App Store", p.acct.Region)
+	}
+	return ""
+}
+
+// parseList reads `mas list` output, "497799835  Xcode  (15.0)", into
+// versions by app ID.
+func parseList(out string) map[int64]string {
+	ids := map[int64]string{}
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if id, err := strconv.ParseInt(fields[0], 10, 64); err == nil {
+			ids[id] = ""
+			if last := fields[len(fields)-1]; len(fields) > 2 && strings.HasPrefix(last, "(") {
+				ids[id] = strings.Trim(last, "()")
+			}
+		}
+	}
+	return ids
+}
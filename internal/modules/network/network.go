@@ -0,0 +1,226 @@
+// Package network configures DNS servers, search domains and proxies per
+// network service, and preferred Wi-Fi networks, with networksetup.
+package network
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/hmziqrs/maziq/internal/resource"
+	"github.com/hmziqrs/maziq/internal/shell"
+	"github.com/hmziqrs/maziq/internal/templates"
+)
+
+// Kind is the resource kind for network settings.
+const Kind = "network"
+
+func init() {
+	resource.Register(build)
+}
+
+func build(env *resource.Env) ([]resource.Resource, error) {
+	var out []resource.Resource
+	for _, n := range env.Template.Network {
+		ok, err := env.Holds(n.When)
+		if err != nil {
+			return nil, fmt.Errorf("network %q: when: %w", n.Service, err)
+		}
+		if !ok {
+			continue
+		}
+		if n.Service == "" {
+			return nil, fmt.Errorf("network: service is required")
+		}
+		svc := n.Service
+		if n.DNS != nil {
+			out = append(out, listSetting(svc, "dns", "-getdnsservers", "-setdnsservers", expandAll(env, n.DNS)))
+		}
+		if n.SearchDomains != nil {
+			out = append(out, listSetting(svc, "search-domains", "-getsearchdomains", "-setsearchdomains", expandAll(env, n.SearchDomains)))
+		}
+		if n.ProxyBypass != nil {
+			out = append(out, listSetting(svc, "proxy-bypass", "-getproxybypassdomains", "-setproxybypassdomains", expandAll(env, n.ProxyBypass)))
+		}
+		for _, p := range []struct{ name, value, get, set, state string }{
+			{"web-proxy", n.WebProxy, "-getwebproxy", "-setwebproxy", "-setwebproxystate"},
+			{"secure-web-proxy", n.SecureWebProxy, "-getsecurewebproxy", "-setsecurewebproxy", "-setsecurewebproxystate"},
+			{"socks-proxy", n.SOCKSProxy, "-getsocksfirewallproxy", "-setsocksfirewallproxy", "-setsocksfirewallproxystate"},
+		} {
+			if p.value == "" {
+				continue
+			}
+			s, err := proxySetting(svc, p.name, env.Expand(p.value), p.get, p.set, p.state)
+			if err != nil {
+				return nil, fmt.Errorf("network %q: %w", svc, err)
+			}
+			out = append(out, s)
+		}
+		if n.AutoProxyURL != "" {
+			out = append(out, autoProxySetting(svc, env.Expand(n.AutoProxyURL)))
+		}
+	}
+	return out, nil
+}
+
+func expandAll(env *resource.Env, values []string) []string {
+	out := make([]string, len(values))
+	for i, v := range values {
+		out[i] = env.Expand(v)
+	}
+	return out
+}
+
+// Setting is one networksetup value on one service.
+type Setting struct {
+	service string
+	name    string
+	desired string
+	// get reads the current value, normalised to compare with desired.
+	get func(ctx context.Context, env *resource.Env) (string, error)
+	// set converges it.
+	set [][]string
+}
+
+// ID implements resource.Resource.
+func (s *Setting) ID() string { return resource.ID(Kind, s.service+"/"+s.name) }
+
+// Scope implements resource.Scoper.
+func (s *Setting) Scope() string { return resource.ScopeSystem }
+
+// Describe implements resource.Resource.
+func (s *Setting) Describe() string {
+	return fmt.Sprintf("set %s %s to %s", s.service, s.name, s.desired)
+}
+
+// Check implements resource.Resource.
+func (s *Setting) Check(ctx context.Context, env *resource.Env) (resource.State, error) {
+	state := resource.State{Desired: s.desired}
+	current, err := s.get(ctx, env)
+	if err != nil {
+		return state, err
+	}
+	state.Current, state.Converged = current, current == s.desired
+	return state, nil
+}
+
+// Apply implements resource.Resource. networksetup needs admin rights to
+// change settings, so it runs under sudo.
+func (s *Setting) Apply(ctx context.Context, env *resource.Env) error {
+	for _, args := range s.set {
+		if _, err := env.Run(ctx, shell.Command{Name: "networksetup", Args: args, Sudo: true}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func networksetup(ctx context.Context, env *resource.Env, args ...string) (string, error) {
+	res, err := env.Run(ctx, shell.Cmd("networksetup", args...))
+	if err != nil {
+		return "", err
+	}
+	out := strings.TrimSpace(res.Stdout)
+	if strings.Contains(out, "is not a recognized network service") {
+		return "", fmt.Errorf("%s", out)
+	}
+	return out, nil
+}
+
+// listSetting manages a list value. networksetup reports an empty list as a
+// sentence ("There aren't any DNS Servers set on Wi-Fi.") and clears it with
+// the literal argument "Empty".
+func listSetting(service, name, get, set string, values []string) *Setting {
+	desired := strings.Join(values, ", ")
+	if len(values) == 0 {
+		desired = "none"
+	}
+	args := append([]string{set, service}, values...)
+	if len(values) == 0 {
+		args = []string{set, service, "Empty"}
+	}
+	return &Setting{
+		service: service,
+		name:    name,
+		desired: desired,
+		get: func(ctx context.Context, env *resource.Env) (string, error) {
+			out, err := networksetup(ctx, env, get, service)
+			if err != nil {
+				return "", err
+			}
+			var items []string
+			for _, line := range strings.Split(out, "\n") {
+				line = strings.TrimSpace(line)
+				if line == "" || strings.Contains(line, " ") {
+					continue
+				}
+				items = append(items, line)
+			}
+			if len(items) == 0 {
+				return "none", nil
+			}
+			return strings.Join(items, ", "), nil
+		},
+		set: [][]string{args},
+	}
+}
+
+// proxySetting manages a host:port proxy, or disables it for "off".
+func proxySetting(service, name, value, get, set, state string) (*Setting, error) {
+	s := &Setting{service: service, name: name, desired: value}
+	if value == templates.ProxyOff {
+		s.set = [][]string{{state, service, "off"}}
+	} else {
+		host, port, err := net.SplitHostPort(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: want host:port: %w", name, err)
+		}
+		s.set = [][]string{{set, service, host, port}, {state, service, "on"}}
+	}
+	s.get = func(ctx context.Context, env *resource.Env) (string, error) {
+		out, err := networksetup(ctx, env, get, service)
+		if err != nil {
+			return "", err
+		}
+		f := fields(out)
+		if f["Enabled"] != "Yes" {
+			return templates.ProxyOff, nil
+		}
+		return net.JoinHostPort(f["Server"], f["Port"]), nil
+	}
+	return s, nil
+}
+
+// autoProxySetting manages a PAC URL, or disables it for "off".
+func autoProxySetting(service, url string) *Setting {
+	s := &Setting{service: service, name: "auto-proxy", desired: url}
+	if url == templates.ProxyOff {
+		s.set = [][]string{{"-setautoproxystate", service, "off"}}
+	} else {
+		s.set = [][]string{{"-setautoproxyurl", service, url}, {"-setautoproxystate", service, "on"}}
+	}
+	s.get = func(ctx context.Context, env *resource.Env) (string, error) {
+		out, err := networksetup(ctx, env, "-getautoproxyurl", service)
+		if err != nil {
+			return "", err
+		}
+		f := fields(out)
+		if f["Enabled"] != "Yes" {
+			return templates.ProxyOff, nil
+		}
+		return f["URL"], nil
+	}
+	return s
+}
+
+// fields parses networksetup's "Key: value" output.
+func fields(out string) map[string]string {
+	m := map[string]string{}
+	for _, line := range strings.Split(out, "\n") {
+		if k, v, ok := strings.Cut(line, ":"); ok {
+			m[strings.TrimSpace(k)] = strings.TrimSpace(v)
+		}
+	}
+	return m
+}
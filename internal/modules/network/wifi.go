@@ -0,0 +1,174 @@
+package network
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/hmziqrs/maziq/internal/resource"
+	"github.com/hmziqrs/maziq/internal/shell"
+	"github.com/hmziqrs/maziq/internal/templates"
+)
+
+// WiFiKind is the resource kind for preferred wireless networks.
+const WiFiKind = "wifi"
+
+func init() {
+	resource.Register(buildWiFi)
+}
+
+func buildWiFi(env *resource.Env) ([]resource.Resource, error) {
+	var out []resource.Resource
+	dev := &device{}
+	for _, w := range env.Template.WiFi {
+		ok, err := env.Holds(w.When)
+		if err != nil {
+			return nil, fmt.Errorf("wifi %q: when: %w", w.SSID, err)
+		}
+		if !ok {
+			continue
+		}
+		if w.SSID == "" {
+			return nil, fmt.Errorf("wifi: ssid is required")
+		}
+		if w.Security == "" {
+			w.Security = "WPA2"
+		}
+		if w.Security != "OPEN" && w.Secret == "" {
+			return nil, fmt.Errorf("wifi %q: %s networks need a secret", w.SSID, w.Security)
+		}
+		out = append(out, &WiFi{spec: w, index: len(out), dev: dev})
+	}
+	return out, nil
+}
+
+// WiFi is one preferred network at a fixed position in the preferred list.
+type WiFi struct {
+	spec  templates.WiFi
+	index int
+	dev   *device
+}
+
+// ID implements resource.Resource.
+func (w *WiFi) ID() string { return resource.ID(WiFiKind, w.spec.SSID) }
+
+// Scope implements resource.Scoper.
+func (w *WiFi) Scope() string { return resource.ScopeSystem }
+
+// Describe implements resource.Resource.
+func (w *WiFi) Describe() string {
+	return fmt.Sprintf("prefer %s network %q at position %d", w.spec.Security, w.spec.SSID, w.index+1)
+}
+
+// Check implements resource.Resource.
+func (w *WiFi) Check(ctx context.Context, env *resource.Env) (resource.State, error) {
+	state := resource.State{Desired: "position " + strconv.Itoa(w.index+1)}
+	iface, err := w.iface(ctx, env)
+	if err != nil {
+		return state, err
+	}
+	list, err := preferred(ctx, env, iface)
+	if err != nil {
+		return state, err
+	}
+	switch i := slices.Index(list, w.spec.SSID); i {
+	case -1:
+		state.Current = "not known"
+	default:
+		state.Current = "position " + strconv.Itoa(i+1)
+		state.Converged = i == w.index
+	}
+	return state, nil
+}
+
+// Apply implements resource.Resource. The network is removed and re-added at
+// its index, which both stores the password and fixes its priority. The
+// password is piped in on stdin so it never appears in a logged command line.
+func (w *WiFi) Apply(ctx context.Context, env *resource.Env) error {
+	iface, err := w.iface(ctx, env)
+	if err != nil {
+		return err
+	}
+	var password string
+	if w.spec.Secret != "" {
+		if password, err = env.Secrets.Get(ctx, w.spec.Secret); err != nil {
+			return err
+		}
+	}
+	list, err := preferred(ctx, env, iface)
+	if err != nil {
+		return err
+	}
+	if slices.Contains(list, w.spec.SSID) {
+		if _, err := env.Run(ctx, shell.Command{Name: "networksetup", Args: []string{"-removepreferredwirelessnetwork", iface, w.spec.SSID}, Sudo: true}); err != nil {
+			return err
+		}
+	}
+	script := `read -r pw; exec networksetup -addpreferredwirelessnetworkatindex "$1" "$2" "$3" "$4" ${pw:+"$pw"}`
+	_, err = env.Run(ctx, shell.Command{
+		Name:  "/bin/bash",
+		Args:  []string{"-c", script, "maziq", iface, w.spec.SSID, strconv.Itoa(w.index), w.spec.Security},
+		Stdin: password + "\n",
+		Sudo:  true,
+	})
+	return err
+}
+
+func (w *WiFi) iface(ctx context.Context, env *resource.Env) (string, error) {
+	if w.spec.Interface != "" {
+		return w.spec.Interface, nil
+	}
+	return w.dev.get(ctx, env)
+}
+
+// device caches the detected Wi-Fi interface for a run.
+type device struct {
+	once sync.Once
+	name string
+	err  error
+}
+
+func (d *device) get(ctx context.Context, env *resource.Env) (string, error) {
+	d.once.Do(func() {
+		out, err := networksetup(ctx, env, "-listallhardwareports")
+		if err != nil {
+			d.err = err
+			return
+		}
+		// Blocks of "Hardware Port: Wi-Fi\nDevice: en0\nEthernet Address: …".
+		wifi := false
+		for _, line := range strings.Split(out, "\n") {
+			k, v, _ := strings.Cut(line, ":")
+			switch strings.TrimSpace(k) {
+			case "Hardware Port":
+				wifi = strings.TrimSpace(v) == "Wi-Fi" || strings.TrimSpace(v) == "AirPort"
+			case "Device":
+				if wifi {
+					d.name = strings.TrimSpace(v)
+					return
+				}
+			}
+		}
+		d.err = fmt.Errorf("no Wi-Fi interface found; set interface in the template")
+	})
+	return d.name, d.err
+}
+
+// preferred returns the preferred networks on iface in priority order.
+func preferred(ctx context.Context, env *resource.Env, iface string) ([]string, error) {
+	out, err := networksetup(ctx, env, "-listpreferredwirelessnetworks", iface)
+	if err != nil {
+		return nil, err
+	}
+	var list []string
+	for _, line := range strings.Split(out, "\n") {
+		// The header is unindented; entries are tab-indented.
+		if strings.HasPrefix(line, "\t") {
+			list = append(list, strings.TrimSpace(line))
+		}
+	}
+	return list, nil
+}
@@ -0,0 +1,191 @@
+// Package printers adds CUPS print queues with lpadmin.
+package printers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hmziqrs/maziq/internal/resource"
+	"github.com/hmziqrs/maziq/internal/shell"
+	"github.com/hmziqrs/maziq/internal/templates"
+)
+
+// Kind is the resource kind for printers.
+const Kind = "printer"
+
+func init() {
+	resource.Register(build)
+}
+
+func build(env *resource.Env) ([]resource.Resource, error) {
+	var out []resource.Resource
+	for _, p := range env.Template.Printers {
+		ok, err := env.Holds(p.When)
+		if err != nil {
+			return nil, fmt.Errorf("printers %q: when: %w", p.Name, err)
+		}
+		if !ok {
+			continue
+		}
+		if err := ValidName(p.Name); err != nil {
+			return nil, fmt.Errorf("printers %q: %w", p.Name, err)
+		}
+		if p.Address == "" {
+			return nil, fmt.Errorf("printers %q: address is required", p.Name)
+		}
+		p.Address = env.Expand(p.Address)
+		if p.Driver == "" {
+			p.Driver = "everywhere"
+		}
+		out = append(out, &Printer{spec: p})
+	}
+	return out, nil
+}
+
+// ValidName reports whether name can be a CUPS queue name: printable
+// characters other than space, tab, "/" and "#".
+func ValidName(name string) error {
+	if name == "" {
+		return errors.New("name is required")
+	}
+	if i := strings.IndexAny(name, " \t/#"); i >= 0 {
+		return fmt.Errorf("queue names cannot contain %q; use description for a display name", name[i])
+	}
+	return nil
+}
+
+// Printer is one print queue.
+type Printer struct {
+	spec templates.Printer
+}
+
+// ID implements resource.Resource.
+func (p *Printer) ID() string { return resource.ID(Kind, p.spec.Name) }
+
+// Scope implements resource.Scoper.
+func (p *Printer) Scope() string { return resource.ScopeSystem }
+
+// Describe implements resource.Resource.
+func (p *Printer) Describe() string {
+	desc := fmt.Sprintf("add printer %s at %s", p.spec.Name, p.spec.Address)
+	if p.spec.Default {
+		desc += " as default"
+	}
+	return desc
+}
+
+// Check implements resource.Resource.
+func (p *Printer) Check(ctx context.Context, env *resource.Env) (resource.State, error) {
+	state := resource.State{Desired: p.spec.Address}
+	if p.spec.Default {
+		state.Desired += " (default)"
+	}
+	res, err := env.Run(ctx, shell.Cmd("lpstat", "-v", p.spec.Name))
+	if err != nil {
+		if errors.As(err, new(*shell.ExitError)) {
+			state.Current = "not installed"
+			return state, nil
+		}
+		return state, err
+	}
+	// "device for Office_LaserJet: ipp://10.0.0.40/ipp/print"
+	_, uri, _ := strings.Cut(strings.TrimSpace(res.Stdout), ": ")
+	state.Current = uri
+	state.Converged = uri == p.spec.Address
+	if p.spec.Default {
+		def, err := Default(ctx, env)
+		if err != nil {
+			return state, err
+		}
+		if def == p.spec.Name {
+			state.Current += " (default)"
+		} else {
+			state.Converged = false
+		}
+	}
+	return state, nil
+}
+
+// Apply implements resource.Resource. lpadmin replaces an existing queue of
+// the same name, so the same command both adds and repairs it.
+func (p *Printer) Apply(ctx context.Context, env *resource.Env) error {
+	args := []string{"-p", p.spec.Name, "-E", "-v", p.spec.Address}
+	if isPPDPath(p.spec.Driver) {
+		args = append(args, "-P", env.Path(p.spec.Driver))
+	} else {
+		args = append(args, "-m", p.spec.Driver)
+	}
+	if p.spec.Description != "" {
+		args = append(args, "-D", p.spec.Description)
+	}
+	if p.spec.Location != "" {
+		args = append(args, "-L", p.spec.Location)
+	}
+	keys := make([]string, 0, len(p.spec.Options))
+	for k := range p.spec.Options {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		args = append(args, "-o", k+"="+p.spec.Options[k])
+	}
+	if _, err := env.Run(ctx, shell.Command{Name: "lpadmin", Args: args, Sudo: true}); err != nil {
+		return err
+	}
+	if p.spec.Default {
+		if _, err := env.Run(ctx, shell.Cmd("lpoptions", "-d", p.spec.Name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isPPDPath tells a PPD file path from an lpinfo model name. Model names can
+// end in .ppd too ("drv:///sample.drv/generic.ppd") but are never paths.
+func isPPDPath(driver string) bool {
+	return strings.HasPrefix(driver, "/") || strings.HasPrefix(driver, "~/") || strings.HasPrefix(driver, "./")
+}
+
+// Assertions implements resource.Asserter.
+func (p *Printer) Assertions() []resource.Assertion {
+	as := []resource.Assertion{
+		{
+			Name:   p.spec.Name + " printer exists",
+			Run:    "lpstat -v " + shell.Quote(p.spec.Name),
+			Expect: p.spec.Address,
+		},
+		{
+			Name:   p.spec.Name + " printer accepts jobs",
+			Run:    "lpstat -a " + shell.Quote(p.spec.Name),
+			Expect: "accepting requests",
+		},
+	}
+	if p.spec.Default {
+		as = append(as, resource.Assertion{
+			Name:   p.spec.Name + " is the default printer",
+			Run:    "lpstat -d",
+			Expect: p.spec.Name,
+		})
+	}
+	return as
+}
+
+// Default returns the current default destination, or "" when none is set.
+func Default(ctx context.Context, env *resource.Env) (string, error) {
+	res, err := env.Run(ctx, shell.Cmd("lpstat", "-d"))
+	if err != nil {
+		if errors.As(err, new(*shell.ExitError)) {
+			return "", nil
+		}
+		return "", err
+	}
+	// "system default destination: Office_LaserJet" or "no system default destination"
+	_, name, ok := strings.Cut(strings.TrimSpace(res.Stdout), ": ")
+	if !ok {
+		return "", nil
+	}
+	return name, nil
+}
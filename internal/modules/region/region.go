@@ -0,0 +1,105 @@
+// Package region sets the time zone, locale, measurement units and first
+// day of the week.
+package region
+
+import (
+	"context"
+	"strings"
+
+	"github.com/hmziqrs/maziq/internal/modules/defaults"
+	"github.com/hmziqrs/maziq/internal/resource"
+	"github.com/hmziqrs/maziq/internal/shell"
+	"github.com/hmziqrs/maziq/internal/templates"
+)
+
+// Kind is the resource kind for the time zone.
+const Kind = "timezone"
+
+// AutoDomain holds "Set time zone automatically using your current
+// location".
+const AutoDomain = "/Library/Preferences/com.apple.timezone.auto"
+
+func init() {
+	resource.Register(build)
+}
+
+func build(env *resource.Env) ([]resource.Resource, error) {
+	spec := env.Template.Region
+	var out []resource.Resource
+	global := func(key string, value any) {
+		// Values are strings, bools and tables of integers, which
+		// defaults.New always accepts.
+		s, _ := defaults.New("NSGlobalDomain", key, value)
+		// Running apps keep the formats they started with.
+		s.Logout = true
+		out = append(out, s)
+	}
+	if spec.Timezone != "" {
+		auto, _ := defaults.New(AutoDomain, "Active", false)
+		auto.Sudo = true
+		out = append(out, auto, &Timezone{name: spec.Timezone, needs: []string{auto.ID()}})
+	}
+	if spec.Locale != "" {
+		global("AppleLocale", spec.Locale)
+	}
+	if units, ok := templates.MeasurementUnits[spec.Measurement]; ok {
+		global("AppleMeasurementUnits", units)
+		global("AppleMetricUnits", spec.Measurement == "metric")
+	}
+	if day, ok := templates.Weekdays[spec.FirstWeekday]; ok {
+		global("AppleFirstWeekday", map[string]any{"gregorian": day})
+	}
+	return out, nil
+}
+
+// Timezone is the system time zone.
+type Timezone struct {
+	name  string
+	needs []string
+}
+
+// ID implements resource.Resource.
+func (t *Timezone) ID() string { return resource.ID(Kind, "system") }
+
+// Scope implements resource.Scoper.
+func (t *Timezone) Scope() string { return resource.ScopeSystem }
+
+// Describe implements resource.Resource.
+func (t *Timezone) Describe() string { return "set the time zone to " + t.name }
+
+// Requires implements resource.Requirer: automatic time zone is off first.
+func (t *Timezone) Requires() []string { return t.needs }
+
+// Check implements resource.Resource. /etc/localtime links to the zone's
+// file, which needs no administrator access to read, unlike
+// systemsetup -gettimezone.
+func (t *Timezone) Check(ctx context.Context, env *resource.Env) (resource.State, error) {
+	state := resource.State{Desired: t.name}
+	res, err := env.Run(ctx, shell.Cmd("readlink", "/etc/localtime"))
+	if err != nil {
+		return state, err
+	}
+	link := strings.TrimSpace(res.Stdout)
+	if _, zone, ok := strings.Cut(link, "zoneinfo/"); ok {
+		state.Current = zone
+	} else {
+		state.Current = "unknown"
+	}
+	state.Converged = state.Current == t.name
+	return state, nil
+}
+
+// Apply implements resource.Resource.
+func (t *Timezone) Apply(ctx context.Context, env *resource.Env) error {
+	_, err := env.Run(ctx, shell.Command{Name: "systemsetup", Args: []string{"-settimezone", t.name}, Sudo: true})
+	return err
+}
+
+// Assertions implements resource.Asserter.
+func (t *Timezone) Assertions() []resource.Assertion {
+	return []resource.Assertion{{
+		Name:   "time zone is " + t.name,
+		Run:    "readlink /etc/localtime",
+		Expect: "zoneinfo/" + t.name,
+	}}
+}
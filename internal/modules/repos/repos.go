@@ -0,0 +1,213 @@
+// Package repos clones project repositories into the workspace and runs
+// their bootstrap commands.
+package repos
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/hmziqrs/maziq/internal/modules/software"
+	"github.com/hmziqrs/maziq/internal/resource"
+	"github.com/hmziqrs/maziq/internal/shell"
+	"github.com/hmziqrs/maziq/internal/templates"
+)
+
+// Kind is the resource kind for cloned repositories.
+const Kind = "repo"
+
+// DefaultWorkspace is used when the template sets none.
+const DefaultWorkspace = "~/Code"
+
+// defaultKeys are the identities ssh tries when no key is configured.
+var defaultKeys = []string{"~/.ssh/id_ed25519", "~/.ssh/id_ecdsa", "~/.ssh/id_rsa"}
+
+func init() {
+	resource.Register(build)
+}
+
+func build(env *resource.Env) ([]resource.Resource, error) {
+	spec := env.Template.Repos
+	workspace := spec.Workspace
+	if workspace == "" {
+		workspace = DefaultWorkspace
+	}
+	workspace = env.Path(workspace)
+	key := &sshKey{}
+	if spec.SSHKey != "" {
+		key.identity = env.Path(spec.SSHKey)
+		key.paths = []string{key.identity}
+	} else {
+		for _, k := range defaultKeys {
+			key.paths = append(key.paths, env.Path(k))
+		}
+	}
+	var out []resource.Resource
+	for i, r := range spec.Repos {
+		ok, err := env.Holds(r.When)
+		if err != nil {
+			return nil, fmt.Errorf("repos[%d]: when: %w", i, err)
+		}
+		if !ok {
+			continue
+		}
+		if r.URL == "" {
+			return nil, fmt.Errorf("repos[%d]: url is required", i)
+		}
+		r.URL = env.Expand(r.URL)
+		dir := env.Path(r.Dir())
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(workspace, dir)
+		}
+		out = append(out, &Repo{spec: r, dir: dir, key: key})
+	}
+	return out, nil
+}
+
+// Repo is one clone.
+type Repo struct {
+	spec templates.Repo
+	dir  string
+	key  *sshKey
+}
+
+// ID implements resource.Resource.
+func (r *Repo) ID() string { return resource.ID(Kind, r.dir) }
+
+// Describe implements resource.Resource.
+func (r *Repo) Describe() string {
+	d := "clone " + r.spec.URL + " into " + r.dir
+	if r.spec.Bootstrap != "" {
+		d += " and run its bootstrap"
+	}
+	return d
+}
+
+// Requires implements resource.Requirer. git ships with the Command Line
+// Tools.
+func (r *Repo) Requires() []string {
+	return []string{resource.ID(software.Kind, "xcode_clt")}
+}
+
+// Dir returns the clone directory.
+func (r *Repo) Dir() string { return r.dir }
+
+// Check implements resource.Resource. An existing clone counts as converged
+// whatever its branch or local changes; maziq never pulls over someone's
+// work. A directory in the way, or a missing SSH key, blocks the clone.
+func (r *Repo) Check(ctx context.Context, env *resource.Env) (resource.State, error) {
+	state := resource.State{Desired: "cloned"}
+	entries, err := os.ReadDir(r.dir)
+	switch {
+	case os.IsNotExist(err):
+		state.Current = "missing"
+	case err != nil:
+		return state, err
+	case len(entries) == 0:
+		state.Current = "empty directory"
+	default:
+		res, err := env.Run(ctx, shell.Cmd("git", "-C", r.dir, "remote", "get-url", "origin"))
+		if err != nil {
+			state.Current = "not a git clone"
+			state.Blocked = r.dir + " exists and is not a clone of " + r.spec.URL
+			return state, nil
+		}
+		origin := strings.TrimSpace(res.Stdout)
+		state.Current, state.Converged = "cloned", true
+		if origin != r.spec.URL {
+			state.Current = "cloned from " + origin
+		}
+		return state, nil
+	}
+	if r.spec.SSH() && !r.key.ok(ctx, env) {
+		state.Blocked = "no SSH key for " + r.spec.URL + "; create one or load it into ssh-agent"
+	}
+	return state, nil
+}
+
+// Fetch implements resource.Fetcher: clones come from the remote.
+func (r *Repo) Fetch(context.Context, *resource.Env) error { return resource.ErrNeedsNetwork }
+
+// Apply implements resource.Resource. New hosts are trusted on first use so
+// the clone does not stop at a host key prompt.
+func (r *Repo) Apply(ctx context.Context, env *resource.Env) error {
+	if err := os.MkdirAll(filepath.Dir(r.dir), 0o755); err != nil {
+		return err
+	}
+	args := []string{"clone"}
+	if r.spec.Branch != "" {
+		args = append(args, "--branch", r.spec.Branch)
+	}
+	args = append(args, r.spec.URL, r.dir)
+	clone := shell.Command{Name: "git", Args: args, Env: []string{"GIT_SSH_COMMAND=ssh -o StrictHostKeyChecking=accept-new -o BatchMode=yes"}}
+	if r.key.identity != "" {
+		clone.Env[0] += " -i " + shell.Quote(r.key.identity)
+	}
+	if _, err := env.Run(ctx, clone); err != nil {
+		return fmt.Errorf("clone failed: %w", err)
+	}
+	if r.spec.Bootstrap == "" {
+		return nil
+	}
+	c := shell.Script(env.Expand(r.spec.Bootstrap))
+	c.Dir = r.dir
+	if _, err := env.Run(ctx, c); err != nil {
+		return fmt.Errorf("cloned, but bootstrap failed: %w", err)
+	}
+	return nil
+}
+
+// Export implements resource.Exporter. Like Check, an existing clone is
+// left alone.
+func (r *Repo) Export(ctx context.Context, env *resource.Env) (resource.Step, error) {
+	args := []string{"clone"}
+	if r.spec.Branch != "" {
+		args = append(args, "--branch", r.spec.Branch)
+	}
+	step := resource.Step{
+		Check:    "[ -d " + shell.Quote(filepath.Join(r.dir, ".git")) + " ]",
+		Commands: []shell.Command{shell.Cmd("mkdir", "-p", filepath.Dir(r.dir)), shell.Cmd("git", append(args, r.spec.URL, r.dir)...)},
+	}
+	if r.spec.Bootstrap != "" {
+		c := shell.Script(env.Expand(r.spec.Bootstrap))
+		c.Dir = r.dir
+		step.Commands = append(step.Commands, c)
+	}
+	return step, nil
+}
+
+// Assertions implements resource.Asserter.
+func (r *Repo) Assertions() []resource.Assertion {
+	return []resource.Assertion{{
+		Name:   r.dir + " is a git clone",
+		Run:    "git -C " + shell.Quote(r.dir) + " rev-parse --is-inside-work-tree",
+		Expect: "true",
+	}}
+}
+
+// sshKey checks once per run that an SSH identity is available, either as a
+// key file or in the agent.
+type sshKey struct {
+	// identity is the configured key, passed to ssh explicitly.
+	identity string
+	paths    []string
+	once     sync.Once
+	found    bool
+}
+
+func (k *sshKey) ok(ctx context.Context, env *resource.Env) bool {
+	k.once.Do(func() {
+		for _, p := range k.paths {
+			if _, err := os.Stat(p); err == nil {
+				k.found = true
+				return
+			}
+		}
+		_, err := env.Run(ctx, shell.Cmd("ssh-add", "-l"))
+		k.found = err == nil
+	})
+	return k.found
+}
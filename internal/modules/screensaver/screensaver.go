@@ -0,0 +1,146 @@
+// Package screensaver configures the screen saver idle time, the screen lock
+// and hot corners.
+package screensaver
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/hmziqrs/maziq/internal/modules/defaults"
+	"github.com/hmziqrs/maziq/internal/resource"
+	"github.com/hmziqrs/maziq/internal/shell"
+	"github.com/hmziqrs/maziq/internal/templates"
+)
+
+// Kind is the resource kind for the screen lock policy.
+const Kind = "screenlock"
+
+// DefaultPasswordSecret holds the login password sysadminctl asks for.
+const DefaultPasswordSecret = "login-password"
+
+func init() {
+	resource.Register(build)
+}
+
+func build(env *resource.Env) ([]resource.Resource, error) {
+	spec := env.Template.Screensaver
+	var out []resource.Resource
+	if spec.Idle != nil {
+		s, err := defaults.New("com.apple.screensaver", "idleTime", *spec.Idle)
+		if err != nil {
+			return nil, err
+		}
+		s.CurrentHost = true
+		out = append(out, s)
+	}
+	corners := make([]string, 0, len(spec.HotCorners))
+	for c := range spec.HotCorners {
+		corners = append(corners, c)
+	}
+	sort.Strings(corners)
+	for _, corner := range corners {
+		prefix, ok := templates.HotCorners[corner]
+		if !ok {
+			return nil, fmt.Errorf("screensaver.hot_corners: unknown corner %q", corner)
+		}
+		hc := spec.HotCorners[corner]
+		code, ok := templates.HotCornerActions[hc.Action]
+		if !ok {
+			return nil, fmt.Errorf("screensaver.hot_corners.%s: unknown action %q", corner, hc.Action)
+		}
+		flags, err := hc.ModifierFlags()
+		if err != nil {
+			return nil, fmt.Errorf("screensaver.hot_corners.%s: %w", corner, err)
+		}
+		action, _ := defaults.New("com.apple.dock", prefix+"-corner", code)
+		modifier, _ := defaults.New("com.apple.dock", prefix+"-modifier", flags)
+		action.Restart, modifier.Restart = "Dock", "Dock"
+		out = append(out, action, modifier)
+	}
+	if spec.RequirePassword != nil {
+		secret := spec.PasswordSecret
+		if secret == "" {
+			secret = DefaultPasswordSecret
+		}
+		out = append(out, &Lock{enabled: *spec.RequirePassword, delay: spec.PasswordDelay, secret: secret})
+	}
+	return out, nil
+}
+
+// Lock is the require-password-after-sleep policy.
+type Lock struct {
+	enabled bool
+	delay   int64
+	secret  string
+}
+
+// ID implements resource.Resource.
+func (l *Lock) ID() string { return resource.ID(Kind, "password") }
+
+// Describe implements resource.Resource.
+func (l *Lock) Describe() string { return "require password " + l.desired() }
+
+func (l *Lock) desired() string {
+	switch {
+	case !l.enabled:
+		return "off"
+	case l.delay == 0:
+		return "immediately"
+	}
+	return fmt.Sprintf("after %ds", l.delay)
+}
+
+// sysadminctl logs "screenLock delay is 300 seconds", "... is immediate" or
+// "screenLock is off" to stderr.
+var lockStatus = regexp.MustCompile(`screenLock (?:delay is (immediate|\d+)|is (off))`)
+
+// Check implements resource.Resource.
+func (l *Lock) Check(ctx context.Context, env *resource.Env) (resource.State, error) {
+	state := resource.State{Desired: l.desired()}
+	res, err := env.Run(ctx, shell.Cmd("sysadminctl", "-screenLock", "status"))
+	if err != nil {
+		return state, err
+	}
+	m := lockStatus.FindStringSubmatch(res.Stderr + res.Stdout)
+	switch {
+	case m == nil:
+		state.Current = "unknown"
+	case m[2] == "off":
+		state.Current = "off"
+	case m[1] == "immediate" || m[1] == "0":
+		state.Current = "immediately"
+	default:
+		state.Current = "after " + m[1] + "s"
+	}
+	state.Converged = state.Current == state.Desired
+	return state, nil
+}
+
+// Apply implements resource.Resource. sysadminctl only changes the screen
+// lock with the user's login password, which is read from the secrets
+// provider and piped in on stdin.
+func (l *Lock) Apply(ctx context.Context, env *resource.Env) error {
+	password, err := env.Secrets.Get(ctx, l.secret)
+	if err != nil {
+		return fmt.Errorf("%w; store the login password as %q or run: sysadminctl -screenLock %s -password -", err, l.secret, l.arg())
+	}
+	_, err = env.Run(ctx, shell.Command{
+		Name:  "sysadminctl",
+		Args:  []string{"-screenLock", l.arg(), "-password", "-"},
+		Stdin: password + "\n",
+	})
+	return err
+}
+
+func (l *Lock) arg() string {
+	switch {
+	case !l.enabled:
+		return "off"
+	case l.delay == 0:
+		return "immediate"
+	}
+	return strconv.FormatInt(l.delay, 10)
+}
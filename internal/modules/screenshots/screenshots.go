@@ -0,0 +1,93 @@
+// Package screenshots configures screenshot and screen recording options:
+// format, save location, file names, window shadows and the floating
+// thumbnail.
+package screenshots
+
+import (
+	"context"
+	"os"
+
+	"github.com/hmziqrs/maziq/internal/modules/defaults"
+	"github.com/hmziqrs/maziq/internal/resource"
+)
+
+// Kind is the resource kind for the screenshot folder.
+const Kind = "screenshots"
+
+// Domain is the screencapture preferences domain.
+const Domain = "com.apple.screencapture"
+
+func init() {
+	resource.Register(build)
+}
+
+func build(env *resource.Env) ([]resource.Resource, error) {
+	spec := env.Template.Screenshots
+	var out []resource.Resource
+	add := func(domain, key string, value any) {
+		// Values are bools and strings, which defaults.New always accepts.
+		s, _ := defaults.New(domain, key, value)
+		// SystemUIServer owns the capture shortcuts and only rereads its
+		// preferences on restart.
+		s.Restart = "SystemUIServer"
+		out = append(out, s)
+	}
+	if spec.Location != "" {
+		dir := env.Path(spec.Location)
+		out = append(out, &Folder{path: dir})
+		add(Domain, "location", dir)
+	}
+	if spec.Format != "" {
+		add(Domain, "type", spec.Format)
+	}
+	if spec.Name != "" {
+		add(Domain, "name", env.Expand(spec.Name))
+	}
+	if spec.Shadow != nil {
+		add(Domain, "disable-shadow", !*spec.Shadow)
+	}
+	if spec.Thumbnail != nil {
+		add(Domain, "show-thumbnail", *spec.Thumbnail)
+	}
+	if spec.IncludeDate != nil {
+		add(Domain, "include-date", *spec.IncludeDate)
+	}
+	if spec.ShowCursor != nil {
+		add(Domain, "showsCursor", *spec.ShowCursor)
+	}
+	if spec.ShowClicks != nil {
+		add("com.apple.screencaptureui", "showsClicks", *spec.ShowClicks)
+	}
+	return out, nil
+}
+
+// Folder is the directory screenshots are saved to.
+type Folder struct {
+	path string
+}
+
+// ID implements resource.Resource.
+func (f *Folder) ID() string { return resource.ID(Kind, "folder:"+f.path) }
+
+// Describe implements resource.Resource.
+func (f *Folder) Describe() string { return "create screenshot folder " + f.path }
+
+// Check implements resource.Resource.
+func (f *Folder) Check(ctx context.Context, env *resource.Env) (resource.State, error) {
+	state := resource.State{Current: "missing", Desired: "directory"}
+	info, err := os.Stat(f.path)
+	switch {
+	case err == nil && info.IsDir():
+		state.Current, state.Converged = "directory", true
+	case err == nil:
+		state.Blocked = f.path + " exists and is not a directory"
+	case !os.IsNotExist(err):
+		return state, err
+	}
+	return state, nil
+}
+
+// Apply implements resource.Resource.
+func (f *Folder) Apply(ctx context.Context, env *resource.Env) error {
+	return os.MkdirAll(f.path, 0o755)
+}
@@ -0,0 +1,161 @@
+// Package services starts and stops Homebrew formula services.
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/hmziqrs/maziq/internal/modules/software"
+	"github.com/hmziqrs/maziq/internal/resource"
+	"github.com/hmziqrs/maziq/internal/shell"
+	"github.com/hmziqrs/maziq/internal/templates"
+)
+
+// Kind is the resource kind for brew services.
+const Kind = "service"
+
+func init() {
+	resource.Register(build)
+}
+
+func build(env *resource.Env) ([]resource.Resource, error) {
+	var out []resource.Resource
+	list := &cache{}
+	for _, s := range env.Template.Services {
+		ok, err := env.Holds(s.When)
+		if err != nil {
+			return nil, fmt.Errorf("services %q: when: %w", s.Name, err)
+		}
+		if !ok {
+			continue
+		}
+		if s.Name == "" {
+			return nil, fmt.Errorf("services: name is required")
+		}
+		switch s.State {
+		case "":
+			s.State = templates.ServiceStarted
+		case templates.ServiceStarted, templates.ServiceStopped:
+		default:
+			return nil, fmt.Errorf("services %q: state must be %q or %q", s.Name, templates.ServiceStarted, templates.ServiceStopped)
+		}
+		out = append(out, &Service{spec: s, list: list})
+	}
+	return out, nil
+}
+
+// Status is one row of `brew services list --json`.
+type Status struct {
+	Name   string `json:"name"`
+	Status string `json:"status"` // started, stopped, none, error, scheduled, unknown
+	User   string `json:"user"`
+	File   string `json:"file"`
+}
+
+// List returns every formula service Homebrew knows about.
+func List(ctx context.Context, env *resource.Env) ([]Status, error) {
+	res, err := env.Run(ctx, shell.Cmd("brew", "services", "list", "--json"))
+	if err != nil {
+		return nil, err
+	}
+	var out []Status
+	if err := json.Unmarshal([]byte(res.Stdout), &out); err != nil {
+		return nil, fmt.Errorf("brew services list: %w", err)
+	}
+	return out, nil
+}
+
+// cache shares one `brew services list` between the services of a run.
+type cache struct {
+	once   sync.Once
+	status map[string]Status
+	err    error
+}
+
+func (c *cache) get(ctx context.Context, env *resource.Env) (map[string]Status, error) {
+	c.once.Do(func() {
+		list, err := List(ctx, env)
+		if err != nil {
+			c.err = err
+			return
+		}
+		c.status = map[string]Status{}
+		for _, s := range list {
+			c.status[s.Name] = s
+		}
+	})
+	return c.status, c.err
+}
+
+// Service is one formula service in its desired state.
+type Service struct {
+	spec templates.Service
+	list *cache
+}
+
+// ID implements resource.Resource.
+func (s *Service) ID() string { return resource.ID(Kind, s.spec.Name) }
+
+// Describe implements resource.Resource.
+func (s *Service) Describe() string {
+	if s.spec.State == templates.ServiceStopped {
+		return "stop brew service " + s.spec.Name
+	}
+	return "start brew service " + s.spec.Name + " at login"
+}
+
+// Requires implements resource.Requirer.
+func (s *Service) Requires() []string {
+	return []string{resource.ID(software.Kind, "homebrew")}
+}
+
+// Check implements resource.Resource. A formula that is not installed has
+// no service entry; it counts as stopped.
+func (s *Service) Check(ctx context.Context, env *resource.Env) (resource.State, error) {
+	state := resource.State{Desired: s.spec.State}
+	status, err := s.list.get(ctx, env)
+	if err != nil {
+		return state, err
+	}
+	st, ok := status[s.spec.Name]
+	switch {
+	case !ok && s.spec.State == templates.ServiceStarted:
+		state.Current = "formula not installed"
+	case !ok:
+		state.Current, state.Converged = "not installed", true
+	default:
+		state.Current = st.Status
+		switch s.spec.State {
+		case templates.ServiceStarted:
+			state.Converged = st.Status == "started"
+		case templates.ServiceStopped:
+			state.Converged = st.Status != "started" && st.Status != "scheduled"
+		}
+	}
+	return state, nil
+}
+
+// Apply implements resource.Resource. `brew services start` also registers
+// the service to start at login.
+func (s *Service) Apply(ctx context.Context, env *resource.Env) error {
+	verb := "start"
+	if s.spec.State == templates.ServiceStopped {
+		verb = "stop"
+	}
+	_, err := env.Run(ctx, shell.Cmd("brew", "services", verb, s.spec.Name))
+	return err
+}
+
+// Assertions implements resource.Asserter.
+func (s *Service) Assertions() []resource.Assertion {
+	if s.spec.State != templates.ServiceStarted {
+		return nil
+	}
+	return []resource.Assertion{{
+		Name:   s.spec.Name + " service is running",
+		Run:    fmt.Sprintf("brew services list | awk '$1 == %q { print $2 }'", s.spec.Name),
+		Expect: "started",
+	}}
+}
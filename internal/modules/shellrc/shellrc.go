@@ -0,0 +1,644 @@
+// Package shellrc keeps a managed block in the login shell's rc file,
+// exporting the template's environment variables, ordering PATH and
+// MANPATH, loading completions and defining its aliases and functions, and
+// checks what a login shell actually ends up with.
+package shellrc
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/hmziqrs/maziq/internal/catalog"
+	"github.com/hmziqrs/maziq/internal/resource"
+	"github.com/hmziqrs/maziq/internal/shell"
+	"github.com/hmziqrs/maziq/internal/templates"
+)
+
+// Kind is the resource kind for the managed rc block.
+const Kind = "shell"
+
+// The managed block sits between these lines; the rest of the rc file is
+// the user's.
+const (
+	Begin = "# >>> maziq >>>"
+	End   = "# <<< maziq <<<"
+)
+
+func init() {
+	resource.Register(build)
+}
+
+func build(env *resource.Env) ([]resource.Resource, error) {
+	t := env.Template
+	sh := Shell(env)
+	entries, err := t.Active(env.Facts)
+	if err != nil {
+		return nil, err
+	}
+	// A demo's rc file cannot be taken back, so software alone does not
+	// bring the block in for MANPATH and completions.
+	if t.Demo != nil {
+		entries = nil
+	}
+	if len(t.Env) == 0 && len(t.SearchPath.Order) == 0 && len(t.Aliases) == 0 && len(t.Functions) == 0 && len(entries) == 0 {
+		return nil, nil
+	}
+	b := &Block{RC: env.Path(RCFile(sh)), Shell: sh, Env: map[string]string{}, Aliases: map[string]string{}, Functions: map[string]string{}}
+	if len(entries) > 0 {
+		brew, _ := templates.PathDirs("brew", env.Facts["arch"])
+		b.Brew = filepath.Dir(brew[0])
+		// fish loads completions from its own directories.
+		if t.CompletionsOn() && sh != "fish" {
+			b.CompletionDir = env.Path(CompletionDir(sh))
+		}
+		for _, e := range entries {
+			if sw, ok := catalog.Lookup(e.ID); ok && sw.Kind == catalog.KindCLI && len(sw.Version) > 0 && !slices.Contains(b.Manuals, sw.Version[0]) {
+				b.Manuals = append(b.Manuals, sw.Version[0])
+			}
+		}
+	}
+	// Names are written into the rc file and the probe's script as they
+	// are, so one that is not a plain name would break or inject into both.
+	for _, name := range slices.Sorted(maps.Keys(t.Env)) {
+		if !templates.ValidEnvName(name) {
+			return nil, fmt.Errorf("env: %q is not a valid variable name", name)
+		}
+		b.Env[name] = env.Expand(t.Env[name])
+	}
+	for _, name := range slices.Sorted(maps.Keys(t.Aliases)) {
+		if !templates.ValidCommandName(name) {
+			return nil, fmt.Errorf("aliases: %q is not a valid command name", name)
+		}
+		b.Aliases[name] = env.Expand(t.Aliases[name])
+	}
+	for _, name := range slices.Sorted(maps.Keys(t.Functions)) {
+		if !templates.ValidCommandName(name) {
+			return nil, fmt.Errorf("functions: %q is not a valid command name", name)
+		}
+		if _, ok := t.Aliases[name]; ok {
+			return nil, fmt.Errorf("functions.%s: also an alias", name)
+		}
+		b.Functions[name] = env.Expand(t.Functions[name])
+	}
+	for i, entry := range t.SearchPath.Order {
+		dirs, ok := templates.PathDirs(entry, env.Facts["arch"])
+		if !ok {
+			dirs = []string{entry}
+		}
+		for _, dir := range dirs {
+			if dir = env.Path(dir); dir == "" {
+				return nil, fmt.Errorf("path.order[%d]: directory is empty", i)
+			}
+			if !slices.Contains(b.Path, dir) {
+				b.Path = append(b.Path, dir)
+			}
+		}
+	}
+	return []resource.Resource{b}, nil
+}
+
+// Shell returns the login shell the environment is for: zsh, bash or fish,
+// and zsh for a shell MazIQ does not know.
+func Shell(env *resource.Env) string {
+	if sh := env.Facts["shell"]; sh == "bash" || sh == "fish" {
+		return sh
+	}
+	return "zsh"
+}
+
+// CompletionDir returns the directory completion scripts for sh are
+// installed in, one file per command, which the shell or the managed block
+// loads them from.
+func CompletionDir(sh string) string {
+	switch sh {
+	case "bash":
+		return "~/.local/share/bash-completion/completions"
+	case "fish":
+		return "~/.config/fish/completions"
+	default:
+		return "~/.local/share/zsh/site-functions"
+	}
+}
+
+// CompletionFile returns the name of cmd's completion script for sh.
+func CompletionFile(sh, cmd string) string {
+	switch sh {
+	case "bash":
+		return cmd
+	case "fish":
+		return cmd + ".fish"
+	default:
+		return "_" + cmd
+	}
+}
+
+// RCFile returns the rc file a login shell of the named kind reads, zsh's
+// for a shell MazIQ does not know.
+func RCFile(sh string) string {
+	switch sh {
+	case "bash":
+		return "~/.bash_profile"
+	case "fish":
+		return "~/.config/fish/config.fish"
+	default:
+		return "~/.zshrc"
+	}
+}
+
+// Block is the managed block of one rc file.
+type Block struct {
+	RC    string
+	Shell string
+	// Env maps variable names to values, which the shell expands, so they
+	// may refer to other variables.
+	Env map[string]string
+	// Path lists the directories put first on PATH, in order.
+	Path []string
+	// Aliases map names to the command they stand for; Functions map names
+	// to a body in the shell's own syntax.
+	Aliases   map[string]string
+	Functions map[string]string
+	// Brew is Homebrew's prefix, when the template installs software: the
+	// block puts its man pages on MANPATH and, unless CompletionDir is "",
+	// loads its completions.
+	Brew string
+	// CompletionDir is where MazIQ installs completion scripts.
+	CompletionDir string
+	// Manuals lists the template's CLIs, whose man pages man should find
+	// when Homebrew installed them.
+	Manuals []string
+}
+
+// ID implements resource.Resource.
+func (b *Block) ID() string { return resource.ID(Kind, b.RC) }
+
+// Describe implements resource.Resource.
+func (b *Block) Describe() string {
+	var what []string
+	if len(b.Env) > 0 {
+		what = append(what, fmt.Sprintf("export %d variable(s)", len(b.Env)))
+	}
+	if len(b.Path) > 0 {
+		what = append(what, fmt.Sprintf("put %d directories first on PATH", len(b.Path)))
+	}
+	if b.Brew != "" {
+		what = append(what, "add Homebrew's man pages to MANPATH")
+	}
+	if b.CompletionDir != "" {
+		what = append(what, "load completions")
+	}
+	if n := len(b.Aliases) + len(b.Functions); n > 0 {
+		what = append(what, fmt.Sprintf("define %d alias(es) and function(s)", n))
+	}
+	return strings.Join(what, " and ") + " from " + b.RC
+}
+
+// Fingerprint implements resource.Fingerprinter.
+func (b *Block) Fingerprint() string { return b.render() }
+
+// Check implements resource.Resource. Once the block is in place, a login
+// shell is started to compare what it ends up with: a line after the block,
+// or a file the shell reads later, may set a variable again, put other
+// directories first on PATH, hiding commands in the template's, or define
+// an alias again. The block is then moved to the end of the rc file; when
+// it is already there, the cause is elsewhere and only reported. Aliases
+// and functions that replace the user's own, or hide a command, are
+// reported too.
+func (b *Block) Check(ctx context.Context, env *resource.Env) (resource.State, error) {
+	state := resource.State{Desired: b.summary()}
+	data, err := os.ReadFile(b.RC)
+	if err != nil && !os.IsNotExist(err) {
+		return state, err
+	}
+	start, end, ok := bounds(data)
+	switch {
+	case !ok:
+		state.Current = "no maziq block"
+		return state, nil
+	case string(data[start:end]) != b.render():
+		state.Current = "block differs"
+		return state, nil
+	}
+	state.Current, state.Converged = state.Desired, true
+	var notes []string
+	problems, path, err := b.probe(ctx, env)
+	if err != nil {
+		notes = append(notes, "could not start a login shell to compare: "+err.Error())
+	}
+	before, after := b.redefined(data, start, end)
+	if len(after) > 0 {
+		problems = append(problems, "defined again after the block: "+strings.Join(after, ", "))
+	}
+	if len(before) > 0 {
+		notes = append(notes, "replaces your own "+strings.Join(before, ", "))
+	}
+	if hidden := b.hides(path); len(hidden) > 0 {
+		notes = append(notes, strings.Join(hidden, ", "))
+	}
+	switch {
+	case len(problems) == 0:
+	case len(bytes.TrimSpace(data[end:])) > 0:
+		state.Current, state.Converged = "overridden after the block", false
+		notes = append(problems, notes...)
+	default:
+		notes = append([]string{"a login shell overrides the block: " + strings.Join(problems, "; ")}, notes...)
+	}
+	state.Warning = strings.Join(notes, "; ")
+	return state, nil
+}
+
+// Verify implements resource.Verifier; the check reads the rc file and
+// starts a shell, both locally.
+func (b *Block) Verify(ctx context.Context, env *resource.Env) (resource.State, error) {
+	return b.Check(ctx, env)
+}
+
+// Apply implements resource.Resource.
+func (b *Block) Apply(ctx context.Context, env *resource.Env) error {
+	current, err := os.ReadFile(b.RC)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(b.RC), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(b.RC, b.splice(current), 0o644)
+}
+
+// Diff implements resource.Differ.
+func (b *Block) Diff(ctx context.Context, env *resource.Env) (resource.FileChange, error) {
+	c := resource.FileChange{Path: b.RC}
+	current, err := os.ReadFile(b.RC)
+	if err != nil && !os.IsNotExist(err) {
+		return c, err
+	}
+	if err == nil {
+		c.Current = current
+	}
+	c.Desired = b.splice(current)
+	return c, nil
+}
+
+// Assertions implements resource.Asserter.
+func (b *Block) Assertions() []resource.Assertion {
+	var out []resource.Assertion
+	for _, name := range b.names() {
+		out = append(out, resource.Assertion{
+			Name:   name + " is set in a login shell",
+			Run:    fmt.Sprintf("%s -l -i -c 'test -n \"$%s\" && echo set' 2>/dev/null", b.Shell, name),
+			Expect: "set",
+		})
+	}
+	for _, name := range b.defined() {
+		out = append(out, resource.Assertion{
+			Name:   name + " is defined in a login shell",
+			Run:    fmt.Sprintf("%s -l -i -c 'type %s >/dev/null && echo defined' 2>/dev/null", b.Shell, name),
+			Expect: "defined",
+		})
+	}
+	return out
+}
+
+func (b *Block) summary() string {
+	var what []string
+	if len(b.Env) > 0 {
+		what = append(what, fmt.Sprintf("%d variable(s)", len(b.Env)))
+	}
+	if len(b.Path) > 0 {
+		what = append(what, "PATH ordered")
+	}
+	if b.Brew != "" {
+		what = append(what, "MANPATH set")
+	}
+	if b.CompletionDir != "" {
+		what = append(what, "completions loaded")
+	}
+	if n := len(b.Aliases) + len(b.Functions); n > 0 {
+		what = append(what, fmt.Sprintf("%d alias(es) and function(s)", n))
+	}
+	return strings.Join(what, ", ")
+}
+
+func (b *Block) names() []string {
+	names := make([]string, 0, len(b.Env))
+	for name := range b.Env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// defined returns the names of the aliases and functions, sorted.
+func (b *Block) defined() []string {
+	names := make([]string, 0, len(b.Aliases)+len(b.Functions))
+	for name := range b.Aliases {
+		names = append(names, name)
+	}
+	for name := range b.Functions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// render returns the block, markers included, in the shell's syntax.
+func (b *Block) render() string {
+	var s strings.Builder
+	s.WriteString(Begin + "\n")
+	s.WriteString("# Managed by maziq; edit the template instead.\n")
+	for _, name := range b.names() {
+		if b.Shell == "fish" {
+			fmt.Fprintf(&s, "set -gx %s %s\n", name, quote(b.Shell, b.Env[name]))
+		} else {
+			fmt.Fprintf(&s, "export %s=%s\n", name, quote(b.Shell, b.Env[name]))
+		}
+	}
+	// After the variables, so the directories may use them.
+	if len(b.Path) > 0 {
+		if b.Shell == "fish" {
+			var dirs []string
+			for _, dir := range b.Path {
+				dirs = append(dirs, quote(b.Shell, dir))
+			}
+			fmt.Fprintf(&s, "set -gx PATH %s $PATH\n", strings.Join(dirs, " "))
+		} else {
+			fmt.Fprintf(&s, "export PATH=%s\n", quote(b.Shell, strings.Join(append(slices.Clone(b.Path), "$PATH"), ":")))
+		}
+	}
+	// An empty MANPATH entry stands for man's default search path, which
+	// setting MANPATH would otherwise replace.
+	if man := b.Brew + "/share/man"; b.Brew != "" && b.Shell == "fish" {
+		fmt.Fprintf(&s, "set -gx MANPATH %s $MANPATH \"\"\n", quote(b.Shell, man))
+	} else if b.Brew != "" {
+		fmt.Fprintf(&s, "export MANPATH=%s\n", quote(b.Shell, man+":$MANPATH"))
+	}
+	switch {
+	case b.CompletionDir == "":
+	case b.Shell == "bash":
+		// bash-completion loads scripts from CompletionDir on first use.
+		script := b.Brew + "/etc/profile.d/bash_completion.sh"
+		fmt.Fprintf(&s, "[ -r %s ] && . %s\n", quote(b.Shell, script), quote(b.Shell, script))
+	default:
+		fmt.Fprintf(&s, "fpath=(%s %s $fpath)\n", quote(b.Shell, b.CompletionDir), quote(b.Shell, b.Brew+"/share/zsh/site-functions"))
+		s.WriteString("autoload -Uz compinit && compinit\n")
+	}
+	// Last, so they can use the commands on PATH.
+	for _, name := range b.defined() {
+		body, isFunc := b.Functions[name]
+		switch {
+		case !isFunc && b.Shell == "fish":
+			fmt.Fprintf(&s, "alias %s %s\n", name, literal(b.Shell, b.Aliases[name]))
+		case !isFunc:
+			fmt.Fprintf(&s, "alias %s=%s\n", name, literal(b.Shell, b.Aliases[name]))
+		case b.Shell == "fish":
+			fmt.Fprintf(&s, "function %s\n%s\nend\n", name, indent(body))
+		default:
+			fmt.Fprintf(&s, "%s() {\n%s\n}\n", name, indent(body))
+		}
+	}
+	s.WriteString(End + "\n")
+	return s.String()
+}
+
+// splice returns rc with the block replaced, or appended when it has none.
+// A block that is already up to date is moved to the end, as Apply only
+// runs for it when something after it overrides it.
+func (b *Block) splice(rc []byte) []byte {
+	block := b.render()
+	start, end, ok := bounds(rc)
+	if ok && string(rc[start:end]) != block {
+		return append(append(append([]byte{}, rc[:start]...), block...), rc[end:]...)
+	}
+	var out []byte
+	if ok {
+		out = append(append(out, rc[:start]...), rc[end:]...)
+	} else {
+		out = append(out, rc...)
+	}
+	if len(out) > 0 && !bytes.HasSuffix(out, []byte("\n")) {
+		out = append(out, '\n')
+	}
+	if len(out) > 0 && !bytes.HasSuffix(out, []byte("\n\n")) {
+		out = append(out, '\n')
+	}
+	return append(out, block...)
+}
+
+// probe starts a login shell that prints its PATH, for every variable the
+// value it has and the value the block gives it, and the CLIs whose
+// Homebrew man pages man does not find. It returns what does not match the
+// block: variables set to something else, commands in the block's
+// directories that an earlier PATH entry hides, and those man pages.
+func (b *Block) probe(ctx context.Context, env *resource.Env) ([]string, []string, error) {
+	names := b.names()
+	manuals := b.manuals()
+	var script strings.Builder
+	// The rc file may print; only what follows the marker is read.
+	fmt.Fprintf(&script, "printf '%%s\\n' %s; printf '%%s\\0' \"$PATH\"", marker)
+	for _, name := range names {
+		fmt.Fprintf(&script, "; printf '%%s\\0' \"$%s\" %s", name, quote(b.Shell, b.Env[name]))
+	}
+	for _, cmd := range manuals {
+		if b.Shell == "fish" {
+			fmt.Fprintf(&script, "; man -w %s >/dev/null 2>&1; or printf '%%s\\0' %s", cmd, cmd)
+		} else {
+			fmt.Fprintf(&script, "; man -w %s >/dev/null 2>&1 || printf '%%s\\0' %s", cmd, cmd)
+		}
+	}
+	res, err := env.Run(ctx, shell.Cmd(b.Shell, "-l", "-i", "-c", script.String()))
+	if err != nil {
+		return nil, nil, err
+	}
+	_, out, ok := strings.Cut(res.Stdout, marker+"\n")
+	if !ok {
+		return nil, nil, nil
+	}
+	values := strings.Split(out, "\x00")
+	// fish prints PATH with spaces between its entries.
+	path := strings.FieldsFunc(values[0], func(r rune) bool { return r == ':' || b.Shell == "fish" && r == ' ' })
+	var problems []string
+	for i, name := range names {
+		if 2*i+2 >= len(values) {
+			break
+		}
+		if have, want := values[2*i+1], values[2*i+2]; have != want {
+			problems = append(problems, fmt.Sprintf("%s is %q, not %q", name, have, want))
+		}
+	}
+	if n := 1 + 2*len(names); n < len(values) {
+		if missing := slices.DeleteFunc(values[n:], func(v string) bool { return v == "" }); len(missing) > 0 {
+			problems = append(problems, "man does not find the pages of "+strings.Join(missing, ", "))
+		}
+	}
+	if hidden := b.shadowed(path); len(hidden) > 0 {
+		if len(hidden) > 5 {
+			hidden = append(hidden[:5], fmt.Sprintf("%d more", len(hidden)-5))
+		}
+		problems = append(problems, "PATH hides "+strings.Join(hidden, ", "))
+	}
+	return problems, path, nil
+}
+
+// manuals returns the template's CLIs that have man pages in Homebrew's
+// prefix.
+func (b *Block) manuals() []string {
+	if b.Brew == "" {
+		return nil
+	}
+	var out []string
+	for _, cmd := range b.Manuals {
+		if pages, _ := filepath.Glob(filepath.Join(b.Brew, "share", "man", "man*", cmd+".*")); len(pages) > 0 {
+			out = append(out, cmd)
+		}
+	}
+	return out
+}
+
+// definition matches a line that defines an alias or a function, in any of
+// the shells' syntaxes.
+var definition = regexp.MustCompile(`^\s*(?:alias\s+([^\s=]+)|function\s+([^\s({;]+)|([A-Za-z0-9_.:-]+)\s*\(\))`)
+
+// redefined returns the block's aliases and functions that rc defines again
+// outside the block, which spans start to end, as "name (line n)": those
+// defined before the block, which it replaces, and those defined after it,
+// which replace it.
+func (b *Block) redefined(rc []byte, start, end int) (before, after []string) {
+	offset := 0
+	for i, line := range strings.SplitAfter(string(rc), "\n") {
+		at := offset
+		offset += len(line)
+		if at >= start && at < end {
+			continue
+		}
+		m := definition.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		name := m[1] + m[2] + m[3]
+		_, isAlias := b.Aliases[name]
+		_, isFunc := b.Functions[name]
+		if !isAlias && !isFunc {
+			continue
+		}
+		where := fmt.Sprintf("%s (line %d)", name, i+1)
+		if at < start {
+			before = append(before, where)
+		} else {
+			after = append(after, where)
+		}
+	}
+	return before, after
+}
+
+// hides returns the aliases and functions named like a command on path.
+// An alias that runs the command it is named after, such as ls = "ls -G",
+// only adds options, and so does a function that calls it with `command`.
+func (b *Block) hides(path []string) []string {
+	var out []string
+	for _, name := range b.defined() {
+		if words := strings.Fields(b.Aliases[name]); len(words) > 0 && words[0] == name {
+			continue
+		}
+		if body, ok := b.Functions[name]; ok && strings.Contains(body, "command "+name) {
+			continue
+		}
+		for _, dir := range path {
+			if cmd := filepath.Join(dir, name); executable(cmd) {
+				out = append(out, fmt.Sprintf("%s hides %s", name, cmd))
+				break
+			}
+		}
+	}
+	return out
+}
+
+// shadowed returns the commands in the block's directories that a
+// directory before them on path hides, like macOS's git before Homebrew's.
+// A directory the block itself puts earlier is meant to win.
+func (b *Block) shadowed(path []string) []string {
+	var out []string
+	for i, dir := range b.Path {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, e := range entries {
+			if !executable(filepath.Join(dir, e.Name())) {
+				continue
+			}
+			for _, first := range path {
+				if first == dir || slices.Contains(b.Path[:i], first) {
+					break
+				}
+				if executable(filepath.Join(first, e.Name())) {
+					out = append(out, fmt.Sprintf("%s (%s before %s)", e.Name(), first, dir))
+					break
+				}
+			}
+		}
+	}
+	return out
+}
+
+func executable(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir() && info.Mode()&0o111 != 0
+}
+
+// marker opens what probe's shell prints.
+const marker = "maziq-env"
+
+// bounds locates the block in rc, from the start of its first line to the
+// end of its last.
+func bounds(rc []byte) (int, int, bool) {
+	start := bytes.Index(rc, []byte(Begin+"\n"))
+	if start < 0 {
+		return 0, 0, false
+	}
+	n := bytes.Index(rc[start:], []byte(End))
+	if n < 0 {
+		return 0, 0, false
+	}
+	end := start + n + len(End)
+	if end < len(rc) && rc[end] == '\n' {
+		end++
+	}
+	return start, end, true
+}
+
+// literal single-quotes v, so the shell takes it as it is.
+func literal(sh, v string) string {
+	if sh == "fish" {
+		return "'" + strings.NewReplacer(`\`, `\\`, "'", `\'`).Replace(v) + "'"
+	}
+	return "'" + strings.ReplaceAll(v, "'", `'\''`) + "'"
+}
+
+// indent indents each line of a function body by two spaces.
+func indent(body string) string {
+	lines := strings.Split(strings.Trim(body, "\n"), "\n")
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = "  " + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// quote double-quotes v so the shell still expands the variables in it.
+// Backticks run commands in POSIX shells but not in fish.
+func quote(sh, v string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "`", "\\`")
+	if sh == "fish" {
+		r = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+	}
+	return `"` + r.Replace(v) + `"`
+}
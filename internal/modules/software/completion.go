@@ -0,0 +1,129 @@
+package software
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hmziqrs/maziq/internal/catalog"
+	"github.com/hmziqrs/maziq/internal/modules/shellrc"
+	"github.com/hmziqrs/maziq/internal/resource"
+	"github.com/hmziqrs/maziq/internal/shell"
+)
+
+// CompletionKind is the resource kind for generated completion scripts.
+const CompletionKind = "completion"
+
+// completions returns the completion scripts of sw to install for the
+// login shell. Homebrew installs those of what it installs itself.
+func completions(env *resource.Env, sw catalog.Software) []resource.Resource {
+	if b := sw.Primary().Backend; b == catalog.BackendBrew || b == catalog.BackendCask || !env.Template.CompletionsOn() {
+		return nil
+	}
+	sh := shellrc.Shell(env)
+	cmds := make([]string, 0, len(sw.Completions))
+	for cmd := range sw.Completions {
+		cmds = append(cmds, cmd)
+	}
+	sort.Strings(cmds)
+	var out []resource.Resource
+	for _, cmd := range cmds {
+		gen := make([]string, len(sw.Completions[cmd]))
+		for i, arg := range sw.Completions[cmd] {
+			gen[i] = strings.ReplaceAll(arg, "{shell}", sh)
+		}
+		out = append(out, &Completion{
+			Command:  cmd,
+			Shell:    sh,
+			Generate: gen,
+			Path:     filepath.Join(env.Path(shellrc.CompletionDir(sh)), shellrc.CompletionFile(sh, cmd)),
+			software: sw.ID,
+		})
+	}
+	return out
+}
+
+// Completion is the completion script of one command, as the command
+// itself prints it.
+type Completion struct {
+	Command  string
+	Shell    string
+	Generate []string
+	Path     string
+	software string
+}
+
+// ID implements resource.Resource.
+func (c *Completion) ID() string { return resource.ID(CompletionKind, c.Command) }
+
+// Describe implements resource.Resource.
+func (c *Completion) Describe() string {
+	return fmt.Sprintf("install %s completion for %s in %s", c.Shell, c.Command, c.Path)
+}
+
+// Requires implements resource.Requirer.
+func (c *Completion) Requires() []string {
+	return []string{resource.ID(Kind, c.software)}
+}
+
+// Check implements resource.Resource. The script is not compared with what
+// the command prints now, which would run it on every plan; removing the
+// file has the next apply write it again.
+func (c *Completion) Check(ctx context.Context, env *resource.Env) (resource.State, error) {
+	state := resource.State{Desired: "installed"}
+	info, err := os.Stat(c.Path)
+	switch {
+	case os.IsNotExist(err):
+		state.Current = "missing"
+	case err != nil:
+		return state, err
+	case info.Size() == 0:
+		state.Current = "empty"
+	default:
+		state.Current, state.Converged = "installed", true
+	}
+	return state, nil
+}
+
+// Verify implements resource.Verifier; the check only looks at the file.
+func (c *Completion) Verify(ctx context.Context, env *resource.Env) (resource.State, error) {
+	return c.Check(ctx, env)
+}
+
+// Apply implements resource.Resource.
+func (c *Completion) Apply(ctx context.Context, env *resource.Env) error {
+	res, err := env.Run(ctx, shell.Cmd(c.Generate[0], c.Generate[1:]...))
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(res.Stdout) == "" {
+		return fmt.Errorf("%s printed no completion script", strings.Join(c.Generate, " "))
+	}
+	if err := os.MkdirAll(filepath.Dir(c.Path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(c.Path, []byte(res.Stdout), 0o644)
+}
+
+// Assertions implements resource.Asserter: a login shell completes the
+// command.
+func (c *Completion) Assertions() []resource.Assertion {
+	var check string
+	switch c.Shell {
+	case "bash":
+		// bash-completion loads the script the first time it is needed.
+		check = fmt.Sprintf("_completion_loader %s; complete -p %s >/dev/null && echo completes", c.Command, c.Command)
+	case "fish":
+		check = fmt.Sprintf("complete -C %s >/dev/null; complete -c %s | string length -q && echo completes", shell.Quote(c.Command+" "), c.Command)
+	default:
+		check = fmt.Sprintf("(( $+_comps[%s] )) && echo completes", c.Command)
+	}
+	return []resource.Assertion{{
+		Name:   fmt.Sprintf("%s completes %s", c.Shell, c.Command),
+		Run:    fmt.Sprintf("%s -l -i -c %s 2>/dev/null", c.Shell, shell.Quote(check)),
+		Expect: "completes",
+	}}
+}
@@ -0,0 +1,336 @@
+// Package software turns template software entries into resources, pulling
+// in catalog dependencies that the template does not list itself.
+package software
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/hmziqrs/maziq/internal/catalog"
+	"github.com/hmziqrs/maziq/internal/fetch"
+	"github.com/hmziqrs/maziq/internal/gatekeeper"
+	"github.com/hmziqrs/maziq/internal/manager"
+	"github.com/hmziqrs/maziq/internal/resource"
+	"github.com/hmziqrs/maziq/internal/sbom"
+	"github.com/hmziqrs/maziq/internal/shell"
+)
+
+// Kind is the resource kind for catalog software.
+const Kind = "software"
+
+func init() {
+	resource.Register(build)
+}
+
+func build(env *resource.Env) ([]resource.Resource, error) {
+	entries, err := env.Template.Active(env.Facts)
+	if err != nil {
+		return nil, err
+	}
+	arch := env.Facts["arch"]
+	overrides := map[string]catalog.Source{}
+	for _, e := range entries {
+		if src, ok := e.Arch[arch]; ok {
+			overrides[e.ID] = src
+		}
+	}
+	var out []resource.Resource
+	added := map[string]bool{}
+	var add func(id, requiredBy string) error
+	add = func(id, requiredBy string) error {
+		if added[id] {
+			return nil
+		}
+		sw, ok := catalog.Lookup(id)
+		if !ok {
+			if requiredBy != "" {
+				return fmt.Errorf("%s depends on unknown software %q", requiredBy, id)
+			}
+			return fmt.Errorf("unknown software %q", id)
+		}
+		added[id] = true
+		sw = sw.ForArch(arch)
+		if src, ok := overrides[id]; ok {
+			sw.Sources = []catalog.Source{src}
+		}
+		for _, dep := range sw.Deps {
+			if err := add(dep, id); err != nil {
+				return err
+			}
+		}
+		out = append(out, &Software{sw: sw, implicit: requiredBy != ""})
+		out = append(out, completions(env, sw)...)
+		return nil
+	}
+	for _, e := range entries {
+		if err := add(e.ID, ""); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// Software is a catalog entry that should be installed.
+type Software struct {
+	sw       catalog.Software
+	implicit bool
+}
+
+// ID implements resource.Resource.
+func (s *Software) ID() string { return resource.ID(Kind, s.sw.ID) }
+
+// Scope implements resource.Scoper.
+func (s *Software) Scope() string { return resource.ScopeSystem }
+
+// Describe implements resource.Resource.
+func (s *Software) Describe() string {
+	src := s.sw.Primary()
+	desc := fmt.Sprintf("install %s via %s", s.sw.Name, src.Backend)
+	if s.sw.IntelOnly {
+		desc += " (Intel build)"
+	}
+	if s.implicit {
+		desc += " (dependency)"
+	}
+	return desc
+}
+
+// Requires implements resource.Requirer.
+func (s *Software) Requires() []string {
+	ids := make([]string, len(s.sw.Deps))
+	for i, dep := range s.sw.Deps {
+		ids[i] = resource.ID(Kind, dep)
+	}
+	return ids
+}
+
+// Check implements resource.Resource. An app that is installed but has not
+// been opened yet is assessed the way Gatekeeper will on first launch.
+func (s *Software) Check(ctx context.Context, env *resource.Env) (resource.State, error) {
+	res := manager.Detect(ctx, env.Runner, s.sw)
+	state := resource.State{
+		Converged: res.Status == manager.StatusInstalled,
+		Current:   string(res.Status) + versionSuffix(res.Version),
+		Desired:   string(manager.StatusInstalled),
+		Version:   res.Version,
+	}
+	if res.Path != "" {
+		if q, err := gatekeeper.Quarantined(ctx, env.Runner, res.Path); err == nil && q {
+			if a, err := gatekeeper.Assess(ctx, env.Runner, res.Path); err == nil && a.Problem() != "" {
+				state.Warning = fmt.Sprintf("%s is %s; macOS will refuse to open it", res.Path, a.Problem())
+			}
+		}
+	}
+	if state.Warning == "" && env.Facts["arch"] == "arm64" {
+		state.Warning = s.rosetta()
+	}
+	if !state.Converged {
+		state.Blocked = s.deferred(ctx, env)
+	}
+	return state, nil
+}
+
+// rosetta explains, on Apple silicon, what of s runs as Intel code, or
+// returns "".
+func (s *Software) rosetta() string {
+	switch {
+	case s.sw.IntelOnly:
+		return s.sw.Name + " only ships Intel builds, which run under Rosetta"
+	case s.sw.ID == "homebrew":
+		// The Intel Homebrew installs Intel bottles and cask variants.
+		if path, err := exec.LookPath("brew"); err == nil && strings.HasPrefix(path, "/usr/local/") {
+			return "Homebrew in /usr/local is the Intel one and installs Intel builds; install it in /opt/homebrew for Apple silicon ones"
+		}
+	}
+	return ""
+}
+
+// deferred explains why a cask with a large download waits for the
+// network.defer_over window, or returns "".
+func (s *Software) deferred(ctx context.Context, env *resource.Env) string {
+	src := s.sw.Primary()
+	if src.Backend != catalog.BackendCask {
+		return ""
+	}
+	size, until, ok := fetch.Deferred(time.Now(), func() (int64, error) {
+		return manager.CaskSize(ctx, env.Runner, src.Package)
+	})
+	if !ok {
+		return ""
+	}
+	return fmt.Sprintf("%s download deferred to the network window at %s", size, until.Format("Mon 15:04"))
+}
+
+// Verify implements resource.Verifier. An app outside /Applications and
+// ~/Applications counts as missing; only a full check searches Spotlight.
+func (s *Software) Verify(ctx context.Context, env *resource.Env) (resource.State, error) {
+	st := manager.Present(s.sw)
+	if st == manager.StatusUnknown {
+		return resource.State{}, resource.ErrUnverifiable
+	}
+	return resource.State{Converged: st == manager.StatusInstalled, Current: string(st), Desired: string(manager.StatusInstalled)}, nil
+}
+
+// Apply implements resource.Resource. A freshly installed app that passes
+// Gatekeeper is released from quarantine so its first launch does not
+// prompt; one that does not stays quarantined.
+func (s *Software) Apply(ctx context.Context, env *resource.Env) error {
+	src, err := manager.Install(ctx, env.Runner, s.sw)
+	if err != nil {
+		return err
+	}
+	env.Log("installed %s via %s", s.sw.Name, src.Backend)
+	if res := manager.Detect(ctx, env.Runner, s.sw); res.Path != "" {
+		Release(ctx, env, res.Path)
+	}
+	return nil
+}
+
+// Undo implements resource.Reverter. Install falls back through the
+// sources, so the undo tries to remove the software from each of them in
+// turn until one succeeds. Software with a source that cannot be
+// uninstalled cannot be undone.
+func (s *Software) Undo(ctx context.Context, env *resource.Env) (resource.Undo, error) {
+	var cmds []string
+	for _, src := range s.sw.Sources {
+		c, err := manager.UninstallCommand(src)
+		if err != nil {
+			return resource.Undo{}, err
+		}
+		cmds = append(cmds, c.String())
+	}
+	if len(cmds) == 0 {
+		return resource.Undo{}, fmt.Errorf("%s has no install sources", s.sw.ID)
+	}
+	return resource.Undo{ID: s.ID(), Commands: []shell.Command{shell.Script(strings.Join(cmds, " || "))}}, nil
+}
+
+// Fetch implements resource.Fetcher. Homebrew downloads the bottles, with
+// those of the dependencies, or the cask into its cache, which a bundle
+// points HOMEBREW_CACHE at. Homebrew itself comes from the installer
+// package the bundle carries.
+func (s *Software) Fetch(ctx context.Context, env *resource.Env) error {
+	if s.sw.ID == "homebrew" {
+		return nil
+	}
+	switch src := s.sw.Primary(); src.Backend {
+	case catalog.BackendBrew:
+		_, err := env.Run(ctx, shell.Cmd("brew", "fetch", "--deps", src.Package))
+		return err
+	case catalog.BackendCask:
+		_, err := env.Run(ctx, shell.Cmd("brew", "fetch", "--cask", src.Package))
+		return err
+	default:
+		return fmt.Errorf("%w: installs via %s", resource.ErrNeedsNetwork, src.Backend)
+	}
+}
+
+// Export implements resource.Exporter. The sources are tried in order, as
+// Install does; Homebrew's own list is the check for its packages and the
+// app or executable for the rest.
+func (s *Software) Export(ctx context.Context, env *resource.Env) (resource.Step, error) {
+	var step resource.Step
+	switch src := s.sw.Primary(); {
+	case src.Backend == catalog.BackendBrew:
+		step.Check = "brew list --formula " + shell.Quote(src.Package) + " >/dev/null 2>&1"
+	case src.Backend == catalog.BackendCask:
+		step.Check = "brew list --cask " + shell.Quote(src.Package) + " >/dev/null 2>&1"
+	case src.Backend == catalog.BackendXcode:
+		step.Check = "xcode-select -p >/dev/null 2>&1"
+	case s.sw.App != "":
+		step.Check = "[ -d " + shell.Quote("/Applications/"+s.sw.App) + " ]"
+	case len(s.sw.Version) > 0:
+		step.Check = "command -v " + shell.Quote(s.sw.Version[0]) + " >/dev/null 2>&1"
+	default:
+		return step, fmt.Errorf("%s has no way to tell it is installed", s.sw.Name)
+	}
+	var lines []string
+	for _, src := range s.sw.Sources {
+		c, err := manager.InstallCommand(src)
+		if err != nil {
+			return step, err
+		}
+		step.Commands = []shell.Command{c}
+		lines = append(lines, c.String())
+	}
+	if len(lines) > 1 {
+		step.Commands = []shell.Command{shell.Script(strings.Join(lines, " || "))}
+	}
+	return step, nil
+}
+
+// Component implements resource.Componenter. The package URL follows the
+// entry's first source; a CLI's executable is hashed.
+func (s *Software) Component(ctx context.Context, env *resource.Env) (resource.Component, bool, error) {
+	res := manager.Detect(ctx, env.Runner, s.sw)
+	if res.Status != manager.StatusInstalled {
+		return resource.Component{}, false, nil
+	}
+	c := resource.Component{ID: s.ID(), Name: s.sw.Name, Version: sbom.Version(res.Version), Paths: manager.Locate(ctx, env.Runner, s.sw, res)}
+	switch src := s.sw.Primary(); src.Backend {
+	case catalog.BackendBrew:
+		c.PURL, c.Supplier = sbom.PURL("brew", src.Package, c.Version, nil), "Homebrew"
+	case catalog.BackendCask:
+		c.PURL, c.Supplier = sbom.PURL("brew", "cask/"+src.Package, c.Version, nil), "Homebrew"
+	case catalog.BackendCargo:
+		c.PURL, c.Supplier = sbom.PURL("cargo", src.Package, c.Version, nil), "crates.io"
+	case catalog.BackendNPM:
+		c.PURL, c.Supplier = sbom.PURL("npm", src.Package, c.Version, nil), "npm"
+	case catalog.BackendUV:
+		c.PURL, c.Supplier = sbom.PURL("pypi", src.Package, c.Version, nil), "PyPI"
+	default:
+		c.PURL = sbom.PURL("generic", s.sw.ID, c.Version, nil)
+	}
+	if res.Path == "" && len(s.sw.Version) > 0 {
+		if path, err := exec.LookPath(s.sw.Version[0]); err == nil {
+			c.SHA256, _ = fetch.SHA256(path)
+		}
+	}
+	return c, true, nil
+}
+
+// UpgradeCommand returns the command that upgrades the software a resource
+// ID names to its latest release through its first source.
+func UpgradeCommand(id string) (shell.Command, error) {
+	name, ok := strings.CutPrefix(id, Kind+":")
+	if !ok {
+		return shell.Command{}, fmt.Errorf("%s is not catalog software", id)
+	}
+	sw, ok := catalog.Lookup(name)
+	if !ok {
+		return shell.Command{}, fmt.Errorf("unknown software %q", name)
+	}
+	return manager.UpgradeCommand(sw.Primary())
+}
+
+// Release clears quarantine on a freshly installed app at path if it passes
+// Gatekeeper and logs why not otherwise.
+func Release(ctx context.Context, env *resource.Env, path string) {
+	if q, err := gatekeeper.Quarantined(ctx, env.Runner, path); err != nil || !q {
+		return
+	}
+	a, err := gatekeeper.Assess(ctx, env.Runner, path)
+	switch {
+	case err != nil:
+		env.Log("could not check the signature of %s: %v", path, err)
+	case a.Problem() != "":
+		env.Log("warning: %s is %s; leaving it quarantined", path, a.Problem())
+	default:
+		if err := gatekeeper.Release(ctx, env.Runner, path); err != nil {
+			env.Log("could not clear quarantine on %s: %v", path, err)
+		}
+	}
+}
+
+// Catalog returns the underlying catalog entry.
+func (s *Software) Catalog() catalog.Software { return s.sw }
+
+func versionSuffix(v string) string {
+	if v == "" {
+		return ""
+	}
+	return " (" + v + ")"
+}
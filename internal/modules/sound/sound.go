@@ -0,0 +1,81 @@
+// Package sound manages the startup chime. The other [sound] keys are
+// preferences, written by the defaults module.
+package sound
+
+import (
+	"context"
+	"strings"
+
+	"github.com/hmziqrs/maziq/internal/resource"
+	"github.com/hmziqrs/maziq/internal/shell"
+)
+
+// Kind is the resource kind for the startup chime.
+const Kind = "chime"
+
+// muteVar is the NVRAM variable that silences the chime when it is %01.
+const muteVar = "StartupMute"
+
+func init() {
+	resource.Register(build)
+}
+
+func build(env *resource.Env) ([]resource.Resource, error) {
+	if on := env.Template.Sound.StartupChime; on != nil {
+		return []resource.Resource{&Chime{on: *on}}, nil
+	}
+	return nil, nil
+}
+
+// Chime is whether the Mac plays its sound when it starts up.
+type Chime struct {
+	on bool
+}
+
+// ID implements resource.Resource.
+func (c *Chime) ID() string { return resource.ID(Kind, "startup") }
+
+// Scope implements resource.Scoper.
+func (c *Chime) Scope() string { return resource.ScopeSystem }
+
+// Describe implements resource.Resource.
+func (c *Chime) Describe() string {
+	return "turn the startup chime " + onOff(c.on)
+}
+
+// Check implements resource.Resource. The chime plays unless StartupMute
+// is set, and the variable is absent until something sets it, so the
+// whole list is read rather than the one variable, which fails when unset.
+func (c *Chime) Check(ctx context.Context, env *resource.Env) (resource.State, error) {
+	state := resource.State{Desired: onOff(c.on)}
+	res, err := env.Run(ctx, shell.Cmd("nvram", "-p"))
+	if err != nil {
+		return state, err
+	}
+	on := true
+	for _, line := range strings.Split(res.Stdout, "\n") {
+		if f := strings.Fields(line); len(f) == 2 && f[0] == muteVar {
+			on = f[1] != "%01"
+		}
+	}
+	state.Current = onOff(on)
+	state.Converged = on == c.on
+	return state, nil
+}
+
+// Apply implements resource.Resource.
+func (c *Chime) Apply(ctx context.Context, env *resource.Env) error {
+	value := "%01"
+	if c.on {
+		value = "%00"
+	}
+	_, err := env.Run(ctx, shell.Command{Name: "nvram", Args: []string{muteVar + "=" + value}, Sudo: true})
+	return err
+}
+
+func onOff(on bool) string {
+	if on {
+		return "on"
+	}
+	return "off"
+}
@@ -0,0 +1,168 @@
+// Package spotlight manages Spotlight's privacy exclusions and per-volume
+// indexing.
+package spotlight
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/hmziqrs/maziq/internal/modules/directories"
+	"github.com/hmziqrs/maziq/internal/resource"
+	"github.com/hmziqrs/maziq/internal/shell"
+)
+
+// Kind is the resource kind for Spotlight settings.
+const Kind = "spotlight"
+
+// VolumeConfig is where Spotlight keeps the privacy list for the data volume.
+// It is only readable by root.
+const VolumeConfig = "/System/Volumes/Data/.Spotlight-V100/VolumeConfiguration.plist"
+
+func init() {
+	resource.Register(build)
+}
+
+func build(env *resource.Env) ([]resource.Resource, error) {
+	spec := env.Template.Spotlight
+	var out []resource.Resource
+	list := &exclusions{}
+	seen := map[string]bool{}
+	for _, p := range spec.Exclude {
+		path := env.Path(p)
+		seen[path] = true
+		out = append(out, &Exclusion{path: path, list: list})
+	}
+	// Directories of the workspace skeleton that ask for it are excluded
+	// once they exist.
+	for _, d := range env.Template.Directories {
+		ok, err := env.Holds(d.When)
+		if err != nil {
+			return nil, fmt.Errorf("directories %q: when: %w", d.Path, err)
+		}
+		if !ok || !d.ExcludeSpotlight {
+			continue
+		}
+		for _, path := range directories.Paths(env, d) {
+			if !seen[path] {
+				seen[path] = true
+				out = append(out, &Exclusion{path: path, list: list, needs: []string{directories.ID(path)}})
+			}
+		}
+	}
+	for _, v := range spec.DisableVolumes {
+		out = append(out, &Volume{path: env.Path(v)})
+	}
+	return out, nil
+}
+
+// Exclusion keeps one directory out of the index.
+type Exclusion struct {
+	path  string
+	list  *exclusions
+	needs []string
+}
+
+// ID implements resource.Resource.
+func (e *Exclusion) ID() string { return resource.ID(Kind, "exclude:"+e.path) }
+
+// Requires implements resource.Requirer.
+func (e *Exclusion) Requires() []string { return e.needs }
+
+// Scope implements resource.Scoper.
+func (e *Exclusion) Scope() string { return resource.ScopeSystem }
+
+// Describe implements resource.Resource.
+func (e *Exclusion) Describe() string { return "exclude " + e.path + " from Spotlight" }
+
+// Check implements resource.Resource. Reading the privacy list needs root;
+// when sudo would prompt, the exclusion is reported as unknown and left
+// pending so apply can ask for the password.
+func (e *Exclusion) Check(ctx context.Context, env *resource.Env) (resource.State, error) {
+	state := resource.State{Desired: "excluded"}
+	paths, err := e.list.get(ctx, env)
+	switch {
+	case err != nil:
+		state.Current = "unknown (needs sudo)"
+	case slices.Contains(paths, e.path):
+		state.Current, state.Converged = "excluded", true
+	default:
+		state.Current = "indexed"
+	}
+	return state, nil
+}
+
+// Apply implements resource.Resource. mds is restarted so it picks up the
+// new list; launchd relaunches it immediately.
+func (e *Exclusion) Apply(ctx context.Context, env *resource.Env) error {
+	if _, err := env.Run(ctx, shell.Command{Name: "defaults", Args: []string{"write", VolumeConfig, "Exclusions", "-array-add", e.path}, Sudo: true}); err != nil {
+		return err
+	}
+	_, err := env.Run(ctx, shell.Command{Name: "killall", Args: []string{"mds"}, Sudo: true})
+	return err
+}
+
+// exclusions caches the privacy list for a run.
+type exclusions struct {
+	once  sync.Once
+	paths []string
+	err   error
+}
+
+func (x *exclusions) get(ctx context.Context, env *resource.Env) ([]string, error) {
+	x.once.Do(func() {
+		// -n: fail instead of prompting during plan.
+		res, err := env.Run(ctx, shell.Cmd("sudo", "-n", "plutil", "-extract", "Exclusions", "json", "-o", "-", VolumeConfig))
+		if err != nil {
+			if strings.Contains(res.Stderr, "No value at that key path") {
+				return
+			}
+			x.err = err
+			return
+		}
+		x.err = json.Unmarshal([]byte(res.Stdout), &x.paths)
+	})
+	return x.paths, x.err
+}
+
+// Volume turns indexing off for a whole volume.
+type Volume struct {
+	path string
+}
+
+// ID implements resource.Resource.
+func (v *Volume) ID() string { return resource.ID(Kind, "volume:"+v.path) }
+
+// Scope implements resource.Scoper.
+func (v *Volume) Scope() string { return resource.ScopeSystem }
+
+// Describe implements resource.Resource.
+func (v *Volume) Describe() string { return "disable Spotlight indexing on " + v.path }
+
+// Check implements resource.Resource.
+func (v *Volume) Check(ctx context.Context, env *resource.Env) (resource.State, error) {
+	state := resource.State{Desired: "disabled"}
+	res, err := env.Run(ctx, shell.Cmd("mdutil", "-s", v.path))
+	if err != nil {
+		return state, err
+	}
+	out := res.Stdout
+	switch {
+	case strings.Contains(out, "Indexing disabled"):
+		state.Current, state.Converged = "disabled", true
+	case strings.Contains(out, "Indexing enabled"):
+		state.Current = "enabled"
+	default:
+		return state, fmt.Errorf("unexpected mdutil output: %s", strings.TrimSpace(out))
+	}
+	return state, nil
+}
+
+// Apply implements resource.Resource.
+func (v *Volume) Apply(ctx context.Context, env *resource.Env) error {
+	_, err := env.Run(ctx, shell.Command{Name: "mdutil", Args: []string{"-i", "off", v.path}, Sudo: true})
+	return err
+}
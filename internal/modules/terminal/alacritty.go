@@ -0,0 +1,117 @@
+package terminal
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hmziqrs/maziq/internal/modules/dotfile"
+	"github.com/hmziqrs/maziq/internal/templates"
+)
+
+// ansiNames are Alacritty's names for the eight normal and bright colors.
+var ansiNames = []string{"black", "red", "green", "yellow", "blue", "magenta", "cyan", "white"}
+
+// alacrittyMods maps chord modifiers to Alacritty's names.
+var alacrittyMods = map[string]string{
+	"cmd": "Command", "command": "Command", "super": "Command",
+	"ctrl": "Control", "control": "Control",
+	"shift": "Shift",
+	"alt":   "Alt", "opt": "Alt", "option": "Alt",
+}
+
+func alacritty(t templates.Terminal) ([]byte, error) {
+	if err := checkColors(t.Colors); err != nil {
+		return nil, err
+	}
+	var b strings.Builder
+	b.WriteString(dotfile.Header)
+	table := func(name string, kv ...[2]string) {
+		var lines []string
+		for _, p := range kv {
+			if p[1] != "" {
+				lines = append(lines, p[0]+" = "+p[1])
+			}
+		}
+		if len(lines) > 0 {
+			fmt.Fprintf(&b, "\n[%s]\n%s\n", name, strings.Join(lines, "\n"))
+		}
+	}
+	q := func(s string) string {
+		if s == "" {
+			return ""
+		}
+		return quote(s)
+	}
+	color := func(s string) string {
+		if s == "" {
+			return ""
+		}
+		return q(hex(s))
+	}
+	if t.FontSize > 0 {
+		table("font", [2]string{"size", size(t.FontSize)})
+	}
+	table("font.normal", [2]string{"family", q(t.Font)})
+	c := t.Colors
+	table("colors.primary", [2]string{"background", color(c.Background)}, [2]string{"foreground", color(c.Foreground)})
+	table("colors.cursor", [2]string{"cursor", color(c.Cursor)})
+	table("colors.selection", [2]string{"background", color(c.Selection)})
+	if len(c.Palette) == 16 {
+		for i, section := range []string{"colors.normal", "colors.bright"} {
+			var kv [][2]string
+			for j, name := range ansiNames {
+				kv = append(kv, [2]string{name, color(c.Palette[i*8+j])})
+			}
+			table(section, kv...)
+		}
+	}
+	for _, chord := range sortedKeys(t.Alacritty.Keybindings) {
+		parts := strings.Split(chord, "+")
+		key := parts[len(parts)-1]
+		var mods []string
+		for _, m := range parts[:len(parts)-1] {
+			name, ok := alacrittyMods[strings.ToLower(m)]
+			if !ok {
+				return nil, fmt.Errorf("keybinding %q: unknown modifier %q", chord, m)
+			}
+			mods = append(mods, name)
+		}
+		if key != "" {
+			key = strings.ToUpper(key[:1]) + key[1:]
+		}
+		action := t.Alacritty.Keybindings[chord]
+		fmt.Fprintf(&b, "\n[[keyboard.bindings]]\nkey = %s\n", quote(key))
+		if len(mods) > 0 {
+			fmt.Fprintf(&b, "mods = %s\n", quote(strings.Join(mods, "|")))
+		}
+		if chars, ok := strings.CutPrefix(action, "chars:"); ok {
+			fmt.Fprintf(&b, "chars = %s\n", quote(chars))
+		} else {
+			fmt.Fprintf(&b, "action = %s\n", quote(action))
+		}
+	}
+	if e := extra(t.Alacritty.Extra); e != "" {
+		b.WriteString("\n" + e)
+	}
+	return []byte(b.String()), nil
+}
+
+// quote renders s as a TOML basic string; TOML has no \x escapes, so control
+// characters use \u.
+func quote(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch {
+		case r == '"' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < 0x20 || r == 0x7f:
+			fmt.Fprintf(&b, "\\u%04X", r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
@@ -0,0 +1,47 @@
+package terminal
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hmziqrs/maziq/internal/modules/dotfile"
+	"github.com/hmziqrs/maziq/internal/templates"
+)
+
+func ghostty(t templates.Terminal) ([]byte, error) {
+	if err := checkColors(t.Colors); err != nil {
+		return nil, err
+	}
+	var b strings.Builder
+	b.WriteString(dotfile.Header)
+	set := func(key, value string) {
+		if value != "" {
+			fmt.Fprintf(&b, "%s = %s\n", key, value)
+		}
+	}
+	if t.Font != "" {
+		set("font-family", fmt.Sprintf("%q", t.Font))
+	}
+	if t.FontSize > 0 {
+		set("font-size", size(t.FontSize))
+	}
+	c := t.Colors
+	for _, kv := range [][2]string{
+		{"background", c.Background},
+		{"foreground", c.Foreground},
+		{"cursor-color", c.Cursor},
+		{"selection-background", c.Selection},
+	} {
+		if kv[1] != "" {
+			set(kv[0], hex(kv[1]))
+		}
+	}
+	for i, p := range c.Palette {
+		set("palette", fmt.Sprintf("%d=%s", i, hex(p)))
+	}
+	for _, k := range sortedKeys(t.Ghostty.Keybindings) {
+		set("keybind", k+"="+t.Ghostty.Keybindings[k])
+	}
+	b.WriteString(extra(t.Ghostty.Extra))
+	return []byte(b.String()), nil
+}
@@ -0,0 +1,68 @@
+package terminal
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/hmziqrs/maziq/internal/templates"
+)
+
+// iterm2 renders a dynamic profile. iTerm2 reloads the DynamicProfiles
+// folder on its own; the profile appears under Settings → Profiles.
+func iterm2(t templates.Terminal) ([]byte, error) {
+	if err := checkColors(t.Colors); err != nil {
+		return nil, err
+	}
+	name := t.ITerm2.Profile
+	if name == "" {
+		name = "maziq"
+	}
+	profile := map[string]any{}
+	for k, v := range t.ITerm2.Extra {
+		profile[k] = v
+	}
+	profile["Name"] = name
+	profile["Guid"] = "maziq-" + name
+	font := t.ITerm2.Font
+	if font == "" && t.Font != "" {
+		font = strings.ReplaceAll(t.Font, " ", "") + "-Regular"
+	}
+	if font != "" {
+		fs := t.FontSize
+		if fs <= 0 {
+			fs = 12
+		}
+		profile["Normal Font"] = font + " " + size(fs)
+	}
+	c := t.Colors
+	for key, v := range map[string]string{
+		"Background Color": c.Background,
+		"Foreground Color": c.Foreground,
+		"Cursor Color":     c.Cursor,
+		"Selection Color":  c.Selection,
+	} {
+		if v != "" {
+			profile[key] = itermColor(v)
+		}
+	}
+	for i, p := range c.Palette {
+		profile[fmt.Sprintf("Ansi %d Color", i)] = itermColor(p)
+	}
+	data, err := json.MarshalIndent(map[string]any{"Profiles": []any{profile}}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+func itermColor(s string) map[string]any {
+	c, _ := rgb(s)
+	return map[string]any{
+		"Red Component":   float64(c[0]) / 255,
+		"Green Component": float64(c[1]) / 255,
+		"Blue Component":  float64(c[2]) / 255,
+		"Alpha Component": 1,
+		"Color Space":     "sRGB",
+	}
+}
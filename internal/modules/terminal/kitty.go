@@ -0,0 +1,45 @@
+package terminal
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hmziqrs/maziq/internal/modules/dotfile"
+	"github.com/hmziqrs/maziq/internal/templates"
+)
+
+func kitty(t templates.Terminal) ([]byte, error) {
+	if err := checkColors(t.Colors); err != nil {
+		return nil, err
+	}
+	var b strings.Builder
+	b.WriteString(dotfile.Header)
+	set := func(key, value string) {
+		if value != "" {
+			fmt.Fprintf(&b, "%s %s\n", key, value)
+		}
+	}
+	set("font_family", t.Font)
+	if t.FontSize > 0 {
+		set("font_size", size(t.FontSize))
+	}
+	c := t.Colors
+	for _, kv := range [][2]string{
+		{"background", c.Background},
+		{"foreground", c.Foreground},
+		{"cursor", c.Cursor},
+		{"selection_background", c.Selection},
+	} {
+		if kv[1] != "" {
+			set(kv[0], hex(kv[1]))
+		}
+	}
+	for i, p := range c.Palette {
+		set(fmt.Sprintf("color%d", i), hex(p))
+	}
+	for _, k := range sortedKeys(t.Kitty.Keybindings) {
+		set("map", k+" "+t.Kitty.Keybindings[k])
+	}
+	b.WriteString(extra(t.Kitty.Extra))
+	return []byte(b.String()), nil
+}
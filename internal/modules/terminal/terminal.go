@@ -0,0 +1,110 @@
+// Package terminal renders the template's font, colors and keybindings into
+// the config files of the chosen terminal emulators. The files are managed
+// dotfiles: hand edits show up as drift.
+package terminal
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hmziqrs/maziq/internal/modules/dotfile"
+	"github.com/hmziqrs/maziq/internal/resource"
+	"github.com/hmziqrs/maziq/internal/templates"
+)
+
+func init() {
+	resource.Register(build)
+}
+
+// renderer produces one emulator's config file.
+type renderer struct {
+	label  string
+	render func(t templates.Terminal) ([]byte, error)
+}
+
+var renderers = map[string]renderer{
+	templates.EmulatorGhostty:   {"Ghostty", ghostty},
+	templates.EmulatorKitty:     {"kitty", kitty},
+	templates.EmulatorAlacritty: {"Alacritty", alacritty},
+	templates.EmulatorITerm2:    {"iTerm2", iterm2},
+}
+
+func build(env *resource.Env) ([]resource.Resource, error) {
+	t := env.Template.Terminal
+	t.Font = env.Expand(t.Font)
+	var out []resource.Resource
+	for _, name := range t.Emulators {
+		r, ok := renderers[name]
+		if !ok {
+			return nil, fmt.Errorf("terminal: unknown emulator %q (want one of %s)", name, strings.Join(templates.Emulators, ", "))
+		}
+		data, err := r.render(t)
+		if err != nil {
+			return nil, fmt.Errorf("terminal %s: %w", name, err)
+		}
+		f := dotfile.New(env.Path(templates.EmulatorConfigs[name]), data)
+		f.Label = r.label
+		out = append(out, f)
+	}
+	return out, nil
+}
+
+// rgb parses "#rrggbb".
+func rgb(s string) ([3]uint8, error) {
+	var c [3]uint8
+	h := strings.TrimPrefix(s, "#")
+	if len(h) != 6 {
+		return c, fmt.Errorf("color %q: want #rrggbb", s)
+	}
+	for i := range c {
+		v, err := strconv.ParseUint(h[2*i:2*i+2], 16, 8)
+		if err != nil {
+			return c, fmt.Errorf("color %q: want #rrggbb", s)
+		}
+		c[i] = uint8(v)
+	}
+	return c, nil
+}
+
+// checkColors validates every color up front so renderers can format them
+// without error handling.
+func checkColors(c templates.TerminalColors) error {
+	if len(c.Palette) != 0 && len(c.Palette) != 16 {
+		return fmt.Errorf("palette has %d colors, want 16", len(c.Palette))
+	}
+	for _, s := range append([]string{c.Background, c.Foreground, c.Cursor, c.Selection}, c.Palette...) {
+		if s == "" {
+			continue
+		}
+		if _, err := rgb(s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// hex normalises a color to "#rrggbb".
+func hex(s string) string { return "#" + strings.ToLower(strings.TrimPrefix(s, "#")) }
+
+func size(f float64) string { return strconv.FormatFloat(f, 'f', -1, 64) }
+
+// sortedKeys returns the keybinding chords in a stable order.
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// extra returns s with a trailing newline, or "".
+func extra(s string) string {
+	s = strings.TrimRight(s, "\n")
+	if s == "" {
+		return ""
+	}
+	return s + "\n"
+}
@@ -0,0 +1,310 @@
+// Package tiling sets up keyboard remapping and tiling window management:
+// Karabiner-Elements rules, skhd hotkeys and yabai. The permissions these
+// tools need cannot be granted from a script, so they appear as guided
+// manual steps that verify themselves.
+package tiling
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hmziqrs/maziq/internal/modules/dotfile"
+	"github.com/hmziqrs/maziq/internal/modules/manual"
+	"github.com/hmziqrs/maziq/internal/modules/software"
+	"github.com/hmziqrs/maziq/internal/resource"
+	"github.com/hmziqrs/maziq/internal/shell"
+	"github.com/hmziqrs/maziq/internal/templates"
+)
+
+// Kind is the resource kind for the skhd and yabai services and yabai's
+// sudoers entry.
+const Kind = "tiling"
+
+func init() {
+	resource.Register(build)
+}
+
+func build(env *resource.Env) ([]resource.Resource, error) {
+	t := env.Template
+	var out []resource.Resource
+	if t.Karabiner.Used() {
+		rs, err := karabiner(env, t.Karabiner)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, rs...)
+	}
+	if t.Skhd.Used() {
+		out = append(out, skhd(env, t.Skhd)...)
+	}
+	if t.Yabai.Used() {
+		rs, err := yabai(env, t.Yabai)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, rs...)
+	}
+	return out, nil
+}
+
+func karabiner(env *resource.Env, k templates.Karabiner) ([]resource.Resource, error) {
+	app := resource.ID(software.Kind, "karabiner_elements")
+	dir := env.Path("~/.config/karabiner")
+	var out []resource.Resource
+	var needs []string
+	if k.Config != "" {
+		link := &dotfile.Link{Path: dir, Target: env.Path(k.Config), Label: "Karabiner"}
+		out = append(out, link)
+		needs = append(needs, link.ID())
+	}
+	out = append(out, &manual.Step{
+		Name:  "karabiner-permissions",
+		Title: "allow Karabiner-Elements' driver and Input Monitoring",
+		Instructions: "open Karabiner-Elements and follow its prompts: allow the driver extension in System Settings → General → " +
+			"Login Items & Extensions, and karabiner_grabber and karabiner_observer in Privacy & Security → Input Monitoring",
+		Verify:   "systemextensionsctl list | grep -q 'org.pqrs.Karabiner-DriverKit-VirtualHIDDevice.*activated enabled'",
+		Settings: "login-items",
+		Needs:    []string{app},
+	})
+	if len(k.Rules) == 0 {
+		return out, nil
+	}
+	data, err := json.MarshalIndent(map[string]any{"title": "maziq", "rules": k.Rules}, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("karabiner rules: %w", err)
+	}
+	rules := dotfile.New(filepath.Join(dir, "assets", "complex_modifications", "maziq.json"), append(data, '\n'))
+	rules.Label = "Karabiner rules"
+	rules.Needs = needs
+	var checks []string
+	for i, r := range k.Rules {
+		desc, _ := r["description"].(string)
+		if desc == "" {
+			return nil, fmt.Errorf("karabiner.rule[%d]: description is required", i)
+		}
+		checks = append(checks, "grep -qF "+shell.Quote(desc)+" "+shell.Quote(filepath.Join(dir, "karabiner.json")))
+	}
+	out = append(out, rules, &manual.Step{
+		Name:         "karabiner-rules",
+		Title:        "enable the maziq Karabiner rules",
+		Instructions: "open Karabiner-Elements → Complex Modifications → Add predefined rule, and enable the rules under “maziq”",
+		Verify:       strings.Join(checks, " && "),
+		Needs:        []string{app, rules.ID()},
+	})
+	return out, nil
+}
+
+func skhd(env *resource.Env, s templates.Skhd) []resource.Resource {
+	path := env.Path("~/.config/skhd/skhdrc")
+	var config resource.Resource
+	if s.Config != "" {
+		config = &dotfile.Link{Path: path, Target: env.Path(s.Config), Label: "skhd"}
+	} else {
+		keys := make([]string, 0, len(s.Hotkeys))
+		for k := range s.Hotkeys {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		var b strings.Builder
+		b.WriteString(dotfile.Header)
+		for _, k := range keys {
+			fmt.Fprintf(&b, "%s : %s\n", k, env.Expand(s.Hotkeys[k]))
+		}
+		f := dotfile.New(path, []byte(b.String()))
+		f.Label = "skhd"
+		config = f
+	}
+	svc := &Service{name: "skhd", label: "com.koekeishiya.skhd", needs: []string{resource.ID(software.Kind, "skhd"), config.ID()}}
+	return []resource.Resource{config, svc, &manual.Step{
+		Name:         "skhd-accessibility",
+		Title:        "grant skhd Accessibility",
+		Instructions: "allow skhd in System Settings → Privacy & Security → Accessibility, then run `skhd --restart-service`",
+		Verify:       "pgrep -xq skhd",
+		Settings:     "accessibility",
+		Needs:        []string{svc.ID()},
+	}}
+}
+
+func yabai(env *resource.Env, y templates.Yabai) ([]resource.Resource, error) {
+	sw := resource.ID(software.Kind, "yabai")
+	path := env.Path("~/.config/yabai/yabairc")
+	var out []resource.Resource
+	var config resource.Resource
+	if y.Config != "" {
+		config = &dotfile.Link{Path: path, Target: env.Path(y.Config), Label: "yabai"}
+	} else {
+		data, err := yabairc(env, y)
+		if err != nil {
+			return nil, err
+		}
+		f := dotfile.New(path, data)
+		f.Mode = 0o755
+		f.Label = "yabai"
+		// yabai only reads its config at startup.
+		f.Reload = "yabai --restart-service"
+		config = f
+	}
+	out = append(out, config)
+	svcNeeds := []string{sw, config.ID()}
+	if y.ScriptingAddition {
+		sip := &manual.Step{
+			Name:  "yabai-sip",
+			Title: "partly disable System Integrity Protection for yabai",
+			Instructions: "restart into Recovery, run `csrutil enable --without fs --without debug --without nvram` in Terminal and restart; " +
+				"on Apple silicon also run `sudo nvram boot-args=-arm64e_preview_abi` and restart again",
+			Verify: "! csrutil status | grep -q 'status: enabled'",
+		}
+		sudoers := &Sudoers{user: env.Facts["user"], needs: []string{sw}}
+		out = append(out, sip, sudoers)
+		svcNeeds = append(svcNeeds, sudoers.ID())
+	}
+	svc := &Service{name: "yabai", label: "com.koekeishiya.yabai", needs: svcNeeds}
+	out = append(out, svc, &manual.Step{
+		Name:         "yabai-accessibility",
+		Title:        "grant yabai Accessibility",
+		Instructions: "allow yabai in System Settings → Privacy & Security → Accessibility, then run `yabai --restart-service`",
+		Verify:       "yabai -m query --displays >/dev/null",
+		Settings:     "accessibility",
+		Needs:        []string{svc.ID()},
+	})
+	return out, nil
+}
+
+// yabairc renders the config script: scripting addition first, then
+// settings sorted by name, then rules in order.
+func yabairc(env *resource.Env, y templates.Yabai) ([]byte, error) {
+	var b strings.Builder
+	b.WriteString("#!/usr/bin/env sh\n")
+	b.WriteString(dotfile.Header)
+	if y.ScriptingAddition {
+		b.WriteString("yabai -m signal --add event=dock_did_restart action=\"sudo yabai --load-sa\"\n")
+		b.WriteString("sudo yabai --load-sa\n")
+	}
+	keys := make([]string, 0, len(y.Settings))
+	for k := range y.Settings {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		var v string
+		switch x := y.Settings[k].(type) {
+		case string:
+			v = env.Expand(x)
+		case bool:
+			v = "off"
+			if x {
+				v = "on"
+			}
+		case int64, float64:
+			v = fmt.Sprint(x)
+		default:
+			return nil, fmt.Errorf("yabai.settings.%s: unsupported value type %T", k, x)
+		}
+		fmt.Fprintf(&b, "yabai -m config %s %s\n", k, v)
+	}
+	for _, r := range y.Rules {
+		fmt.Fprintf(&b, "yabai -m rule --add %s\n", env.Expand(r))
+	}
+	return []byte(b.String()), nil
+}
+
+// Service is skhd or yabai running as a launch agent.
+type Service struct {
+	name  string
+	label string
+	needs []string
+}
+
+// ID implements resource.Resource.
+func (s *Service) ID() string { return resource.ID(Kind, s.name) }
+
+// Describe implements resource.Resource.
+func (s *Service) Describe() string { return "start the " + s.name + " service" }
+
+// Requires implements resource.Requirer.
+func (s *Service) Requires() []string { return s.needs }
+
+// Check implements resource.Resource.
+func (s *Service) Check(ctx context.Context, env *resource.Env) (resource.State, error) {
+	state := resource.State{Desired: "running"}
+	_, err := env.Run(ctx, shell.Script("launchctl print gui/$(id -u)/"+s.label+" >/dev/null"))
+	switch {
+	case err == nil:
+		state.Current, state.Converged = "running", true
+	case errors.As(err, new(*shell.ExitError)):
+		state.Current = "not loaded"
+	default:
+		return state, err
+	}
+	return state, nil
+}
+
+// Apply implements resource.Resource.
+func (s *Service) Apply(ctx context.Context, env *resource.Env) error {
+	_, err := env.Run(ctx, shell.Cmd(s.name, "--start-service"))
+	return err
+}
+
+// SudoersPath lets `sudo yabai --load-sa` run without a password.
+const SudoersPath = "/private/etc/sudoers.d/yabai"
+
+// Sudoers is the NOPASSWD entry for loading yabai's scripting addition. It
+// pins the binary's hash, so it has to be refreshed after every yabai
+// upgrade; the check catches that.
+type Sudoers struct {
+	user  string
+	needs []string
+}
+
+// ID implements resource.Resource.
+func (s *Sudoers) ID() string { return resource.ID(Kind, "yabai-sudoers") }
+
+// Scope implements resource.Scoper.
+func (s *Sudoers) Scope() string { return resource.ScopeSystem }
+
+// Describe implements resource.Resource.
+func (s *Sudoers) Describe() string { return "allow " + s.user + " to load yabai's scripting addition" }
+
+// Requires implements resource.Requirer.
+func (s *Sudoers) Requires() []string { return s.needs }
+
+// Check implements resource.Resource. `sudo -n -l` answers without a
+// password when a NOPASSWD entry matches, and its hash check fails for a
+// stale entry.
+func (s *Sudoers) Check(ctx context.Context, env *resource.Env) (resource.State, error) {
+	state := resource.State{Desired: "allowed"}
+	_, err := env.Run(ctx, shell.Script(`sudo -n -l "$(command -v yabai)" --load-sa >/dev/null 2>&1`))
+	switch {
+	case err == nil:
+		state.Current, state.Converged = "allowed", true
+	case errors.As(err, new(*shell.ExitError)):
+		state.Current = "missing or stale"
+	default:
+		return state, err
+	}
+	return state, nil
+}
+
+// Apply implements resource.Resource. The entry is validated with visudo
+// before it is installed; a broken sudoers file would lock out sudo.
+func (s *Sudoers) Apply(ctx context.Context, env *resource.Env) error {
+	res, err := env.Run(ctx, shell.Script(`bin=$(command -v yabai) && echo "$bin $(shasum -a 256 "$bin" | cut -d' ' -f1)"`))
+	if err != nil {
+		return err
+	}
+	bin, hash, ok := strings.Cut(strings.TrimSpace(res.Stdout), " ")
+	if !ok {
+		return fmt.Errorf("cannot hash the yabai binary")
+	}
+	entry := fmt.Sprintf("%s ALL=(root) NOPASSWD: sha256:%s %s --load-sa\n", s.user, hash, bin)
+	install := `tmp=$(mktemp) && cat > "$tmp" && visudo -cf "$tmp" >/dev/null && install -m 0440 -o root -g wheel "$tmp" ` + SudoersPath + `; status=$?; rm -f "$tmp"; exit $status`
+	c := shell.Script(install)
+	c.Stdin, c.Sudo = entry, true
+	_, err = env.Run(ctx, c)
+	return err
+}
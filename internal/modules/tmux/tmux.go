@@ -0,0 +1,178 @@
+// Package tmux links the tmux config, installs TPM and installs the
+// template's plugins through it.
+package tmux
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hmziqrs/maziq/internal/modules/dotfile"
+	"github.com/hmziqrs/maziq/internal/modules/software"
+	"github.com/hmziqrs/maziq/internal/resource"
+	"github.com/hmziqrs/maziq/internal/shell"
+)
+
+// Kind is the resource kind for TPM and its plugins.
+const Kind = "tmux"
+
+// TPMRepo is the tmux plugin manager.
+const TPMRepo = "https://github.com/tmux-plugins/tpm"
+
+func init() {
+	resource.Register(build)
+}
+
+func build(env *resource.Env) ([]resource.Resource, error) {
+	spec := env.Template.Tmux
+	conf := env.Path("~/.tmux.conf")
+	var out []resource.Resource
+	var needs []string
+	source := conf
+	if spec.Config != "" {
+		source = env.Path(spec.Config)
+		link := &dotfile.Link{Path: conf, Target: source, Label: "tmux"}
+		out = append(out, link)
+		needs = append(needs, link.ID())
+	}
+	if len(spec.Plugins) == 0 {
+		return out, nil
+	}
+	dir := env.Path("~/.tmux/plugins")
+	var b strings.Builder
+	b.WriteString(dotfile.Header)
+	b.WriteString("set -g @plugin 'tmux-plugins/tpm'\n")
+	for _, p := range spec.Plugins {
+		fmt.Fprintf(&b, "set -g @plugin '%s'\n", p)
+	}
+	// TPM must be initialised after every @plugin line.
+	fmt.Fprintf(&b, "run '%s'\n", filepath.Join(dir, "tpm", "tpm"))
+	plugins := dotfile.New(env.Path("~/.tmux/plugins.conf"), []byte(b.String()))
+	plugins.Label = "tmux plugins"
+	tpm := &TPM{dir: filepath.Join(dir, "tpm")}
+	out = append(out, plugins, tpm, &Plugins{
+		names:  spec.Plugins,
+		dir:    dir,
+		source: source,
+		conf:   plugins.Path,
+		needs:  append(needs, plugins.ID(), tpm.ID(), resource.ID(software.Kind, "tmux")),
+	})
+	return out, nil
+}
+
+// TPM is the plugin manager checkout.
+type TPM struct {
+	dir string
+}
+
+// ID implements resource.Resource.
+func (t *TPM) ID() string { return resource.ID(Kind, "tpm") }
+
+// Describe implements resource.Resource.
+func (t *TPM) Describe() string { return "clone TPM into " + t.dir }
+
+// Requires implements resource.Requirer.
+func (t *TPM) Requires() []string {
+	return []string{resource.ID(software.Kind, "xcode_clt")}
+}
+
+// Check implements resource.Resource.
+func (t *TPM) Check(ctx context.Context, env *resource.Env) (resource.State, error) {
+	state := resource.State{Desired: "installed"}
+	if _, err := os.Stat(filepath.Join(t.dir, "bin", "install_plugins")); err != nil {
+		state.Current = "missing"
+		return state, nil
+	}
+	state.Current, state.Converged = "installed", true
+	return state, nil
+}
+
+// Fetch implements resource.Fetcher: TPM is cloned from GitHub.
+func (t *TPM) Fetch(context.Context, *resource.Env) error { return resource.ErrNeedsNetwork }
+
+// Apply implements resource.Resource.
+func (t *TPM) Apply(ctx context.Context, env *resource.Env) error {
+	if err := os.MkdirAll(filepath.Dir(t.dir), 0o755); err != nil {
+		return err
+	}
+	_, err := env.Run(ctx, shell.Cmd("git", "clone", "--depth", "1", TPMRepo, t.dir))
+	return err
+}
+
+// Plugins installs every declared plugin with TPM's install script, which
+// works without an attached tmux client.
+type Plugins struct {
+	names  []string
+	dir    string
+	source string // the tmux config that must load conf
+	conf   string
+	needs  []string
+}
+
+// ID implements resource.Resource.
+func (p *Plugins) ID() string { return resource.ID(Kind, "plugins") }
+
+// Describe implements resource.Resource.
+func (p *Plugins) Describe() string {
+	return fmt.Sprintf("install %d tmux plugin(s) with TPM", len(p.names))
+}
+
+// Requires implements resource.Requirer.
+func (p *Plugins) Requires() []string { return p.needs }
+
+// Check implements resource.Resource. TPM only sees plugins declared in
+// files the tmux config sources, so a config that does not load the
+// managed plugins.conf blocks the install.
+func (p *Plugins) Check(ctx context.Context, env *resource.Env) (resource.State, error) {
+	state := resource.State{Desired: fmt.Sprintf("%d installed", len(p.names))}
+	var missing []string
+	for _, name := range p.names {
+		if _, err := os.Stat(filepath.Join(p.dir, Dir(name))); err != nil {
+			missing = append(missing, Dir(name))
+		}
+	}
+	if len(missing) == 0 {
+		state.Current, state.Converged = state.Desired, true
+		return state, nil
+	}
+	state.Current = "missing " + strings.Join(missing, ", ")
+	if data, err := os.ReadFile(p.source); err == nil && !strings.Contains(string(data), "plugins.conf") {
+		state.Blocked = fmt.Sprintf("%s does not load the plugin list; add `source-file %s`", p.source, p.conf)
+	}
+	return state, nil
+}
+
+// Fetch implements resource.Fetcher: TPM clones the plugins.
+func (p *Plugins) Fetch(context.Context, *resource.Env) error { return resource.ErrNeedsNetwork }
+
+// Apply implements resource.Resource.
+func (p *Plugins) Apply(ctx context.Context, env *resource.Env) error {
+	_, err := env.Run(ctx, shell.Cmd(filepath.Join(p.dir, "tpm", "bin", "install_plugins")))
+	return err
+}
+
+// Assertions implements resource.Asserter.
+func (p *Plugins) Assertions() []resource.Assertion {
+	var out []resource.Assertion
+	for _, name := range p.names {
+		out = append(out, resource.Assertion{
+			Name:   "tmux plugin " + name + " is installed",
+			Run:    "test -d " + shell.Quote(filepath.Join(p.dir, Dir(name))) + " && echo installed",
+			Expect: "installed",
+		})
+	}
+	return out
+}
+
+// Dir returns the directory TPM clones a plugin into: the repository name
+// without any "#branch" suffix or .git extension.
+func Dir(plugin string) string {
+	plugin, _, _ = strings.Cut(plugin, "#")
+	plugin = strings.TrimSuffix(strings.TrimRight(plugin, "/"), ".git")
+	if i := strings.LastIndexAny(plugin, "/:"); i >= 0 {
+		plugin = plugin[i+1:]
+	}
+	return plugin
+}
@@ -0,0 +1,255 @@
+// Package updates manages the Software Update policy and installs pending
+// macOS updates, honouring a deferral period and a reboot policy.
+package updates
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/hmziqrs/maziq/internal/config"
+	"github.com/hmziqrs/maziq/internal/modules/defaults"
+	"github.com/hmziqrs/maziq/internal/resource"
+	"github.com/hmziqrs/maziq/internal/shell"
+	"github.com/hmziqrs/maziq/internal/templates"
+)
+
+// Kind is the resource kind for installing pending updates.
+const Kind = "osupdate"
+
+const (
+	// Domain holds the system-wide Software Update settings.
+	Domain = "/Library/Preferences/com.apple.SoftwareUpdate"
+	// CommerceDomain holds the App Store auto-update setting.
+	CommerceDomain = "/Library/Preferences/com.apple.commerce"
+)
+
+func init() {
+	resource.Register(build)
+}
+
+func build(env *resource.Env) ([]resource.Resource, error) {
+	spec := env.Template.Updates
+	var out []resource.Resource
+	add := func(domain, key string, value *bool) {
+		if value == nil {
+			return
+		}
+		s, _ := defaults.New(domain, key, *value)
+		s.Sudo = true
+		out = append(out, s)
+	}
+	add(Domain, "AutomaticCheckEnabled", spec.AutomaticCheck)
+	add(Domain, "AutomaticDownload", spec.AutomaticDownload)
+	add(Domain, "AutomaticallyInstallMacOSUpdates", spec.InstallMacOS)
+	add(Domain, "CriticalUpdateInstall", spec.InstallSecurity)
+	add(Domain, "ConfigDataInstall", spec.InstallSecurity)
+	add(CommerceDomain, "AutoUpdate", spec.InstallAppStore)
+	if spec.Install {
+		reboot := spec.Reboot
+		switch reboot {
+		case "":
+			reboot = templates.RebootNever
+		case templates.RebootNever, templates.RebootPrompt, templates.RebootAllow:
+		default:
+			return nil, fmt.Errorf("updates.reboot: unknown policy %q", spec.Reboot)
+		}
+		out = append(out, &Install{deferDays: spec.DeferDays, reboot: reboot})
+	}
+	return out, nil
+}
+
+// Update is one entry from `softwareupdate --list`.
+type Update struct {
+	Label       string `json:"label"`
+	Title       string `json:"title"`
+	Version     string `json:"version,omitempty"`
+	Recommended bool   `json:"recommended"`
+	// Restart is set when installing needs a restart.
+	Restart bool `json:"restart"`
+	// Seen is when MazIQ first saw the update available.
+	Seen time.Time `json:"seen"`
+}
+
+// Deferred reports whether the update is still inside the deferral period.
+func (u Update) Deferred(days int64, now time.Time) bool {
+	return days > 0 && now.Sub(u.Seen) < time.Duration(days)*24*time.Hour
+}
+
+// List asks Software Update for pending updates. It contacts Apple's
+// servers and can take a while.
+func List(ctx context.Context, env *resource.Env) ([]Update, error) {
+	res, err := env.Run(ctx, shell.Cmd("softwareupdate", "--list"))
+	if err != nil {
+		return nil, err
+	}
+	updates := parseList(res.Stdout)
+	seen, err := remember(updates, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	for i := range updates {
+		updates[i].Seen = seen[updates[i].Label]
+	}
+	return updates, nil
+}
+
+// parseList reads the "* Label:" entries and the detail line after each.
+func parseList(out string) []Update {
+	var updates []Update
+	for _, line := range strings.Split(out, "\n") {
+		line = strings.TrimSpace(line)
+		if label, ok := strings.CutPrefix(line, "* Label: "); ok {
+			updates = append(updates, Update{Label: label, Title: label})
+			continue
+		}
+		if len(updates) == 0 || !strings.HasPrefix(line, "Title: ") {
+			continue
+		}
+		u := &updates[len(updates)-1]
+		for _, field := range strings.Split(line, ", ") {
+			k, v, _ := strings.Cut(strings.TrimSuffix(field, ","), ": ")
+			switch k {
+			case "Title":
+				u.Title = v
+			case "Version":
+				u.Version = v
+			case "Recommended":
+				u.Recommended = v == "YES"
+			case "Action":
+				u.Restart = v == "restart"
+			}
+		}
+	}
+	return updates
+}
+
+func seenPath() string {
+	return filepath.Join(config.Dir(), "updates.json")
+}
+
+// remember records when each update was first seen, forgetting updates that
+// are no longer offered, and returns the first-seen times.
+func remember(updates []Update, now time.Time) (map[string]time.Time, error) {
+	old := map[string]time.Time{}
+	if data, err := os.ReadFile(seenPath()); err == nil {
+		_ = json.Unmarshal(data, &old)
+	}
+	seen := map[string]time.Time{}
+	for _, u := range updates {
+		seen[u.Label] = now
+		if t, ok := old[u.Label]; ok {
+			seen[u.Label] = t
+		}
+	}
+	data, err := json.MarshalIndent(seen, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(config.Dir(), 0o755); err != nil {
+		return nil, err
+	}
+	return seen, os.WriteFile(seenPath(), data, 0o644)
+}
+
+// Install installs pending updates once they are past the deferral period.
+type Install struct {
+	deferDays int64
+	reboot    string
+
+	// due is the set of updates Check found ready to install.
+	due []Update
+}
+
+// ID implements resource.Resource.
+func (i *Install) ID() string { return resource.ID(Kind, "pending") }
+
+// Scope implements resource.Scoper.
+func (i *Install) Scope() string { return resource.ScopeSystem }
+
+// Describe implements resource.Resource.
+func (i *Install) Describe() string {
+	return fmt.Sprintf("install %d macOS update(s) (reboot: %s)", len(i.due), i.reboot)
+}
+
+// Check implements resource.Resource.
+func (i *Install) Check(ctx context.Context, env *resource.Env) (resource.State, error) {
+	state := resource.State{Desired: "up to date"}
+	all, err := List(ctx, env)
+	if err != nil {
+		return state, err
+	}
+	now := time.Now()
+	var deferred, held int
+	i.due = nil
+	for _, u := range all {
+		switch {
+		case u.Deferred(i.deferDays, now):
+			deferred++
+		case u.Restart && i.reboot == templates.RebootNever:
+			held++
+		default:
+			i.due = append(i.due, u)
+		}
+	}
+	var parts []string
+	if len(i.due) > 0 {
+		parts = append(parts, fmt.Sprintf("%d pending", len(i.due)))
+	}
+	if deferred > 0 {
+		parts = append(parts, fmt.Sprintf("%d deferred", deferred))
+	}
+	if held > 0 {
+		parts = append(parts, fmt.Sprintf("%d need a restart", held))
+	}
+	state.Current = strings.Join(parts, ", ")
+	if state.Current == "" {
+		state.Current = "up to date"
+	}
+	state.Converged = len(i.due) == 0
+	if state.Converged && held > 0 {
+		state.Blocked = fmt.Sprintf("%d update(s) need a restart and reboot = %q; install them with `softwareupdate --install --all --restart`", held, templates.RebootNever)
+		state.Converged = false
+	}
+	return state, nil
+}
+
+// Fetch implements resource.Fetcher: updates come from Apple's servers.
+func (i *Install) Fetch(context.Context, *resource.Env) error { return resource.ErrNeedsNetwork }
+
+// Apply implements resource.Resource.
+func (i *Install) Apply(ctx context.Context, env *resource.Env) error {
+	if len(i.due) == 0 {
+		return errors.New("no updates are due")
+	}
+	args := []string{"--install"}
+	restart := false
+	for _, u := range i.due {
+		args = append(args, u.Label)
+		restart = restart || u.Restart
+	}
+	if restart && i.reboot == templates.RebootAllow {
+		args = append(args, "--restart")
+	}
+	_, err := env.Run(ctx, shell.Command{Name: "softwareupdate", Args: args, Sudo: true})
+	return err
+}
+
+// Restarts implements resource.Restarter. With reboot = "allow"
+// softwareupdate restarts by itself.
+func (i *Install) Restarts() resource.Restart {
+	if i.reboot != templates.RebootPrompt {
+		return resource.Restart{}
+	}
+	for _, u := range i.due {
+		if u.Restart {
+			return resource.Restart{Reboot: true}
+		}
+	}
+	return resource.Restart{}
+}
@@ -0,0 +1,247 @@
+// Package orphans finds the Homebrew formulae that were installed only as
+// dependencies of packages the template no longer has, like `brew
+// autoremove` but counting the template's formulae and casks as wanted
+// however they were installed, and removes them.
+package orphans
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+
+	"github.com/hmziqrs/maziq/internal/catalog"
+	"github.com/hmziqrs/maziq/internal/history"
+	"github.com/hmziqrs/maziq/internal/modules/software"
+	"github.com/hmziqrs/maziq/internal/resource"
+	"github.com/hmziqrs/maziq/internal/shell"
+)
+
+// Orphan is a formula nothing wanted needs.
+type Orphan struct {
+	Formula string `json:"formula"`
+	// NeededBy lists the installed packages the template dropped that
+	// still depend on it, directly or not. Removing it would break them,
+	// so it stays until they are uninstalled.
+	NeededBy []string `json:"needed_by,omitempty"`
+}
+
+// Removable reports whether removing o breaks nothing still installed.
+func (o Orphan) Removable() bool { return len(o.NeededBy) == 0 }
+
+// Report is what Find found.
+type Report struct {
+	// Dropped lists the formulae and casks MazIQ installed that the
+	// template no longer has and that are still installed.
+	Dropped []string `json:"dropped"`
+	Orphans []Orphan `json:"orphans"`
+	// Skipped says why nothing was looked at.
+	Skipped string `json:"skipped,omitempty"`
+}
+
+// Removable returns the formulae of the orphans that can go.
+func (r Report) Removable() []string {
+	var out []string
+	for _, o := range r.Orphans {
+		if o.Removable() {
+			out = append(out, o.Formula)
+		}
+	}
+	return out
+}
+
+// Find compares what Homebrew installed with env.Template. Every entry of
+// the template counts, including those in groups that are off.
+func Find(ctx context.Context, env *resource.Env) (Report, error) {
+	if err := env.Template.Decrypt(ctx, env.Secrets); err != nil {
+		return Report{}, err
+	}
+	rs, err := resource.Build(env)
+	if err != nil {
+		return Report{}, err
+	}
+	wanted := map[string]bool{}
+	for _, r := range rs {
+		if s, ok := r.(*software.Software); ok {
+			if name, ok := brewName(s.Catalog()); ok {
+				wanted[name] = true
+			}
+		}
+	}
+
+	deps, err := installed(ctx, env)
+	if err != nil || deps == nil {
+		return Report{Skipped: "Homebrew is not installed"}, err
+	}
+	onRequest, err := list(ctx, env, "--formula", "--installed-on-request")
+	if err != nil {
+		return Report{}, err
+	}
+	casks, err := list(ctx, env, "--cask")
+	if err != nil {
+		return Report{}, err
+	}
+	dropped, err := droppedPackages(wanted)
+	if err != nil {
+		return Report{}, err
+	}
+
+	// What is kept: the template's packages, casks, and formulae installed
+	// on request by hand, with everything they depend on.
+	kept := map[string]bool{}
+	var keep func(name string)
+	keep = func(name string) {
+		if kept[name] {
+			return
+		}
+		kept[name] = true
+		for _, d := range deps[name] {
+			keep(d)
+		}
+	}
+	for name := range deps {
+		if wanted[name] || (onRequest[name] || casks[name]) && !dropped[name] {
+			keep(name)
+		}
+	}
+	var rep Report
+	for _, name := range sortedKeys(deps) {
+		if dropped[name] && !kept[name] {
+			rep.Dropped = append(rep.Dropped, name)
+		}
+	}
+	for _, name := range sortedKeys(deps) {
+		if kept[name] || dropped[name] {
+			continue
+		}
+		o := Orphan{Formula: name}
+		for _, p := range rep.Dropped {
+			if needs(deps, p, name) {
+				o.NeededBy = append(o.NeededBy, p)
+			}
+		}
+		rep.Orphans = append(rep.Orphans, o)
+	}
+	return rep, nil
+}
+
+// Remove uninstalls formulae in one brew run, which lets Homebrew remove
+// ones that depend on each other together.
+func Remove(ctx context.Context, runner shell.Runner, formulae []string) error {
+	if len(formulae) == 0 {
+		return nil
+	}
+	_, err := runner.Run(ctx, shell.Cmd("brew", append([]string{"uninstall", "--formula"}, formulae...)...))
+	return err
+}
+
+// installed returns every installed formula and cask with the formulae it
+// depends on, or nil when Homebrew is not installed.
+func installed(ctx context.Context, env *resource.Env) (map[string][]string, error) {
+	res, err := env.Run(ctx, shell.Script("command -v brew >/dev/null || exit 3; brew deps --installed --for-each"))
+	if err != nil {
+		var exit *shell.ExitError
+		if errors.As(err, &exit) && exit.Result.ExitCode == 3 {
+			return nil, nil
+		}
+		return nil, err
+	}
+	out := map[string][]string{}
+	// One line per package: "git: gettext pcre2".
+	for _, line := range strings.Split(res.Stdout, "\n") {
+		name, list, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		var ds []string
+		for _, d := range strings.Fields(list) {
+			ds = append(ds, short(d))
+		}
+		out[short(strings.TrimSpace(name))] = ds
+	}
+	return out, nil
+}
+
+// list returns the names `brew list` prints with args.
+func list(ctx context.Context, env *resource.Env, args ...string) (map[string]bool, error) {
+	res, err := env.Run(ctx, shell.Cmd("brew", append([]string{"list", "-1"}, args...)...))
+	if err != nil {
+		return nil, err
+	}
+	out := map[string]bool{}
+	for _, name := range strings.Fields(res.Stdout) {
+		out[short(name)] = true
+	}
+	return out, nil
+}
+
+// droppedPackages returns the formulae and casks MazIQ installed whose
+// catalog entries are no longer in the template.
+func droppedPackages(wanted map[string]bool) (map[string]bool, error) {
+	records, err := history.Load()
+	if err != nil {
+		return nil, err
+	}
+	last := map[string]history.Record{}
+	for _, r := range records {
+		last[r.Software] = r
+	}
+	out := map[string]bool{}
+	for id, r := range last {
+		if r.Action != "install" {
+			continue
+		}
+		sw, ok := catalog.Lookup(id)
+		if !ok {
+			continue
+		}
+		if name, ok := brewName(sw); ok && !wanted[name] {
+			out[name] = true
+		}
+	}
+	return out, nil
+}
+
+// needs reports whether from depends on to, directly or not.
+func needs(deps map[string][]string, from, to string) bool {
+	seen := map[string]bool{}
+	queue := []string{from}
+	for len(queue) > 0 {
+		next := queue[0]
+		queue = queue[1:]
+		for _, d := range deps[next] {
+			if d == to {
+				return true
+			}
+			if !seen[d] {
+				seen[d] = true
+				queue = append(queue, d)
+			}
+		}
+	}
+	return false
+}
+
+// brewName returns the name Homebrew lists sw's package as, when Homebrew
+// installs it.
+func brewName(sw catalog.Software) (string, bool) {
+	src := sw.Primary()
+	if src.Backend != catalog.BackendBrew && src.Backend != catalog.BackendCask {
+		return "", false
+	}
+	return short(src.Package), true
+}
+
+// short drops the tap from a package name.
+func short(name string) string {
+	return name[strings.LastIndexByte(name, '/')+1:]
+}
+
+func sortedKeys(m map[string][]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
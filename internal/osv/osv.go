@@ -0,0 +1,279 @@
+// Package osv looks up known vulnerabilities in installed packages with the
+// OSV API (https://osv.dev), which aggregates the GitHub, RustSec, PyPA and
+// other advisory databases.
+package osv
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/hmziqrs/maziq/internal/fetch"
+	"github.com/hmziqrs/maziq/internal/resource"
+	"github.com/hmziqrs/maziq/internal/selfupdate"
+)
+
+// API is the OSV endpoint, a variable so a mirror can stand in for it.
+var API = "https://api.osv.dev/v1"
+
+// covered are the package URL types OSV has advisories for among those
+// MazIQ installs. Homebrew formulae and casks have no advisory database.
+var covered = []string{"cargo", "npm", "pypi"}
+
+// Severity ranks a vulnerability the way CVSS v3 bands its scores.
+type Severity int
+
+const (
+	SeverityUnknown Severity = iota
+	SeverityLow
+	SeverityMedium
+	SeverityHigh
+	SeverityCritical
+)
+
+var severityNames = []string{"unknown", "low", "medium", "high", "critical"}
+
+func (s Severity) String() string { return severityNames[s] }
+
+// MarshalText implements encoding.TextMarshaler.
+func (s Severity) MarshalText() ([]byte, error) { return []byte(s.String()), nil }
+
+// ParseSeverity parses a severity name. GitHub's "moderate" is medium.
+func ParseSeverity(name string) (Severity, error) {
+	name = strings.ToLower(name)
+	if name == "moderate" {
+		return SeverityMedium, nil
+	}
+	if i := slices.Index(severityNames, name); i > 0 {
+		return Severity(i), nil
+	}
+	return SeverityUnknown, fmt.Errorf("unknown severity %q (want low, medium, high or critical)", name)
+}
+
+// Vuln is one advisory affecting an installed package.
+type Vuln struct {
+	ID       string   `json:"id"`
+	Aliases  []string `json:"aliases,omitempty"`
+	Summary  string   `json:"summary,omitempty"`
+	Severity Severity `json:"severity"`
+	// Fixed is the lowest release above the installed one that fixes it,
+	// empty when there is none yet.
+	Fixed string `json:"fixed,omitempty"`
+	URL   string `json:"url"`
+}
+
+// Finding is an installed package with known vulnerabilities.
+type Finding struct {
+	Component resource.Component `json:"component"`
+	Vulns     []Vuln             `json:"vulns"`
+}
+
+// Worst returns the highest severity among f's vulnerabilities.
+func (f Finding) Worst() Severity {
+	var worst Severity
+	for _, v := range f.Vulns {
+		worst = max(worst, v.Severity)
+	}
+	return worst
+}
+
+// Covered reports whether OSV can be asked about c.
+func Covered(c resource.Component) bool {
+	typ, _, ok := strings.Cut(strings.TrimPrefix(c.PURL, "pkg:"), "/")
+	return ok && c.Version != "" && slices.Contains(covered, typ)
+}
+
+// Check looks up the vulnerabilities of the covered components, worst
+// first. Components without any are left out.
+func Check(ctx context.Context, components []resource.Component) ([]Finding, error) {
+	var queried []resource.Component
+	type query struct {
+		Package struct {
+			PURL string `json:"purl"`
+		} `json:"package"`
+	}
+	var req struct {
+		Queries []query `json:"queries"`
+	}
+	for _, c := range components {
+		if !Covered(c) {
+			continue
+		}
+		var q query
+		q.Package.PURL = c.PURL
+		req.Queries = append(req.Queries, q)
+		queried = append(queried, c)
+	}
+	if len(queried) == 0 {
+		return nil, nil
+	}
+	var resp struct {
+		Results []struct {
+			Vulns []struct {
+				ID string `json:"id"`
+			} `json:"vulns"`
+		} `json:"results"`
+	}
+	if err := fetch.PostJSON(ctx, API+"/querybatch", req, &resp); err != nil {
+		return nil, err
+	}
+	if len(resp.Results) != len(queried) {
+		return nil, fmt.Errorf("osv: %d results for %d queries", len(resp.Results), len(queried))
+	}
+	// The batch only returns IDs; several packages can share an advisory.
+	details := map[string]*advisory{}
+	var findings []Finding
+	for i, r := range resp.Results {
+		if len(r.Vulns) == 0 {
+			continue
+		}
+		f := Finding{Component: queried[i]}
+		for _, v := range r.Vulns {
+			a := details[v.ID]
+			if a == nil {
+				a = new(advisory)
+				if err := fetch.JSON(ctx, API+"/vulns/"+url.PathEscape(v.ID), a); err != nil {
+					return nil, err
+				}
+				details[v.ID] = a
+			}
+			f.Vulns = append(f.Vulns, a.vuln(queried[i]))
+		}
+		slices.SortStableFunc(f.Vulns, func(a, b Vuln) int { return int(b.Severity - a.Severity) })
+		findings = append(findings, f)
+	}
+	slices.SortStableFunc(findings, func(a, b Finding) int { return int(b.Worst() - a.Worst()) })
+	return findings, nil
+}
+
+// advisory is the part of an OSV record Check reads.
+type advisory struct {
+	ID       string   `json:"id"`
+	Summary  string   `json:"summary"`
+	Aliases  []string `json:"aliases"`
+	Severity []struct {
+		Type  string `json:"type"`
+		Score string `json:"score"`
+	} `json:"severity"`
+	Affected []struct {
+		Package struct {
+			Name string `json:"name"`
+			PURL string `json:"purl"`
+		} `json:"package"`
+		Ranges []struct {
+			Events []map[string]string `json:"events"`
+		} `json:"ranges"`
+	} `json:"affected"`
+	// DatabaseSpecific is free-form; GitHub advisories rate severity there.
+	DatabaseSpecific map[string]any `json:"database_specific"`
+}
+
+func (a *advisory) vuln(c resource.Component) Vuln {
+	v := Vuln{ID: a.ID, Aliases: a.Aliases, Summary: a.Summary, URL: "https://osv.dev/vulnerability/" + a.ID}
+	for _, s := range a.Severity {
+		if s.Type == "CVSS_V3" {
+			if score, err := cvss3(s.Score); err == nil {
+				v.Severity = max(v.Severity, band(score))
+			}
+		}
+	}
+	if v.Severity == SeverityUnknown {
+		if name, ok := a.DatabaseSpecific["severity"].(string); ok {
+			v.Severity, _ = ParseSeverity(name)
+		}
+	}
+	pkg := strings.SplitN(c.PURL, "@", 2)[0]
+	for _, af := range a.Affected {
+		if af.Package.PURL != "" && strings.SplitN(af.Package.PURL, "@", 2)[0] != pkg {
+			continue
+		}
+		for _, r := range af.Ranges {
+			for _, e := range r.Events {
+				fixed := e["fixed"]
+				if fixed == "" || selfupdate.Compare(fixed, c.Version) <= 0 {
+					continue
+				}
+				if v.Fixed == "" || selfupdate.Compare(fixed, v.Fixed) < 0 {
+					v.Fixed = fixed
+				}
+			}
+		}
+	}
+	return v
+}
+
+// band maps a CVSS base score to its qualitative rating.
+func band(score float64) Severity {
+	switch {
+	case score >= 9:
+		return SeverityCritical
+	case score >= 7:
+		return SeverityHigh
+	case score >= 4:
+		return SeverityMedium
+	case score > 0:
+		return SeverityLow
+	}
+	return SeverityUnknown
+}
+
+// cvss3Weights are the CVSS v3.1 base metric values, by metric and value.
+var cvss3Weights = map[string]map[string]float64{
+	"AV": {"N": 0.85, "A": 0.62, "L": 0.55, "P": 0.2},
+	"AC": {"L": 0.77, "H": 0.44},
+	"PR": {"N": 0.85, "L": 0.62, "H": 0.27},
+	"UI": {"N": 0.85, "R": 0.62},
+	"C":  {"H": 0.56, "L": 0.22, "N": 0},
+	"I":  {"H": 0.56, "L": 0.22, "N": 0},
+	"A":  {"H": 0.56, "L": 0.22, "N": 0},
+}
+
+// cvss3 computes the base score of a CVSS v3 vector such as
+// "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H".
+func cvss3(vector string) (float64, error) {
+	m := map[string]string{}
+	for _, part := range strings.Split(vector, "/")[1:] {
+		k, val, _ := strings.Cut(part, ":")
+		m[k] = val
+	}
+	w := map[string]float64{}
+	for metric, values := range cvss3Weights {
+		x, ok := values[m[metric]]
+		if !ok {
+			return 0, fmt.Errorf("cvss vector %q: bad or missing %s", vector, metric)
+		}
+		w[metric] = x
+	}
+	changed := m["S"] == "C"
+	if changed {
+		// Privileges count for more when the impact crosses a boundary.
+		w["PR"] = map[string]float64{"N": 0.85, "L": 0.68, "H": 0.5}[m["PR"]]
+	}
+	iss := 1 - (1-w["C"])*(1-w["I"])*(1-w["A"])
+	impact := 6.42 * iss
+	if changed {
+		impact = 7.52*(iss-0.029) - 3.25*math.Pow(iss-0.02, 15)
+	}
+	if impact <= 0 {
+		return 0, nil
+	}
+	exploitability := 8.22 * w["AV"] * w["AC"] * w["PR"] * w["UI"]
+	score := impact + exploitability
+	if changed {
+		score *= 1.08
+	}
+	return roundUp(min(score, 10)), nil
+}
+
+// roundUp rounds up to one decimal as the CVSS v3.1 specification defines,
+// avoiding floating point surprises such as 4.000001 becoming 4.1.
+func roundUp(x float64) float64 {
+	n := int(math.Round(x * 100000))
+	if n%10000 == 0 {
+		return float64(n) / 100000
+	}
+	return float64(n/10000+1) / 10
+}
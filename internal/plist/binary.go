@@ -0,0 +1,219 @@
+package plist
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"unicode/utf16"
+)
+
+// binaryPlist is a bplist00 file: objects addressed through an offset
+// table, described by the 32-byte trailer.
+type binaryPlist struct {
+	data     []byte
+	offsets  []uint64
+	refSize  int
+	visiting map[uint64]bool
+}
+
+var errTruncated = errors.New("truncated binary property list")
+
+func decodeBinary(data []byte) (any, error) {
+	if len(data) < 8+32 {
+		return nil, errTruncated
+	}
+	trailer := data[len(data)-32:]
+	offsetSize, refSize := int(trailer[6]), int(trailer[7])
+	count := binary.BigEndian.Uint64(trailer[8:])
+	top := binary.BigEndian.Uint64(trailer[16:])
+	table := binary.BigEndian.Uint64(trailer[24:])
+	if offsetSize < 1 || offsetSize > 8 || refSize < 1 || refSize > 8 || top >= count ||
+		table > uint64(len(data)) || count > (uint64(len(data))-table)/uint64(offsetSize) {
+		return nil, errors.New("corrupt binary property list trailer")
+	}
+	p := &binaryPlist{data: data, refSize: refSize, visiting: map[uint64]bool{}}
+	p.offsets = make([]uint64, count)
+	for i := range p.offsets {
+		at := table + uint64(i*offsetSize)
+		p.offsets[i] = uintN(data[at : at+uint64(offsetSize)])
+	}
+	return p.object(top)
+}
+
+func uintN(b []byte) uint64 {
+	var n uint64
+	for _, c := range b {
+		n = n<<8 | uint64(c)
+	}
+	return n
+}
+
+// bytes returns n bytes at off.
+func (p *binaryPlist) bytes(off, n uint64) ([]byte, error) {
+	if off > uint64(len(p.data)) || n > uint64(len(p.data))-off {
+		return nil, errTruncated
+	}
+	return p.data[off : off+n], nil
+}
+
+// length reads the element count of the object whose marker is at off,
+// returning it and where the content starts.
+func (p *binaryPlist) length(off uint64, info byte) (uint64, uint64, error) {
+	if info != 0x0F {
+		return uint64(info), off + 1, nil
+	}
+	m, err := p.bytes(off+1, 1)
+	if err != nil {
+		return 0, 0, err
+	}
+	if m[0]>>4 != 0x1 {
+		return 0, 0, errors.New("corrupt length in binary property list")
+	}
+	size := uint64(1) << (m[0] & 0x0F)
+	b, err := p.bytes(off+2, size)
+	if err != nil {
+		return 0, 0, err
+	}
+	return uintN(b), off + 2 + size, nil
+}
+
+func (p *binaryPlist) refs(off, n uint64) ([]uint64, error) {
+	b, err := p.bytes(off, n*uint64(p.refSize))
+	if err != nil {
+		return nil, err
+	}
+	out := make([]uint64, n)
+	for i := range out {
+		out[i] = uintN(b[i*p.refSize : (i+1)*p.refSize])
+	}
+	return out, nil
+}
+
+func (p *binaryPlist) object(ref uint64) (any, error) {
+	if ref >= uint64(len(p.offsets)) {
+		return nil, fmt.Errorf("object %d out of range", ref)
+	}
+	if p.visiting[ref] {
+		return nil, errors.New("binary property list refers to itself")
+	}
+	p.visiting[ref] = true
+	defer delete(p.visiting, ref)
+
+	off := p.offsets[ref]
+	m, err := p.bytes(off, 1)
+	if err != nil {
+		return nil, err
+	}
+	kind, info := m[0]>>4, m[0]&0x0F
+	switch kind {
+	case 0x0:
+		switch info {
+		case 0x8:
+			return false, nil
+		case 0x9:
+			return true, nil
+		}
+		return nil, fmt.Errorf("unsupported binary plist marker %#x", m[0])
+	case 0x1:
+		b, err := p.bytes(off+1, 1<<info)
+		if err != nil {
+			return nil, err
+		}
+		// Shorter integers are unsigned and 8-byte ones signed, which the
+		// conversion gives both; 16-byte ones hold a 64-bit value in their
+		// low half.
+		if len(b) > 8 {
+			b = b[len(b)-8:]
+		}
+		return int64(uintN(b)), nil
+	case 0x2:
+		b, err := p.bytes(off+1, 1<<info)
+		if err != nil {
+			return nil, err
+		}
+		switch len(b) {
+		case 4:
+			return float64(math.Float32frombits(binary.BigEndian.Uint32(b))), nil
+		case 8:
+			return math.Float64frombits(binary.BigEndian.Uint64(b)), nil
+		}
+		return nil, fmt.Errorf("unsupported %d-byte real", len(b))
+	case 0x3:
+		b, err := p.bytes(off+1, 8)
+		if err != nil {
+			return nil, err
+		}
+		return fromEpoch(math.Float64frombits(binary.BigEndian.Uint64(b))), nil
+	case 0x4, 0x5, 0x6:
+		n, start, err := p.length(off, info)
+		if err != nil {
+			return nil, err
+		}
+		if kind == 0x6 {
+			n *= 2
+		}
+		b, err := p.bytes(start, n)
+		if err != nil {
+			return nil, err
+		}
+		switch kind {
+		case 0x4:
+			return append([]byte{}, b...), nil
+		case 0x5:
+			return string(b), nil
+		}
+		units := make([]uint16, n/2)
+		for i := range units {
+			units[i] = binary.BigEndian.Uint16(b[2*i:])
+		}
+		return string(utf16.Decode(units)), nil
+	case 0x8:
+		b, err := p.bytes(off+1, uint64(info)+1)
+		if err != nil {
+			return nil, err
+		}
+		return int64(uintN(b)), nil
+	case 0xA, 0xC:
+		n, start, err := p.length(off, info)
+		if err != nil {
+			return nil, err
+		}
+		refs, err := p.refs(start, n)
+		if err != nil {
+			return nil, err
+		}
+		out := make([]any, n)
+		for i, r := range refs {
+			if out[i], err = p.object(r); err != nil {
+				return nil, fmt.Errorf("[%d]: %w", i, err)
+			}
+		}
+		return out, nil
+	case 0xD:
+		n, start, err := p.length(off, info)
+		if err != nil {
+			return nil, err
+		}
+		refs, err := p.refs(start, 2*n)
+		if err != nil {
+			return nil, err
+		}
+		out := make(map[string]any, n)
+		for i := uint64(0); i < n; i++ {
+			k, err := p.object(refs[i])
+			if err != nil {
+				return nil, err
+			}
+			key, ok := k.(string)
+			if !ok {
+				return nil, fmt.Errorf("dict key is a %T", k)
+			}
+			if out[key], err = p.object(refs[n+i]); err != nil {
+				return nil, fmt.Errorf("%s: %w", key, err)
+			}
+		}
+		return out, nil
+	}
+	return nil, fmt.Errorf("unsupported binary plist marker %#x", m[0])
+}
@@ -0,0 +1,329 @@
+package plist
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Change is one key path whose value differs. Old is nil for a key that is
+// added and New for one that is removed.
+type Change struct {
+	Path     string
+	Old, New any
+}
+
+func (c Change) String() string {
+	switch {
+	case c.Old == nil:
+		return c.Path + ": added " + Format(c.New)
+	case c.New == nil:
+		return c.Path + ": removed " + Format(c.Old)
+	}
+	return c.Path + ": " + Format(c.Old) + " → " + Format(c.New)
+}
+
+// Diff returns the key paths where a and b differ, in order. Dicts are
+// compared key by key and arrays element by element; a value that changes
+// type is one change.
+func Diff(a, b any) []Change {
+	var out []Change
+	diff("", a, b, &out)
+	return out
+}
+
+func diff(path string, a, b any, out *[]Change) {
+	switch {
+	case a == nil && b == nil:
+		return
+	case a == nil || b == nil:
+		*out = append(*out, Change{Path: path, Old: a, New: b})
+		return
+	}
+	switch a := a.(type) {
+	case map[string]any:
+		if b, ok := b.(map[string]any); ok {
+			keys := sortedKeys(a)
+			for _, k := range sortedKeys(b) {
+				if _, ok := a[k]; !ok {
+					keys = append(keys, k)
+				}
+			}
+			for _, k := range keys {
+				diff(join(path, k), a[k], b[k], out)
+			}
+			return
+		}
+	case []any:
+		if b, ok := b.([]any); ok {
+			for i := 0; i < max(len(a), len(b)); i++ {
+				var x, y any
+				if i < len(a) {
+					x = a[i]
+				}
+				if i < len(b) {
+					y = b[i]
+				}
+				diff(fmt.Sprintf("%s[%d]", path, i), x, y, out)
+			}
+			return
+		}
+	}
+	if !Equal(a, b) {
+		*out = append(*out, Change{Path: path, Old: a, New: b})
+	}
+}
+
+var bareKey = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// join appends a dict key to a key path. Keys that are not plain words are
+// quoted: com.apple.dock["key.with.dots"].
+func join(path, key string) string {
+	if !bareKey.MatchString(key) {
+		return path + "[" + strconv.Quote(key) + "]"
+	}
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// Format renders a value as it appears in a key path listing: strings
+// quoted, reals with a decimal point, dates and data wrapped so their type
+// shows, and empty collections as {} and [].
+func Format(v any) string {
+	switch v := v.(type) {
+	case string:
+		data, _ := json.Marshal(v)
+		return string(data)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		s := strconv.FormatFloat(v, 'g', -1, 64)
+		if !strings.ContainsAny(s, ".eEnN") {
+			s += ".0"
+		}
+		return s
+	case bool:
+		return strconv.FormatBool(v)
+	case time.Time:
+		return "date(" + v.UTC().Format(time.RFC3339) + ")"
+	case []byte:
+		return "data(" + base64.StdEncoding.EncodeToString(v) + ")"
+	case map[string]any:
+		if len(v) == 0 {
+			return "{}"
+		}
+		return fmt.Sprintf("{%d keys}", len(v))
+	case []any:
+		if len(v) == 0 {
+			return "[]"
+		}
+		return fmt.Sprintf("[%d items]", len(v))
+	}
+	return fmt.Sprint(v)
+}
+
+// Flatten lists every leaf of v under root as "path = value" lines, so two
+// versions diff line by line along key paths. Unflatten reads it back.
+func Flatten(root string, v any) string {
+	var b strings.Builder
+	flatten(&b, root, v)
+	return b.String()
+}
+
+func flatten(b *strings.Builder, path string, v any) {
+	switch v := v.(type) {
+	case map[string]any:
+		if len(v) > 0 {
+			for _, k := range sortedKeys(v) {
+				flatten(b, join(path, k), v[k])
+			}
+			return
+		}
+	case []any:
+		if len(v) > 0 {
+			for i, x := range v {
+				flatten(b, fmt.Sprintf("%s[%d]", path, i), x)
+			}
+			return
+		}
+	}
+	b.WriteString(path + " = " + Format(v) + "\n")
+}
+
+// Unflatten parses the lines Flatten writes into a dict of their roots.
+func Unflatten(text string) (map[string]any, error) {
+	root := map[string]any{}
+	for n, line := range strings.Split(strings.TrimRight(text, "\n"), "\n") {
+		if line == "" {
+			continue
+		}
+		path, literal, ok := cutAssign(line)
+		if !ok {
+			return nil, fmt.Errorf("line %d: want path = value", n+1)
+		}
+		segs, err := splitPath(path)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n+1, err)
+		}
+		v, err := parseLiteral(literal)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n+1, err)
+		}
+		if _, err := set(root, segs, v); err != nil {
+			return nil, fmt.Errorf("line %d: %s: %w", n+1, path, err)
+		}
+	}
+	return root, nil
+}
+
+// cutAssign splits a line at the " = " after its path; quoted keys may
+// hold " = " themselves.
+func cutAssign(line string) (string, string, bool) {
+	inQuote := false
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; {
+		case c == '\\' && inQuote:
+			i++
+		case c == '"':
+			inQuote = !inQuote
+		case !inQuote && strings.HasPrefix(line[i:], " = "):
+			return line[:i], line[i+3:], true
+		}
+	}
+	return "", "", false
+}
+
+// segment is a dict key or, when key is false, an array index.
+type segment struct {
+	name  string
+	index int
+	key   bool
+}
+
+func splitPath(path string) ([]segment, error) {
+	var out []segment
+	for i := 0; i < len(path); {
+		switch {
+		case path[i] == '.':
+			i++
+		case strings.HasPrefix(path[i:], `["`):
+			end := i + 2
+			for end < len(path) && path[end] != '"' {
+				if path[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end+1 >= len(path) || path[end+1] != ']' {
+				return nil, fmt.Errorf("unterminated key in %s", path)
+			}
+			key, err := strconv.Unquote(path[i+1 : end+1])
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, segment{name: key, key: true})
+			i = end + 2
+		case path[i] == '[':
+			end := strings.IndexByte(path[i:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated index in %s", path)
+			}
+			n, err := strconv.Atoi(path[i+1 : i+end])
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("bad index in %s", path)
+			}
+			out = append(out, segment{index: n})
+			i += end + 1
+		default:
+			end := i
+			for end < len(path) && path[end] != '.' && path[end] != '[' {
+				end++
+			}
+			out = append(out, segment{name: path[i:end], key: true})
+			i = end
+		}
+	}
+	if len(out) == 0 || !out[0].key {
+		return nil, fmt.Errorf("path %q must start with a key", path)
+	}
+	return out, nil
+}
+
+// set stores v at segs under node and returns node, grown as needed.
+// Array elements must be added in order.
+func set(node any, segs []segment, v any) (any, error) {
+	if len(segs) == 0 {
+		if node != nil {
+			return nil, fmt.Errorf("set twice")
+		}
+		return v, nil
+	}
+	s := segs[0]
+	if s.key {
+		if node == nil {
+			node = map[string]any{}
+		}
+		m, ok := node.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("%s is not in a dict", s.name)
+		}
+		child, err := set(m[s.name], segs[1:], v)
+		if err != nil {
+			return nil, err
+		}
+		m[s.name] = child
+		return m, nil
+	}
+	if node == nil {
+		node = []any{}
+	}
+	a, ok := node.([]any)
+	if !ok {
+		return nil, fmt.Errorf("[%d] is not in an array", s.index)
+	}
+	switch {
+	case s.index == len(a):
+		a = append(a, nil)
+	case s.index > len(a):
+		return nil, fmt.Errorf("array has no element %d", len(a))
+	}
+	child, err := set(a[s.index], segs[1:], v)
+	if err != nil {
+		return nil, err
+	}
+	a[s.index] = child
+	return a, nil
+}
+
+// parseLiteral reads a value written by Format.
+func parseLiteral(s string) (any, error) {
+	switch {
+	case s == "true", s == "false":
+		return s == "true", nil
+	case s == "{}":
+		return map[string]any{}, nil
+	case s == "[]":
+		return []any{}, nil
+	case strings.HasPrefix(s, `"`):
+		var out string
+		err := json.Unmarshal([]byte(s), &out)
+		return out, err
+	case strings.HasPrefix(s, "date(") && strings.HasSuffix(s, ")"):
+		return time.Parse(time.RFC3339, s[5:len(s)-1])
+	case strings.HasPrefix(s, "data(") && strings.HasSuffix(s, ")"):
+		return base64.StdEncoding.DecodeString(s[5 : len(s)-1])
+	case strings.ContainsAny(s, ".eEnN"):
+		return strconv.ParseFloat(s, 64)
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("unknown value %s", s)
+	}
+	return n, nil
+}
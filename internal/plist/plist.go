@@ -0,0 +1,163 @@
+// Package plist reads and writes property lists, the format macOS keeps
+// preferences in, and compares them key by key so a change shows up as the
+// key paths it touches rather than as a rewritten binary file.
+//
+// Values are map[string]any for dicts, []any for arrays, string, int64,
+// float64, bool, time.Time for dates and []byte for data.
+package plist
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// Decode parses an XML or binary property list.
+func Decode(data []byte) (any, error) {
+	if bytes.HasPrefix(data, []byte("bplist00")) {
+		return decodeBinary(data)
+	}
+	return decodeXML(data)
+}
+
+// Normalize converts a value decoded from TOML into plist types: ints
+// become int64 and arrays and tables of any element type become []any and
+// map[string]any, recursively.
+func Normalize(v any) (any, error) {
+	switch v := v.(type) {
+	case string, int64, float64, bool, time.Time, []byte:
+		return v, nil
+	case int:
+		return int64(v), nil
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for k, x := range v {
+			n, err := Normalize(x)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", k, err)
+			}
+			out[k] = n
+		}
+		return out, nil
+	case []any:
+		out := make([]any, len(v))
+		for i, x := range v {
+			n, err := Normalize(x)
+			if err != nil {
+				return nil, fmt.Errorf("[%d]: %w", i, err)
+			}
+			out[i] = n
+		}
+		return out, nil
+	case []map[string]any:
+		items := make([]any, len(v))
+		for i, x := range v {
+			items[i] = x
+		}
+		return Normalize(items)
+	case []string:
+		items := make([]any, len(v))
+		for i, x := range v {
+			items[i] = x
+		}
+		return items, nil
+	case nil:
+		return nil, errors.New("value is required")
+	}
+	return nil, fmt.Errorf("unsupported value type %T", v)
+}
+
+// Equal reports whether a and b hold the same value. Integers and reals
+// compare as numbers, since `defaults` is loose about which it writes.
+func Equal(a, b any) bool {
+	switch a := a.(type) {
+	case map[string]any:
+		b, ok := b.(map[string]any)
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for k, x := range a {
+			y, ok := b[k]
+			if !ok || !Equal(x, y) {
+				return false
+			}
+		}
+		return true
+	case []any:
+		b, ok := b.([]any)
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for i := range a {
+			if !Equal(a[i], b[i]) {
+				return false
+			}
+		}
+		return true
+	case []byte:
+		b, ok := b.([]byte)
+		return ok && bytes.Equal(a, b)
+	case time.Time:
+		b, ok := b.(time.Time)
+		return ok && a.Equal(b)
+	}
+	if x, ok := number(a); ok {
+		y, ok := number(b)
+		return ok && x == y
+	}
+	return a == b
+}
+
+func number(v any) (float64, bool) {
+	switch n := v.(type) {
+	case int64:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
+
+// Type names the plist type of v as `defaults read-type` does.
+func Type(v any) string {
+	switch v.(type) {
+	case map[string]any:
+		return "dictionary"
+	case []any:
+		return "array"
+	case string:
+		return "string"
+	case int64:
+		return "integer"
+	case float64:
+		return "float"
+	case bool:
+		return "boolean"
+	case time.Time:
+		return "date"
+	case []byte:
+		return "data"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// sortedKeys returns the keys of a dict in order.
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// epoch is the reference date of binary plist dates.
+var epoch = time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func fromEpoch(seconds float64) time.Time {
+	whole, frac := math.Modf(seconds)
+	return epoch.Add(time.Duration(whole) * time.Second).Add(time.Duration(frac * float64(time.Second))).UTC()
+}
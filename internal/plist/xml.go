@@ -0,0 +1,191 @@
+package plist
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const header = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+`
+
+// Encode renders v as an XML property list. Dict keys are sorted so the
+// output is stable and can be compared byte for byte.
+func Encode(v any) ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteString(header)
+	if err := write(&b, v, 0); err != nil {
+		return nil, err
+	}
+	b.WriteString("</plist>\n")
+	return b.Bytes(), nil
+}
+
+// Fragment renders v as the XML of the value alone, the form `defaults
+// write domain key` takes for arrays and dicts.
+func Fragment(v any) (string, error) {
+	var b bytes.Buffer
+	if err := write(&b, v, -1); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// write renders v indented by depth tabs; a negative depth writes it on
+// one line.
+func write(b *bytes.Buffer, v any, depth int) error {
+	indent, nl := strings.Repeat("\t", max(depth, 0)), "\n"
+	next := depth + 1
+	if depth < 0 {
+		nl, next = "", -1
+	}
+	switch v := v.(type) {
+	case string:
+		b.WriteString(indent + "<string>")
+		xml.EscapeText(b, []byte(v))
+		b.WriteString("</string>" + nl)
+	case bool:
+		fmt.Fprintf(b, "%s<%t/>%s", indent, v, nl)
+	case int:
+		fmt.Fprintf(b, "%s<integer>%d</integer>%s", indent, v, nl)
+	case int64:
+		fmt.Fprintf(b, "%s<integer>%d</integer>%s", indent, v, nl)
+	case float64:
+		fmt.Fprintf(b, "%s<real>%s</real>%s", indent, strconv.FormatFloat(v, 'g', -1, 64), nl)
+	case time.Time:
+		fmt.Fprintf(b, "%s<date>%s</date>%s", indent, v.UTC().Format(time.RFC3339), nl)
+	case []byte:
+		fmt.Fprintf(b, "%s<data>%s</data>%s", indent, base64.StdEncoding.EncodeToString(v), nl)
+	case []string:
+		items := make([]any, len(v))
+		for i, s := range v {
+			items[i] = s
+		}
+		return write(b, items, depth)
+	case []any:
+		b.WriteString(indent + "<array>" + nl)
+		for _, item := range v {
+			if err := write(b, item, next); err != nil {
+				return err
+			}
+		}
+		b.WriteString(indent + "</array>" + nl)
+	case map[string]any:
+		inner := strings.Repeat("\t", max(next, 0))
+		b.WriteString(indent + "<dict>" + nl)
+		for _, k := range sortedKeys(v) {
+			b.WriteString(inner + "<key>")
+			xml.EscapeText(b, []byte(k))
+			b.WriteString("</key>" + nl)
+			if err := write(b, v[k], next); err != nil {
+				return fmt.Errorf("%s: %w", k, err)
+			}
+		}
+		b.WriteString(indent + "</dict>" + nl)
+	default:
+		return fmt.Errorf("unsupported plist value %T", v)
+	}
+	return nil
+}
+
+// decodeXML parses an XML property list.
+func decodeXML(data []byte) (any, error) {
+	d := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			return nil, errors.New("empty property list")
+		}
+		if err != nil {
+			return nil, err
+		}
+		if start, ok := tok.(xml.StartElement); ok {
+			if start.Name.Local == "plist" {
+				continue
+			}
+			return readValue(d, start)
+		}
+	}
+}
+
+// readValue reads the element opened by start.
+func readValue(d *xml.Decoder, start xml.StartElement) (any, error) {
+	switch name := start.Name.Local; name {
+	case "dict":
+		out := map[string]any{}
+		key := ""
+		for {
+			tok, err := d.Token()
+			if err != nil {
+				return nil, err
+			}
+			switch t := tok.(type) {
+			case xml.StartElement:
+				if t.Name.Local == "key" {
+					if err := d.DecodeElement(&key, &t); err != nil {
+						return nil, err
+					}
+					continue
+				}
+				v, err := readValue(d, t)
+				if err != nil {
+					return nil, fmt.Errorf("%s: %w", key, err)
+				}
+				out[key] = v
+			case xml.EndElement:
+				return out, nil
+			}
+		}
+	case "array":
+		out := []any{}
+		for {
+			tok, err := d.Token()
+			if err != nil {
+				return nil, err
+			}
+			switch t := tok.(type) {
+			case xml.StartElement:
+				v, err := readValue(d, t)
+				if err != nil {
+					return nil, fmt.Errorf("[%d]: %w", len(out), err)
+				}
+				out = append(out, v)
+			case xml.EndElement:
+				return out, nil
+			}
+		}
+	}
+	var text string
+	if err := d.DecodeElement(&text, &start); err != nil {
+		return nil, err
+	}
+	return scalar(start.Name.Local, text)
+}
+
+func scalar(name, text string) (any, error) {
+	switch name {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "string":
+		return text, nil
+	case "integer":
+		return strconv.ParseInt(strings.TrimSpace(text), 10, 64)
+	case "real":
+		return strconv.ParseFloat(strings.TrimSpace(text), 64)
+	case "date":
+		return time.Parse(time.RFC3339, strings.TrimSpace(text))
+	case "data":
+		return base64.StdEncoding.DecodeString(strings.Join(strings.Fields(text), ""))
+	}
+	return nil, fmt.Errorf("unknown plist element <%s>", name)
+}
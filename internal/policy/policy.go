@@ -0,0 +1,206 @@
+// Package policy evaluates an organization's restrictions on what may be
+// installed — deny and allow lists, version floors and signed casks —
+// against a plan, so managed machines can be kept free of certain software
+// whatever their templates say.
+package policy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+
+	"github.com/hmziqrs/maziq/internal/catalog"
+	"github.com/hmziqrs/maziq/internal/config"
+	"github.com/hmziqrs/maziq/internal/resource"
+	"github.com/hmziqrs/maziq/internal/selfupdate"
+	"github.com/hmziqrs/maziq/internal/shell"
+)
+
+// Policy is a policy file:
+//
+//	deny = ["software:docker", "mas:*"]
+//	allow = ["software:*", "font:*"]
+//	require_signed = true
+//
+//	[min_versions]
+//	"software:git" = "2.40"
+//	ripgrep = "14"
+//
+// Patterns are resource IDs as plan shows them, with * and ? wildcards. A
+// pattern without a kind matches the name of anything that installs
+// software: catalog software, App Store apps, direct downloads, release
+// binaries and fonts. Allow, when set, only restricts those kinds.
+type Policy struct {
+	Deny  []string `toml:"deny"`
+	Allow []string `toml:"allow"`
+	// MinVersions maps patterns to the lowest version allowed installed.
+	MinVersions map[string]string `toml:"min_versions"`
+	// RequireSigned refuses Homebrew casks that Homebrew lists as unsigned.
+	RequireSigned bool `toml:"require_signed"`
+
+	// Path is where the policy was loaded from.
+	Path string `toml:"-"`
+}
+
+// installKinds are the resource kinds that put software on the machine.
+var installKinds = []string{"software", "mas", "app", "bin", "font"}
+
+// Rules a Violation can break.
+const (
+	RuleDeny          = "deny"
+	RuleAllow         = "allow"
+	RuleMinVersion    = "min_version"
+	RuleRequireSigned = "require_signed"
+)
+
+// Violation is a plan item that breaks the policy.
+type Violation struct {
+	ID      string `json:"id"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("%s: %s", v.ID, v.Message)
+}
+
+// File returns where the policy is read from.
+func File() string {
+	if p := os.Getenv("MAZIQ_POLICY"); p != "" {
+		return p
+	}
+	if cfg, err := config.Load(); err == nil && cfg.Policy.Path != "" {
+		return cfg.Policy.Path
+	}
+	return filepath.Join(config.Dir(), "policy.toml")
+}
+
+// Load reads the policy. It returns nil when there is none.
+func Load() (*Policy, error) {
+	p := &Policy{Path: File()}
+	meta, err := toml.DecodeFile(p.Path, p)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return nil, nil
+	case err != nil:
+		return nil, fmt.Errorf("policy %s: %w", p.Path, err)
+	}
+	if keys := meta.Undecoded(); len(keys) > 0 {
+		return nil, fmt.Errorf("policy %s: unknown key %q", p.Path, keys[0].String())
+	}
+	for _, pat := range slices.Concat(p.Deny, p.Allow, mapKeys(p.MinVersions)) {
+		if _, err := path.Match(pat, ""); err != nil {
+			return nil, fmt.Errorf("policy %s: bad pattern %q", p.Path, pat)
+		}
+	}
+	return p, nil
+}
+
+func mapKeys(m map[string]string) []string {
+	out := make([]string, 0, len(m))
+	for k := range m {
+		out = append(out, k)
+	}
+	return out
+}
+
+// Item is what the policy is evaluated against: a resource and what its
+// check found.
+type Item struct {
+	Resource resource.Resource
+	State    resource.State
+}
+
+// Evaluate returns every violation among items. Signed casks are looked up
+// with brew through r; without brew that rule is not evaluated.
+func (p *Policy) Evaluate(ctx context.Context, r shell.Runner, items []Item) []Violation {
+	var out []Violation
+	var casks []string
+	caskIDs := map[string]string{}
+	for _, it := range items {
+		id := it.Resource.ID()
+		kind, _, _ := strings.Cut(id, ":")
+		installs := slices.Contains(installKinds, kind)
+		if pat, ok := p.match(p.Deny, id, installs); ok {
+			out = append(out, Violation{ID: id, Rule: RuleDeny, Message: fmt.Sprintf("denied (%s)", pat)})
+			continue
+		}
+		if _, ok := p.match(p.Allow, id, installs); installs && len(p.Allow) > 0 && !ok {
+			out = append(out, Violation{ID: id, Rule: RuleAllow, Message: "not on the allow list"})
+			continue
+		}
+		if v := it.State.Version; v != "" {
+			for pat, floor := range p.MinVersions {
+				if _, ok := p.match([]string{pat}, id, installs); ok && selfupdate.Compare(v, floor) < 0 {
+					out = append(out, Violation{ID: id, Rule: RuleMinVersion, Message: fmt.Sprintf("version %s is below the minimum of %s", v, floor)})
+				}
+			}
+		}
+		if c, ok := it.Resource.(interface{ Catalog() catalog.Software }); ok && p.RequireSigned {
+			if src := c.Catalog().Primary(); src.Backend == catalog.BackendCask {
+				casks = append(casks, src.Package)
+				caskIDs[src.Package] = id
+			}
+		}
+	}
+	if len(casks) > 0 {
+		for token, reason := range unsigned(ctx, r, casks) {
+			out = append(out, Violation{ID: caskIDs[token], Rule: RuleRequireSigned, Message: fmt.Sprintf("cask %s is %s; signed apps are required", token, reason)})
+		}
+	}
+	slices.SortStableFunc(out, func(a, b Violation) int { return strings.Compare(a.ID, b.ID) })
+	return out
+}
+
+// match returns the first pattern matching id, or its name when the
+// pattern has no kind and id installs software.
+func (p *Policy) match(patterns []string, id string, installs bool) (string, bool) {
+	_, name, _ := strings.Cut(id, ":")
+	for _, pat := range patterns {
+		if ok, _ := path.Match(pat, id); ok {
+			return pat, true
+		}
+		if ok, _ := path.Match(pat, name); ok && installs && !strings.Contains(pat, ":") {
+			return pat, true
+		}
+	}
+	return "", false
+}
+
+// unsigned maps the casks among tokens that Homebrew deprecated or disabled
+// for being unsigned to how it describes them.
+func unsigned(ctx context.Context, r shell.Runner, tokens []string) map[string]string {
+	res, err := r.Run(ctx, shell.Cmd("brew", append([]string{"info", "--cask", "--json=v2"}, tokens...)...))
+	if err != nil {
+		return nil
+	}
+	var info struct {
+		Casks []struct {
+			Token             string `json:"token"`
+			DeprecationReason string `json:"deprecation_reason"`
+			DisableReason     string `json:"disable_reason"`
+		} `json:"casks"`
+	}
+	if json.Unmarshal([]byte(res.Stdout), &info) != nil {
+		return nil
+	}
+	out := map[string]string{}
+	for _, c := range info.Casks {
+		switch {
+		case c.DisableReason == "unsigned":
+			out[c.Token] = "disabled by Homebrew as unsigned"
+		case c.DeprecationReason == "unsigned":
+			out[c.Token] = "deprecated by Homebrew as unsigned"
+		}
+	}
+	return out
+}
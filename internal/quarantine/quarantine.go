@@ -0,0 +1,250 @@
+// Package quarantine sets aside resources whose apply keeps failing. After
+// enough failures in a row a resource is quarantined: its diagnostics are
+// zipped into a bundle for a bug report, and applies skip it until
+// `maziq quarantine clear` lets it be tried again.
+package quarantine
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hmziqrs/maziq/internal/catalog"
+	"github.com/hmziqrs/maziq/internal/config"
+	"github.com/hmziqrs/maziq/internal/resource"
+	"github.com/hmziqrs/maziq/internal/shell"
+	"github.com/hmziqrs/maziq/internal/state"
+)
+
+const prefix = "quarantine/"
+
+// keepErrors is how many of the latest failures a Record remembers.
+const keepErrors = 5
+
+// Record is the failure streak of one resource.
+type Record struct {
+	ID string `json:"id"`
+	// Failures counts the applies in a row that failed.
+	Failures int       `json:"failures"`
+	Errors   []Failure `json:"errors"`
+	// Since is when the resource was quarantined, zero while it is not.
+	Since  time.Time `json:"since,omitzero"`
+	Bundle string    `json:"bundle,omitempty"`
+}
+
+// Failure is one failed apply.
+type Failure struct {
+	Time  time.Time `json:"time"`
+	Error string    `json:"error"`
+}
+
+// Quarantined reports whether applies skip the resource.
+func (r Record) Quarantined() bool { return !r.Since.IsZero() }
+
+// Dir is where bundles are written.
+func Dir() string {
+	return filepath.Join(config.Dir(), "quarantine")
+}
+
+// Load returns the records of the resources that failed their last apply,
+// keyed by resource ID.
+func Load() (map[string]Record, error) {
+	records, err := state.List[Record](prefix)
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]Record, len(records))
+	for _, r := range records {
+		out[r.ID] = r
+	}
+	return out, nil
+}
+
+// Clear forgets id's failures, so the next apply tries it again. Its bundle
+// is kept.
+func Clear(id string) error {
+	return state.Delete(prefix + id)
+}
+
+// Fail records a failed apply of r. The transcript is what the attempt ran
+// and logged. Once the resource has failed after times in a row, it is
+// quarantined and a diagnostics bundle is written.
+func Fail(ctx context.Context, env *resource.Env, r resource.Resource, failure error, transcript string, after int) (Record, error) {
+	var rec Record
+	if _, err := state.Get(prefix+r.ID(), &rec); err != nil {
+		return Record{}, err
+	}
+	rec.ID = r.ID()
+	rec.Failures++
+	rec.Errors = append(rec.Errors, Failure{Time: time.Now().UTC(), Error: failure.Error()})
+	if len(rec.Errors) > keepErrors {
+		rec.Errors = rec.Errors[len(rec.Errors)-keepErrors:]
+	}
+	if rec.Failures >= after && !rec.Quarantined() {
+		rec.Since = time.Now().UTC()
+		path, err := writeBundle(ctx, env, r, rec, transcript)
+		if err != nil {
+			env.Log("warning: writing diagnostics for %s: %v", rec.ID, err)
+		}
+		rec.Bundle = path
+	}
+	return rec, state.Put(prefix+rec.ID, rec)
+}
+
+// writeBundle zips what is known about the failures of r.
+func writeBundle(ctx context.Context, env *resource.Env, r resource.Resource, rec Record, transcript string) (string, error) {
+	if err := os.MkdirAll(Dir(), 0o755); err != nil {
+		return "", err
+	}
+	name := strings.Map(func(c rune) rune {
+		if strings.ContainsRune(`:/\ ~`, c) {
+			return '_'
+		}
+		return c
+	}, rec.ID)
+	path := filepath.Join(Dir(), name+"-"+rec.Since.Local().Format("20060102-150405")+".zip")
+	f, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	z := zip.NewWriter(f)
+	add := func(name string, data []byte) error {
+		w, err := z.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: rec.Since})
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+	}
+
+	var failures bytes.Buffer
+	fmt.Fprintf(&failures, "%s: %s\n\n", rec.ID, r.Describe())
+	for _, e := range rec.Errors {
+		fmt.Fprintf(&failures, "%s  %s\n", e.Time.Local().Format(time.DateTime), e.Error)
+	}
+	facts, _ := json.MarshalIndent(env.Facts, "", "  ")
+	type file struct {
+		name string
+		data []byte
+	}
+	files := []file{
+		{"failures.txt", failures.Bytes()},
+		{"transcript.txt", []byte(transcript)},
+		{"facts.json", facts},
+	}
+	if _, err := exec.LookPath("brew"); err == nil {
+		files = append(files,
+			file{"brew-doctor.txt", capture(ctx, env, shell.Cmd("brew", "doctor"))},
+			file{"brew-config.txt", capture(ctx, env, shell.Cmd("brew", "config"))},
+		)
+	}
+	for _, f := range files {
+		if err := add(f.name, f.data); err != nil {
+			return "", err
+		}
+	}
+	for _, dir := range logDirs(env, r) {
+		_ = filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return nil
+			}
+			data, err := os.ReadFile(p)
+			if err != nil {
+				return nil
+			}
+			rel, _ := filepath.Rel(filepath.Dir(dir), p)
+			return add(filepath.Join("logs", rel), tail(data))
+		})
+	}
+	if err := z.Close(); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// capture runs c for the bundle, keeping its output whether or not it
+// succeeds; brew doctor exits non-zero whenever it has a warning.
+func capture(ctx context.Context, env *resource.Env, c shell.Command) []byte {
+	res, err := env.Run(ctx, c)
+	out := "$ " + c.String() + "\n" + res.Stdout + res.Stderr
+	if err != nil {
+		out += "\n" + err.Error() + "\n"
+	}
+	return []byte(out)
+}
+
+// logDirs are the Homebrew build logs of r's catalog packages.
+func logDirs(env *resource.Env, r resource.Resource) []string {
+	s, ok := r.(interface{ Catalog() catalog.Software })
+	if !ok {
+		return nil
+	}
+	var dirs []string
+	for _, src := range s.Catalog().Sources {
+		if src.Backend != catalog.BackendBrew && src.Backend != catalog.BackendCask {
+			continue
+		}
+		dir := filepath.Join(env.Facts["home"], "Library", "Logs", "Homebrew", filepath.Base(src.Package))
+		if _, err := os.Stat(dir); err == nil {
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs
+}
+
+// maxOutput caps each command output and log file in a bundle; the end is
+// where the error usually is.
+const maxOutput = 64 << 10
+
+func tail(b []byte) []byte {
+	if len(b) <= maxOutput {
+		return b
+	}
+	return append([]byte("[…]\n"), b[len(b)-maxOutput:]...)
+}
+
+// Transcript is a shell.Runner that keeps a copy of every command run
+// through it and its output, along with the lines logged beside them.
+type Transcript struct {
+	Runner shell.Runner
+	mu     sync.Mutex
+	buf    bytes.Buffer
+}
+
+// Run implements shell.Runner.
+func (t *Transcript) Run(ctx context.Context, c shell.Command) (shell.Result, error) {
+	res, err := t.Runner.Run(ctx, c)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	fmt.Fprintf(&t.buf, "$ %s\n", c)
+	t.buf.Write(tail([]byte(res.Stdout)))
+	t.buf.Write(tail([]byte(res.Stderr)))
+	fmt.Fprintf(&t.buf, "[exit %d after %s]\n", res.ExitCode, res.Duration.Round(time.Millisecond))
+	if err != nil {
+		fmt.Fprintf(&t.buf, "[%v]\n", err)
+	}
+	return res, err
+}
+
+// Log adds a progress line.
+func (t *Transcript) Log(line string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	fmt.Fprintf(&t.buf, "# %s\n", line)
+}
+
+func (t *Transcript) String() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.buf.String()
+}
@@ -0,0 +1,244 @@
+// Package remedy recognises common install failures by what the failing
+// command printed and knows the steps that fix them, so a failed apply can
+// offer a fix rather than an error message to search for.
+package remedy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/hmziqrs/maziq/internal/catalog"
+	"github.com/hmziqrs/maziq/internal/resource"
+	"github.com/hmziqrs/maziq/internal/shell"
+)
+
+// Step is one part of a fix: a command to run, or something the user does
+// by hand and confirms, such as clicking through a system dialog.
+type Step struct {
+	// Say describes the step.
+	Say     string
+	Command *shell.Command
+	// Wait is the prompt to confirm once the manual step is done.
+	Wait string
+}
+
+// rule is a known failure. match finds it in the output; vars pull values
+// the steps need out of the output, each from its first group.
+type rule struct {
+	id      string
+	match   *regexp.Regexp
+	vars    map[string]*regexp.Regexp
+	title   string
+	explain string
+	steps   func(vars map[string]string) []Step
+}
+
+func cmd(c shell.Command) *shell.Command { return &c }
+
+var rules = []rule{
+	{
+		id:      "developer-tools",
+		match:   regexp.MustCompile("xcrun: error: (invalid active developer path|unable to find utility)|missing xcrun at|linker `cc` not found"),
+		title:   "Reinstall the Xcode Command Line Tools",
+		explain: "The compilers and git come from the Command Line Tools, which macOS updates often remove or leave pointing at a missing path.",
+		steps: func(map[string]string) []Step {
+			return []Step{
+				{Say: "Start the Command Line Tools installer", Command: cmd(shell.Cmd("xcode-select", "--install"))},
+				{Say: "Install the tools from the dialog macOS opened", Wait: "Press Enter once the installer has finished"},
+				{Say: "Point xcode-select at the installed tools", Command: cmd(shell.Command{Name: "xcode-select", Args: []string{"--reset"}, Sudo: true})},
+			}
+		},
+	},
+	{
+		id:      "xcode-license",
+		match:   regexp.MustCompile(`You have not agreed to the Xcode license`),
+		title:   "Accept the Xcode license",
+		explain: "Xcode's tools refuse to run until its license has been accepted, once per Xcode version.",
+		steps: func(map[string]string) []Step {
+			return []Step{{Say: "Accept the license", Command: cmd(shell.Command{Name: "xcodebuild", Args: []string{"-license", "accept"}, Sudo: true})}}
+		},
+	},
+	{
+		id:    "cask-checksum",
+		match: regexp.MustCompile(`SHA256 mismatch|Checksum for Cask '[^']+' does not match`),
+		vars: map[string]*regexp.Regexp{
+			"file": regexp.MustCompile(`(?m)^\s*File: (\S+)`),
+		},
+		title:   "Download the cask again",
+		explain: "The download does not match the checksum in the cask. Usually the vendor replaced the file and Homebrew's copy of the cask is out of date, or the download was cut short.",
+		steps: func(vars map[string]string) []Step {
+			var steps []Step
+			if f := vars["file"]; f != "" {
+				steps = append(steps, Step{Say: "Remove the bad download", Command: cmd(shell.Cmd("rm", "-f", f))})
+			}
+			return append(steps, Step{Say: "Update Homebrew's casks", Command: cmd(shell.Cmd("brew", "update"))})
+		},
+	},
+	{
+		id:    "shallow-tap",
+		match: regexp.MustCompile(`is a shallow clone`),
+		vars: map[string]*regexp.Regexp{
+			"tap": regexp.MustCompile(`(?:homebrew/)?homebrew-([\w-]+) is a shallow clone`),
+		},
+		title:   "Fetch the full history of the tap",
+		explain: "Homebrew cannot update a tap that was cloned shallowly, as some CI images and older installers do.",
+		steps: func(vars map[string]string) []Step {
+			tap := "homebrew/" + vars["tap"]
+			if vars["tap"] == "" {
+				tap = "homebrew/core"
+			}
+			return []Step{
+				{Say: "Unshallow " + tap, Command: cmd(shell.Script(`git -C "$(brew --repository ` + shell.Quote(tap) + `)" fetch --unshallow`))},
+				{Say: "Update Homebrew", Command: cmd(shell.Cmd("brew", "update"))},
+			}
+		},
+	},
+	{
+		id:    "brew-permissions",
+		match: regexp.MustCompile(`Permission denied @ \w+ - \S+|\S+ is not writable`),
+		vars: map[string]*regexp.Regexp{
+			"path": regexp.MustCompile(`Permission denied @ \w+ - (\S+)|(\S+) is not writable`),
+		},
+		title:   "Give your user back Homebrew's directories",
+		explain: "Something ran with sudo and left files in Homebrew's prefix owned by root, so Homebrew can no longer write there.",
+		steps: func(vars map[string]string) []Step {
+			return []Step{{Say: "Take ownership of " + vars["path"], Command: cmd(shell.Command{Name: "chown", Args: []string{"-R", vars["user"], vars["path"]}, Sudo: true})}}
+		},
+	},
+	{
+		id:      "app-exists",
+		match:   regexp.MustCompile(`It seems there is already an App at '[^']+'`),
+		vars:    map[string]*regexp.Regexp{"app": regexp.MustCompile(`already an App at '([^']+)'`)},
+		title:   "Let Homebrew adopt the app that is already installed",
+		explain: "The app was installed without Homebrew, by hand or from its own installer, and Homebrew will not overwrite it.",
+		steps: func(vars map[string]string) []Step {
+			if vars["cask"] == "" {
+				return []Step{{Say: "Move " + vars["app"] + " to the Trash", Wait: "Press Enter once it is gone"}}
+			}
+			return []Step{{Say: "Adopt " + vars["app"], Command: cmd(shell.Cmd("brew", "install", "--cask", "--adopt", vars["cask"]))}}
+		},
+	},
+	{
+		id:      "brew-locked",
+		match:   regexp.MustCompile(`Another active Homebrew (update )?process is already in progress|has already locked`),
+		title:   "Wait for the other Homebrew command",
+		explain: "Another brew command is running, perhaps in another terminal or an automatic update, and holds Homebrew's lock.",
+		steps: func(map[string]string) []Step {
+			return []Step{{Say: "Let the other brew finish (pgrep -fl brew shows it)", Wait: "Press Enter once it has finished"}}
+		},
+	},
+	{
+		id:      "network",
+		match:   regexp.MustCompile(`Failed to download resource|curl: \((6|7|28|35|56)\)|Could not resolve host`),
+		title:   "Check the network connection",
+		explain: "The download failed before it finished: the network is down, a proxy or VPN is in the way, or the server is unreachable.",
+		steps: func(map[string]string) []Step {
+			return []Step{
+				{Say: "Check the connection, proxy and VPN", Wait: "Press Enter once you are back online"},
+				{Say: "Make sure Homebrew is reachable", Command: cmd(shell.Cmd("curl", "-sSfI", "https://formulae.brew.sh"))},
+			}
+		},
+	},
+}
+
+// Fix is a known failure that was recognised in a failed apply, with the
+// values the fix needs. It is plain data so it can be kept with the report.
+type Fix struct {
+	Rule string            `json:"rule"`
+	Vars map[string]string `json:"vars,omitempty"`
+}
+
+func (f Fix) rule() rule {
+	for _, r := range rules {
+		if r.id == f.Rule {
+			return r
+		}
+	}
+	return rule{id: f.Rule, title: "Unknown fix " + f.Rule, steps: func(map[string]string) []Step { return nil }}
+}
+
+// Title says what the fix does.
+func (f Fix) Title() string { return f.rule().title }
+
+// Explain says why the failure happens.
+func (f Fix) Explain() string { return f.rule().explain }
+
+// Steps lists what the fix does, in order.
+func (f Fix) Steps() []Step { return f.rule().steps(f.Vars) }
+
+// Match recognises the failure err of r, or returns nil. The output of the
+// commands that failed is searched along with the error itself.
+func Match(env *resource.Env, r resource.Resource, err error) *Fix {
+	text := output(err)
+	for _, rl := range rules {
+		if !rl.match.MatchString(text) {
+			continue
+		}
+		f := &Fix{Rule: rl.id, Vars: map[string]string{"user": env.Facts["user"]}}
+		for name, re := range rl.vars {
+			if m := re.FindStringSubmatch(text); m != nil {
+				// The value is in whichever alternative matched.
+				f.Vars[name] = strings.Join(m[1:], "")
+			}
+		}
+		if s, ok := r.(interface{ Catalog() catalog.Software }); ok {
+			for _, src := range s.Catalog().Sources {
+				if src.Backend == catalog.BackendCask {
+					f.Vars["cask"] = src.Package
+				}
+			}
+		}
+		return f
+	}
+	return nil
+}
+
+// output collects err's message and the output of every command failure
+// it wraps.
+func output(err error) string {
+	var b strings.Builder
+	b.WriteString(err.Error())
+	var walk func(error)
+	walk = func(err error) {
+		if e, ok := err.(*shell.ExitError); ok {
+			fmt.Fprintf(&b, "\n%s\n%s", e.Result.Stdout, e.Result.Stderr)
+		}
+		switch u := err.(type) {
+		case interface{ Unwrap() error }:
+			if err := u.Unwrap(); err != nil {
+				walk(err)
+			}
+		case interface{ Unwrap() []error }:
+			for _, err := range u.Unwrap() {
+				walk(err)
+			}
+		}
+	}
+	walk(err)
+	return b.String()
+}
+
+// Run carries out the fix, stopping at the first step that fails. Manual
+// steps need env.Wait.
+func (f Fix) Run(ctx context.Context, env *resource.Env) error {
+	for _, s := range f.Steps() {
+		env.Log("%s", s.Say)
+		switch {
+		case s.Command != nil:
+			if _, err := env.Run(ctx, *s.Command); err != nil {
+				return fmt.Errorf("%s: %w", s.Say, err)
+			}
+		case s.Wait != "":
+			if env.Wait == nil {
+				return errors.New(s.Say + " needs someone at the terminal")
+			}
+			if err := env.Wait(ctx, s.Wait); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,28 @@
+package resource
+
+import "context"
+
+// FileChange is what applying a resource does to one file. Structured
+// files such as property lists are rendered as one key path per line
+// (plist.Flatten), so the diff lines up along keys.
+type FileChange struct {
+	Path string
+	// Current is nil when the file does not exist yet.
+	Current []byte
+	Desired []byte
+}
+
+// Differ is implemented by resources that write a file, so the change can
+// be reviewed as a diff before it is applied.
+type Differ interface {
+	Diff(ctx context.Context, env *Env) (FileChange, error)
+}
+
+// Merger is implemented by Differs that can write content other than what
+// the template renders. Merge makes Apply write content, in the form Diff
+// returned, instead; a diff with some hunks rejected keeps the local lines
+// and shows as drift again on the next plan.
+type Merger interface {
+	Differ
+	Merge(content []byte) error
+}
@@ -0,0 +1,32 @@
+package resource
+
+import (
+	"context"
+	"os"
+
+	"github.com/hmziqrs/maziq/internal/shell"
+)
+
+// Step is a change written out for `maziq export`, to be made by a shell
+// script or an Ansible task instead of MazIQ. Check is a shell test that
+// succeeds when the change is already made; otherwise Files are written and
+// Commands run, in that order.
+type Step struct {
+	Check    string
+	Files    []FileContent
+	Commands []shell.Command
+}
+
+// FileContent is a file a Step writes whole.
+type FileContent struct {
+	Path    string
+	Content []byte
+	Mode    os.FileMode
+}
+
+// Exporter is implemented by resources that can be applied without MazIQ.
+// Export describes the change whatever the machine's current state; the
+// Step's Check keeps the script idempotent.
+type Exporter interface {
+	Export(ctx context.Context, env *Env) (Step, error)
+}
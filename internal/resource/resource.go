@@ -0,0 +1,297 @@
+// Package resource defines the unit of provisioning. Every template section
+// (software, licenses, ...) is turned into resources by a registered Builder;
+// the engine plans and applies them without knowing their kind.
+package resource
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/hmziqrs/maziq/internal/facts"
+	"github.com/hmziqrs/maziq/internal/secrets"
+	"github.com/hmziqrs/maziq/internal/shell"
+	"github.com/hmziqrs/maziq/internal/templates"
+)
+
+// Resource is a piece of desired machine state.
+type Resource interface {
+	// ID is unique across the plan, formatted as "kind:name".
+	ID() string
+	// Describe summarises the desired state for plan output. It must never
+	// include secret values.
+	Describe() string
+	// Check compares the machine against the desired state without changing
+	// anything.
+	Check(ctx context.Context, env *Env) (State, error)
+	// Apply converges the machine to the desired state.
+	Apply(ctx context.Context, env *Env) error
+}
+
+// State is the result of Check.
+type State struct {
+	Converged bool
+	// Current and Desired are short human descriptions used in diffs.
+	Current string
+	Desired string
+	// Blocked explains why Apply cannot succeed on this machine, e.g. an app
+	// that is not sold in the signed-in storefront. The engine skips blocked
+	// resources instead of failing mid-apply.
+	Blocked string
+	// Warning is shown in the plan but does not stop apply, e.g. an app
+	// that Gatekeeper would refuse to open.
+	Warning string
+	// Version is the installed version, for resources that know it.
+	Version string
+}
+
+// Requirer is implemented by resources that must run after others.
+type Requirer interface {
+	Requires() []string
+}
+
+// Assertion is an E2E check contributed by a resource.
+type Assertion struct {
+	Name   string
+	Run    string
+	Expect string
+	// Secrets maps environment variables of Run to the names of secrets,
+	// which are fetched when it runs so their values stay off its command
+	// line.
+	Secrets map[string]string
+}
+
+// Asserter is implemented by resources that contribute E2E assertions.
+type Asserter interface {
+	Assertions() []Assertion
+}
+
+// Restart is what it takes for an applied change to show up.
+type Restart struct {
+	// Processes are killed so they relaunch and reread their preferences,
+	// e.g. "Dock".
+	Processes []string `json:"processes,omitempty"`
+	Logout    bool     `json:"logout,omitempty"`
+	Reboot    bool     `json:"reboot,omitempty"`
+}
+
+// Empty reports whether nothing needs restarting.
+func (r Restart) Empty() bool {
+	return len(r.Processes) == 0 && !r.Logout && !r.Reboot
+}
+
+// Merge combines two restarts, listing each process once.
+func (r Restart) Merge(o Restart) Restart {
+	out := Restart{Logout: r.Logout || o.Logout, Reboot: r.Reboot || o.Reboot}
+	for _, p := range append(append([]string{}, r.Processes...), o.Processes...) {
+		if !slices.Contains(out.Processes, p) {
+			out.Processes = append(out.Processes, p)
+		}
+	}
+	sort.Strings(out.Processes)
+	return out
+}
+
+// Restarter is implemented by resources whose changes only take effect after
+// a process relaunch, a logout or a reboot. The engine asks once Apply has
+// succeeded and leaves the restarts to the caller, so each happens once at
+// the end.
+type Restarter interface {
+	Restarts() Restart
+}
+
+// Scopes, for Scoper.
+const (
+	ScopeUser   = "user"
+	ScopeSystem = "system"
+)
+
+// Scoper is implemented by resources whose change is shared by every user
+// of the machine: applications in /Applications, Homebrew, system settings
+// and files outside the home directory. Everything else is user-scoped, so
+// on a shared Mac a system baseline can be applied once and each user's own
+// preferences on top.
+type Scoper interface {
+	Scope() string
+}
+
+// ScopeOf returns r's scope, ScopeUser unless r says otherwise.
+func ScopeOf(r Resource) string {
+	if s, ok := r.(Scoper); ok {
+		return s.Scope()
+	}
+	return ScopeUser
+}
+
+// Component is a piece of installed software, as a bill of materials lists
+// it.
+type Component struct {
+	// ID is the resource that manages the component.
+	ID      string
+	Name    string
+	Version string
+	// PURL is its package URL, such as pkg:brew/git@2.45.0.
+	PURL string
+	// Supplier is who it came from: Homebrew, the App Store, a GitHub
+	// repository or a vendor.
+	Supplier string
+	// URL is where it was downloaded from, when MazIQ knows.
+	URL string
+	// SHA256 is the digest of the installed executable, for software that
+	// is a single file.
+	SHA256 string
+	// DownloadSHA256 is the digest the template pins the download to.
+	DownloadSHA256 string
+	// Paths are where it is installed, for `maziq du`.
+	Paths []string
+}
+
+// Componenter is implemented by resources that install software, for
+// `maziq sbom`. Component reports false when the software is not installed.
+type Componenter interface {
+	Component(ctx context.Context, env *Env) (Component, bool, error)
+}
+
+// Fetcher is implemented by resources whose Apply downloads, for `maziq
+// bundle create`: Fetch makes the same downloads without installing
+// anything, while the bundle records them for an offline apply. Resources
+// that need the network at apply time in a way a bundle cannot carry
+// return ErrNeedsNetwork.
+type Fetcher interface {
+	Fetch(ctx context.Context, env *Env) error
+}
+
+// ErrNeedsNetwork is returned by Fetch for changes only an online apply can
+// make.
+var ErrNeedsNetwork = errors.New("needs the network at apply time")
+
+// Verifier is implemented by resources that can tell whether they are
+// converged from local files and preferences alone, without package
+// managers or the network. `maziq verify` only runs these checks.
+type Verifier interface {
+	Verify(ctx context.Context, env *Env) (State, error)
+}
+
+// ErrUnverifiable is returned by Verify when the cheap check cannot decide.
+var ErrUnverifiable = errors.New("cannot be verified locally")
+
+// Fingerprinter is implemented by resources whose Describe leaves out part
+// of the desired state. Incremental applies treat a resource as unchanged
+// while its fingerprint, by default its ID and description, stays the same.
+type Fingerprinter interface {
+	Fingerprint() string
+}
+
+// Env carries everything a resource needs to check and apply itself.
+type Env struct {
+	Runner   shell.Runner
+	Secrets  secrets.Provider
+	Facts    facts.Facts
+	Template *templates.Template
+	// Logf reports progress. It may be nil.
+	Logf func(format string, args ...any)
+	// Wait pauses until the user confirms prompt or ctx ends. It is nil when
+	// nobody is at the terminal, and resources that need a person are then
+	// reported as blocked instead of applied.
+	Wait func(ctx context.Context, prompt string) error
+	// Only and Skip limit plans to some subsystems: resource kinds such as
+	// dotfile, or the backend software installs with, such as brew. Empty
+	// Only means every subsystem.
+	Only, Skip []string
+}
+
+// Log reports progress through Logf when set.
+func (e *Env) Log(format string, args ...any) {
+	if e.Logf != nil {
+		e.Logf(format, args...)
+	}
+}
+
+// Lookup resolves a ${name} reference against facts and template variables.
+func (e *Env) Lookup(name string) string {
+	if v, ok := e.Facts[name]; ok {
+		return v
+	}
+	if e.Template != nil {
+		return e.Template.Vars[name]
+	}
+	return ""
+}
+
+// Expand substitutes ${name} references in s.
+func (e *Env) Expand(s string) string {
+	return templates.Expand(s, e.Lookup)
+}
+
+// Path expands variables and a leading ~/ in p.
+func (e *Env) Path(p string) string {
+	p = e.Expand(p)
+	if p == "~" || strings.HasPrefix(p, "~/") {
+		home := e.Facts["home"]
+		if home == "" {
+			home, _ = os.UserHomeDir()
+		}
+		p = filepath.Join(home, p[1:])
+	}
+	return p
+}
+
+// Run executes c with the environment's runner.
+func (e *Env) Run(ctx context.Context, c shell.Command) (shell.Result, error) {
+	return e.Runner.Run(ctx, c)
+}
+
+// Holds reports whether a `when` condition is true on this machine.
+func (e *Env) Holds(when string) (bool, error) {
+	c, err := templates.ParseCond(when)
+	if err != nil || c == nil {
+		return err == nil, err
+	}
+	return c.Eval(e.Lookup), nil
+}
+
+// Builder turns the sections of a template it owns into resources.
+type Builder func(env *Env) ([]Resource, error)
+
+var builders []Builder
+
+// Register adds a builder. Modules call it from init.
+func Register(b Builder) {
+	builders = append(builders, b)
+}
+
+// Build runs every registered builder against env.Template.
+func Build(env *Env) ([]Resource, error) {
+	var out []Resource
+	seen := map[string]bool{}
+	for _, b := range builders {
+		rs, err := b(env)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range rs {
+			if seen[r.ID()] {
+				return nil, fmt.Errorf("duplicate resource %s", r.ID())
+			}
+			seen[r.ID()] = true
+			out = append(out, r)
+		}
+	}
+	return out, nil
+}
+
+// ID joins a kind and name into a resource ID.
+func ID(kind, name string) string {
+	return kind + ":" + name
+}
+
+// Kind returns the kind part of a resource ID.
+func Kind(id string) string {
+	kind, _, _ := strings.Cut(id, ":")
+	return kind
+}
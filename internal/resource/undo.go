@@ -0,0 +1,95 @@
+package resource
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/hmziqrs/maziq/internal/shell"
+)
+
+// Undo is how to take back one applied change: commands to run, in order,
+// then files to put back as they were. It is plain data so it can be kept
+// until the change is reset, possibly by another process.
+type Undo struct {
+	ID       string          `json:"id"`
+	Commands []shell.Command `json:"commands,omitempty"`
+	Files    []SavedFile     `json:"files,omitempty"`
+}
+
+// SavedFile is a file as it was before a change. Missing files are removed
+// again, along with the directories created for them once they are empty.
+type SavedFile struct {
+	Path    string      `json:"path"`
+	Missing bool        `json:"missing,omitempty"`
+	Content []byte      `json:"content,omitempty"`
+	Mode    os.FileMode `json:"mode,omitempty"`
+	// NewDirs are the missing directories above Path, deepest first.
+	NewDirs []string `json:"new_dirs,omitempty"`
+}
+
+// SaveFile records the file at path as it is now.
+func SaveFile(path string) (SavedFile, error) {
+	info, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		f := SavedFile{Path: path, Missing: true}
+		for dir := filepath.Dir(path); dir != filepath.Dir(dir); dir = filepath.Dir(dir) {
+			if _, err := os.Stat(dir); !errors.Is(err, fs.ErrNotExist) {
+				break
+			}
+			f.NewDirs = append(f.NewDirs, dir)
+		}
+		return f, nil
+	}
+	if err != nil {
+		return SavedFile{}, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return SavedFile{}, err
+	}
+	return SavedFile{Path: path, Content: data, Mode: info.Mode().Perm()}, nil
+}
+
+// Restore puts the file back as it was saved.
+func (f SavedFile) Restore() error {
+	if f.Missing {
+		if err := os.Remove(f.Path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		for _, dir := range f.NewDirs {
+			// Directories something else has since put files in stay.
+			_ = os.Remove(dir)
+		}
+		return nil
+	}
+	if err := os.WriteFile(f.Path, f.Content, f.Mode); err != nil {
+		return err
+	}
+	return os.Chmod(f.Path, f.Mode)
+}
+
+// Run takes the change back. It stops at the first step that fails.
+func (u Undo) Run(ctx context.Context, env *Env) error {
+	for _, c := range u.Commands {
+		if _, err := env.Run(ctx, c); err != nil {
+			return err
+		}
+	}
+	for _, f := range u.Files {
+		if err := f.Restore(); err != nil {
+			return fmt.Errorf("restore %s: %w", f.Path, err)
+		}
+	}
+	return nil
+}
+
+// Reverter is implemented by resources whose changes can be taken back, so
+// a demo template can leave the machine as it found it. Undo is called
+// just before Apply, while the machine is still as Check saw it.
+type Reverter interface {
+	Undo(ctx context.Context, env *Env) (Undo, error)
+}
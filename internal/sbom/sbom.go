@@ -0,0 +1,228 @@
+// Package sbom writes software bills of materials for what MazIQ manages,
+// in CycloneDX 1.5 or SPDX 2.3 JSON, for supply-chain tooling.
+package sbom
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/hmziqrs/maziq/internal/resource"
+)
+
+// Formats Write can produce.
+const (
+	FormatCycloneDX = "cyclonedx"
+	FormatSPDX      = "spdx"
+)
+
+// Document is a bill of materials for a template on this machine.
+type Document struct {
+	Template string
+	Host     string
+	Created  time.Time
+	// Tool is the MazIQ version that wrote it.
+	Tool       string
+	Components []resource.Component
+}
+
+// Write renders d in format.
+func (d Document) Write(w io.Writer, format string) error {
+	var v any
+	switch format {
+	case FormatCycloneDX:
+		v = d.cycloneDX()
+	case FormatSPDX:
+		v = d.spdx()
+	default:
+		return fmt.Errorf("unknown format %q (want %s or %s)", format, FormatCycloneDX, FormatSPDX)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// PURL returns the package URL of name at version. name may have a
+// namespace, as in owner/repo.
+func PURL(typ, name, version string, qualifiers url.Values) string {
+	segs := strings.Split(name, "/")
+	for i, s := range segs {
+		segs[i] = url.PathEscape(s)
+	}
+	p := "pkg:" + typ + "/" + strings.Join(segs, "/")
+	if version != "" {
+		p += "@" + url.PathEscape(version)
+	}
+	if len(qualifiers) > 0 {
+		p += "?" + qualifiers.Encode()
+	}
+	return p
+}
+
+// newUUID returns a random version 4 UUID.
+func newUUID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+var versionRe = regexp.MustCompile(`\d+(\.\d+)+[0-9A-Za-z.+-]*`)
+
+// Version picks the version number out of a tool's --version line, such as
+// 2.39.3 from "git version 2.39.3 (Apple Git-145)". A line without one is
+// returned as is.
+func Version(line string) string {
+	if v := versionRe.FindString(line); v != "" {
+		return v
+	}
+	return strings.TrimSpace(line)
+}
+
+func (d Document) cycloneDX() any {
+	type hash struct {
+		Alg     string `json:"alg"`
+		Content string `json:"content"`
+	}
+	type ref struct {
+		Type   string `json:"type"`
+		URL    string `json:"url"`
+		Hashes []hash `json:"hashes,omitempty"`
+	}
+	type property struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+	}
+	type component struct {
+		Type       string     `json:"type"`
+		BOMRef     string     `json:"bom-ref"`
+		Name       string     `json:"name"`
+		Version    string     `json:"version,omitempty"`
+		Publisher  string     `json:"publisher,omitempty"`
+		PURL       string     `json:"purl,omitempty"`
+		Hashes     []hash     `json:"hashes,omitempty"`
+		References []ref      `json:"externalReferences,omitempty"`
+		Properties []property `json:"properties"`
+	}
+	components := []component{}
+	for _, c := range d.Components {
+		cc := component{
+			Type:       "application",
+			BOMRef:     c.ID,
+			Name:       c.Name,
+			Version:    c.Version,
+			Publisher:  c.Supplier,
+			PURL:       c.PURL,
+			Properties: []property{{Name: "maziq:resource", Value: c.ID}},
+		}
+		if c.SHA256 != "" {
+			cc.Hashes = []hash{{Alg: "SHA-256", Content: c.SHA256}}
+		}
+		if c.URL != "" {
+			r := ref{Type: "distribution", URL: c.URL}
+			if c.DownloadSHA256 != "" {
+				r.Hashes = []hash{{Alg: "SHA-256", Content: c.DownloadSHA256}}
+			}
+			cc.References = []ref{r}
+		}
+		components = append(components, cc)
+	}
+	return map[string]any{
+		"bomFormat":    "CycloneDX",
+		"specVersion":  "1.5",
+		"serialNumber": "urn:uuid:" + newUUID(),
+		"version":      1,
+		"metadata": map[string]any{
+			"timestamp": d.Created.UTC().Format(time.RFC3339),
+			"tools": map[string]any{
+				"components": []map[string]string{{"type": "application", "name": "maziq", "version": d.Tool}},
+			},
+			"component": map[string]string{"type": "device", "bom-ref": "host", "name": d.Host, "description": "provisioned with the " + d.Template + " template"},
+		},
+		"components": components,
+	}
+}
+
+// spdxID turns a resource ID into an SPDX element ID, which only allows
+// letters, digits, dots and dashes.
+func spdxID(id string) string {
+	return "SPDXRef-" + strings.Map(func(r rune) rune {
+		if r == '.' || r == '-' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return '-'
+	}, id)
+}
+
+func (d Document) spdx() any {
+	type checksum struct {
+		Algorithm string `json:"algorithm"`
+		Value     string `json:"checksumValue"`
+	}
+	type extRef struct {
+		Category string `json:"referenceCategory"`
+		Type     string `json:"referenceType"`
+		Locator  string `json:"referenceLocator"`
+	}
+	type pkg struct {
+		ID               string     `json:"SPDXID"`
+		Name             string     `json:"name"`
+		Version          string     `json:"versionInfo,omitempty"`
+		Supplier         string     `json:"supplier,omitempty"`
+		DownloadLocation string     `json:"downloadLocation"`
+		FilesAnalyzed    bool       `json:"filesAnalyzed"`
+		Checksums        []checksum `json:"checksums,omitempty"`
+		ExternalRefs     []extRef   `json:"externalRefs,omitempty"`
+		Comment          string     `json:"comment"`
+	}
+	type relationship struct {
+		Element string `json:"spdxElementId"`
+		Type    string `json:"relationshipType"`
+		Related string `json:"relatedSpdxElement"`
+	}
+	packages := []pkg{}
+	rels := []relationship{}
+	for _, c := range d.Components {
+		p := pkg{
+			ID:               spdxID(c.ID),
+			Name:             c.Name,
+			Version:          c.Version,
+			DownloadLocation: "NOASSERTION",
+			Comment:          "managed by maziq as " + c.ID,
+		}
+		if c.Supplier != "" {
+			p.Supplier = "Organization: " + c.Supplier
+		}
+		if c.URL != "" {
+			p.DownloadLocation = c.URL
+		}
+		if c.SHA256 != "" {
+			p.Checksums = []checksum{{Algorithm: "SHA256", Value: c.SHA256}}
+		}
+		if c.PURL != "" {
+			p.ExternalRefs = []extRef{{Category: "PACKAGE-MANAGER", Type: "purl", Locator: c.PURL}}
+		}
+		packages = append(packages, p)
+		rels = append(rels, relationship{Element: "SPDXRef-DOCUMENT", Type: "DESCRIBES", Related: p.ID})
+	}
+	name := d.Template + "-" + d.Host
+	return map[string]any{
+		"spdxVersion":       "SPDX-2.3",
+		"dataLicense":       "CC0-1.0",
+		"SPDXID":            "SPDXRef-DOCUMENT",
+		"name":              name,
+		"documentNamespace": "https://maziq.invalid/spdx/" + url.PathEscape(name) + "-" + newUUID(),
+		"creationInfo": map[string]any{
+			"created":  d.Created.UTC().Format(time.RFC3339),
+			"creators": []string{"Tool: maziq-" + d.Tool},
+		},
+		"packages":      packages,
+		"relationships": rels,
+	}
+}
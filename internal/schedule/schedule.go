@@ -0,0 +1,227 @@
+// Package schedule parses cron expressions, works out when they next fire,
+// and renders them as launchd calendar intervals.
+package schedule
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// aliases are the named schedules, with or without a leading @.
+var aliases = map[string]string{
+	"hourly":   "0 * * * *",
+	"daily":    "0 0 * * *",
+	"midnight": "0 0 * * *",
+	"nightly":  "0 2 * * *",
+	"weekly":   "0 0 * * 0",
+	"monthly":  "0 0 1 * *",
+	"yearly":   "0 0 1 1 *",
+	"annually": "0 0 1 1 *",
+}
+
+// field describes one of the five cron fields.
+type field struct {
+	name     string
+	key      string // launchd StartCalendarInterval key
+	min, max int
+	names    []string // names accepted for min, min+1, ...
+}
+
+var fields = [5]field{
+	{name: "minute", key: "Minute", max: 59},
+	{name: "hour", key: "Hour", max: 23},
+	{name: "day of month", key: "Day", min: 1, max: 31},
+	{name: "month", key: "Month", min: 1, max: 12, names: []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}},
+	{name: "day of week", key: "Weekday", max: 7, names: []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}},
+}
+
+const (
+	minute = iota
+	hour
+	dom
+	month
+	dow
+)
+
+// Schedule is a parsed cron expression. A nil field is a wildcard.
+type Schedule struct {
+	expr   string
+	fields [5][]int
+}
+
+// Parse reads a five-field cron expression ("30 2 * * 1-5") or one of the
+// names hourly, daily, nightly (02:00), weekly, monthly and yearly.
+func Parse(expr string) (*Schedule, error) {
+	s := &Schedule{expr: expr}
+	text := strings.TrimSpace(expr)
+	if a, ok := aliases[strings.TrimPrefix(strings.ToLower(text), "@")]; ok {
+		text = a
+	}
+	parts := strings.Fields(text)
+	if len(parts) != 5 {
+		return nil, fmt.Errorf("schedule %q: want five fields (minute hour day month weekday) or a name such as daily", expr)
+	}
+	for i, part := range parts {
+		values, err := parseField(fields[i], part)
+		if err != nil {
+			return nil, fmt.Errorf("schedule %q: %s: %w", expr, fields[i].name, err)
+		}
+		s.fields[i] = values
+	}
+	if s.fields[dow] != nil {
+		// 7 is another name for Sunday.
+		for i, v := range s.fields[dow] {
+			if v == 7 {
+				s.fields[dow][i] = 0
+			}
+		}
+		slices.Sort(s.fields[dow])
+		s.fields[dow] = slices.Compact(s.fields[dow])
+	}
+	return s, nil
+}
+
+// parseField returns the sorted values a field matches, or nil for *.
+func parseField(f field, text string) ([]int, error) {
+	if text == "*" {
+		return nil, nil
+	}
+	var out []int
+	for _, item := range strings.Split(text, ",") {
+		rng, stepText, hasStep := strings.Cut(item, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepText)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("bad step %q", stepText)
+			}
+			step = n
+		}
+		// A wildcard or open-ended step on weekdays stops at Saturday, so 7
+		// only matches when written.
+		top := f.max
+		if f.key == "Weekday" {
+			top = 6
+		}
+		lo, hi := f.min, top
+		if rng != "*" {
+			a, b, isRange := strings.Cut(rng, "-")
+			var err error
+			if lo, err = f.value(a); err != nil {
+				return nil, err
+			}
+			hi = lo
+			if isRange {
+				if hi, err = f.value(b); err != nil {
+					return nil, err
+				}
+			} else if hasStep {
+				hi = top
+			}
+			if hi < lo {
+				return nil, fmt.Errorf("range %q runs backwards", rng)
+			}
+		}
+		for v := lo; v <= hi; v += step {
+			out = append(out, v)
+		}
+	}
+	slices.Sort(out)
+	return slices.Compact(out), nil
+}
+
+func (f field) value(text string) (int, error) {
+	if i := slices.Index(f.names, strings.ToLower(text)); i >= 0 {
+		return f.min + i, nil
+	}
+	n, err := strconv.Atoi(text)
+	if err != nil || n < f.min || n > f.max {
+		return 0, fmt.Errorf("%q is not between %d and %d", text, f.min, f.max)
+	}
+	return n, nil
+}
+
+// String returns the expression as written.
+func (s *Schedule) String() string { return s.expr }
+
+// matches reports whether v is in a field's values.
+func (s *Schedule) matches(i, v int) bool {
+	return s.fields[i] == nil || slices.Contains(s.fields[i], v)
+}
+
+// matchesDay applies cron's rule for the two day fields: when both are
+// restricted, a day matching either one counts.
+func (s *Schedule) matchesDay(t time.Time) bool {
+	d, w := s.matches(dom, t.Day()), s.matches(dow, int(t.Weekday()))
+	if s.fields[dom] != nil && s.fields[dow] != nil {
+		return d || w
+	}
+	return d && w
+}
+
+// Next returns the first time after t the schedule fires, in t's location,
+// or the zero time when it never does (e.g. on February 30).
+func (s *Schedule) Next(t time.Time) time.Time {
+	start := t.Truncate(time.Minute).Add(time.Minute)
+	day := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, start.Location())
+	// Every combination of month and day recurs within eight years (leap
+	// days included).
+	for i := 0; i < 8*366; i++ {
+		d := day.AddDate(0, 0, i)
+		if !s.matches(month, int(d.Month())) || !s.matchesDay(d) {
+			continue
+		}
+		for h := 0; h < 24; h++ {
+			if !s.matches(hour, h) {
+				continue
+			}
+			for m := 0; m < 60; m++ {
+				if !s.matches(minute, m) {
+					continue
+				}
+				if next := time.Date(d.Year(), d.Month(), d.Day(), h, m, 0, 0, d.Location()); !next.Before(start) {
+					return next
+				}
+			}
+		}
+	}
+	return time.Time{}
+}
+
+// Calendar renders the schedule as launchd StartCalendarInterval dicts. A
+// field launchd leaves out is a wildcard, as in cron; since launchd
+// requires every key of a dict to match, a schedule restricting both day
+// fields becomes one set of dicts for each.
+func (s *Schedule) Calendar() []map[string]any {
+	if s.fields[dom] != nil && s.fields[dow] != nil {
+		return append(s.product(dow), s.product(dom)...)
+	}
+	return s.product(-1)
+}
+
+// product returns the dicts for every combination of restricted field
+// values, leaving out the field skip.
+func (s *Schedule) product(skip int) []map[string]any {
+	out := []map[string]any{{}}
+	for i, values := range s.fields {
+		if values == nil || i == skip {
+			continue
+		}
+		var next []map[string]any
+		for _, d := range out {
+			for _, v := range values {
+				c := make(map[string]any, len(d)+1)
+				for k, x := range d {
+					c[k] = x
+				}
+				c[fields[i].key] = int64(v)
+				next = append(next, c)
+			}
+		}
+		out = next
+	}
+	return out
+}
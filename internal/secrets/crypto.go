@@ -0,0 +1,110 @@
+package secrets
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// KeyName is the secret holding the base64 AES-256 key used for template
+// encryption.
+const KeyName = "manifest-key"
+
+const (
+	envelopePrefix = "ENC[AES256_GCM,"
+	envelopeSuffix = "]"
+)
+
+// IsEncrypted reports whether v is an encrypted envelope.
+func IsEncrypted(v string) bool {
+	return strings.HasPrefix(v, envelopePrefix) && strings.HasSuffix(v, envelopeSuffix)
+}
+
+// GenerateKey returns a new random key, base64 encoded.
+func GenerateKey() (string, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+// LoadKey fetches and decodes the template key from p.
+func LoadKey(ctx context.Context, p Provider) ([]byte, error) {
+	encoded, err := p.Get(ctx, KeyName)
+	if err != nil {
+		return nil, fmt.Errorf("template key: %w (run `maziq secrets keygen`)", err)
+	}
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil || len(key) != 32 {
+		return nil, errors.New("template key must be 32 bytes, base64 encoded")
+	}
+	return key, nil
+}
+
+// Encrypt seals plaintext in a sops-style envelope:
+//
+//	ENC[AES256_GCM,data:…,iv:…,tag:…,type:str]
+//
+// path (e.g. "vars.vpn_secret") is authenticated as additional data, so an
+// envelope copied to another key fails to decrypt.
+func Encrypt(key []byte, path, plaintext string) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	iv := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(iv); err != nil {
+		return "", err
+	}
+	sealed := gcm.Seal(nil, iv, []byte(plaintext), []byte(path))
+	data, tag := sealed[:len(sealed)-gcm.Overhead()], sealed[len(sealed)-gcm.Overhead():]
+	b64 := base64.StdEncoding.EncodeToString
+	return fmt.Sprintf("%sdata:%s,iv:%s,tag:%s,type:str%s", envelopePrefix, b64(data), b64(iv), b64(tag), envelopeSuffix), nil
+}
+
+// Decrypt opens an envelope produced by Encrypt for the same path.
+func Decrypt(key []byte, path, envelope string) (string, error) {
+	if !IsEncrypted(envelope) {
+		return "", errors.New("not an encrypted value")
+	}
+	fields := map[string]string{}
+	body := strings.TrimSuffix(strings.TrimPrefix(envelope, envelopePrefix), envelopeSuffix)
+	for _, part := range strings.Split(body, ",") {
+		k, v, _ := strings.Cut(part, ":")
+		fields[k] = v
+	}
+	var raw [3][]byte
+	for i, name := range []string{"data", "iv", "tag"} {
+		b, err := base64.StdEncoding.DecodeString(fields[name])
+		if err != nil {
+			return "", fmt.Errorf("%s: malformed %s", path, name)
+		}
+		raw[i] = b
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	if len(raw[1]) != gcm.NonceSize() {
+		return "", fmt.Errorf("%s: malformed iv", path)
+	}
+	plain, err := gcm.Open(nil, raw[1], append(raw[0], raw[2]...), []byte(path))
+	if err != nil {
+		return "", fmt.Errorf("%s: decryption failed (wrong key or tampered value)", path)
+	}
+	return string(plain), nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,126 @@
+package secrets
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func testKey(t *testing.T) []byte {
+	t.Helper()
+	encoded, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func TestEncryptRoundTrip(t *testing.T) {
+	key := testKey(t)
+	for _, plain := range []string{
+		"",
+		"hunter2",
+		"data:x,iv:y,tag:z]",
+		"line one\nline two",
+		"ünïcødé 🔑",
+		strings.Repeat("a", 4096),
+	} {
+		env, err := Encrypt(key, "vars.secret", plain)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !IsEncrypted(env) {
+			t.Errorf("Encrypt(%q) = %q, not an envelope", plain, env)
+		}
+		if strings.Contains(env, plain) && plain != "" {
+			t.Errorf("envelope %q contains the plaintext", env)
+		}
+		got, err := Decrypt(key, "vars.secret", env)
+		if err != nil {
+			t.Fatalf("Decrypt(%q): %v", plain, err)
+		}
+		if got != plain {
+			t.Errorf("round trip of %q = %q", plain, got)
+		}
+	}
+}
+
+func TestEncryptUsesFreshIV(t *testing.T) {
+	key := testKey(t)
+	a, _ := Encrypt(key, "vars.secret", "same")
+	b, _ := Encrypt(key, "vars.secret", "same")
+	if a == b {
+		t.Errorf("two encryptions of the same value are identical: %s", a)
+	}
+}
+
+// field replaces one field of an envelope.
+func field(env, name string, edit func(string) string) string {
+	body := strings.TrimSuffix(strings.TrimPrefix(env, envelopePrefix), envelopeSuffix)
+	parts := strings.Split(body, ",")
+	for i, p := range parts {
+		if k, v, _ := strings.Cut(p, ":"); k == name {
+			parts[i] = k + ":" + edit(v)
+		}
+	}
+	return envelopePrefix + strings.Join(parts, ",") + envelopeSuffix
+}
+
+// flip changes the first byte of base64 data.
+func flip(v string) string {
+	b, _ := base64.StdEncoding.DecodeString(v)
+	if len(b) == 0 {
+		return v
+	}
+	b[0] ^= 0xff
+	return base64.StdEncoding.EncodeToString(b)
+}
+
+func TestDecryptRejects(t *testing.T) {
+	key := testKey(t)
+	env, err := Encrypt(key, "vars.secret", "hunter2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name     string
+		key      []byte
+		path     string
+		envelope string
+		want     string
+	}{
+		{"wrong key", testKey(t), "vars.secret", env, "decryption failed"},
+		{"other path", key, "vars.other", env, "decryption failed"},
+		{"tampered data", key, "vars.secret", field(env, "data", flip), "decryption failed"},
+		{"tampered tag", key, "vars.secret", field(env, "tag", flip), "decryption failed"},
+		{"tampered iv", key, "vars.secret", field(env, "iv", flip), "decryption failed"},
+		{"truncated tag", key, "vars.secret", field(env, "tag", func(v string) string {
+			b, _ := base64.StdEncoding.DecodeString(v)
+			return base64.StdEncoding.EncodeToString(b[:len(b)/2])
+		}), "decryption failed"},
+		{"short iv", key, "vars.secret", field(env, "iv", func(string) string { return base64.StdEncoding.EncodeToString([]byte("short")) }), "malformed iv"},
+		{"missing iv", key, "vars.secret", field(env, "iv", func(string) string { return "" }), "malformed iv"},
+		{"bad base64", key, "vars.secret", field(env, "data", func(string) string { return "not base64!" }), "malformed data"},
+		{"truncated envelope", key, "vars.secret", env[:len(env)/2], "not an encrypted value"},
+		{"plaintext", key, "vars.secret", "hunter2", "not an encrypted value"},
+		{"short key", key[:10], "vars.secret", env, "invalid key size"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Decrypt(tt.key, tt.path, tt.envelope)
+			if err == nil {
+				t.Fatalf("Decrypt = %q, want an error", got)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %q, want it to mention %q", err, tt.want)
+			}
+			if strings.Contains(err.Error(), "hunter2") {
+				t.Errorf("error %q leaks the plaintext", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package secrets
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+var (
+	// tableHeader matches both [table] and [[array]] headers.
+	tableHeader = regexp.MustCompile(`^\s*\[(\[?)\s*([A-Za-z0-9_.\-]+)\s*\]((?:\])?)\s*(#.*)?$`)
+	stringPair  = regexp.MustCompile(`^(\s*)([A-Za-z0-9_\-]+)(\s*=\s*)("(?:[^"\\]|\\.)*"|'[^']*')(\s*(#.*)?)$`)
+)
+
+// TransformFile rewrites every single-line string value inside the named TOML
+// tables, and the tables nested in them, with fn, leaving comments, ordering
+// and other tables untouched. fn receives the dotted key path and the decoded
+// value; every entry of an array of tables shares the array's path. It
+// returns the new file and how many values were changed.
+func TransformFile(data []byte, sections []string, fn func(path, value string) (string, bool, error)) ([]byte, int, error) {
+	want := map[string]bool{}
+	for _, s := range sections {
+		want[s] = true
+	}
+	lines := strings.SplitAfter(string(data), "\n")
+	current, changed := "", 0
+	for i, line := range lines {
+		body := strings.TrimRight(line, "\r\n")
+		if m := tableHeader.FindStringSubmatch(body); m != nil && (m[1] == "") == (m[3] == "") {
+			current = m[2]
+			continue
+		}
+		if !wanted(want, current) {
+			continue
+		}
+		m := stringPair.FindStringSubmatch(body)
+		if m == nil {
+			continue
+		}
+		var decoded map[string]string
+		if _, err := toml.Decode("v = "+m[4], &decoded); err != nil {
+			return nil, 0, fmt.Errorf("line %d: %w", i+1, err)
+		}
+		path := current + "." + m[2]
+		out, ok, err := fn(path, decoded["v"])
+		if err != nil {
+			return nil, 0, fmt.Errorf("line %d: %w", i+1, err)
+		}
+		if !ok {
+			continue
+		}
+		lines[i] = m[1] + m[2] + m[3] + quote(out) + m[5] + line[len(body):]
+		changed++
+	}
+	return []byte(strings.Join(lines, "")), changed, nil
+}
+
+// EncryptFile encrypts every plaintext string in sections.
+func EncryptFile(data []byte, sections []string, key []byte) ([]byte, int, error) {
+	return TransformFile(data, sections, func(path, v string) (string, bool, error) {
+		if IsEncrypted(v) {
+			return "", false, nil
+		}
+		enc, err := Encrypt(key, path, v)
+		return enc, err == nil, err
+	})
+}
+
+// DecryptFile decrypts every envelope in sections.
+func DecryptFile(data []byte, sections []string, key []byte) ([]byte, int, error) {
+	return TransformFile(data, sections, func(path, v string) (string, bool, error) {
+		if !IsEncrypted(v) {
+			return "", false, nil
+		}
+		plain, err := Decrypt(key, path, v)
+		return plain, err == nil, err
+	})
+}
+
+func quote(s string) string {
+	var b bytes.Buffer
+	if err := toml.NewEncoder(&b).Encode(map[string]string{"v": s}); err != nil {
+		return fmt.Sprintf("%q", s)
+	}
+	return strings.TrimSpace(strings.TrimPrefix(b.String(), "v = "))
+}
+
+// wanted reports whether table is one of the sections or nested in one.
+func wanted(sections map[string]bool, table string) bool {
+	for {
+		if sections[table] {
+			return true
+		}
+		i := strings.LastIndexByte(table, '.')
+		if i < 0 {
+			return false
+		}
+		table = table[:i]
+	}
+}
@@ -0,0 +1,87 @@
+// Package secrets resolves secret values from the macOS Keychain or the
+// environment and encrypts sensitive template values at rest.
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Service is the Keychain service under which MazIQ stores its items.
+const Service = "maziq"
+
+// ErrNotFound is returned when no provider holds the requested secret.
+var ErrNotFound = errors.New("secret not found")
+
+// Provider looks up secrets by name.
+type Provider interface {
+	Get(ctx context.Context, name string) (string, error)
+}
+
+// Keychain reads generic passwords stored under Service with the secret name
+// as the account.
+type Keychain struct{}
+
+// Get implements Provider.
+func (Keychain) Get(ctx context.Context, name string) (string, error) {
+	out, err := exec.CommandContext(ctx, "security", "find-generic-password", "-s", Service, "-a", name, "-w").Output()
+	if err != nil {
+		return "", fmt.Errorf("keychain %s/%s: %w", Service, name, ErrNotFound)
+	}
+	return strings.TrimRight(string(out), "\n"), nil
+}
+
+// Set stores or replaces a secret in the login Keychain. The value is
+// typed at security's prompt on stdin, which asks for it twice, so it never
+// appears in the process list.
+func (Keychain) Set(ctx context.Context, name, value string) error {
+	cmd := exec.CommandContext(ctx, "security", "add-generic-password", "-U", "-s", Service, "-a", name, "-w")
+	cmd.Stdin = strings.NewReader(value + "\n" + value + "\n")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("keychain: %v: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// Env reads MAZIQ_SECRET_<NAME>, with the name upper-cased and dashes and
+// dots turned into underscores. It exists for CI runners without a Keychain.
+type Env struct{}
+
+// Get implements Provider.
+func (Env) Get(_ context.Context, name string) (string, error) {
+	if v, ok := os.LookupEnv(EnvName(name)); ok {
+		return v, nil
+	}
+	return "", fmt.Errorf("$%s: %w", EnvName(name), ErrNotFound)
+}
+
+// EnvName returns the environment variable consulted for name.
+func EnvName(name string) string {
+	return "MAZIQ_SECRET_" + strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(name))
+}
+
+// Chain tries each provider in order.
+type Chain []Provider
+
+// Get implements Provider.
+func (c Chain) Get(ctx context.Context, name string) (string, error) {
+	var errs []error
+	for _, p := range c {
+		v, err := p.Get(ctx, name)
+		if err == nil {
+			return v, nil
+		}
+		errs = append(errs, err)
+	}
+	return "", errors.Join(errs...)
+}
+
+// Default is the provider chain used by MazIQ: environment first so CI can
+// override, then the Keychain.
+func Default() Provider {
+	return Chain{Env{}, Keychain{}}
+}
@@ -0,0 +1,175 @@
+// Package selfupdate replaces the running maziq binary with the latest
+// GitHub release after verifying it.
+package selfupdate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/hmziqrs/maziq/internal/fetch"
+	"github.com/hmziqrs/maziq/internal/github"
+	"github.com/hmziqrs/maziq/internal/modules/binaries"
+	"github.com/hmziqrs/maziq/internal/shell"
+)
+
+// Repo is where maziq is released.
+const Repo = "hmziqrs/maziq"
+
+// Version is the running version, set at build time with
+// -ldflags "-X github.com/hmziqrs/maziq/internal/selfupdate.Version=v1.2.3".
+var Version = "dev"
+
+// PublicKey is the minisign key release binaries are signed with, set at
+// build time like Version. Builds without one only check the checksum.
+var PublicKey = ""
+
+// ErrDevBuild is returned for builds without a release version, which
+// cannot tell whether a release is newer.
+var ErrDevBuild = errors.New("this is a development build; it does not know its version")
+
+// Available is the latest release and how it compares to the running one.
+type Available struct {
+	Current string
+	Latest  string
+	Release github.Release
+}
+
+// Newer reports whether the latest release is newer than the running build.
+func (a Available) Newer() bool {
+	return a.Current != "dev" && Compare(a.Latest, a.Current) > 0
+}
+
+// Check looks up the latest release.
+func Check(ctx context.Context) (Available, error) {
+	rel, err := github.Latest(ctx, Repo)
+	if err != nil {
+		return Available{Current: Version}, err
+	}
+	return Available{Current: Version, Latest: rel.Tag, Release: rel}, nil
+}
+
+// Compare orders two dotted versions, ignoring a leading "v" and anything
+// after a "-" or "+". Missing parts count as zero.
+func Compare(a, b string) int {
+	pa, pb := parts(a), parts(b)
+	for i := range max(len(pa), len(pb)) {
+		var x, y int
+		if i < len(pa) {
+			x = pa[i]
+		}
+		if i < len(pb) {
+			y = pb[i]
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+func parts(v string) []int {
+	v = strings.TrimPrefix(strings.TrimSpace(v), "v")
+	if i := strings.IndexAny(v, "-+"); i >= 0 {
+		v = v[:i]
+	}
+	var out []int
+	for _, p := range strings.Split(v, ".") {
+		n, _ := strconv.Atoi(p)
+		out = append(out, n)
+	}
+	return out
+}
+
+// Executable returns the path of the running binary with symlinks resolved,
+// refusing installs another package manager owns.
+func Executable() (string, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+	if exe, err = filepath.EvalSymlinks(exe); err != nil {
+		return "", err
+	}
+	if strings.Contains(exe, "/Cellar/") {
+		return "", errors.New("maziq was installed with Homebrew; run `brew upgrade maziq` instead")
+	}
+	return exe, nil
+}
+
+// Apply downloads the release for arch, verifies it against the release's
+// checksums (and signature when the build has a PublicKey) and atomically
+// replaces the running binary. It returns the path it replaced.
+func Apply(ctx context.Context, r shell.Runner, rel github.Release, arch string) (string, error) {
+	exe, err := Executable()
+	if err != nil {
+		return "", err
+	}
+	asset, err := pick(rel, arch)
+	if err != nil {
+		return "", err
+	}
+	file, cleanup, err := fetch.TempDownload(ctx, asset.URL)
+	if err != nil {
+		return "", err
+	}
+	defer cleanup()
+
+	sum, err := rel.Checksum(ctx, asset)
+	if err != nil {
+		return "", fmt.Errorf("checksums: %w", err)
+	}
+	if sum == "" {
+		return "", fmt.Errorf("%s publishes no checksum for %s; not installing an unverified binary", rel.Tag, asset.Name)
+	}
+	in := fetch.Integrity{SHA256: sum}
+	if PublicKey != "" {
+		sig, ok := find(rel, asset.Name+".minisig")
+		if !ok {
+			return "", fmt.Errorf("%s has no signature for %s", rel.Tag, asset.Name)
+		}
+		in.Signature, in.MinisignKey = sig.URL, PublicKey
+	}
+	if err := fetch.Verify(ctx, r, file, in); err != nil {
+		return "", err
+	}
+
+	if github.IsArchive(asset.Name) {
+		err = binaries.Extract(file, "maziq", exe)
+	} else {
+		var f *os.File
+		if f, err = os.Open(file); err == nil {
+			err = binaries.WriteExecutable(f, exe)
+			f.Close()
+		}
+	}
+	if errors.Is(err, os.ErrPermission) {
+		return "", fmt.Errorf("cannot replace %s; rerun with sudo", exe)
+	}
+	return exe, err
+}
+
+// pick prefers the bare maziq-darwin-<arch> binary the release builds
+// publish and falls back to an archive.
+func pick(rel github.Release, arch string) (github.Asset, error) {
+	if a, ok := find(rel, "maziq-darwin-"+arch); ok {
+		return a, nil
+	}
+	return rel.Select(arch, "")
+}
+
+func find(rel github.Release, name string) (github.Asset, bool) {
+	for _, a := range rel.Assets {
+		if a.Name == name {
+			return a, true
+		}
+	}
+	return github.Asset{}, false
+}
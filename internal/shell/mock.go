@@ -0,0 +1,109 @@
+package shell
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/BurntSushi/toml"
+)
+
+// Mock is a Runner that runs nothing. It records every command it is given
+// and answers with the first Response whose Match the command line
+// contains, or with success and no output when none does, so planning,
+// ordering and failure paths can be exercised without touching the system.
+type Mock struct {
+	Responses []Response `toml:"response"`
+	// Log, when set, receives each command line as it is recorded.
+	Log io.Writer `toml:"-"`
+
+	mu    sync.Mutex
+	calls []Command
+	err   error
+}
+
+// Response is a canned answer. A non-zero Exit makes Run return an
+// *ExitError, as Local does.
+type Response struct {
+	Match  string `toml:"match"`
+	Stdout string `toml:"stdout"`
+	Stderr string `toml:"stderr"`
+	Exit   int    `toml:"exit"`
+}
+
+// LoadMock reads a Mock's responses from a TOML file:
+//
+//	[[response]]
+//	match = "brew install --cask docker"
+//	exit = 1
+//	stderr = "Error: Download failed"
+func LoadMock(path string) (*Mock, error) {
+	m := &Mock{}
+	meta, err := toml.DecodeFile(path, m)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if keys := meta.Undecoded(); len(keys) > 0 {
+		return nil, fmt.Errorf("%s: unknown key %q", path, keys[0].String())
+	}
+	return m, nil
+}
+
+// Run implements Runner.
+func (m *Mock) Run(ctx context.Context, c Command) (Result, error) {
+	line := c.String()
+	m.mu.Lock()
+	m.calls = append(m.calls, c)
+	if m.Log != nil {
+		fmt.Fprintf(m.Log, "mock: %s\n", line)
+	}
+	err := m.err
+	m.mu.Unlock()
+	if err != nil {
+		return Result{ExitCode: -1}, err
+	}
+	if err := ctx.Err(); err != nil {
+		return Result{ExitCode: -1}, err
+	}
+	for _, r := range m.Responses {
+		if !strings.Contains(line, r.Match) {
+			continue
+		}
+		res := Result{Stdout: r.Stdout, Stderr: r.Stderr, ExitCode: r.Exit}
+		if r.Exit != 0 {
+			return res, &ExitError{Command: c, Result: res}
+		}
+		return res, nil
+	}
+	return Result{}, nil
+}
+
+// Calls returns the commands run so far, in order.
+func (m *Mock) Calls() []Command {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Command(nil), m.calls...)
+}
+
+// FromEnv returns l, or a Mock logging to stderr when $MAZIQ_MOCK is set:
+// to 1 for one that answers everything with success, or to the path of a
+// responses file for LoadMock. A file that does not load makes every
+// command fail with the reason, rather than quietly running for real.
+func FromEnv(l Local) Runner {
+	v := os.Getenv("MAZIQ_MOCK")
+	switch v {
+	case "", "0", "false":
+		return l
+	case "1", "true":
+		return &Mock{Log: os.Stderr}
+	}
+	m, err := LoadMock(v)
+	if err != nil {
+		m = &Mock{err: fmt.Errorf("MAZIQ_MOCK: %w", err)}
+	}
+	m.Log = os.Stderr
+	return m
+}
@@ -0,0 +1,278 @@
+// Package shell runs external commands on behalf of the provisioning engine.
+// Every command MazIQ executes goes through a Runner so it can be logged,
+// mirrored to the UI and replaced in tests.
+package shell
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// GracePeriod is how long a cancelled command gets to exit after SIGINT
+// before it is killed.
+const GracePeriod = 10 * time.Second
+
+// Command describes a process to run.
+type Command struct {
+	Name  string
+	Args  []string
+	Dir   string
+	Env   []string // extra KEY=VALUE pairs appended to the environment
+	Stdin string
+	// Sudo runs the command through `sudo`.
+	Sudo bool
+}
+
+// Script returns a command running s with bash.
+func Script(s string) Command {
+	return Command{Name: "/bin/bash", Args: []string{"-c", s}}
+}
+
+// Cmd returns a command for name and args.
+func Cmd(name string, args ...string) Command {
+	return Command{Name: name, Args: args}
+}
+
+// String renders the command as a copy-pasteable shell line.
+func (c Command) String() string {
+	parts := make([]string, 0, len(c.Args)+2)
+	if c.Sudo {
+		parts = append(parts, "sudo")
+	}
+	parts = append(parts, Quote(c.Name))
+	for _, a := range c.Args {
+		parts = append(parts, Quote(a))
+	}
+	return strings.Join(parts, " ")
+}
+
+// Quote single-quotes s for a POSIX shell when needed.
+func Quote(s string) string {
+	if s == "" {
+		return "''"
+	}
+	if strings.IndexFunc(s, func(r rune) bool {
+		return !(r == '-' || r == '_' || r == '.' || r == '/' || r == '=' || r == ':' || r == '@' || r == '+' || r == ',' ||
+			(r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9'))
+	}) < 0 {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// Result is the outcome of a finished command.
+type Result struct {
+	Stdout   string
+	Stderr   string
+	ExitCode int
+	Duration time.Duration
+}
+
+// Runner executes commands.
+type Runner interface {
+	Run(ctx context.Context, c Command) (Result, error)
+}
+
+// ExitError is returned when a command exits non-zero.
+type ExitError struct {
+	Command Command
+	Result  Result
+}
+
+func (e *ExitError) Error() string {
+	msg := strings.TrimSpace(e.Result.Stderr)
+	if msg == "" {
+		msg = strings.TrimSpace(e.Result.Stdout)
+	}
+	if i := strings.LastIndexByte(msg, '\n'); i >= 0 {
+		msg = msg[i+1:]
+	}
+	return fmt.Sprintf("%s: exit status %d: %s", e.Command.Name, e.Result.ExitCode, msg)
+}
+
+// TimeoutError is returned when Local kills a command that ran longer than
+// its timeout or went quiet for longer than its idle timeout.
+type TimeoutError struct {
+	Command Command
+	After   time.Duration
+	// Idle is set when the command was killed for printing nothing.
+	Idle bool
+}
+
+func (e *TimeoutError) Error() string {
+	if e.Idle {
+		return fmt.Sprintf("%s: no output for %s; killed", e.Command.Name, e.After)
+	}
+	return fmt.Sprintf("%s: timed out after %s", e.Command.Name, e.After)
+}
+
+// Local runs commands on this machine.
+type Local struct {
+	// Output, when set, receives a live copy of stdout and stderr.
+	Output io.Writer
+	// Timeout, when set, kills commands that run longer.
+	Timeout time.Duration
+	// IdleTimeout, when set, kills commands that print nothing for this
+	// long, which is how a hung installer usually looks.
+	IdleTimeout time.Duration
+	// Env is added to the environment of every command, before the
+	// command's own Env: a template's proxy and mirror variables.
+	Env []string
+}
+
+// Run implements Runner.
+func (l Local) Run(ctx context.Context, c Command) (Result, error) {
+	name, args := c.Name, c.Args
+	if c.Sudo {
+		name, args = "sudo", append([]string{c.Name}, c.Args...)
+	}
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	if l.Timeout > 0 {
+		t := time.AfterFunc(l.Timeout, func() { cancel(&TimeoutError{Command: c, After: l.Timeout}) })
+		defer t.Stop()
+	}
+
+	cmd := exec.CommandContext(ctx, name, args...)
+	reap := supervise(ctx, cmd)
+	cmd.Dir = c.Dir
+	if env := append(slices.Clone(l.Env), c.Env...); len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
+	if c.Stdin != "" {
+		cmd.Stdin = strings.NewReader(c.Stdin)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if l.Output != nil {
+		cmd.Stdout = io.MultiWriter(&stdout, l.Output)
+		cmd.Stderr = io.MultiWriter(&stderr, l.Output)
+	}
+	if l.IdleTimeout > 0 {
+		idle := time.AfterFunc(l.IdleTimeout, func() { cancel(&TimeoutError{Command: c, After: l.IdleTimeout, Idle: true}) })
+		defer idle.Stop()
+		cmd.Stdout = activity{cmd.Stdout, idle, l.IdleTimeout}
+		cmd.Stderr = activity{cmd.Stderr, idle, l.IdleTimeout}
+	}
+
+	start := time.Now()
+	err := cmd.Run()
+	reap()
+	res := Result{Stdout: stdout.String(), Stderr: stderr.String(), Duration: time.Since(start)}
+	var exitErr *exec.ExitError
+	var timeout *TimeoutError
+	switch {
+	case err != nil && errors.As(context.Cause(ctx), &timeout):
+		res.ExitCode = -1
+		return res, timeout
+	case errors.As(err, &exitErr):
+		res.ExitCode = exitErr.ExitCode()
+		return res, &ExitError{Command: c, Result: res}
+	case err != nil:
+		res.ExitCode = -1
+		return res, err
+	}
+	return res, nil
+}
+
+// activity restarts an idle timer whenever the command writes.
+type activity struct {
+	w     io.Writer
+	timer *time.Timer
+	after time.Duration
+}
+
+func (a activity) Write(p []byte) (int, error) {
+	a.timer.Reset(a.after)
+	return a.w.Write(p)
+}
+
+// supervise makes cancelling cmd's context interrupt it the way Ctrl+C
+// would, so installers like brew can clean up, and kill it after
+// GracePeriod. Without a terminal the command gets its own process group
+// and the whole group is signalled, so helpers it spawned do not outlive it.
+// With one, the command stays in the foreground group: sudo and installers
+// must be able to prompt, and Ctrl+C reaches the whole group anyway. That
+// group is MazIQ's too, so a timeout cannot signal it; it signals the
+// command and every process it started instead.
+//
+// The returned func must be called once cmd has finished; after a
+// cancellation it kills what is left of the group.
+func supervise(ctx context.Context, cmd *exec.Cmd) (reap func()) {
+	cmd.WaitDelay = GracePeriod
+	if terminal() {
+		var spawned []int
+		cmd.Cancel = func() error {
+			if timedOut(ctx) {
+				spawned = descendants(cmd.Process.Pid)
+				for _, pid := range spawned {
+					_ = syscall.Kill(pid, syscall.SIGINT)
+				}
+			}
+			return cmd.Process.Signal(os.Interrupt)
+		}
+		return func() {
+			for _, pid := range spawned {
+				_ = syscall.Kill(pid, syscall.SIGKILL)
+			}
+		}
+	}
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error { return syscall.Kill(-cmd.Process.Pid, syscall.SIGINT) }
+	return func() {
+		if ctx.Err() != nil && cmd.Process != nil {
+			_ = syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+		}
+	}
+}
+
+// timedOut reports whether ctx was cancelled by Local's timeout or idle
+// watchdog rather than by the caller.
+func timedOut(ctx context.Context) bool {
+	var t *TimeoutError
+	return errors.As(context.Cause(ctx), &t)
+}
+
+// descendants returns the processes under pid, children before their own
+// children. It is read before signalling anything, while the tree is
+// still linked: an orphaned grandchild is reparented to launchd.
+func descendants(pid int) []int {
+	out, err := exec.Command("ps", "-A", "-o", "pid=", "-o", "ppid=").Output()
+	if err != nil {
+		return nil
+	}
+	children := map[int][]int{}
+	for _, line := range strings.Split(string(out), "\n") {
+		var child, parent int
+		if _, err := fmt.Sscan(line, &child, &parent); err == nil {
+			children[parent] = append(children[parent], child)
+		}
+	}
+	var found []int
+	for queue := children[pid]; len(queue) > 0; queue = queue[1:] {
+		found = append(found, queue[0])
+		queue = append(queue, children[queue[0]]...)
+	}
+	return found
+}
+
+// terminal reports whether MazIQ has a controlling terminal, which is what
+// sudo and installers prompt on.
+var terminal = sync.OnceValue(func() bool {
+	f, err := os.Open("/dev/tty")
+	if err != nil {
+		return false
+	}
+	f.Close()
+	return true
+})
@@ -0,0 +1,466 @@
+// Package state is MazIQ's record of what it did to the machine: the apps
+// and binaries it installed and anything else later runs need to remember.
+//
+// Values live under slash-separated keys in ~/.maziq/state. Every change is
+// appended to journal.jsonl with a checksum and fsynced; once the journal
+// grows long it is folded into snapshot.json. An exclusive flock serialises
+// writers, so the TUI, the daemon and a CLI run can share the store. Reads
+// fail with a *CorruptError on damaged data; `maziq state repair` salvages
+// what is intact.
+package state
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/hmziqrs/maziq/internal/config"
+)
+
+// compactAt is how many journal entries trigger a compaction.
+const compactAt = 500
+
+// Dir is where the store lives.
+func Dir() string {
+	return filepath.Join(config.Dir(), "state")
+}
+
+func journalPath() string  { return filepath.Join(Dir(), "journal.jsonl") }
+func snapshotPath() string { return filepath.Join(Dir(), "snapshot.json") }
+
+// entry is one journal line.
+type entry struct {
+	Seq     int64           `json:"seq"`
+	Time    int64           `json:"time"`
+	Key     string          `json:"key"`
+	Value   json.RawMessage `json:"value,omitempty"`
+	Deleted bool            `json:"deleted,omitempty"`
+	Sum     string          `json:"sum"`
+}
+
+func (e entry) sum() string {
+	h := crc32.NewIEEE()
+	fmt.Fprintf(h, "%d\x00%d\x00%s\x00%t\x00", e.Seq, e.Time, e.Key, e.Deleted)
+	h.Write(e.Value)
+	return fmt.Sprintf("%08x", h.Sum32())
+}
+
+// snapshot is the compacted store.
+type snapshot struct {
+	// Seq is the last journal entry folded in.
+	Seq    int64                      `json:"seq"`
+	Values map[string]json.RawMessage `json:"values"`
+	Sum    string                     `json:"sum"`
+}
+
+func (s snapshot) sum() string {
+	keys := make([]string, 0, len(s.Values))
+	for k := range s.Values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	h := crc32.NewIEEE()
+	fmt.Fprintf(h, "%d\x00", s.Seq)
+	for _, k := range keys {
+		// Values are summed in compact form so the snapshot can be
+		// pretty-printed.
+		var v bytes.Buffer
+		if json.Compact(&v, s.Values[k]) != nil {
+			v.Write(s.Values[k])
+		}
+		fmt.Fprintf(h, "%s\x00%s\x00", k, v.Bytes())
+	}
+	return fmt.Sprintf("%08x", h.Sum32())
+}
+
+// CorruptError reports damaged store data.
+type CorruptError struct {
+	File string
+	// Line is the 1-based journal line, or 0 for the snapshot.
+	Line   int
+	Reason string
+}
+
+func (e *CorruptError) Error() string {
+	where := e.File
+	if e.Line > 0 {
+		where = fmt.Sprintf("%s:%d", e.File, e.Line)
+	}
+	return fmt.Sprintf("state: %s: %s; run maziq state repair", where, e.Reason)
+}
+
+// data is the store as read from disk.
+type data struct {
+	values map[string]json.RawMessage
+	seq    int64
+	// entries counts the journal entries not yet compacted.
+	entries  int
+	problems []*CorruptError
+	// torn is set when the journal ends in a partial line, the trace of a
+	// write interrupted by a crash. It was never acknowledged, so reads
+	// ignore it and the next write cuts it off at size.
+	torn bool
+	size int64
+}
+
+// read loads the snapshot and replays the journal, collecting every problem
+// instead of stopping at the first.
+func read() (*data, error) {
+	d := &data{values: map[string]json.RawMessage{}}
+	raw, err := os.ReadFile(snapshotPath())
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		return nil, err
+	default:
+		var s snapshot
+		if err := json.Unmarshal(raw, &s); err != nil {
+			d.problems = append(d.problems, &CorruptError{File: snapshotPath(), Reason: err.Error()})
+		} else if s.sum() != s.Sum {
+			d.problems = append(d.problems, &CorruptError{File: snapshotPath(), Reason: "checksum mismatch"})
+		} else {
+			d.seq = s.Seq
+			for k, v := range s.Values {
+				d.values[k] = v
+			}
+		}
+	}
+
+	f, err := os.Open(journalPath())
+	if errors.Is(err, fs.ErrNotExist) {
+		return d, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	r := bufio.NewReader(f)
+	for n := 1; ; n++ {
+		line, err := r.ReadBytes('\n')
+		if err == io.EOF {
+			d.torn = len(bytes.TrimSpace(line)) > 0
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		d.size += int64(len(line))
+		var e entry
+		switch {
+		case json.Unmarshal(line, &e) != nil:
+			d.problems = append(d.problems, &CorruptError{File: journalPath(), Line: n, Reason: "malformed entry"})
+		case e.sum() != e.Sum:
+			d.problems = append(d.problems, &CorruptError{File: journalPath(), Line: n, Reason: "checksum mismatch"})
+		case e.Seq <= d.seq:
+			// Already folded into the snapshot by a compaction that was
+			// interrupted before truncating the journal.
+		default:
+			d.apply(e)
+		}
+	}
+	return d, nil
+}
+
+func (d *data) apply(e entry) {
+	d.seq = e.Seq
+	d.entries++
+	if e.Deleted {
+		delete(d.values, e.Key)
+	} else {
+		d.values[e.Key] = e.Value
+	}
+}
+
+func (d *data) err() error {
+	if len(d.problems) > 0 {
+		return d.problems[0]
+	}
+	return nil
+}
+
+// lock takes the store's flock, shared for readers and exclusive for
+// writers, and returns its release.
+func lock(exclusive bool) (func(), error) {
+	if err := os.MkdirAll(Dir(), 0o755); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(filepath.Join(Dir(), "lock"), os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	how := syscall.LOCK_SH
+	if exclusive {
+		how = syscall.LOCK_EX
+	}
+	if err := syscall.Flock(int(f.Fd()), how); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return func() {
+		_ = syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+		f.Close()
+	}, nil
+}
+
+// Get decodes the value under key into v and reports whether there was one.
+func Get(key string, v any) (bool, error) {
+	unlock, err := lock(false)
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+	d, err := read()
+	if err != nil {
+		return false, err
+	}
+	if err := d.err(); err != nil {
+		return false, err
+	}
+	raw, ok := d.values[key]
+	if !ok {
+		return false, nil
+	}
+	return true, json.Unmarshal(raw, v)
+}
+
+// List decodes every value whose key starts with prefix, keyed by the rest
+// of the key.
+func List[T any](prefix string) (map[string]T, error) {
+	unlock, err := lock(false)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	d, err := read()
+	if err != nil {
+		return nil, err
+	}
+	if err := d.err(); err != nil {
+		return nil, err
+	}
+	out := map[string]T{}
+	for k, raw := range d.values {
+		if name, ok := strings.CutPrefix(k, prefix); ok {
+			var v T
+			if err := json.Unmarshal(raw, &v); err != nil {
+				return nil, fmt.Errorf("state: %s: %w", k, err)
+			}
+			out[name] = v
+		}
+	}
+	return out, nil
+}
+
+// Put stores v under key.
+func Put(key string, v any) error {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return write(entry{Key: key, Value: raw})
+}
+
+// Delete removes key. Deleting a missing key is not an error.
+func Delete(key string) error {
+	return write(entry{Key: key, Deleted: true})
+}
+
+func write(e entry) error {
+	unlock, err := lock(true)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return writeLocked(e)
+}
+
+// writeLocked appends e to the journal; the caller holds the exclusive lock.
+func writeLocked(e entry) error {
+	d, err := read()
+	if err != nil {
+		return err
+	}
+	if err := d.err(); err != nil {
+		return err
+	}
+	if d.torn {
+		if err := os.Truncate(journalPath(), d.size); err != nil {
+			return err
+		}
+	}
+
+	e.Seq, e.Time = d.seq+1, time.Now().Unix()
+	e.Sum = e.sum()
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(journalPath(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	d.apply(e)
+	if d.entries >= compactAt {
+		return compact(d)
+	}
+	return nil
+}
+
+// compact writes d as the snapshot and empties the journal. The snapshot is
+// replaced atomically first, so a crash in between leaves journal entries
+// the snapshot already holds, which read skips.
+func compact(d *data) error {
+	s := snapshot{Seq: d.seq, Values: d.values}
+	s.Sum = s.sum()
+	raw, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := snapshotPath() + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(raw); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, snapshotPath()); err != nil {
+		return err
+	}
+	return os.Truncate(journalPath(), 0)
+}
+
+// Summary describes the store for `maziq state`.
+type Summary struct {
+	Keys     int
+	Seq      int64
+	Entries  int
+	Problems []*CorruptError
+	Torn     bool
+}
+
+// Check reads the whole store and reports its problems.
+func Check() (Summary, error) {
+	unlock, err := lock(false)
+	if err != nil {
+		return Summary{}, err
+	}
+	defer unlock()
+	d, err := read()
+	if err != nil {
+		return Summary{}, err
+	}
+	return Summary{Keys: len(d.values), Seq: d.seq, Entries: d.entries, Problems: d.problems, Torn: d.torn}, nil
+}
+
+// Repair rewrites the store from whatever is intact: a valid snapshot and
+// every valid journal entry. Damaged files are kept next to the store with
+// a .corrupt suffix. It returns how many problems were dropped.
+func Repair() (int, error) {
+	unlock, err := lock(true)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+	d, err := read()
+	if err != nil {
+		return 0, err
+	}
+	dropped := len(d.problems)
+	if d.torn {
+		dropped++
+	}
+	if dropped == 0 {
+		return 0, nil
+	}
+	stamp := time.Now().Format("20060102-150405")
+	for _, p := range []string{snapshotPath(), journalPath()} {
+		if raw, err := os.ReadFile(p); err == nil {
+			if err := os.WriteFile(p+".corrupt-"+stamp, raw, 0o644); err != nil {
+				return 0, err
+			}
+		}
+	}
+	return dropped, compact(d)
+}
+
+// Compact folds the journal into the snapshot.
+func Compact() error {
+	unlock, err := lock(true)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	d, err := read()
+	if err != nil {
+		return err
+	}
+	if err := d.err(); err != nil {
+		return err
+	}
+	return compact(d)
+}
+
+// Import moves a JSON object file written before the store existed into it,
+// one key per member under prefix, and renames the file with a .migrated
+// suffix. A missing file is not an error. The whole move happens under the
+// store's lock, so a concurrent import finds the file already gone.
+func Import(path, prefix string) error {
+	unlock, err := lock(true)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &m); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := writeLocked(entry{Key: prefix + name, Value: m[name]}); err != nil {
+			return err
+		}
+	}
+	return os.Rename(path, path+".migrated")
+}
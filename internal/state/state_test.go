@@ -0,0 +1,295 @@
+package state
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"testing"
+)
+
+// testStore points the store at an empty temporary directory.
+func testStore(t *testing.T) {
+	t.Helper()
+	t.Setenv("MAZIQ_HOME", t.TempDir())
+}
+
+func get(t *testing.T, key string) (string, bool) {
+	t.Helper()
+	var v string
+	ok, err := Get(key, &v)
+	if err != nil {
+		t.Fatalf("Get(%q): %v", key, err)
+	}
+	return v, ok
+}
+
+func put(t *testing.T, key, v string) {
+	t.Helper()
+	if err := Put(key, v); err != nil {
+		t.Fatalf("Put(%q): %v", key, err)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, compacted := range []bool{false, true} {
+		name := "journal"
+		if compacted {
+			name = "snapshot"
+		}
+		t.Run(name, func(t *testing.T) {
+			testStore(t)
+			put(t, "apps/a", "1")
+			put(t, "apps/b", "2")
+			put(t, "apps/a", "3")
+			put(t, "bins/c", "4")
+			if err := Delete("apps/b"); err != nil {
+				t.Fatal(err)
+			}
+			if err := Delete("missing"); err != nil {
+				t.Errorf("Delete of a missing key: %v", err)
+			}
+			if compacted {
+				if err := Compact(); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if v, ok := get(t, "apps/a"); !ok || v != "3" {
+				t.Errorf("apps/a = %q, %t, want 3", v, ok)
+			}
+			if _, ok := get(t, "apps/b"); ok {
+				t.Error("apps/b survived its delete")
+			}
+			apps, err := List[string]("apps/")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(apps) != 1 || apps["a"] != "3" {
+				t.Errorf("List(apps/) = %v, want map[a:3]", apps)
+			}
+		})
+	}
+}
+
+func TestCompactsLongJournal(t *testing.T) {
+	testStore(t)
+	for i := 0; i < compactAt; i++ {
+		put(t, "counter", string(rune('a'+i%26)))
+	}
+	if info, err := os.Stat(journalPath()); err != nil || info.Size() != 0 {
+		t.Fatalf("journal not emptied after %d entries: %v, %v", compactAt, info, err)
+	}
+	s, err := Check()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Seq != compactAt || s.Entries != 0 || s.Keys != 1 {
+		t.Errorf("summary = %+v, want seq %d, no entries, one key", s, compactAt)
+	}
+	put(t, "after", "x")
+	if v, _ := get(t, "counter"); v != string(rune('a'+(compactAt-1)%26)) {
+		t.Errorf("counter = %q after compaction", v)
+	}
+}
+
+// TestInterruptedCompaction replays a journal the snapshot already holds,
+// as a crash between writing the snapshot and truncating the journal
+// leaves it.
+func TestInterruptedCompaction(t *testing.T) {
+	testStore(t)
+	put(t, "k", "1")
+	put(t, "k", "2")
+	journal, err := os.ReadFile(journalPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Compact(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(journalPath(), journal, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s, err := Check()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Entries != 0 || len(s.Problems) != 0 {
+		t.Errorf("summary = %+v, want the journal skipped", s)
+	}
+	put(t, "k", "3")
+	if v, _ := get(t, "k"); v != "3" {
+		t.Errorf("k = %q, want 3", v)
+	}
+	if s, _ := Check(); s.Seq != 3 {
+		t.Errorf("seq = %d after the next write, want 3", s.Seq)
+	}
+}
+
+// TestTornWrite cuts the journal's last line short, as a crash during
+// the append would.
+func TestTornWrite(t *testing.T) {
+	testStore(t)
+	put(t, "a", "1")
+	put(t, "b", "2")
+	journal, err := os.ReadFile(journalPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	torn := journal[:len(journal)-10]
+	if err := os.WriteFile(journalPath(), torn, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := get(t, "b"); ok {
+		t.Error("read the torn entry")
+	}
+	if v, _ := get(t, "a"); v != "1" {
+		t.Errorf("a = %q, want 1", v)
+	}
+	if s, _ := Check(); !s.Torn || len(s.Problems) != 0 {
+		t.Errorf("summary = %+v, want torn and no problems", s)
+	}
+	put(t, "c", "3")
+	s, err := Check()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Torn || len(s.Problems) != 0 || s.Keys != 2 {
+		t.Errorf("summary after the next write = %+v, want two intact keys", s)
+	}
+}
+
+func TestCorruption(t *testing.T) {
+	tests := []struct {
+		name string
+		// damage edits the store after a, b and c were written, with
+		// a and b compacted into the snapshot.
+		damage func(t *testing.T)
+		file   func() string
+		line   int
+		reason string
+		// keep is what Repair salvages.
+		keep []string
+	}{
+		{
+			name: "journal checksum",
+			damage: func(t *testing.T) {
+				edit(t, journalPath(), `"3"`, `"9"`)
+			},
+			file: journalPath, line: 1, reason: "checksum mismatch",
+			keep: []string{"a", "b"},
+		},
+		{
+			name: "journal garbage",
+			damage: func(t *testing.T) {
+				edit(t, journalPath(), `{"seq"`, `{"seq"garbage`)
+			},
+			file: journalPath, line: 1, reason: "malformed entry",
+			keep: []string{"a", "b"},
+		},
+		{
+			name: "snapshot checksum",
+			damage: func(t *testing.T) {
+				edit(t, snapshotPath(), `"2"`, `"9"`)
+			},
+			file: snapshotPath, reason: "checksum mismatch",
+			keep: []string{"c"},
+		},
+		{
+			name: "snapshot truncated",
+			damage: func(t *testing.T) {
+				raw, err := os.ReadFile(snapshotPath())
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(snapshotPath(), raw[:len(raw)/2], 0o644); err != nil {
+					t.Fatal(err)
+				}
+			},
+			file: snapshotPath, reason: "unexpected end of JSON input",
+			keep: []string{"c"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testStore(t)
+			put(t, "a", "1")
+			put(t, "b", "2")
+			if err := Compact(); err != nil {
+				t.Fatal(err)
+			}
+			put(t, "c", "3")
+			tt.damage(t)
+
+			var v string
+			_, err := Get("a", &v)
+			var ce *CorruptError
+			if !errors.As(err, &ce) {
+				t.Fatalf("Get = %v, want a *CorruptError", err)
+			}
+			if ce.File != tt.file() || ce.Line != tt.line || ce.Reason != tt.reason {
+				t.Errorf("error = %+v, want %s:%d %s", ce, tt.file(), tt.line, tt.reason)
+			}
+			if err := Put("d", "4"); !errors.As(err, &ce) {
+				t.Errorf("Put on a damaged store = %v, want a *CorruptError", err)
+			}
+
+			n, err := Repair()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n != 1 {
+				t.Errorf("Repair dropped %d problems, want 1", n)
+			}
+			s, err := Check()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(s.Problems) != 0 || s.Keys != len(tt.keep) {
+				t.Errorf("after repair: %+v, want %d intact keys", s, len(tt.keep))
+			}
+			for _, k := range tt.keep {
+				if _, ok := get(t, k); !ok {
+					t.Errorf("repair lost %s", k)
+				}
+			}
+			put(t, "d", "4")
+		})
+	}
+}
+
+func TestImport(t *testing.T) {
+	testStore(t)
+	path := t.TempDir() + "/legacy.json"
+	if err := os.WriteFile(path, []byte(`{"x": "1", "y": "2"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for range 2 {
+		if err := Import(path, "legacy/"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got, err := List[string]("legacy/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got["x"] != "1" || got["y"] != "2" {
+		t.Errorf("imported %v", got)
+	}
+	if _, err := os.Stat(path + ".migrated"); err != nil {
+		t.Error(err)
+	}
+}
+
+// edit replaces the first old in the file with new.
+func edit(t *testing.T, path, old, new string) {
+	t.Helper()
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(raw, []byte(old)) {
+		t.Fatalf("%s does not contain %s:\n%s", path, old, raw)
+	}
+	if err := os.WriteFile(path, bytes.Replace(raw, []byte(old), []byte(new), 1), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,122 @@
+// Package status collects a read-only snapshot of the machine against a
+// template: what is installed, what drifted, what is outdated and whether the
+// provisioning prerequisites are healthy.
+package status
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/hmziqrs/maziq/internal/catalog"
+	"github.com/hmziqrs/maziq/internal/facts"
+	"github.com/hmziqrs/maziq/internal/fetch"
+	"github.com/hmziqrs/maziq/internal/health"
+	"github.com/hmziqrs/maziq/internal/history"
+	"github.com/hmziqrs/maziq/internal/manager"
+	"github.com/hmziqrs/maziq/internal/modules/binaries"
+	"github.com/hmziqrs/maziq/internal/modules/direct"
+	"github.com/hmziqrs/maziq/internal/resource"
+	"github.com/hmziqrs/maziq/internal/shell"
+	"github.com/hmziqrs/maziq/internal/templates"
+)
+
+// Snapshot is the state of the machine at a point in time. Collecting it
+// never mutates anything.
+type Snapshot struct {
+	Template  string            `json:"template"`
+	Taken     time.Time         `json:"taken"`
+	Software  []manager.Result  `json:"software"`
+	Drift     []string          `json:"drift"`
+	Outdated  map[string]string `json:"outdated"`
+	Health    []health.Result   `json:"health"`
+	LastApply *history.Record   `json:"last_apply,omitempty"`
+	Errors    []string          `json:"errors,omitempty"`
+}
+
+// Installed returns how many template entries are installed.
+func (s Snapshot) Installed() int {
+	n := 0
+	for _, r := range s.Software {
+		if r.Status == manager.StatusInstalled {
+			n++
+		}
+	}
+	return n
+}
+
+// Healthy reports whether no health check failed.
+func (s Snapshot) Healthy() bool {
+	for _, h := range s.Health {
+		if h.Level == health.Fail {
+			return false
+		}
+	}
+	return true
+}
+
+// Collect probes every active template entry and runs the health checks.
+func Collect(ctx context.Context, t *templates.Template) Snapshot {
+	s := Snapshot{Template: t.Name, Taken: time.Now(), Outdated: map[string]string{}}
+	ctx = fetch.WithProxy(ctx, t.Proxy.Environ())
+
+	f := facts.Detect()
+	env := &resource.Env{Runner: shell.FromEnv(shell.Local{Env: t.Proxy.Environ()}), Facts: f, Template: t}
+	entries, err := t.Active(f)
+	if err != nil {
+		s.Errors = append(s.Errors, err.Error())
+	}
+	ids := make([]string, 0, len(entries))
+	for _, e := range entries {
+		ids = append(ids, e.ID)
+		sw, ok := catalog.Lookup(e.ID)
+		if !ok {
+			s.Software = append(s.Software, manager.Result{ID: e.ID, Status: manager.StatusUnknown})
+			continue
+		}
+		r := manager.Detect(ctx, env.Runner, sw)
+		s.Software = append(s.Software, r)
+		if r.Status == manager.StatusNotInstalled {
+			s.Drift = append(s.Drift, e.ID)
+		}
+	}
+
+	apps, err := direct.Apps(env)
+	if err != nil {
+		s.Errors = append(s.Errors, err.Error())
+	}
+	for _, a := range apps {
+		if _, ok, err := a.Installed(ctx, env); err == nil && !ok {
+			s.Drift = append(s.Drift, a.ID())
+		}
+	}
+
+	bins, err := binaries.Binaries(env)
+	if err != nil {
+		s.Errors = append(s.Errors, err.Error())
+	}
+	for _, b := range bins {
+		if _, err := os.Stat(b.Path()); err != nil {
+			s.Drift = append(s.Drift, b.ID())
+		}
+	}
+
+	if outdated, err := manager.Outdated(ctx, env.Runner, ids); err != nil {
+		s.Errors = append(s.Errors, "outdated: "+err.Error())
+	} else if outdated != nil {
+		s.Outdated = outdated
+	}
+	updates, err := binaries.Outdated(ctx, env)
+	if err != nil {
+		s.Errors = append(s.Errors, "outdated: "+err.Error())
+	}
+	for _, u := range updates {
+		s.Outdated[u.Binary.ID()] = u.Latest
+	}
+
+	s.Health = health.Run(ctx)
+	if last, ok := history.Last(); ok {
+		s.LastApply = &last
+	}
+	return s
+}
@@ -0,0 +1,99 @@
+// Package sysprefs opens System Settings at a specific pane through
+// x-apple.systempreferences: URLs, so manual steps and error hints can take
+// the user straight to the toggle that needs flipping.
+package sysprefs
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/hmziqrs/maziq/internal/shell"
+)
+
+// Pane is a System Settings location.
+type Pane struct {
+	Name string
+	// Title is the breadcrumb the user sees, e.g. "Privacy & Security →
+	// Accessibility".
+	Title string
+	URL   string
+}
+
+const (
+	scheme  = "x-apple.systempreferences:"
+	privacy = scheme + "com.apple.preference.security?"
+)
+
+var panes = map[string]Pane{}
+
+func add(name, title, url string) {
+	panes[name] = Pane{Name: name, Title: title, URL: url}
+}
+
+func init() {
+	add("accessibility", "Privacy & Security → Accessibility", privacy+"Privacy_Accessibility")
+	add("automation", "Privacy & Security → Automation", privacy+"Privacy_Automation")
+	add("developer-tools", "Privacy & Security → Developer Tools", privacy+"Privacy_DevTools")
+	add("full-disk-access", "Privacy & Security → Full Disk Access", privacy+"Privacy_AllFiles")
+	add("input-monitoring", "Privacy & Security → Input Monitoring", privacy+"Privacy_ListenEvent")
+	add("location", "Privacy & Security → Location Services", privacy+"Privacy_LocationServices")
+	add("screen-recording", "Privacy & Security → Screen & System Audio Recording", privacy+"Privacy_ScreenCapture")
+	add("security", "Privacy & Security", privacy+"General")
+	add("file-vault", "Privacy & Security → FileVault", privacy+"FDE")
+
+	add("apple-account", "Apple Account", scheme+"com.apple.preferences.AppleIDPrefPane")
+	add("battery", "Battery", scheme+"com.apple.preference.battery")
+	add("bluetooth", "Bluetooth", scheme+"com.apple.preferences.Bluetooth")
+	add("date-time", "General → Date & Time", scheme+"com.apple.preference.datetime")
+	add("desktop-dock", "Desktop & Dock", scheme+"com.apple.preference.dock")
+	add("displays", "Displays", scheme+"com.apple.preference.displays")
+	add("keyboard", "Keyboard", scheme+"com.apple.preference.keyboard")
+	add("language-region", "General → Language & Region", scheme+"com.apple.Localization-Settings.extension")
+	add("lock-screen", "Lock Screen", scheme+"com.apple.Lock-Screen-Settings.extension")
+	add("login-items", "General → Login Items & Extensions", scheme+"com.apple.LoginItems-Settings.extension")
+	add("mouse", "Mouse", scheme+"com.apple.preference.mouse")
+	add("network", "Network", scheme+"com.apple.Network-Settings.extension")
+	add("notifications", "Notifications", scheme+"com.apple.preference.notifications")
+	add("printers", "Printers & Scanners", scheme+"com.apple.preference.printfax")
+	add("sharing", "General → Sharing", scheme+"com.apple.preferences.sharing")
+	add("software-update", "General → Software Update", scheme+"com.apple.preferences.softwareupdate")
+	add("sound", "Sound", scheme+"com.apple.preference.sound")
+	add("spotlight", "Spotlight", scheme+"com.apple.preference.spotlight")
+	add("time-machine", "General → Time Machine", scheme+"com.apple.prefs.backup")
+	add("trackpad", "Trackpad", scheme+"com.apple.preference.trackpad")
+	add("users", "Users & Groups", scheme+"com.apple.preferences.users")
+	add("wifi", "Wi-Fi", scheme+"com.apple.wifi-settings-extension")
+}
+
+// Lookup returns the pane called name.
+func Lookup(name string) (Pane, bool) {
+	p, ok := panes[name]
+	return p, ok
+}
+
+// Names returns every pane name sorted alphabetically.
+func Names() []string {
+	out := make([]string, 0, len(panes))
+	for name := range panes {
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Open brings System Settings to the front at the named pane.
+func Open(ctx context.Context, r shell.Runner, name string) error {
+	p, ok := Lookup(name)
+	if !ok {
+		return fmt.Errorf("unknown System Settings pane %q", name)
+	}
+	_, err := r.Run(ctx, shell.Cmd("open", p.URL))
+	return err
+}
+
+// Linker is implemented by resources that can point the user at the pane
+// where they would fix them by hand.
+type Linker interface {
+	SettingsPane() string
+}
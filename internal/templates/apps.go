@@ -0,0 +1,32 @@
+package templates
+
+// DirectApp is an app that is in neither Homebrew nor the App Store,
+// installed straight from the vendor's dmg, pkg or zip.
+//
+//	[[apps]]
+//	name = "Example"
+//	url = "https://example.com/downloads/Example-{version}.dmg"
+//	app = "Example.app"
+//	version_url = "https://example.com/downloads/latest.json"
+//	version_key = "version"
+//
+// App is the bundle the download provides and that ends up in
+// /Applications; pkgs that install no app name their receipt with pkg_id
+// instead. When version_url is set, the installed version is compared with
+// the one it reports and apply upgrades; {version} in url and signature is
+// replaced with it. The response is the bare version, or JSON with the
+// version under version_key (dots descend into objects).
+type DirectApp struct {
+	Name       string `toml:"name"`
+	URL        string `toml:"url"`
+	App        string `toml:"app"`
+	PkgID      string `toml:"pkg_id"`
+	VersionURL string `toml:"version_url"`
+	VersionKey string `toml:"version_key"`
+	When       string `toml:"when"`
+	// Integrity pins the download.
+	Integrity
+}
+
+// VersionPlaceholder in a DirectApp url is replaced with the latest version.
+const VersionPlaceholder = "{version}"
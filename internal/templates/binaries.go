@@ -0,0 +1,34 @@
+package templates
+
+// Binary is a single-binary tool installed from a GitHub release archive
+// into ~/.local/bin.
+//
+//	[[binaries]]
+//	name = "ripgrep"
+//	repo = "BurntSushi/ripgrep"
+//	bin = "rg"
+//
+// Bin is the executable inside the archive and defaults to Name. Without a
+// version the latest release is installed and kept up to date; asset is a
+// regular expression for releases whose archive names MazIQ cannot match to
+// macOS and the machine's architecture on its own. Downloads are checked
+// against the release's checksums file when it has one; pin further with
+// sha256 or a signature, where {version} is the release tag.
+type Binary struct {
+	Name    string `toml:"name"`
+	Repo    string `toml:"repo"`
+	Bin     string `toml:"bin"`
+	Version string `toml:"version"`
+	Asset   string `toml:"asset"`
+	When    string `toml:"when"`
+	// Integrity pins the release archive.
+	Integrity
+}
+
+// Executable returns the name of the binary to install.
+func (b Binary) Executable() string {
+	if b.Bin != "" {
+		return b.Bin
+	}
+	return b.Name
+}
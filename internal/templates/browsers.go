@@ -0,0 +1,68 @@
+package templates
+
+import "fmt"
+
+// Browsers configures browser policies. Each browser's policy file is owned
+// by MazIQ: it is rendered from the template and drift is reported when it
+// is edited by hand.
+//
+//	[browsers.chrome]
+//	extensions = ["cjpalhdlnbpafiamejdnhcphjbkeiagm"]
+//	search = { name = "DuckDuckGo", url = "https://duckduckgo.com/?q={searchTerms}" }
+//
+//	[browsers.firefox]
+//	extensions = [{ id = "uBlock0@raymondhill.net", url = "https://addons.mozilla.org/firefox/downloads/latest/ublock-origin/latest.xpi" }]
+type Browsers struct {
+	Chrome  *Browser `toml:"chrome"`
+	Firefox *Browser `toml:"firefox"`
+}
+
+// Browser is the policy set for one browser.
+type Browser struct {
+	Extensions []Extension `toml:"extensions"`
+	Search     *Search     `toml:"search"`
+	Homepage   string      `toml:"homepage"`
+	// Policies are passed through verbatim for anything not modelled above.
+	Policies map[string]any `toml:"policies"`
+	When     string         `toml:"when"`
+}
+
+// Search is the default search engine. URL uses {searchTerms} as the query
+// placeholder in both Chrome and Firefox.
+type Search struct {
+	Name string `toml:"name"`
+	URL  string `toml:"url"`
+}
+
+// Extension is a force-installed extension: a bare ID or a table with an
+// explicit update/download URL.
+type Extension struct {
+	ID  string `toml:"id"`
+	URL string `toml:"url"`
+}
+
+// UnmarshalTOML accepts both the string and table forms of an extension.
+func (e *Extension) UnmarshalTOML(v any) error {
+	switch v := v.(type) {
+	case string:
+		e.ID = v
+	case map[string]any:
+		for key, val := range v {
+			s, ok := val.(string)
+			if !ok {
+				return fmt.Errorf("extension field %q must be a string", key)
+			}
+			switch key {
+			case "id":
+				e.ID = s
+			case "url":
+				e.URL = s
+			default:
+				return fmt.Errorf("unknown extension field %q", key)
+			}
+		}
+	default:
+		return fmt.Errorf("extension must be a string or table, got %T", v)
+	}
+	return nil
+}
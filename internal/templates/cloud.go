@@ -0,0 +1,103 @@
+package templates
+
+// Cloud configures the non-secret side of cloud CLI accounts: AWS profiles,
+// gcloud configurations and Azure defaults. Signing in stays interactive;
+// apply prints the login command for each account.
+//
+//	[[cloud.aws]]
+//	profile = "dev"
+//	region = "eu-west-1"
+//	sso_start_url = "https://acme.awsapps.com/start"
+//	sso_region = "eu-west-1"
+//	sso_account_id = "123456789012"
+//	sso_role_name = "Developer"
+//
+//	[[cloud.gcloud]]
+//	configuration = "acme-dev"
+//	project = "acme-dev"
+//	account = "me@acme.com"
+//	region = "europe-west1"
+//
+//	[cloud.azure]
+//	subscription = "00000000-0000-0000-0000-000000000000"
+//	location = "westeurope"
+type Cloud struct {
+	AWS    []AWSProfile   `toml:"aws"`
+	GCloud []GCloudConfig `toml:"gcloud"`
+	Azure  *Azure         `toml:"azure"`
+}
+
+// AWSProfile is a named profile in ~/.aws/config.
+type AWSProfile struct {
+	Profile string `toml:"profile"`
+	Region  string `toml:"region"`
+	Output  string `toml:"output"`
+	// IAM Identity Center (SSO) settings.
+	SSOStartURL  string `toml:"sso_start_url"`
+	SSORegion    string `toml:"sso_region"`
+	SSOAccountID string `toml:"sso_account_id"`
+	SSORoleName  string `toml:"sso_role_name"`
+	// Assume-role settings.
+	RoleARN       string `toml:"role_arn"`
+	SourceProfile string `toml:"source_profile"`
+	When          string `toml:"when"`
+}
+
+// Settings returns the profile's config keys in a stable order, skipping
+// empty ones.
+func (p AWSProfile) Settings() [][2]string {
+	var out [][2]string
+	for _, kv := range [][2]string{
+		{"region", p.Region},
+		{"output", p.Output},
+		{"sso_start_url", p.SSOStartURL},
+		{"sso_region", p.SSORegion},
+		{"sso_account_id", p.SSOAccountID},
+		{"sso_role_name", p.SSORoleName},
+		{"role_arn", p.RoleARN},
+		{"source_profile", p.SourceProfile},
+	} {
+		if kv[1] != "" {
+			out = append(out, kv)
+		}
+	}
+	return out
+}
+
+// GCloudConfig is a named gcloud configuration.
+type GCloudConfig struct {
+	Configuration string `toml:"configuration"`
+	Project       string `toml:"project"`
+	Account       string `toml:"account"`
+	Region        string `toml:"region"`
+	Zone          string `toml:"zone"`
+	// Activate makes this the active configuration.
+	Activate bool   `toml:"activate"`
+	When     string `toml:"when"`
+}
+
+// Properties returns the configuration's gcloud properties in a stable
+// order, skipping empty ones.
+func (c GCloudConfig) Properties() [][2]string {
+	var out [][2]string
+	for _, kv := range [][2]string{
+		{"core/project", c.Project},
+		{"core/account", c.Account},
+		{"compute/region", c.Region},
+		{"compute/zone", c.Zone},
+	} {
+		if kv[1] != "" {
+			out = append(out, kv)
+		}
+	}
+	return out
+}
+
+// Azure holds Azure CLI defaults.
+type Azure struct {
+	// Subscription is selected once you are logged in.
+	Subscription string `toml:"subscription"`
+	Location     string `toml:"location"`
+	Group        string `toml:"group"`
+	When         string `toml:"when"`
+}
@@ -0,0 +1,65 @@
+package templates
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Compat records which macOS major versions honour a preference key. Since
+// and Until are inclusive; zero leaves that end open. Keys without an entry
+// are assumed to work everywhere.
+type Compat struct {
+	Domain, Key  string
+	Since, Until int
+	// Note points at what to use instead, when there is something.
+	Note string
+}
+
+// SettingCompat lists preference keys that only work on some macOS
+// versions.
+var SettingCompat = []Compat{
+	{Domain: "com.apple.dashboard", Key: "mcx-disabled", Until: 10, Note: "Dashboard was removed in Catalina"},
+	{Domain: "com.apple.dock", Key: "dashboard-in-overlay", Until: 10, Note: "Dashboard was removed in Catalina"},
+	{Domain: "com.apple.systemuiserver", Key: "menuExtras", Until: 10, Note: "menu bar items moved to Control Center in Big Sur"},
+	{Domain: "com.apple.screensaver", Key: "askForPassword", Until: 12, Note: "use [screensaver] require_password"},
+	{Domain: "com.apple.screensaver", Key: "askForPasswordDelay", Until: 12, Note: "use [screensaver] password_delay"},
+	{Domain: "com.apple.WindowManager", Key: "GloballyEnabled", Since: 13, Note: "Stage Manager arrived in Ventura"},
+	{Domain: "com.apple.WindowManager", Key: "EnableStandardClickToShowDesktop", Since: 14},
+	{Domain: "com.apple.WindowManager", Key: "EnableTilingByEdgeDrag", Since: 15},
+	{Domain: "com.apple.WindowManager", Key: "EnableTopTilingByEdgeDrag", Since: 15},
+	{Domain: "com.apple.WindowManager", Key: "EnableTilingOptionAccelerator", Since: 15},
+	{Domain: "com.apple.WindowManager", Key: "EnableTiledWindowMargins", Since: 15},
+}
+
+// LookupCompat returns the compatibility entry for a key.
+func LookupCompat(domain, key string) (Compat, bool) {
+	for _, c := range SettingCompat {
+		if c.Domain == domain && c.Key == key {
+			return c, true
+		}
+	}
+	return Compat{}, false
+}
+
+// Supports reports whether the key works on a macOS major version such as
+// the "macos" fact. An unknown version is given the benefit of the doubt.
+func (c Compat) Supports(macos string) bool {
+	v, err := strconv.Atoi(macos)
+	if err != nil {
+		return true
+	}
+	return (c.Since == 0 || v >= c.Since) && (c.Until == 0 || v <= c.Until)
+}
+
+// Range describes the supported versions, e.g. "macOS 13 and later".
+func (c Compat) Range() string {
+	switch {
+	case c.Since != 0 && c.Until != 0:
+		return fmt.Sprintf("macOS %d to %d", c.Since, c.Until)
+	case c.Since != 0:
+		return fmt.Sprintf("macOS %d and later", c.Since)
+	case c.Until != 0:
+		return fmt.Sprintf("macOS %d and earlier", c.Until)
+	}
+	return "every macOS"
+}
@@ -0,0 +1,220 @@
+package templates
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Cond is a parsed `when` expression. The grammar is intentionally small:
+//
+//	expr    = and { "||" and }
+//	and     = unary { "&&" unary }
+//	unary   = "!" unary | primary
+//	primary = "(" expr ")" | ident [ ("==" | "!=") literal ]
+//
+// A bare identifier is true when its value is "true". Identifiers resolve to
+// facts first and template variables second.
+type Cond interface {
+	Eval(lookup func(string) string) bool
+	// Idents appends every identifier referenced by the expression.
+	Idents(dst []string) []string
+	// Literals appends every literal compared against ident.
+	Literals(ident string, dst []string) []string
+}
+
+type (
+	orCond  struct{ l, r Cond }
+	andCond struct{ l, r Cond }
+	notCond struct{ c Cond }
+	cmpCond struct {
+		ident, value string
+		negate       bool
+	}
+	boolCond struct{ ident string }
+)
+
+func (c orCond) Eval(f func(string) string) bool  { return c.l.Eval(f) || c.r.Eval(f) }
+func (c andCond) Eval(f func(string) string) bool { return c.l.Eval(f) && c.r.Eval(f) }
+func (c notCond) Eval(f func(string) string) bool { return !c.c.Eval(f) }
+func (c cmpCond) Eval(f func(string) string) bool { return (f(c.ident) == c.value) != c.negate }
+func (c boolCond) Eval(f func(string) string) bool {
+	return f(c.ident) == "true"
+}
+
+func (c orCond) Idents(dst []string) []string   { return c.r.Idents(c.l.Idents(dst)) }
+func (c andCond) Idents(dst []string) []string  { return c.r.Idents(c.l.Idents(dst)) }
+func (c notCond) Idents(dst []string) []string  { return c.c.Idents(dst) }
+func (c cmpCond) Idents(dst []string) []string  { return append(dst, c.ident) }
+func (c boolCond) Idents(dst []string) []string { return append(dst, c.ident) }
+
+func (c orCond) Literals(id string, dst []string) []string {
+	return c.r.Literals(id, c.l.Literals(id, dst))
+}
+func (c andCond) Literals(id string, dst []string) []string {
+	return c.r.Literals(id, c.l.Literals(id, dst))
+}
+func (c notCond) Literals(id string, dst []string) []string { return c.c.Literals(id, dst) }
+func (c cmpCond) Literals(id string, dst []string) []string {
+	if c.ident == id {
+		dst = append(dst, c.value)
+	}
+	return dst
+}
+func (c boolCond) Literals(id string, dst []string) []string {
+	if c.ident == id {
+		dst = append(dst, "true", "false")
+	}
+	return dst
+}
+
+// ParseCond parses a `when` expression. An empty expression is always true.
+func ParseCond(src string) (Cond, error) {
+	if strings.TrimSpace(src) == "" {
+		return nil, nil
+	}
+	p := &condParser{src: src}
+	p.next()
+	c, err := p.expr()
+	if err != nil {
+		return nil, err
+	}
+	if p.tok != "" || p.quoted {
+		return nil, fmt.Errorf("unexpected %q at offset %d", p.tok, p.start)
+	}
+	return c, nil
+}
+
+type condParser struct {
+	src        string
+	pos, start int
+	tok        string
+	quoted     bool
+}
+
+// next advances to the following token, leaving "" at end of input.
+func (p *condParser) next() {
+	for p.pos < len(p.src) && p.src[p.pos] == ' ' {
+		p.pos++
+	}
+	p.start, p.quoted = p.pos, false
+	if p.pos >= len(p.src) {
+		p.tok = ""
+		return
+	}
+	rest := p.src[p.pos:]
+	for _, op := range []string{"&&", "||", "==", "!=", "!", "(", ")"} {
+		if strings.HasPrefix(rest, op) {
+			p.tok = op
+			p.pos += len(op)
+			return
+		}
+	}
+	if q := rest[0]; q == '"' || q == '\'' {
+		end := strings.IndexByte(rest[1:], q)
+		if end < 0 {
+			p.tok, p.pos = rest, len(p.src)
+			return
+		}
+		p.tok, p.quoted = rest[1:end+1], true
+		p.pos += end + 2
+		return
+	}
+	end := strings.IndexFunc(rest, func(r rune) bool {
+		return !(unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '.' || r == '-')
+	})
+	if end == 0 {
+		end = 1
+	} else if end < 0 {
+		end = len(rest)
+	}
+	p.tok = rest[:end]
+	p.pos += end
+}
+
+func (p *condParser) expr() (Cond, error) {
+	l, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	for p.tok == "||" && !p.quoted {
+		p.next()
+		r, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		l = orCond{l, r}
+	}
+	return l, nil
+}
+
+func (p *condParser) and() (Cond, error) {
+	l, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for p.tok == "&&" && !p.quoted {
+		p.next()
+		r, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		l = andCond{l, r}
+	}
+	return l, nil
+}
+
+func (p *condParser) unary() (Cond, error) {
+	if p.tok == "!" && !p.quoted {
+		p.next()
+		c, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return notCond{c}, nil
+	}
+	return p.primary()
+}
+
+func (p *condParser) primary() (Cond, error) {
+	switch {
+	case p.tok == "" && !p.quoted:
+		return nil, fmt.Errorf("unexpected end of expression")
+	case p.tok == "(" && !p.quoted:
+		p.next()
+		c, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		if p.tok != ")" {
+			return nil, fmt.Errorf("missing ) at offset %d", p.start)
+		}
+		p.next()
+		return c, nil
+	case p.quoted || !isIdent(p.tok):
+		return nil, fmt.Errorf("expected identifier, got %q at offset %d", p.tok, p.start)
+	}
+	ident := p.tok
+	p.next()
+	if (p.tok != "==" && p.tok != "!=") || p.quoted {
+		return boolCond{ident}, nil
+	}
+	negate := p.tok == "!="
+	p.next()
+	// A quoted value may be empty, as in os == "".
+	if !p.quoted && (p.tok == "" || strings.ContainsAny(p.tok, "()!&|=")) {
+		return nil, fmt.Errorf("expected value after %s at offset %d", map[bool]string{false: "==", true: "!="}[negate], p.start)
+	}
+	value := p.tok
+	p.next()
+	return cmpCond{ident: ident, value: value, negate: negate}, nil
+}
+
+func isIdent(s string) bool {
+	for i, r := range s {
+		if !(unicode.IsLetter(r) || r == '_' || (i > 0 && (unicode.IsDigit(r) || r == '.'))) {
+			return false
+		}
+	}
+	return s != ""
+}
@@ -0,0 +1,48 @@
+package templates
+
+// Database is a post-install recipe for a local development database.
+//
+//	[[databases]]
+//	engine = "postgres"
+//	service = "postgresql@16"
+//	role = "dev"
+//	password_secret = "pg-dev"
+//	database = "app_dev"
+//
+//	[[databases]]
+//	engine = "redis"
+//	service = "redis"
+//	config = { maxmemory = "268435456", maxmemory-policy = "allkeys-lru" }
+//
+//	[[databases]]
+//	engine = "mysql"
+//	service = "mysql"
+//	root_password_secret = "mysql-root"
+//	role = "dev"
+//	database = "app_dev"
+type Database struct {
+	Engine string `toml:"engine"`
+	// Service is the brew service that must be running first.
+	Service string `toml:"service"`
+	// Role and Database are created (Postgres, MySQL) when set; the role
+	// owns the database.
+	Role           string `toml:"role"`
+	PasswordSecret string `toml:"password_secret"`
+	Superuser      bool   `toml:"superuser"`
+	Database       string `toml:"database"`
+	// RootPasswordSecret secures a fresh MySQL install the way
+	// mysql_secure_installation does.
+	RootPasswordSecret string `toml:"root_password_secret"`
+	// Config holds Redis CONFIG SET values, persisted with CONFIG REWRITE.
+	// Values are compared with CONFIG GET, so write them the way Redis
+	// reports them (memory sizes in bytes).
+	Config map[string]string `toml:"config"`
+	When   string            `toml:"when"`
+}
+
+// Database engines.
+const (
+	EnginePostgres = "postgres"
+	EngineMySQL    = "mysql"
+	EngineRedis    = "redis"
+)
@@ -0,0 +1,35 @@
+package templates
+
+// Default is a raw `defaults write`. Value may be a string, integer, float,
+// boolean or datetime, or an array or table of them nested to any depth; the
+// defaults type follows the TOML type, and tables become dictionaries.
+//
+//	[[defaults]]
+//	domain = "com.apple.dock"
+//	key = "autohide"
+//	value = true
+//	restart = "Dock"
+//
+//	[[defaults]]
+//	domain = "com.apple.finder"
+//	key = "FK_StandardViewSettings"
+//	value = { IconViewSettings = { iconSize = 64, arrangeBy = "name" } }
+//
+// An entry with Group instead of Domain and Key enables a curated
+// SettingGroup:
+//
+//	[[defaults]]
+//	group = "finder-power-user"
+type Default struct {
+	Group  string `toml:"group"`
+	Domain string `toml:"domain"`
+	Key    string `toml:"key"`
+	Value  any    `toml:"value"`
+	// CurrentHost writes the per-host (ByHost) preferences.
+	CurrentHost bool `toml:"current_host"`
+	// Restart names a process to killall after writing, e.g. "Dock".
+	Restart string `toml:"restart"`
+	// Logout marks keys that only take effect after logging out.
+	Logout bool   `toml:"logout"`
+	When   string `toml:"when"`
+}
@@ -0,0 +1,16 @@
+package templates
+
+import "time"
+
+// Demo makes a template a demo profile, for conference machines and loaner
+// laptops: apply records how to take back every change it makes, refuses
+// changes it could not take back, and `maziq demo reset` returns the
+// machine to how it was.
+//
+//	[demo]
+//	reset_after = "8h"
+type Demo struct {
+	// ResetAfter, when set, has the daemon reset the machine this long
+	// after the first demo apply.
+	ResetAfter time.Duration `toml:"reset_after"`
+}
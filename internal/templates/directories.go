@@ -0,0 +1,71 @@
+package templates
+
+import "strings"
+
+// Directory is part of the workspace skeleton: directories created on
+// apply, optionally kept out of iCloud Drive and Spotlight. Path takes
+// shell-style braces for several siblings at once.
+//
+//	[[directories]]
+//	path = "~/code/{work,oss,scratch}"
+//	exclude_icloud = true
+//	exclude_spotlight = true
+//
+//	[[directories]]
+//	path = "~/Screenshots"
+type Directory struct {
+	Path string `toml:"path"`
+	// Mode is octal permission bits; unset leaves new directories at 0755
+	// and existing ones alone. Setuid, setgid and sticky bits are not
+	// supported.
+	Mode string `toml:"mode"`
+	// ExcludeICloud keeps the directory out of iCloud Drive when it sits
+	// in Desktop or Documents.
+	ExcludeICloud bool `toml:"exclude_icloud"`
+	// ExcludeSpotlight adds the directory to Spotlight's privacy list.
+	ExcludeSpotlight bool   `toml:"exclude_spotlight"`
+	When             string `toml:"when"`
+}
+
+// Braces expands {a,b} alternatives in s, left to right, as a shell does:
+// "~/code/{work,oss}" is ["~/code/work", "~/code/oss"]. Groups may nest;
+// an unbalanced brace is kept literally.
+func Braces(s string) []string {
+	open := strings.IndexByte(s, '{')
+	if open < 0 {
+		return []string{s}
+	}
+	depth, closing := 0, -1
+	var commas []int
+	for i := open; i < len(s) && closing < 0; i++ {
+		switch s[i] {
+		case '{':
+			depth++
+		case '}':
+			if depth--; depth == 0 {
+				closing = i
+			}
+		case ',':
+			if depth == 1 {
+				commas = append(commas, i)
+			}
+		}
+	}
+	if closing < 0 || len(commas) == 0 {
+		// Not a group; keep the brace and expand the rest.
+		var out []string
+		for _, rest := range Braces(s[open+1:]) {
+			out = append(out, s[:open+1]+rest)
+		}
+		return out
+	}
+	var out []string
+	start := open + 1
+	for _, end := range append(commas, closing) {
+		for _, alt := range Braces(s[start:end] + s[closing+1:]) {
+			out = append(out, s[:open]+alt)
+		}
+		start = end + 1
+	}
+	return out
+}
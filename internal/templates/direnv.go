@@ -0,0 +1,36 @@
+package templates
+
+// Direnv hooks direnv into the login shell and provisions per-project
+// .envrc files.
+//
+//	[direnv]
+//	[[direnv.project]]
+//	path = "~/Code/api"
+//	content = "layout python3"
+//	env = { RAILS_ENV = "development" }
+//	secrets = { DATABASE_PASSWORD = "pg-dev" }
+//
+// The shell hook is installed whenever a project is declared, or when hook
+// is true.
+type Direnv struct {
+	Hook     bool            `toml:"hook"`
+	Projects []DirenvProject `toml:"project"`
+}
+
+// DirenvProject is one directory whose .envrc maziq writes and allows.
+type DirenvProject struct {
+	Path string `toml:"path"`
+	// Content is copied to the top of .envrc. When Content, Env and Secrets
+	// are all empty, an existing .envrc (e.g. checked into the repo) is only
+	// allowed.
+	Content string            `toml:"content"`
+	Env     map[string]string `toml:"env"`
+	// Secrets maps variable names to secrets-provider names.
+	Secrets map[string]string `toml:"secrets"`
+	When    string            `toml:"when"`
+}
+
+// Managed reports whether maziq writes the project's .envrc.
+func (p DirenvProject) Managed() bool {
+	return p.Content != "" || len(p.Env) > 0 || len(p.Secrets) > 0
+}
@@ -0,0 +1,159 @@
+// Code generated by gen_docs.go; DO NOT EDIT.
+
+package templates
+
+var typeDocs = map[string]string{
+	"AWSProfile":     "AWSProfile is a named profile in ~/.aws/config.\n",
+	"AppStoreApp":    "AppStoreApp is a Mac App Store app installed with mas. ID is the numeric\nApp Store identifier from the app's store URL.\n\n\t[[mas]]\n\tname = \"Xcode\"\n\tid = 497799835\n",
+	"Azure":          "Azure holds Azure CLI defaults.\n",
+	"Binary":         "Binary is a single-binary tool installed from a GitHub release archive\ninto ~/.local/bin.\n\n\t[[binaries]]\n\tname = \"ripgrep\"\n\trepo = \"BurntSushi/ripgrep\"\n\tbin = \"rg\"\n\nBin is the executable inside the archive and defaults to Name. Without a\nversion the latest release is installed and kept up to date; asset is a\nregular expression for releases whose archive names MazIQ cannot match to\nmacOS and the machine's architecture on its own. Downloads are checked\nagainst the release's checksums file when it has one; pin further with\nsha256 or a signature, where {version} is the release tag.\n",
+	"Browser":        "Browser is the policy set for one browser.\n",
+	"Browsers":       "Browsers configures browser policies. Each browser's policy file is owned\nby MazIQ: it is rendered from the template and drift is reported when it\nis edited by hand.\n\n\t[browsers.chrome]\n\textensions = [\"cjpalhdlnbpafiamejdnhcphjbkeiagm\"]\n\tsearch = { name = \"DuckDuckGo\", url = \"https://duckduckgo.com/?q={searchTerms}\" }\n\n\t[browsers.firefox]\n\textensions = [{ id = \"uBlock0@raymondhill.net\", url = \"https://addons.mozilla.org/firefox/downloads/latest/ublock-origin/latest.xpi\" }]\n",
+	"Cloud":          "Cloud configures the non-secret side of cloud CLI accounts: AWS profiles,\ngcloud configurations and Azure defaults. Signing in stays interactive;\napply prints the login command for each account.\n\n\t[[cloud.aws]]\n\tprofile = \"dev\"\n\tregion = \"eu-west-1\"\n\tsso_start_url = \"https://acme.awsapps.com/start\"\n\tsso_region = \"eu-west-1\"\n\tsso_account_id = \"123456789012\"\n\tsso_role_name = \"Developer\"\n\n\t[[cloud.gcloud]]\n\tconfiguration = \"acme-dev\"\n\tproject = \"acme-dev\"\n\taccount = \"me@acme.com\"\n\tregion = \"europe-west1\"\n\n\t[cloud.azure]\n\tsubscription = \"00000000-0000-0000-0000-000000000000\"\n\tlocation = \"westeurope\"\n",
+	"Compat":         "Compat records which macOS major versions honour a preference key. Since\nand Until are inclusive; zero leaves that end open. Keys without an entry\nare assumed to work everywhere.\n",
+	"Database":       "Database is a post-install recipe for a local development database.\n\n\t[[databases]]\n\tengine = \"postgres\"\n\tservice = \"postgresql@16\"\n\trole = \"dev\"\n\tpassword_secret = \"pg-dev\"\n\tdatabase = \"app_dev\"\n\n\t[[databases]]\n\tengine = \"redis\"\n\tservice = \"redis\"\n\tconfig = { maxmemory = \"268435456\", maxmemory-policy = \"allkeys-lru\" }\n\n\t[[databases]]\n\tengine = \"mysql\"\n\tservice = \"mysql\"\n\troot_password_secret = \"mysql-root\"\n\trole = \"dev\"\n\tdatabase = \"app_dev\"\n",
+	"Default":        "Default is a raw `defaults write`. Value may be a string, integer, float,\nboolean or datetime, or an array or table of them nested to any depth; the\ndefaults type follows the TOML type, and tables become dictionaries.\n\n\t[[defaults]]\n\tdomain = \"com.apple.dock\"\n\tkey = \"autohide\"\n\tvalue = true\n\trestart = \"Dock\"\n\n\t[[defaults]]\n\tdomain = \"com.apple.finder\"\n\tkey = \"FK_StandardViewSettings\"\n\tvalue = { IconViewSettings = { iconSize = 64, arrangeBy = \"name\" } }\n\nAn entry with Group instead of Domain and Key enables a curated\nSettingGroup:\n\n\t[[defaults]]\n\tgroup = \"finder-power-user\"\n",
+	"Demo":           "Demo makes a template a demo profile, for conference machines and loaner\nlaptops: apply records how to take back every change it makes, refuses\nchanges it could not take back, and `maziq demo reset` returns the\nmachine to how it was.\n\n\t[demo]\n\treset_after = \"8h\"\n",
+	"DirectApp":      "DirectApp is an app that is in neither Homebrew nor the App Store,\ninstalled straight from the vendor's dmg, pkg or zip.\n\n\t[[apps]]\n\tname = \"Example\"\n\turl = \"https://example.com/downloads/Example-{version}.dmg\"\n\tapp = \"Example.app\"\n\tversion_url = \"https://example.com/downloads/latest.json\"\n\tversion_key = \"version\"\n\nApp is the bundle the download provides and that ends up in\n/Applications; pkgs that install no app name their receipt with pkg_id\ninstead. When version_url is set, the installed version is compared with\nthe one it reports and apply upgrades; {version} in url and signature is\nreplaced with it. The response is the bare version, or JSON with the\nversion under version_key (dots descend into objects).\n",
+	"Directory":      "Directory is part of the workspace skeleton: directories created on\napply, optionally kept out of iCloud Drive and Spotlight. Path takes\nshell-style braces for several siblings at once.\n\n\t[[directories]]\n\tpath = \"~/code/{work,oss,scratch}\"\n\texclude_icloud = true\n\texclude_spotlight = true\n\n\t[[directories]]\n\tpath = \"~/Screenshots\"\n",
+	"Direnv":         "Direnv hooks direnv into the login shell and provisions per-project\n.envrc files.\n\n\t[direnv]\n\t[[direnv.project]]\n\tpath = \"~/Code/api\"\n\tcontent = \"layout python3\"\n\tenv = { RAILS_ENV = \"development\" }\n\tsecrets = { DATABASE_PASSWORD = \"pg-dev\" }\n\nThe shell hook is installed whenever a project is declared, or when hook\nis true.\n",
+	"DirenvProject":  "DirenvProject is one directory whose .envrc maziq writes and allows.\n",
+	"Energy":         "Energy holds pmset settings per power source. Values are minutes for the\ntimers (0 disables) and booleans or 0/1 for switches.\n\n\t[energy.charger]\n\tsleep = 0          # clamshell desk setup: never sleep on power\n\tdisplaysleep = 15\n\n\t[energy.battery]\n\tpowernap = false\n",
+	"Entry":          "Entry is a software item in a template. In TOML it is either a bare catalog\nID or an inline table with an optional `when` condition:\n\n\tsoftware = [\"go\", { id = \"rosetta\", when = 'arch == \"arm64\"' }]\n\nThe table form can also install the entry from another package on Intel\nor Apple silicon Macs, for software whose builds are separate packages:\n\n\tsoftware = [{ id = \"foo\", intel = { cask = \"foo-intel\" } }]\n",
+	"Extension":      "Extension is a force-installed extension: a bare ID or a table with an\nexplicit update/download URL.\n",
+	"Field":          "Field is a key inside a section. Keys of nested tables are dotted.\n",
+	"File":           "File is a configuration file maziq owns outside the sections that render\ntheir own, such as a tool's config in ~/.config or a file under /etc.\n\n\t[[files]]\n\tpath = \"~/.config/ripgrep/config\"\n\tcontent = \"--smart-case\\n--hidden\\n\"\n\n\t[[files]]\n\tpath = \"/etc/paths.d/20-${user}\"\n\tsource = \"files/paths\"\n\tmode = \"0644\"\n\towner = \"root:wheel\"\n\n\t[[files]]\n\tpath = \"~/.npmrc\"\n\tstate = \"absent\"\n\nThe content is given inline or read from Source, a path relative to the\ntemplate file; ${name} references in either are expanded. A file with an\nOwner is written with sudo.\n",
+	"Finding":        "Finding is a single lint result.\n",
+	"Font":           "Font installs a font family into ~/Library/Fonts from exactly one source:\na Homebrew font cask, a direct URL (a font file or a zip of them) or local\nfiles.\n\n\t[[fonts]]\n\tname = \"JetBrains Mono\"\n\tcask = \"font-jetbrains-mono\"\n\tpostscript = [\"JetBrainsMono-Regular\"]\n\nPostScript names, when given, make the installed check exact and let\nMazIQ skip fonts that are already installed under another file name. URL\ndownloads can be pinned with sha256 and a signature (see Integrity).\n",
+	"GCloudConfig":   "GCloudConfig is a named gcloud configuration.\n",
+	"Group":          "Group names a set of resources that are turned on and off together,\nwithout removing them from the template. Resources are named like in\n[[order]] rules:\n\n\t[[group]]\n\tname = \"apps\"\n\tdescription = \"App Store and downloaded apps\"\n\tresources = [\"mas:*\", \"app:*\"]\n\tenabled = false\n\n\t[[group]]\n\tname = \"security tooling\"\n\tresources = [\"software:gnupg\", \"software:minisign\"]\n\tprofiles = [\"work\"]\n\nA group with profiles is on only when the profile in config.toml (or\n--profile) is one of them. A resource that only disabled groups name is\nleft out of the plan.\n",
+	"GroupSetting":   "GroupSetting is one preference in a SettingGroup.\n",
+	"HotCorner":      "HotCorner is the action of one corner and the modifier keys it needs. In\nTOML it is either the action name or a table with action and modifier.\n",
+	"ITerm2":         "ITerm2 holds iTerm2 dynamic profile settings.\n",
+	"Integrity":      "Integrity pins a file downloaded from a URL. Sections that fetch from URLs\nembed it, so the keys sit next to the url:\n\n\t[[fonts]]\n\tname = \"Berkeley Mono\"\n\turl = \"https://example.com/berkeley-mono.zip\"\n\tsha256 = \"9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08\"\n\tsignature = \"https://example.com/berkeley-mono.zip.minisig\"\n\tminisign_key = \"RWQf6LRCGA9i53mlYecO4IzT51TGPpvWucNSCh1CBM0QTaLn73Y7GFO3\"\n",
+	"Job":            "Job is a recurring command, run by a launchd agent that maziq writes and\nloads.\n\n\t[[jobs]]\n\tname = \"backup\"\n\trun = \"~/bin/backup.sh\"\n\tschedule = \"nightly\"\n\n\t[[jobs]]\n\tname = \"brew-cleanup\"\n\trun = \"brew cleanup --prune=30\"\n\tschedule = \"0 10 * * sun\"\n\nSchedule is a cron expression (minute hour day month weekday) or one of\nhourly, daily, nightly (02:00), weekly, monthly and yearly. Run is a bash\ncommand run in a login shell; its output goes to\n~/Library/Logs/maziq/jobs/<name>.log.\n",
+	"Karabiner":      "Karabiner configures Karabiner-Elements.\n\n\t[karabiner]\n\tconfig = \"~/dotfiles/karabiner\"     # linked as ~/.config/karabiner\n\n\t[[karabiner.rule]]\n\tdescription = \"Caps Lock to Escape\"\n\tmanipulators = [{ type = \"basic\", from = { key_code = \"caps_lock\" }, to = [{ key_code = \"escape\" }] }]\n\nRules are complex modifications in Karabiner's own JSON shape. They are\nwritten to assets/complex_modifications/maziq.json; enabling them is a\nmanual step in the Karabiner-Elements window.\n",
+	"KubeContext":    "KubeContext is one context to merge.\n",
+	"Kubernetes":     "Kubernetes merges cluster contexts into ~/.kube/config.\n\n\t[[kubernetes.context]]\n\tname = \"staging\"\n\tfile = \"~/Downloads/staging.kubeconfig\"\n\tnamespace = \"api\"\n\n\t[[kubernetes.context]]\n\tname = \"prod\"\n\tsecret = \"kubeconfig-prod\"\n\tcurrent = true\n\nThe kubeconfig comes from File or, for credentials that should not sit on\ndisk, from the secrets provider under Secret. It must define a context\ncalled Name.\n",
+	"License":        "License places a license file and/or runs an activation command for an app.\n\n\t[[licenses]]\n\tname = \"sublime-text\"\n\tsoftware = \"sublime_text\"\n\tsecret = \"sublime-license\"\n\tfile = \"~/Library/Application Support/Sublime Text/Local/License.sublime_license\"\n\tverify = \"defaults read com.sublimetext.4 license\"\n\texpect = \"registered\"\n\nThe license value is read from the secrets provider under Secret and is\navailable to Content, Activate and Verify as ${secret}; commands get it\nthrough $MAZIQ_SECRET. When Content is empty the file receives the secret\nvalue verbatim.\n",
+	"Manual":         "Manual is a step maziq cannot automate. Apply pauses on it, shows the\ninstructions, opens Settings or Open if set, and continues once the user\nconfirms or Verify passes.\n\n\t[[manual]]\n\tname = \"app-store-sign-in\"\n\tinstructions = \"Sign in to the App Store with your Apple ID.\"\n\topen = \"macappstore://\"\n\tverify = \"mas account\"\n\n\t[[manual]]\n\tname = \"terminal-full-disk-access\"\n\tinstructions = \"Allow your terminal under Full Disk Access.\"\n\tsettings = \"full-disk-access\"\n\nWithout Verify a step counts as done once confirmed, and maziq remembers\nthat.\n",
+	"MenuBar":        "MenuBar chooses which Control Center modules have their own menu bar\nitem. Control Center is relaunched after a change.\n\n\t[menubar]\n\tbattery_percentage = true\n\tshow = [\"bluetooth\", \"sound\"]\n\thide = [\"now-playing\"]\n",
+	"Network":        "Network configures one network service (as listed by `networksetup\n-listallnetworkservices`). Unset fields are left alone; an empty list\nclears the setting and a proxy of \"off\" disables it.\n\n\t[[network]]\n\tservice = \"Wi-Fi\"\n\tdns = [\"10.0.0.53\", \"1.1.1.1\"]\n\tsearch_domains = [\"corp.example.com\"]\n\tweb_proxy = \"proxy.corp.example.com:8080\"\n\tproxy_bypass = [\"*.local\", \"169.254/16\"]\n",
+	"Order":          "Order puts resources in order beyond what they require themselves, e.g.\nso repositories are cloned once the SSH config is written. Resources are\nnamed by the IDs `maziq plan` shows, with ~ for the home directory and *\nmatching any run of characters:\n\n\t[[order]]\n\tresource = \"repo:~/src/*\"\n\trequires = [\"file:~/.ssh/config\"]\n\n\t[[order]]\n\tresource = \"dotfile:~/.zshrc\"\n\tbefore = [\"software:zsh_*\"]\n\nRequires makes the matched resources wait for the required ones, and\nskips them when one fails. Before is the same from the other side: the\nlisted resources require the matched ones.\n",
+	"Platform":       "Platform is an entry of the E2E test matrix: a kind of machine the\ntemplate is meant to work on, described by its facts. `maziq test\n--matrix` works out which assertions apply on each platform, runs those of\nthe platforms the current machine is, and reports which are untested\nwhere.\n\n\t[[matrix]]\n\tname = \"macos-13 x86_64\"\n\tfacts = { macos = \"13\", arch = \"amd64\" }\n\n\t[[matrix]]\n\tname = \"macos-14 arm64\"\n\tfacts = { macos = \"14\", arch = \"arm64\" }\n",
+	"Printer":        "Printer is a CUPS print queue added with lpadmin.\n\n\t[[printers]]\n\tname = \"Office_LaserJet\"\n\taddress = \"ipp://10.0.0.40/ipp/print\"\n\tdriver = \"everywhere\"\n\tlocation = \"2nd floor\"\n\tdefault = true\n\nDriver is \"everywhere\" for driverless IPP printers, a model from\n`lpinfo -m`, or a path to a PPD file.\n",
+	"Proxy":          "Proxy routes MazIQ and the package managers it runs through a corporate\nproxy and artifact mirrors, in place of exporting the variables by hand.\nSet fields become the usual environment variables for every command\napply runs and for MazIQ's own downloads; unset ones are left as the\nshell had them.\n\n\t[proxy]\n\thttps = \"http://proxy.corp.example.com:8080\"\n\tno_proxy = [\".corp.example.com\", \"localhost\"]\n\tbottle_domain = \"https://artifacts.corp.example.com/homebrew-bottles\"\n\tapi_domain = \"https://artifacts.corp.example.com/homebrew-api\"\n\tnpm_registry = \"https://artifacts.corp.example.com/npm/\"\n",
+	"Region":         "Region sets the time zone and the formats System Settings → General →\nLanguage & Region controls.\n\n\t[region]\n\ttimezone = \"Europe/Berlin\"\n\tlocale = \"en_DE\"            # language_COUNTRY, as in AppleLocale\n\tmeasurement = \"metric\"      # metric or us\n\tfirst_weekday = \"monday\"\n",
+	"Repo":           "Repo is one git repository.\n",
+	"Repos":          "Repos clones project repositories into a workspace directory.\n\n\t[repos]\n\tworkspace = \"~/Code\"\n\tssh_key = \"~/.ssh/id_ed25519\"\n\n\t[[repos.repo]]\n\turl = \"git@github.com:acme/api.git\"\n\tbootstrap = \"make setup\"\n\n\t[[repos.repo]]\n\turl = \"https://github.com/acme/docs.git\"\n\tpath = \"acme-docs\"\n\tbranch = \"main\"\n",
+	"Screensaver":    "Screensaver configures the screen saver, screen lock and hot corners.\n\n\t[screensaver]\n\tidle = 600                 # seconds; 0 never starts it\n\trequire_password = true\n\tpassword_delay = 0         # seconds after sleep or screen saver\n\thot_corners = { bottom_right = \"lock-screen\", top_left = \"screen-saver\" }\n\nA corner that should only fire while keys are held takes a table:\n\n\t[screensaver.hot_corners]\n\ttop_right = { action = \"desktop\", modifier = \"command+option\" }\n",
+	"Screenshots":    "Screenshots configures where and how screenshots and screen recordings are\nsaved.\n\n\t[screenshots]\n\tformat = \"png\"\n\tlocation = \"~/Pictures/Screenshots\"\n\tshadow = false\n\tthumbnail = false\n",
+	"Search":         "Search is the default search engine. URL uses {searchTerms} as the query\nplaceholder in both Chrome and Firefox.\n",
+	"SearchPath":     "SearchPath puts directories at the front of PATH, in order, from the same\nmanaged rc block as [env]. An entry is a directory or the name of one\nthat tools install their commands into:\n\n\t[path]\n\torder = [\"brew\", \"cargo\", \"go\", \"~/bin\"]\n\nNames are brew (Homebrew's bin and sbin), cargo, go, bun, local\n(~/.local/bin, where uv and pipx put tools) and system (macOS's own).\n",
+	"Section":        "Section is a top-level key of a template file. Its keys and types are read\nfrom the same struct tags the decoder uses to reject unknown keys, and its\nprose and example from the doc comments of the Go types, so explain and the\nmanual cannot drift from what Parse accepts.\n",
+	"Service":        "Service is a Homebrew formula service managed with `brew services`.\n\n\t[[services]]\n\tname = \"postgresql@16\"\n\n\t[[services]]\n\tname = \"redis\"\n\tstate = \"stopped\"\n",
+	"SettingGroup":   "SettingGroup is a curated bundle of preferences that is enabled as a whole\nwith `[[defaults]] group = \"<name>\"` or from the Configuration screen.\n",
+	"Skhd":           "Skhd configures the skhd hotkey daemon.\n\n\t[skhd]\n\thotkeys = { \"alt - h\" = \"yabai -m window --focus west\" }\n\nConfig, when set, is linked as ~/.config/skhd/skhdrc and Hotkeys is\nignored.\n",
+	"Sound":          "Sound sets the alert volume, interface sound effects and the startup\nchime.\n\n\t[sound]\n\talert_volume = 50          # percent of the output volume\n\tui_sounds = false          # Finder, trash and screenshot sounds\n\tvolume_feedback = false    # beep when the volume keys are pressed\n\tstartup_chime = false\n",
+	"Spotlight":      "Spotlight keeps directories and volumes out of the Spotlight index.\n\n\t[spotlight]\n\texclude = [\"~/code\", \"~/VMs\"]\n\tdisable_volumes = [\"/Volumes/Backup\"]\n",
+	"Template":       "Template is a parsed template file: the software to provision plus the\nvariables, conditions and tests that go with it.\n",
+	"Terminal":       "Terminal renders one look across terminal emulators. Font and colors are\nshared; keybindings and extra settings are per emulator because every\nemulator names its actions differently.\n\n\t[terminal]\n\temulators = [\"ghostty\", \"kitty\"]\n\tfont = \"JetBrains Mono\"\n\tfont_size = 14\n\n\t[terminal.colors]\n\tbackground = \"#1e1e2e\"\n\tforeground = \"#cdd6f4\"\n\tpalette = [\"#45475a\", \"#f38ba8\", ...]   # 16 ANSI colors\n\n\t[terminal.ghostty]\n\tkeybindings = { \"cmd+d\" = \"new_split:right\" }\n",
+	"TerminalApp":    "TerminalApp holds per-emulator settings.\n",
+	"TerminalColors": "TerminalColors is a color scheme as #rrggbb values.\n",
+	"Test":           "Test is an E2E assertion run after provisioning.\n",
+	"Tmux":           "Tmux links a tmux config and installs TPM plugins.\n\n\t[tmux]\n\tconfig = \"~/dotfiles/tmux.conf\"\n\tplugins = [\"tmux-plugins/tmux-sensible\", \"tmux-plugins/tmux-resurrect\"]\n\nConfig is linked as ~/.tmux.conf. Plugins are declared in a managed\n~/.tmux/plugins.conf, which the config should load with\n`source-file ~/.tmux/plugins.conf`.\n",
+	"Trackpad":       "Trackpad configures the trackpad and mouse. Pointer settings are read at\nlogin, so a change asks to log out.\n\n\t[trackpad]\n\ttap_to_click = true\n\ttracking_speed = 2.0        # 0 to 3, as the System Settings slider\n\tnatural_scrolling = false\n\tthree_finger_drag = true\n\tsecondary_click = \"two-finger\"\n",
+	"Updates":        "Updates sets the Software Update policy and can install pending updates.\n\n\t[updates]\n\tautomatic_check = true\n\tautomatic_download = true\n\tinstall_macos = false\n\tinstall_security = true\n\tinstall = true\n\tdefer_days = 7\n\treboot = \"prompt\"\n",
+	"WiFi":           "WiFi is a preferred wireless network. Networks are added to the top of the\npreferred list in template order, so the first entry is joined first. The\npassword comes from the secrets provider, never from the template.\n\n\t[[wifi]]\n\tssid = \"Office\"\n\tsecret = \"office-wifi\"\n",
+	"Yabai":          "Yabai configures the yabai window manager.\n\n\t[yabai]\n\tsettings = { layout = \"bsp\", window_gap = 8 }\n\trules = ['app=\"^System Settings$\" manage=off']\n\tscripting_addition = true\n\nConfig, when set, is linked as ~/.config/yabai/yabairc and Settings and\nRules are ignored.\n",
+}
+
+var fieldDocs = map[string]string{
+	"AWSProfile.RoleARN":          "Assume-role settings.\n",
+	"AWSProfile.SSOStartURL":      "IAM Identity Center (SSO) settings.\n",
+	"Azure.Subscription":          "Subscription is selected once you are logged in.\n",
+	"Browser.Policies":            "Policies are passed through verbatim for anything not modelled above.\n",
+	"Compat.Note":                 "Note points at what to use instead, when there is something.\n",
+	"Database.Config":             "Config holds Redis CONFIG SET values, persisted with CONFIG REWRITE.\nValues are compared with CONFIG GET, so write them the way Redis\nreports them (memory sizes in bytes).\n",
+	"Database.Role":               "Role and Database are created (Postgres, MySQL) when set; the role\nowns the database.\n",
+	"Database.RootPasswordSecret": "RootPasswordSecret secures a fresh MySQL install the way\nmysql_secure_installation does.\n",
+	"Database.Service":            "Service is the brew service that must be running first.\n",
+	"Default.CurrentHost":         "CurrentHost writes the per-host (ByHost) preferences.\n",
+	"Default.Logout":              "Logout marks keys that only take effect after logging out.\n",
+	"Default.Restart":             "Restart names a process to killall after writing, e.g. \"Dock\".\n",
+	"Demo.ResetAfter":             "ResetAfter, when set, has the daemon reset the machine this long\nafter the first demo apply.\n",
+	"Directory.ExcludeICloud":     "ExcludeICloud keeps the directory out of iCloud Drive when it sits\nin Desktop or Documents.\n",
+	"Directory.ExcludeSpotlight":  "ExcludeSpotlight adds the directory to Spotlight's privacy list.\n",
+	"Directory.Mode":              "Mode is octal permission bits; unset leaves new directories at 0755\nand existing ones alone. Setuid, setgid and sticky bits are not\nsupported.\n",
+	"DirenvProject.Content":       "Content is copied to the top of .envrc. When Content, Env and Secrets\nare all empty, an existing .envrc (e.g. checked into the repo) is only\nallowed.\n",
+	"DirenvProject.Secrets":       "Secrets maps variable names to secrets-provider names.\n",
+	"Entry.Arch":                  "Arch replaces the catalog's sources on one architecture, \"amd64\"\n(intel in TOML) or \"arm64\" (arm).\n",
+	"Entry.Origin":                "Origin records where a merged entry came from, e.g. OriginBaseline.\n",
+	"File.Mode":                   "Mode is octal permission bits; the default is 0644. Setuid, setgid\nand sticky bits are not supported.\n",
+	"File.Owner":                  "Owner is user or user:group.\n",
+	"Finding.Where":               "Where locates the offending item, e.g. `software[3]` or `tests[\"rust\"]`.\n",
+	"GCloudConfig.Activate":       "Activate makes this the active configuration.\n",
+	"Group.Enabled":               "Enabled defaults to true.\n",
+	"HotCorner.Modifier":          "Modifier is shift, control, option or command, or several joined\nwith +; empty fires without a key.\n",
+	"ITerm2.Extra":                "Extra holds raw profile keys such as \"Keyboard Map\".\n",
+	"ITerm2.Font":                 "Font is the PostScript font name, e.g. \"JetBrainsMono-Regular\";\nderived from the shared font when empty.\n",
+	"ITerm2.Profile":              "Profile is the dynamic profile name; it defaults to \"maziq\".\n",
+	"Integrity.GPGKey":            "GPGKey is the full 40-digit fingerprint of the primary key that must\nhave made the GPG signature; key IDs are refused. The key has to be in\nthe user's keyring.\n",
+	"Integrity.MinisignKey":       "MinisignKey is the public key the minisign signature must verify with.\n",
+	"Integrity.SHA256":            "SHA256 is the hex digest the download must have.\n",
+	"Integrity.Signature":         "Signature is the URL of a detached minisign or GPG signature.\n",
+	"Karabiner.Config":            "Config is a directory linked as ~/.config/karabiner. Karabiner rewrites\nkarabiner.json in place, so the whole directory is linked rather than\nthe file.\n",
+	"KubeContext.Current":         "Current makes this the active context.\n",
+	"KubeContext.Verify":          "Verify adds a cluster reachability check to `maziq test`; it is on\nunless set to false.\n",
+	"Manual.Open":                 "Open is a URL or path handed to `open`.\n",
+	"Manual.Settings":             "Settings names a System Settings pane (see `maziq settings`).\n",
+	"MenuBar.Show":                "Show and Hide name items: bluetooth, sound, wifi, display, focus,\nnow-playing, airdrop or screen-mirroring.\n",
+	"Platform.Facts":              "Facts are the values the platform has; keys are facts or template\nvars, and anything not set is taken from the current machine.\n",
+	"Proxy.BottleDomain":          "BottleDomain, APIDomain and ArtifactDomain point Homebrew's bottle,\nJSON API and every other download at mirrors.\n",
+	"Proxy.BrewGitRemote":         "BrewGitRemote and CoreGitRemote are mirrors of Homebrew's own\nrepositories, for installs that cannot reach GitHub.\n",
+	"Proxy.HTTP":                  "HTTP and HTTPS are the proxies for plain and TLS requests.\n",
+	"Region.Timezone":             "Timezone is a zoneinfo name. Setting it turns off \"Set time zone\nautomatically using your current location\", which would change it\nback.\n",
+	"Repo.Bootstrap":              "Bootstrap runs with bash inside the fresh clone.\n",
+	"Repo.Path":                   "Path is where to clone, relative to the workspace; it defaults to the\nrepository name.\n",
+	"Repos.SSHKey":                "SSHKey must exist before SSH URLs are cloned. When empty any of the\nusual ~/.ssh/id_* keys will do.\n",
+	"Repos.Workspace":             "Workspace is the parent directory for relative repo paths.\n",
+	"Screensaver.PasswordSecret":  "PasswordSecret names the secret holding the login password, which\nsysadminctl needs to change the screen lock. Default \"login-password\".\n",
+	"Screenshots.Format":          "Format is the image type: png, jpg, heic, pdf, tiff, gif or bmp.\n",
+	"Screenshots.Location":        "Location is the folder captures are saved to; it is created if missing.\n",
+	"Screenshots.Name":            "Name replaces the \"Screenshot\" file name prefix.\n",
+	"Screenshots.Shadow":          "Shadow keeps the drop shadow on window captures.\n",
+	"Screenshots.ShowClicks":      "ShowClicks draws mouse clicks in screen recordings.\n",
+	"Screenshots.Thumbnail":       "Thumbnail shows the floating preview after a capture.\n",
+	"Section.Conditional":         "Conditional sections take a `when` condition per entry.\n",
+	"Section.Example":             "Example is TOML taken from the type's doc comment.\n",
+	"SettingGroup.Explain":        "Explain says in plain words what changes for the user.\n",
+	"SettingGroup.Unsafe":         "Unsafe marks groups that trade security or stability for convenience.\n",
+	"Sound.StartupChime":          "StartupChime is kept in NVRAM, which needs sudo to change.\n",
+	"Spotlight.DisableVolumes":    "DisableVolumes turns indexing off entirely with mdutil.\n",
+	"Spotlight.Exclude":           "Exclude adds directories to Spotlight's privacy list.\n",
+	"Template.Aliases":            "Aliases and Functions are defined in the managed rc block, after\n[env] and [path]. A function's body is in the login shell's syntax:\nmkcd = 'mkdir -p \"$1\" && cd \"$1\"'.\n",
+	"Template.Completions":        "Completions = false stops MazIQ installing the completion scripts of\nthe template's CLIs and loading them from the managed rc block.\n",
+	"Template.Description":        "Description is shown next to the name when choosing a template.\n",
+	"Template.Env":                "Env holds environment variables exported from the managed block of\nthe login shell's rc file, e.g. EDITOR = \"nvim\". Values may refer to\nvariables set before it, as in GOPATH = \"$HOME/go\".\n",
+	"Template.Handlers":           "Handlers maps file extensions, UTIs and URL schemes to the bundle ID\nof their default app.\n",
+	"Template.Name":               "Name identifies the template in the TUI and in reports.\n",
+	"Template.Path":               "Path is where the template was loaded from. Built-in templates use a\n\"builtin:\" prefix.\n",
+	"Template.Profile":            "Profile selects the groups that name profiles; see Group.\n",
+	"Template.Raw":                "Raw is the unparsed file contents.\n",
+	"Template.Shared":             "Shared is the template as committed, before the machine's LocalFile\nwas merged over it; nil when there is none.\n",
+	"Template.Toggles":            "Toggles turn groups on or off for one run, by name, over what the\ntemplate and profile say.\n",
+	"Template.Vars":               "Vars are referenced as ${name} in commands, paths and URLs, and as\nbare identifiers in `when` conditions.\n",
+	"TerminalApp.Extra":           "Extra is appended verbatim to the rendered config.\n",
+	"TerminalApp.Keybindings":     "Keybindings maps a key chord such as \"cmd+shift+t\" to the emulator's\naction.\n",
+	"Trackpad.MouseSpeed":         "MouseSpeed is the tracking speed of a mouse, 0 to 3.\n",
+	"Trackpad.NaturalScrolling":   "NaturalScrolling moves content with the fingers; it applies to mice\ntoo.\n",
+	"Trackpad.SecondaryClick":     "SecondaryClick is two-finger, bottom-right, bottom-left or off.\n",
+	"Trackpad.ThreeFingerDrag":    "ThreeFingerDrag is under Accessibility → Pointer Control. It moves\nthe three-finger swipes between spaces and to Mission Control to\nfour fingers, as System Settings does.\n",
+	"Updates.DeferDays":           "DeferDays holds an update back until it has been available this long.\n",
+	"Updates.Install":             "Install makes apply install pending updates.\n",
+	"Updates.InstallMacOS":        "InstallMacOS installs macOS updates automatically.\n",
+	"Updates.InstallSecurity":     "InstallSecurity installs security responses and system data files.\n",
+	"Updates.Reboot":              "Reboot is RebootNever (the default), RebootPrompt or RebootAllow.\n",
+	"WiFi.Interface":              "Interface is the Wi-Fi device, e.g. en0. Detected when empty.\n",
+	"Yabai.ScriptingAddition":     "ScriptingAddition loads yabai's Dock scripting addition, which needs\nSystem Integrity Protection partly disabled. maziq installs the\nsudoers entry; the SIP change is a guided manual step.\n",
+}
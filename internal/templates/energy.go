@@ -0,0 +1,53 @@
+package templates
+
+import "fmt"
+
+// Energy holds pmset settings per power source. Values are minutes for the
+// timers (0 disables) and booleans or 0/1 for switches.
+//
+//	[energy.charger]
+//	sleep = 0          # clamshell desk setup: never sleep on power
+//	displaysleep = 15
+//
+//	[energy.battery]
+//	powernap = false
+type Energy struct {
+	All     map[string]any `toml:"all"`
+	Battery map[string]any `toml:"battery"`
+	Charger map[string]any `toml:"charger"`
+}
+
+// Scopes returns the configured settings keyed by pmset scope name.
+func (e Energy) Scopes() map[string]map[string]any {
+	out := map[string]map[string]any{}
+	for name, m := range map[string]map[string]any{"all": e.All, "battery": e.Battery, "charger": e.Charger} {
+		if len(m) > 0 {
+			out[name] = m
+		}
+	}
+	return out
+}
+
+// PmsetKeys are the settings MazIQ knows how to manage.
+var PmsetKeys = []string{
+	"acwake", "autorestart", "disksleep", "displaysleep", "hibernatemode",
+	"lessbright", "lidwake", "lowpowermode", "powernap", "proximitywake",
+	"sleep", "standby", "tcpkeepalive", "ttyskeepawake", "womp",
+}
+
+// PmsetValue converts a template value to pmset's integer form.
+func PmsetValue(v any) (int64, error) {
+	switch v := v.(type) {
+	case bool:
+		if v {
+			return 1, nil
+		}
+		return 0, nil
+	case int64:
+		if v < 0 {
+			return 0, fmt.Errorf("must not be negative")
+		}
+		return v, nil
+	}
+	return 0, fmt.Errorf("must be a number or boolean, got %T", v)
+}
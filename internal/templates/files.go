@@ -0,0 +1,40 @@
+package templates
+
+// File is a configuration file maziq owns outside the sections that render
+// their own, such as a tool's config in ~/.config or a file under /etc.
+//
+//	[[files]]
+//	path = "~/.config/ripgrep/config"
+//	content = "--smart-case\n--hidden\n"
+//
+//	[[files]]
+//	path = "/etc/paths.d/20-${user}"
+//	source = "files/paths"
+//	mode = "0644"
+//	owner = "root:wheel"
+//
+//	[[files]]
+//	path = "~/.npmrc"
+//	state = "absent"
+//
+// The content is given inline or read from Source, a path relative to the
+// template file; ${name} references in either are expanded. A file with an
+// Owner is written with sudo.
+type File struct {
+	Path    string `toml:"path"`
+	Content string `toml:"content"`
+	Source  string `toml:"source"`
+	// Mode is octal permission bits; the default is 0644. Setuid, setgid
+	// and sticky bits are not supported.
+	Mode string `toml:"mode"`
+	// Owner is user or user:group.
+	Owner string `toml:"owner"`
+	State string `toml:"state"` // FilePresent (default) or FileAbsent
+	When  string `toml:"when"`
+}
+
+// File states.
+const (
+	FilePresent = "present"
+	FileAbsent  = "absent"
+)
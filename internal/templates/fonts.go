@@ -0,0 +1,36 @@
+package templates
+
+// Font installs a font family into ~/Library/Fonts from exactly one source:
+// a Homebrew font cask, a direct URL (a font file or a zip of them) or local
+// files.
+//
+//	[[fonts]]
+//	name = "JetBrains Mono"
+//	cask = "font-jetbrains-mono"
+//	postscript = ["JetBrainsMono-Regular"]
+//
+// PostScript names, when given, make the installed check exact and let
+// MazIQ skip fonts that are already installed under another file name. URL
+// downloads can be pinned with sha256 and a signature (see Integrity).
+type Font struct {
+	Name       string   `toml:"name"`
+	Cask       string   `toml:"cask"`
+	URL        string   `toml:"url"`
+	Path       string   `toml:"path"`
+	PostScript []string `toml:"postscript"`
+	When       string   `toml:"when"`
+	// Integrity pins URL downloads.
+	Integrity
+}
+
+// Source returns the kind of source the font declares and how many were set.
+func (f Font) Source() (string, int) {
+	kind, n := "", 0
+	for _, s := range []struct{ kind, value string }{{"cask", f.Cask}, {"url", f.URL}, {"path", f.Path}} {
+		if s.value != "" {
+			kind = s.kind
+			n++
+		}
+	}
+	return kind, n
+}
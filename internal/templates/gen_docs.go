@@ -0,0 +1,86 @@
+//go:build ignore
+
+// gen_docs.go copies the doc comments of the template types and their fields
+// into docs_gen.go, so `maziq explain` and the manual can show them at run
+// time. Run it with go generate after editing a comment.
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"log"
+	"os"
+	"sort"
+	"strings"
+)
+
+func main() {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, ".", func(fi os.FileInfo) bool {
+		return !strings.HasSuffix(fi.Name(), "_test.go") && fi.Name() != "docs_gen.go"
+	}, parser.ParseComments)
+	if err != nil {
+		log.Fatal(err)
+	}
+	types, fields := map[string]string{}, map[string]string{}
+	for _, f := range pkgs["templates"].Files {
+		for _, decl := range f.Decls {
+			gd, ok := decl.(*ast.GenDecl)
+			if !ok || gd.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range gd.Specs {
+				ts := spec.(*ast.TypeSpec)
+				st, ok := ts.Type.(*ast.StructType)
+				if !ok || !ts.Name.IsExported() {
+					continue
+				}
+				doc := ts.Doc
+				if doc == nil && len(gd.Specs) == 1 {
+					doc = gd.Doc
+				}
+				if doc != nil {
+					types[ts.Name.Name] = doc.Text()
+				}
+				for _, fd := range st.Fields.List {
+					if fd.Doc == nil {
+						continue
+					}
+					for _, name := range fd.Names {
+						fields[ts.Name.Name+"."+name.Name] = fd.Doc.Text()
+					}
+				}
+			}
+		}
+	}
+
+	var b bytes.Buffer
+	b.WriteString("// Code generated by gen_docs.go; DO NOT EDIT.\n\npackage templates\n\n")
+	writeMap(&b, "typeDocs", types)
+	b.WriteString("\n")
+	writeMap(&b, "fieldDocs", fields)
+	src, err := format.Source(b.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile("docs_gen.go", src, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func writeMap(b *bytes.Buffer, name string, m map[string]string) {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	fmt.Fprintf(b, "var %s = map[string]string{\n", name)
+	for _, k := range keys {
+		fmt.Fprintf(b, "\t%q: %q,\n", k, m[k])
+	}
+	b.WriteString("}\n")
+}
@@ -0,0 +1,42 @@
+package templates
+
+import "slices"
+
+// Group names a set of resources that are turned on and off together,
+// without removing them from the template. Resources are named like in
+// [[order]] rules:
+//
+//	[[group]]
+//	name = "apps"
+//	description = "App Store and downloaded apps"
+//	resources = ["mas:*", "app:*"]
+//	enabled = false
+//
+//	[[group]]
+//	name = "security tooling"
+//	resources = ["software:gnupg", "software:minisign"]
+//	profiles = ["work"]
+//
+// A group with profiles is on only when the profile in config.toml (or
+// --profile) is one of them. A resource that only disabled groups name is
+// left out of the plan.
+type Group struct {
+	Name        string   `toml:"name"`
+	Description string   `toml:"description"`
+	Resources   []string `toml:"resources"`
+	// Enabled defaults to true.
+	Enabled  *bool    `toml:"enabled"`
+	Profiles []string `toml:"profiles"`
+}
+
+// GroupOn reports whether g is on: as toggled for this run, else for the
+// profile when g names profiles, else as the template sets it.
+func (t *Template) GroupOn(g Group) bool {
+	if on, ok := t.Toggles[g.Name]; ok {
+		return on
+	}
+	if len(g.Profiles) > 0 {
+		return slices.Contains(g.Profiles, t.Profile)
+	}
+	return g.Enabled == nil || *g.Enabled
+}
@@ -0,0 +1,137 @@
+package templates
+
+import "sort"
+
+// SettingGroup is a curated bundle of preferences that is enabled as a whole
+// with `[[defaults]] group = "<name>"` or from the Configuration screen.
+type SettingGroup struct {
+	Name  string
+	Title string
+	// Explain says in plain words what changes for the user.
+	Explain string
+	// Unsafe marks groups that trade security or stability for convenience.
+	Unsafe   bool
+	Settings []GroupSetting
+}
+
+// GroupSetting is one preference in a SettingGroup.
+type GroupSetting struct {
+	Domain      string
+	Key         string
+	Value       any
+	CurrentHost bool
+	Restart     string
+	Logout      bool
+}
+
+// SettingGroups are the curated groups, keyed by name.
+var SettingGroups = map[string]SettingGroup{}
+
+func group(g SettingGroup) {
+	SettingGroups[g.Name] = g
+}
+
+// SettingGroupNames returns the curated group names sorted alphabetically.
+func SettingGroupNames() []string {
+	names := make([]string, 0, len(SettingGroups))
+	for name := range SettingGroups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func init() {
+	const global = "NSGlobalDomain"
+	dock := func(key string, value any) GroupSetting {
+		return GroupSetting{Domain: "com.apple.dock", Key: key, Value: value, Restart: "Dock"}
+	}
+	finder := func(key string, value any) GroupSetting {
+		return GroupSetting{Domain: "com.apple.finder", Key: key, Value: value, Restart: "Finder"}
+	}
+	capture := func(key string, value any) GroupSetting {
+		return GroupSetting{Domain: "com.apple.screencapture", Key: key, Value: value, Restart: "SystemUIServer"}
+	}
+
+	group(SettingGroup{
+		Name:    "dock-tweaks",
+		Title:   "Dock tweaks",
+		Explain: "Hides the Dock until you point at it, with no delay, drops the recent apps section and minimizes windows into their app icon.",
+		Settings: []GroupSetting{
+			dock("autohide", true),
+			dock("autohide-delay", 0.0),
+			dock("show-recents", false),
+			dock("mineffect", "scale"),
+			dock("minimize-to-application", true),
+		},
+	})
+	group(SettingGroup{
+		Name:    "finder-power-user",
+		Title:   "Finder power user",
+		Explain: "Shows every file extension, hidden files, the path and status bars and the full path in window titles; searches the current folder and stops asking before you change an extension.",
+		Settings: []GroupSetting{
+			{Domain: global, Key: "AppleShowAllExtensions", Value: true, Restart: "Finder"},
+			finder("AppleShowAllFiles", true),
+			finder("ShowPathbar", true),
+			finder("ShowStatusBar", true),
+			finder("_FXShowPosixPathInTitle", true),
+			finder("FXDefaultSearchScope", "SCcf"),
+			finder("FXEnableExtensionChangeWarning", false),
+		},
+	})
+	group(SettingGroup{
+		Name:    "developer-keyboard",
+		Title:   "Developer keyboard",
+		Explain: "Fast key repeat instead of the accent popup, no autocorrect, smart quotes, smart dashes or auto-capitalization, and Tab moves between all controls. Key repeat changes apply after logging out.",
+		Settings: []GroupSetting{
+			{Domain: global, Key: "KeyRepeat", Value: int64(2), Logout: true},
+			{Domain: global, Key: "InitialKeyRepeat", Value: int64(15), Logout: true},
+			{Domain: global, Key: "ApplePressAndHoldEnabled", Value: false},
+			{Domain: global, Key: "NSAutomaticSpellingCorrectionEnabled", Value: false},
+			{Domain: global, Key: "NSAutomaticQuoteSubstitutionEnabled", Value: false},
+			{Domain: global, Key: "NSAutomaticDashSubstitutionEnabled", Value: false},
+			{Domain: global, Key: "NSAutomaticCapitalizationEnabled", Value: false},
+			{Domain: global, Key: "NSAutomaticPeriodSubstitutionEnabled", Value: false},
+			{Domain: global, Key: "AppleKeyboardUIMode", Value: int64(3)},
+		},
+	})
+	on := true
+	group(SettingGroup{
+		Name:     "trackpad-essentials",
+		Title:    "Trackpad essentials",
+		Explain:  "Tap to click, click with two fingers for the secondary click, and drag with three fingers, which moves switching spaces to four-finger swipes. Applies after logging out.",
+		Settings: Trackpad{TapToClick: &on, ThreeFingerDrag: &on, SecondaryClick: "two-finger"}.Settings(),
+	})
+	group(SettingGroup{
+		Name:     "menu-bar-status",
+		Title:    "Menu bar status",
+		Explain:  "Shows the battery percentage, and Bluetooth and Sound as their own menu bar items instead of only in Control Center.",
+		Settings: MenuBar{BatteryPercentage: &on, Show: []string{"bluetooth", "sound"}}.Settings(),
+	})
+	off, half := false, int64(50)
+	group(SettingGroup{
+		Name:     "quiet-sounds",
+		Title:    "Quiet sounds",
+		Explain:  "Turns off interface sound effects, such as emptying the trash, and the beep when you change the volume, and plays alerts at half volume.",
+		Settings: Sound{AlertVolume: &half, UISounds: &off, VolumeFeedback: &off}.Settings(),
+	})
+	group(SettingGroup{
+		Name:    "clean-screenshots",
+		Title:   "Clean screenshots",
+		Explain: "Saves screenshots as PNG without window shadows and skips the floating thumbnail, so the file lands on disk immediately.",
+		Settings: []GroupSetting{
+			capture("type", "png"),
+			capture("disable-shadow", true),
+			capture("show-thumbnail", false),
+		},
+	})
+	group(SettingGroup{
+		Name:    "no-quarantine-prompt",
+		Title:   "No download warning",
+		Explain: "Stops macOS asking whether you really want to open an app downloaded from the internet. Malware gets the same free pass.",
+		Unsafe:  true,
+		Settings: []GroupSetting{
+			{Domain: "com.apple.LaunchServices", Key: "LSQuarantine", Value: false},
+		},
+	})
+}
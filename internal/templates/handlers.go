@@ -0,0 +1,26 @@
+package templates
+
+import "strings"
+
+// HandlerKind classifies a default-handler key.
+type HandlerKind string
+
+// Handler key kinds.
+const (
+	HandlerExtension HandlerKind = "extension" // ".md"
+	HandlerUTI       HandlerKind = "uti"       // "public.plain-text"
+	HandlerScheme    HandlerKind = "scheme"    // "mailto"
+)
+
+// HandlerKindOf classifies a `[handlers]` key: a leading dot is a file
+// extension, any other dot a uniform type identifier, and anything else a
+// URL scheme.
+func HandlerKindOf(key string) HandlerKind {
+	switch {
+	case strings.HasPrefix(key, "."):
+		return HandlerExtension
+	case strings.Contains(key, "."):
+		return HandlerUTI
+	}
+	return HandlerScheme
+}
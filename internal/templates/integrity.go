@@ -0,0 +1,23 @@
+package templates
+
+// Integrity pins a file downloaded from a URL. Sections that fetch from URLs
+// embed it, so the keys sit next to the url:
+//
+//	[[fonts]]
+//	name = "Berkeley Mono"
+//	url = "https://example.com/berkeley-mono.zip"
+//	sha256 = "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+//	signature = "https://example.com/berkeley-mono.zip.minisig"
+//	minisign_key = "RWQf6LRCGA9i53mlYecO4IzT51TGPpvWucNSCh1CBM0QTaLn73Y7GFO3"
+type Integrity struct {
+	// SHA256 is the hex digest the download must have.
+	SHA256 string `toml:"sha256"`
+	// Signature is the URL of a detached minisign or GPG signature.
+	Signature string `toml:"signature"`
+	// MinisignKey is the public key the minisign signature must verify with.
+	MinisignKey string `toml:"minisign_key"`
+	// GPGKey is the full 40-digit fingerprint of the primary key that must
+	// have made the GPG signature; key IDs are refused. The key has to be in
+	// the user's keyring.
+	GPGKey string `toml:"gpg_key"`
+}
@@ -0,0 +1,25 @@
+package templates
+
+// Job is a recurring command, run by a launchd agent that maziq writes and
+// loads.
+//
+//	[[jobs]]
+//	name = "backup"
+//	run = "~/bin/backup.sh"
+//	schedule = "nightly"
+//
+//	[[jobs]]
+//	name = "brew-cleanup"
+//	run = "brew cleanup --prune=30"
+//	schedule = "0 10 * * sun"
+//
+// Schedule is a cron expression (minute hour day month weekday) or one of
+// hourly, daily, nightly (02:00), weekly, monthly and yearly. Run is a bash
+// command run in a login shell; its output goes to
+// ~/Library/Logs/maziq/jobs/<name>.log.
+type Job struct {
+	Name     string `toml:"name"`
+	Run      string `toml:"run"`
+	Schedule string `toml:"schedule"`
+	When     string `toml:"when"`
+}
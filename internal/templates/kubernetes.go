@@ -0,0 +1,34 @@
+package templates
+
+// Kubernetes merges cluster contexts into ~/.kube/config.
+//
+//	[[kubernetes.context]]
+//	name = "staging"
+//	file = "~/Downloads/staging.kubeconfig"
+//	namespace = "api"
+//
+//	[[kubernetes.context]]
+//	name = "prod"
+//	secret = "kubeconfig-prod"
+//	current = true
+//
+// The kubeconfig comes from File or, for credentials that should not sit on
+// disk, from the secrets provider under Secret. It must define a context
+// called Name.
+type Kubernetes struct {
+	Contexts []KubeContext `toml:"context"`
+}
+
+// KubeContext is one context to merge.
+type KubeContext struct {
+	Name      string `toml:"name"`
+	File      string `toml:"file"`
+	Secret    string `toml:"secret"`
+	Namespace string `toml:"namespace"`
+	// Current makes this the active context.
+	Current bool `toml:"current"`
+	// Verify adds a cluster reachability check to `maziq test`; it is on
+	// unless set to false.
+	Verify *bool  `toml:"verify"`
+	When   string `toml:"when"`
+}
@@ -0,0 +1,31 @@
+package templates
+
+// License places a license file and/or runs an activation command for an app.
+//
+//	[[licenses]]
+//	name = "sublime-text"
+//	software = "sublime_text"
+//	secret = "sublime-license"
+//	file = "~/Library/Application Support/Sublime Text/Local/License.sublime_license"
+//	verify = "defaults read com.sublimetext.4 license"
+//	expect = "registered"
+//
+// The license value is read from the secrets provider under Secret and is
+// available to Content, Activate and Verify as ${secret}; commands get it
+// through $MAZIQ_SECRET. When Content is empty the file receives the secret
+// value verbatim.
+type License struct {
+	Name     string `toml:"name"`
+	Software string `toml:"software"`
+	Secret   string `toml:"secret"`
+	File     string `toml:"file"`
+	Content  string `toml:"content"`
+	Mode     string `toml:"mode"`
+	Activate string `toml:"activate"`
+	Verify   string `toml:"verify"`
+	Expect   string `toml:"expect"`
+	When     string `toml:"when"`
+}
+
+// SecretVar is the variable through which license fields see the secret.
+const SecretVar = "secret"
@@ -0,0 +1,1707 @@
+package templates
+
+import (
+	"fmt"
+	"maps"
+	"net"
+	"net/url"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	// Time zones are checked the same on machines without zoneinfo.
+	_ "time/tzdata"
+
+	"github.com/hmziqrs/maziq/internal/catalog"
+	"github.com/hmziqrs/maziq/internal/facts"
+	"github.com/hmziqrs/maziq/internal/plist"
+	"github.com/hmziqrs/maziq/internal/schedule"
+	"github.com/hmziqrs/maziq/internal/sysprefs"
+)
+
+// Severity ranks lint findings.
+type Severity int
+
+const (
+	SeverityInfo Severity = iota
+	SeverityWarning
+	SeverityError
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityError:
+		return "error"
+	case SeverityWarning:
+		return "warning"
+	default:
+		return "info"
+	}
+}
+
+// MarshalText renders the severity by name in JSON output.
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// Finding is a single lint result.
+type Finding struct {
+	Severity Severity `json:"severity"`
+	// Where locates the offending item, e.g. `software[3]` or `tests["rust"]`.
+	Where   string `json:"where"`
+	Message string `json:"message"`
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("%s: %s: %s", f.Severity, f.Where, f.Message)
+}
+
+// maxCombinations bounds the truth-table search used to detect unreachable
+// conditions. Expressions referencing more combinations are not analysed.
+const maxCombinations = 4096
+
+// Lint validates a template: unknown packages, undefined variables,
+// unreachable conditions, software without tests and plaintext secrets.
+func Lint(t *Template) []Finding {
+	l := &linter{t: t, usedVars: map[string]bool{}}
+	l.header()
+	l.software()
+	l.tests()
+	l.matrix()
+	l.demo()
+	l.licenses()
+	l.fonts()
+	l.appStore()
+	l.apps()
+	l.binaries()
+	l.browsers()
+	l.handlers()
+	l.env()
+	l.searchPath()
+	l.aliases()
+	l.network()
+	l.proxy()
+	l.wifi()
+	l.printers()
+	l.energy()
+	l.updates()
+	l.defaults()
+	l.files()
+	l.directories()
+	l.screensaver()
+	l.screenshots()
+	l.spotlight()
+	l.region()
+	l.trackpad()
+	l.menuBar()
+	l.sound()
+	l.services()
+	l.jobs()
+	l.databases()
+	l.repos()
+	l.direnv()
+	l.kubernetes()
+	l.cloud()
+	l.terminal()
+	l.tmux()
+	l.tiling()
+	l.manual()
+	l.order()
+	l.groups()
+	l.conflicts()
+	l.unusedVars()
+	l.plaintextSecrets()
+	sort.SliceStable(l.findings, func(i, j int) bool {
+		return l.findings[i].Severity > l.findings[j].Severity
+	})
+	return l.findings
+}
+
+// Count returns how many findings are at or above min.
+func Count(findings []Finding, min Severity) int {
+	n := 0
+	for _, f := range findings {
+		if f.Severity >= min {
+			n++
+		}
+	}
+	return n
+}
+
+type linter struct {
+	t        *Template
+	findings []Finding
+	usedVars map[string]bool
+}
+
+func (l *linter) add(sev Severity, where, format string, args ...any) {
+	l.findings = append(l.findings, Finding{Severity: sev, Where: where, Message: fmt.Sprintf(format, args...)})
+}
+
+func (l *linter) header() {
+	if strings.TrimSpace(l.t.Name) == "" {
+		l.add(SeverityError, "name", "template has no name")
+	}
+	if strings.TrimSpace(l.t.Description) == "" {
+		l.add(SeverityInfo, "description", "template has no description")
+	}
+	if len(l.t.Software) == 0 {
+		l.add(SeverityWarning, "software", "template installs no software")
+	}
+}
+
+func (l *linter) software() {
+	seen := map[string]int{}
+	declared := map[string]bool{}
+	for _, e := range l.t.Software {
+		declared[e.ID] = true
+	}
+	for i, e := range l.t.Software {
+		where := fmt.Sprintf("software[%d] %q", i, e.ID)
+		if prev, dup := seen[e.ID]; dup {
+			l.add(SeverityWarning, where, "duplicate of software[%d]", prev)
+		} else {
+			seen[e.ID] = i
+		}
+		sw, ok := catalog.Lookup(e.ID)
+		if !ok {
+			if hint := catalog.Suggest(e.ID); hint != "" {
+				l.add(SeverityError, where, "unknown package (did you mean %q?)", hint)
+			} else {
+				l.add(SeverityError, where, "unknown package")
+			}
+		} else {
+			if sw.IntelOnly {
+				l.add(SeverityInfo, where, "only ships Intel builds; on Apple silicon it runs under Rosetta, which is installed with it")
+			}
+			for _, dep := range sw.Deps {
+				if !declared[dep] {
+					l.add(SeverityInfo, where, "depends on %q which the template does not list; it will be installed implicitly", dep)
+				}
+			}
+		}
+		l.cond(where, e.When)
+	}
+}
+
+func (l *linter) tests() {
+	covered := map[string]bool{}
+	declared := map[string]bool{}
+	for _, e := range l.t.Software {
+		declared[e.ID] = true
+	}
+	for i, tc := range l.t.Tests {
+		where := fmt.Sprintf("tests[%d]", i)
+		if tc.Name != "" {
+			where = fmt.Sprintf("tests[%d] %q", i, tc.Name)
+		} else {
+			l.add(SeverityWarning, where, "test has no name")
+		}
+		if strings.TrimSpace(tc.Run) == "" {
+			l.add(SeverityError, where, "test has no run command")
+		}
+		if tc.Software != "" {
+			covered[tc.Software] = true
+			switch _, known := catalog.Lookup(tc.Software); {
+			case !known:
+				l.add(SeverityError, where, "tests unknown package %q", tc.Software)
+			case !declared[tc.Software]:
+				l.add(SeverityWarning, where, "tests %q which the template does not install", tc.Software)
+			}
+		}
+		l.vars(where, tc.Run, tc.Expect)
+		l.cond(where, tc.When)
+	}
+	for i, e := range l.t.Software {
+		if covered[e.ID] {
+			continue
+		}
+		where := fmt.Sprintf("software[%d] %q", i, e.ID)
+		if sw, ok := catalog.Lookup(e.ID); ok && sw.HasProbe() {
+			l.add(SeverityInfo, where, "no test declared; relying on the built-in version probe")
+		} else if ok {
+			l.add(SeverityWarning, where, "no test declared and the catalog has no probe for it")
+		}
+	}
+}
+
+func (l *linter) demo() {
+	if l.t.Demo != nil && l.t.Demo.ResetAfter < 0 {
+		l.add(SeverityError, "demo", "reset_after must not be negative")
+	}
+}
+
+func (l *linter) matrix() {
+	seen := map[string]bool{}
+	for i, p := range l.t.Matrix {
+		where := fmt.Sprintf("matrix[%d] %q", i, p.Name)
+		switch {
+		case p.Name == "":
+			l.add(SeverityError, where, "name is required")
+		case seen[p.Name]:
+			l.add(SeverityError, where, "duplicate platform")
+		}
+		seen[p.Name] = true
+		if len(p.Facts) == 0 {
+			l.add(SeverityWarning, where, "no facts; the platform is every machine")
+		}
+		for _, name := range slices.Sorted(maps.Keys(p.Facts)) {
+			d, isFact := facts.DomainOf(name)
+			_, isVar := l.t.Vars[name]
+			switch {
+			case isFact && !d.Open && !slices.Contains(d.Values, p.Facts[name]):
+				l.add(SeverityError, where, "%s is never %q (known values: %s)", name, p.Facts[name], strings.Join(d.Values, ", "))
+			case !isFact && !isVar:
+				l.add(SeverityError, where, "%q is neither a fact nor a template variable", name)
+			}
+			l.usedVars[name] = true
+		}
+	}
+}
+
+func (l *linter) licenses() {
+	seen := map[string]bool{}
+	for i, lic := range l.t.Licenses {
+		where := fmt.Sprintf("licenses[%d] %q", i, lic.Name)
+		switch {
+		case lic.Name == "":
+			l.add(SeverityError, where, "license has no name")
+		case seen[lic.Name]:
+			l.add(SeverityError, where, "duplicate license name")
+		}
+		seen[lic.Name] = true
+		if lic.File == "" && lic.Activate == "" {
+			l.add(SeverityError, where, "needs a file or an activate command")
+		}
+		if lic.Software != "" {
+			if _, ok := catalog.Lookup(lic.Software); !ok {
+				l.add(SeverityError, where, "unknown package %q", lic.Software)
+			}
+		}
+		if lic.Secret == "" && lic.Content != "" {
+			l.add(SeverityWarning, where, "license content is inline; store it with the secrets provider instead")
+		}
+		if lic.Verify == "" {
+			l.add(SeverityWarning, where, "no verify command; E2E tests cannot assert a licensed state")
+		}
+		l.vars(where, lic.File, lic.Content, lic.Activate, lic.Verify, lic.Expect)
+		l.cond(where, lic.When)
+	}
+}
+
+func (l *linter) fonts() {
+	seen := map[string]bool{}
+	for i, f := range l.t.Fonts {
+		where := fmt.Sprintf("fonts[%d] %q", i, f.Name)
+		switch {
+		case f.Name == "":
+			l.add(SeverityError, where, "font has no name")
+		case seen[f.Name]:
+			l.add(SeverityError, where, "duplicate font name")
+		}
+		seen[f.Name] = true
+		if _, n := f.Source(); n != 1 {
+			l.add(SeverityError, where, "set exactly one of cask, url or path")
+		}
+		if f.URL != "" && len(f.PostScript) == 0 {
+			l.add(SeverityWarning, where, "no postscript names; installed state is only known after MazIQ installs it")
+		}
+		l.integrity(where, f.URL, f.Integrity)
+		l.vars(where, f.URL, f.Path)
+		l.cond(where, f.When)
+	}
+}
+
+// hasSoftware reports whether the template lists the catalog ID.
+func (l *linter) hasSoftware(id string) bool {
+	return slices.ContainsFunc(l.t.Software, func(e Entry) bool { return e.ID == id })
+}
+
+var (
+	sha256Hex = regexp.MustCompile(`^[0-9a-fA-F]{64}$`)
+	// gpgFingerprint is a full fingerprint, which may be grouped by spaces
+	// as gpg prints it.
+	gpgFingerprint = regexp.MustCompile(`^(?:\s*[0-9a-fA-F]){40}\s*$`)
+)
+
+// integrity checks the pinning keys of something downloaded from url.
+func (l *linter) integrity(where, url string, in Integrity) {
+	pinned := in.SHA256 != "" || in.Signature != "" || in.MinisignKey != "" || in.GPGKey != ""
+	switch {
+	case url == "" && pinned:
+		l.add(SeverityWarning, where, "sha256 and signature only apply to url downloads")
+		return
+	case url == "":
+		return
+	case in.SHA256 == "" && in.Signature == "":
+		l.add(SeverityInfo, where, "download is not pinned; add sha256 so a changed file is caught")
+	}
+	if in.SHA256 != "" && !sha256Hex.MatchString(in.SHA256) {
+		l.add(SeverityError, where, "sha256 must be 64 hex digits")
+	}
+	if in.GPGKey != "" && !gpgFingerprint.MatchString(in.GPGKey) {
+		l.add(SeverityError, where, "gpg_key must be the key's full 40-digit fingerprint, not a key ID")
+	}
+	switch {
+	case in.Signature != "" && (in.MinisignKey == "") == (in.GPGKey == ""):
+		l.add(SeverityError, where, "signature needs exactly one of minisign_key or gpg_key")
+	case in.Signature == "" && (in.MinisignKey != "" || in.GPGKey != ""):
+		l.add(SeverityError, where, "minisign_key and gpg_key need a signature url")
+	case in.MinisignKey != "" && !l.hasSoftware("minisign"):
+		l.add(SeverityWarning, where, "needs %q in software", "minisign")
+	case in.GPGKey != "" && !l.hasSoftware("gnupg"):
+		l.add(SeverityWarning, where, "needs %q in software", "gnupg")
+	}
+	l.vars(where, in.Signature)
+}
+
+func (l *linter) appStore() {
+	seen := map[int64]int{}
+	for i, a := range l.t.AppStore {
+		where := fmt.Sprintf("mas[%d] %q", i, a.Name)
+		if a.Name == "" {
+			l.add(SeverityError, where, "app has no name")
+		}
+		if a.ID <= 0 {
+			l.add(SeverityError, where, "id must be the numeric App Store ID")
+		} else if prev, dup := seen[a.ID]; dup {
+			l.add(SeverityWarning, where, "duplicate of mas[%d]", prev)
+		} else {
+			seen[a.ID] = i
+		}
+		l.cond(where, a.When)
+	}
+	if len(l.t.AppStore) > 0 && !slices.ContainsFunc(l.t.Software, func(e Entry) bool { return e.ID == "mas" }) {
+		l.add(SeverityWarning, "mas", "App Store apps need %q in software", "mas")
+	}
+}
+
+func (l *linter) apps() {
+	seen := map[string]bool{}
+	for i, a := range l.t.Apps {
+		where := fmt.Sprintf("apps[%d] %q", i, a.Name)
+		switch {
+		case a.Name == "":
+			l.add(SeverityError, where, "app has no name")
+		case seen[a.Name]:
+			l.add(SeverityError, where, "duplicate app name")
+		}
+		seen[a.Name] = true
+		if a.URL == "" {
+			l.add(SeverityError, where, "url is required")
+		}
+		switch {
+		case a.App == "" && a.PkgID == "":
+			l.add(SeverityError, where, "set app or pkg_id so MazIQ can tell it is installed")
+		case a.App != "" && !strings.HasSuffix(a.App, ".app"):
+			l.add(SeverityError, where, "app must be a bundle name ending in .app")
+		}
+		templated := strings.Contains(a.URL, VersionPlaceholder) || strings.Contains(a.Signature, VersionPlaceholder)
+		switch {
+		case templated && a.VersionURL == "":
+			l.add(SeverityError, where, "%s in url needs a version_url", VersionPlaceholder)
+		case a.VersionKey != "" && a.VersionURL == "":
+			l.add(SeverityWarning, where, "version_key has no effect without version_url")
+		case a.VersionURL != "" && a.SHA256 != "":
+			l.add(SeverityWarning, where, "sha256 pins one release; upgrades found via version_url will fail to verify")
+		case a.VersionURL == "":
+			l.add(SeverityInfo, where, "no version_url; MazIQ installs it once and never upgrades it")
+		}
+		l.integrity(where, a.URL, a.Integrity)
+		l.vars(where, a.URL, a.VersionURL)
+		l.cond(where, a.When)
+	}
+}
+
+func (l *linter) binaries() {
+	seen := map[string]bool{}
+	for i, b := range l.t.Binaries {
+		where := fmt.Sprintf("binaries[%d] %q", i, b.Name)
+		switch {
+		case b.Name == "":
+			l.add(SeverityError, where, "binary has no name")
+		case seen[b.Executable()]:
+			l.add(SeverityError, where, "another entry installs %s", b.Executable())
+		}
+		seen[b.Executable()] = true
+		if owner, name, _ := strings.Cut(b.Repo, "/"); owner == "" || name == "" || strings.Contains(name, "/") {
+			l.add(SeverityError, where, "repo must be owner/name")
+		}
+		if b.Asset != "" {
+			if _, err := regexp.Compile(b.Asset); err != nil {
+				l.add(SeverityError, where, "asset: %v", err)
+			}
+		}
+		if b.SHA256 != "" && b.Version == "" {
+			l.add(SeverityWarning, where, "sha256 pins one release; set version or new releases will fail to verify")
+		}
+		// Release checksums are used when nothing is pinned, so only check
+		// the keys that are set. The asset URL is only known at apply time.
+		if b.Integrity != (Integrity{}) {
+			l.integrity(where, b.Repo, b.Integrity)
+		}
+		l.cond(where, b.When)
+	}
+}
+
+func (l *linter) browsers() {
+	for _, b := range []struct {
+		name string
+		spec *Browser
+	}{{"chrome", l.t.Browsers.Chrome}, {"firefox", l.t.Browsers.Firefox}} {
+		if b.spec == nil {
+			continue
+		}
+		where := "browsers." + b.name
+		if !slices.ContainsFunc(l.t.Software, func(e Entry) bool { return e.ID == b.name }) {
+			l.add(SeverityInfo, where, "%q is not in software; policies apply only if it is installed some other way", b.name)
+		}
+		for i, e := range b.spec.Extensions {
+			switch {
+			case e.ID == "":
+				l.add(SeverityError, fmt.Sprintf("%s.extensions[%d]", where, i), "extension has no id")
+			case b.name == "firefox" && e.URL == "":
+				l.add(SeverityError, fmt.Sprintf("%s.extensions[%d] %q", where, i, e.ID), "Firefox extensions need an install url")
+			}
+		}
+		if s := b.spec.Search; s != nil && !strings.Contains(s.URL, "{searchTerms}") {
+			l.add(SeverityWarning, where+".search", "url has no {searchTerms} placeholder")
+		}
+		l.cond(where, b.spec.When)
+	}
+}
+
+func (l *linter) handlers() {
+	keys := make([]string, 0, len(l.t.Handlers))
+	for k := range l.t.Handlers {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		where, bundle := fmt.Sprintf("handlers %q", k), l.t.Handlers[k]
+		switch {
+		case bundle == "":
+			l.add(SeverityError, where, "no bundle ID")
+		case !strings.Contains(bundle, "."):
+			l.add(SeverityWarning, where, "%q looks like an app name; use its bundle ID (osascript -e 'id of app \"%s\"')", bundle, bundle)
+		}
+	}
+	if len(keys) > 0 && !slices.ContainsFunc(l.t.Software, func(e Entry) bool { return e.ID == "duti" }) {
+		l.add(SeverityWarning, "handlers", "default handlers need %q in software", "duti")
+	}
+}
+
+func (l *linter) network() {
+	seen := map[string]int{}
+	for i, n := range l.t.Network {
+		where := fmt.Sprintf("network[%d] %q", i, n.Service)
+		if n.Service == "" {
+			l.add(SeverityError, where, "service is required (see networksetup -listallnetworkservices)")
+		} else if prev, dup := seen[n.Service]; dup && n.When == "" && l.t.Network[prev].When == "" {
+			l.add(SeverityWarning, where, "duplicate of network[%d]", prev)
+		} else {
+			seen[n.Service] = i
+		}
+		for _, ip := range n.DNS {
+			if len(Refs(ip)) == 0 && net.ParseIP(ip) == nil {
+				l.add(SeverityError, where, "dns server %q is not an IP address", ip)
+			}
+		}
+		for _, p := range []struct{ name, value string }{
+			{"web_proxy", n.WebProxy}, {"secure_web_proxy", n.SecureWebProxy}, {"socks_proxy", n.SOCKSProxy},
+		} {
+			if p.value == "" || p.value == ProxyOff || len(Refs(p.value)) > 0 {
+				continue
+			}
+			if _, _, err := net.SplitHostPort(p.value); err != nil {
+				l.add(SeverityError, where, "%s %q: want host:port or %q", p.name, p.value, ProxyOff)
+			}
+		}
+		l.vars(where, append(append(append([]string{n.WebProxy, n.SecureWebProxy, n.SOCKSProxy, n.AutoProxyURL}, n.DNS...), n.SearchDomains...), n.ProxyBypass...)...)
+		l.cond(where, n.When)
+	}
+}
+
+func (l *linter) proxy() {
+	p := l.t.Proxy
+	for _, f := range []struct{ name, value string }{
+		{"http", p.HTTP}, {"https", p.HTTPS},
+		{"bottle_domain", p.BottleDomain}, {"api_domain", p.APIDomain}, {"artifact_domain", p.ArtifactDomain},
+		{"brew_git_remote", p.BrewGitRemote}, {"core_git_remote", p.CoreGitRemote},
+		{"npm_registry", p.NPMRegistry}, {"pypi_index", p.PyPIIndex},
+	} {
+		if f.value == "" {
+			continue
+		}
+		if u, err := url.Parse(f.value); err != nil || u.Scheme == "" || u.Host == "" {
+			l.add(SeverityError, "proxy", "%s %q is not a URL (want e.g. http://host:port)", f.name, f.value)
+		}
+	}
+	if p.HTTP != "" && p.HTTPS == "" {
+		l.add(SeverityWarning, "proxy", "http is set but https is not; most downloads use https")
+	}
+}
+
+func (l *linter) wifi() {
+	seen := map[string]bool{}
+	for i, w := range l.t.WiFi {
+		where := fmt.Sprintf("wifi[%d] %q", i, w.SSID)
+		switch {
+		case w.SSID == "":
+			l.add(SeverityError, where, "ssid is required")
+		case seen[w.SSID]:
+			l.add(SeverityWarning, where, "duplicate network; only the first position is kept")
+		}
+		seen[w.SSID] = true
+		if w.Security != "" && !slices.Contains(WiFiSecurityTypes, w.Security) {
+			l.add(SeverityError, where, "unknown security %q (want one of %s)", w.Security, strings.Join(WiFiSecurityTypes, ", "))
+		}
+		if w.Security != "OPEN" && w.Secret == "" {
+			l.add(SeverityError, where, "secured network needs a secret")
+		}
+		l.cond(where, w.When)
+	}
+}
+
+func (l *linter) printers() {
+	seen := map[string]bool{}
+	defaults := 0
+	for i, p := range l.t.Printers {
+		where := fmt.Sprintf("printers[%d] %q", i, p.Name)
+		switch {
+		case p.Name == "":
+			l.add(SeverityError, where, "name is required")
+		case strings.ContainsAny(p.Name, " \t/#"):
+			l.add(SeverityError, where, "queue names cannot contain spaces, tabs, / or #; use description for a display name")
+		case seen[p.Name]:
+			l.add(SeverityError, where, "duplicate printer name")
+		}
+		seen[p.Name] = true
+		if p.Address == "" {
+			l.add(SeverityError, where, "address is required")
+		} else if len(Refs(p.Address)) == 0 && !strings.Contains(p.Address, "://") {
+			l.add(SeverityError, where, "address %q is not a device URI such as ipp://host/ipp/print", p.Address)
+		}
+		if p.Default && p.When == "" {
+			defaults++
+		}
+		l.vars(where, p.Address, p.Driver)
+		l.cond(where, p.When)
+	}
+	if defaults > 1 {
+		l.add(SeverityWarning, "printers", "%d printers are marked default; the last one applied wins", defaults)
+	}
+}
+
+func (l *linter) energy() {
+	scopes := l.t.Energy.Scopes()
+	names := make([]string, 0, len(scopes))
+	for name := range scopes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, scope := range names {
+		keys := make([]string, 0, len(scopes[scope]))
+		for key := range scopes[scope] {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			v := scopes[scope][key]
+			where := fmt.Sprintf("energy.%s.%s", scope, key)
+			if !slices.Contains(PmsetKeys, key) {
+				l.add(SeverityWarning, where, "unknown pmset setting (known: %s)", strings.Join(PmsetKeys, ", "))
+			}
+			if _, err := PmsetValue(v); err != nil {
+				l.add(SeverityError, where, "%v", err)
+			}
+		}
+	}
+	if len(l.t.Energy.All) > 0 {
+		for _, scope := range []map[string]any{l.t.Energy.Battery, l.t.Energy.Charger} {
+			for key := range scope {
+				if _, dup := l.t.Energy.All[key]; dup {
+					l.add(SeverityWarning, "energy.all."+key, "also set per power source; the values will fight on every apply")
+				}
+			}
+		}
+	}
+}
+
+func (l *linter) updates() {
+	u := l.t.Updates
+	switch u.Reboot {
+	case "", RebootNever, RebootPrompt, RebootAllow:
+	default:
+		l.add(SeverityError, "updates.reboot", "unknown policy %q (want never, prompt or allow)", u.Reboot)
+	}
+	if u.DeferDays < 0 {
+		l.add(SeverityError, "updates.defer_days", "must not be negative")
+	}
+	if !u.Install && (u.Reboot != "" || u.DeferDays != 0) {
+		l.add(SeverityWarning, "updates", "reboot and defer_days only apply with install = true")
+	}
+	if u.Reboot == RebootAllow {
+		l.add(SeverityInfo, "updates.reboot", "apply restarts the machine without asking when an update needs it")
+	}
+}
+
+func (l *linter) defaults() {
+	seen := map[string]int{}
+	for i, d := range l.t.Defaults {
+		if d.Group != "" {
+			where := fmt.Sprintf("defaults[%d] group %q", i, d.Group)
+			g, ok := SettingGroups[d.Group]
+			switch {
+			case !ok:
+				l.add(SeverityError, where, "unknown group (want one of %s)", strings.Join(SettingGroupNames(), ", "))
+			case g.Unsafe:
+				l.add(SeverityWarning, where, "unsafe: %s", g.Explain)
+			}
+			if d.Domain != "" || d.Key != "" || d.Value != nil || d.Restart != "" || d.CurrentHost || d.Logout {
+				l.add(SeverityError, where, "a group entry takes no domain, key, value, current_host, restart or logout")
+			}
+			l.cond(where, d.When)
+			continue
+		}
+		where := fmt.Sprintf("defaults[%d] %s %s", i, d.Domain, d.Key)
+		if d.Domain == "" || d.Key == "" {
+			l.add(SeverityError, where, "domain and key are required")
+		}
+		if _, err := plist.Normalize(d.Value); err != nil {
+			l.add(SeverityError, where, "value: %v", err)
+		}
+		l.compat(where, d.Domain, d.Key)
+		id := fmt.Sprint(d.CurrentHost, d.Domain, d.Key)
+		if prev, dup := seen[id]; dup && d.When == "" && l.t.Defaults[prev].When == "" {
+			l.add(SeverityError, where, "duplicate of defaults[%d]", prev)
+		} else {
+			seen[id] = i
+		}
+		l.cond(where, d.When)
+	}
+}
+
+func (l *linter) files() {
+	seen := map[string]int{}
+	for i, f := range l.t.Files {
+		where := fmt.Sprintf("files[%d] %q", i, f.Path)
+		if f.Path == "" {
+			l.add(SeverityError, where, "path is required")
+		}
+		if prev, dup := seen[f.Path]; dup && f.When == "" && l.t.Files[prev].When == "" {
+			l.add(SeverityError, where, "duplicate of files[%d]", prev)
+		} else {
+			seen[f.Path] = i
+		}
+		switch f.State {
+		case "", FilePresent:
+			if f.Content != "" && f.Source != "" {
+				l.add(SeverityError, where, "set content or source, not both")
+			}
+		case FileAbsent:
+			if f.Content != "" || f.Source != "" || f.Mode != "" || f.Owner != "" {
+				l.add(SeverityWarning, where, "content, source, mode and owner are ignored for an absent file")
+			}
+		default:
+			l.add(SeverityError, where, "state must be %q or %q", FilePresent, FileAbsent)
+		}
+		if f.Mode != "" {
+			if m, err := strconv.ParseUint(f.Mode, 8, 32); err != nil || m > 0o7777 {
+				l.add(SeverityError, where, "mode %q is not an octal mode such as 0644", f.Mode)
+			} else if m > 0o777 {
+				l.add(SeverityError, where, "mode %q: setuid, setgid and sticky bits are not supported", f.Mode)
+			}
+		}
+		// Sources under ~ or built from variables depend on the machine.
+		if f.Source != "" && f.State != FileAbsent && len(Refs(f.Source)) == 0 && !strings.HasPrefix(f.Source, "~") && !l.t.IsBuiltin() {
+			data, err := os.ReadFile(l.t.Rel(f.Source))
+			if err != nil {
+				l.add(SeverityError, where, "source: %v", err)
+			}
+			l.vars(where, string(data))
+		}
+		l.vars(where, f.Path, f.Content, f.Source, f.Owner)
+		l.cond(where, f.When)
+	}
+}
+
+// iCloudRoots are the directories iCloud Drive syncs.
+var iCloudRoots = []string{"~/Desktop/", "~/Documents/", "~/Library/Mobile Documents/"}
+
+func (l *linter) directories() {
+	seen := map[string]int{}
+	for i, d := range l.t.Directories {
+		where := fmt.Sprintf("directories[%d] %q", i, d.Path)
+		if d.Path == "" {
+			l.add(SeverityError, where, "path is required")
+		}
+		for _, p := range Braces(d.Path) {
+			if !strings.HasPrefix(p, "/") && !strings.HasPrefix(p, "~/") && !strings.HasPrefix(p, "${") {
+				l.add(SeverityError, where, "%s: path must be absolute or start with ~/", p)
+			}
+			if prev, dup := seen[p]; dup && prev != i && d.When == "" && l.t.Directories[prev].When == "" {
+				l.add(SeverityWarning, where, "%s is also declared by directories[%d]", p, prev)
+			} else {
+				seen[p] = i
+			}
+			if d.ExcludeICloud && !slices.ContainsFunc(iCloudRoots, func(root string) bool { return strings.HasPrefix(p+"/", root) }) {
+				l.add(SeverityInfo, where, "%s is outside Desktop, Documents and iCloud Drive, so iCloud never syncs it; exclude_icloud has no effect", p)
+			}
+		}
+		if d.Mode != "" {
+			if m, err := strconv.ParseUint(d.Mode, 8, 32); err != nil || m > 0o7777 {
+				l.add(SeverityError, where, "mode %q is not an octal mode such as 0755", d.Mode)
+			} else if m > 0o777 {
+				l.add(SeverityError, where, "mode %q: setuid, setgid and sticky bits are not supported", d.Mode)
+			}
+		}
+		l.vars(where, d.Path)
+		l.cond(where, d.When)
+	}
+}
+
+// compat flags keys that do nothing on some or all supported macOS
+// versions.
+func (l *linter) compat(where, domain, key string) {
+	c, ok := LookupCompat(domain, key)
+	if !ok {
+		return
+	}
+	versions, _ := facts.DomainOf("macos")
+	supported := 0
+	for _, v := range versions.Values {
+		if c.Supports(v) {
+			supported++
+		}
+	}
+	msg := "only works on " + c.Range()
+	if c.Note != "" {
+		msg += "; " + c.Note
+	}
+	switch supported {
+	case 0:
+		l.add(SeverityWarning, where, "has no effect on any supported macOS: %s", msg)
+	case len(versions.Values):
+	default:
+		l.add(SeverityInfo, where, "%s; skipped elsewhere", msg)
+	}
+}
+
+func (l *linter) screensaver() {
+	s := l.t.Screensaver
+	if s.Idle != nil && *s.Idle < 0 {
+		l.add(SeverityError, "screensaver.idle", "must not be negative")
+	}
+	if s.PasswordDelay < 0 {
+		l.add(SeverityError, "screensaver.password_delay", "must not be negative")
+	}
+	if s.PasswordDelay > 0 && s.RequirePassword == nil {
+		l.add(SeverityWarning, "screensaver.password_delay", "has no effect without require_password")
+	}
+	corners := make([]string, 0, len(s.HotCorners))
+	for c := range s.HotCorners {
+		corners = append(corners, c)
+	}
+	sort.Strings(corners)
+	for _, c := range corners {
+		where := "screensaver.hot_corners." + c
+		if _, ok := HotCorners[c]; !ok {
+			l.add(SeverityError, where, "unknown corner (want top_left, top_right, bottom_left or bottom_right)")
+		}
+		if _, ok := HotCornerActions[s.HotCorners[c].Action]; !ok {
+			actions := make([]string, 0, len(HotCornerActions))
+			for a := range HotCornerActions {
+				actions = append(actions, a)
+			}
+			sort.Strings(actions)
+			l.add(SeverityError, where, "unknown action %q (want one of %s)", s.HotCorners[c].Action, strings.Join(actions, ", "))
+		}
+		if _, err := s.HotCorners[c].ModifierFlags(); err != nil {
+			l.add(SeverityError, where+".modifier", "%v", err)
+		}
+	}
+}
+
+func (l *linter) screenshots() {
+	s := l.t.Screenshots
+	if s.Format != "" && !slices.Contains(ScreenshotFormats, s.Format) {
+		l.add(SeverityError, "screenshots.format", "unknown format %q (want one of %s)", s.Format, strings.Join(ScreenshotFormats, ", "))
+	}
+	if p := s.Location; p != "" {
+		if !strings.HasPrefix(p, "/") && !strings.HasPrefix(p, "~/") && !strings.HasPrefix(p, "${") {
+			l.add(SeverityError, "screenshots.location", "path must be absolute or start with ~/")
+		}
+		l.vars("screenshots.location", p)
+	}
+	if strings.Contains(s.Name, "/") {
+		l.add(SeverityError, "screenshots.name", "must not contain /")
+	}
+	l.vars("screenshots.name", s.Name)
+}
+
+func (l *linter) spotlight() {
+	for _, f := range []struct {
+		name  string
+		paths []string
+	}{{"exclude", l.t.Spotlight.Exclude}, {"disable_volumes", l.t.Spotlight.DisableVolumes}} {
+		seen := map[string]bool{}
+		for i, p := range f.paths {
+			where := fmt.Sprintf("spotlight.%s[%d] %q", f.name, i, p)
+			if !strings.HasPrefix(p, "/") && !strings.HasPrefix(p, "~/") && !strings.HasPrefix(p, "${") {
+				l.add(SeverityError, where, "path must be absolute or start with ~/")
+			}
+			if seen[p] {
+				l.add(SeverityWarning, where, "duplicate path")
+			}
+			seen[p] = true
+			l.vars(where, p)
+		}
+	}
+	for i, v := range l.t.Spotlight.DisableVolumes {
+		if v == "/" || v == "/System/Volumes/Data" {
+			l.add(SeverityWarning, fmt.Sprintf("spotlight.disable_volumes[%d]", i), "disables search for the whole startup disk; use exclude for directories")
+		}
+	}
+}
+
+// locale matches AppleLocale values such as en_US or zh-Hans_CN, with an
+// optional @key=value suffix.
+var locale = regexp.MustCompile(`^[a-z]{2,3}(-[A-Z][a-z]{3})?(_[A-Z]{2}|_\d{3})?(@.+)?$`)
+
+func (l *linter) region() {
+	r := l.t.Region
+	if r.Timezone != "" {
+		if _, err := time.LoadLocation(r.Timezone); err != nil || r.Timezone == "Local" {
+			l.add(SeverityError, "region.timezone", "unknown time zone %q (want a name like Europe/Berlin)", r.Timezone)
+		}
+	}
+	if r.Locale != "" && !locale.MatchString(r.Locale) {
+		l.add(SeverityError, "region.locale", "%q is not a locale like en_US", r.Locale)
+	}
+	if r.Measurement != "" {
+		if _, ok := MeasurementUnits[r.Measurement]; !ok {
+			l.add(SeverityError, "region.measurement", "unknown system %q (want metric or us)", r.Measurement)
+		}
+	}
+	if r.FirstWeekday != "" {
+		if _, ok := Weekdays[r.FirstWeekday]; !ok {
+			l.add(SeverityError, "region.first_weekday", "unknown day %q (want sunday through saturday)", r.FirstWeekday)
+		}
+	}
+}
+
+func (l *linter) trackpad() {
+	t := l.t.Trackpad
+	for _, f := range []struct {
+		key   string
+		speed *float64
+	}{{"tracking_speed", t.TrackingSpeed}, {"mouse_speed", t.MouseSpeed}} {
+		if f.speed != nil && (*f.speed < 0 || *f.speed > 3) {
+			l.add(SeverityError, "trackpad."+f.key, "must be between 0 and 3")
+		}
+	}
+	if c := t.SecondaryClick; c != "" {
+		if _, ok := SecondaryClicks[c]; !ok {
+			l.add(SeverityError, "trackpad.secondary_click", "unknown click %q (want two-finger, bottom-right, bottom-left or off)", c)
+		}
+	}
+}
+
+func (l *linter) menuBar() {
+	m := l.t.MenuBar
+	shown := map[string]bool{}
+	for i, name := range m.Show {
+		shown[name] = true
+		l.menuBarItem(fmt.Sprintf("menubar.show[%d]", i), name)
+	}
+	for i, name := range m.Hide {
+		where := fmt.Sprintf("menubar.hide[%d]", i)
+		l.menuBarItem(where, name)
+		if shown[name] {
+			l.add(SeverityError, where, "%q is also in show", name)
+		}
+	}
+}
+
+func (l *linter) menuBarItem(where, name string) {
+	if _, ok := MenuBarItems[name]; !ok {
+		l.add(SeverityError, where, "unknown item %q (want one of %s)", name, strings.Join(slices.Sorted(maps.Keys(MenuBarItems)), ", "))
+	}
+}
+
+func (l *linter) sound() {
+	if v := l.t.Sound.AlertVolume; v != nil && (*v < 0 || *v > 100) {
+		l.add(SeverityError, "sound.alert_volume", "must be a percentage from 0 to 100")
+	}
+}
+
+func (l *linter) services() {
+	seen := map[string]bool{}
+	for i, s := range l.t.Services {
+		where := fmt.Sprintf("services[%d] %q", i, s.Name)
+		switch {
+		case s.Name == "":
+			l.add(SeverityError, where, "name is required")
+		case seen[s.Name] && s.When == "":
+			l.add(SeverityWarning, where, "duplicate service")
+		}
+		seen[s.Name] = true
+		if s.State != "" && s.State != ServiceStarted && s.State != ServiceStopped {
+			l.add(SeverityError, where, "state must be %q or %q", ServiceStarted, ServiceStopped)
+		}
+		l.cond(where, s.When)
+	}
+}
+
+// jobName is what a job name may contain: it becomes part of a launchd
+// label and a file name.
+var jobName = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+
+func (l *linter) jobs() {
+	seen := map[string]bool{}
+	for i, j := range l.t.Jobs {
+		where := fmt.Sprintf("jobs[%d] %q", i, j.Name)
+		switch {
+		case j.Name == "":
+			l.add(SeverityError, where, "name is required")
+		case !jobName.MatchString(j.Name):
+			l.add(SeverityError, where, "name may only contain letters, digits, '.', '_' and '-'")
+		case seen[j.Name] && j.When == "":
+			l.add(SeverityError, where, "duplicate job name")
+		}
+		seen[j.Name] = true
+		if j.Run == "" {
+			l.add(SeverityError, where, "run is required")
+		}
+		if _, err := schedule.Parse(j.Schedule); err != nil {
+			l.add(SeverityError, where, "%v", err)
+		}
+		l.vars(where, j.Run)
+		l.cond(where, j.When)
+	}
+}
+
+func (l *linter) databases() {
+	managed := map[string]bool{}
+	for _, s := range l.t.Services {
+		managed[s.Name] = true
+	}
+	for i, d := range l.t.Databases {
+		where := fmt.Sprintf("databases[%d] %q", i, d.Engine)
+		switch d.Engine {
+		case EnginePostgres, EngineMySQL:
+			if d.Role == "" && d.Database == "" && d.RootPasswordSecret == "" {
+				l.add(SeverityWarning, where, "nothing to do; set role, database or root_password_secret")
+			}
+			if len(d.Config) > 0 {
+				l.add(SeverityError, where, "config is only supported for %s", EngineRedis)
+			}
+			if d.Engine == EnginePostgres && d.RootPasswordSecret != "" {
+				l.add(SeverityError, where, "root_password_secret is only supported for %s", EngineMySQL)
+			}
+		case EngineRedis:
+			if len(d.Config) == 0 {
+				l.add(SeverityWarning, where, "nothing to do; set config")
+			}
+			if d.Role != "" || d.Database != "" || d.PasswordSecret != "" || d.RootPasswordSecret != "" {
+				l.add(SeverityError, where, "role, database and password secrets are not supported for %s", EngineRedis)
+			}
+		default:
+			l.add(SeverityError, where, "engine must be %q, %q or %q", EnginePostgres, EngineMySQL, EngineRedis)
+		}
+		switch {
+		case d.Service == "":
+			l.add(SeverityInfo, where, "no service set; bootstrap may run before the server is up")
+		case !managed[d.Service]:
+			l.add(SeverityWarning, where, "service %q is not in [[services]]", d.Service)
+		}
+		l.cond(where, d.When)
+	}
+}
+
+func (l *linter) repos() {
+	l.vars("repos", l.t.Repos.Workspace, l.t.Repos.SSHKey)
+	seen := map[string]bool{}
+	for i, r := range l.t.Repos.Repos {
+		where := fmt.Sprintf("repos.repo[%d] %q", i, r.URL)
+		if r.URL == "" {
+			l.add(SeverityError, where, "url is required")
+			continue
+		}
+		dir := r.Dir()
+		switch {
+		case dir == "" || dir == "." || dir == ".." || strings.HasPrefix(dir, "../"):
+			l.add(SeverityError, where, "cannot derive a directory; set path")
+		case seen[dir] && r.When == "":
+			l.add(SeverityError, where, "another repo already clones into %q", dir)
+		}
+		seen[dir] = true
+		l.vars(where, r.URL, r.Path, r.Bootstrap)
+		l.cond(where, r.When)
+	}
+}
+
+func (l *linter) direnv() {
+	d := l.t.Direnv
+	if (d.Hook || len(d.Projects) > 0) && !slices.ContainsFunc(l.t.Software, func(e Entry) bool { return e.ID == "direnv" }) {
+		l.add(SeverityWarning, "direnv", "direnv projects need %q in software", "direnv")
+	}
+	seen := map[string]bool{}
+	for i, p := range d.Projects {
+		where := fmt.Sprintf("direnv.project[%d] %q", i, p.Path)
+		switch {
+		case p.Path == "":
+			l.add(SeverityError, where, "path is required")
+		case seen[p.Path] && p.When == "":
+			l.add(SeverityWarning, where, "duplicate project")
+		}
+		seen[p.Path] = true
+		names := make([]string, 0, len(p.Env)+len(p.Secrets))
+		for k, v := range p.Env {
+			names = append(names, k)
+			l.vars(where, v)
+		}
+		for k, secret := range p.Secrets {
+			names = append(names, k)
+			if secret == "" {
+				l.add(SeverityError, where, "secrets.%s: secret name is empty", k)
+			}
+			if _, dup := p.Env[k]; dup {
+				l.add(SeverityError, where, "%s is set in both env and secrets", k)
+			}
+		}
+		sort.Strings(names)
+		for _, k := range names {
+			if !envName.MatchString(k) {
+				l.add(SeverityError, where, "%q is not a valid variable name", k)
+			}
+		}
+		l.vars(where, p.Path, p.Content)
+		l.cond(where, p.When)
+	}
+}
+
+var envName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// ValidEnvName reports whether name can be exported as an environment
+// variable.
+func ValidEnvName(name string) bool { return envName.MatchString(name) }
+
+func (l *linter) env() {
+	for _, name := range slices.Sorted(maps.Keys(l.t.Env)) {
+		where := "env." + name
+		if !envName.MatchString(name) {
+			l.add(SeverityError, where, "%q is not a valid variable name", name)
+		}
+		l.vars(where, l.t.Env[name])
+	}
+	if _, ok := l.t.Env["PATH"]; ok {
+		l.add(SeverityWarning, "env.PATH", "replaces PATH outright; list the directories to put first in [path] order")
+	}
+}
+
+// commandName matches the names aliases and functions can take in every
+// supported shell.
+var commandName = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.:+-]*$`)
+
+// ValidCommandName reports whether name can be defined as an alias or
+// function.
+func ValidCommandName(name string) bool { return commandName.MatchString(name) }
+
+func (l *linter) aliases() {
+	for _, section := range []struct {
+		name string
+		defs map[string]string
+	}{{"aliases", l.t.Aliases}, {"functions", l.t.Functions}} {
+		for _, name := range slices.Sorted(maps.Keys(section.defs)) {
+			where := section.name + "." + name
+			switch {
+			case !commandName.MatchString(name):
+				l.add(SeverityError, where, "%q is not a valid command name", name)
+			case strings.TrimSpace(section.defs[name]) == "":
+				l.add(SeverityError, where, "is empty")
+			}
+			if _, ok := l.t.Aliases[name]; ok && section.name == "functions" {
+				l.add(SeverityError, where, "is also an alias")
+			}
+			l.vars(where, section.defs[name])
+		}
+	}
+}
+
+func (l *linter) searchPath() {
+	seen := map[string]bool{}
+	for i, entry := range l.t.SearchPath.Order {
+		where := fmt.Sprintf("path.order[%d] %q", i, entry)
+		_, known := PathDirs(entry, "arm64")
+		switch {
+		case entry == "":
+			l.add(SeverityError, where, "directory is empty")
+		case !known && !strings.HasPrefix(entry, "/") && !strings.HasPrefix(entry, "~") && !strings.HasPrefix(entry, "$"):
+			l.add(SeverityError, where, "unknown directory name (want brew, cargo, go, bun, local, system or a path)")
+		case seen[entry]:
+			l.add(SeverityWarning, where, "listed twice")
+		}
+		seen[entry] = true
+		l.vars(where, entry)
+	}
+}
+
+func (l *linter) kubernetes() {
+	contexts := l.t.Kubernetes.Contexts
+	if len(contexts) > 0 && !slices.ContainsFunc(l.t.Software, func(e Entry) bool { return e.ID == "kubectl" }) {
+		l.add(SeverityWarning, "kubernetes", "kube contexts need %q in software", "kubectl")
+	}
+	seen := map[string]bool{}
+	current := 0
+	for i, c := range contexts {
+		where := fmt.Sprintf("kubernetes.context[%d] %q", i, c.Name)
+		switch {
+		case c.Name == "":
+			l.add(SeverityError, where, "name is required")
+		case seen[c.Name] && c.When == "":
+			l.add(SeverityWarning, where, "duplicate context")
+		}
+		seen[c.Name] = true
+		if (c.File == "") == (c.Secret == "") {
+			l.add(SeverityError, where, "set exactly one of file and secret")
+		}
+		if c.Current && c.When == "" {
+			current++
+		}
+		l.vars(where, c.File)
+		l.cond(where, c.When)
+	}
+	if current > 1 {
+		l.add(SeverityError, "kubernetes", "%d contexts are marked current", current)
+	}
+}
+
+func (l *linter) cloud() {
+	c := l.t.Cloud
+	need := func(section, id string, used bool) {
+		if used && !slices.ContainsFunc(l.t.Software, func(e Entry) bool { return e.ID == id }) {
+			l.add(SeverityWarning, section, "needs %q in software", id)
+		}
+	}
+	need("cloud.aws", "aws_cli", len(c.AWS) > 0)
+	need("cloud.gcloud", "gcloud", len(c.GCloud) > 0)
+	need("cloud.azure", "azure_cli", c.Azure != nil)
+
+	seen := map[string]bool{}
+	for i, p := range c.AWS {
+		where := fmt.Sprintf("cloud.aws[%d] %q", i, p.Profile)
+		switch {
+		case p.Profile == "":
+			l.add(SeverityError, where, "profile is required")
+		case seen[p.Profile] && p.When == "":
+			l.add(SeverityWarning, where, "duplicate profile")
+		}
+		seen[p.Profile] = true
+		if p.SSOStartURL != "" && (p.SSOAccountID == "" || p.SSORoleName == "") {
+			l.add(SeverityWarning, where, "sso_start_url without sso_account_id and sso_role_name")
+		}
+		if p.RoleARN != "" && p.SourceProfile == "" {
+			l.add(SeverityWarning, where, "role_arn without source_profile")
+		}
+		var fields []string
+		for _, kv := range p.Settings() {
+			fields = append(fields, kv[1])
+		}
+		l.vars(where, fields...)
+		l.cond(where, p.When)
+	}
+	seen = map[string]bool{}
+	active := 0
+	for i, g := range c.GCloud {
+		where := fmt.Sprintf("cloud.gcloud[%d] %q", i, g.Configuration)
+		switch {
+		case g.Configuration == "":
+			l.add(SeverityError, where, "configuration is required")
+		case seen[g.Configuration] && g.When == "":
+			l.add(SeverityWarning, where, "duplicate configuration")
+		}
+		seen[g.Configuration] = true
+		if g.Activate && g.When == "" {
+			active++
+		}
+		var fields []string
+		for _, kv := range g.Properties() {
+			fields = append(fields, kv[1])
+		}
+		l.vars(where, fields...)
+		l.cond(where, g.When)
+	}
+	if active > 1 {
+		l.add(SeverityError, "cloud.gcloud", "%d configurations are marked activate", active)
+	}
+	if az := c.Azure; az != nil {
+		if az.Subscription == "" && az.Location == "" && az.Group == "" {
+			l.add(SeverityWarning, "cloud.azure", "nothing to configure")
+		}
+		l.vars("cloud.azure", az.Location, az.Group)
+		l.cond("cloud.azure", az.When)
+	}
+}
+
+func (l *linter) terminal() {
+	t := l.t.Terminal
+	for _, e := range t.Emulators {
+		if !slices.Contains(Emulators, e) {
+			l.add(SeverityError, "terminal.emulators", "unknown emulator %q (want one of %s)", e, strings.Join(Emulators, ", "))
+			continue
+		}
+		if !slices.ContainsFunc(l.t.Software, func(s Entry) bool { return s.ID == e }) {
+			l.add(SeverityInfo, "terminal.emulators", "%q is configured but not in software", e)
+		}
+	}
+	c := t.Colors
+	for _, kv := range [][2]string{{"background", c.Background}, {"foreground", c.Foreground}, {"cursor", c.Cursor}, {"selection", c.Selection}} {
+		if kv[1] != "" && !hexColor.MatchString(kv[1]) {
+			l.add(SeverityError, "terminal.colors."+kv[0], "%q is not a #rrggbb color", kv[1])
+		}
+	}
+	if len(c.Palette) != 0 && len(c.Palette) != 16 {
+		l.add(SeverityError, "terminal.colors.palette", "has %d colors, want 16", len(c.Palette))
+	}
+	for i, p := range c.Palette {
+		if !hexColor.MatchString(p) {
+			l.add(SeverityError, fmt.Sprintf("terminal.colors.palette[%d]", i), "%q is not a #rrggbb color", p)
+		}
+	}
+	if t.FontSize < 0 {
+		l.add(SeverityError, "terminal.font_size", "must be positive")
+	}
+	for _, app := range []struct {
+		name string
+		set  bool
+	}{
+		{EmulatorGhostty, len(t.Ghostty.Keybindings) > 0 || t.Ghostty.Extra != ""},
+		{EmulatorKitty, len(t.Kitty.Keybindings) > 0 || t.Kitty.Extra != ""},
+		{EmulatorAlacritty, len(t.Alacritty.Keybindings) > 0 || t.Alacritty.Extra != ""},
+		{EmulatorITerm2, t.ITerm2.Profile != "" || t.ITerm2.Font != "" || len(t.ITerm2.Extra) > 0},
+	} {
+		if app.set && !slices.Contains(t.Emulators, app.name) {
+			l.add(SeverityWarning, "terminal."+app.name, "settings are ignored; %q is not in terminal.emulators", app.name)
+		}
+	}
+	l.vars("terminal", t.Font)
+}
+
+var hexColor = regexp.MustCompile(`^#?[0-9a-fA-F]{6}$`)
+
+func (l *linter) tmux() {
+	t := l.t.Tmux
+	if (t.Config != "" || len(t.Plugins) > 0) && !slices.ContainsFunc(l.t.Software, func(e Entry) bool { return e.ID == "tmux" }) {
+		l.add(SeverityWarning, "tmux", "needs %q in software", "tmux")
+	}
+	seen := map[string]bool{}
+	for i, p := range t.Plugins {
+		where := fmt.Sprintf("tmux.plugins[%d] %q", i, p)
+		switch {
+		case strings.TrimSpace(p) == "":
+			l.add(SeverityError, where, "plugin is empty")
+		case p == "tmux-plugins/tpm":
+			l.add(SeverityInfo, where, "TPM is always installed; no need to list it")
+		case seen[p]:
+			l.add(SeverityWarning, where, "duplicate plugin")
+		case strings.ContainsAny(p, "'\n"):
+			l.add(SeverityError, where, "plugin contains a quote or newline")
+		}
+		seen[p] = true
+	}
+	l.vars("tmux", t.Config)
+}
+
+func (l *linter) tiling() {
+	for _, tool := range []struct {
+		section, id string
+		used        bool
+	}{
+		{"karabiner", "karabiner_elements", l.t.Karabiner.Used()},
+		{"skhd", "skhd", l.t.Skhd.Used()},
+		{"yabai", "yabai", l.t.Yabai.Used()},
+	} {
+		if tool.used && !slices.ContainsFunc(l.t.Software, func(e Entry) bool { return e.ID == tool.id }) {
+			l.add(SeverityWarning, tool.section, "needs %q in software", tool.id)
+		}
+	}
+	for i, r := range l.t.Karabiner.Rules {
+		where := fmt.Sprintf("karabiner.rule[%d]", i)
+		if d, _ := r["description"].(string); d == "" {
+			l.add(SeverityError, where, "description is required")
+		}
+		if _, ok := r["manipulators"].([]any); !ok {
+			l.add(SeverityError, where, "manipulators must be an array of tables")
+		}
+	}
+	if s := l.t.Skhd; s.Config != "" && len(s.Hotkeys) > 0 {
+		l.add(SeverityWarning, "skhd", "hotkeys are ignored when config is set")
+	}
+	y := l.t.Yabai
+	if y.Config != "" && (len(y.Settings) > 0 || len(y.Rules) > 0) {
+		l.add(SeverityWarning, "yabai", "settings and rules are ignored when config is set")
+	}
+	if y.Config != "" && y.ScriptingAddition {
+		l.add(SeverityInfo, "yabai", "your yabairc must run `sudo yabai --load-sa` itself")
+	}
+	keys := make([]string, 0, len(y.Settings))
+	for k := range y.Settings {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		switch y.Settings[k].(type) {
+		case string, bool, int64, float64:
+		default:
+			l.add(SeverityError, "yabai.settings."+k, "must be a string, number or boolean")
+		}
+	}
+	l.vars("karabiner", l.t.Karabiner.Config)
+	l.vars("skhd", l.t.Skhd.Config)
+	l.vars("yabai", y.Config)
+}
+
+func (l *linter) manual() {
+	seen := map[string]bool{}
+	for i, m := range l.t.Manual {
+		where := fmt.Sprintf("manual[%d] %q", i, m.Name)
+		switch {
+		case m.Name == "":
+			l.add(SeverityError, where, "name is required")
+		case seen[m.Name] && m.When == "":
+			l.add(SeverityWarning, where, "duplicate step")
+		}
+		seen[m.Name] = true
+		if strings.TrimSpace(m.Instructions) == "" {
+			l.add(SeverityError, where, "instructions are required")
+		}
+		if m.Verify == "" {
+			l.add(SeverityInfo, where, "no verify command; the step is trusted once confirmed")
+		}
+		if _, ok := sysprefs.Lookup(m.Settings); m.Settings != "" && !ok {
+			l.add(SeverityError, where, "unknown settings pane %q; run `maziq settings` for the list", m.Settings)
+		}
+		l.vars(where, m.Instructions, m.Open)
+		l.cond(where, m.When)
+	}
+}
+
+func (l *linter) order() {
+	for i, o := range l.t.Order {
+		where := fmt.Sprintf("order[%d] %q", i, o.Resource)
+		if o.Resource == "" {
+			l.add(SeverityError, where, "resource is required")
+		} else if !strings.Contains(o.Resource, ":") {
+			l.add(SeverityError, where, "resource must be an ID such as software:git")
+		}
+		if len(o.Requires) == 0 && len(o.Before) == 0 {
+			l.add(SeverityWarning, where, "neither requires nor before is set; the rule does nothing")
+		}
+		patterns := slices.Concat(o.Requires, o.Before)
+		for _, p := range patterns {
+			if !strings.Contains(p, ":") {
+				l.add(SeverityError, where, "%q must be an ID such as software:git", p)
+			}
+		}
+		l.vars(where, append(patterns, o.Resource)...)
+		l.cond(where, o.When)
+	}
+}
+
+func (l *linter) groups() {
+	seen := map[string]bool{}
+	for i, g := range l.t.Groups {
+		where := fmt.Sprintf("group[%d] %q", i, g.Name)
+		switch {
+		case g.Name == "":
+			l.add(SeverityError, where, "name is required")
+		case seen[g.Name]:
+			l.add(SeverityError, where, "duplicate group")
+		}
+		seen[g.Name] = true
+		if len(g.Resources) == 0 {
+			l.add(SeverityWarning, where, "no resources; the group turns nothing off")
+		}
+		for _, p := range g.Resources {
+			if !strings.Contains(p, ":") {
+				l.add(SeverityError, where, "%q must be an ID such as software:git", p)
+			}
+		}
+		if g.Enabled != nil && len(g.Profiles) > 0 {
+			l.add(SeverityWarning, where, "enabled is ignored when profiles are set")
+		}
+		l.vars(where, g.Resources...)
+	}
+}
+
+// vars reports ${name} references that are neither template variables nor
+// facts.
+func (l *linter) vars(where string, fields ...string) {
+	for _, field := range fields {
+		for _, name := range Refs(field) {
+			l.usedVars[name] = true
+			if _, ok := l.t.Vars[name]; !ok && !facts.Known(name) && name != SecretVar {
+				l.add(SeverityError, where, "undefined variable ${%s}", name)
+			}
+		}
+	}
+}
+
+// conflicts reports the same tool installed by two entries, through two
+// backends or two sections, and a file in [[files]] that a section also
+// writes. Entries whose conditions differ are assumed never to apply
+// together.
+func (l *linter) conflicts() {
+	type provider struct {
+		section, where, via, when string
+	}
+	var keys []string
+	labels := map[string]string{}
+	provides := map[string][]provider{}
+	add := func(key, label string, p provider) {
+		if _, ok := provides[key]; !ok {
+			keys = append(keys, key)
+			labels[key] = label
+		}
+		provides[key] = append(provides[key], p)
+	}
+	app := func(name string) (string, string) {
+		name = strings.TrimSuffix(name, ".app")
+		return "app " + strings.ToLower(name), name + ".app"
+	}
+	for i, e := range l.t.Software {
+		sw, ok := catalog.Lookup(e.ID)
+		if !ok {
+			continue
+		}
+		p := provider{"software", fmt.Sprintf("software[%d] %q", i, e.ID), installedVia(sw), e.When}
+		// "cargo watch --version" names a subcommand, not a command.
+		if v := sw.Version; len(v) > 0 && (len(v) == 1 || strings.HasPrefix(v[1], "-")) {
+			add("command "+v[0], "the "+v[0]+" command", p)
+		}
+		if sw.App != "" {
+			key, label := app(sw.App)
+			add(key, label, p)
+		}
+	}
+	for i, b := range l.t.Binaries {
+		add("command "+b.Executable(), "the "+b.Executable()+" command", provider{"binaries", fmt.Sprintf("binaries[%d] %q", i, b.Name), "a GitHub release of " + b.Repo, b.When})
+	}
+	for i, a := range l.t.Apps {
+		if a.App != "" {
+			key, label := app(a.App)
+			add(key, label, provider{"apps", fmt.Sprintf("apps[%d] %q", i, a.Name), "a download", a.When})
+		}
+	}
+	for i, a := range l.t.AppStore {
+		key, label := app(a.Name)
+		add(key, label, provider{"mas", fmt.Sprintf("mas[%d] %q", i, a.Name), "the App Store", a.When})
+	}
+	reported := map[[2]string]bool{}
+	for _, key := range keys {
+		ps := provides[key]
+		for j, p := range ps {
+			for _, prev := range ps[:j] {
+				pair := [2]string{prev.where, p.where}
+				if reported[pair] || prev.when != "" && p.when != "" && prev.when != p.when {
+					continue
+				}
+				reported[pair] = true
+				l.add(SeverityWarning, p.where, "installs %s from %s, and %s installs it from %s; %s",
+					labels[key], p.via, prev.where, prev.via, resolution(prev.section, p.section, strings.HasPrefix(key, "app ")))
+			}
+		}
+	}
+
+	// The files sections render or link.
+	type owner struct{ section, path string }
+	var owners []owner
+	for _, e := range l.t.Terminal.Emulators {
+		if path, ok := EmulatorConfigs[e]; ok {
+			owners = append(owners, owner{"terminal", path})
+		}
+	}
+	if l.t.Tmux.Config != "" {
+		owners = append(owners, owner{"tmux", "~/.tmux.conf"})
+	}
+	if len(l.t.Tmux.Plugins) > 0 {
+		owners = append(owners, owner{"tmux", "~/.tmux/plugins.conf"})
+	}
+	if len(l.t.Karabiner.Rules) > 0 {
+		owners = append(owners, owner{"karabiner", "~/.config/karabiner/assets/complex_modifications/maziq.json"})
+	}
+	if l.t.Skhd.Used() {
+		owners = append(owners, owner{"skhd", "~/.config/skhd/skhdrc"})
+	}
+	if l.t.Yabai.Used() {
+		owners = append(owners, owner{"yabai", "~/.config/yabai/yabairc"})
+	}
+	for i, f := range l.t.Files {
+		where := fmt.Sprintf("files[%d] %q", i, f.Path)
+		path := homePath(f.Path)
+		for _, o := range owners {
+			if path == o.path {
+				l.add(SeverityWarning, where, "[%s] also writes %s, and each apply would undo the other; configure it through [%s] or drop this entry", o.section, o.path, o.section)
+			}
+		}
+		// Karabiner's whole directory is a link.
+		if c := l.t.Karabiner.Config; c != "" && strings.HasPrefix(path, "~/.config/karabiner/") {
+			l.add(SeverityWarning, where, "[karabiner] links ~/.config/karabiner to %s, so this writes into that directory; keep the file in %s instead", c, c)
+		}
+	}
+}
+
+// resolution suggests how to settle two entries installing the same thing,
+// by the sections they are in.
+func resolution(a, b string, isApp bool) string {
+	pair := []string{a, b}
+	slices.Sort(pair)
+	switch {
+	case pair[0] == "software" && pair[1] == "software" && isApp:
+		return "both casks install the same bundle; keep one"
+	case pair[0] == "apps" && pair[1] == "software":
+		return "keep the software entry, which Homebrew keeps up to date, and drop the [[apps]] one"
+	case slices.Contains(pair, "mas"):
+		return "keep one; both would update the app and undo each other's version"
+	case pair[0] == "binaries":
+		return "keep one; whichever comes first on PATH hides the other"
+	}
+	return "keep one, or give them conditions that never hold together"
+}
+
+// installedVia names what installs sw, e.g. "Homebrew formula git".
+func installedVia(sw catalog.Software) string {
+	src := sw.Primary()
+	switch src.Backend {
+	case catalog.BackendBrew:
+		return "Homebrew formula " + src.Package
+	case catalog.BackendCask:
+		return "Homebrew cask " + src.Package
+	case catalog.BackendNPM:
+		return "npm package " + src.Package
+	case catalog.BackendCargo:
+		return "Cargo crate " + src.Package
+	}
+	return string(src.Backend)
+}
+
+// homePath writes a path under $HOME with ~, as sections name their files.
+func homePath(path string) string {
+	for _, home := range []string{"$HOME/", "${HOME}/"} {
+		if rest, ok := strings.CutPrefix(path, home); ok {
+			return "~/" + rest
+		}
+	}
+	return strings.TrimSuffix(path, "/")
+}
+
+func (l *linter) unusedVars() {
+	names := make([]string, 0, len(l.t.Vars))
+	for name := range l.t.Vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if !l.usedVars[name] {
+			l.add(SeverityInfo, "vars."+name, "variable is never used")
+		}
+	}
+}
+
+func (l *linter) plaintextSecrets() {
+	for _, name := range l.t.plaintextSecrets() {
+		l.add(SeverityWarning, "vars."+name, "looks sensitive but is stored in plaintext; run `maziq secrets encrypt`")
+	}
+}
+
+// cond parses a `when` expression and checks that it references defined
+// identifiers and can evaluate both ways.
+func (l *linter) cond(where, src string) {
+	c, err := ParseCond(src)
+	if err != nil {
+		l.add(SeverityError, where, "invalid when: %v", err)
+		return
+	}
+	if c == nil {
+		return
+	}
+	idents := unique(c.Idents(nil))
+	domains := map[string][]string{}
+	for _, id := range idents {
+		l.usedVars[id] = true
+		literals := unique(c.Literals(id, nil))
+		d, isFact := facts.DomainOf(id)
+		_, isVar := l.t.Vars[id]
+		switch {
+		case isFact && !d.Open:
+			for _, lit := range literals {
+				if !slices.Contains(d.Values, lit) {
+					l.add(SeverityWarning, where, "%s is never %q (known values: %s)", id, lit, strings.Join(d.Values, ", "))
+				}
+			}
+			domains[id] = d.Values
+		case isFact || isVar:
+			// Open domains: every literal plus one value matching none of them.
+			domains[id] = append(literals, "\x00other")
+		default:
+			l.add(SeverityError, where, "undefined variable %q in when", id)
+			return
+		}
+	}
+	sometimes, always := truthTable(c, idents, domains)
+	switch {
+	case !sometimes:
+		l.add(SeverityWarning, where, "unreachable: when %q can never be true", src)
+	case always:
+		l.add(SeverityInfo, where, "when %q is always true", src)
+	}
+}
+
+// truthTable evaluates c over every combination of identifier values and
+// reports whether it is ever true and whether it is always true. When the
+// search space is too large it optimistically reports (true, false).
+func truthTable(c Cond, idents []string, domains map[string][]string) (sometimes, always bool) {
+	total := 1
+	for _, id := range idents {
+		total *= len(domains[id])
+		if total > maxCombinations {
+			return true, false
+		}
+	}
+	always = true
+	assign := map[string]string{}
+	var walk func(int)
+	walk = func(i int) {
+		if i == len(idents) {
+			if c.Eval(func(name string) string { return assign[name] }) {
+				sometimes = true
+			} else {
+				always = false
+			}
+			return
+		}
+		for _, v := range domains[idents[i]] {
+			assign[idents[i]] = v
+			walk(i + 1)
+		}
+	}
+	walk(0)
+	return sometimes, always
+}
+
+func unique(in []string) []string {
+	seen := map[string]bool{}
+	out := in[:0]
+	for _, s := range in {
+		if !seen[s] {
+			seen[s] = true
+			out = append(out, s)
+		}
+	}
+	return out
+}
@@ -0,0 +1,143 @@
+package templates
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/BurntSushi/toml"
+
+	"github.com/hmziqrs/maziq/internal/config"
+)
+
+// LocalFile holds one machine's tweaks to the template next to it: a
+// different git email on the work laptop, an extra package. It is kept out
+// of version control so the shared template stays the same everywhere.
+const LocalFile = "maziq.local.toml"
+
+// OriginLocal marks entries added by the machine's LocalFile.
+const OriginLocal = "local"
+
+// LocalPath returns where t's LocalFile is: next to the template, or in
+// MazIQ's home for a built-in template.
+func (t *Template) LocalPath() string {
+	if t.IsBuiltin() || t.Path == "" {
+		return filepath.Join(config.Dir(), LocalFile)
+	}
+	return filepath.Join(filepath.Dir(t.Path), LocalFile)
+}
+
+// WithLocal returns t with its LocalFile merged over it, or t itself when
+// there is none. Tables are merged key by key, values the file sets replace
+// the template's, and its arrays are appended, except that a software entry
+// the file lists again replaces the template's. Shared keeps t, so the plan
+// can tell what the file changed.
+func (t *Template) WithLocal() (*Template, error) {
+	path := t.LocalPath()
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return t, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var shared, local map[string]any
+	if _, err := toml.Decode(string(t.Raw), &shared); err != nil {
+		return nil, fmt.Errorf("%s: %w", t.Path, err)
+	}
+	if _, err := toml.Decode(string(data), &local); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	// Check the file on its own first, so its mistakes are reported
+	// against it rather than the template.
+	if _, err := Parse(data, path); err != nil {
+		return nil, err
+	}
+	if sw, ok := local["software"].([]any); ok {
+		shared["software"] = withoutEntries(shared["software"], sw)
+	}
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(mergeTables(shared, local)); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	merged, err := Parse(buf.Bytes(), t.Path)
+	if err != nil {
+		return nil, err
+	}
+	merged.Raw = t.Raw
+	merged.Profile, merged.Toggles = t.Profile, t.Toggles
+	ids := map[string]bool{}
+	for _, e := range t.Software {
+		ids[e.ID+"\x00"+e.When] = true
+	}
+	for i, e := range merged.Software {
+		if !ids[e.ID+"\x00"+e.When] {
+			merged.Software[i].Origin = OriginLocal
+		}
+	}
+	merged.Shared = t
+	return merged, nil
+}
+
+// mergeTables layers over on base: see WithLocal.
+func mergeTables(base, over map[string]any) map[string]any {
+	out := make(map[string]any, len(base)+len(over))
+	for k, v := range base {
+		out[k] = v
+	}
+	for k, v := range over {
+		switch v := v.(type) {
+		case map[string]any:
+			if b, ok := out[k].(map[string]any); ok {
+				out[k] = mergeTables(b, v)
+				continue
+			}
+		case []map[string]any:
+			if b, ok := out[k].([]map[string]any); ok {
+				out[k] = append(append([]map[string]any{}, b...), v...)
+				continue
+			}
+		case []any:
+			if b, ok := out[k].([]any); ok {
+				out[k] = append(append([]any{}, b...), v...)
+				continue
+			}
+		}
+		out[k] = v
+	}
+	return out
+}
+
+// withoutEntries drops the software entries of base that local lists
+// again.
+func withoutEntries(base any, local []any) any {
+	entries, ok := base.([]any)
+	if !ok {
+		return base
+	}
+	relisted := map[string]bool{}
+	for _, e := range local {
+		relisted[entryID(e)] = true
+	}
+	var out []any
+	for _, e := range entries {
+		if !relisted[entryID(e)] {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+func entryID(e any) string {
+	switch e := e.(type) {
+	case string:
+		return e
+	case map[string]any:
+		id, _ := e["id"].(string)
+		return id
+	}
+	return ""
+}
@@ -0,0 +1,29 @@
+package templates
+
+// Manual is a step maziq cannot automate. Apply pauses on it, shows the
+// instructions, opens Settings or Open if set, and continues once the user
+// confirms or Verify passes.
+//
+//	[[manual]]
+//	name = "app-store-sign-in"
+//	instructions = "Sign in to the App Store with your Apple ID."
+//	open = "macappstore://"
+//	verify = "mas account"
+//
+//	[[manual]]
+//	name = "terminal-full-disk-access"
+//	instructions = "Allow your terminal under Full Disk Access."
+//	settings = "full-disk-access"
+//
+// Without Verify a step counts as done once confirmed, and maziq remembers
+// that.
+type Manual struct {
+	Name         string `toml:"name"`
+	Instructions string `toml:"instructions"`
+	// Settings names a System Settings pane (see `maziq settings`).
+	Settings string `toml:"settings"`
+	// Open is a URL or path handed to `open`.
+	Open   string `toml:"open"`
+	Verify string `toml:"verify"`
+	When   string `toml:"when"`
+}
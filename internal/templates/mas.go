@@ -0,0 +1,13 @@
+package templates
+
+// AppStoreApp is a Mac App Store app installed with mas. ID is the numeric
+// App Store identifier from the app's store URL.
+//
+//	[[mas]]
+//	name = "Xcode"
+//	id = 497799835
+type AppStoreApp struct {
+	Name string `toml:"name"`
+	ID   int64  `toml:"id"`
+	When string `toml:"when"`
+}
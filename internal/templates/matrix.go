@@ -0,0 +1,21 @@
+package templates
+
+// Platform is an entry of the E2E test matrix: a kind of machine the
+// template is meant to work on, described by its facts. `maziq test
+// --matrix` works out which assertions apply on each platform, runs those of
+// the platforms the current machine is, and reports which are untested
+// where.
+//
+//	[[matrix]]
+//	name = "macos-13 x86_64"
+//	facts = { macos = "13", arch = "amd64" }
+//
+//	[[matrix]]
+//	name = "macos-14 arm64"
+//	facts = { macos = "14", arch = "arm64" }
+type Platform struct {
+	Name string `toml:"name"`
+	// Facts are the values the platform has; keys are facts or template
+	// vars, and anything not set is taken from the current machine.
+	Facts map[string]string `toml:"facts"`
+}
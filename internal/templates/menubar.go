@@ -0,0 +1,67 @@
+package templates
+
+// MenuBar chooses which Control Center modules have their own menu bar
+// item. Control Center is relaunched after a change.
+//
+//	[menubar]
+//	battery_percentage = true
+//	show = ["bluetooth", "sound"]
+//	hide = ["now-playing"]
+type MenuBar struct {
+	BatteryPercentage *bool `toml:"battery_percentage"`
+	// Show and Hide name items: bluetooth, sound, wifi, display, focus,
+	// now-playing, airdrop or screen-mirroring.
+	Show []string `toml:"show"`
+	Hide []string `toml:"hide"`
+}
+
+// MenuBarItems maps item names to their com.apple.controlcenter keys.
+var MenuBarItems = map[string]string{
+	"airdrop":          "AirDrop",
+	"bluetooth":        "Bluetooth",
+	"display":          "Display",
+	"focus":            "FocusModes",
+	"now-playing":      "NowPlaying",
+	"screen-mirroring": "ScreenMirroring",
+	"sound":            "Sound",
+	"wifi":             "WiFi",
+}
+
+// Control Center's per-host item states: 18 always shows the item, 24
+// leaves it in Control Center only.
+const (
+	menuBarShown  = int64(18)
+	menuBarHidden = int64(24)
+)
+
+// Settings returns the preferences m sets. Unknown items are left to Lint.
+func (m MenuBar) Settings() []GroupSetting {
+	const domain = "com.apple.controlcenter"
+	var out []GroupSetting
+	if m.BatteryPercentage != nil {
+		out = append(out, GroupSetting{Domain: domain, Key: "BatteryShowPercentage", Value: *m.BatteryPercentage, CurrentHost: true, Restart: "ControlCenter"})
+	}
+	item := func(name string, shown bool) {
+		key, ok := MenuBarItems[name]
+		if !ok {
+			return
+		}
+		state := menuBarHidden
+		if shown {
+			state = menuBarShown
+		}
+		// System Settings writes both: the per-host state Control Center
+		// reads, and the status item's visibility.
+		out = append(out,
+			GroupSetting{Domain: domain, Key: key, Value: state, CurrentHost: true, Restart: "ControlCenter"},
+			GroupSetting{Domain: domain, Key: "NSStatusItem Visible " + key, Value: shown, Restart: "ControlCenter"},
+		)
+	}
+	for _, name := range m.Show {
+		item(name, true)
+	}
+	for _, name := range m.Hide {
+		item(name, false)
+	}
+	return out
+}
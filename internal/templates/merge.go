@@ -0,0 +1,69 @@
+package templates
+
+import "fmt"
+
+// Merge layers personal over an organization baseline. Every baseline entry
+// and test is kept and marked locked; personal entries for the same software
+// cannot narrow a baseline entry with their own `when`. Personal variables
+// override baseline defaults, and a personal test matrix or proxy replaces
+// the baseline's. The returned notes describe every personal setting that
+// was overruled.
+func Merge(baseline, personal *Template) (*Template, []string) {
+	merged := &Template{
+		Name:        personal.Name,
+		Description: personal.Description,
+		Vars:        map[string]string{},
+		Matrix:      personal.Matrix,
+		Demo:        personal.Demo,
+		Proxy:       personal.Proxy,
+		Path:        personal.Path,
+		Raw:         personal.Raw,
+	}
+	var notes []string
+
+	for k, v := range baseline.Vars {
+		merged.Vars[k] = v
+	}
+	for k, v := range personal.Vars {
+		merged.Vars[k] = v
+	}
+
+	locked := map[string]bool{}
+	for _, e := range baseline.Software {
+		e.Origin = OriginBaseline
+		locked[e.ID] = true
+		merged.Software = append(merged.Software, e)
+	}
+	for _, e := range personal.Software {
+		if locked[e.ID] {
+			if e.When != "" {
+				notes = append(notes, fmt.Sprintf("%s: baseline requires it; personal condition %q ignored", e.ID, e.When))
+			}
+			continue
+		}
+		merged.Software = append(merged.Software, e)
+	}
+
+	lockedTests := map[string]bool{}
+	for _, tc := range baseline.Tests {
+		tc.Origin = OriginBaseline
+		lockedTests[tc.Name] = true
+		merged.Tests = append(merged.Tests, tc)
+	}
+	for _, tc := range personal.Tests {
+		if tc.Name != "" && lockedTests[tc.Name] {
+			notes = append(notes, fmt.Sprintf("test %q: baseline definition takes precedence", tc.Name))
+			continue
+		}
+		merged.Tests = append(merged.Tests, tc)
+	}
+	if len(merged.Matrix) == 0 {
+		merged.Matrix = baseline.Matrix
+	}
+	// The organization's proxy and mirrors apply unless the personal
+	// template sets its own.
+	if merged.Proxy.IsZero() {
+		merged.Proxy = baseline.Proxy
+	}
+	return merged, notes
+}
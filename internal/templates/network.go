@@ -0,0 +1,26 @@
+package templates
+
+// Network configures one network service (as listed by `networksetup
+// -listallnetworkservices`). Unset fields are left alone; an empty list
+// clears the setting and a proxy of "off" disables it.
+//
+//	[[network]]
+//	service = "Wi-Fi"
+//	dns = ["10.0.0.53", "1.1.1.1"]
+//	search_domains = ["corp.example.com"]
+//	web_proxy = "proxy.corp.example.com:8080"
+//	proxy_bypass = ["*.local", "169.254/16"]
+type Network struct {
+	Service        string   `toml:"service"`
+	DNS            []string `toml:"dns"`
+	SearchDomains  []string `toml:"search_domains"`
+	WebProxy       string   `toml:"web_proxy"`
+	SecureWebProxy string   `toml:"secure_web_proxy"`
+	SOCKSProxy     string   `toml:"socks_proxy"`
+	ProxyBypass    []string `toml:"proxy_bypass"`
+	AutoProxyURL   string   `toml:"auto_proxy_url"`
+	When           string   `toml:"when"`
+}
+
+// ProxyOff disables a proxy instead of leaving it unmanaged.
+const ProxyOff = "off"
@@ -0,0 +1,24 @@
+package templates
+
+// Order puts resources in order beyond what they require themselves, e.g.
+// so repositories are cloned once the SSH config is written. Resources are
+// named by the IDs `maziq plan` shows, with ~ for the home directory and *
+// matching any run of characters:
+//
+//	[[order]]
+//	resource = "repo:~/src/*"
+//	requires = ["file:~/.ssh/config"]
+//
+//	[[order]]
+//	resource = "dotfile:~/.zshrc"
+//	before = ["software:zsh_*"]
+//
+// Requires makes the matched resources wait for the required ones, and
+// skips them when one fails. Before is the same from the other side: the
+// listed resources require the matched ones.
+type Order struct {
+	Resource string   `toml:"resource"`
+	Requires []string `toml:"requires"`
+	Before   []string `toml:"before"`
+	When     string   `toml:"when"`
+}
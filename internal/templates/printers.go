@@ -0,0 +1,23 @@
+package templates
+
+// Printer is a CUPS print queue added with lpadmin.
+//
+//	[[printers]]
+//	name = "Office_LaserJet"
+//	address = "ipp://10.0.0.40/ipp/print"
+//	driver = "everywhere"
+//	location = "2nd floor"
+//	default = true
+//
+// Driver is "everywhere" for driverless IPP printers, a model from
+// `lpinfo -m`, or a path to a PPD file.
+type Printer struct {
+	Name        string            `toml:"name"`
+	Description string            `toml:"description"`
+	Location    string            `toml:"location"`
+	Address     string            `toml:"address"`
+	Driver      string            `toml:"driver"`
+	Default     bool              `toml:"default"`
+	Options     map[string]string `toml:"options"`
+	When        string            `toml:"when"`
+}
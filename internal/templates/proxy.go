@@ -0,0 +1,61 @@
+package templates
+
+import "strings"
+
+// Proxy routes MazIQ and the package managers it runs through a corporate
+// proxy and artifact mirrors, in place of exporting the variables by hand.
+// Set fields become the usual environment variables for every command
+// apply runs and for MazIQ's own downloads; unset ones are left as the
+// shell had them.
+//
+//	[proxy]
+//	https = "http://proxy.corp.example.com:8080"
+//	no_proxy = [".corp.example.com", "localhost"]
+//	bottle_domain = "https://artifacts.corp.example.com/homebrew-bottles"
+//	api_domain = "https://artifacts.corp.example.com/homebrew-api"
+//	npm_registry = "https://artifacts.corp.example.com/npm/"
+type Proxy struct {
+	// HTTP and HTTPS are the proxies for plain and TLS requests.
+	HTTP    string   `toml:"http"`
+	HTTPS   string   `toml:"https"`
+	NoProxy []string `toml:"no_proxy"`
+	// BottleDomain, APIDomain and ArtifactDomain point Homebrew's bottle,
+	// JSON API and every other download at mirrors.
+	BottleDomain   string `toml:"bottle_domain"`
+	APIDomain      string `toml:"api_domain"`
+	ArtifactDomain string `toml:"artifact_domain"`
+	// BrewGitRemote and CoreGitRemote are mirrors of Homebrew's own
+	// repositories, for installs that cannot reach GitHub.
+	BrewGitRemote string `toml:"brew_git_remote"`
+	CoreGitRemote string `toml:"core_git_remote"`
+	NPMRegistry   string `toml:"npm_registry"`
+	PyPIIndex     string `toml:"pypi_index"`
+}
+
+// IsZero reports whether the template configures no proxy or mirror.
+func (p Proxy) IsZero() bool { return len(p.Environ()) == 0 }
+
+// Environ returns the set fields as KEY=VALUE pairs. Proxies are set in both
+// cases, as tools disagree about which they read.
+func (p Proxy) Environ() []string {
+	var env []string
+	set := func(value string, keys ...string) {
+		if value == "" {
+			return
+		}
+		for _, k := range keys {
+			env = append(env, k+"="+value)
+		}
+	}
+	set(p.HTTP, "HTTP_PROXY", "http_proxy")
+	set(p.HTTPS, "HTTPS_PROXY", "https_proxy")
+	set(strings.Join(p.NoProxy, ","), "NO_PROXY", "no_proxy")
+	set(p.BottleDomain, "HOMEBREW_BOTTLE_DOMAIN")
+	set(p.APIDomain, "HOMEBREW_API_DOMAIN")
+	set(p.ArtifactDomain, "HOMEBREW_ARTIFACT_DOMAIN")
+	set(p.BrewGitRemote, "HOMEBREW_BREW_GIT_REMOTE")
+	set(p.CoreGitRemote, "HOMEBREW_CORE_GIT_REMOTE")
+	set(p.NPMRegistry, "NPM_CONFIG_REGISTRY")
+	set(p.PyPIIndex, "UV_DEFAULT_INDEX", "PIP_INDEX_URL")
+	return env
+}
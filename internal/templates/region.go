@@ -0,0 +1,36 @@
+package templates
+
+// Region sets the time zone and the formats System Settings → General →
+// Language & Region controls.
+//
+//	[region]
+//	timezone = "Europe/Berlin"
+//	locale = "en_DE"            # language_COUNTRY, as in AppleLocale
+//	measurement = "metric"      # metric or us
+//	first_weekday = "monday"
+type Region struct {
+	// Timezone is a zoneinfo name. Setting it turns off "Set time zone
+	// automatically using your current location", which would change it
+	// back.
+	Timezone     string `toml:"timezone"`
+	Locale       string `toml:"locale"`
+	Measurement  string `toml:"measurement"`
+	FirstWeekday string `toml:"first_weekday"`
+}
+
+// Weekdays maps day names to the numbers AppleFirstWeekday uses.
+var Weekdays = map[string]int64{
+	"sunday":    1,
+	"monday":    2,
+	"tuesday":   3,
+	"wednesday": 4,
+	"thursday":  5,
+	"friday":    6,
+	"saturday":  7,
+}
+
+// MeasurementUnits maps measurement systems to AppleMeasurementUnits.
+var MeasurementUnits = map[string]string{
+	"metric": "Centimeters",
+	"us":     "Inches",
+}
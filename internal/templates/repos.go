@@ -0,0 +1,62 @@
+package templates
+
+import (
+	"path"
+	"strings"
+)
+
+// Repos clones project repositories into a workspace directory.
+//
+//	[repos]
+//	workspace = "~/Code"
+//	ssh_key = "~/.ssh/id_ed25519"
+//
+//	[[repos.repo]]
+//	url = "git@github.com:acme/api.git"
+//	bootstrap = "make setup"
+//
+//	[[repos.repo]]
+//	url = "https://github.com/acme/docs.git"
+//	path = "acme-docs"
+//	branch = "main"
+type Repos struct {
+	// Workspace is the parent directory for relative repo paths.
+	Workspace string `toml:"workspace"`
+	// SSHKey must exist before SSH URLs are cloned. When empty any of the
+	// usual ~/.ssh/id_* keys will do.
+	SSHKey string `toml:"ssh_key"`
+	Repos  []Repo `toml:"repo"`
+}
+
+// Repo is one git repository.
+type Repo struct {
+	URL string `toml:"url"`
+	// Path is where to clone, relative to the workspace; it defaults to the
+	// repository name.
+	Path   string `toml:"path"`
+	Branch string `toml:"branch"`
+	// Bootstrap runs with bash inside the fresh clone.
+	Bootstrap string `toml:"bootstrap"`
+	When      string `toml:"when"`
+}
+
+// Dir returns the clone directory relative to the workspace.
+func (r Repo) Dir() string {
+	if r.Path != "" {
+		return r.Path
+	}
+	name := strings.TrimSuffix(strings.TrimRight(r.URL, "/"), ".git")
+	if i := strings.LastIndexAny(name, "/:"); i >= 0 {
+		name = name[i+1:]
+	}
+	return path.Clean(name)
+}
+
+// SSH reports whether the URL is cloned over SSH.
+func (r Repo) SSH() bool {
+	if strings.HasPrefix(r.URL, "ssh://") {
+		return true
+	}
+	// scp-like syntax: user@host:path
+	return !strings.Contains(r.URL, "://") && strings.Contains(r.URL, "@") && strings.Contains(r.URL, ":")
+}
@@ -0,0 +1,190 @@
+package templates
+
+import (
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+//go:generate go run gen_docs.go
+
+// Section is a top-level key of a template file. Its keys and types are read
+// from the same struct tags the decoder uses to reject unknown keys, and its
+// prose and example from the doc comments of the Go types, so explain and the
+// manual cannot drift from what Parse accepts.
+type Section struct {
+	Key  string `json:"key"`
+	Type string `json:"type"`
+	Doc  string `json:"doc"`
+	// Example is TOML taken from the type's doc comment.
+	Example string  `json:"example,omitempty"`
+	Fields  []Field `json:"fields,omitempty"`
+	// Conditional sections take a `when` condition per entry.
+	Conditional bool `json:"conditional"`
+}
+
+// Field is a key inside a section. Keys of nested tables are dotted.
+type Field struct {
+	Key  string `json:"key"`
+	Type string `json:"type"`
+	Doc  string `json:"doc,omitempty"`
+}
+
+// Sections describes every top-level key in file order.
+func Sections() []Section {
+	var out []Section
+	t := reflect.TypeOf(Template{})
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		key := tomlKey(f)
+		if key == "" {
+			continue
+		}
+		s := Section{Key: key, Type: typeName(f.Type)}
+		doc := fieldDocs["Template."+f.Name]
+		if st := structOf(f.Type); st != nil {
+			if d := typeDocs[st.Name()]; d != "" {
+				doc = d
+			}
+			s.Fields = fields(st, "", map[reflect.Type]bool{})
+			for _, fd := range s.Fields {
+				if fd.Key == "when" || strings.HasSuffix(fd.Key, ".when") {
+					s.Conditional = true
+				}
+			}
+		}
+		s.Doc, s.Example = splitDoc(doc)
+		out = append(out, s)
+	}
+	return out
+}
+
+// LookupSection returns the section for a top-level key.
+func LookupSection(key string) (Section, bool) {
+	for _, s := range Sections() {
+		if s.Key == key {
+			return s, true
+		}
+	}
+	return Section{}, false
+}
+
+func tomlKey(f reflect.StructField) string {
+	tag, _, _ := strings.Cut(f.Tag.Get("toml"), ",")
+	switch {
+	case tag == "-" || !f.IsExported():
+		return ""
+	case tag == "":
+		return strings.ToLower(f.Name)
+	}
+	return tag
+}
+
+// fields lists the keys of st. Embedded structs are flattened the way the
+// decoder flattens them.
+func fields(st reflect.Type, prefix string, seen map[reflect.Type]bool) []Field {
+	if seen[st] {
+		return nil
+	}
+	seen[st] = true
+	defer delete(seen, st)
+	var out []Field
+	for i := 0; i < st.NumField(); i++ {
+		f := st.Field(i)
+		if f.Anonymous && f.Tag.Get("toml") == "" {
+			out = append(out, fields(f.Type, prefix, seen)...)
+			continue
+		}
+		key := tomlKey(f)
+		if key == "" {
+			continue
+		}
+		doc, _ := splitDoc(fieldDocs[st.Name()+"."+f.Name])
+		out = append(out, Field{Key: prefix + key, Type: typeName(f.Type), Doc: strings.Join(strings.Fields(doc), " ")})
+		if nested := structOf(f.Type); nested != nil && !decodesItself(nested) {
+			out = append(out, fields(nested, prefix+key+".", seen)...)
+		}
+	}
+	return out
+}
+
+// structOf returns the struct behind t, a slice of it or a pointer to it.
+func structOf(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	if t.Kind() == reflect.Struct && t != reflect.TypeOf(time.Time{}) {
+		return t
+	}
+	return nil
+}
+
+func decodesItself(t reflect.Type) bool {
+	return reflect.PointerTo(t).Implements(reflect.TypeOf((*toml.Unmarshaler)(nil)).Elem())
+}
+
+// typeName names t in TOML terms.
+func typeName(t reflect.Type) string {
+	if t == reflect.TypeOf(time.Duration(0)) {
+		return "duration"
+	}
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Map:
+		if e := typeName(t.Elem()); e != "any" {
+			return "table of " + plural(e)
+		}
+		return "table"
+	case reflect.Slice:
+		if structOf(t) != nil && !decodesItself(structOf(t)) {
+			return "array of tables"
+		}
+		return "array of " + plural(typeName(t.Elem()))
+	case reflect.Struct:
+		if decodesItself(t) {
+			return "string or table"
+		}
+		return "table"
+	}
+	return "any"
+}
+
+func plural(s string) string {
+	switch s {
+	case "any":
+		return "values"
+	case "string or table":
+		return "strings or tables"
+	}
+	return s + "s"
+}
+
+// splitDoc separates a doc comment into its prose and the tab-indented TOML
+// example blocks.
+func splitDoc(doc string) (prose, example string) {
+	var p, e []string
+	for _, line := range strings.Split(strings.TrimRight(doc, "\n"), "\n") {
+		if ex, ok := strings.CutPrefix(line, "\t"); ok {
+			e = append(e, ex)
+			continue
+		}
+		if line == "" && len(e) > 0 && e[len(e)-1] != "" {
+			e = append(e, "")
+		}
+		p = append(p, line)
+	}
+	return strings.TrimSpace(strings.ReplaceAll(strings.Join(p, "\n"), "\n\n\n", "\n\n")), strings.TrimSpace(strings.Join(e, "\n"))
+}
@@ -0,0 +1,120 @@
+package templates
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Screensaver configures the screen saver, screen lock and hot corners.
+//
+//	[screensaver]
+//	idle = 600                 # seconds; 0 never starts it
+//	require_password = true
+//	password_delay = 0         # seconds after sleep or screen saver
+//	hot_corners = { bottom_right = "lock-screen", top_left = "screen-saver" }
+//
+// A corner that should only fire while keys are held takes a table:
+//
+//	[screensaver.hot_corners]
+//	top_right = { action = "desktop", modifier = "command+option" }
+type Screensaver struct {
+	Idle            *int64 `toml:"idle"`
+	RequirePassword *bool  `toml:"require_password"`
+	PasswordDelay   int64  `toml:"password_delay"`
+	// PasswordSecret names the secret holding the login password, which
+	// sysadminctl needs to change the screen lock. Default "login-password".
+	PasswordSecret string               `toml:"password_secret"`
+	HotCorners     map[string]HotCorner `toml:"hot_corners"`
+}
+
+// HotCorner is the action of one corner and the modifier keys it needs. In
+// TOML it is either the action name or a table with action and modifier.
+type HotCorner struct {
+	Action string `toml:"action"`
+	// Modifier is shift, control, option or command, or several joined
+	// with +; empty fires without a key.
+	Modifier string `toml:"modifier"`
+}
+
+// UnmarshalTOML accepts both the string and table forms of a corner.
+func (h *HotCorner) UnmarshalTOML(v any) error {
+	switch v := v.(type) {
+	case string:
+		h.Action = v
+	case map[string]any:
+		for key, val := range v {
+			s, ok := val.(string)
+			if !ok {
+				return fmt.Errorf("hot corner field %q must be a string", key)
+			}
+			switch key {
+			case "action":
+				h.Action = s
+			case "modifier":
+				h.Modifier = s
+			default:
+				return fmt.Errorf("unknown hot corner field %q", key)
+			}
+		}
+	default:
+		return fmt.Errorf("hot corner must be an action name or table, got %T", v)
+	}
+	return nil
+}
+
+// HotCornerModifiers maps modifier names to the dock's wvous-*-modifier
+// flags.
+var HotCornerModifiers = map[string]int64{
+	"shift":   1 << 17,
+	"control": 1 << 18,
+	"option":  1 << 19,
+	"command": 1 << 20,
+}
+
+// Modifiers returns the names of the corner's modifier keys, as written.
+func (h HotCorner) Modifiers() []string {
+	if strings.TrimSpace(h.Modifier) == "" {
+		return nil
+	}
+	keys := strings.Split(h.Modifier, "+")
+	for i, key := range keys {
+		keys[i] = strings.TrimSpace(key)
+	}
+	return keys
+}
+
+// ModifierFlags returns the wvous-*-modifier value of the corner's keys.
+func (h HotCorner) ModifierFlags() (int64, error) {
+	var flags int64
+	for _, key := range h.Modifiers() {
+		flag, ok := HotCornerModifiers[key]
+		if !ok {
+			return 0, fmt.Errorf("unknown modifier %q (want shift, control, option or command)", key)
+		}
+		flags |= flag
+	}
+	return flags, nil
+}
+
+// HotCornerActions maps action names to the dock's wvous-*-corner codes.
+var HotCornerActions = map[string]int64{
+	"none":                 1,
+	"mission-control":      2,
+	"application-windows":  3,
+	"desktop":              4,
+	"screen-saver":         5,
+	"disable-screen-saver": 6,
+	"display-sleep":        10,
+	"launchpad":            11,
+	"notification-center":  12,
+	"lock-screen":          13,
+	"quick-note":           14,
+}
+
+// HotCorners maps corner names to their dock key prefix.
+var HotCorners = map[string]string{
+	"top_left":     "wvous-tl",
+	"top_right":    "wvous-tr",
+	"bottom_left":  "wvous-bl",
+	"bottom_right": "wvous-br",
+}
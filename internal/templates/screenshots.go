@@ -0,0 +1,29 @@
+package templates
+
+// Screenshots configures where and how screenshots and screen recordings are
+// saved.
+//
+//	[screenshots]
+//	format = "png"
+//	location = "~/Pictures/Screenshots"
+//	shadow = false
+//	thumbnail = false
+type Screenshots struct {
+	// Format is the image type: png, jpg, heic, pdf, tiff, gif or bmp.
+	Format string `toml:"format"`
+	// Location is the folder captures are saved to; it is created if missing.
+	Location string `toml:"location"`
+	// Name replaces the "Screenshot" file name prefix.
+	Name string `toml:"name"`
+	// Shadow keeps the drop shadow on window captures.
+	Shadow *bool `toml:"shadow"`
+	// Thumbnail shows the floating preview after a capture.
+	Thumbnail   *bool `toml:"thumbnail"`
+	IncludeDate *bool `toml:"include_date"`
+	ShowCursor  *bool `toml:"show_cursor"`
+	// ShowClicks draws mouse clicks in screen recordings.
+	ShowClicks *bool `toml:"show_clicks"`
+}
+
+// ScreenshotFormats are the image types screencapture can write.
+var ScreenshotFormats = []string{"png", "jpg", "heic", "pdf", "tiff", "gif", "bmp"}
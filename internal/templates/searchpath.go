@@ -0,0 +1,38 @@
+package templates
+
+// SearchPath puts directories at the front of PATH, in order, from the same
+// managed rc block as [env]. An entry is a directory or the name of one
+// that tools install their commands into:
+//
+//	[path]
+//	order = ["brew", "cargo", "go", "~/bin"]
+//
+// Names are brew (Homebrew's bin and sbin), cargo, go, bun, local
+// (~/.local/bin, where uv and pipx put tools) and system (macOS's own).
+type SearchPath struct {
+	Order []string `toml:"order"`
+}
+
+// PathDirs returns the directories a [path] name stands for on arch, with
+// ~ for the home directory.
+func PathDirs(name, arch string) ([]string, bool) {
+	switch name {
+	case "brew":
+		prefix := "/usr/local"
+		if arch == "arm64" {
+			prefix = "/opt/homebrew"
+		}
+		return []string{prefix + "/bin", prefix + "/sbin"}, true
+	case "cargo":
+		return []string{"~/.cargo/bin"}, true
+	case "go":
+		return []string{"~/go/bin"}, true
+	case "bun":
+		return []string{"~/.bun/bin"}, true
+	case "local":
+		return []string{"~/.local/bin"}, true
+	case "system":
+		return []string{"/usr/bin", "/bin", "/usr/sbin", "/sbin"}, true
+	}
+	return nil, false
+}
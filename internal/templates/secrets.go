@@ -0,0 +1,135 @@
+package templates
+
+import (
+	"context"
+	"reflect"
+	"regexp"
+	"sort"
+
+	"github.com/hmziqrs/maziq/internal/secrets"
+)
+
+// sensitiveName matches variable names that should never be committed in
+// plaintext.
+var sensitiveName = regexp.MustCompile(`(?i)(secret|token|password|passwd|license|api_?key|private)`)
+
+// EncryptedSections lists the tables `maziq secrets encrypt` targets by
+// default.
+var EncryptedSections = []string{"vars"}
+
+// Encrypted reports whether any value in a section holds an encrypted value.
+func (t *Template) Encrypted() bool {
+	found := false
+	_ = t.eachString(func(_, v string) (string, bool, error) {
+		found = found || secrets.IsEncrypted(v)
+		return "", false, nil
+	})
+	return found
+}
+
+// Decrypt replaces encrypted values in every section with their plaintext
+// using the template key from p. Templates without encrypted values never
+// touch p, so the Keychain is only consulted when it is actually needed.
+func (t *Template) Decrypt(ctx context.Context, p secrets.Provider) error {
+	if !t.Encrypted() {
+		return nil
+	}
+	key, err := secrets.LoadKey(ctx, p)
+	if err != nil {
+		return err
+	}
+	return t.eachString(func(path, v string) (string, bool, error) {
+		if !secrets.IsEncrypted(v) {
+			return "", false, nil
+		}
+		plain, err := secrets.Decrypt(key, path, v)
+		return plain, err == nil, err
+	})
+}
+
+// eachString calls fn with the dotted path and value of every string in the
+// template's tables, replacing the value when fn says so. Paths are the ones
+// secrets.TransformFile gives: entries of an array of tables share the
+// array's path, as in licenses.content.
+func (t *Template) eachString(fn func(path, value string) (string, bool, error)) error {
+	v := reflect.ValueOf(t).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		key := tomlKey(v.Type().Field(i))
+		// Top-level strings are outside any table and never encrypted.
+		if key == "" || v.Field(i).Kind() == reflect.String {
+			continue
+		}
+		if err := eachString(key, v.Field(i), fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func eachString(path string, v reflect.Value, fn func(path, value string) (string, bool, error)) error {
+	switch v.Kind() {
+	case reflect.String:
+		out, ok, err := fn(path, v.String())
+		if ok && v.CanSet() {
+			v.SetString(out)
+		}
+		return err
+	case reflect.Interface:
+		if s, isString := v.Interface().(string); isString {
+			out, ok, err := fn(path, s)
+			if ok && v.CanSet() {
+				v.Set(reflect.ValueOf(out))
+			}
+			return err
+		}
+	case reflect.Pointer:
+		if !v.IsNil() {
+			return eachString(path, v.Elem(), fn)
+		}
+	case reflect.Slice:
+		// Arrays of strings are never single-line string values.
+		if v.Type().Elem().Kind() == reflect.String {
+			return nil
+		}
+		for i := 0; i < v.Len(); i++ {
+			if err := eachString(path, v.Index(i), fn); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		for _, k := range v.MapKeys() {
+			if k.Kind() != reflect.String {
+				continue
+			}
+			// Map values are not addressable: work on a copy and store it.
+			elem := reflect.New(v.Type().Elem()).Elem()
+			elem.Set(v.MapIndex(k))
+			if err := eachString(path+"."+k.String(), elem, fn); err != nil {
+				return err
+			}
+			v.SetMapIndex(k, elem)
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if key := tomlKey(v.Type().Field(i)); key != "" {
+				if err := eachString(path+"."+key, v.Field(i), fn); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// plaintextSecrets returns variable names that look sensitive but are stored
+// unencrypted.
+func (t *Template) plaintextSecrets() []string {
+	var out []string
+	for name, v := range t.Vars {
+		if v != "" && sensitiveName.MatchString(name) && !secrets.IsEncrypted(v) {
+			out = append(out, name)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,21 @@
+package templates
+
+// Service is a Homebrew formula service managed with `brew services`.
+//
+//	[[services]]
+//	name = "postgresql@16"
+//
+//	[[services]]
+//	name = "redis"
+//	state = "stopped"
+type Service struct {
+	Name  string `toml:"name"`
+	State string `toml:"state"` // ServiceStarted (default) or ServiceStopped
+	When  string `toml:"when"`
+}
+
+// Service states.
+const (
+	ServiceStarted = "started"
+	ServiceStopped = "stopped"
+)
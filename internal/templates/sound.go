@@ -0,0 +1,40 @@
+package templates
+
+// Sound sets the alert volume, interface sound effects and the startup
+// chime.
+//
+//	[sound]
+//	alert_volume = 50          # percent of the output volume
+//	ui_sounds = false          # Finder, trash and screenshot sounds
+//	volume_feedback = false    # beep when the volume keys are pressed
+//	startup_chime = false
+type Sound struct {
+	AlertVolume    *int64 `toml:"alert_volume"`
+	UISounds       *bool  `toml:"ui_sounds"`
+	VolumeFeedback *bool  `toml:"volume_feedback"`
+	// StartupChime is kept in NVRAM, which needs sudo to change.
+	StartupChime *bool `toml:"startup_chime"`
+}
+
+// Settings returns the preferences s sets; the startup chime is not one.
+func (s Sound) Settings() []GroupSetting {
+	var out []GroupSetting
+	if s.AlertVolume != nil {
+		out = append(out, GroupSetting{Domain: "NSGlobalDomain", Key: "com.apple.sound.beep.volume", Value: float64(*s.AlertVolume) / 100})
+	}
+	if s.UISounds != nil {
+		out = append(out, GroupSetting{Domain: "com.apple.systemsound", Key: "com.apple.sound.uiaudio.enabled", Value: flag(*s.UISounds)})
+	}
+	if s.VolumeFeedback != nil {
+		out = append(out, GroupSetting{Domain: "NSGlobalDomain", Key: "com.apple.sound.beep.feedback", Value: flag(*s.VolumeFeedback)})
+	}
+	return out
+}
+
+// flag is the 0 or 1 integer some switches are stored as.
+func flag(on bool) int64 {
+	if on {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,13 @@
+package templates
+
+// Spotlight keeps directories and volumes out of the Spotlight index.
+//
+//	[spotlight]
+//	exclude = ["~/code", "~/VMs"]
+//	disable_volumes = ["/Volumes/Backup"]
+type Spotlight struct {
+	// Exclude adds directories to Spotlight's privacy list.
+	Exclude []string `toml:"exclude"`
+	// DisableVolumes turns indexing off entirely with mdutil.
+	DisableVolumes []string `toml:"disable_volumes"`
+}
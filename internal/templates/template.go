@@ -0,0 +1,351 @@
+// Package templates loads and validates MazIQ template files.
+package templates
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+
+	"github.com/hmziqrs/maziq/internal/catalog"
+	"github.com/hmziqrs/maziq/internal/config"
+	builtin "github.com/hmziqrs/maziq/templates"
+)
+
+// Template is a parsed template file: the software to provision plus the
+// variables, conditions and tests that go with it.
+type Template struct {
+	// Name identifies the template in the TUI and in reports.
+	Name string `toml:"name"`
+	// Description is shown next to the name when choosing a template.
+	Description string `toml:"description"`
+	// Vars are referenced as ${name} in commands, paths and URLs, and as
+	// bare identifiers in `when` conditions.
+	Vars     map[string]string `toml:"vars"`
+	Software []Entry           `toml:"software"`
+	Tests    []Test            `toml:"tests"`
+	Matrix   []Platform        `toml:"matrix"`
+	Licenses []License         `toml:"licenses"`
+	Fonts    []Font            `toml:"fonts"`
+	AppStore []AppStoreApp     `toml:"mas"`
+	Apps     []DirectApp       `toml:"apps"`
+	Binaries []Binary          `toml:"binaries"`
+	Browsers Browsers          `toml:"browsers"`
+	// Handlers maps file extensions, UTIs and URL schemes to the bundle ID
+	// of their default app.
+	Handlers map[string]string `toml:"handlers"`
+	// Env holds environment variables exported from the managed block of
+	// the login shell's rc file, e.g. EDITOR = "nvim". Values may refer to
+	// variables set before it, as in GOPATH = "$HOME/go".
+	Env        map[string]string `toml:"env"`
+	SearchPath SearchPath        `toml:"path"`
+	// Aliases and Functions are defined in the managed rc block, after
+	// [env] and [path]. A function's body is in the login shell's syntax:
+	// mkcd = 'mkdir -p "$1" && cd "$1"'.
+	Aliases   map[string]string `toml:"aliases"`
+	Functions map[string]string `toml:"functions"`
+	// Completions = false stops MazIQ installing the completion scripts of
+	// the template's CLIs and loading them from the managed rc block.
+	Completions *bool       `toml:"completions"`
+	Network     []Network   `toml:"network"`
+	Proxy       Proxy       `toml:"proxy"`
+	WiFi        []WiFi      `toml:"wifi"`
+	Printers    []Printer   `toml:"printers"`
+	Energy      Energy      `toml:"energy"`
+	Updates     Updates     `toml:"updates"`
+	Defaults    []Default   `toml:"defaults"`
+	Files       []File      `toml:"files"`
+	Directories []Directory `toml:"directories"`
+	Screensaver Screensaver `toml:"screensaver"`
+	Screenshots Screenshots `toml:"screenshots"`
+	Spotlight   Spotlight   `toml:"spotlight"`
+	Region      Region      `toml:"region"`
+	Trackpad    Trackpad    `toml:"trackpad"`
+	MenuBar     MenuBar     `toml:"menubar"`
+	Sound       Sound       `toml:"sound"`
+	Services    []Service   `toml:"services"`
+	Jobs        []Job       `toml:"jobs"`
+	Databases   []Database  `toml:"databases"`
+	Repos       Repos       `toml:"repos"`
+	Direnv      Direnv      `toml:"direnv"`
+	Kubernetes  Kubernetes  `toml:"kubernetes"`
+	Cloud       Cloud       `toml:"cloud"`
+	Terminal    Terminal    `toml:"terminal"`
+	Tmux        Tmux        `toml:"tmux"`
+	Karabiner   Karabiner   `toml:"karabiner"`
+	Skhd        Skhd        `toml:"skhd"`
+	Yabai       Yabai       `toml:"yabai"`
+	Manual      []Manual    `toml:"manual"`
+	Order       []Order     `toml:"order"`
+	Groups      []Group     `toml:"group"`
+	Demo        *Demo       `toml:"demo"`
+
+	// Path is where the template was loaded from. Built-in templates use a
+	// "builtin:" prefix.
+	Path string `toml:"-"`
+	// Raw is the unparsed file contents.
+	Raw []byte `toml:"-"`
+	// Profile selects the groups that name profiles; see Group.
+	Profile string `toml:"-"`
+	// Toggles turn groups on or off for one run, by name, over what the
+	// template and profile say.
+	Toggles map[string]bool `toml:"-"`
+	// Shared is the template as committed, before the machine's LocalFile
+	// was merged over it; nil when there is none.
+	Shared *Template `toml:"-"`
+}
+
+// Entry is a software item in a template. In TOML it is either a bare catalog
+// ID or an inline table with an optional `when` condition:
+//
+//	software = ["go", { id = "rosetta", when = 'arch == "arm64"' }]
+//
+// The table form can also install the entry from another package on Intel
+// or Apple silicon Macs, for software whose builds are separate packages:
+//
+//	software = [{ id = "foo", intel = { cask = "foo-intel" } }]
+type Entry struct {
+	ID   string `toml:"id"`
+	When string `toml:"when"`
+	// Arch replaces the catalog's sources on one architecture, "amd64"
+	// (intel in TOML) or "arm64" (arm).
+	Arch map[string]catalog.Source `toml:"-"`
+
+	// Origin records where a merged entry came from, e.g. OriginBaseline.
+	Origin string `toml:"-"`
+}
+
+// OriginBaseline marks entries contributed by an organization baseline.
+// They are locked: the personal template cannot remove or narrow them.
+const OriginBaseline = "baseline"
+
+// Locked reports whether the entry is enforced by a baseline.
+func (e Entry) Locked() bool {
+	return e.Origin == OriginBaseline
+}
+
+// UnmarshalTOML accepts both the string and table forms of an entry.
+func (e *Entry) UnmarshalTOML(v any) error {
+	switch v := v.(type) {
+	case string:
+		e.ID = v
+	case map[string]any:
+		for key, val := range v {
+			if arch, ok := entryArches[key]; ok {
+				src, err := archSource(key, val)
+				if err != nil {
+					return err
+				}
+				if e.Arch == nil {
+					e.Arch = map[string]catalog.Source{}
+				}
+				e.Arch[arch] = src
+				continue
+			}
+			s, ok := val.(string)
+			if !ok {
+				return fmt.Errorf("software entry field %q must be a string", key)
+			}
+			switch key {
+			case "id":
+				e.ID = s
+			case "when":
+				e.When = s
+			default:
+				return fmt.Errorf("unknown software entry field %q", key)
+			}
+		}
+	default:
+		return fmt.Errorf("software entry must be a string or table, got %T", v)
+	}
+	return nil
+}
+
+// entryArches maps the architecture keys of an entry to arch fact values.
+var entryArches = map[string]string{"intel": "amd64", "arm": "arm64"}
+
+// archSource reads an entry's per-architecture package, such as
+// { cask = "foo-intel" }.
+func archSource(key string, v any) (catalog.Source, error) {
+	m, ok := v.(map[string]any)
+	if !ok || len(m) != 1 {
+		return catalog.Source{}, fmt.Errorf("software entry field %q must name one package, like { cask = \"name\" }", key)
+	}
+	for backend, pkg := range m {
+		name, ok := pkg.(string)
+		switch b := catalog.Backend(backend); {
+		case !ok || name == "":
+			return catalog.Source{}, fmt.Errorf("software entry %s.%s must be a package name", key, backend)
+		case b == catalog.BackendBrew, b == catalog.BackendCask, b == catalog.BackendCargo, b == catalog.BackendNPM, b == catalog.BackendUV:
+			return catalog.Source{Backend: b, Package: name}, nil
+		}
+		return catalog.Source{}, fmt.Errorf("software entry %s: unknown backend %q (want brew, cask, cargo, npm or uv)", key, backend)
+	}
+	return catalog.Source{}, nil
+}
+
+// Test is an E2E assertion run after provisioning.
+type Test struct {
+	Name     string `toml:"name"`
+	Software string `toml:"software"`
+	Run      string `toml:"run"`
+	Expect   string `toml:"expect"`
+	When     string `toml:"when"`
+
+	Origin string `toml:"-"`
+}
+
+// CompletionsOn reports whether shell completions are set up for the
+// template's CLIs.
+func (t *Template) CompletionsOn() bool {
+	return t.Completions == nil || *t.Completions
+}
+
+// IsBuiltin reports whether the template ships embedded in the binary.
+func (t *Template) IsBuiltin() bool {
+	return strings.HasPrefix(t.Path, "builtin:")
+}
+
+// Rel resolves a path relative to the template file. Absolute and ~ paths,
+// and every path of a built-in template, are returned as they are.
+func (t *Template) Rel(path string) string {
+	if path == "" || filepath.IsAbs(path) || path == "~" || strings.HasPrefix(path, "~/") || t.IsBuiltin() || t.Path == "" {
+		return path
+	}
+	return filepath.Join(filepath.Dir(t.Path), path)
+}
+
+// Parse decodes template source.
+func Parse(data []byte, path string) (*Template, error) {
+	t := &Template{Path: path, Raw: data}
+	meta, err := toml.Decode(string(data), t)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	var unknown []string
+	for _, k := range meta.Undecoded() {
+		if !freeform(k.String()) {
+			unknown = append(unknown, k.String())
+		}
+	}
+	if len(unknown) > 0 {
+		return nil, fmt.Errorf("%s: unknown keys: %s", path, strings.Join(unknown, ", "))
+	}
+	if t.Vars == nil {
+		t.Vars = map[string]string{}
+	}
+	return t, nil
+}
+
+// freeformSections hold arbitrary tables passed through to another program.
+// The TOML decoder reports keys nested in their arrays of tables as
+// undecoded even though they were stored.
+var freeformSections = []string{
+	"browsers.chrome.policies",
+	"browsers.firefox.policies",
+	"defaults.value",
+	"karabiner.rule",
+	"terminal.iterm2.extra",
+}
+
+func freeform(key string) bool {
+	for _, s := range freeformSections {
+		if key == s || strings.HasPrefix(key, s+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// Load reads a template from disk.
+func Load(path string) (*Template, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data, path)
+}
+
+// Dirs returns the directories searched for user templates, in priority order.
+func Dirs() []string {
+	return []string{"templates", filepath.Join(config.Dir(), "templates")}
+}
+
+// Resolve loads a template by name or path. Names are looked up in Dirs()
+// first and then among the built-in templates.
+func Resolve(ref string) (*Template, error) {
+	if strings.HasSuffix(ref, ".toml") || strings.ContainsRune(ref, filepath.Separator) {
+		return Load(ref)
+	}
+	for _, dir := range Dirs() {
+		path := filepath.Join(dir, ref+".toml")
+		if _, err := os.Stat(path); err == nil {
+			return Load(path)
+		}
+	}
+	data, err := builtin.FS.ReadFile(ref + ".toml")
+	if err != nil {
+		return nil, fmt.Errorf("template %q not found", ref)
+	}
+	return Parse(data, "builtin:"+ref+".toml")
+}
+
+// List returns the names of every resolvable template.
+func List() []string {
+	seen := map[string]bool{}
+	add := func(name string) {
+		if strings.HasSuffix(name, ".toml") {
+			seen[strings.TrimSuffix(name, ".toml")] = true
+		}
+	}
+	for _, dir := range Dirs() {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, e := range entries {
+			add(e.Name())
+		}
+	}
+	entries, _ := builtin.FS.ReadDir(".")
+	for _, e := range entries {
+		add(e.Name())
+	}
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Lookup returns the value of a condition identifier, consulting facts before
+// template variables.
+func (t *Template) Lookup(f map[string]string) func(string) string {
+	return func(name string) string {
+		if v, ok := f[name]; ok {
+			return v
+		}
+		return t.Vars[name]
+	}
+}
+
+// Active returns the software entries whose conditions hold for the given
+// facts.
+func (t *Template) Active(f map[string]string) ([]Entry, error) {
+	var out []Entry
+	lookup := t.Lookup(f)
+	for _, e := range t.Software {
+		c, err := ParseCond(e.When)
+		if err != nil {
+			return nil, fmt.Errorf("software %q: when: %w", e.ID, err)
+		}
+		if c == nil || c.Eval(lookup) {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
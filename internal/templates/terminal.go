@@ -0,0 +1,77 @@
+package templates
+
+// Terminal renders one look across terminal emulators. Font and colors are
+// shared; keybindings and extra settings are per emulator because every
+// emulator names its actions differently.
+//
+//	[terminal]
+//	emulators = ["ghostty", "kitty"]
+//	font = "JetBrains Mono"
+//	font_size = 14
+//
+//	[terminal.colors]
+//	background = "#1e1e2e"
+//	foreground = "#cdd6f4"
+//	palette = ["#45475a", "#f38ba8", ...]   # 16 ANSI colors
+//
+//	[terminal.ghostty]
+//	keybindings = { "cmd+d" = "new_split:right" }
+type Terminal struct {
+	Emulators []string       `toml:"emulators"`
+	Font      string         `toml:"font"`
+	FontSize  float64        `toml:"font_size"`
+	Colors    TerminalColors `toml:"colors"`
+
+	Ghostty   TerminalApp `toml:"ghostty"`
+	Kitty     TerminalApp `toml:"kitty"`
+	Alacritty TerminalApp `toml:"alacritty"`
+	ITerm2    ITerm2      `toml:"iterm2"`
+}
+
+// TerminalColors is a color scheme as #rrggbb values.
+type TerminalColors struct {
+	Background string   `toml:"background"`
+	Foreground string   `toml:"foreground"`
+	Cursor     string   `toml:"cursor"`
+	Selection  string   `toml:"selection"`
+	Palette    []string `toml:"palette"`
+}
+
+// TerminalApp holds per-emulator settings.
+type TerminalApp struct {
+	// Keybindings maps a key chord such as "cmd+shift+t" to the emulator's
+	// action.
+	Keybindings map[string]string `toml:"keybindings"`
+	// Extra is appended verbatim to the rendered config.
+	Extra string `toml:"extra"`
+}
+
+// ITerm2 holds iTerm2 dynamic profile settings.
+type ITerm2 struct {
+	// Profile is the dynamic profile name; it defaults to "maziq".
+	Profile string `toml:"profile"`
+	// Font is the PostScript font name, e.g. "JetBrainsMono-Regular";
+	// derived from the shared font when empty.
+	Font string `toml:"font"`
+	// Extra holds raw profile keys such as "Keyboard Map".
+	Extra map[string]any `toml:"extra"`
+}
+
+// Terminal emulators.
+const (
+	EmulatorGhostty   = "ghostty"
+	EmulatorKitty     = "kitty"
+	EmulatorAlacritty = "alacritty"
+	EmulatorITerm2    = "iterm2"
+)
+
+// Emulators lists the supported terminal emulators.
+var Emulators = []string{EmulatorAlacritty, EmulatorGhostty, EmulatorITerm2, EmulatorKitty}
+
+// EmulatorConfigs is the file maziq renders for each emulator.
+var EmulatorConfigs = map[string]string{
+	EmulatorGhostty:   "~/.config/ghostty/config",
+	EmulatorKitty:     "~/.config/kitty/kitty.conf",
+	EmulatorAlacritty: "~/.config/alacritty/alacritty.toml",
+	EmulatorITerm2:    "~/Library/Application Support/iTerm2/DynamicProfiles/maziq.json",
+}
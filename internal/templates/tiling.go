@@ -0,0 +1,63 @@
+package templates
+
+// Karabiner configures Karabiner-Elements.
+//
+//	[karabiner]
+//	config = "~/dotfiles/karabiner"     # linked as ~/.config/karabiner
+//
+//	[[karabiner.rule]]
+//	description = "Caps Lock to Escape"
+//	manipulators = [{ type = "basic", from = { key_code = "caps_lock" }, to = [{ key_code = "escape" }] }]
+//
+// Rules are complex modifications in Karabiner's own JSON shape. They are
+// written to assets/complex_modifications/maziq.json; enabling them is a
+// manual step in the Karabiner-Elements window.
+type Karabiner struct {
+	// Config is a directory linked as ~/.config/karabiner. Karabiner rewrites
+	// karabiner.json in place, so the whole directory is linked rather than
+	// the file.
+	Config string           `toml:"config"`
+	Rules  []map[string]any `toml:"rule"`
+}
+
+// Skhd configures the skhd hotkey daemon.
+//
+//	[skhd]
+//	hotkeys = { "alt - h" = "yabai -m window --focus west" }
+//
+// Config, when set, is linked as ~/.config/skhd/skhdrc and Hotkeys is
+// ignored.
+type Skhd struct {
+	Config  string            `toml:"config"`
+	Hotkeys map[string]string `toml:"hotkeys"`
+}
+
+// Yabai configures the yabai window manager.
+//
+//	[yabai]
+//	settings = { layout = "bsp", window_gap = 8 }
+//	rules = ['app="^System Settings$" manage=off']
+//	scripting_addition = true
+//
+// Config, when set, is linked as ~/.config/yabai/yabairc and Settings and
+// Rules are ignored.
+type Yabai struct {
+	Config   string         `toml:"config"`
+	Settings map[string]any `toml:"settings"`
+	Rules    []string       `toml:"rules"`
+	// ScriptingAddition loads yabai's Dock scripting addition, which needs
+	// System Integrity Protection partly disabled. maziq installs the
+	// sudoers entry; the SIP change is a guided manual step.
+	ScriptingAddition bool `toml:"scripting_addition"`
+}
+
+// Used reports whether the template configures yabai.
+func (y Yabai) Used() bool {
+	return y.Config != "" || len(y.Settings) > 0 || len(y.Rules) > 0 || y.ScriptingAddition
+}
+
+// Used reports whether the template configures skhd.
+func (s Skhd) Used() bool { return s.Config != "" || len(s.Hotkeys) > 0 }
+
+// Used reports whether the template configures Karabiner-Elements.
+func (k Karabiner) Used() bool { return k.Config != "" || len(k.Rules) > 0 }
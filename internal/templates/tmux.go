@@ -0,0 +1,15 @@
+package templates
+
+// Tmux links a tmux config and installs TPM plugins.
+//
+//	[tmux]
+//	config = "~/dotfiles/tmux.conf"
+//	plugins = ["tmux-plugins/tmux-sensible", "tmux-plugins/tmux-resurrect"]
+//
+// Config is linked as ~/.tmux.conf. Plugins are declared in a managed
+// ~/.tmux/plugins.conf, which the config should load with
+// `source-file ~/.tmux/plugins.conf`.
+type Tmux struct {
+	Config  string   `toml:"config"`
+	Plugins []string `toml:"plugins"`
+}
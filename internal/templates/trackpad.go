@@ -0,0 +1,94 @@
+package templates
+
+// Trackpad configures the trackpad and mouse. Pointer settings are read at
+// login, so a change asks to log out.
+//
+//	[trackpad]
+//	tap_to_click = true
+//	tracking_speed = 2.0        # 0 to 3, as the System Settings slider
+//	natural_scrolling = false
+//	three_finger_drag = true
+//	secondary_click = "two-finger"
+type Trackpad struct {
+	TapToClick    *bool    `toml:"tap_to_click"`
+	TrackingSpeed *float64 `toml:"tracking_speed"`
+	// MouseSpeed is the tracking speed of a mouse, 0 to 3.
+	MouseSpeed *float64 `toml:"mouse_speed"`
+	// NaturalScrolling moves content with the fingers; it applies to mice
+	// too.
+	NaturalScrolling *bool `toml:"natural_scrolling"`
+	// ThreeFingerDrag is under Accessibility → Pointer Control. It moves
+	// the three-finger swipes between spaces and to Mission Control to
+	// four fingers, as System Settings does.
+	ThreeFingerDrag *bool `toml:"three_finger_drag"`
+	// SecondaryClick is two-finger, bottom-right, bottom-left or off.
+	SecondaryClick string `toml:"secondary_click"`
+}
+
+// SecondaryClicks maps secondary_click values to TrackpadRightClick and
+// TrackpadCornerSecondaryClick.
+var SecondaryClicks = map[string]struct {
+	TwoFinger bool
+	Corner    int64
+}{
+	"two-finger":   {true, 0},
+	"bottom-right": {false, 2},
+	"bottom-left":  {false, 1},
+	"off":          {false, 0},
+}
+
+// Trackpad domains: the built-in trackpad's, and the Magic Trackpad's.
+const (
+	trackpadDomain  = "com.apple.AppleMultitouchTrackpad"
+	bluetoothDomain = "com.apple.driver.AppleBluetoothMultitouch.trackpad"
+)
+
+// Settings returns the preferences t sets. Unknown secondary_click values
+// are left to Lint.
+func (t Trackpad) Settings() []GroupSetting {
+	var out []GroupSetting
+	global := func(key string, value any) {
+		out = append(out, GroupSetting{Domain: "NSGlobalDomain", Key: key, Value: value, Logout: true})
+	}
+	// Both trackpads take the same keys.
+	both := func(key string, value any) {
+		for _, domain := range []string{trackpadDomain, bluetoothDomain} {
+			out = append(out, GroupSetting{Domain: domain, Key: key, Value: value, Logout: true})
+		}
+	}
+	if t.TapToClick != nil {
+		both("Clicking", *t.TapToClick)
+		// The login window reads the tap setting from the host preferences.
+		tap := int64(0)
+		if *t.TapToClick {
+			tap = 1
+		}
+		out = append(out, GroupSetting{Domain: "NSGlobalDomain", Key: "com.apple.mouse.tapBehavior", Value: tap, CurrentHost: true, Logout: true})
+	}
+	if t.TrackingSpeed != nil {
+		global("com.apple.trackpad.scaling", *t.TrackingSpeed)
+	}
+	if t.MouseSpeed != nil {
+		global("com.apple.mouse.scaling", *t.MouseSpeed)
+	}
+	if t.NaturalScrolling != nil {
+		global("com.apple.swipescrolldirection", *t.NaturalScrolling)
+	}
+	if t.ThreeFingerDrag != nil {
+		both("TrackpadThreeFingerDrag", *t.ThreeFingerDrag)
+		// 2 swipes with four fingers, 0 leaves the three-finger swipe off.
+		swipe := int64(2)
+		if *t.ThreeFingerDrag {
+			swipe = 0
+		}
+		both("TrackpadThreeFingerHorizSwipeGesture", swipe)
+		both("TrackpadThreeFingerVertSwipeGesture", swipe)
+		both("TrackpadFourFingerHorizSwipeGesture", int64(2))
+		both("TrackpadFourFingerVertSwipeGesture", int64(2))
+	}
+	if c, ok := SecondaryClicks[t.SecondaryClick]; ok {
+		both("TrackpadRightClick", c.TwoFinger)
+		both("TrackpadCornerSecondaryClick", c.Corner)
+	}
+	return out
+}
@@ -0,0 +1,37 @@
+package templates
+
+// Updates sets the Software Update policy and can install pending updates.
+//
+//	[updates]
+//	automatic_check = true
+//	automatic_download = true
+//	install_macos = false
+//	install_security = true
+//	install = true
+//	defer_days = 7
+//	reboot = "prompt"
+type Updates struct {
+	AutomaticCheck    *bool `toml:"automatic_check"`
+	AutomaticDownload *bool `toml:"automatic_download"`
+	// InstallMacOS installs macOS updates automatically.
+	InstallMacOS *bool `toml:"install_macos"`
+	// InstallSecurity installs security responses and system data files.
+	InstallSecurity *bool `toml:"install_security"`
+	InstallAppStore *bool `toml:"install_app_store"`
+	// Install makes apply install pending updates.
+	Install bool `toml:"install"`
+	// DeferDays holds an update back until it has been available this long.
+	DeferDays int64 `toml:"defer_days"`
+	// Reboot is RebootNever (the default), RebootPrompt or RebootAllow.
+	Reboot string `toml:"reboot"`
+}
+
+// Reboot policies for installing updates.
+const (
+	// RebootNever skips updates that need a restart.
+	RebootNever = "never"
+	// RebootPrompt installs them and leaves the restart to the user.
+	RebootPrompt = "prompt"
+	// RebootAllow restarts the machine as soon as they are installed.
+	RebootAllow = "allow"
+)
@@ -0,0 +1,24 @@
+package templates
+
+import (
+	"regexp"
+)
+
+var varRef = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_.]*)\}`)
+
+// Refs returns the variable names referenced as ${name} in s.
+func Refs(s string) []string {
+	var out []string
+	for _, m := range varRef.FindAllStringSubmatch(s, -1) {
+		out = append(out, m[1])
+	}
+	return out
+}
+
+// Expand substitutes ${name} references using lookup. Unknown names expand to
+// the empty string; lint reports them before apply ever sees them.
+func Expand(s string, lookup func(string) string) string {
+	return varRef.ReplaceAllStringFunc(s, func(m string) string {
+		return lookup(varRef.FindStringSubmatch(m)[1])
+	})
+}
@@ -0,0 +1,20 @@
+package templates
+
+// WiFi is a preferred wireless network. Networks are added to the top of the
+// preferred list in template order, so the first entry is joined first. The
+// password comes from the secrets provider, never from the template.
+//
+//	[[wifi]]
+//	ssid = "Office"
+//	secret = "office-wifi"
+type WiFi struct {
+	SSID     string `toml:"ssid"`
+	Security string `toml:"security"` // networksetup security type; default WPA2
+	Secret   string `toml:"secret"`
+	// Interface is the Wi-Fi device, e.g. en0. Detected when empty.
+	Interface string `toml:"interface"`
+	When      string `toml:"when"`
+}
+
+// WiFiSecurityTypes are the security types networksetup accepts.
+var WiFiSecurityTypes = []string{"OPEN", "WEP", "WPA", "WPA2", "WPA3", "WPAE", "WPA2E", "WPA3E"}
@@ -0,0 +1,121 @@
+// Package textdiff compares text line by line, for showing what a change to
+// a file does and for making only some of it.
+package textdiff
+
+import "strings"
+
+// Op is what an edit does with a line.
+type Op int
+
+const (
+	Equal Op = iota
+	Delete
+	Insert
+)
+
+// Line is one line of an edit script.
+type Line struct {
+	Op   Op
+	Text string
+}
+
+// Split splits text into lines that keep their newline, so joining them
+// gives the text back.
+func Split(text string) []string {
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// Lines returns the shortest edit script turning a into b, deletions of a
+// run before its insertions.
+func Lines(a, b []string) []Line {
+	// The common prefix and suffix are cut first to keep the table small.
+	var head, tail []Line
+	for len(a) > 0 && len(b) > 0 && a[0] == b[0] {
+		head = append(head, Line{Equal, a[0]})
+		a, b = a[1:], b[1:]
+	}
+	for len(a) > 0 && len(b) > 0 && a[len(a)-1] == b[len(b)-1] {
+		tail = append([]Line{{Equal, a[len(a)-1]}}, tail...)
+		a, b = a[:len(a)-1], b[:len(b)-1]
+	}
+
+	// lcs[i][j] is the length of the longest common subsequence of a[i:]
+	// and b[j:].
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	out := head
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			out = append(out, Line{Equal, a[i]})
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			out = append(out, Line{Delete, a[i]})
+			i++
+		default:
+			out = append(out, Line{Insert, b[j]})
+			j++
+		}
+	}
+	return append(out, tail...)
+}
+
+// Hunk is a run of changed lines, script[Start:End].
+type Hunk struct {
+	Start, End int
+}
+
+// Hunks returns the runs of changes in script.
+func Hunks(script []Line) []Hunk {
+	var out []Hunk
+	for i := 0; i < len(script); {
+		if script[i].Op == Equal {
+			i++
+			continue
+		}
+		h := Hunk{Start: i}
+		for i < len(script) && script[i].Op != Equal {
+			i++
+		}
+		h.End = i
+		out = append(out, h)
+	}
+	return out
+}
+
+// Merge returns the text script turns a into with only the accepted hunks
+// applied; a rejected hunk keeps the lines of a.
+func Merge(script []Line, hunks []Hunk, accept []bool) string {
+	var b strings.Builder
+	h := 0
+	for i, l := range script {
+		for h < len(hunks) && i >= hunks[h].End {
+			h++
+		}
+		applied := h < len(hunks) && i >= hunks[h].Start && accept[h]
+		switch {
+		case l.Op == Equal,
+			l.Op == Insert && applied,
+			l.Op == Delete && !applied:
+			b.WriteString(l.Text)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,372 @@
+package tui
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hmziqrs/maziq/internal/engine"
+	"github.com/hmziqrs/maziq/internal/templates"
+)
+
+// accessible is the line-by-line interface for screen readers. It never
+// takes over the screen or redraws: every prompt is a numbered list read
+// from stdin, and every change of state is announced as a line of its own,
+// so VoiceOver reads the session in order. It reuses the loaders of the
+// full-screen TUI, so both show the same thing.
+type accessible struct {
+	ctx   context.Context
+	out   io.Writer
+	lines <-chan string
+}
+
+// RunAccessible runs the accessible interface until the user quits, in
+// runs out or ctx is cancelled. Cancelling ctx during an apply stops it
+// after the current change.
+func RunAccessible(ctx context.Context, in io.Reader, out io.Writer) error {
+	lines := make(chan string)
+	go func() {
+		defer close(lines)
+		sc := bufio.NewScanner(in)
+		for sc.Scan() {
+			lines <- sc.Text()
+		}
+	}()
+	a := &accessible{ctx: ctx, out: out, lines: lines}
+	a.say("MazIQ, macOS provisioning and automation.")
+	menu := []string{menuTemplates, menuApply, menuConfiguration, menuOutdated, menuSecurity}
+	for {
+		i, ok := a.choose("Main menu", menu)
+		if !ok {
+			a.say("Goodbye.")
+			return nil
+		}
+		switch menu[i] {
+		case menuTemplates:
+			a.templates()
+		case menuApply:
+			a.apply()
+		case menuConfiguration:
+			a.configuration()
+		case menuOutdated:
+			a.outdated()
+		case menuSecurity:
+			a.security()
+		}
+	}
+}
+
+func (a *accessible) say(format string, args ...any) {
+	fmt.Fprintf(a.out, format+"\n", args...)
+}
+
+// ask prompts for a line. It reports false on EOF or cancellation.
+func (a *accessible) ask(prompt string) (string, bool) {
+	fmt.Fprint(a.out, prompt+" ")
+	select {
+	case line, ok := <-a.lines:
+		return strings.TrimSpace(line), ok
+	case <-a.ctx.Done():
+		fmt.Fprintln(a.out)
+		return "", false
+	}
+}
+
+// choose lists items by number and returns the index picked. An empty
+// answer or q goes back.
+func (a *accessible) choose(title string, items []string) (int, bool) {
+	for {
+		a.say("%s, %d options:", title, len(items))
+		for i, item := range items {
+			a.say("%d. %s", i+1, item)
+		}
+		answer, ok := a.ask(fmt.Sprintf("Choose 1 to %d, or press enter to go back:", len(items)))
+		if !ok || answer == "" || answer == "q" {
+			return 0, false
+		}
+		if n, err := strconv.Atoi(answer); err == nil && n >= 1 && n <= len(items) {
+			return n - 1, true
+		}
+		a.say("%q is not an option.", answer)
+	}
+}
+
+func (a *accessible) confirm(question string) bool {
+	answer, ok := a.ask(question + " Type y for yes or n for no:")
+	return ok && (answer == "y" || answer == "yes")
+}
+
+func (a *accessible) templates() {
+	names := templates.List()
+	if len(names) == 0 {
+		a.say("No templates found in %s.", strings.Join(templates.Dirs(), ", "))
+		return
+	}
+	for {
+		i, ok := a.choose("Templates; choose one to check it", names)
+		if !ok {
+			return
+		}
+		t := lintTemplate(names[i])
+		if t.err != nil && t.tmpl == nil {
+			a.say("Error: %v", t.err)
+			continue
+		}
+		a.say("%s: %d software, %d vars, %d tests.", t.tmpl.Name, len(t.tmpl.Software), len(t.tmpl.Vars), len(t.tmpl.Tests))
+		errs := templates.Count(t.findings, templates.SeverityError)
+		warns := templates.Count(t.findings, templates.SeverityWarning) - errs
+		if errs+warns == 0 {
+			a.say("The template is valid.")
+		} else {
+			a.say("%d errors, %d warnings.", errs, warns)
+		}
+		for _, f := range t.findings {
+			a.say("%s", f)
+		}
+	}
+}
+
+func (a *accessible) apply() {
+	a.say("Planning…")
+	msg := loadPlan(nil)().(planLoadedMsg)
+	if msg.err != nil {
+		a.say("Error: %v", msg.err)
+		return
+	}
+	pending := msg.summary.Pending()
+	if len(pending) == 0 {
+		a.say("Nothing to do; %s is converged.", msg.summary.Template)
+		return
+	}
+	a.say("%d change(s) pending for %s:", len(pending), msg.summary.Template)
+	for _, it := range pending {
+		a.say("%s: %s", it.ID, it.Description)
+	}
+	if !a.confirm("Apply these changes?") {
+		return
+	}
+
+	m := applyModel{summary: msg.summary, plan: msg.plan, client: msg.client}
+	events, err := m.start(a.ctx)
+	if err != nil {
+		a.say("Error: %v", err)
+		return
+	}
+	stopping := a.ctx.Done()
+	for {
+		select {
+		case <-stopping:
+			a.say("Stopping after the current change.")
+			stopping = nil
+			continue
+		case e, ok := <-events:
+			switch {
+			case !ok && stopping == nil:
+				a.say("Stopped.")
+				return
+			case !ok:
+				a.say("Lost the connection to the daemon.")
+				return
+			}
+			switch e := e.(type) {
+			case engine.TaskStarted:
+				a.say("Applying %d of %d: %s, %s.", e.Index, e.Total, e.ID, e.Description)
+			case engine.TaskLog:
+				a.say("%s", e.Line)
+			case engine.TaskDone:
+				switch o := e.Outcome; o.Status {
+				case engine.OutcomeApplied:
+					a.say("Applied %s.", o.ID)
+				case engine.OutcomeFailed:
+					a.say("Failed %s: %s", o.ID, o.Error)
+					if o.Quarantined {
+						a.say("%s failed too many times in a row and is quarantined; later applies skip it.", o.ID)
+					}
+				case engine.OutcomeSkipped:
+					a.say("Skipped %s: %s", o.ID, o.Error)
+				}
+			case engine.RunFinished:
+				m.record(e.Report)
+				r := e.Report
+				a.say("Finished: %d applied, %d failed, %d skipped.",
+					r.Count(engine.OutcomeApplied), r.Count(engine.OutcomeFailed), r.Count(engine.OutcomeSkipped))
+				a.fixes(r)
+				return
+			}
+		}
+	}
+}
+
+// fixes offers the fix for each known failure of r.
+func (a *accessible) fixes(r engine.Report) {
+	for _, o := range r.Outcomes {
+		if o.Fix == nil {
+			continue
+		}
+		a.say("%s failed with a known problem. %s: %s", o.ID, o.Fix.Title(), o.Fix.Explain())
+		for i, s := range o.Fix.Steps() {
+			a.say("Step %d: %s.", i+1, s.Say)
+		}
+		if !a.confirm("Run these steps?") {
+			continue
+		}
+		env, err := configurationEnv()
+		if err != nil {
+			a.say("Error: %v", err)
+			return
+		}
+		env.Logf = func(format string, args ...any) { a.say(format, args...) }
+		env.Wait = func(ctx context.Context, prompt string) error {
+			if _, ok := a.ask(prompt + "."); !ok {
+				return context.Canceled
+			}
+			return nil
+		}
+		if err := o.Fix.Run(a.ctx, env); err != nil {
+			a.say("The fix failed: %v", err)
+		} else {
+			a.say("Fixed. Apply again to retry %s.", o.ID)
+		}
+	}
+}
+
+func (a *accessible) configuration() {
+	for {
+		a.say("Checking settings…")
+		msg := loadConfiguration().(configurationLoadedMsg)
+		if msg.err != nil {
+			a.say("Error: %v", msg.err)
+			return
+		}
+		changes := 0
+		for _, it := range msg.items {
+			switch {
+			case it.Err != nil:
+				a.say("%s: could not be checked: %v", it.ID(), it.Err)
+			case it.Pending():
+				changes++
+				a.say("%s: is %s, should be %s.", it.ID(), it.State.Current, it.State.Desired)
+			default:
+				a.say("%s: %s, matches.", it.ID(), it.State.Current)
+			}
+		}
+		switch {
+		case len(msg.items) == 0:
+			a.say("Template %q manages no settings.", msg.template)
+		case changes > 0:
+			a.say("%d setting(s) differ from %s; apply changes them.", changes, msg.template)
+		default:
+			a.say("All settings match %s.", msg.template)
+		}
+
+		items := make([]string, len(msg.groups))
+		for i, g := range msg.groups {
+			state := "off"
+			switch {
+			case g.Enabled():
+				state = "on"
+			case g.On > 0:
+				state = fmt.Sprintf("%d of %d on", g.On, g.Total)
+			}
+			if g.Group.Unsafe {
+				state += ", unsafe"
+			}
+			items[i] = fmt.Sprintf("%s, %s. %s", g.Group.Title, state, g.Group.Explain)
+		}
+		i, ok := a.choose("Setting groups; choose one to switch it on or off", items)
+		if !ok {
+			return
+		}
+		t := toggle(msg.groups[i])
+		switch {
+		case t.err != nil:
+			a.say("%s: %v", t.title, t.err)
+		case t.on:
+			a.say("%s switched on.", t.title)
+		default:
+			a.say("%s switched off; macOS defaults restored.", t.title)
+		}
+		if t.err == nil && t.logout {
+			a.say("Log out to finish.")
+		}
+	}
+}
+
+func (a *accessible) outdated() {
+	for {
+		a.say("Checking for updates…")
+		msg := loadOutdated().(outdatedLoadedMsg)
+		if msg.err != nil {
+			a.say("Error: %v", msg.err)
+		}
+		ids := make([]string, 0, len(msg.brew))
+		for id := range msg.brew {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		for _, id := range ids {
+			a.say("Homebrew: %s can be upgraded to %s with brew upgrade.", id, msg.brew[id])
+		}
+		if len(msg.binaries) == 0 {
+			if len(ids) == 0 && msg.err == nil {
+				a.say("Everything is up to date.")
+			}
+			return
+		}
+		items := make([]string, len(msg.binaries))
+		for i, u := range msg.binaries {
+			items[i] = fmt.Sprintf("%s, %s to %s", u.Name, u.Installed, u.Latest)
+		}
+		i, ok := a.choose("Release binaries; choose one to upgrade it", items)
+		if !ok {
+			return
+		}
+		u := msg.binaries[i]
+		a.say("Upgrading %s to %s…", u.Name, u.Latest)
+		if r := upgradeBinary(u)().(binaryUpgradedMsg); r.err != nil {
+			a.say("%s: %v", u.Name, r.err)
+		} else {
+			a.say("%s upgraded to %s.", u.Name, u.Latest)
+		}
+	}
+}
+
+func (a *accessible) security() {
+	for {
+		a.say("Checking advisories…")
+		msg := loadSecurity().(securityLoadedMsg)
+		if msg.err != nil {
+			a.say("Error: %v", msg.err)
+		}
+		a.say("%d of %d installed packages have an advisory feed.", msg.covered, msg.checked)
+		if len(msg.findings) == 0 {
+			if msg.err == nil {
+				a.say("No known vulnerabilities.")
+			}
+			return
+		}
+		items := make([]string, len(msg.findings))
+		for i, f := range msg.findings {
+			ids := make([]string, len(f.Vulns))
+			for j, v := range f.Vulns {
+				ids[j] = v.ID
+			}
+			items[i] = fmt.Sprintf("%s %s, worst %s: %s", f.Component.Name, f.Component.Version, f.Worst(), strings.Join(ids, ", "))
+		}
+		i, ok := a.choose("Vulnerable packages; choose one to upgrade it", items)
+		if !ok {
+			return
+		}
+		c := msg.findings[i].Component
+		a.say("Upgrading %s…", c.Name)
+		if r := upgradeVulnerable(c)().(securityUpgradedMsg); r.err != nil {
+			a.say("%s: %v", c.Name, r.err)
+		} else {
+			a.say("%s upgraded.", c.Name)
+		}
+	}
+}
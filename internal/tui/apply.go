@@ -0,0 +1,402 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/hmziqrs/maziq/internal/audit"
+	"github.com/hmziqrs/maziq/internal/config"
+	"github.com/hmziqrs/maziq/internal/daemon"
+	"github.com/hmziqrs/maziq/internal/engine"
+	"github.com/hmziqrs/maziq/internal/facts"
+	"github.com/hmziqrs/maziq/internal/glyphs"
+	"github.com/hmziqrs/maziq/internal/metrics"
+	"github.com/hmziqrs/maziq/internal/selfupdate"
+)
+
+const (
+	applyHelp        = "enter: Apply • ↑/↓: Select • space: Leave out • d: Diff • r: Re-plan • esc: Back • q: Quit"
+	applyRunningHelp = "Applying… • q: Quit after the current change"
+	applyStopHelp    = "Stopping after the current change…"
+)
+
+// applyLogLines is how many of the latest log lines the Apply screen keeps.
+const applyLogLines = 6
+
+// applyModel plans the configured template and applies it, rendering the
+// engine's events as they arrive. When a daemon is running the screen is
+// its client and the daemon plans and applies; otherwise it runs the engine
+// itself.
+type applyModel struct {
+	summary *engine.Summary
+	// plan is set when planning ran here rather than in the daemon.
+	plan   *engine.Plan
+	client *daemon.Client
+	err    error
+
+	events  <-chan engine.Event
+	cancel  context.CancelFunc
+	current string
+	// status is the outcome status of each item once it is done.
+	status   map[string]string
+	errs     map[string]string
+	log      []string
+	progress engine.TaskProgress
+	report   *engine.Report
+	// stopping is set once the user asked to quit mid-run; MazIQ quits when
+	// the run has wound down so no installer is left half-done.
+	stopping bool
+	// fix is the fix being shown for a failure of the run; fixed records
+	// the failures whose fix succeeded.
+	fix   *fixModel
+	fixed map[string]bool
+	// selected is the pending change under the cursor; diff is the file
+	// diff open for it. merged records the changes with rejected hunks.
+	selected int
+	diff     *diffModel
+	merged   map[string]bool
+	// excluded are the pending changes left out of the run.
+	excluded map[string]bool
+	// checklist is the template's groups, shown before planning; toggles
+	// are the groups turned on or off there, nil when left as they were.
+	checklist *groupsModel
+	toggles   map[string]bool
+}
+
+type planLoadedMsg struct {
+	summary *engine.Summary
+	plan    *engine.Plan
+	client  *daemon.Client
+	err     error
+}
+
+// engineEventMsg carries one event together with the channel to read the
+// next one from.
+type engineEventMsg struct {
+	event  engine.Event
+	events <-chan engine.Event
+}
+
+// loadPlan plans the configured template with toggles set on its groups.
+// The daemon plans with the template as it is, so a plan with toggles is
+// made here.
+func loadPlan(toggles map[string]bool) tea.Cmd {
+	return func() tea.Msg {
+		ctx := context.Background()
+		if toggles == nil {
+			if c, err := daemon.Dial(daemon.SocketPath()); err == nil {
+				s, err := c.Plan(ctx, "")
+				return planLoadedMsg{summary: &s, client: c, err: err}
+			}
+		}
+		env, err := configurationEnv()
+		if err != nil {
+			return planLoadedMsg{err: err}
+		}
+		env.Template.Toggles = toggles
+		plan, err := engine.Build(ctx, env)
+		if err != nil {
+			return planLoadedMsg{err: err}
+		}
+		s := plan.Summary()
+		return planLoadedMsg{summary: &s, plan: plan}
+	}
+}
+
+// waitEvent reads the next engine event. A closed channel yields a message
+// without one.
+func waitEvent(events <-chan engine.Event) tea.Cmd {
+	return func() tea.Msg {
+		e := <-events
+		return engineEventMsg{event: e, events: events}
+	}
+}
+
+func (a applyModel) running() bool { return a.events != nil && a.report == nil }
+
+func (m model) updateApply(msg tea.Msg) (tea.Model, tea.Cmd) {
+	a := &m.apply
+	switch msg := msg.(type) {
+	case groupsLoadedMsg:
+		a.checklist = &msg.groups
+	case planLoadedMsg:
+		*a = applyModel{summary: msg.summary, plan: msg.plan, client: msg.client, err: msg.err, merged: map[string]bool{}, excluded: map[string]bool{}, toggles: a.toggles}
+	case diffLoadedMsg:
+		a.diff = newDiffModel(msg)
+	case engineEventMsg:
+		switch e := msg.event.(type) {
+		case nil:
+			// Only a daemon connection ends before RunFinished.
+			if a.report == nil {
+				a.cancel()
+				a.events, a.err = nil, errors.New("lost the connection to the daemon")
+			}
+			if a.stopping {
+				return m, tea.Quit
+			}
+			return m, nil
+		case engine.TaskStarted:
+			a.current = e.ID
+		case engine.TaskLog:
+			a.log = append(a.log, e.Line)
+			if len(a.log) > applyLogLines {
+				a.log = a.log[len(a.log)-applyLogLines:]
+			}
+		case engine.TaskDone:
+			a.status[e.Outcome.ID] = e.Outcome.Status
+			if e.Outcome.Error != "" {
+				a.errs[e.Outcome.ID] = e.Outcome.Error
+				if e.Outcome.Quarantined {
+					a.errs[e.Outcome.ID] += " (quarantined)"
+				}
+			}
+		case engine.TaskProgress:
+			a.progress = e
+		case engine.RunFinished:
+			a.report, a.current = &e.Report, ""
+			a.fixed = map[string]bool{}
+			a.cancel()
+			a.record(e.Report)
+			if a.stopping {
+				return m, tea.Quit
+			}
+			return m, nil
+		}
+		return m, waitEvent(msg.events)
+	case fixLogMsg, fixPromptMsg, fixDoneMsg:
+		return m.updateFix(msg)
+	case tea.KeyMsg:
+		if a.checklist != nil {
+			return m.updateGroups(msg)
+		}
+		if a.diff != nil {
+			return m.updateDiff(msg)
+		}
+		if a.fix != nil {
+			switch msg.String() {
+			case "enter", "n", "esc":
+				return m.updateFix(msg)
+			case "r":
+				if a.fix.events != nil {
+					return m, nil
+				}
+			}
+		}
+		switch msg.String() {
+		case "q":
+			if a.running() {
+				return m.stopApply()
+			}
+			if a.fix != nil && a.fix.events != nil {
+				a.fix.cancel()
+			}
+			return m, tea.Quit
+		case "up", "k":
+			if a.selected > 0 {
+				a.selected--
+			}
+		case "down", "j":
+			if a.summary != nil && a.selected < len(a.summary.Pending())-1 {
+				a.selected++
+			}
+		case " ":
+			if a.summary != nil && a.events == nil && a.selected < len(a.summary.Pending()) {
+				id := a.summary.Pending()[a.selected].ID
+				a.excluded[id] = !a.excluded[id]
+			}
+		case "d":
+			if a.summary != nil && a.events == nil && a.selected < len(a.summary.Pending()) {
+				return m, a.loadDiff(a.summary.Pending()[a.selected].ID)
+			}
+		case "f":
+			if o, ok := a.nextFixable(""); ok && a.fix == nil {
+				a.fix = &fixModel{outcome: o}
+			}
+		case "esc", "backspace":
+			if !a.running() {
+				m.screen = screenMenu
+			}
+		case "r":
+			if !a.running() {
+				m.apply = applyModel{toggles: a.toggles}
+				return m, loadPlan(a.toggles)
+			}
+		case "enter":
+			if a.summary == nil || a.running() || a.report != nil || len(a.summary.Pending()) == len(a.exclude()) {
+				break
+			}
+			ctx, cancel := context.WithCancel(context.Background())
+			events, err := a.start(ctx)
+			if err != nil {
+				cancel()
+				a.err = err
+				break
+			}
+			a.events, a.cancel = events, cancel
+			a.status, a.errs = map[string]string{}, map[string]string{}
+			a.progress = engine.TaskProgress{Total: len(a.summary.Pending()) - len(a.exclude())}
+			return m, waitEvent(a.events)
+		}
+	}
+	return m, nil
+}
+
+// exclude returns the pending changes left out of the run.
+func (a applyModel) exclude() []string {
+	var ids []string
+	for id, out := range a.excluded {
+		if out {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// start applies the plan through the daemon or, without one, here.
+func (a applyModel) start(ctx context.Context) (<-chan engine.Event, error) {
+	if a.client != nil {
+		return a.client.Apply(ctx, "", false, a.exclude())
+	}
+	a.plan.Exclude(a.exclude())
+	env, err := configurationEnv()
+	if err != nil {
+		return nil, err
+	}
+	env.Template.Toggles = a.toggles
+	cfg, _ := config.Load()
+	return engine.Start(ctx, a.plan, env, engine.Options{OnTimeout: cfg.Apply.OnTimeout, QuarantineAfter: cfg.Apply.QuarantineAfter}), nil
+}
+
+// record saves a finished run's metrics and results. The daemon records its
+// own runs.
+func (a applyModel) record(report engine.Report) {
+	if a.plan == nil {
+		return
+	}
+	_ = metrics.Record(metrics.FromReport(a.plan, report, selfupdate.Version, facts.Detect()["macos"]))
+	_ = audit.Record("tui", a.plan, report)
+	_ = engine.SaveLast("", report)
+	_ = engine.SaveVerified(a.plan, report)
+}
+
+// stopApply cancels the running apply and quits once it has finished.
+func (m model) stopApply() (tea.Model, tea.Cmd) {
+	m.apply.cancel()
+	m.apply.stopping = true
+	return m, nil
+}
+
+func (a applyModel) help() string {
+	if a.stopping {
+		return applyStopHelp
+	}
+	if a.running() {
+		return applyRunningHelp
+	}
+	if a.checklist != nil {
+		return groupsHelp
+	}
+	if a.diff != nil {
+		return a.diff.help()
+	}
+	if a.fix != nil {
+		return a.fix.help()
+	}
+	if _, ok := a.nextFixable(""); ok {
+		return "f: Fix known problems • " + applyHelp
+	}
+	return applyHelp
+}
+
+func (a applyModel) view(width, height int) string {
+	switch {
+	case a.err != nil:
+		return errorStyle.Render(a.err.Error())
+	case a.checklist != nil:
+		return a.checklist.view()
+	case a.summary == nil:
+		return mutedStyle.Render("Planning…")
+	case a.diff != nil:
+		return a.diff.view(width, height)
+	}
+
+	pending := a.summary.Pending()
+	var header string
+	switch {
+	case a.report != nil:
+		header = fmt.Sprintf("%d applied, %d failed, %d skipped",
+			a.report.Count(engine.OutcomeApplied), a.report.Count(engine.OutcomeFailed), a.report.Count(engine.OutcomeSkipped))
+		if a.report.Count(engine.OutcomeFailed) > 0 {
+			header = errorStyle.Render(header)
+		} else {
+			header = readyStyle.Render(header)
+		}
+	case a.events != nil:
+		header = warningStyle.Render(fmt.Sprintf("Applying %d/%d", a.progress.Done, a.progress.Total))
+		// Plain mode keeps to the count; the bar is motion for little gain.
+		if !glyphs.Plain() {
+			header += " " + progressBar(a.progress, 24)
+		}
+	case len(pending) == 0:
+		return readyStyle.Render("✓ Nothing to do; " + a.summary.Template + " is converged.")
+	default:
+		header = warningStyle.Render(fmt.Sprintf("%d change(s) pending for %s; press enter to apply", len(pending), a.summary.Template))
+	}
+	if len(a.summary.GroupsOff) > 0 {
+		header += "\n" + mutedStyle.Render("Groups off: "+strings.Join(a.summary.GroupsOff, ", "))
+	}
+
+	var rows []string
+	for i, it := range pending {
+		id := it.ID
+		var row string
+		switch {
+		case i == a.selected && a.events == nil && a.excluded[id]:
+			row = selectedMenuItemStyle.Render(fmt.Sprintf("❯ %-32s", id)) + " " + mutedStyle.Render("left out")
+		case i == a.selected && a.events == nil:
+			row = selectedMenuItemStyle.Render(fmt.Sprintf("❯ %-32s", id)) + " " + mutedStyle.Render(it.Description)
+		case a.excluded[id]:
+			row = mutedStyle.Render(fmt.Sprintf("○ %-32s left out", id))
+		case id == a.current:
+			row = warningStyle.Render("▶ " + id)
+		case a.status[id] == engine.OutcomeApplied:
+			row = readyStyle.Render("✓ ") + id
+		case a.status[id] == engine.OutcomeFailed:
+			row = errorStyle.Render(fmt.Sprintf("✗ %s: %s", id, a.errs[id]))
+		case a.status[id] == engine.OutcomeSkipped:
+			row = mutedStyle.Render(fmt.Sprintf("- %s: %s", id, a.errs[id]))
+		default:
+			row = fmt.Sprintf("  %-32s %s", id, mutedStyle.Render(it.Description))
+		}
+		if a.merged[id] {
+			row += mutedStyle.Render(" (merged)")
+		}
+		if it.Local {
+			row += warningStyle.Render(" [local]")
+		}
+		rows = append(rows, row)
+	}
+	if room := height - len(a.log) - 4; room > 0 && len(rows) > room {
+		rows = append(rows[:room-1], mutedStyle.Render(fmt.Sprintf("  … %d more", len(rows)-room+1)))
+	}
+	out := header + "\n\n" + strings.Join(rows, "\n")
+	if a.fix != nil {
+		return out + "\n\n" + a.fix.view()
+	}
+	if len(a.log) > 0 {
+		out += "\n\n" + mutedStyle.Render(strings.Join(a.log, "\n"))
+	}
+	return out
+}
+
+func progressBar(p engine.TaskProgress, width int) string {
+	filled := 0
+	if p.Total > 0 {
+		filled = p.Done * width / p.Total
+	}
+	return readyStyle.Render(strings.Repeat("█", filled)) + mutedStyle.Render(strings.Repeat("░", width-filled))
+}
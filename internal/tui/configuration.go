@@ -0,0 +1,448 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/hmziqrs/maziq/internal/config"
+	"github.com/hmziqrs/maziq/internal/engine"
+	"github.com/hmziqrs/maziq/internal/facts"
+	"github.com/hmziqrs/maziq/internal/modules/defaults"
+	"github.com/hmziqrs/maziq/internal/modules/directories"
+	"github.com/hmziqrs/maziq/internal/modules/dotfile"
+	"github.com/hmziqrs/maziq/internal/modules/energy"
+	"github.com/hmziqrs/maziq/internal/modules/files"
+	"github.com/hmziqrs/maziq/internal/modules/handlers"
+	"github.com/hmziqrs/maziq/internal/modules/jobs"
+	"github.com/hmziqrs/maziq/internal/modules/manual"
+	"github.com/hmziqrs/maziq/internal/modules/network"
+	"github.com/hmziqrs/maziq/internal/modules/printers"
+	"github.com/hmziqrs/maziq/internal/modules/region"
+	"github.com/hmziqrs/maziq/internal/modules/screensaver"
+	"github.com/hmziqrs/maziq/internal/modules/services"
+	"github.com/hmziqrs/maziq/internal/modules/shellrc"
+	"github.com/hmziqrs/maziq/internal/modules/sound"
+	"github.com/hmziqrs/maziq/internal/modules/spotlight"
+	"github.com/hmziqrs/maziq/internal/resource"
+	"github.com/hmziqrs/maziq/internal/secrets"
+	"github.com/hmziqrs/maziq/internal/shell"
+	"github.com/hmziqrs/maziq/internal/sysprefs"
+	"github.com/hmziqrs/maziq/internal/templates"
+)
+
+const (
+	configurationHelp = "↑/↓ or j/k: Move • /: Search • space: Toggle group • r: Refresh • o: Open System Settings • esc: Back • q: Quit"
+	searchHelp        = "Type to filter • ↑/↓: Move • enter: Done • esc: Clear search"
+)
+
+// configurationKinds are the resource kinds shown on the Configuration
+// screen: machine settings, as opposed to installed software.
+var configurationKinds = map[string]bool{
+	defaults.Kind:    true,
+	directories.Kind: true,
+	dotfile.Kind:     true,
+	energy.Kind:      true,
+	files.Kind:       true,
+	handlers.Kind:    true,
+	jobs.Kind:        true,
+	manual.Kind:      true,
+	network.Kind:     true,
+	network.WiFiKind: true,
+	printers.Kind:    true,
+	region.Kind:      true,
+	screensaver.Kind: true,
+	services.Kind:    true,
+	shellrc.Kind:     true,
+	sound.Kind:       true,
+	spotlight.Kind:   true,
+}
+
+// configurationModel shows the curated setting groups, which can be
+// switched on and off as a whole, and the current and desired value of every
+// setting the configured template manages, flagging the ones apply would
+// change.
+type configurationModel struct {
+	template string
+	groups   []defaults.GroupStatus
+	items    []engine.Item
+	// corners are the template's hot corners, drawn above the list.
+	corners map[string]templates.HotCorner
+	loading bool
+	err     error
+	// notice reports the outcome of the last group toggle.
+	notice string
+	cursor int
+	// query filters groups and settings; searching is set while it is
+	// being typed.
+	query     string
+	searching bool
+}
+
+type configurationLoadedMsg struct {
+	template string
+	groups   []defaults.GroupStatus
+	items    []engine.Item
+	corners  map[string]templates.HotCorner
+	err      error
+}
+
+type groupToggledMsg struct {
+	title string
+	on    bool
+	// logout is set when the change only shows up after logging out.
+	logout bool
+	err    error
+}
+
+// configurationEnv loads the configured template and the environment to
+// check it in.
+func configurationEnv() (*resource.Env, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, err
+	}
+	t, err := templates.Resolve(cfg.Template)
+	if err != nil {
+		return nil, err
+	}
+	if t, err = t.WithLocal(); err != nil {
+		return nil, err
+	}
+	t.Profile = cfg.Profile
+	return &resource.Env{
+		Runner:   shell.FromEnv(shell.Local{Timeout: cfg.Apply.Timeout, IdleTimeout: cfg.Apply.IdleTimeout, Env: t.Proxy.Environ()}),
+		Secrets:  secrets.Default(),
+		Facts:    facts.Detect(),
+		Template: t,
+	}, nil
+}
+
+func loadConfiguration() tea.Msg {
+	env, err := configurationEnv()
+	if err != nil {
+		return configurationLoadedMsg{err: err}
+	}
+	t := env.Template
+	ctx := context.Background()
+	var groups []defaults.GroupStatus
+	for _, name := range templates.SettingGroupNames() {
+		g, err := defaults.CheckGroup(ctx, env, templates.SettingGroups[name])
+		if err != nil {
+			return configurationLoadedMsg{template: t.Name, err: err}
+		}
+		groups = append(groups, g)
+	}
+	rs, err := engine.Resources(ctx, env)
+	if err != nil {
+		return configurationLoadedMsg{template: t.Name, err: err}
+	}
+	var items []engine.Item
+	for _, r := range rs {
+		if configurationKinds[resource.Kind(r.ID())] {
+			state, err := r.Check(ctx, env)
+			items = append(items, engine.Item{Resource: r, State: state, Err: err})
+		}
+	}
+	return configurationLoadedMsg{template: t.Name, groups: groups, items: items, corners: t.Screensaver.HotCorners}
+}
+
+// toggleGroup switches the group under the cursor on or off.
+func (c configurationModel) toggleGroup() tea.Cmd {
+	groups, _ := c.visible()
+	if c.cursor >= len(groups) {
+		return nil
+	}
+	g := groups[c.cursor]
+	return func() tea.Msg { return toggle(g) }
+}
+
+// toggle switches g on, or off when it is already on.
+func toggle(g defaults.GroupStatus) groupToggledMsg {
+	on := !g.Enabled()
+	env, err := configurationEnv()
+	if err != nil {
+		return groupToggledMsg{title: g.Group.Title, err: err}
+	}
+	ctx := context.Background()
+	restart, err := defaults.SetGroup(ctx, env, g.Group, on)
+	defaults.Relaunch(ctx, env, restart.Processes)
+	return groupToggledMsg{title: g.Group.Title, on: on, logout: restart.Logout, err: err}
+}
+
+func (m model) updateConfiguration(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case configurationLoadedMsg:
+		m.configuration = configurationModel{
+			template: msg.template,
+			groups:   msg.groups,
+			items:    msg.items,
+			corners:  msg.corners,
+			err:      msg.err,
+			notice:   m.configuration.notice,
+			query:    m.configuration.query,
+		}
+		groups, items := m.configuration.visible()
+		m.configuration.cursor = min(m.configuration.cursor, max(len(groups)+len(items)-1, 0))
+	case groupToggledMsg:
+		switch {
+		case msg.err != nil:
+			m.configuration.notice = errorStyle.Render(fmt.Sprintf("%s: %v", msg.title, msg.err))
+		case msg.on:
+			m.configuration.notice = readyStyle.Render(msg.title + " switched on")
+		default:
+			m.configuration.notice = readyStyle.Render(msg.title + " switched off; macOS defaults restored")
+		}
+		if msg.err == nil && msg.logout {
+			m.configuration.notice += warningStyle.Render(" · log out to finish")
+		}
+		m.configuration.loading = true
+		return m, loadConfiguration
+	case tea.KeyMsg:
+		if m.configuration.searching {
+			m.configuration = m.configuration.search(msg)
+			return m, nil
+		}
+		switch msg.String() {
+		case "q":
+			return m, tea.Quit
+		case "/":
+			m.configuration.searching = true
+		case "esc", "backspace":
+			if m.configuration.query != "" {
+				m.configuration.query, m.configuration.cursor = "", 0
+				break
+			}
+			m.screen = screenMenu
+		case "r":
+			m.configuration.loading = true
+			m.configuration.notice = ""
+			return m, loadConfiguration
+		case "o":
+			return m, m.configuration.openSettings()
+		case " ", "enter":
+			if !m.configuration.loading {
+				return m, m.configuration.toggleGroup()
+			}
+		case "up", "k", "down", "j":
+			m.configuration = m.configuration.move(msg.String())
+		}
+	}
+	return m, nil
+}
+
+// search edits the query while the search box has focus.
+func (c configurationModel) search(msg tea.KeyMsg) configurationModel {
+	switch msg.Type {
+	case tea.KeyEsc:
+		c.query, c.searching = "", false
+	case tea.KeyEnter:
+		c.searching = false
+	case tea.KeyUp, tea.KeyDown:
+		return c.move(msg.String())
+	case tea.KeyBackspace:
+		if r := []rune(c.query); len(r) > 0 {
+			c.query = string(r[:len(r)-1])
+		}
+	case tea.KeyRunes, tea.KeySpace:
+		c.query += string(msg.Runes)
+	default:
+		return c
+	}
+	c.cursor = 0
+	return c
+}
+
+func (c configurationModel) move(key string) configurationModel {
+	groups, items := c.visible()
+	switch key {
+	case "up", "k":
+		if c.cursor > 0 {
+			c.cursor--
+		}
+	case "down", "j":
+		if c.cursor < len(groups)+len(items)-1 {
+			c.cursor++
+		}
+	}
+	return c
+}
+
+// visible returns the groups and settings matching the search query, best
+// matches first. Groups match on their name, title, explanation and the
+// domains and keys they set; settings on their ID and description.
+func (c configurationModel) visible() ([]defaults.GroupStatus, []engine.Item) {
+	if c.query == "" {
+		return c.groups, c.items
+	}
+	type scored[T any] struct {
+		v     T
+		score int
+	}
+	var groups []scored[defaults.GroupStatus]
+	for _, g := range c.groups {
+		fields := []string{g.Group.Name, g.Group.Title, g.Group.Explain}
+		for _, s := range g.Group.Settings {
+			fields = append(fields, s.Domain+" "+s.Key)
+		}
+		if score, ok := bestScore(c.query, fields...); ok {
+			groups = append(groups, scored[defaults.GroupStatus]{g, score})
+		}
+	}
+	var items []scored[engine.Item]
+	for _, it := range c.items {
+		if score, ok := bestScore(c.query, it.ID(), it.Resource.Describe()); ok {
+			items = append(items, scored[engine.Item]{it, score})
+		}
+	}
+	sort.SliceStable(groups, func(i, j int) bool { return groups[i].score > groups[j].score })
+	sort.SliceStable(items, func(i, j int) bool { return items[i].score > items[j].score })
+	outGroups := make([]defaults.GroupStatus, len(groups))
+	for i, g := range groups {
+		outGroups[i] = g.v
+	}
+	outItems := make([]engine.Item, len(items))
+	for i, it := range items {
+		outItems[i] = it.v
+	}
+	return outGroups, outItems
+}
+
+// openSettings opens System Settings at the pane of the first pending item
+// that can only be fixed there.
+func (c configurationModel) openSettings() tea.Cmd {
+	for _, it := range c.items {
+		l, ok := it.Resource.(sysprefs.Linker)
+		if !ok || !it.Pending() || l.SettingsPane() == "" {
+			continue
+		}
+		pane := l.SettingsPane()
+		return func() tea.Msg {
+			_ = sysprefs.Open(context.Background(), shell.Local{}, pane)
+			return nil
+		}
+	}
+	return nil
+}
+
+func (c configurationModel) view(height int) string {
+	switch {
+	case c.loading && c.groups == nil:
+		return mutedStyle.Render("Checking settings…")
+	case c.err != nil:
+		return errorStyle.Render(c.err.Error())
+	}
+
+	groups, items := c.visible()
+	var rows []string
+	for _, g := range groups {
+		mark, state := mutedStyle.Render("○"), "off"
+		switch {
+		case g.Enabled():
+			mark, state = readyStyle.Render("●"), "on"
+		case g.On > 0:
+			mark, state = warningStyle.Render("◐"), fmt.Sprintf("%d/%d", g.On, g.Total)
+		}
+		label := "safe"
+		if g.Group.Unsafe {
+			label = errorStyle.Render("unsafe")
+		}
+		rows = append(rows, fmt.Sprintf("%s %-22s %-5s %s", mark, g.Group.Title, state, label))
+	}
+	changes := 0
+	for _, it := range items {
+		switch {
+		case it.Err != nil:
+			rows = append(rows, errorStyle.Render(fmt.Sprintf("! %-28s %v", it.ID(), it.Err)))
+		case it.Pending():
+			changes++
+			rows = append(rows, warningStyle.Render(fmt.Sprintf("● %-28s %s → %s", it.ID(), it.State.Current, it.State.Desired)))
+		default:
+			rows = append(rows, readyStyle.Render("✓ ")+fmt.Sprintf("%-28s %s", it.ID(), it.State.Current))
+		}
+	}
+
+	var header []string
+	switch {
+	case len(c.items) == 0:
+		header = append(header, mutedStyle.Render(fmt.Sprintf("Template %q manages no settings.", c.template)))
+	case changes > 0:
+		header = append(header, warningStyle.Render(fmt.Sprintf("%d setting(s) differ from %s; run `maziq apply` to change them", changes, c.template)))
+	default:
+		header = append(header, readyStyle.Render("All settings match "+c.template))
+	}
+	switch {
+	case c.searching:
+		header = append(header, selectedMenuItemStyle.Render("/ "+c.query+"▏"))
+	case c.query != "":
+		header = append(header, mutedStyle.Render(fmt.Sprintf("/ %s · %d match(es) · esc to clear", c.query, len(rows))))
+	}
+	if len(c.corners) > 0 && c.query == "" {
+		header = append(header, c.hotCorners()...)
+	}
+	if c.cursor < len(groups) {
+		header = append(header, mutedStyle.Render(groups[c.cursor].Group.Explain))
+	}
+	if c.notice != "" {
+		header = append(header, c.notice)
+	}
+
+	visible := height - len(header) - 1
+	offset := 0
+	if visible > 0 && len(rows) > visible {
+		offset = min(max(c.cursor-visible+1, 0), len(rows)-visible)
+		rows = rows[offset : offset+visible]
+	}
+	return strings.Join(header, "\n") + "\n\n" + renderList(rows, c.cursor-offset)
+}
+
+// modifierSymbols are the keys of a hot corner's modifier, in the order
+// macOS menus list them.
+var modifierSymbols = []struct{ name, symbol string }{
+	{"control", "⌃"}, {"option", "⌥"}, {"shift", "⇧"}, {"command", "⌘"},
+}
+
+// hotCorners draws the template's hot corners around a small screen. Corners
+// apply would change are highlighted and those it leaves alone are dots.
+func (c configurationModel) hotCorners() []string {
+	pending := map[string]bool{}
+	for _, it := range c.items {
+		if it.Pending() {
+			pending[it.ID()] = true
+		}
+	}
+	label := func(corner string) string {
+		hc, ok := c.corners[corner]
+		if !ok {
+			return mutedStyle.Render("·")
+		}
+		keys := hc.Modifiers()
+		text := hc.Action
+		for i := len(modifierSymbols) - 1; i >= 0; i-- {
+			if slices.Contains(keys, modifierSymbols[i].name) {
+				text = modifierSymbols[i].symbol + text
+			}
+		}
+		prefix := templates.HotCorners[corner]
+		for _, key := range []string{prefix + "-corner", prefix + "-modifier"} {
+			if pending[resource.ID(defaults.Kind, "com.apple.dock/"+key)] {
+				return warningStyle.Render(text)
+			}
+		}
+		return text
+	}
+	tl, tr, bl, br := label("top_left"), label("top_right"), label("bottom_left"), label("bottom_right")
+	width := max(lipgloss.Width(tl), lipgloss.Width(bl))
+	pad := func(s string) string { return strings.Repeat(" ", width-lipgloss.Width(s)) + s }
+	return []string{
+		mutedStyle.Render("Hot corners"),
+		pad(tl) + " ┌──────┐ " + tr,
+		strings.Repeat(" ", width) + " │      │",
+		pad(bl) + " └──────┘ " + br,
+	}
+}
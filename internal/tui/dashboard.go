@@ -0,0 +1,191 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/hmziqrs/maziq/internal/glyphs"
+	"github.com/hmziqrs/maziq/internal/health"
+	"github.com/hmziqrs/maziq/internal/status"
+)
+
+// CollectFunc gathers a status snapshot.
+type CollectFunc func(ctx context.Context) status.Snapshot
+
+type snapshotMsg status.Snapshot
+
+type refreshMsg struct{}
+
+// dashboardModel is the read-only kiosk view behind `maziq status --watch`.
+// It deliberately exposes no key bindings that change the machine.
+type dashboardModel struct {
+	width, height int
+	collect       CollectFunc
+	interval      time.Duration
+	snap          *status.Snapshot
+	loading       bool
+}
+
+// RunDashboard shows a live status dashboard, refreshing every interval.
+func RunDashboard(collect CollectFunc, interval time.Duration) error {
+	m := dashboardModel{collect: collect, interval: interval, loading: true}
+	_, err := tea.NewProgram(m, tea.WithAltScreen(), tea.WithOutput(glyphs.Stdout())).Run()
+	return err
+}
+
+func (m dashboardModel) Init() tea.Cmd {
+	return m.fetch()
+}
+
+func (m dashboardModel) fetch() tea.Cmd {
+	return func() tea.Msg {
+		return snapshotMsg(m.collect(context.Background()))
+	}
+}
+
+func (m dashboardModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c", "q", "esc":
+			return m, tea.Quit
+		case "r":
+			if !m.loading {
+				m.loading = true
+				return m, m.fetch()
+			}
+		}
+	case snapshotMsg:
+		snap := status.Snapshot(msg)
+		m.snap, m.loading = &snap, false
+		return m, tea.Tick(m.interval, func(time.Time) tea.Msg { return refreshMsg{} })
+	case refreshMsg:
+		if !m.loading {
+			m.loading = true
+			return m, m.fetch()
+		}
+	}
+	return m, nil
+}
+
+func (m dashboardModel) View() string {
+	if glyphs.Plain() {
+		return glyphs.ASCII(m.view())
+	}
+	return m.view()
+}
+
+func (m dashboardModel) view() string {
+	if m.width == 0 {
+		return "Loading..."
+	}
+	if m.snap == nil {
+		return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, mutedStyle.Render("Collecting status…"))
+	}
+	s := m.snap
+
+	updated := "updated " + s.Taken.Format("15:04:05")
+	if m.loading {
+		updated = "refreshing…"
+	}
+	title := titleStyle.Render("MazIQ status · "+s.Template) + mutedStyle.Render(updated)
+
+	panelWidth := max((m.width-8)/2, 30)
+	panel := boxStyle.Width(panelWidth)
+
+	summary := panel.Render(strings.Join([]string{
+		countLine("Installed", fmt.Sprintf("%d/%d", s.Installed(), len(s.Software)), len(s.Drift) == 0),
+		countLine("Drift", fmt.Sprint(len(s.Drift)), len(s.Drift) == 0),
+		countLine("Outdated", fmt.Sprint(len(s.Outdated)), len(s.Outdated) == 0),
+		countLine("Health", healthSummary(s.Health), s.Healthy()),
+		lastApplyLine(s),
+	}, "\n"))
+
+	var healthLines []string
+	for _, h := range s.Health {
+		line := fmt.Sprintf("%s %s", healthDot(h.Level), h.Name)
+		if h.Detail != "" {
+			line += mutedStyle.Render(" · " + h.Detail)
+		}
+		healthLines = append(healthLines, line)
+	}
+	healthPanel := panel.Render(strings.Join(healthLines, "\n"))
+
+	drift := panel.Render(listOrNone("Drift (not installed)", s.Drift))
+	outdated := make([]string, 0, len(s.Outdated))
+	for id, v := range s.Outdated {
+		outdated = append(outdated, fmt.Sprintf("%s → %s", id, v))
+	}
+	sort.Strings(outdated)
+	outdatedPanel := panel.Render(listOrNone("Outdated", outdated))
+
+	sections := []string{
+		title,
+		lipgloss.JoinHorizontal(lipgloss.Top, summary, healthPanel),
+		lipgloss.JoinHorizontal(lipgloss.Top, drift, outdatedPanel),
+	}
+	for _, e := range s.Errors {
+		sections = append(sections, errorStyle.Render("✗ "+e))
+	}
+	sections = append(sections, helpStyle.Render(fmt.Sprintf("Read-only • refreshes every %s • r: Refresh now • q: Quit", m.interval)))
+
+	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Top,
+		lipgloss.JoinVertical(lipgloss.Left, sections...))
+}
+
+func countLine(label, value string, good bool) string {
+	style := readyStyle
+	if !good {
+		style = warningStyle
+	}
+	return fmt.Sprintf("%-10s %s", label, style.Render(value))
+}
+
+func healthSummary(results []health.Result) string {
+	ok := 0
+	for _, h := range results {
+		if h.Level == health.OK {
+			ok++
+		}
+	}
+	return fmt.Sprintf("%d/%d ok", ok, len(results))
+}
+
+func healthDot(level health.Level) string {
+	switch level {
+	case health.OK:
+		return readyStyle.Render("●")
+	case health.Warn:
+		return warningStyle.Render("●")
+	default:
+		return errorStyle.Render("●")
+	}
+}
+
+func lastApplyLine(s *status.Snapshot) string {
+	if s.LastApply == nil {
+		return fmt.Sprintf("%-10s %s", "Last run", mutedStyle.Render("never"))
+	}
+	r := s.LastApply
+	return fmt.Sprintf("%-10s %s %s %s", "Last run", r.Action, r.Software,
+		mutedStyle.Render(r.Time().Format("2006-01-02 15:04")))
+}
+
+func listOrNone(title string, items []string) string {
+	lines := []string{selectedMenuItemStyle.Render(title)}
+	if len(items) == 0 {
+		lines = append(lines, mutedStyle.Render("none"))
+	}
+	for _, item := range items {
+		lines = append(lines, "• "+item)
+	}
+	return strings.Join(lines, "\n")
+}
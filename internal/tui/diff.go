@@ -0,0 +1,283 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/hmziqrs/maziq/internal/engine"
+	"github.com/hmziqrs/maziq/internal/resource"
+	"github.com/hmziqrs/maziq/internal/textdiff"
+)
+
+const (
+	diffHelp     = "↑/↓ or j/k: Hunk • space: Accept/reject hunk • enter: Done • esc: Cancel"
+	diffViewHelp = "↑/↓ or j/k: Hunk • esc: Close"
+)
+
+// diffContext is how many unchanged lines are shown around a hunk.
+const diffContext = 3
+
+var (
+	deleteStyle = lipgloss.NewStyle().Background(lipgloss.Color("#3B1219"))
+	insertStyle = lipgloss.NewStyle().Background(lipgloss.Color("#0F2E1F"))
+)
+
+// diffModel shows the change a resource makes to a file side by side, the
+// current content on the left. When the resource is a Merger each hunk can
+// be rejected to keep the local lines.
+type diffModel struct {
+	id       string
+	change   resource.FileChange
+	merger   resource.Merger
+	script   []textdiff.Line
+	hunks    []textdiff.Hunk
+	accept   []bool
+	selected int
+	err      error
+}
+
+type diffLoadedMsg struct {
+	id     string
+	change resource.FileChange
+	merger resource.Merger
+	err    error
+}
+
+// loadDiff asks the resource behind id for its change. A plan applied by
+// the daemon is the daemon's, so its diffs are only shown, not merged.
+func (a applyModel) loadDiff(id string) tea.Cmd {
+	plan := a.plan
+	return func() tea.Msg {
+		ctx := context.Background()
+		env, err := configurationEnv()
+		if err != nil {
+			return diffLoadedMsg{id: id, err: err}
+		}
+		var rs []resource.Resource
+		if plan != nil {
+			for _, it := range plan.Items {
+				rs = append(rs, it.Resource)
+			}
+		} else if rs, err = engine.Resources(ctx, env); err != nil {
+			return diffLoadedMsg{id: id, err: err}
+		}
+		for _, r := range rs {
+			if r.ID() != id {
+				continue
+			}
+			d, ok := r.(resource.Differ)
+			if !ok {
+				return diffLoadedMsg{id: id, err: fmt.Errorf("%s does not write a file", id)}
+			}
+			c, err := d.Diff(ctx, env)
+			m, _ := r.(resource.Merger)
+			if plan == nil {
+				m = nil
+			}
+			return diffLoadedMsg{id: id, change: c, merger: m, err: err}
+		}
+		return diffLoadedMsg{id: id, err: fmt.Errorf("%s is no longer in the plan", id)}
+	}
+}
+
+func newDiffModel(msg diffLoadedMsg) *diffModel {
+	d := &diffModel{id: msg.id, change: msg.change, merger: msg.merger, err: msg.err}
+	if d.err != nil {
+		return d
+	}
+	d.script = textdiff.Lines(textdiff.Split(string(d.change.Current)), textdiff.Split(string(d.change.Desired)))
+	d.hunks = textdiff.Hunks(d.script)
+	d.accept = make([]bool, len(d.hunks))
+	for i := range d.accept {
+		d.accept[i] = true
+	}
+	return d
+}
+
+func (m model) updateDiff(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	a := &m.apply
+	d := a.diff
+	switch msg.String() {
+	case "up", "k":
+		if d.selected > 0 {
+			d.selected--
+		}
+	case "down", "j":
+		if d.selected < len(d.hunks)-1 {
+			d.selected++
+		}
+	case " ":
+		if d.merger != nil && len(d.hunks) > 0 {
+			d.accept[d.selected] = !d.accept[d.selected]
+		}
+	case "enter":
+		if d.merger != nil && d.rejected() > 0 {
+			if err := d.merger.Merge([]byte(textdiff.Merge(d.script, d.hunks, d.accept))); err != nil {
+				d.err = err
+				break
+			}
+			a.merged[d.id] = true
+		}
+		a.diff = nil
+	case "esc", "backspace":
+		a.diff = nil
+	case "q":
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+func (d *diffModel) rejected() int {
+	n := 0
+	for _, ok := range d.accept {
+		if !ok {
+			n++
+		}
+	}
+	return n
+}
+
+func (d *diffModel) help() string {
+	if d.merger == nil {
+		return diffViewHelp
+	}
+	return diffHelp
+}
+
+func (d *diffModel) view(width, height int) string {
+	if d.err != nil {
+		return errorStyle.Render(d.err.Error())
+	}
+	header := warningStyle.Render(d.change.Path)
+	switch {
+	case d.change.Current == nil:
+		header += mutedStyle.Render(" (new file)")
+	case len(d.hunks) == 0:
+		return header + "\n\n" + mutedStyle.Render("The content is unchanged; apply only corrects the file's mode.")
+	}
+	if d.merger != nil {
+		header += mutedStyle.Render(fmt.Sprintf(" · %d of %d hunk(s) accepted", len(d.hunks)-d.rejected(), len(d.hunks)))
+	}
+	col := (width - 3) / 2
+	titles := mutedStyle.Render(pad("current", col) + " │ " + pad("after apply", col))
+
+	// oldNum[i] and newNum[i] are the line numbers of script[i] in each file.
+	oldNum, newNum := make([]int, len(d.script)), make([]int, len(d.script))
+	o, n := 0, 0
+	for i, l := range d.script {
+		if l.Op != textdiff.Insert {
+			o++
+		}
+		if l.Op != textdiff.Delete {
+			n++
+		}
+		oldNum[i], newNum[i] = o, n
+	}
+
+	var rows []string
+	var starts []int
+	shown := 0
+	for k, h := range d.hunks {
+		from := max(h.Start-diffContext, shown)
+		to := h.End + diffContext
+		if k+1 < len(d.hunks) {
+			to = min(to, d.hunks[k+1].Start)
+		}
+		to = min(to, len(d.script))
+		shown = to
+
+		starts = append(starts, len(rows))
+		title := fmt.Sprintf("@@ line %d", max(oldNum[h.Start], 1))
+		switch {
+		case d.merger == nil:
+		case d.accept[k]:
+			title += " · accepted"
+		default:
+			title += " · rejected, keeping the current lines"
+		}
+		if k == d.selected {
+			rows = append(rows, selectedMenuItemStyle.Render("❯ "+title))
+		} else {
+			rows = append(rows, mutedStyle.Render("  "+title))
+		}
+
+		for i := from; i < h.Start; i++ {
+			rows = append(rows, cell(oldNum[i], d.script[i].Text, col, lipgloss.NewStyle())+" │ "+cell(newNum[i], d.script[i].Text, col, lipgloss.NewStyle()))
+		}
+		var dels, ins []int
+		for i := h.Start; i < h.End; i++ {
+			if d.script[i].Op == textdiff.Delete {
+				dels = append(dels, i)
+			} else {
+				ins = append(ins, i)
+			}
+		}
+		del, add := deleteStyle, insertStyle
+		if !d.accept[k] {
+			del, add = lipgloss.NewStyle(), mutedStyle
+		}
+		for r := 0; r < max(len(dels), len(ins)); r++ {
+			left, right := strings.Repeat(" ", col), strings.Repeat(" ", col)
+			if r < len(dels) {
+				left = cell(oldNum[dels[r]], d.script[dels[r]].Text, col, del)
+			}
+			if r < len(ins) {
+				right = cell(newNum[ins[r]], d.script[ins[r]].Text, col, add)
+			}
+			rows = append(rows, left+" │ "+right)
+		}
+		for i := h.End; i < to; i++ {
+			rows = append(rows, cell(oldNum[i], d.script[i].Text, col, lipgloss.NewStyle())+" │ "+cell(newNum[i], d.script[i].Text, col, lipgloss.NewStyle()))
+		}
+	}
+
+	// Keep the selected hunk in view.
+	room := max(height-4, 1)
+	start := 0
+	if len(rows) > room {
+		start = min(max(starts[d.selected]-1, 0), len(rows)-room)
+		rows = rows[start : start+room]
+	}
+	return header + "\n\n" + titles + "\n" + strings.Join(rows, "\n")
+}
+
+// cell renders one side of a diff row: the line number and the text,
+// highlighted and cut or padded to width.
+func cell(num int, text string, width int, base lipgloss.Style) string {
+	gutter := mutedStyle.Render(fmt.Sprintf("%4d ", num))
+	text = strings.ReplaceAll(strings.TrimRight(text, "\n"), "\t", "    ")
+	return gutter + highlight(pad(text, width-5), base)
+}
+
+// pad cuts or pads s to width columns.
+func pad(s string, width int) string {
+	width = max(width, 1)
+	r := []rune(s)
+	if len(r) > width {
+		return string(r[:width-1]) + "…"
+	}
+	return s + strings.Repeat(" ", width-len(r))
+}
+
+// highlight colours a line of a config file: comments, XML tags, and the
+// key of a key = value or key: value line.
+func highlight(s string, base lipgloss.Style) string {
+	trimmed := strings.TrimLeft(s, " ")
+	switch {
+	case strings.HasPrefix(trimmed, "#"), strings.HasPrefix(trimmed, "//"),
+		strings.HasPrefix(trimmed, ";"), strings.HasPrefix(trimmed, "<!--"):
+		return base.Foreground(mutedColor).Render(s)
+	case strings.HasPrefix(trimmed, "<"):
+		return base.Foreground(secondaryColor).Render(s)
+	}
+	if i := strings.IndexAny(s, "=:"); i > 0 {
+		if key := strings.TrimSpace(s[:i]); key != "" && !strings.ContainsAny(key, " \"'()") {
+			return base.Foreground(primaryColor).Render(s[:i]) + base.Render(s[i:])
+		}
+	}
+	return base.Render(s)
+}
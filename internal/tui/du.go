@@ -0,0 +1,104 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/hmziqrs/maziq/internal/config"
+	"github.com/hmziqrs/maziq/internal/du"
+)
+
+const duHelp = "↑/↓ or j/k: Move • s: Sort • r: Refresh • esc: Back • q: Quit"
+
+// duSorts are the orders s cycles through.
+var duSorts = []string{"size", "name", "category"}
+
+// duModel lists the disk space each managed package and cache takes up.
+type duModel struct {
+	report  du.Report
+	loading bool
+	err     error
+	sort    int
+	cursor  int
+}
+
+type duLoadedMsg struct {
+	report du.Report
+	err    error
+}
+
+func loadDU() tea.Msg {
+	env, err := configurationEnv()
+	if err != nil {
+		return duLoadedMsg{err: err}
+	}
+	report, err := du.Collect(context.Background(), env, func(string, error) {})
+	return duLoadedMsg{report: report, err: err}
+}
+
+func (m model) updateDU(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case duLoadedMsg:
+		m.du = duModel{report: msg.report, err: msg.err, sort: m.du.sort}
+		du.Sort(m.du.report.Entries, duSorts[m.du.sort])
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "q":
+			return m, tea.Quit
+		case "esc", "backspace":
+			m.screen = screenMenu
+		case "r":
+			if !m.du.loading {
+				m.du.loading = true
+				return m, loadDU
+			}
+		case "s":
+			m.du.sort = (m.du.sort + 1) % len(duSorts)
+			du.Sort(m.du.report.Entries, duSorts[m.du.sort])
+			m.du.cursor = 0
+		case "up", "k":
+			if m.du.cursor > 0 {
+				m.du.cursor--
+			}
+		case "down", "j":
+			if m.du.cursor < len(m.du.report.Entries)-1 {
+				m.du.cursor++
+			}
+		}
+	}
+	return m, nil
+}
+
+// view shows the entries around the cursor that fit in height lines,
+// followed by the totals.
+func (d duModel) view(height int) string {
+	if d.loading && d.report.Entries == nil {
+		return mutedStyle.Render("Measuring installed software…")
+	}
+	if d.err != nil {
+		return errorStyle.Render(d.err.Error())
+	}
+	if len(d.report.Entries) == 0 {
+		return mutedStyle.Render("Nothing installed to measure.")
+	}
+	var totals []string
+	for _, c := range du.Categories {
+		if n, ok := d.report.Totals[c]; ok {
+			totals = append(totals, fmt.Sprintf("%s %s", c, config.Size(n)))
+		}
+	}
+	summary := fmt.Sprintf("Total %s · %s", readyStyle.Render(config.Size(d.report.Total).String()), mutedStyle.Render(strings.Join(totals, " · ")))
+
+	rows := make([]string, len(d.report.Entries))
+	for i, e := range d.report.Entries {
+		rows[i] = fmt.Sprintf("%-28s %-10s %10s", e.Name, e.Category, config.Size(e.Size))
+	}
+	visible := max(height-4, 1)
+	start := min(max(d.cursor-visible/2, 0), max(len(rows)-visible, 0))
+	end := min(start+visible, len(rows))
+	list := renderList(rows[start:end], d.cursor-start)
+	return mutedStyle.Render("Sorted by "+duSorts[d.sort]) + "\n\n" + list + "\n\n" + summary
+}
@@ -0,0 +1,188 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/hmziqrs/maziq/internal/engine"
+)
+
+const (
+	fixHelp        = "enter: Run the fix • n: Next problem • esc: Close"
+	fixRunningHelp = "Fixing…"
+	fixWaitHelp    = "enter: Done • esc: Stop the fix"
+)
+
+// fixModel walks through the fix for a known failure of the last run. It
+// runs on its own goroutine and reports through events; a manual step
+// waits for reply.
+type fixModel struct {
+	outcome engine.Outcome
+	events  <-chan tea.Msg
+	cancel  context.CancelFunc
+	// prompt and reply are set while a manual step waits for the user.
+	prompt string
+	reply  chan<- struct{}
+	log    []string
+	done   bool
+	err    error
+}
+
+type fixLogMsg struct {
+	line   string
+	events <-chan tea.Msg
+}
+
+type fixPromptMsg struct {
+	prompt string
+	reply  chan<- struct{}
+	events <-chan tea.Msg
+}
+
+type fixDoneMsg struct {
+	err error
+}
+
+func waitFix(events <-chan tea.Msg) tea.Cmd {
+	return func() tea.Msg { return <-events }
+}
+
+// startFix runs the fix for o and returns its events. The channel is closed
+// after fixDoneMsg.
+func startFix(ctx context.Context, o engine.Outcome) <-chan tea.Msg {
+	events := make(chan tea.Msg)
+	go func() {
+		defer close(events)
+		env, err := configurationEnv()
+		if err != nil {
+			events <- fixDoneMsg{err: err}
+			return
+		}
+		env.Logf = func(format string, args ...any) {
+			events <- fixLogMsg{line: fmt.Sprintf(format, args...), events: events}
+		}
+		env.Wait = func(ctx context.Context, prompt string) error {
+			reply := make(chan struct{})
+			events <- fixPromptMsg{prompt: prompt, reply: reply, events: events}
+			select {
+			case <-reply:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		events <- fixDoneMsg{err: o.Fix.Run(ctx, env)}
+	}()
+	return events
+}
+
+// nextFixable returns the first failure of the run after id that has a fix
+// and was not fixed yet.
+func (a applyModel) nextFixable(after string) (engine.Outcome, bool) {
+	if a.report == nil {
+		return engine.Outcome{}, false
+	}
+	var candidates []engine.Outcome
+	for _, o := range a.report.Outcomes {
+		if o.Fix != nil && !a.fixed[o.ID] {
+			candidates = append(candidates, o)
+		}
+	}
+	for i, o := range candidates {
+		if o.ID == after && len(candidates) > 1 {
+			return candidates[(i+1)%len(candidates)], true
+		}
+	}
+	if len(candidates) == 0 {
+		return engine.Outcome{}, false
+	}
+	return candidates[0], true
+}
+
+func (m model) updateFix(msg tea.Msg) (tea.Model, tea.Cmd) {
+	a := &m.apply
+	f := a.fix
+	switch msg := msg.(type) {
+	case fixLogMsg:
+		f.log = append(f.log, msg.line)
+		return m, waitFix(msg.events)
+	case fixPromptMsg:
+		f.prompt, f.reply = msg.prompt, msg.reply
+		return m, waitFix(msg.events)
+	case fixDoneMsg:
+		f.cancel()
+		f.events, f.done, f.err = nil, true, msg.err
+		if msg.err == nil {
+			a.fixed[f.outcome.ID] = true
+		}
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "enter":
+			switch {
+			case f.reply != nil:
+				close(f.reply)
+				f.prompt, f.reply = "", nil
+			case f.events == nil && !f.done:
+				ctx, cancel := context.WithCancel(context.Background())
+				f.cancel = cancel
+				f.events = startFix(ctx, f.outcome)
+				return m, waitFix(f.events)
+			}
+		case "n":
+			if f.events == nil {
+				if o, ok := a.nextFixable(f.outcome.ID); ok {
+					a.fix = &fixModel{outcome: o}
+				}
+			}
+		case "esc":
+			if f.events != nil {
+				f.cancel()
+				break
+			}
+			a.fix = nil
+		}
+	}
+	return m, nil
+}
+
+func (f *fixModel) help() string {
+	switch {
+	case f.reply != nil:
+		return fixWaitHelp
+	case f.events != nil:
+		return fixRunningHelp
+	case f.done:
+		return "n: Next problem • esc: Close • r: Re-plan"
+	}
+	return fixHelp
+}
+
+func (f *fixModel) view() string {
+	o := f.outcome
+	lines := []string{
+		warningStyle.Render(o.Fix.Title()) + mutedStyle.Render(" for "+o.ID),
+		mutedStyle.Render(o.Fix.Explain()),
+	}
+	for i, s := range o.Fix.Steps() {
+		line := fmt.Sprintf("  %d. %s", i+1, s.Say)
+		if s.Command != nil {
+			line += mutedStyle.Render(": " + s.Command.String())
+		}
+		lines = append(lines, line)
+	}
+	if len(f.log) > 0 {
+		lines = append(lines, "", mutedStyle.Render(strings.Join(f.log, "\n")))
+	}
+	switch {
+	case f.prompt != "":
+		lines = append(lines, "", warningStyle.Render(f.prompt))
+	case f.done && f.err != nil:
+		lines = append(lines, "", errorStyle.Render("✗ "+f.err.Error()))
+	case f.done:
+		lines = append(lines, "", readyStyle.Render("✓ Fixed; press r to plan again"))
+	}
+	return strings.Join(lines, "\n")
+}
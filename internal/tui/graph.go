@@ -0,0 +1,117 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/hmziqrs/maziq/internal/graph"
+)
+
+const graphHelp = "↑/↓ or j/k: Move • r: Refresh • esc: Back • q: Quit"
+
+// graphModel lists the template's resources and shows, for the selected
+// one, what it needs and what removing it would break.
+type graphModel struct {
+	graph   *graph.Graph
+	loading bool
+	err     error
+	cursor  int
+}
+
+type graphLoadedMsg struct {
+	graph *graph.Graph
+	err   error
+}
+
+func loadGraph() tea.Msg {
+	env, err := configurationEnv()
+	if err != nil {
+		return graphLoadedMsg{err: err}
+	}
+	g, err := graph.Build(context.Background(), env)
+	return graphLoadedMsg{graph: g, err: err}
+}
+
+func (m model) updateGraph(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case graphLoadedMsg:
+		m.graph = graphModel{graph: msg.graph, err: msg.err, cursor: m.graph.cursor}
+		if msg.graph != nil {
+			m.graph.cursor = min(m.graph.cursor, max(len(msg.graph.Nodes)-1, 0))
+		}
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "q":
+			return m, tea.Quit
+		case "esc", "backspace":
+			m.screen = screenMenu
+		case "r":
+			if !m.graph.loading {
+				m.graph.loading = true
+				return m, loadGraph
+			}
+		case "up", "k":
+			if m.graph.cursor > 0 {
+				m.graph.cursor--
+			}
+		case "down", "j":
+			if m.graph.graph != nil && m.graph.cursor < len(m.graph.graph.Nodes)-1 {
+				m.graph.cursor++
+			}
+		}
+	}
+	return m, nil
+}
+
+// view shows the resources around the cursor that fit in height lines,
+// and the edges of the selected one.
+func (gm graphModel) view(height int) string {
+	switch {
+	case gm.loading && gm.graph == nil:
+		return mutedStyle.Render("Building the dependency graph…")
+	case gm.err != nil:
+		return errorStyle.Render(gm.err.Error())
+	case gm.graph == nil || len(gm.graph.Nodes) == 0:
+		return mutedStyle.Render("The template has no resources.")
+	}
+	g := gm.graph
+	rows := make([]string, len(g.Nodes))
+	for i, n := range g.Nodes {
+		rows[i] = n.ID
+		if k := len(g.RequiredBy(n.ID)); k > 0 {
+			rows[i] += mutedStyle.Render(fmt.Sprintf("  ← %d", k))
+		}
+	}
+	visible := max(height/2, 1)
+	start := min(max(gm.cursor-visible/2, 0), max(len(rows)-visible, 0))
+	end := min(start+visible, len(rows))
+	list := renderList(rows[start:end], gm.cursor-start)
+
+	id := g.Nodes[gm.cursor].ID
+	var detail []string
+	for _, e := range g.Requires(id) {
+		detail = append(detail, "requires     "+graphEdge(e.To, e))
+	}
+	for _, e := range g.RequiredBy(id) {
+		detail = append(detail, "required by  "+graphEdge(e.From, e))
+	}
+	if deps := g.Dependents(id); len(deps) > 0 {
+		detail = append(detail, "", warningStyle.Render(fmt.Sprintf("Removing it would break %d: %s", len(deps), strings.Join(deps, ", "))))
+	} else {
+		detail = append(detail, "", readyStyle.Render("Nothing else needs it"))
+	}
+	return list + "\n\n" + g.Nodes[gm.cursor].Description + "\n" + strings.Join(detail, "\n")
+}
+
+func graphEdge(id string, e graph.Edge) string {
+	switch e.Kind {
+	case graph.Order:
+		return id + mutedStyle.Render(" ([[order]])")
+	case graph.Brew:
+		return id + mutedStyle.Render(" (Homebrew dependency)")
+	}
+	return id
+}
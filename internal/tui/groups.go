@@ -0,0 +1,102 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/hmziqrs/maziq/internal/engine"
+	"github.com/hmziqrs/maziq/internal/templates"
+)
+
+const groupsHelp = "space: Toggle • enter: Plan • ↑/↓ or j/k: Move • esc: Back • q: Quit"
+
+// groupsModel is the checklist of the template's groups shown before the
+// Apply screen plans, to leave some out of this run.
+type groupsModel struct {
+	groups  []templates.Group
+	members map[string][]string
+	// on starts as the template and profile set the groups.
+	on       map[string]bool
+	defaults map[string]bool
+	cursor   int
+}
+
+type groupsLoadedMsg struct {
+	groups groupsModel
+}
+
+// loadGroups loads the checklist, or goes straight to planning when the
+// template has no groups.
+func loadGroups() tea.Msg {
+	env, err := configurationEnv()
+	if err != nil {
+		return planLoadedMsg{err: err}
+	}
+	t := env.Template
+	if len(t.Groups) == 0 {
+		return loadPlan(nil)()
+	}
+	members, err := engine.GroupMembers(context.Background(), env)
+	if err != nil {
+		return planLoadedMsg{err: err}
+	}
+	g := groupsModel{groups: t.Groups, members: members, on: map[string]bool{}}
+	for _, group := range t.Groups {
+		g.on[group.Name] = t.GroupOn(group)
+	}
+	g.defaults = maps.Clone(g.on)
+	return groupsLoadedMsg{groups: g}
+}
+
+func (m model) updateGroups(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	g := m.apply.checklist
+	switch msg.String() {
+	case "q":
+		return m, tea.Quit
+	case "esc", "backspace":
+		m.screen = screenMenu
+	case "up", "k":
+		if g.cursor > 0 {
+			g.cursor--
+		}
+	case "down", "j":
+		if g.cursor < len(g.groups)-1 {
+			g.cursor++
+		}
+	case " ", "x":
+		name := g.groups[g.cursor].Name
+		g.on[name] = !g.on[name]
+	case "enter":
+		var toggles map[string]bool
+		if !maps.Equal(g.on, g.defaults) {
+			toggles = g.on
+		}
+		m.apply = applyModel{toggles: toggles}
+		return m, loadPlan(toggles)
+	}
+	return m, nil
+}
+
+func (g *groupsModel) view() string {
+	rows := make([]string, len(g.groups))
+	for i, group := range g.groups {
+		mark := "[ ]"
+		if g.on[group.Name] {
+			mark = "[x]"
+		}
+		row := fmt.Sprintf("%s %-20s %s", mark, group.Name, mutedStyle.Render(fmt.Sprintf("%d resource(s)", len(g.members[group.Name]))))
+		if group.Description != "" {
+			row += "  " + mutedStyle.Render(group.Description)
+		}
+		rows[i] = row
+	}
+	out := "Groups to apply this time; the template is left as it is.\n\n" + renderList(rows, g.cursor)
+	if ids := g.members[g.groups[g.cursor].Name]; len(ids) > 0 {
+		out += "\n\n" + mutedStyle.Render(strings.Join(ids, ", "))
+	}
+	return out
+}
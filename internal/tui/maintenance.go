@@ -0,0 +1,103 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/hmziqrs/maziq/internal/config"
+	"github.com/hmziqrs/maziq/internal/maintenance"
+	"github.com/hmziqrs/maziq/internal/shell"
+)
+
+const maintenanceHelp = "enter: Clean up • r: Refresh • esc: Back • q: Quit"
+
+// maintenanceModel previews what maintenance would reclaim and runs it.
+type maintenanceModel struct {
+	results []maintenance.Result
+	// done is set once results are from a real run rather than a preview.
+	done     bool
+	keepDays int
+	loading  bool
+	running  bool
+	err      error
+}
+
+type maintenanceMsg struct {
+	results  []maintenance.Result
+	keepDays int
+	dryRun   bool
+	err      error
+}
+
+func runMaintenance(dryRun bool) tea.Cmd {
+	return func() tea.Msg {
+		cfg, err := config.Load()
+		if err != nil {
+			return maintenanceMsg{err: err}
+		}
+		runner := shell.FromEnv(shell.Local{Timeout: cfg.Apply.Timeout, IdleTimeout: cfg.Apply.IdleTimeout})
+		opts := maintenance.Options{KeepDays: cfg.Maintenance.KeepDays, Brew: true, DryRun: dryRun}
+		return maintenanceMsg{results: maintenance.Run(context.Background(), runner, opts), keepDays: opts.KeepDays, dryRun: dryRun}
+	}
+}
+
+func (m model) updateMaintenance(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case maintenanceMsg:
+		m.maintenance = maintenanceModel{results: msg.results, done: !msg.dryRun, keepDays: msg.keepDays, err: msg.err}
+	case tea.KeyMsg:
+		busy := m.maintenance.loading || m.maintenance.running
+		switch msg.String() {
+		case "q":
+			return m, tea.Quit
+		case "esc", "backspace":
+			if !m.maintenance.running {
+				m.screen = screenMenu
+			}
+		case "r":
+			if !busy {
+				m.maintenance.loading = true
+				return m, runMaintenance(true)
+			}
+		case "enter", " ":
+			if !busy && !m.maintenance.done && m.maintenance.err == nil {
+				m.maintenance.running = true
+				return m, runMaintenance(false)
+			}
+		}
+	}
+	return m, nil
+}
+
+func (mm maintenanceModel) view() string {
+	switch {
+	case mm.running:
+		return mutedStyle.Render("Cleaning up…")
+	case mm.loading && mm.results == nil:
+		return mutedStyle.Render("Measuring what can be reclaimed…")
+	case mm.err != nil:
+		return errorStyle.Render(mm.err.Error())
+	}
+	var rows []string
+	for _, r := range mm.results {
+		switch {
+		case r.Err != nil:
+			rows = append(rows, errorStyle.Render(fmt.Sprintf("✗ %-20s %v", r.Task, r.Err)))
+		case r.Skipped != "":
+			rows = append(rows, mutedStyle.Render(fmt.Sprintf("- %-20s %s", r.Task, r.Skipped)))
+		case r.Removed == 0 && r.Freed == 0:
+			rows = append(rows, mutedStyle.Render(fmt.Sprintf("  %-20s nothing to reclaim", r.Task)))
+		default:
+			rows = append(rows, fmt.Sprintf("  %-20s %d, %s", r.Task, r.Removed, config.Size(r.Freed)))
+		}
+	}
+	total := config.Size(maintenance.Freed(mm.results))
+	summary := warningStyle.Render(fmt.Sprintf("%s can be reclaimed, keeping the last %d days · press enter to clean up", total, mm.keepDays))
+	if mm.done {
+		summary = readyStyle.Render(fmt.Sprintf("✓ Reclaimed %s", total))
+	}
+	return strings.Join(rows, "\n") + "\n\n" + summary
+}
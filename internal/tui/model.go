@@ -0,0 +1,383 @@
+// Package tui implements the interactive Bubbletea interface.
+package tui
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/hmziqrs/maziq/internal/glyphs"
+	"github.com/hmziqrs/maziq/internal/selfupdate"
+	"github.com/hmziqrs/maziq/internal/shell"
+)
+
+type screen int
+
+const (
+	screenMenu screen = iota
+	screenTemplates
+	screenAuthoring
+	screenConfiguration
+	screenOutdated
+	screenApply
+	screenSecurity
+	screenMaintenance
+	screenDU
+	screenGraph
+	screenWhich
+	screenOrphans
+)
+
+const (
+	menuCatalog       = "Software Catalog"
+	menuTemplates     = "Templates"
+	menuE2E           = "E2E Testing"
+	menuConfiguration = "Configuration"
+	menuOutdated      = "Outdated"
+	menuApply         = "Apply"
+	menuSecurity      = "Security"
+	menuMaintenance   = "Maintenance"
+	menuDU            = "Disk Usage"
+	menuGraph         = "Dependencies"
+	menuWhich         = "Which"
+	menuOrphans       = "Orphans"
+)
+
+type model struct {
+	width        int
+	height       int
+	screen       screen
+	selectedMenu int
+	menuItems    []string
+	ready        bool
+
+	templates     templatesModel
+	authoring     authoringModel
+	configuration configurationModel
+	outdated      outdatedModel
+	apply         applyModel
+	security      securityModel
+	maintenance   maintenanceModel
+	du            duModel
+	graph         graphModel
+	which         whichModel
+	orphans       orphansModel
+
+	// update is a newer maziq release, offered on the menu.
+	update *selfupdate.Available
+	// updateNotice reports a self-update in progress or its outcome.
+	updateNotice string
+}
+
+type selfUpdateMsg selfupdate.Available
+
+type selfUpdatedMsg struct {
+	version string
+	err     error
+}
+
+// checkSelfUpdate looks for a newer maziq release in the background. Errors
+// are dropped; being offline should not nag.
+func checkSelfUpdate() tea.Msg {
+	a, err := selfupdate.Check(context.Background())
+	if err != nil || !a.Newer() {
+		return nil
+	}
+	return selfUpdateMsg(a)
+}
+
+func applySelfUpdate(a selfupdate.Available) tea.Cmd {
+	return func() tea.Msg {
+		_, err := selfupdate.Apply(context.Background(), shell.Local{}, a.Release, runtime.GOARCH)
+		return selfUpdatedMsg{version: a.Latest, err: err}
+	}
+}
+
+func initialModel() model {
+	return model{
+		menuItems: []string{
+			menuCatalog,
+			menuTemplates,
+			menuApply,
+			menuE2E,
+			menuConfiguration,
+			menuGraph,
+			menuWhich,
+			menuOutdated,
+			menuSecurity,
+			menuDU,
+			menuOrphans,
+			menuMaintenance,
+		},
+		ready: true,
+	}
+}
+
+// Run starts the interactive interface and blocks until it exits.
+func Run() error {
+	p := tea.NewProgram(
+		initialModel(),
+		tea.WithAltScreen(),
+		tea.WithMouseCellMotion(),
+	)
+	_, err := p.Run()
+	return err
+}
+
+func (m model) Init() tea.Cmd {
+	return checkSelfUpdate
+}
+
+func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		return m, nil
+
+	case tea.KeyMsg:
+		if msg.String() == "ctrl+c" {
+			if m.apply.running() {
+				return m.stopApply()
+			}
+			return m, tea.Quit
+		}
+
+	case selfUpdateMsg:
+		a := selfupdate.Available(msg)
+		m.update = &a
+		return m, nil
+
+	case selfUpdatedMsg:
+		if msg.err != nil {
+			m.updateNotice = errorStyle.Render("Update failed: " + msg.err.Error())
+		} else {
+			m.update = nil
+			m.updateNotice = readyStyle.Render(fmt.Sprintf("Updated to maziq %s · restart to use it", msg.version))
+		}
+		return m, nil
+	}
+
+	switch m.screen {
+	case screenTemplates:
+		return m.updateTemplates(msg)
+	case screenAuthoring:
+		return m.updateAuthoring(msg)
+	case screenConfiguration:
+		return m.updateConfiguration(msg)
+	case screenOutdated:
+		return m.updateOutdated(msg)
+	case screenApply:
+		return m.updateApply(msg)
+	case screenSecurity:
+		return m.updateSecurity(msg)
+	case screenMaintenance:
+		return m.updateMaintenance(msg)
+	case screenDU:
+		return m.updateDU(msg)
+	case screenGraph:
+		return m.updateGraph(msg)
+	case screenWhich:
+		return m.updateWhich(msg)
+	case screenOrphans:
+		return m.updateOrphans(msg)
+	}
+	return m.updateMenu(msg)
+}
+
+func (m model) updateMenu(msg tea.Msg) (tea.Model, tea.Cmd) {
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	switch key.String() {
+	case "q":
+		return m, tea.Quit
+
+	case "u":
+		if m.update != nil {
+			m.updateNotice = mutedStyle.Render(fmt.Sprintf("Downloading maziq %s…", m.update.Latest))
+			return m, applySelfUpdate(*m.update)
+		}
+
+	case "up", "k":
+		if m.selectedMenu > 0 {
+			m.selectedMenu--
+		}
+
+	case "down", "j":
+		if m.selectedMenu < len(m.menuItems)-1 {
+			m.selectedMenu++
+		}
+
+	case "enter", " ":
+		switch m.menuItems[m.selectedMenu] {
+		case menuTemplates:
+			m.templates = newTemplatesModel()
+			m.screen = screenTemplates
+		case menuConfiguration:
+			m.configuration = configurationModel{loading: true}
+			m.screen = screenConfiguration
+			return m, loadConfiguration
+		case menuApply:
+			m.apply = applyModel{}
+			m.screen = screenApply
+			return m, loadGroups
+		case menuOutdated:
+			m.outdated = outdatedModel{loading: true}
+			m.screen = screenOutdated
+			return m, loadOutdated
+		case menuSecurity:
+			m.security = securityModel{loading: true}
+			m.screen = screenSecurity
+			return m, loadSecurity
+		case menuGraph:
+			m.graph = graphModel{loading: true}
+			m.screen = screenGraph
+			return m, loadGraph
+		case menuWhich:
+			m.which = whichModel{loading: true}
+			m.screen = screenWhich
+			return m, loadWhich
+		case menuDU:
+			m.du = duModel{loading: true}
+			m.screen = screenDU
+			return m, loadDU
+		case menuOrphans:
+			m.orphans = orphansModel{loading: true}
+			m.screen = screenOrphans
+			return m, loadOrphans
+		case menuMaintenance:
+			m.maintenance = maintenanceModel{loading: true}
+			m.screen = screenMaintenance
+			return m, runMaintenance(true)
+		}
+	}
+	return m, nil
+}
+
+func (m model) View() string {
+	if glyphs.Plain() {
+		return glyphs.ASCII(m.view())
+	}
+	return m.view()
+}
+
+func (m model) view() string {
+	if m.width == 0 {
+		return "Loading..."
+	}
+
+	switch m.screen {
+	case screenTemplates:
+		return m.frame("Templates", m.templates.view(), "↑/↓ or j/k: Navigate • Enter: Open • esc: Back • q: Quit")
+	case screenAuthoring:
+		return m.frame("Template authoring", m.authoring.view(m.height-12), authoringHelp)
+	case screenConfiguration:
+		help := configurationHelp
+		if m.configuration.searching {
+			help = searchHelp
+		}
+		return m.frame("Configuration", m.configuration.view(m.height-12), help)
+	case screenOutdated:
+		return m.frame("Outdated", m.outdated.view(), outdatedHelp)
+	case screenApply:
+		return m.frame("Apply", m.apply.view(m.width-10, m.height-12), m.apply.help())
+	case screenSecurity:
+		return m.frame("Security", m.security.view(), securityHelp)
+	case screenMaintenance:
+		return m.frame("Maintenance", m.maintenance.view(), maintenanceHelp)
+	case screenDU:
+		return m.frame("Disk usage", m.du.view(m.height-12), duHelp)
+	case screenGraph:
+		return m.frame("Dependencies", m.graph.view(m.height-12), graphHelp)
+	case screenWhich:
+		return m.frame("Which", m.which.view(m.height-12), whichHelp)
+	case screenOrphans:
+		return m.frame("Orphans", m.orphans.view(), orphansHelp)
+	}
+
+	var sections []string
+
+	// Logo and title
+	logo := logoStyle.Render(`
+ ███╗   ███╗ █████╗ ███████╗██╗ ██████╗
+ ████╗ ████║██╔══██╗╚══███╔╝██║██╔═══██╗
+ ██╔████╔██║███████║  ███╔╝ ██║██║   ██║
+ ██║╚██╔╝██║██╔══██║ ███╔╝  ██║██║▄▄ ██║
+ ██║ ╚═╝ ██║██║  ██║███████╗██║╚██████╔╝
+ ╚═╝     ╚═╝╚═╝  ╚═╝╚══════╝╚═╝ ╚══▀▀═╝ `)
+
+	subtitle := subtitleStyle.Render("macOS Provisioning & Automation Tool")
+
+	header := lipgloss.JoinVertical(lipgloss.Center, logo, subtitle)
+	sections = append(sections, header)
+
+	// Status indicator
+	var status string
+	if m.ready {
+		status = readyStyle.Render("● Ready")
+	} else {
+		status = errorStyle.Render("● Not Ready")
+	}
+	switch {
+	case m.updateNotice != "":
+		status += "   " + m.updateNotice
+	case m.update != nil:
+		status += "   " + warningStyle.Render(fmt.Sprintf("↑ maziq %s is available · press u to update", m.update.Latest))
+	}
+	statusBox := boxStyle.Width(m.width - 4).Render(status)
+	sections = append(sections, statusBox)
+
+	// Menu
+	menu := renderList(m.menuItems, m.selectedMenu)
+	menuBox := boxStyle.
+		Width(m.width - 4).
+		Render(menu)
+	sections = append(sections, menuBox)
+
+	// Help text
+	help := helpStyle.Render(
+		"↑/↓ or j/k: Navigate • Enter: Select • q: Quit",
+	)
+	sections = append(sections, help)
+
+	// Join all sections
+	content := lipgloss.JoinVertical(lipgloss.Left, sections...)
+
+	// Center the content
+	return lipgloss.Place(
+		m.width,
+		m.height,
+		lipgloss.Center,
+		lipgloss.Center,
+		content,
+	)
+}
+
+// frame renders a sub-screen with a title, boxed body and help line.
+func (m model) frame(title, body, help string) string {
+	content := lipgloss.JoinVertical(lipgloss.Left,
+		titleStyle.Render(title),
+		boxStyle.Width(m.width-4).Render(body),
+		helpStyle.Render(help),
+	)
+	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Top, content)
+}
+
+// renderList renders items with a cursor next to the selected one.
+func renderList(items []string, selected int) string {
+	rendered := make([]string, len(items))
+	for i, item := range items {
+		if i == selected {
+			rendered[i] = selectedMenuItemStyle.Render("❯ " + item)
+		} else {
+			rendered[i] = menuItemStyle.Render("  " + item)
+		}
+	}
+	return strings.Join(rendered, "\n")
+}
@@ -0,0 +1,122 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/hmziqrs/maziq/internal/orphans"
+)
+
+const orphansHelp = "enter: Uninstall • r: Refresh • esc: Back • q: Quit"
+
+// orphansModel lists the formulae installed only for packages the template
+// dropped, and uninstalls the ones nothing installed needs.
+type orphansModel struct {
+	report  orphans.Report
+	loading bool
+	running bool
+	// removed is set once the removable orphans were uninstalled.
+	removed []string
+	err     error
+}
+
+type orphansLoadedMsg struct {
+	report orphans.Report
+	err    error
+}
+
+type orphansRemovedMsg struct {
+	removed []string
+	err     error
+}
+
+func loadOrphans() tea.Msg {
+	env, err := configurationEnv()
+	if err != nil {
+		return orphansLoadedMsg{err: err}
+	}
+	report, err := orphans.Find(context.Background(), env)
+	return orphansLoadedMsg{report: report, err: err}
+}
+
+func removeOrphans(formulae []string) tea.Cmd {
+	return func() tea.Msg {
+		env, err := configurationEnv()
+		if err == nil {
+			err = orphans.Remove(context.Background(), env.Runner, formulae)
+		}
+		return orphansRemovedMsg{removed: formulae, err: err}
+	}
+}
+
+func (m model) updateOrphans(msg tea.Msg) (tea.Model, tea.Cmd) {
+	o := &m.orphans
+	switch msg := msg.(type) {
+	case orphansLoadedMsg:
+		*o = orphansModel{report: msg.report, err: msg.err}
+	case orphansRemovedMsg:
+		o.running, o.err = false, msg.err
+		if msg.err == nil {
+			o.removed = msg.removed
+		}
+	case tea.KeyMsg:
+		busy := o.loading || o.running
+		switch msg.String() {
+		case "q":
+			return m, tea.Quit
+		case "esc", "backspace":
+			if !o.running {
+				m.screen = screenMenu
+			}
+		case "r":
+			if !busy {
+				o.loading = true
+				return m, loadOrphans
+			}
+		case "enter", " ":
+			if formulae := o.report.Removable(); !busy && o.removed == nil && o.err == nil && len(formulae) > 0 {
+				o.running = true
+				return m, removeOrphans(formulae)
+			}
+		}
+	}
+	return m, nil
+}
+
+func (om orphansModel) view() string {
+	switch {
+	case om.running:
+		return mutedStyle.Render("Uninstalling…")
+	case om.loading && om.report.Orphans == nil:
+		return mutedStyle.Render("Comparing what Homebrew installed with the template…")
+	case om.err != nil:
+		return errorStyle.Render(om.err.Error())
+	case om.removed != nil:
+		return readyStyle.Render("✓ Uninstalled " + strings.Join(om.removed, ", "))
+	case om.report.Skipped != "":
+		return mutedStyle.Render(om.report.Skipped + "; nothing to check.")
+	}
+	var out string
+	if len(om.report.Orphans) == 0 {
+		out = readyStyle.Render("Every formula Homebrew installed is in the template or needed by something that is.")
+	} else {
+		rows := make([]string, len(om.report.Orphans))
+		for i, o := range om.report.Orphans {
+			rows[i] = "  " + o.Formula
+			if !o.Removable() {
+				rows[i] = fmt.Sprintf("  %-24s %s", o.Formula, mutedStyle.Render("needed by "+strings.Join(o.NeededBy, ", ")))
+			}
+		}
+		out = "Installed only as dependencies of packages the template does not have:\n\n" + strings.Join(rows, "\n")
+	}
+	if len(om.report.Dropped) > 0 {
+		out += "\n\n" + warningStyle.Render("Installed by MazIQ and since dropped from the template: "+strings.Join(om.report.Dropped, ", "))
+	}
+	if n := len(om.report.Removable()); n > 0 {
+		out += "\n\n" + warningStyle.Render(fmt.Sprintf("%d formula(e) nothing installed needs · press enter to uninstall them", n))
+	}
+	return out
+}
@@ -0,0 +1,158 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/hmziqrs/maziq/internal/manager"
+	"github.com/hmziqrs/maziq/internal/modules/binaries"
+)
+
+const outdatedHelp = "↑/↓ or j/k: Move • enter: Upgrade binary • r: Refresh • esc: Back • q: Quit"
+
+// outdatedModel lists software with a newer version available: Homebrew
+// packages, which brew upgrades, and release binaries, which are upgraded in
+// place from here.
+type outdatedModel struct {
+	brew     map[string]string
+	binaries []binaries.Update
+	loading  bool
+	// upgrading names the binary being upgraded.
+	upgrading string
+	err       error
+	notice    string
+	cursor    int
+}
+
+type outdatedLoadedMsg struct {
+	brew     map[string]string
+	binaries []binaries.Update
+	err      error
+}
+
+type binaryUpgradedMsg struct {
+	update binaries.Update
+	err    error
+}
+
+func loadOutdated() tea.Msg {
+	env, err := configurationEnv()
+	if err != nil {
+		return outdatedLoadedMsg{err: err}
+	}
+	ctx := context.Background()
+	entries, err := env.Template.Active(env.Facts)
+	if err != nil {
+		return outdatedLoadedMsg{err: err}
+	}
+	ids := make([]string, len(entries))
+	for i, e := range entries {
+		ids[i] = e.ID
+	}
+	brew, err := manager.Outdated(ctx, env.Runner, ids)
+	if err != nil {
+		return outdatedLoadedMsg{err: err}
+	}
+	// A failed lookup still leaves the updates that were found.
+	updates, err := binaries.Outdated(ctx, env)
+	return outdatedLoadedMsg{brew: brew, binaries: updates, err: err}
+}
+
+func upgradeBinary(u binaries.Update) tea.Cmd {
+	return func() tea.Msg {
+		env, err := configurationEnv()
+		if err != nil {
+			return binaryUpgradedMsg{update: u, err: err}
+		}
+		return binaryUpgradedMsg{update: u, err: u.Binary.Apply(context.Background(), env)}
+	}
+}
+
+func (m model) updateOutdated(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case outdatedLoadedMsg:
+		m.outdated = outdatedModel{brew: msg.brew, binaries: msg.binaries, err: msg.err, notice: m.outdated.notice}
+		m.outdated.cursor = min(m.outdated.cursor, max(len(msg.binaries)-1, 0))
+	case binaryUpgradedMsg:
+		m.outdated.upgrading = ""
+		if msg.err != nil {
+			m.outdated.notice = errorStyle.Render(fmt.Sprintf("%s: %v", msg.update.Name, msg.err))
+		} else {
+			m.outdated.notice = readyStyle.Render(fmt.Sprintf("%s upgraded to %s", msg.update.Name, msg.update.Latest))
+		}
+		m.outdated.loading = true
+		return m, loadOutdated
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "q":
+			return m, tea.Quit
+		case "esc", "backspace":
+			m.screen = screenMenu
+		case "r":
+			if !m.outdated.loading {
+				m.outdated.loading, m.outdated.notice = true, ""
+				return m, loadOutdated
+			}
+		case "up", "k":
+			if m.outdated.cursor > 0 {
+				m.outdated.cursor--
+			}
+		case "down", "j":
+			if m.outdated.cursor < len(m.outdated.binaries)-1 {
+				m.outdated.cursor++
+			}
+		case "enter", " ":
+			o := m.outdated
+			if o.upgrading == "" && !o.loading && o.cursor < len(o.binaries) {
+				u := o.binaries[o.cursor]
+				m.outdated.upgrading = u.Name
+				m.outdated.notice = mutedStyle.Render(fmt.Sprintf("Upgrading %s to %s…", u.Name, u.Latest))
+				return m, upgradeBinary(u)
+			}
+		}
+	}
+	return m, nil
+}
+
+func (o outdatedModel) view() string {
+	if o.loading && o.brew == nil && o.binaries == nil {
+		return mutedStyle.Render("Checking for updates…")
+	}
+	var sections []string
+	if o.err != nil {
+		sections = append(sections, errorStyle.Render(o.err.Error()))
+	}
+	if o.notice != "" {
+		sections = append(sections, o.notice)
+	}
+
+	var rows []string
+	for _, u := range o.binaries {
+		rows = append(rows, fmt.Sprintf("%-24s %s → %s", u.Name, u.Installed, readyStyle.Render(u.Latest)))
+	}
+	if len(rows) > 0 {
+		sections = append(sections, "Release binaries", renderList(rows, o.cursor))
+	}
+
+	ids := make([]string, 0, len(o.brew))
+	for id := range o.brew {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	var brew []string
+	for _, id := range ids {
+		brew = append(brew, fmt.Sprintf("  %-24s → %s", id, o.brew[id]))
+	}
+	if len(brew) > 0 {
+		sections = append(sections, "Homebrew "+mutedStyle.Render("(brew upgrade)"), strings.Join(brew, "\n"))
+	}
+
+	if len(rows) == 0 && len(brew) == 0 && o.err == nil {
+		sections = append(sections, readyStyle.Render("✓ Everything is up to date"))
+	}
+	return strings.Join(sections, "\n\n")
+}
@@ -0,0 +1,58 @@
+package tui
+
+import (
+	"strings"
+	"unicode"
+)
+
+// fuzzyScore matches query against text as a case-insensitive subsequence.
+// Consecutive runs and matches at the start of a word score higher, so
+// "shwext" ranks AppleShowAllExtensions above a key that merely contains the
+// letters. ok is false when text does not contain query at all.
+func fuzzyScore(query, text string) (score int, ok bool) {
+	q := []rune(strings.ToLower(query))
+	if len(q) == 0 {
+		return 0, true
+	}
+	t := []rune(text)
+	qi, run := 0, 0
+	for ti, r := range t {
+		if qi == len(q) {
+			break
+		}
+		if unicode.ToLower(r) != q[qi] {
+			run = 0
+			continue
+		}
+		score++
+		if run > 0 {
+			score += 2 * run
+		}
+		if ti == 0 || wordStart(t[ti-1], r) {
+			score += 3
+		}
+		run++
+		qi++
+	}
+	return score, qi == len(q)
+}
+
+// wordStart reports whether cur begins a word after prev: after a
+// separator, or an upper-case letter after a lower-case one.
+func wordStart(prev, cur rune) bool {
+	if !unicode.IsLetter(prev) && !unicode.IsDigit(prev) {
+		return true
+	}
+	return unicode.IsLower(prev) && unicode.IsUpper(cur)
+}
+
+// bestScore is the highest fuzzyScore of query over fields.
+func bestScore(query string, fields ...string) (int, bool) {
+	best, found := 0, false
+	for _, f := range fields {
+		if s, ok := fuzzyScore(query, f); ok && (!found || s > best) {
+			best, found = s, true
+		}
+	}
+	return best, found
+}
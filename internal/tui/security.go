@@ -0,0 +1,166 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/hmziqrs/maziq/internal/engine"
+	"github.com/hmziqrs/maziq/internal/modules/software"
+	"github.com/hmziqrs/maziq/internal/osv"
+	"github.com/hmziqrs/maziq/internal/resource"
+)
+
+const securityHelp = "↑/↓ or j/k: Move • enter: Upgrade • r: Refresh • esc: Back • q: Quit"
+
+// securityModel lists installed packages with known vulnerabilities and
+// upgrades the selected one.
+type securityModel struct {
+	findings []osv.Finding
+	// covered and checked count the packages OSV could be asked about and
+	// all the installed ones.
+	covered, checked int
+	loading          bool
+	upgrading        string
+	err              error
+	notice           string
+	cursor           int
+}
+
+type securityLoadedMsg struct {
+	findings         []osv.Finding
+	covered, checked int
+	err              error
+}
+
+type securityUpgradedMsg struct {
+	name string
+	err  error
+}
+
+func loadSecurity() tea.Msg {
+	env, err := configurationEnv()
+	if err != nil {
+		return securityLoadedMsg{err: err}
+	}
+	ctx := context.Background()
+	components, err := engine.Components(ctx, env, func(string, error) {})
+	if err != nil {
+		return securityLoadedMsg{err: err}
+	}
+	msg := securityLoadedMsg{checked: len(components)}
+	for _, c := range components {
+		if osv.Covered(c) {
+			msg.covered++
+		}
+	}
+	msg.findings, msg.err = osv.Check(ctx, components)
+	return msg
+}
+
+func upgradeVulnerable(c resource.Component) tea.Cmd {
+	return func() tea.Msg {
+		env, err := configurationEnv()
+		if err != nil {
+			return securityUpgradedMsg{name: c.Name, err: err}
+		}
+		cmd, err := software.UpgradeCommand(c.ID)
+		if err == nil {
+			_, err = env.Run(context.Background(), cmd)
+		}
+		return securityUpgradedMsg{name: c.Name, err: err}
+	}
+}
+
+func (m model) updateSecurity(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case securityLoadedMsg:
+		m.security = securityModel{findings: msg.findings, covered: msg.covered, checked: msg.checked, err: msg.err, notice: m.security.notice}
+		m.security.cursor = min(m.security.cursor, max(len(msg.findings)-1, 0))
+	case securityUpgradedMsg:
+		m.security.upgrading = ""
+		if msg.err != nil {
+			m.security.notice = errorStyle.Render(fmt.Sprintf("%s: %v", msg.name, msg.err))
+		} else {
+			m.security.notice = readyStyle.Render(msg.name + " upgraded")
+		}
+		m.security.loading = true
+		return m, loadSecurity
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "q":
+			return m, tea.Quit
+		case "esc", "backspace":
+			m.screen = screenMenu
+		case "r":
+			if !m.security.loading {
+				m.security.loading, m.security.notice = true, ""
+				return m, loadSecurity
+			}
+		case "up", "k":
+			if m.security.cursor > 0 {
+				m.security.cursor--
+			}
+		case "down", "j":
+			if m.security.cursor < len(m.security.findings)-1 {
+				m.security.cursor++
+			}
+		case "enter", " ":
+			s := m.security
+			if s.upgrading == "" && !s.loading && s.cursor < len(s.findings) {
+				c := s.findings[s.cursor].Component
+				m.security.upgrading = c.Name
+				m.security.notice = mutedStyle.Render(fmt.Sprintf("Upgrading %s…", c.Name))
+				return m, upgradeVulnerable(c)
+			}
+		}
+	}
+	return m, nil
+}
+
+func (s securityModel) view() string {
+	if s.loading && s.findings == nil {
+		return mutedStyle.Render("Checking advisories…")
+	}
+	var sections []string
+	if s.err != nil {
+		sections = append(sections, errorStyle.Render(s.err.Error()))
+	}
+	if s.notice != "" {
+		sections = append(sections, s.notice)
+	}
+	sections = append(sections, mutedStyle.Render(fmt.Sprintf("%d of %d installed packages have an advisory feed", s.covered, s.checked)))
+
+	var rows []string
+	for _, f := range s.findings {
+		rows = append(rows, fmt.Sprintf("%-24s %-10s %s", f.Component.Name, f.Component.Version, severityStyle(f.Worst())))
+	}
+	if len(rows) > 0 {
+		sections = append(sections, renderList(rows, s.cursor))
+		f := s.findings[min(s.cursor, len(s.findings)-1)]
+		var vulns []string
+		for _, v := range f.Vulns {
+			line := fmt.Sprintf("  %-8s %s", v.Severity, v.ID)
+			if v.Fixed != "" {
+				line += mutedStyle.Render(" fixed in " + v.Fixed)
+			}
+			if v.Summary != "" {
+				line += "\n           " + v.Summary
+			}
+			vulns = append(vulns, line)
+		}
+		sections = append(sections, strings.Join(vulns, "\n"))
+	} else if s.err == nil {
+		sections = append(sections, readyStyle.Render("✓ No known vulnerabilities"))
+	}
+	return strings.Join(sections, "\n\n")
+}
+
+func severityStyle(s osv.Severity) string {
+	if s >= osv.SeverityHigh {
+		return errorStyle.Render(s.String())
+	}
+	return warningStyle.Render(s.String())
+}
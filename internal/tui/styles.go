@@ -0,0 +1,72 @@
+package tui
+
+import "github.com/charmbracelet/lipgloss"
+
+// Styles
+var (
+	// Colors
+	primaryColor   = lipgloss.Color("#00D9FF")
+	secondaryColor = lipgloss.Color("#7C3AED")
+	accentColor    = lipgloss.Color("#10B981")
+	mutedColor     = lipgloss.Color("#6B7280")
+	errorColor     = lipgloss.Color("#EF4444")
+	warningColor   = lipgloss.Color("#F59E0B")
+
+	// Title style
+	titleStyle = lipgloss.NewStyle().
+			Foreground(primaryColor).
+			Bold(true).
+			Padding(0, 1).
+			MarginTop(1).
+			MarginBottom(1)
+
+	// Logo ASCII art style
+	logoStyle = lipgloss.NewStyle().
+			Foreground(secondaryColor).
+			Bold(true)
+
+	// Subtitle style
+	subtitleStyle = lipgloss.NewStyle().
+			Foreground(mutedColor).
+			Italic(true).
+			MarginBottom(1)
+
+	// Box style for content sections
+	boxStyle = lipgloss.NewStyle().
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(primaryColor).
+			Padding(1, 2).
+			MarginTop(1).
+			MarginBottom(1)
+
+	// Menu item styles
+	menuItemStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#E5E7EB")).
+			PaddingLeft(2)
+
+	selectedMenuItemStyle = lipgloss.NewStyle().
+				Foreground(primaryColor).
+				Bold(true).
+				PaddingLeft(0)
+
+	// Help style
+	helpStyle = lipgloss.NewStyle().
+			Foreground(mutedColor).
+			Padding(1, 0)
+
+	// Status indicator styles
+	readyStyle = lipgloss.NewStyle().
+			Foreground(accentColor).
+			Bold(true)
+
+	warningStyle = lipgloss.NewStyle().
+			Foreground(warningColor).
+			Bold(true)
+
+	errorStyle = lipgloss.NewStyle().
+			Foreground(errorColor).
+			Bold(true)
+
+	mutedStyle = lipgloss.NewStyle().
+			Foreground(mutedColor)
+)
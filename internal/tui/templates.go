@@ -0,0 +1,178 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/hmziqrs/maziq/internal/templates"
+)
+
+const authoringHelp = "↑/↓ or j/k: Scroll • r: Re-lint • e: Edit in $EDITOR • esc: Back • q: Quit"
+
+type templatesModel struct {
+	names    []string
+	selected int
+}
+
+func newTemplatesModel() templatesModel {
+	return templatesModel{names: templates.List()}
+}
+
+func (t templatesModel) view() string {
+	if len(t.names) == 0 {
+		return mutedStyle.Render("No templates found in " + strings.Join(templates.Dirs(), ", "))
+	}
+	return renderList(t.names, t.selected)
+}
+
+func (m model) updateTemplates(msg tea.Msg) (tea.Model, tea.Cmd) {
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	switch key.String() {
+	case "q":
+		return m, tea.Quit
+	case "esc", "backspace":
+		m.screen = screenMenu
+	case "up", "k":
+		if m.templates.selected > 0 {
+			m.templates.selected--
+		}
+	case "down", "j":
+		if m.templates.selected < len(m.templates.names)-1 {
+			m.templates.selected++
+		}
+	case "enter", " ":
+		if len(m.templates.names) > 0 {
+			m.authoring = lintTemplate(m.templates.names[m.templates.selected])
+			m.screen = screenAuthoring
+		}
+	}
+	return m, nil
+}
+
+// authoringModel shows a template alongside its lint findings so authors can
+// edit, re-lint and iterate without leaving the TUI.
+type authoringModel struct {
+	ref      string
+	tmpl     *templates.Template
+	findings []templates.Finding
+	err      error
+	offset   int
+}
+
+// editorDoneMsg is sent when the external editor exits.
+type editorDoneMsg struct{ err error }
+
+func lintTemplate(ref string) authoringModel {
+	a := authoringModel{ref: ref}
+	a.tmpl, a.err = templates.Resolve(ref)
+	if a.err == nil {
+		a.findings = templates.Lint(a.tmpl)
+	}
+	return a
+}
+
+func (m model) updateAuthoring(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case editorDoneMsg:
+		offset := m.authoring.offset
+		m.authoring = lintTemplate(m.authoring.ref)
+		m.authoring.offset = min(offset, max(len(m.authoring.findings)-1, 0))
+		if msg.err != nil {
+			m.authoring.err = msg.err
+		}
+		return m, nil
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "q":
+			return m, tea.Quit
+		case "esc", "backspace":
+			m.screen = screenTemplates
+		case "up", "k":
+			if m.authoring.offset > 0 {
+				m.authoring.offset--
+			}
+		case "down", "j":
+			if m.authoring.offset < len(m.authoring.findings)-1 {
+				m.authoring.offset++
+			}
+		case "r":
+			m.authoring = lintTemplate(m.authoring.ref)
+		case "e":
+			return m, m.authoring.edit()
+		}
+	}
+	return m, nil
+}
+
+// edit opens the template in $EDITOR. Built-in templates are read-only.
+func (a authoringModel) edit() tea.Cmd {
+	if a.tmpl == nil || a.tmpl.IsBuiltin() {
+		return nil
+	}
+	editor := os.Getenv("EDITOR")
+	if editor == "" {
+		editor = "vi"
+	}
+	cmd := exec.Command(editor, a.tmpl.Path)
+	return tea.ExecProcess(cmd, func(err error) tea.Msg { return editorDoneMsg{err} })
+}
+
+func (a authoringModel) view(height int) string {
+	if a.err != nil && a.tmpl == nil {
+		return errorStyle.Render("✗ " + a.err.Error())
+	}
+
+	var b strings.Builder
+	t := a.tmpl
+	fmt.Fprintf(&b, "%s  %s\n", titleStyle.UnsetMargins().UnsetPadding().Render(t.Name), mutedStyle.Render(t.Path))
+	if t.IsBuiltin() {
+		b.WriteString(mutedStyle.Render("built-in template (read-only); copy it to templates/ to edit") + "\n")
+	}
+	fmt.Fprintf(&b, "%d software • %d vars • %d tests\n\n", len(t.Software), len(t.Vars), len(t.Tests))
+	if a.err != nil {
+		b.WriteString(errorStyle.Render("✗ "+a.err.Error()) + "\n\n")
+	}
+
+	errs := templates.Count(a.findings, templates.SeverityError)
+	warns := templates.Count(a.findings, templates.SeverityWarning) - errs
+	switch {
+	case errs > 0:
+		b.WriteString(errorStyle.Render(fmt.Sprintf("● %d errors, %d warnings", errs, warns)))
+	case warns > 0:
+		b.WriteString(warningStyle.Render(fmt.Sprintf("● %d warnings", warns)))
+	default:
+		b.WriteString(readyStyle.Render("● Template is valid"))
+	}
+	b.WriteString("\n\n")
+
+	height = max(height-6, 3)
+	end := min(a.offset+height, len(a.findings))
+	for _, f := range a.findings[a.offset:end] {
+		b.WriteString(renderFinding(f) + "\n")
+	}
+	if end < len(a.findings) {
+		b.WriteString(mutedStyle.Render(fmt.Sprintf("… %d more", len(a.findings)-end)))
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+func renderFinding(f templates.Finding) string {
+	var marker string
+	switch f.Severity {
+	case templates.SeverityError:
+		marker = errorStyle.Render("✗ error  ")
+	case templates.SeverityWarning:
+		marker = warningStyle.Render("! warning")
+	default:
+		marker = mutedStyle.Render("· info   ")
+	}
+	return fmt.Sprintf("%s %s %s", marker, f.Where, mutedStyle.Render(f.Message))
+}
@@ -0,0 +1,130 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/hmziqrs/maziq/internal/which"
+)
+
+const whichHelp = "Type a command or app • enter: Look up • ↑/↓: Move • esc: Clear, then Back"
+
+// whichModel answers what provides a command or app, and lists the
+// commands on PATH that no template entry installed.
+type whichModel struct {
+	index     *which.Index
+	unmanaged []which.Answer
+	loading   bool
+	err       error
+	query     string
+	answer    *which.Answer
+	cursor    int
+}
+
+type whichLoadedMsg struct {
+	index *which.Index
+	err   error
+}
+
+func loadWhich() tea.Msg {
+	env, err := configurationEnv()
+	if err != nil {
+		return whichLoadedMsg{err: err}
+	}
+	// Entries that cannot be described still count as not installed here.
+	ix, err := which.NewIndex(context.Background(), env, func(string, error) {})
+	return whichLoadedMsg{index: ix, err: err}
+}
+
+func (m model) updateWhich(msg tea.Msg) (tea.Model, tea.Cmd) {
+	w := &m.which
+	switch msg := msg.(type) {
+	case whichLoadedMsg:
+		w.loading, w.index, w.err = false, msg.index, msg.err
+		if msg.index != nil {
+			w.unmanaged = msg.index.Unmanaged()
+		}
+	case tea.KeyMsg:
+		switch msg.Type {
+		case tea.KeyEsc:
+			if w.query == "" && w.answer == nil {
+				m.screen = screenMenu
+			}
+			w.query, w.answer = "", nil
+		case tea.KeyUp:
+			if w.cursor > 0 {
+				w.cursor--
+			}
+		case tea.KeyDown:
+			if w.cursor < len(w.unmanaged)-1 {
+				w.cursor++
+			}
+		case tea.KeyEnter:
+			if w.index == nil {
+				break
+			}
+			// With nothing typed, look up the selected unmanaged command.
+			name := w.query
+			if name == "" && len(w.unmanaged) > 0 {
+				name = w.unmanaged[w.cursor].Name
+			}
+			if name != "" {
+				a := w.index.Lookup(name)
+				w.answer = &a
+			}
+		case tea.KeyBackspace:
+			if r := []rune(w.query); len(r) > 0 {
+				w.query = string(r[:len(r)-1])
+			}
+		case tea.KeyRunes, tea.KeySpace:
+			w.query += string(msg.Runes)
+		}
+	}
+	return m, nil
+}
+
+func (w whichModel) view(height int) string {
+	switch {
+	case w.loading:
+		return mutedStyle.Render("Reading the template and what is installed…")
+	case w.err != nil:
+		return errorStyle.Render(w.err.Error())
+	}
+	out := selectedMenuItemStyle.Render("Which: "+w.query+"▏") + "\n\n"
+	if a := w.answer; a != nil {
+		out += a.Name
+		if a.Found() {
+			out += " → " + a.Path
+			if a.Target != "" {
+				out += mutedStyle.Render(" → " + a.Target)
+			}
+		}
+		style := readyStyle
+		if a.ID == "" {
+			style = warningStyle
+		}
+		out += "\n" + style.Render(a.Describe()) + "\n"
+		if a.Expected != "" {
+			out += warningStyle.Render(fmt.Sprintf("%s in the template provides a %s too, which this one is not", a.Expected, a.Name)) + "\n"
+		}
+		out += "\n"
+	}
+	if len(w.unmanaged) == 0 {
+		return out + readyStyle.Render("Every command on PATH outside macOS comes from the template.")
+	}
+	rows := make([]string, len(w.unmanaged))
+	for i, a := range w.unmanaged {
+		from := a.From()
+		if from == "" {
+			from = "unknown"
+		}
+		rows[i] = fmt.Sprintf("%-24s %s", a.Name, mutedStyle.Render(from))
+	}
+	visible := max(height-8, 1)
+	start := min(max(w.cursor-visible/2, 0), max(len(rows)-visible, 0))
+	end := min(start+visible, len(rows))
+	out += fmt.Sprintf("%d command(s) on PATH the template does not account for:\n\n", len(rows))
+	return out + renderList(rows[start:end], w.cursor-start)
+}
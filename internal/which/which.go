@@ -0,0 +1,309 @@
+// Package which answers which template entry, and which package manager,
+// provides a command or an app on this machine, and finds the commands on
+// PATH that no entry accounts for.
+package which
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/hmziqrs/maziq/internal/catalog"
+	"github.com/hmziqrs/maziq/internal/engine"
+	"github.com/hmziqrs/maziq/internal/modules/software"
+	"github.com/hmziqrs/maziq/internal/resource"
+)
+
+// Providers, as Answer.Provider names them.
+const (
+	Formula  = "Homebrew formula"
+	Cask     = "Homebrew cask"
+	AppStore = "App Store"
+	App      = "app"
+	Cargo    = "Cargo"
+	Rustup   = "rustup"
+	NPM      = "npm"
+	UV       = "uv"
+	Go       = "Go"
+	Bun      = "Bun"
+	MacOS    = "macOS"
+)
+
+// Answer is what provides one command or app.
+type Answer struct {
+	Name string `json:"name"`
+	// Path is where it was found, on PATH or in an app folder; Target is
+	// the file Path links to, when it is a symlink.
+	Path   string `json:"path,omitempty"`
+	Target string `json:"target,omitempty"`
+	// ID is the template entry that installs it, "" when none does.
+	ID string `json:"id,omitempty"`
+	// Expected is the entry meant to provide a command or app of this name,
+	// when the one found is not from it or none is installed.
+	Expected string `json:"expected,omitempty"`
+	// Provider installed it, and Package is what it installed it as. Both
+	// are "" when the path does not tell.
+	Provider string `json:"provider,omitempty"`
+	Package  string `json:"package,omitempty"`
+}
+
+// Found reports whether the command or app is on this machine.
+func (a Answer) Found() bool { return a.Path != "" }
+
+// Index matches paths and names to the template's entries.
+type Index struct {
+	components []resource.Component
+	// names maps the commands and apps catalog software is known by to
+	// its entry, installed or not.
+	names map[string]string
+}
+
+// NewIndex describes the software env.Template manages. Software that
+// cannot be described is reported to warn and left out.
+func NewIndex(ctx context.Context, env *resource.Env, warn func(id string, err error)) (*Index, error) {
+	rs, err := engine.Resources(ctx, env)
+	if err != nil {
+		return nil, err
+	}
+	ix := &Index{names: map[string]string{}}
+	for _, r := range rs {
+		s, ok := r.(*software.Software)
+		if !ok {
+			continue
+		}
+		sw := s.Catalog()
+		// "cargo watch --version" names a subcommand, not a command.
+		if len(sw.Version) > 0 && (len(sw.Version) == 1 || strings.HasPrefix(sw.Version[1], "-")) {
+			ix.names[sw.Version[0]] = r.ID()
+		}
+		if sw.App != "" {
+			ix.names[strings.ToLower(strings.TrimSuffix(sw.App, ".app"))] = r.ID()
+		}
+	}
+	ix.components, err = engine.Components(ctx, env, warn)
+	return ix, err
+}
+
+// Lookup answers what provides name: a command on PATH, an app in an app
+// folder (with or without .app), or a path.
+func (ix *Index) Lookup(name string) Answer {
+	a := Answer{Name: name, Path: find(name)}
+	expected := ix.names[strings.ToLower(strings.TrimSuffix(filepath.Base(name), ".app"))]
+	if a.Path != "" {
+		if real, err := filepath.EvalSymlinks(a.Path); err == nil && real != a.Path {
+			a.Target = real
+		}
+		// Toolchains install several entries' commands as one file.
+		if owners := ix.owners(a.real()); slices.Contains(owners, expected) {
+			a.ID = expected
+		} else if len(owners) > 0 {
+			a.ID = owners[0]
+		}
+		a.Provider, a.Package = provider(a.real())
+	}
+	if expected != a.ID {
+		a.Expected = expected
+	}
+	if id, ok := strings.CutPrefix(a.ID, software.Kind+":"); ok && a.Provider == "" {
+		if sw, ok := catalog.Lookup(id); ok {
+			a.Provider = backend(sw.Primary().Backend)
+		}
+	}
+	return a
+}
+
+// Unmanaged returns the commands on PATH that no entry installed, leaving
+// out macOS's own. A command shadowed by an earlier one of the same name
+// is left out too, as the shell never runs it.
+func (ix *Index) Unmanaged() []Answer {
+	var out []Answer
+	seen := map[string]bool{}
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, e := range entries {
+			if seen[e.Name()] {
+				continue
+			}
+			path := filepath.Join(dir, e.Name())
+			if info, err := os.Stat(path); err != nil || info.IsDir() || info.Mode()&0o111 == 0 {
+				continue
+			}
+			seen[e.Name()] = true
+			if p, _ := provider(path); p == MacOS {
+				continue
+			}
+			a := Answer{Name: e.Name(), Path: path}
+			if real, err := filepath.EvalSymlinks(path); err == nil && real != path {
+				a.Target = real
+			}
+			if len(ix.owners(a.real())) > 0 {
+				continue
+			}
+			if a.Provider, a.Package = provider(a.real()); a.Provider != MacOS {
+				out = append(out, a)
+			}
+		}
+	}
+	return out
+}
+
+// From names the provider and package of a, such as "Homebrew formula
+// git".
+func (a Answer) From() string {
+	return strings.TrimSpace(a.Provider + " " + a.Package)
+}
+
+// Describe says in a sentence what provides a.
+func (a Answer) Describe() string {
+	switch {
+	case !a.Found() && a.Expected != "":
+		return "not installed; " + a.Expected + " in the template provides it"
+	case !a.Found():
+		return "not found on PATH or in the app folders"
+	case a.ID != "" && a.From() != "":
+		return "provided by " + a.ID + ", installed from " + a.From()
+	case a.ID != "":
+		return "provided by " + a.ID
+	case a.From() != "":
+		return "not in the template; installed from " + a.From()
+	}
+	return "not in the template, and installed by something MazIQ does not recognise"
+}
+
+func (a Answer) real() string {
+	if a.Target != "" {
+		return a.Target
+	}
+	return a.Path
+}
+
+// owners returns the entries whose installed files hold path.
+func (ix *Index) owners(path string) []string {
+	var out []string
+	for _, c := range ix.components {
+		if slices.ContainsFunc(c.Paths, func(p string) bool { return path == p || strings.HasPrefix(path, p+"/") }) {
+			out = append(out, c.ID)
+		}
+	}
+	return out
+}
+
+// find returns where name is: itself when it is a path, else on PATH, else
+// an app bundle in the app folders.
+func find(name string) string {
+	if strings.ContainsRune(name, '/') {
+		if _, err := os.Stat(name); err == nil {
+			abs, _ := filepath.Abs(name)
+			return abs
+		}
+		return ""
+	}
+	if !strings.HasSuffix(name, ".app") {
+		if path, err := exec.LookPath(name); err == nil {
+			return path
+		}
+	}
+	home, _ := os.UserHomeDir()
+	bundle := strings.TrimSuffix(name, ".app") + ".app"
+	for _, dir := range []string{"/Applications", filepath.Join(home, "Applications"), "/System/Applications", "/System/Applications/Utilities"} {
+		entries, _ := os.ReadDir(dir)
+		for _, e := range entries {
+			if strings.EqualFold(e.Name(), bundle) {
+				return filepath.Join(dir, e.Name())
+			}
+		}
+	}
+	return ""
+}
+
+// provider tells from where path lives what installed it, and as which
+// package.
+func provider(path string) (string, string) {
+	home, _ := os.UserHomeDir()
+	after := func(marker string) (string, bool) {
+		_, rest, ok := strings.Cut(path, marker)
+		if !ok {
+			return "", false
+		}
+		pkg, _, _ := strings.Cut(rest, "/")
+		return pkg, true
+	}
+	if pkg, ok := after("/Cellar/"); ok {
+		return Formula, pkg
+	}
+	if pkg, ok := after("/Caskroom/"); ok {
+		return Cask, pkg
+	}
+	if i := strings.Index(path+"/", ".app/"); i >= 0 {
+		bundle := path[:i+len(".app")]
+		name := strings.TrimSuffix(filepath.Base(bundle), ".app")
+		switch {
+		case strings.HasPrefix(bundle, "/System/"):
+			return MacOS, name
+		case exists(filepath.Join(bundle, "Contents", "_MASReceipt")):
+			return AppStore, name
+		}
+		return App, name
+	}
+	if pkg, ok := after("/lib/node_modules/"); ok {
+		if strings.HasPrefix(pkg, "@") {
+			scoped, _ := after("/lib/node_modules/" + pkg + "/")
+			pkg += "/" + scoped
+		}
+		return NPM, pkg
+	}
+	if pkg, ok := after("/uv/tools/"); ok {
+		return UV, pkg
+	}
+	if toolchain, ok := after("/.rustup/toolchains/"); ok {
+		return Rustup, toolchain
+	}
+	dir := filepath.Dir(path)
+	switch {
+	case dir == filepath.Join(home, ".cargo", "bin"):
+		// rustup's proxies live next to the crates Cargo installed.
+		if slices.Contains([]string{"rustup", "rustc", "cargo", "rustdoc", "rustfmt", "clippy-driver", "cargo-clippy", "cargo-fmt", "rust-analyzer", "rust-gdb", "rust-lldb"}, filepath.Base(path)) {
+			return Rustup, ""
+		}
+		return Cargo, filepath.Base(path)
+	case dir == filepath.Join(home, "go", "bin"):
+		return Go, filepath.Base(path)
+	case strings.HasPrefix(path, filepath.Join(home, ".bun")+"/"):
+		return Bun, filepath.Base(path)
+	case slices.Contains([]string{"/bin", "/sbin", "/usr/bin", "/usr/sbin", "/usr/libexec"}, dir),
+		strings.HasPrefix(path, "/System/"), strings.HasPrefix(path, "/Library/Apple/"):
+		return MacOS, ""
+	}
+	return "", ""
+}
+
+// backend names the provider of software installed with b, for paths that
+// do not tell.
+func backend(b catalog.Backend) string {
+	switch b {
+	case catalog.BackendBrew:
+		return Formula
+	case catalog.BackendCask:
+		return Cask
+	case catalog.BackendCargo:
+		return Cargo
+	case catalog.BackendNPM:
+		return NPM
+	case catalog.BackendUV:
+		return UV
+	case catalog.BackendRustup:
+		return Rustup
+	}
+	return string(b)
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,94 @@
+// Package backends is the public API for MazIQ's software catalog and the
+// package managers behind it: looking entries up, probing whether they are
+// installed and installing them.
+//
+// It is experimental: the types are aliases of MazIQ's own and change with
+// them in any release.
+package backends
+
+import (
+	"context"
+
+	"github.com/hmziqrs/maziq/internal/catalog"
+	"github.com/hmziqrs/maziq/internal/manager"
+	"github.com/hmziqrs/maziq/internal/shell"
+)
+
+// Software is a catalog entry.
+type Software = catalog.Software
+
+// Source is one way of installing a catalog entry.
+type Source = catalog.Source
+
+// Backend names an install mechanism.
+type Backend = catalog.Backend
+
+// Install backends.
+const (
+	Brew   = catalog.BackendBrew
+	Cask   = catalog.BackendCask
+	Cargo  = catalog.BackendCargo
+	NPM    = catalog.BackendNPM
+	Script = catalog.BackendScript
+	Manual = catalog.BackendManual
+	Rustup = catalog.BackendRustup
+	UV     = catalog.BackendUV
+	Xcode  = catalog.BackendXcode
+)
+
+// Result is the outcome of probing an entry.
+type Result = manager.Result
+
+// Status is the installation state of an entry.
+type Status = manager.Status
+
+// Installation states.
+const (
+	Installed    = manager.StatusInstalled
+	NotInstalled = manager.StatusNotInstalled
+	Unknown      = manager.StatusUnknown
+)
+
+// Runner runs commands; Local runs them on this machine.
+type Runner = shell.Runner
+
+// Local is the Runner for this machine.
+type Local = shell.Local
+
+// Mock is a Runner that records commands instead of running them and
+// answers them from its Responses, for testing provisioning logic.
+type Mock = shell.Mock
+
+// Response is a Mock's canned answer to the commands it matches.
+type Response = shell.Response
+
+// LoadMock reads a Mock's responses from a TOML file.
+func LoadMock(path string) (*Mock, error) {
+	return shell.LoadMock(path)
+}
+
+// Lookup returns the catalog entry with id.
+func Lookup(id string) (Software, bool) {
+	return catalog.Lookup(id)
+}
+
+// All returns every catalog entry.
+func All() []Software {
+	return catalog.All()
+}
+
+// Detect probes whether sw is installed, running its probes with r.
+func Detect(ctx context.Context, r Runner, sw Software) Result {
+	return manager.Detect(ctx, r, sw)
+}
+
+// Install installs sw with the first source that works and returns it.
+func Install(ctx context.Context, r Runner, sw Software) (Source, error) {
+	return manager.Install(ctx, r, sw)
+}
+
+// Outdated maps the catalog IDs among ids that have a newer Homebrew version
+// to that version, asking brew through r.
+func Outdated(ctx context.Context, r Runner, ids []string) (map[string]string, error) {
+	return manager.Outdated(ctx, r, ids)
+}
@@ -0,0 +1,114 @@
+// Package engine is the public API for planning and applying MazIQ
+// templates, for programs that embed MazIQ instead of running the binary.
+//
+// It is experimental. Its types are aliases of the ones MazIQ uses itself,
+// so they change with MazIQ, and Env exposes internal types through its
+// fields; any release may break programs that use it.
+//
+//	t, err := manifest.Resolve("team.toml")
+//	...
+//	env := engine.NewEnv(t)
+//	plan, err := engine.Build(ctx, env)
+//	...
+//	for e := range engine.Start(ctx, plan, env, engine.Options{}) {
+//		if done, ok := e.(engine.TaskDone); ok {
+//			log.Println(done.Outcome.ID, done.Outcome.Status)
+//		}
+//	}
+//
+// Every built-in module is registered on import. Register adds resources of
+// your own, checked and applied like the built-in ones.
+package engine
+
+import (
+	"context"
+
+	"github.com/hmziqrs/maziq/internal/engine"
+	"github.com/hmziqrs/maziq/internal/facts"
+	"github.com/hmziqrs/maziq/internal/resource"
+	"github.com/hmziqrs/maziq/internal/secrets"
+	"github.com/hmziqrs/maziq/internal/shell"
+	"github.com/hmziqrs/maziq/internal/templates"
+)
+
+// Env is what resources are checked and applied in.
+type Env = resource.Env
+
+// Resource is one thing the engine converges.
+type Resource = resource.Resource
+
+// State is a checked resource's current and desired state.
+type State = resource.State
+
+// Builder creates resources from a template.
+type Builder = resource.Builder
+
+// Plan, Item, Report, Outcome and Options are as in the maziq CLI; Summary
+// and ItemSummary are a plan as plain values, as printed by plan --json.
+type (
+	Plan        = engine.Plan
+	Item        = engine.Item
+	Summary     = engine.Summary
+	ItemSummary = engine.ItemSummary
+	Report      = engine.Report
+	Outcome     = engine.Outcome
+	Options     = engine.Options
+)
+
+// Events sent by Start.
+type (
+	Event        = engine.Event
+	TaskStarted  = engine.TaskStarted
+	TaskLog      = engine.TaskLog
+	TaskDone     = engine.TaskDone
+	TaskProgress = engine.TaskProgress
+	RunFinished  = engine.RunFinished
+)
+
+// Outcome statuses.
+const (
+	OutcomeOK      = engine.OutcomeOK
+	OutcomeApplied = engine.OutcomeApplied
+	OutcomeFailed  = engine.OutcomeFailed
+	OutcomeSkipped = engine.OutcomeSkipped
+)
+
+// NewEnv returns an Env for t on this machine: commands run locally (or are
+// only recorded when $MAZIQ_MOCK is set; see backends.Mock), facts are
+// detected and secrets come from the default store. Set Logf to see
+// progress from Apply and Wait to let manual steps prompt.
+func NewEnv(t *templates.Template) *Env {
+	return &Env{
+		Runner:   shell.FromEnv(shell.Local{}),
+		Secrets:  secrets.Default(),
+		Facts:    facts.Detect(),
+		Template: t,
+	}
+}
+
+// ID builds a resource ID from a kind and a name.
+func ID(kind, name string) string {
+	return resource.ID(kind, name)
+}
+
+// Register adds a resource builder. Call it before Build, typically from
+// init.
+func Register(b Builder) {
+	resource.Register(b)
+}
+
+// Build checks every resource env.Template declares and returns the plan.
+func Build(ctx context.Context, env *Env) (*Plan, error) {
+	return engine.Build(ctx, env)
+}
+
+// Start applies plan on its own goroutine and returns its events; the
+// channel is closed after RunFinished.
+func Start(ctx context.Context, plan *Plan, env *Env, opts Options) <-chan Event {
+	return engine.Start(ctx, plan, env, opts)
+}
+
+// Apply applies plan and returns the report, logging to env.Logf.
+func Apply(ctx context.Context, plan *Plan, env *Env, opts Options) Report {
+	return engine.Apply(ctx, plan, env, opts)
+}
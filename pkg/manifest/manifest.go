@@ -0,0 +1,53 @@
+// Package manifest is the public API for MazIQ templates: loading, parsing,
+// linting and merging them.
+//
+// It is experimental. The types are the ones MazIQ itself uses, so their
+// fields follow the template format documented in the README and grow with
+// it; any release may change them.
+package manifest
+
+import "github.com/hmziqrs/maziq/internal/templates"
+
+// Template is a parsed template.
+type Template = templates.Template
+
+// Finding is a single lint result.
+type Finding = templates.Finding
+
+// Severity ranks lint findings.
+type Severity = templates.Severity
+
+// Lint severities.
+const (
+	SeverityInfo    = templates.SeverityInfo
+	SeverityWarning = templates.SeverityWarning
+	SeverityError   = templates.SeverityError
+)
+
+// Parse parses template TOML. path is only used in error messages and to
+// resolve relative paths in the template.
+func Parse(data []byte, path string) (*Template, error) {
+	return templates.Parse(data, path)
+}
+
+// Load reads and parses the template file at path.
+func Load(path string) (*Template, error) {
+	return templates.Load(path)
+}
+
+// Resolve finds a template by name in the template directories, or loads it
+// when ref is a path.
+func Resolve(ref string) (*Template, error) {
+	return templates.Resolve(ref)
+}
+
+// Lint validates t.
+func Lint(t *Template) []Finding {
+	return templates.Lint(t)
+}
+
+// Merge layers personal over an organization baseline, returning the merged
+// template and notes on what the baseline overruled.
+func Merge(baseline, personal *Template) (*Template, []string) {
+	return templates.Merge(baseline, personal)
+}
@@ -0,0 +1,9 @@
+// Package templates embeds the template files that ship with MazIQ.
+package templates
+
+import "embed"
+
+// FS holds every built-in *.toml template.
+//
+//go:embed *.toml
+var FS embed.FS